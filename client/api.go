@@ -0,0 +1,4646 @@
+// Code generated by client/internal/gen from the OpenAPI specification. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Account struct {
+	Address            string                     `json:"address,omitempty"`
+	AddressType        string                     `json:"address_type,omitempty"`
+	Baker              string                     `json:"baker,omitempty"`
+	Counter            int64                      `json:"counter,omitempty"`
+	Creator            string                     `json:"creator,omitempty"`
+	DelegatedSince     int64                      `json:"delegated_since,omitempty"`
+	DelegatedSinceTime *time.Time                 `json:"delegated_since_time,omitempty"`
+	FirstIn            int64                      `json:"first_in,omitempty"`
+	FirstInTime        *time.Time                 `json:"first_in_time,omitempty"`
+	FirstOut           int64                      `json:"first_out,omitempty"`
+	FirstOutTime       *time.Time                 `json:"first_out_time,omitempty"`
+	FirstSeen          int64                      `json:"first_seen,omitempty"`
+	FirstSeenTime      time.Time                  `json:"first_seen_time,omitempty"`
+	FrozenBond         float64                    `json:"frozen_bond,omitempty"`
+	IsActivated        bool                       `json:"is_activated,omitempty"`
+	IsBaker            bool                       `json:"is_baker,omitempty"`
+	IsContract         bool                       `json:"is_contract,omitempty"`
+	IsDelegated        bool                       `json:"is_delegated,omitempty"`
+	IsFunded           bool                       `json:"is_funded,omitempty"`
+	IsRevealed         bool                       `json:"is_revealed,omitempty"`
+	LastIn             int64                      `json:"last_in,omitempty"`
+	LastInTime         *time.Time                 `json:"last_in_time,omitempty"`
+	LastOut            int64                      `json:"last_out,omitempty"`
+	LastOutTime        *time.Time                 `json:"last_out_time,omitempty"`
+	LastSeen           int64                      `json:"last_seen,omitempty"`
+	LastSeenTime       time.Time                  `json:"last_seen_time,omitempty"`
+	LostBond           float64                    `json:"lost_bond,omitempty"`
+	Metadata           map[string]json.RawMessage `json:"metadata,omitempty"`
+	NTxFailed          int64                      `json:"n_tx_failed,omitempty"`
+	NTxIn              int64                      `json:"n_tx_in,omitempty"`
+	NTxOut             int64                      `json:"n_tx_out,omitempty"`
+	NTxSuccess         int64                      `json:"n_tx_success,omitempty"`
+	Ops                []*Op                      `json:"ops,omitempty"`
+	Pubkey             string                     `json:"pubkey,omitempty"`
+	RowId              int64                      `json:"row_id,omitempty"`
+	SpendableBalance   float64                    `json:"spendable_balance,omitempty"`
+	TotalBurned        float64                    `json:"total_burned,omitempty"`
+	TotalFeesPaid      float64                    `json:"total_fees_paid,omitempty"`
+	TotalFeesUsed      float64                    `json:"total_fees_used,omitempty"`
+	TotalReceived      float64                    `json:"total_received,omitempty"`
+	TotalSent          float64                    `json:"total_sent,omitempty"`
+	UnclaimedBalance   float64                    `json:"unclaimed_balance,omitempty"`
+}
+
+type AccountBatchRequest struct {
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type AccountReward struct {
+	Baker           string          `json:"baker,omitempty"`
+	BakerFee        float64         `json:"baker_fee,omitempty"`
+	BakerIncome     float64         `json:"baker_income,omitempty"`
+	Balance         float64         `json:"balance,omitempty"`
+	Cycle           int64           `json:"cycle,omitempty"`
+	EstimatedReward float64         `json:"estimated_reward,omitempty"`
+	PayoutCycle     int64           `json:"payout_cycle,omitempty"`
+	PayoutDelay     bool            `json:"payout_delay,omitempty"`
+	Payouts         []*RewardPayout `json:"payouts,omitempty"`
+	Received        float64         `json:"received,omitempty"`
+	SnapshotCycle   int64           `json:"snapshot_cycle,omitempty"`
+	StakeShare      float64         `json:"stake_share,omitempty"`
+	StakingBalance  float64         `json:"staking_balance,omitempty"`
+	Status          string          `json:"status,omitempty"`
+}
+
+type AccountStatement struct {
+	Address  string            `json:"address,omitempty"`
+	Currency string            `json:"currency,omitempty"`
+	Entries  []*StatementEntry `json:"entries,omitempty"`
+	From     time.Time         `json:"from,omitempty"`
+	To       time.Time         `json:"to,omitempty"`
+	Totals   *StatementTotals  `json:"totals,omitempty"`
+}
+
+type Baker struct {
+	ActiveDelegations int64            `json:"active_delegations,omitempty"`
+	ActiveStake       float64          `json:"active_stake,omitempty"`
+	Address           string           `json:"address,omitempty"`
+	BakerSince        time.Time        `json:"baker_since,omitempty"`
+	BakerUntil        *time.Time       `json:"baker_until,omitempty"`
+	BakerVersion      string           `json:"baker_version,omitempty"`
+	ConsensusAddress  string           `json:"consensus_address,omitempty"`
+	ConsensusKey      string           `json:"consensus_key,omitempty"`
+	DelegatedBalance  float64          `json:"delegated_balance,omitempty"`
+	DepositsLimit     *float64         `json:"deposits_limit,omitempty"`
+	Events            *BakerEvents     `json:"events,omitempty"`
+	FrozenBalance     float64          `json:"frozen_balance,omitempty"`
+	FrozenBond        float64          `json:"frozen_bond,omitempty"`
+	GracePeriod       int64            `json:"grace_period,omitempty"`
+	IsActive          bool             `json:"is_active,omitempty"`
+	IsFull            bool             `json:"is_full,omitempty"`
+	Metadata          json.RawMessage  `json:"metadata,omitempty"`
+	SpendableBalance  float64          `json:"spendable_balance,omitempty"`
+	StakingBalance    float64          `json:"staking_balance,omitempty"`
+	StakingCapacity   float64          `json:"staking_capacity,omitempty"`
+	StakingShare      float64          `json:"staking_share,omitempty"`
+	Stats             *BakerStatistics `json:"stats,omitempty"`
+	TotalBalance      float64          `json:"total_balance,omitempty"`
+	TotalDelegations  int64            `json:"total_delegations,omitempty"`
+}
+
+type BakerDrain struct {
+	Amount       float64   `json:"amount,omitempty"`
+	ConsensusKey string    `json:"consensus_key,omitempty"`
+	Cycle        int64     `json:"cycle,omitempty"`
+	Destination  string    `json:"destination,omitempty"`
+	Height       int64     `json:"height,omitempty"`
+	OpHash       string    `json:"op_hash,omitempty"`
+	Time         time.Time `json:"time,omitempty"`
+}
+
+type BakerEvents struct {
+	LastBakeBlock     string    `json:"last_bake_block,omitempty"`
+	LastBakeHeight    int64     `json:"last_bake_height,omitempty"`
+	LastBakeTime      time.Time `json:"last_bake_time,omitempty"`
+	LastEndorseBlock  string    `json:"last_endorse_block,omitempty"`
+	LastEndorseHeight int64     `json:"last_endorse_height,omitempty"`
+	LastEndorseTime   time.Time `json:"last_endorse_time,omitempty"`
+	NextBakeHeight    int64     `json:"next_bake_height,omitempty"`
+	NextBakeTime      time.Time `json:"next_bake_time,omitempty"`
+	NextEndorseHeight int64     `json:"next_endorse_height,omitempty"`
+	NextEndorseTime   time.Time `json:"next_endorse_time,omitempty"`
+}
+
+type BakerForecast struct {
+	Baker             string           `json:"baker,omitempty"`
+	BlockTime         int64            `json:"block_time,omitempty"`
+	Cycle             int64            `json:"cycle,omitempty"`
+	Cycles            []*ForecastCycle `json:"cycles,omitempty"`
+	ExpectedReward    float64          `json:"expected_reward,omitempty"`
+	Height            int64            `json:"height,omitempty"`
+	NextBakeHeight    int64            `json:"next_bake_height,omitempty"`
+	NextBakeTime      *time.Time       `json:"next_bake_time,omitempty"`
+	NextEndorseHeight int64            `json:"next_endorse_height,omitempty"`
+	NextEndorseTime   *time.Time       `json:"next_endorse_time,omitempty"`
+	Time              time.Time        `json:"time,omitempty"`
+}
+
+type BakerKey struct {
+	ActivationCycle   int64     `json:"activation_cycle,omitempty"`
+	Address           string    `json:"address,omitempty"`
+	BlocksBaked       int64     `json:"blocks_baked,omitempty"`
+	BlocksProposed    int64     `json:"blocks_proposed,omitempty"`
+	Cycle             int64     `json:"cycle,omitempty"`
+	DeactivationCycle int64     `json:"deactivation_cycle,omitempty"`
+	FirstBlock        int64     `json:"first_block,omitempty"`
+	Height            int64     `json:"height,omitempty"`
+	IsActive          bool      `json:"is_active,omitempty"`
+	IsInitial         bool      `json:"is_initial,omitempty"`
+	IsPending         bool      `json:"is_pending,omitempty"`
+	IsSuperseded      bool      `json:"is_superseded,omitempty"`
+	Key               string    `json:"key,omitempty"`
+	LastBlock         int64     `json:"last_block,omitempty"`
+	OpHash            *string   `json:"op_hash,omitempty"`
+	Time              time.Time `json:"time,omitempty"`
+}
+
+type BakerKeyHistory struct {
+	ActiveKey  string        `json:"active_key,omitempty"`
+	Baker      string        `json:"baker,omitempty"`
+	Drains     []*BakerDrain `json:"drains,omitempty"`
+	Keys       []*BakerKey   `json:"keys,omitempty"`
+	PendingKey *string       `json:"pending_key,omitempty"`
+}
+
+type BakerStatistics struct {
+	AvgContribution64   *int64  `json:"avg_contribution_64,omitempty"`
+	AvgLuck64           *int64  `json:"avg_luck_64,omitempty"`
+	AvgPerformance64    *int64  `json:"avg_performance_64,omitempty"`
+	BlocksBaked         int64   `json:"blocks_baked,omitempty"`
+	BlocksEndorsed      int64   `json:"blocks_endorsed,omitempty"`
+	BlocksNotBaked      int64   `json:"blocks_not_baked,omitempty"`
+	BlocksNotEndorsed   int64   `json:"blocks_not_endorsed,omitempty"`
+	BlocksProposed      int64   `json:"blocks_proposed,omitempty"`
+	NAccusations        int64   `json:"n_accusations,omitempty"`
+	NBakerOps           int64   `json:"n_baker_ops,omitempty"`
+	NBallots            int64   `json:"n_ballots,omitempty"`
+	NDoubleBakings      int64   `json:"n_double_bakings,omitempty"`
+	NDoubleEndorsements int64   `json:"n_double_endorsements,omitempty"`
+	NDrainDelegate      int64   `json:"n_drain_delegate,omitempty"`
+	NEndorsements       int64   `json:"n_endorsements,omitempty"`
+	NNonceRevelations   int64   `json:"n_nonce_revelations,omitempty"`
+	NPreendorsements    int64   `json:"n_preendorsements,omitempty"`
+	NProposals          int64   `json:"n_proposals,omitempty"`
+	NSetLimits          int64   `json:"n_set_limits,omitempty"`
+	NUpdateConsensusKey int64   `json:"n_update_consensus_key,omitempty"`
+	SlotsEndorsed       int64   `json:"slots_endorsed,omitempty"`
+	TotalFeesEarned     float64 `json:"total_fees_earned,omitempty"`
+	TotalLost           float64 `json:"total_lost,omitempty"`
+	TotalRewardsEarned  float64 `json:"total_rewards_earned,omitempty"`
+}
+
+type Ballot struct {
+	Ballot           string    `json:"ballot,omitempty"`
+	ElectionId       int64     `json:"election_id,omitempty"`
+	Height           int64     `json:"height,omitempty"`
+	Op               string    `json:"op,omitempty"`
+	Proposal         string    `json:"proposal,omitempty"`
+	Rolls            int64     `json:"rolls,omitempty"`
+	RowId            int64     `json:"row_id,omitempty"`
+	Sender           string    `json:"sender,omitempty"`
+	Stake            float64   `json:"stake,omitempty"`
+	Time             time.Time `json:"time,omitempty"`
+	VotingPeriod     int64     `json:"voting_period,omitempty"`
+	VotingPeriodKind string    `json:"voting_period_kind,omitempty"`
+}
+
+type BatchItem struct {
+	Error  *Error          `json:"error,omitempty"`
+	Ident  string          `json:"ident,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+type Bigmap struct {
+	AllocBlock    string          `json:"alloc_block,omitempty"`
+	AllocHeight   int64           `json:"alloc_height,omitempty"`
+	AllocTime     time.Time       `json:"alloc_time,omitempty"`
+	BigmapId      int64           `json:"bigmap_id,omitempty"`
+	Contract      string          `json:"contract,omitempty"`
+	DeletedBlock  *string         `json:"deleted_block,omitempty"`
+	DeletedHeight *int64          `json:"deleted_height,omitempty"`
+	DeletedTime   *time.Time      `json:"deleted_time,omitempty"`
+	KeyType       *Typedef        `json:"key_type,omitempty"`
+	KeyTypePrim   json.RawMessage `json:"key_type_prim,omitempty"`
+	NKeys         int64           `json:"n_keys,omitempty"`
+	NUpdates      int64           `json:"n_updates,omitempty"`
+	UpdateBlock   string          `json:"update_block,omitempty"`
+	UpdateHeight  int64           `json:"update_height,omitempty"`
+	UpdateTime    time.Time       `json:"update_time,omitempty"`
+	ValueType     *Typedef        `json:"value_type,omitempty"`
+	ValueTypePrim json.RawMessage `json:"value_type_prim,omitempty"`
+}
+
+type BigmapDiff struct {
+	AllocHeight   int64              `json:"alloc_height,omitempty"`
+	BigmapId      int64              `json:"bigmap_id,omitempty"`
+	Changes       []*BigmapKeyChange `json:"changes,omitempty"`
+	Contract      string             `json:"contract,omitempty"`
+	CopiedFrom    *int64             `json:"copied_from,omitempty"`
+	DeletedHeight int64              `json:"deleted_height,omitempty"`
+	FromHeight    int64              `json:"from_height,omitempty"`
+	NAdded        int64              `json:"n_added,omitempty"`
+	NChanged      int64              `json:"n_changed,omitempty"`
+	NRemoved      int64              `json:"n_removed,omitempty"`
+	ToHeight      int64              `json:"to_height,omitempty"`
+}
+
+type BigmapKey struct {
+	Hash string          `json:"hash,omitempty"`
+	Key  json.RawMessage `json:"key,omitempty"`
+	Meta *BigmapMeta     `json:"meta,omitempty"`
+	Prim json.RawMessage `json:"prim,omitempty"`
+}
+
+type BigmapKeyChange struct {
+	Action       string          `json:"action,omitempty"`
+	Hash         string          `json:"hash,omitempty"`
+	Height       int64           `json:"height,omitempty"`
+	Key          json.RawMessage `json:"key,omitempty"`
+	KeyPrim      json.RawMessage `json:"key_prim,omitempty"`
+	NUpdates     int64           `json:"n_updates,omitempty"`
+	NewValue     json.RawMessage `json:"new_value,omitempty"`
+	NewValuePrim json.RawMessage `json:"new_value_prim,omitempty"`
+	OldValue     json.RawMessage `json:"old_value,omitempty"`
+	OldValuePrim json.RawMessage `json:"old_value_prim,omitempty"`
+}
+
+type BigmapMeta struct {
+	BigmapId int64      `json:"bigmap_id,omitempty"`
+	Contract string     `json:"contract,omitempty"`
+	Height   int64      `json:"height,omitempty"`
+	Op       *string    `json:"op,omitempty"`
+	Sender   *string    `json:"sender,omitempty"`
+	Source   *string    `json:"source,omitempty"`
+	Time     *time.Time `json:"time,omitempty"`
+}
+
+type BigmapUpdate struct {
+	Action            string          `json:"action,omitempty"`
+	BigmapId          int64           `json:"bigmap_id,omitempty"`
+	DestinationBigMap int64           `json:"destination_big_map,omitempty"`
+	Hash              *string         `json:"hash,omitempty"`
+	Key               json.RawMessage `json:"key,omitempty"`
+	KeyPrim           json.RawMessage `json:"key_prim,omitempty"`
+	KeyType           *Typedef        `json:"key_type,omitempty"`
+	KeyTypePrim       json.RawMessage `json:"key_type_prim,omitempty"`
+	Meta              *BigmapMeta     `json:"meta,omitempty"`
+	SourceBigMap      int64           `json:"source_big_map,omitempty"`
+	Value             json.RawMessage `json:"value,omitempty"`
+	ValuePrim         json.RawMessage `json:"value_prim,omitempty"`
+	ValueType         *Typedef        `json:"value_type,omitempty"`
+	ValueTypePrim     json.RawMessage `json:"value_type_prim,omitempty"`
+}
+
+type BigmapValue struct {
+	Hash      *string         `json:"hash,omitempty"`
+	Key       json.RawMessage `json:"key,omitempty"`
+	KeyPrim   json.RawMessage `json:"key_prim,omitempty"`
+	Meta      *BigmapMeta     `json:"meta,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	ValuePrim json.RawMessage `json:"value_prim,omitempty"`
+}
+
+type Block struct {
+	ActivatedSupply      float64                    `json:"activated_supply,omitempty"`
+	Baker                string                     `json:"baker,omitempty"`
+	BakerConsensusKey    string                     `json:"baker_consensus_key,omitempty"`
+	BurnedSupply         float64                    `json:"burned_supply,omitempty"`
+	Cycle                int64                      `json:"cycle,omitempty"`
+	Deposit              float64                    `json:"deposit,omitempty"`
+	Fee                  float64                    `json:"fee,omitempty"`
+	GasLimit             int64                      `json:"gas_limit,omitempty"`
+	GasUsed              int64                      `json:"gas_used,omitempty"`
+	Hash                 string                     `json:"hash,omitempty"`
+	Height               int64                      `json:"height,omitempty"`
+	IsCycleSnapshot      bool                       `json:"is_cycle_snapshot,omitempty"`
+	LbEscEma             int64                      `json:"lb_esc_ema,omitempty"`
+	LbEscVote            string                     `json:"lb_esc_vote,omitempty"`
+	Metadata             map[string]json.RawMessage `json:"metadata,omitempty"`
+	MintedSupply         float64                    `json:"minted_supply,omitempty"`
+	NAccounts            int64                      `json:"n_accounts,omitempty"`
+	NCalls               int64                      `json:"n_calls,omitempty"`
+	NClearedAccounts     int64                      `json:"n_cleared_accounts,omitempty"`
+	NEndorsedSlots       int64                      `json:"n_endorsed_slots,omitempty"`
+	NEvents              int64                      `json:"n_events,omitempty"`
+	NFundedAccounts      int64                      `json:"n_funded_accounts,omitempty"`
+	NNewAccounts         int64                      `json:"n_new_accounts,omitempty"`
+	NNewContracts        int64                      `json:"n_new_contracts,omitempty"`
+	NOpsApplied          int64                      `json:"n_ops_applied,omitempty"`
+	NOpsFailed           int64                      `json:"n_ops_failed,omitempty"`
+	NRollupCalls         int64                      `json:"n_rollup_calls,omitempty"`
+	NTx                  int64                      `json:"n_tx,omitempty"`
+	Nonce                string                     `json:"nonce,omitempty"`
+	Ops                  []*Op                      `json:"ops,omitempty"`
+	PctAccountReuse      float64                    `json:"pct_account_reuse,omitempty"`
+	Predecessor          string                     `json:"predecessor,omitempty"`
+	Proposer             string                     `json:"proposer,omitempty"`
+	ProposerConsensusKey string                     `json:"proposer_consensus_key,omitempty"`
+	Protocol             string                     `json:"protocol,omitempty"`
+	Reward               float64                    `json:"reward,omitempty"`
+	Rights               []*Right                   `json:"rights,omitempty"`
+	Round                int64                      `json:"round,omitempty"`
+	Solvetime            int64                      `json:"solvetime,omitempty"`
+	StoragePaid          int64                      `json:"storage_paid,omitempty"`
+	Successor            string                     `json:"successor,omitempty"`
+	Time                 time.Time                  `json:"time,omitempty"`
+	Version              int64                      `json:"version,omitempty"`
+	Volume               float64                    `json:"volume,omitempty"`
+	VotingPeriodKind     string                     `json:"voting_period_kind,omitempty"`
+}
+
+type BlockchainConfig struct {
+	BakingRewardBonusPerSlot     int64   `json:"baking_reward_bonus_per_slot,omitempty"`
+	BakingRewardFixedPortion     int64   `json:"baking_reward_fixed_portion,omitempty"`
+	BlockReward                  float64 `json:"block_reward,omitempty"`
+	BlockSecurityDeposit         float64 `json:"block_security_deposit,omitempty"`
+	BlocksPerCommitment          int64   `json:"blocks_per_commitment,omitempty"`
+	BlocksPerCycle               int64   `json:"blocks_per_cycle,omitempty"`
+	BlocksPerSnapshot            int64   `json:"blocks_per_snapshot,omitempty"`
+	BlocksPerVotingPeriod        int64   `json:"blocks_per_voting_period,omitempty"`
+	ChainId                      string  `json:"chain_id,omitempty"`
+	ConsensusCommitteeSize       int64   `json:"consensus_committee_size,omitempty"`
+	ConsensusThreshold           int64   `json:"consensus_threshold,omitempty"`
+	CostPerByte                  int64   `json:"cost_per_byte,omitempty"`
+	Decimals                     int64   `json:"decimals,omitempty"`
+	DelayIncrementPerRound       int64   `json:"delay_increment_per_round,omitempty"`
+	Deployment                   int64   `json:"deployment,omitempty"`
+	EndHeight                    int64   `json:"end_height,omitempty"`
+	EndorsementReward            float64 `json:"endorsement_reward,omitempty"`
+	EndorsementSecurityDeposit   float64 `json:"endorsement_security_deposit,omitempty"`
+	EndorsersPerBlock            int64   `json:"endorsers_per_block,omitempty"`
+	EndorsingRewardPerSlot       int64   `json:"endorsing_reward_per_slot,omitempty"`
+	FrozenDepositsPercentage     int64   `json:"frozen_deposits_percentage,omitempty"`
+	HardGasLimitPerBlock         int64   `json:"hard_gas_limit_per_block,omitempty"`
+	HardGasLimitPerOperation     int64   `json:"hard_gas_limit_per_operation,omitempty"`
+	HardStorageLimitPerOperation int64   `json:"hard_storage_limit_per_operation,omitempty"`
+	MaxOperationDataLength       int64   `json:"max_operation_data_length,omitempty"`
+	MaxOperationsTtl             int64   `json:"max_operations_ttl,omitempty"`
+	MichelsonMaximumTypeSize     int64   `json:"michelson_maximum_type_size,omitempty"`
+	MinProposalQuorum            int64   `json:"min_proposal_quorum,omitempty"`
+	MinimalBlockDelay            int64   `json:"minimal_block_delay,omitempty"`
+	MinimalStake                 float64 `json:"minimal_stake,omitempty"`
+	Name                         string  `json:"name,omitempty"`
+	Network                      string  `json:"network,omitempty"`
+	NumVotingPeriods             int64   `json:"num_voting_periods,omitempty"`
+	OriginationBurn              float64 `json:"origination_burn,omitempty"`
+	OriginationSize              int64   `json:"origination_size,omitempty"`
+	PreservedCycles              int64   `json:"preserved_cycles,omitempty"`
+	Protocol                     string  `json:"protocol,omitempty"`
+	QuorumMax                    int64   `json:"quorum_max,omitempty"`
+	QuorumMin                    int64   `json:"quorum_min,omitempty"`
+	SeedNonceRevelationTip       float64 `json:"seed_nonce_revelation_tip,omitempty"`
+	StartHeight                  int64   `json:"start_height,omitempty"`
+	Symbol                       string  `json:"symbol,omitempty"`
+	Units                        int64   `json:"units,omitempty"`
+	Version                      int64   `json:"version,omitempty"`
+}
+
+type BlockchainTip struct {
+	Bakers             int64           `json:"bakers,omitempty"`
+	BlockHash          string          `json:"block_hash,omitempty"`
+	ChainId            string          `json:"chain_id,omitempty"`
+	ClearedAccounts30d int64           `json:"cleared_accounts_30d,omitempty"`
+	Cycle              int64           `json:"cycle,omitempty"`
+	Delegators         int64           `json:"delegators,omitempty"`
+	DustAccounts       int64           `json:"dust_accounts,omitempty"`
+	DustDelegators     int64           `json:"dust_delegators,omitempty"`
+	FundedAccounts     int64           `json:"funded_accounts,omitempty"`
+	FundedAccounts30d  int64           `json:"funded_accounts_30d,omitempty"`
+	GenesisTime        time.Time       `json:"genesis_time,omitempty"`
+	Health             int64           `json:"health,omitempty"`
+	Height             int64           `json:"height,omitempty"`
+	Inflation1y        float64         `json:"inflation_1y,omitempty"`
+	InflationRate1y    float64         `json:"inflation_rate_1y,omitempty"`
+	Name               string          `json:"name,omitempty"`
+	Network            string          `json:"network,omitempty"`
+	NewAccounts30d     int64           `json:"new_accounts_30d,omitempty"`
+	Protocol           string          `json:"protocol,omitempty"`
+	Status             *CrawlerStatus  `json:"status,omitempty"`
+	Supply             json.RawMessage `json:"supply,omitempty"`
+	Symbol             string          `json:"symbol,omitempty"`
+	Timestamp          time.Time       `json:"timestamp,omitempty"`
+	TotalAccounts      int64           `json:"total_accounts,omitempty"`
+	TotalContracts     int64           `json:"total_contracts,omitempty"`
+	TotalOps           int64           `json:"total_ops,omitempty"`
+	TotalRollups       int64           `json:"total_rollups,omitempty"`
+}
+
+type CodeFamily struct {
+	FirstContract string                   `json:"first_contract,omitempty"`
+	FirstDeployer string                   `json:"first_deployer,omitempty"`
+	FirstSeen     int64                    `json:"first_seen,omitempty"`
+	FirstSeenTime time.Time                `json:"first_seen_time,omitempty"`
+	Hash          string                   `json:"hash,omitempty"`
+	Kind          string                   `json:"kind,omitempty"`
+	LastSeen      int64                    `json:"last_seen,omitempty"`
+	LastSeenTime  time.Time                `json:"last_seen_time,omitempty"`
+	NContracts    int64                    `json:"n_contracts,omitempty"`
+	Timeline      []*CodeFamilyDeployments `json:"timeline,omitempty"`
+}
+
+type CodeFamilyDeployments struct {
+	Count int64     `json:"count,omitempty"`
+	Month time.Time `json:"month,omitempty"`
+}
+
+type Cohort struct {
+	Members  []*CohortMember `json:"members,omitempty"`
+	NMembers int64           `json:"n_members,omitempty"`
+	Name     string          `json:"name,omitempty"`
+}
+
+type CohortBalance struct {
+	FrozenBond       float64                `json:"frozen_bond,omitempty"`
+	Members          []*CohortMemberBalance `json:"members,omitempty"`
+	NFunded          int64                  `json:"n_funded,omitempty"`
+	NMembers         int64                  `json:"n_members,omitempty"`
+	Name             string                 `json:"name,omitempty"`
+	SpendableBalance float64                `json:"spendable_balance,omitempty"`
+	TotalBalance     float64                `json:"total_balance,omitempty"`
+}
+
+type CohortFlowDay struct {
+	Day     time.Time `json:"day,omitempty"`
+	Inflow  float64   `json:"inflow,omitempty"`
+	NIn     int64     `json:"n_in,omitempty"`
+	NOut    int64     `json:"n_out,omitempty"`
+	Net     float64   `json:"net,omitempty"`
+	Outflow float64   `json:"outflow,omitempty"`
+}
+
+type CohortMember struct {
+	Address   string    `json:"address,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Label     string    `json:"label,omitempty"`
+}
+
+type CohortMemberBalance struct {
+	Address          string  `json:"address,omitempty"`
+	Balance          float64 `json:"balance,omitempty"`
+	FrozenBond       float64 `json:"frozen_bond,omitempty"`
+	Label            string  `json:"label,omitempty"`
+	LastSeen         int64   `json:"last_seen,omitempty"`
+	SpendableBalance float64 `json:"spendable_balance,omitempty"`
+}
+
+type CohortRequest struct {
+	Members []*CohortMember `json:"members,omitempty"`
+	Name    string          `json:"name,omitempty"`
+}
+
+type ConfigReport struct {
+	Applied         []string `json:"applied,omitempty"`
+	RestartRequired []string `json:"restart_required,omitempty"`
+}
+
+type Constant struct {
+	Address     string          `json:"address,omitempty"`
+	Creator     string          `json:"creator,omitempty"`
+	Features    json.RawMessage `json:"features,omitempty"`
+	Height      int64           `json:"height,omitempty"`
+	StorageSize int64           `json:"storage_size,omitempty"`
+	Time        time.Time       `json:"time,omitempty"`
+	Value       json.RawMessage `json:"value,omitempty"`
+}
+
+type Contract struct {
+	AccountId     int64                      `json:"account_id,omitempty"`
+	Address       string                     `json:"address,omitempty"`
+	Baker         string                     `json:"baker,omitempty"`
+	Bigmaps       map[string]int64           `json:"bigmaps,omitempty"`
+	CallStats     map[string]int64           `json:"call_stats,omitempty"`
+	CodeHash      string                     `json:"code_hash,omitempty"`
+	Creator       string                     `json:"creator,omitempty"`
+	Features      json.RawMessage            `json:"features,omitempty"`
+	FirstSeen     int64                      `json:"first_seen,omitempty"`
+	FirstSeenTime time.Time                  `json:"first_seen_time,omitempty"`
+	IfaceHash     string                     `json:"iface_hash,omitempty"`
+	Interfaces    string                     `json:"interfaces,omitempty"`
+	LastSeen      int64                      `json:"last_seen,omitempty"`
+	LastSeenTime  time.Time                  `json:"last_seen_time,omitempty"`
+	Metadata      map[string]json.RawMessage `json:"metadata,omitempty"`
+	NCallsFailed  int64                      `json:"n_calls_failed,omitempty"`
+	NCallsIn      int64                      `json:"n_calls_in,omitempty"`
+	NCallsOut     int64                      `json:"n_calls_out,omitempty"`
+	StorageBurn   float64                    `json:"storage_burn,omitempty"`
+	StorageHash   string                     `json:"storage_hash,omitempty"`
+	StoragePaid   int64                      `json:"storage_paid,omitempty"`
+	StorageSize   int64                      `json:"storage_size,omitempty"`
+	TotalFeesUsed float64                    `json:"total_fees_used,omitempty"`
+}
+
+type ContractCallDay struct {
+	Day          time.Time `json:"day,omitempty"`
+	Entrypoint   string    `json:"entrypoint,omitempty"`
+	EntrypointId int64     `json:"entrypoint_id,omitempty"`
+	Fee          float64   `json:"fee,omitempty"`
+	GasUsed      int64     `json:"gas_used,omitempty"`
+	NCallers     int64     `json:"n_callers,omitempty"`
+	NCalls       int64     `json:"n_calls,omitempty"`
+	NFailed      int64     `json:"n_failed,omitempty"`
+	Volume       float64   `json:"volume,omitempty"`
+}
+
+type ContractErrorGroup struct {
+	Class        string          `json:"class,omitempty"`
+	Count        int64           `json:"count,omitempty"`
+	Entrypoint   string          `json:"entrypoint,omitempty"`
+	EntrypointId int64           `json:"entrypoint_id,omitempty"`
+	ErrorId      string          `json:"error_id,omitempty"`
+	Failwith     json.RawMessage `json:"failwith,omitempty"`
+	Fee          float64         `json:"fee,omitempty"`
+	FirstHeight  int64           `json:"first_height,omitempty"`
+	FirstTime    time.Time       `json:"first_time,omitempty"`
+	LastHeight   int64           `json:"last_height,omitempty"`
+	LastOp       string          `json:"last_op,omitempty"`
+	LastTime     time.Time       `json:"last_time,omitempty"`
+}
+
+type ContractEvent struct {
+	Contract string          `json:"contract,omitempty"`
+	Height   int64           `json:"height,omitempty"`
+	Id       int64           `json:"id,omitempty"`
+	OpHash   string          `json:"op_hash,omitempty"`
+	OpId     int64           `json:"op_id,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Prim     json.RawMessage `json:"prim,omitempty"`
+	Tag      string          `json:"tag,omitempty"`
+	Time     time.Time       `json:"time,omitempty"`
+	Type     json.RawMessage `json:"type,omitempty"`
+	TypeHash string          `json:"type_hash,omitempty"`
+}
+
+type Counterparty struct {
+	Address        string    `json:"address,omitempty"`
+	FirstHeight    int64     `json:"first_height,omitempty"`
+	FirstTime      time.Time `json:"first_time,omitempty"`
+	LastHeight     int64     `json:"last_height,omitempty"`
+	LastTime       time.Time `json:"last_time,omitempty"`
+	NReceived      int64     `json:"n_received,omitempty"`
+	NSent          int64     `json:"n_sent,omitempty"`
+	VolumeReceived float64   `json:"volume_received,omitempty"`
+	VolumeSent     float64   `json:"volume_sent,omitempty"`
+}
+
+type CrawlerStatus struct {
+	Blocks    int64   `json:"blocks,omitempty"`
+	Finalized int64   `json:"finalized,omitempty"`
+	Indexed   int64   `json:"indexed,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+	Progress  float64 `json:"progress,omitempty"`
+	Status    string  `json:"status,omitempty"`
+}
+
+type Cycle struct {
+	ActiveBakers       int64     `json:"active_bakers,omitempty"`
+	ActiveDelegators   int64     `json:"active_delegators,omitempty"`
+	Cycle              int64     `json:"cycle,omitempty"`
+	EndHeight          int64     `json:"end_height,omitempty"`
+	EndTime            time.Time `json:"end_time,omitempty"`
+	EndorsementRate    float64   `json:"endorsement_rate,omitempty"`
+	EndorsementsMax    int64     `json:"endorsements_max,omitempty"`
+	EndorsementsMean   float64   `json:"endorsements_mean,omitempty"`
+	EndorsementsMin    int64     `json:"endorsements_min,omitempty"`
+	FollowerCycle      *Cycle    `json:"follower_cycle,omitempty"`
+	IsActive           bool      `json:"is_active,omitempty"`
+	IsComplete         bool      `json:"is_complete,omitempty"`
+	IsSnapshot         bool      `json:"is_snapshot,omitempty"`
+	MissedEndorsements int64     `json:"missed_endorsements,omitempty"`
+	MissedRounds       int64     `json:"missed_rounds,omitempty"`
+	NDoubleBaking      int64     `json:"n_double_baking,omitempty"`
+	NDoubleEndorsement int64     `json:"n_double_endorsement,omitempty"`
+	Progress           float64   `json:"progress,omitempty"`
+	RollOwners         int64     `json:"roll_owners,omitempty"`
+	Rolls              int64     `json:"rolls,omitempty"`
+	RoundMax           int64     `json:"round_max,omitempty"`
+	RoundMean          float64   `json:"round_mean,omitempty"`
+	RoundMin           int64     `json:"round_min,omitempty"`
+	SeedRate           float64   `json:"seed_rate,omitempty"`
+	SnapshotCycle      *Cycle    `json:"snapshot_cycle,omitempty"`
+	SnapshotHeight     int64     `json:"snapshot_height,omitempty"`
+	SnapshotIndex      int64     `json:"snapshot_index,omitempty"`
+	SnapshotTime       time.Time `json:"snapshot_time,omitempty"`
+	SolvetimeMax       int64     `json:"solvetime_max,omitempty"`
+	SolvetimeMean      float64   `json:"solvetime_mean,omitempty"`
+	SolvetimeMin       int64     `json:"solvetime_min,omitempty"`
+	StakingPercent     float64   `json:"staking_percent,omitempty"`
+	StakingSupply      float64   `json:"staking_supply,omitempty"`
+	StartHeight        int64     `json:"start_height,omitempty"`
+	StartTime          time.Time `json:"start_time,omitempty"`
+	WorkingBakers      int64     `json:"working_bakers,omitempty"`
+	WorkingEndorsers   int64     `json:"working_endorsers,omitempty"`
+	WorstBakedBlock    int64     `json:"worst_baked_block,omitempty"`
+	WorstEndorsedBlock int64     `json:"worst_endorsed_block,omitempty"`
+}
+
+type CycleBaker struct {
+	AccusationRewards  float64              `json:"accusation_rewards,omitempty"`
+	Baker              string               `json:"baker,omitempty"`
+	Denunciations      []*CycleDenunciation `json:"denunciations,omitempty"`
+	IsDegraded         bool                 `json:"is_degraded,omitempty"`
+	LostDeposits       float64              `json:"lost_deposits,omitempty"`
+	LostFees           float64              `json:"lost_fees,omitempty"`
+	LostRewards        float64              `json:"lost_rewards,omitempty"`
+	NAccusations       int64                `json:"n_accusations,omitempty"`
+	NBakingRights      int64                `json:"n_baking_rights,omitempty"`
+	NBlocksBaked       int64                `json:"n_blocks_baked,omitempty"`
+	NBlocksEndorsed    int64                `json:"n_blocks_endorsed,omitempty"`
+	NBlocksMissed      int64                `json:"n_blocks_missed,omitempty"`
+	NBlocksNotEndorsed int64                `json:"n_blocks_not_endorsed,omitempty"`
+	NBlocksStolen      int64                `json:"n_blocks_stolen,omitempty"`
+	NDoubleBaking      int64                `json:"n_double_baking,omitempty"`
+	NDoubleEndorsement int64                `json:"n_double_endorsement,omitempty"`
+	NEndorsingRights   int64                `json:"n_endorsing_rights,omitempty"`
+	NEndorsingSlots    int64                `json:"n_endorsing_slots,omitempty"`
+	NSeedsRequired     int64                `json:"n_seeds_required,omitempty"`
+	NSeedsRevealed     int64                `json:"n_seeds_revealed,omitempty"`
+	NSeedsUnrevealed   int64                `json:"n_seeds_unrevealed,omitempty"`
+	NSeedsPending      int64                `json:"n_seeds_pending,omitempty"`
+	NSlotsEndorsed     int64                `json:"n_slots_endorsed,omitempty"`
+	NVdfRevelations    int64                `json:"n_vdf_revelations,omitempty"`
+	Reliability        float64              `json:"reliability,omitempty"`
+}
+
+type CycleDenunciation struct {
+	Accuser       string  `json:"accuser,omitempty"`
+	AccuserReward float64 `json:"accuser_reward,omitempty"`
+	Height        int64   `json:"height,omitempty"`
+	LostDeposits  float64 `json:"lost_deposits,omitempty"`
+	LostFees      float64 `json:"lost_fees,omitempty"`
+	LostRewards   float64 `json:"lost_rewards,omitempty"`
+	Offender      string  `json:"offender,omitempty"`
+	OpHash        string  `json:"op_hash,omitempty"`
+	Type          string  `json:"type,omitempty"`
+}
+
+type Election struct {
+	Adoption     *Vote     `json:"adoption,omitempty"`
+	Cooldown     *Vote     `json:"cooldown,omitempty"`
+	ElectionId   int64     `json:"election_id,omitempty"`
+	EndHeight    int64     `json:"end_height,omitempty"`
+	EndTime      time.Time `json:"end_time,omitempty"`
+	Exploration  *Vote     `json:"exploration,omitempty"`
+	IsEmpty      bool      `json:"is_empty,omitempty"`
+	IsFailed     bool      `json:"is_failed,omitempty"`
+	IsOpen       bool      `json:"is_open,omitempty"`
+	MaxPeriods   int64     `json:"max_periods,omitempty"`
+	NoMajority   bool      `json:"no_majority,omitempty"`
+	NoProposal   bool      `json:"no_proposal,omitempty"`
+	NoQuorum     bool      `json:"no_quorum,omitempty"`
+	NumPeriods   int64     `json:"num_periods,omitempty"`
+	NumProposals int64     `json:"num_proposals,omitempty"`
+	Promotion    *Vote     `json:"promotion,omitempty"`
+	Proposal     *Vote     `json:"proposal,omitempty"`
+	StartHeight  int64     `json:"start_height,omitempty"`
+	StartTime    time.Time `json:"start_time,omitempty"`
+	VotingPeriod string    `json:"voting_period,omitempty"`
+}
+
+type ElectionComparison struct {
+	ElectionId int64              `json:"election_id,omitempty"`
+	IsFailed   bool               `json:"is_failed,omitempty"`
+	Proposal   string             `json:"proposal,omitempty"`
+	Stages     []*StageComparison `json:"stages,omitempty"`
+	StartTime  time.Time          `json:"start_time,omitempty"`
+}
+
+type ElectionTimeline struct {
+	ElectionId int64                 `json:"election_id,omitempty"`
+	History    []*ElectionComparison `json:"history,omitempty"`
+	IsOpen     bool                  `json:"is_open,omitempty"`
+	Proposal   string                `json:"proposal,omitempty"`
+	Stages     []*StageTimeline      `json:"stages,omitempty"`
+}
+
+type Entrypoint struct {
+	Branch string          `json:"branch,omitempty"`
+	Id     int64           `json:"id,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	Prim   json.RawMessage `json:"prim,omitempty"`
+	Type   []*Typedef      `json:"type,omitempty"`
+}
+
+type Error struct {
+	Code      int64  `json:"code,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	RequestId string `json:"request_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Status    int64  `json:"status,omitempty"`
+}
+
+type ErrorResponse struct {
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+type Event struct {
+	Contract string          `json:"contract,omitempty"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Tag      string          `json:"tag,omitempty"`
+	Type     json.RawMessage `json:"type,omitempty"`
+	TypeHash string          `json:"type_hash,omitempty"`
+}
+
+type ExplorerDelegator struct {
+	Address  string `json:"address,omitempty"`
+	Balance  int64  `json:"balance,omitempty"`
+	IsFunded bool   `json:"is_funded,omitempty"`
+}
+
+type ExplorerIncome struct {
+	AccusationIncome       float64 `json:"accusation_income,omitempty"`
+	AccusationLoss         float64 `json:"accusation_loss,omitempty"`
+	ActiveStake            float64 `json:"active_stake,omitempty"`
+	BakingIncome           float64 `json:"baking_income,omitempty"`
+	ContributionPercent    int64   `json:"contribution_percent,omitempty"`
+	Cycle                  int64   `json:"cycle,omitempty"`
+	DelegatedBalance       float64 `json:"delegated_balance,omitempty"`
+	EndorsingIncome        float64 `json:"endorsing_income,omitempty"`
+	EndorsingLoss          float64 `json:"endorsing_loss,omitempty"`
+	ExpectedIncome         float64 `json:"expected_income,omitempty"`
+	FeesIncome             float64 `json:"fees_income,omitempty"`
+	LostAccusationDeposits float64 `json:"lost_accusation_deposits,omitempty"`
+	LostAccusationFees     float64 `json:"lost_accusation_fees,omitempty"`
+	LostAccusationRewards  float64 `json:"lost_accusation_rewards,omitempty"`
+	LostSeedFees           float64 `json:"lost_seed_fees,omitempty"`
+	LostSeedRewards        float64 `json:"lost_seed_rewards,omitempty"`
+	Luck                   float64 `json:"luck,omitempty"`
+	LuckPercent            int64   `json:"luck_percent,omitempty"`
+	NBakingRights          int64   `json:"n_baking_rights,omitempty"`
+	NBlocksBaked           int64   `json:"n_blocks_baked,omitempty"`
+	NBlocksEndorsed        int64   `json:"n_blocks_endorsed,omitempty"`
+	NBlocksNotBaked        int64   `json:"n_blocks_not_baked,omitempty"`
+	NBlocksNotEndorsed     int64   `json:"n_blocks_not_endorsed,omitempty"`
+	NBlocksProposed        int64   `json:"n_blocks_proposed,omitempty"`
+	NDelegations           int64   `json:"n_delegations,omitempty"`
+	NEndorsingRights       int64   `json:"n_endorsing_rights,omitempty"`
+	NSeedsRevealed         int64   `json:"n_seeds_revealed,omitempty"`
+	NSlotsEndorsed         int64   `json:"n_slots_endorsed,omitempty"`
+	OwnBalance             float64 `json:"own_balance,omitempty"`
+	PerformancePercent     int64   `json:"performance_percent,omitempty"`
+	SeedIncome             float64 `json:"seed_income,omitempty"`
+	SeedLoss               float64 `json:"seed_loss,omitempty"`
+	SnapshotRolls          int64   `json:"snapshot_rolls,omitempty"`
+	StakingBalance         float64 `json:"staking_balance,omitempty"`
+	TotalDeposits          float64 `json:"total_deposits,omitempty"`
+	TotalIncome            float64 `json:"total_income,omitempty"`
+	TotalLoss              float64 `json:"total_loss,omitempty"`
+}
+
+type ExplorerRights struct {
+	Address         string `json:"address,omitempty"`
+	BakingRights    string `json:"baking_rights,omitempty"`
+	BlocksBaked     string `json:"blocks_baked,omitempty"`
+	BlocksEndorsed  string `json:"blocks_endorsed,omitempty"`
+	Cycle           int64  `json:"cycle,omitempty"`
+	EndorsingRights string `json:"endorsing_rights,omitempty"`
+	SeedsRequired   string `json:"seeds_required,omitempty"`
+	SeedsRevealed   string `json:"seeds_revealed,omitempty"`
+	StartHeight     int64  `json:"start_height,omitempty"`
+}
+
+type ExplorerSnapshot struct {
+	AccusationIncome       int64                `json:"accusation_income,omitempty"`
+	AccusationLoss         int64                `json:"accusation_loss,omitempty"`
+	ActiveStake            int64                `json:"active_stake,omitempty"`
+	BakingCycle            int64                `json:"baking_cycle,omitempty"`
+	BakingIncome           int64                `json:"baking_income,omitempty"`
+	DelegatedBalance       int64                `json:"delegated_balance,omitempty"`
+	Delegators             []*ExplorerDelegator `json:"delegators,omitempty"`
+	EndorsingIncome        int64                `json:"endorsing_income,omitempty"`
+	EndorsingLoss          int64                `json:"endorsing_loss,omitempty"`
+	ExpectedIncome         int64                `json:"expected_income,omitempty"`
+	FeesIncome             int64                `json:"fees_income,omitempty"`
+	LostAccusationDeposits int64                `json:"lost_accusation_deposits,omitempty"`
+	LostAccusationFees     int64                `json:"lost_accusation_fees,omitempty"`
+	LostAccusationRewards  int64                `json:"lost_accusation_rewards,omitempty"`
+	LostSeedFees           int64                `json:"lost_seed_fees,omitempty"`
+	LostSeedRewards        int64                `json:"lost_seed_rewards,omitempty"`
+	NDelegations           int64                `json:"n_delegations,omitempty"`
+	OwnBalance             int64                `json:"own_balance,omitempty"`
+	SeedIncome             int64                `json:"seed_income,omitempty"`
+	SeedLoss               int64                `json:"seed_loss,omitempty"`
+	SnapshotCycle          int64                `json:"snapshot_cycle,omitempty"`
+	SnapshotHeight         int64                `json:"snapshot_height,omitempty"`
+	SnapshotIndex          int64                `json:"snapshot_index,omitempty"`
+	SnapshotRolls          int64                `json:"snapshot_rolls,omitempty"`
+	SnapshotTime           time.Time            `json:"snapshot_time,omitempty"`
+	StakingBalance         int64                `json:"staking_balance,omitempty"`
+	TotalDeposits          int64                `json:"total_deposits,omitempty"`
+	TotalIncome            int64                `json:"total_income,omitempty"`
+	TotalLoss              int64                `json:"total_loss,omitempty"`
+}
+
+type ForecastCycle struct {
+	ActiveStake             float64         `json:"active_stake,omitempty"`
+	BakingSlots             []*ForecastSlot `json:"baking_slots,omitempty"`
+	Cycle                   int64           `json:"cycle,omitempty"`
+	EndHeight               int64           `json:"end_height,omitempty"`
+	EndTime                 time.Time       `json:"end_time,omitempty"`
+	EndorsingSlots          []*ForecastSlot `json:"endorsing_slots,omitempty"`
+	ExpectedBakingReward    float64         `json:"expected_baking_reward,omitempty"`
+	ExpectedDeposit         float64         `json:"expected_deposit,omitempty"`
+	ExpectedEndorsingReward float64         `json:"expected_endorsing_reward,omitempty"`
+	ExpectedReward          float64         `json:"expected_reward,omitempty"`
+	NBakingRights           int64           `json:"n_baking_rights,omitempty"`
+	NEndorsingRights        int64           `json:"n_endorsing_rights,omitempty"`
+	StakeShare              float64         `json:"stake_share,omitempty"`
+	StartHeight             int64           `json:"start_height,omitempty"`
+	StartTime               time.Time       `json:"start_time,omitempty"`
+}
+
+type ForecastSlot struct {
+	Height int64     `json:"height,omitempty"`
+	Time   time.Time `json:"time,omitempty"`
+}
+
+type GraphEdge struct {
+	FirstHeight int64   `json:"first_height,omitempty"`
+	From        string  `json:"from,omitempty"`
+	LastHeight  int64   `json:"last_height,omitempty"`
+	NTransfers  int64   `json:"n_transfers,omitempty"`
+	To          string  `json:"to,omitempty"`
+	Volume      float64 `json:"volume,omitempty"`
+}
+
+type GraphNode struct {
+	Address string `json:"address,omitempty"`
+	Depth   int64  `json:"depth,omitempty"`
+}
+
+type GraphQLError struct {
+	Message string            `json:"message,omitempty"`
+	Path    []json.RawMessage `json:"path,omitempty"`
+}
+
+type GraphQLRequest struct {
+	OperationName string                     `json:"operationName,omitempty"`
+	Query         string                     `json:"query,omitempty"`
+	Variables     map[string]json.RawMessage `json:"variables,omitempty"`
+}
+
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []*GraphQLError `json:"errors,omitempty"`
+}
+
+type HealthCheck struct {
+	Detail string `json:"detail,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+type HealthStatus struct {
+	Checks []*HealthCheck `json:"checks,omitempty"`
+	Status string         `json:"status,omitempty"`
+}
+
+type MetadataDescriptor struct {
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+type MichelineScript struct {
+	Code    json.RawMessage `json:"code,omitempty"`
+	Storage json.RawMessage `json:"storage,omitempty"`
+}
+
+type NonVoter struct {
+	Address string  `json:"address,omitempty"`
+	Rolls   int64   `json:"rolls,omitempty"`
+	Stake   float64 `json:"stake,omitempty"`
+}
+
+type Op struct {
+	Accuser       *string                    `json:"accuser,omitempty"`
+	Baker         *string                    `json:"baker,omitempty"`
+	Batch         []*Op                      `json:"batch,omitempty"`
+	BigMapDiff    json.RawMessage            `json:"big_map_diff,omitempty"`
+	Block         string                     `json:"block,omitempty"`
+	Burned        float64                    `json:"burned,omitempty"`
+	CodeHash      string                     `json:"code_hash,omitempty"`
+	Confirmations int64                      `json:"confirmations,omitempty"`
+	Counter       int64                      `json:"counter,omitempty"`
+	Creator       *string                    `json:"creator,omitempty"`
+	Cycle         int64                      `json:"cycle,omitempty"`
+	Data          json.RawMessage            `json:"data,omitempty"`
+	Deposit       float64                    `json:"deposit,omitempty"`
+	Errors        json.RawMessage            `json:"errors,omitempty"`
+	Events        []*Event                   `json:"events,omitempty"`
+	Fee           float64                    `json:"fee,omitempty"`
+	GasLimit      int64                      `json:"gas_limit,omitempty"`
+	GasUsed       int64                      `json:"gas_used,omitempty"`
+	Hash          string                     `json:"hash,omitempty"`
+	Height        int64                      `json:"height,omitempty"`
+	Id            int64                      `json:"id,omitempty"`
+	Internal      []*Op                      `json:"internal,omitempty"`
+	IsContract    bool                       `json:"is_contract,omitempty"`
+	IsEvent       bool                       `json:"is_event,omitempty"`
+	IsInternal    bool                       `json:"is_internal,omitempty"`
+	IsRollup      bool                       `json:"is_rollup,omitempty"`
+	IsSuccess     bool                       `json:"is_success,omitempty"`
+	Level         *int64                     `json:"level,omitempty"`
+	Limit         json.RawMessage            `json:"limit,omitempty"`
+	Metadata      map[string]json.RawMessage `json:"metadata,omitempty"`
+	NOps          int64                      `json:"n_ops,omitempty"`
+	Offender      *string                    `json:"offender,omitempty"`
+	OpN           int64                      `json:"op_n,omitempty"`
+	OpP           *int64                     `json:"op_p,omitempty"`
+	Parameters    *Parameters                `json:"parameters,omitempty"`
+	Power         int64                      `json:"power,omitempty"`
+	PreviousBaker *string                    `json:"previous_baker,omitempty"`
+	Proof         string                     `json:"proof,omitempty"`
+	Receiver      *string                    `json:"receiver,omitempty"`
+	Reward        float64                    `json:"reward,omitempty"`
+	Sender        *string                    `json:"sender,omitempty"`
+	Solution      string                     `json:"solution,omitempty"`
+	Source        *string                    `json:"source,omitempty"`
+	Status        string                     `json:"status,omitempty"`
+	Storage       *Storage                   `json:"storage,omitempty"`
+	StorageLimit  int64                      `json:"storage_limit,omitempty"`
+	StoragePaid   int64                      `json:"storage_paid,omitempty"`
+	TicketUpdates []*TicketUpdate            `json:"ticket_updates,omitempty"`
+	Time          time.Time                  `json:"time,omitempty"`
+	Type          string                     `json:"type,omitempty"`
+	Value         json.RawMessage            `json:"value,omitempty"`
+	Volume        float64                    `json:"volume,omitempty"`
+}
+
+type OpBatchRequest struct {
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+type OpTrace struct {
+	Block         string       `json:"block,omitempty"`
+	Calls         []*TraceNode `json:"calls,omitempty"`
+	Confirmations int64        `json:"confirmations,omitempty"`
+	Fee           float64      `json:"fee,omitempty"`
+	GasUsed       int64        `json:"gas_used,omitempty"`
+	Hash          string       `json:"hash,omitempty"`
+	Height        int64        `json:"height,omitempty"`
+	IsSuccess     bool         `json:"is_success,omitempty"`
+	MaxDepth      int64        `json:"max_depth,omitempty"`
+	NCalls        int64        `json:"n_calls,omitempty"`
+	Status        string       `json:"status,omitempty"`
+	StoragePaid   int64        `json:"storage_paid,omitempty"`
+	Time          time.Time    `json:"time,omitempty"`
+}
+
+type OpenAPI struct {
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+	Info       *OpenAPIInfo                            `json:"info,omitempty"`
+	Openapi    string                                  `json:"openapi,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths,omitempty"`
+}
+
+type OpenAPIBody struct {
+	Content  map[string]*OpenAPIMedia `json:"content,omitempty"`
+	Required bool                     `json:"required,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type OpenAPIMedia struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPIOperation struct {
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	OperationId string                      `json:"operationId,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	In       string         `json:"in,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema,omitempty"`
+}
+
+type OpenAPIResponse struct {
+	Content     map[string]*OpenAPIMedia `json:"content,omitempty"`
+	Description string                   `json:"description,omitempty"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+}
+
+type Parameters struct {
+	Arguments  json.RawMessage `json:"arguments,omitempty"`
+	Entrypoint string          `json:"entrypoint,omitempty"`
+	L2Address  *string         `json:"l2_address,omitempty"`
+	Method     string          `json:"method,omitempty"`
+	Prim       json.RawMessage `json:"prim,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+}
+
+type Pinger struct {
+	ClientTime int64 `json:"client_time,omitempty"`
+	Sequence   int64 `json:"sequence,omitempty"`
+	ServerTime int64 `json:"server_time,omitempty"`
+}
+
+type Price struct {
+	Currency string    `json:"currency,omitempty"`
+	Price    float64   `json:"price,omitempty"`
+	Time     time.Time `json:"time,omitempty"`
+}
+
+type PriceImportResult struct {
+	Inserted int64 `json:"inserted,omitempty"`
+	Updated  int64 `json:"updated,omitempty"`
+}
+
+type Proposal struct {
+	BlockHash string    `json:"block_hash,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	Height    int64     `json:"height,omitempty"`
+	OpHash    string    `json:"op_hash,omitempty"`
+	Rolls     int64     `json:"rolls,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	Stake     float64   `json:"stake,omitempty"`
+	Time      time.Time `json:"time,omitempty"`
+	Voters    int64     `json:"voters,omitempty"`
+}
+
+type RankListItem struct {
+	Address string  `json:"address,omitempty"`
+	Balance float64 `json:"balance,omitempty"`
+	Rank    int64   `json:"rank,omitempty"`
+	Traffic int64   `json:"traffic,omitempty"`
+	Volume  float64 `json:"volume,omitempty"`
+}
+
+type RewardPayout struct {
+	Amount float64   `json:"amount,omitempty"`
+	Cycle  int64     `json:"cycle,omitempty"`
+	Height int64     `json:"height,omitempty"`
+	IsLate bool      `json:"is_late,omitempty"`
+	OpHash string    `json:"op_hash,omitempty"`
+	Sender string    `json:"sender,omitempty"`
+	Time   time.Time `json:"time,omitempty"`
+}
+
+type Right struct {
+	Address        string `json:"address,omitempty"`
+	IsLost         *bool  `json:"is_lost,omitempty"`
+	IsMissed       *bool  `json:"is_missed,omitempty"`
+	IsSeedRequired *bool  `json:"is_seed_required,omitempty"`
+	IsSeedRevealed *bool  `json:"is_seed_revealed,omitempty"`
+	IsStolen       *bool  `json:"is_stolen,omitempty"`
+	IsUsed         *bool  `json:"is_used,omitempty"`
+	Round          *int64 `json:"round,omitempty"`
+	Type           string `json:"type,omitempty"`
+}
+
+type Script struct {
+	BigmapTypes map[string]json.RawMessage `json:"bigmap_types,omitempty"`
+	Bigmaps     map[string]int64           `json:"bigmaps,omitempty"`
+	Entrypoints map[string]*Entrypoint     `json:"entrypoints,omitempty"`
+	Script      *MichelineScript           `json:"script,omitempty"`
+	StorageType *Typedef                   `json:"storage_type,omitempty"`
+	Views       map[string]json.RawMessage `json:"views,omitempty"`
+}
+
+type SimilarContract struct {
+	Address       string    `json:"address,omitempty"`
+	Creator       string    `json:"creator,omitempty"`
+	Height        int64     `json:"height,omitempty"`
+	SameCode      bool      `json:"same_code,omitempty"`
+	SameInterface bool      `json:"same_interface,omitempty"`
+	SameShape     bool      `json:"same_shape,omitempty"`
+	Time          time.Time `json:"time,omitempty"`
+}
+
+type SimilarContracts struct {
+	Contract  string             `json:"contract,omitempty"`
+	Contracts []*SimilarContract `json:"contracts,omitempty"`
+	Cursor    int64              `json:"cursor,omitempty"`
+	Families  []*CodeFamily      `json:"families,omitempty"`
+}
+
+type SqlRequest struct {
+	Query string `json:"query,omitempty"`
+}
+
+type StageComparison struct {
+	IsFailed           bool    `json:"is_failed,omitempty"`
+	QuorumPct          int64   `json:"quorum_pct,omitempty"`
+	SupermajorityPct   int64   `json:"supermajority_pct,omitempty"`
+	TurnoutPct         int64   `json:"turnout_pct,omitempty"`
+	TurnoutQuartersPct []int64 `json:"turnout_quarters_pct,omitempty"`
+	VotingPeriodKind   string  `json:"voting_period_kind,omitempty"`
+}
+
+type StageTimeline struct {
+	EligibleStake       float64          `json:"eligible_stake,omitempty"`
+	EligibleVoters      int64            `json:"eligible_voters,omitempty"`
+	IsOpen              bool             `json:"is_open,omitempty"`
+	NonVoterStake       float64          `json:"non_voter_stake,omitempty"`
+	NonVoters           []*NonVoter      `json:"non_voters,omitempty"`
+	PeriodEndBlock      int64            `json:"period_end_block,omitempty"`
+	PeriodStartBlock    int64            `json:"period_start_block,omitempty"`
+	QuorumHeight        int64            `json:"quorum_height,omitempty"`
+	QuorumPct           int64            `json:"quorum_pct,omitempty"`
+	QuorumStake         float64          `json:"quorum_stake,omitempty"`
+	SupermajorityHeight int64            `json:"supermajority_height,omitempty"`
+	Timeline            []*TimelinePoint `json:"timeline,omitempty"`
+	VotingPeriod        int64            `json:"voting_period,omitempty"`
+	VotingPeriodKind    string           `json:"voting_period_kind,omitempty"`
+}
+
+type StatementEntry struct {
+	AmountIn     float64   `json:"amount_in,omitempty"`
+	AmountOut    float64   `json:"amount_out,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	CostBasis    float64   `json:"cost_basis,omitempty"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	Currency     string    `json:"currency,omitempty"`
+	Gain         float64   `json:"gain,omitempty"`
+	Height       int64     `json:"height,omitempty"`
+	Holdings     float64   `json:"holdings,omitempty"`
+	Kind         string    `json:"kind,omitempty"`
+	OpC          int64     `json:"op_c,omitempty"`
+	OpI          int64     `json:"op_i,omitempty"`
+	OpN          int64     `json:"op_n,omitempty"`
+	Operation    string    `json:"operation,omitempty"`
+	Price        float64   `json:"price,omitempty"`
+	PriceMissing bool      `json:"price_missing,omitempty"`
+	Time         time.Time `json:"time,omitempty"`
+	Value        float64   `json:"value,omitempty"`
+}
+
+type StatementTotals struct {
+	Burned           float64 `json:"burned,omitempty"`
+	BurnedValue      float64 `json:"burned_value,omitempty"`
+	ClosingCostBasis float64 `json:"closing_cost_basis,omitempty"`
+	ClosingHoldings  float64 `json:"closing_holdings,omitempty"`
+	Fees             float64 `json:"fees,omitempty"`
+	FeesValue        float64 `json:"fees_value,omitempty"`
+	OpeningCostBasis float64 `json:"opening_cost_basis,omitempty"`
+	OpeningHoldings  float64 `json:"opening_holdings,omitempty"`
+	PriceMissing     int64   `json:"price_missing,omitempty"`
+	RealizedGain     float64 `json:"realized_gain,omitempty"`
+	Received         float64 `json:"received,omitempty"`
+	ReceivedValue    float64 `json:"received_value,omitempty"`
+	Rewards          float64 `json:"rewards,omitempty"`
+	RewardsValue     float64 `json:"rewards_value,omitempty"`
+	Sent             float64 `json:"sent,omitempty"`
+	SentValue        float64 `json:"sent_value,omitempty"`
+	UncoveredOut     float64 `json:"uncovered_out,omitempty"`
+}
+
+type Storage struct {
+	Prim  json.RawMessage `json:"prim,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type StorageHistoryEntry struct {
+	Changes     []*StoragePathChange `json:"changes,omitempty"`
+	Height      int64                `json:"height,omitempty"`
+	Id          int64                `json:"id,omitempty"`
+	OpHash      string               `json:"op_hash,omitempty"`
+	Prim        json.RawMessage      `json:"prim,omitempty"`
+	Storage     json.RawMessage      `json:"storage,omitempty"`
+	StorageHash string               `json:"storage_hash,omitempty"`
+	Time        time.Time            `json:"time,omitempty"`
+}
+
+type StoragePathChange struct {
+	Action string          `json:"action,omitempty"`
+	New    json.RawMessage `json:"new,omitempty"`
+	Old    json.RawMessage `json:"old,omitempty"`
+	Path   string          `json:"path,omitempty"`
+}
+
+type StorageSummary struct {
+	BigmapAllocs  int64   `json:"bigmap_allocs,omitempty"`
+	BigmapCopies  int64   `json:"bigmap_copies,omitempty"`
+	BigmapIds     []int64 `json:"bigmap_ids,omitempty"`
+	BigmapRemoves int64   `json:"bigmap_removes,omitempty"`
+	BigmapUpdates int64   `json:"bigmap_updates,omitempty"`
+	StorageHash   string  `json:"storage_hash,omitempty"`
+	StoragePaid   int64   `json:"storage_paid,omitempty"`
+}
+
+type SysStat struct {
+	ContainerName string    `json:"container_name,omitempty"`
+	CpuSystem     float64   `json:"cpu_system,omitempty"`
+	CpuTotal      float64   `json:"cpu_total,omitempty"`
+	CpuUser       float64   `json:"cpu_user,omitempty"`
+	DiskFree      int64     `json:"disk_free,omitempty"`
+	DiskSize      int64     `json:"disk_size,omitempty"`
+	DiskUsed      int64     `json:"disk_used,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	MemFrees      int64     `json:"mem_frees,omitempty"`
+	MemHeap       int64     `json:"mem_heap,omitempty"`
+	MemMallocs    int64     `json:"mem_mallocs,omitempty"`
+	MemStack      int64     `json:"mem_stack,omitempty"`
+	NumCpu        int64     `json:"num_cpu,omitempty"`
+	NumGoroutine  int64     `json:"num_goroutine,omitempty"`
+	NumThreads    int64     `json:"num_threads,omitempty"`
+	Timestamp     time.Time `json:"timestamp,omitempty"`
+	TotalMem      int64     `json:"total_mem,omitempty"`
+	TotalSwap     int64     `json:"total_swap,omitempty"`
+	VmAnon        int64     `json:"vm_anon,omitempty"`
+	VmMap         int64     `json:"vm_map,omitempty"`
+	VmPageFaults  int64     `json:"vm_page_faults,omitempty"`
+	VmPeak        int64     `json:"vm_peak,omitempty"`
+	VmRss         int64     `json:"vm_rss,omitempty"`
+	VmShm         int64     `json:"vm_shm,omitempty"`
+	VmSize        int64     `json:"vm_size,omitempty"`
+	VmSwap        int64     `json:"vm_swap,omitempty"`
+}
+
+type TableStats struct {
+	CallsDelete            int64     `json:"calls_delete,omitempty"`
+	CallsFlush             int64     `json:"calls_flush,omitempty"`
+	CallsInsert            int64     `json:"calls_insert,omitempty"`
+	CallsQuery             int64     `json:"calls_query,omitempty"`
+	CallsStream            int64     `json:"calls_stream,omitempty"`
+	CallsUpdate            int64     `json:"calls_update,omitempty"`
+	IndexName              string    `json:"index_name,omitempty"`
+	JournalBytesWritten    int64     `json:"journal_bytes_written,omitempty"`
+	JournalDiskSize        int64     `json:"journal_disk_size,omitempty"`
+	JournalPacksStored     int64     `json:"journal_packs_stored,omitempty"`
+	JournalSize            int64     `json:"journal_size,omitempty"`
+	JournalTuplesCapacity  int64     `json:"journal_tuples_capacity,omitempty"`
+	JournalTuplesCount     int64     `json:"journal_tuples_count,omitempty"`
+	JournalTuplesFlushed   int64     `json:"journal_tuples_flushed,omitempty"`
+	JournalTuplesThreshold int64     `json:"journal_tuples_threshold,omitempty"`
+	LastFlushDuration      int64     `json:"last_flush_duration,omitempty"`
+	LastFlushTime          time.Time `json:"last_flush_time,omitempty"`
+	MetaBytesRead          int64     `json:"meta_bytes_read,omitempty"`
+	MetaBytesWritten       int64     `json:"meta_bytes_written,omitempty"`
+	MetaSize               int64     `json:"meta_size,omitempty"`
+	PackCacheCapacity      int64     `json:"pack_cache_capacity,omitempty"`
+	PackCacheCount         int64     `json:"pack_cache_count,omitempty"`
+	PackCacheEvictions     int64     `json:"pack_cache_evictions,omitempty"`
+	PackCacheHits          int64     `json:"pack_cache_hits,omitempty"`
+	PackCacheInserts       int64     `json:"pack_cache_inserts,omitempty"`
+	PackCacheMisses        int64     `json:"pack_cache_misses,omitempty"`
+	PackCacheSize          int64     `json:"pack_cache_size,omitempty"`
+	PackCacheUpdates       int64     `json:"pack_cache_updates,omitempty"`
+	PacksAlloc             int64     `json:"packs_alloc,omitempty"`
+	PacksBytesRead         int64     `json:"packs_bytes_read,omitempty"`
+	PacksBytesWritten      int64     `json:"packs_bytes_written,omitempty"`
+	PacksCount             int64     `json:"packs_count,omitempty"`
+	PacksLoaded            int64     `json:"packs_loaded,omitempty"`
+	PacksRecycled          int64     `json:"packs_recycled,omitempty"`
+	PacksSize              int64     `json:"packs_size,omitempty"`
+	PacksStored            int64     `json:"packs_stored,omitempty"`
+	TableName              string    `json:"table_name,omitempty"`
+	TombBytesWritten       int64     `json:"tomb_bytes_written,omitempty"`
+	TombPacksStored        int64     `json:"tomb_packs_stored,omitempty"`
+	TombTuplesCapacity     int64     `json:"tomb_tuples_capacity,omitempty"`
+	TombTuplesCount        int64     `json:"tomb_tuples_count,omitempty"`
+	TombTuplesFlushed      int64     `json:"tomb_tuples_flushed,omitempty"`
+	TombTuplesThreshold    int64     `json:"tomb_tuples_threshold,omitempty"`
+	TombstoneDiskSize      int64     `json:"tombstone_disk_size,omitempty"`
+	TombstoneSize          int64     `json:"tombstone_size,omitempty"`
+	TuplesCount            int64     `json:"tuples_count,omitempty"`
+	TuplesDeleted          int64     `json:"tuples_deleted,omitempty"`
+	TuplesFlushed          int64     `json:"tuples_flushed,omitempty"`
+	TuplesInserted         int64     `json:"tuples_inserted,omitempty"`
+	TuplesQueried          int64     `json:"tuples_queried,omitempty"`
+	TuplesStreamed         int64     `json:"tuples_streamed,omitempty"`
+	TuplesUpdated          int64     `json:"tuples_updated,omitempty"`
+}
+
+type TicketUpdate struct {
+	Account  string          `json:"account,omitempty"`
+	Amount   string          `json:"amount,omitempty"`
+	Content  json.RawMessage `json:"content,omitempty"`
+	Ticketer string          `json:"ticketer,omitempty"`
+	Type     json.RawMessage `json:"type,omitempty"`
+}
+
+type TimelinePoint struct {
+	Height               int64     `json:"height,omitempty"`
+	NayStake             float64   `json:"nay_stake,omitempty"`
+	PassStake            float64   `json:"pass_stake,omitempty"`
+	ProgressPct          int64     `json:"progress_pct,omitempty"`
+	QuorumReached        bool      `json:"quorum_reached,omitempty"`
+	SupermajorityPct     int64     `json:"supermajority_pct,omitempty"`
+	SupermajorityReached bool      `json:"supermajority_reached,omitempty"`
+	Time                 time.Time `json:"time,omitempty"`
+	TurnoutPct           int64     `json:"turnout_pct,omitempty"`
+	TurnoutRolls         int64     `json:"turnout_rolls,omitempty"`
+	TurnoutStake         float64   `json:"turnout_stake,omitempty"`
+	TurnoutVoters        int64     `json:"turnout_voters,omitempty"`
+	YayStake             float64   `json:"yay_stake,omitempty"`
+}
+
+type TokenTransfer struct {
+	Amount  string  `json:"amount,omitempty"`
+	From    string  `json:"from,omitempty"`
+	To      string  `json:"to,omitempty"`
+	Token   string  `json:"token,omitempty"`
+	TokenId *string `json:"token_id,omitempty"`
+}
+
+type TraceNode struct {
+	Amount         float64          `json:"amount,omitempty"`
+	Calls          []*TraceNode     `json:"calls,omitempty"`
+	Depth          int64            `json:"depth,omitempty"`
+	Entrypoint     string           `json:"entrypoint,omitempty"`
+	Errors         json.RawMessage  `json:"errors,omitempty"`
+	Events         []*Event         `json:"events,omitempty"`
+	Fee            float64          `json:"fee,omitempty"`
+	GasUsed        int64            `json:"gas_used,omitempty"`
+	Id             int64            `json:"id,omitempty"`
+	IsInternal     bool             `json:"is_internal,omitempty"`
+	IsSuccess      bool             `json:"is_success,omitempty"`
+	Parameters     *Parameters      `json:"parameters,omitempty"`
+	Receiver       string           `json:"receiver,omitempty"`
+	Sender         string           `json:"sender,omitempty"`
+	Status         string           `json:"status,omitempty"`
+	Storage        *StorageSummary  `json:"storage,omitempty"`
+	TicketUpdates  []*TicketUpdate  `json:"ticket_updates,omitempty"`
+	TokenTransfers []*TokenTransfer `json:"token_transfers,omitempty"`
+	Type           string           `json:"type,omitempty"`
+}
+
+type TransferGraph struct {
+	Edges     []*GraphEdge `json:"edges,omitempty"`
+	Nodes     []*GraphNode `json:"nodes,omitempty"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+type Typedef struct {
+	Args     []*Typedef `json:"args,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	Optional bool       `json:"optional,omitempty"`
+	Type     string     `json:"type,omitempty"`
+}
+
+type Vote struct {
+	EligibleRolls    int64       `json:"eligible_rolls,omitempty"`
+	EligibleStake    float64     `json:"eligible_stake,omitempty"`
+	EligibleVoters   int64       `json:"eligible_voters,omitempty"`
+	IsDraw           bool        `json:"is_draw,omitempty"`
+	IsFailed         bool        `json:"is_failed,omitempty"`
+	IsOpen           bool        `json:"is_open,omitempty"`
+	NayRolls         int64       `json:"nay_rolls,omitempty"`
+	NayStake         float64     `json:"nay_stake,omitempty"`
+	NayVoters        int64       `json:"nay_voters,omitempty"`
+	NoMajority       bool        `json:"no_majority,omitempty"`
+	NoProposal       bool        `json:"no_proposal,omitempty"`
+	NoQuorum         bool        `json:"no_quorum,omitempty"`
+	PassRolls        int64       `json:"pass_rolls,omitempty"`
+	PassStake        float64     `json:"pass_stake,omitempty"`
+	PassVoters       int64       `json:"pass_voters,omitempty"`
+	PeriodEndBlock   int64       `json:"period_end_block,omitempty"`
+	PeriodEndTime    time.Time   `json:"period_end_time,omitempty"`
+	PeriodStartBlock int64       `json:"period_start_block,omitempty"`
+	PeriodStartTime  time.Time   `json:"period_start_time,omitempty"`
+	Proposals        []*Proposal `json:"proposals,omitempty"`
+	QuorumPct        int64       `json:"quorum_pct,omitempty"`
+	QuorumRolls      int64       `json:"quorum_rolls,omitempty"`
+	QuorumStake      float64     `json:"quorum_stake,omitempty"`
+	TurnoutEma       int64       `json:"turnout_ema,omitempty"`
+	TurnoutPct       int64       `json:"turnout_pct,omitempty"`
+	TurnoutRolls     int64       `json:"turnout_rolls,omitempty"`
+	TurnoutStake     float64     `json:"turnout_stake,omitempty"`
+	TurnoutVoters    int64       `json:"turnout_voters,omitempty"`
+	VotingPeriod     int64       `json:"voting_period,omitempty"`
+	VotingPeriodKind string      `json:"voting_period_kind,omitempty"`
+	YayRolls         int64       `json:"yay_rolls,omitempty"`
+	YayStake         float64     `json:"yay_stake,omitempty"`
+	YayVoters        int64       `json:"yay_voters,omitempty"`
+}
+
+type Voter struct {
+	Address   string   `json:"address,omitempty"`
+	Ballot    string   `json:"ballot,omitempty"`
+	HasVoted  bool     `json:"has_voted,omitempty"`
+	Proposals []string `json:"proposals,omitempty"`
+	Rolls     int64    `json:"rolls,omitempty"`
+	RowId     int64    `json:"row_id,omitempty"`
+	Stake     float64  `json:"stake,omitempty"`
+}
+
+// GetAccountParams holds optional query arguments of GetAccount.
+type GetAccountParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *GetAccountParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// GetAccount returns account.
+func (c *Client) GetAccount(ctx context.Context, ident string, params *GetAccountParams) (*Account, error) {
+	v := new(Account)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListAccountContractsParams holds optional query arguments of ListAccountContracts.
+type ListAccountContractsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *ListAccountContractsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// ListAccountContracts returns contracts deployed by account.
+func (c *Client) ListAccountContracts(ctx context.Context, ident string, params *ListAccountContractsParams) ([]*Contract, error) {
+	var v []*Contract
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/contracts", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListAccountCounterpartiesParams holds optional query arguments of ListAccountCounterparties.
+type ListAccountCounterpartiesParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Since  string
+	Until  string
+	Sort   string
+}
+
+func (p *ListAccountCounterpartiesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Until != "" {
+		q.Set("until", p.Until)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	return q
+}
+
+// ListAccountCounterparties returns account counterparties.
+func (c *Client) ListAccountCounterparties(ctx context.Context, ident string, params *ListAccountCounterpartiesParams) ([]*Counterparty, error) {
+	var v []*Counterparty
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/counterparties", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetAccountMetadata returns account metadata.
+func (c *Client) GetAccountMetadata(ctx context.Context, ident string) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/metadata", url.PathEscape(ident)), nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListAccountOpsParams holds optional query arguments of ListAccountOps.
+type ListAccountOpsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *ListAccountOpsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// ListAccountOps returns account operations.
+func (c *Client) ListAccountOps(ctx context.Context, ident string, params *ListAccountOpsParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/operations", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListAccountRewardsParams holds optional query arguments of ListAccountRewards.
+type ListAccountRewardsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListAccountRewardsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListAccountRewards returns account rewards per cycle.
+func (c *Client) ListAccountRewards(ctx context.Context, ident string, params *ListAccountRewardsParams) ([]*AccountReward, error) {
+	var v []*AccountReward
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/rewards", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetAccountStatementParams holds optional query arguments of GetAccountStatement.
+type GetAccountStatementParams struct {
+	Currency string
+	From     string
+	To       string
+	Format   string
+}
+
+func (p *GetAccountStatementParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Currency != "" {
+		q.Set("currency", p.Currency)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Format != "" {
+		q.Set("format", p.Format)
+	}
+	return q
+}
+
+// GetAccountStatement returns account statement.
+func (c *Client) GetAccountStatement(ctx context.Context, ident string, params *GetAccountStatementParams) (*AccountStatement, error) {
+	v := new(AccountStatement)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/account/%s/statement", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetAccountsParams holds optional query arguments of GetAccounts.
+type GetAccountsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *GetAccountsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// GetAccounts returns accounts by address.
+func (c *Client) GetAccounts(ctx context.Context, body *AccountBatchRequest, params *GetAccountsParams) ([]*BatchItem, error) {
+	var v []*BatchItem
+	if err := c.post(ctx, "/explorer/accounts", params.values(), body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBakersParams holds optional query arguments of ListBakers.
+type ListBakersParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Active  bool
+	Status  string
+	Country string
+	Suggest string
+	Ads     bool
+}
+
+func (p *ListBakersParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Active {
+		q.Set("active", "true")
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.Country != "" {
+		q.Set("country", p.Country)
+	}
+	if p.Suggest != "" {
+		q.Set("suggest", p.Suggest)
+	}
+	if p.Ads {
+		q.Set("ads", "true")
+	}
+	return q
+}
+
+// ListBakers returns bakers.
+func (c *Client) ListBakers(ctx context.Context, params *ListBakersParams) ([]*Baker, error) {
+	var v []*Baker
+	if err := c.get(ctx, "/explorer/bakers", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerParams holds optional query arguments of GetBaker.
+type GetBakerParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *GetBakerParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// GetBaker returns baker.
+func (c *Client) GetBaker(ctx context.Context, ident string, params *GetBakerParams) (*Baker, error) {
+	v := new(Baker)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBakerDelegationsParams holds optional query arguments of ListBakerDelegations.
+type ListBakerDelegationsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *ListBakerDelegationsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// ListBakerDelegations returns baker delegations.
+func (c *Client) ListBakerDelegations(ctx context.Context, ident string, params *ListBakerDelegationsParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/delegations", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBakerEndorsementsParams holds optional query arguments of ListBakerEndorsements.
+type ListBakerEndorsementsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *ListBakerEndorsementsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// ListBakerEndorsements returns baker endorsements.
+func (c *Client) ListBakerEndorsements(ctx context.Context, ident string, params *ListBakerEndorsementsParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/endorsements", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerForecastParams holds optional query arguments of GetBakerForecast.
+type GetBakerForecastParams struct {
+	Cycles int64
+	Limit  int64
+}
+
+func (p *GetBakerForecastParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Cycles != 0 {
+		q.Set("cycles", strconv.FormatInt(p.Cycles, 10))
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	return q
+}
+
+// GetBakerForecast returns baker forecast.
+func (c *Client) GetBakerForecast(ctx context.Context, ident string, params *GetBakerForecastParams) (*BakerForecast, error) {
+	v := new(BakerForecast)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/forecast", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerIncome returns baker income in cycle.
+func (c *Client) GetBakerIncome(ctx context.Context, ident string, cycle string) (*ExplorerIncome, error) {
+	v := new(ExplorerIncome)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/income/%s", url.PathEscape(ident), url.PathEscape(cycle)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBakerKeys returns baker consensus keys.
+func (c *Client) ListBakerKeys(ctx context.Context, ident string) (*BakerKeyHistory, error) {
+	v := new(BakerKeyHistory)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/keys", url.PathEscape(ident)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerMetadata returns baker metadata.
+func (c *Client) GetBakerMetadata(ctx context.Context, ident string) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/metadata", url.PathEscape(ident)), nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerRights returns baker rights in cycle.
+func (c *Client) GetBakerRights(ctx context.Context, ident string, cycle string) (*ExplorerRights, error) {
+	v := new(ExplorerRights)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/rights/%s", url.PathEscape(ident), url.PathEscape(cycle)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBakerSnapshot returns baker snapshot for cycle.
+func (c *Client) GetBakerSnapshot(ctx context.Context, ident string, cycle string) (*ExplorerSnapshot, error) {
+	v := new(ExplorerSnapshot)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/snapshot/%s", url.PathEscape(ident), url.PathEscape(cycle)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBakerVotesParams holds optional query arguments of ListBakerVotes.
+type ListBakerVotesParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *ListBakerVotesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// ListBakerVotes returns baker ballots.
+func (c *Client) ListBakerVotes(ctx context.Context, ident string, params *ListBakerVotesParams) ([]*Ballot, error) {
+	var v []*Ballot
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bakers/%s/votes", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBigmapParams holds optional query arguments of GetBigmap.
+type GetBigmapParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *GetBigmapParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// GetBigmap returns bigmap.
+func (c *Client) GetBigmap(ctx context.Context, id string, params *GetBigmapParams) (*Bigmap, error) {
+	v := new(Bigmap)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s", url.PathEscape(id)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBigmapDiffParams holds optional query arguments of GetBigmapDiff.
+type GetBigmapDiffParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	From   string
+	To     string
+	Unpack bool
+	Prim   bool
+}
+
+func (p *GetBigmapDiffParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	return q
+}
+
+// GetBigmapDiff returns bigmap diff between blocks.
+func (c *Client) GetBigmapDiff(ctx context.Context, id string, params *GetBigmapDiffParams) (*BigmapDiff, error) {
+	v := new(BigmapDiff)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/diff", url.PathEscape(id)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBigmapKeysParams holds optional query arguments of ListBigmapKeys.
+type ListBigmapKeysParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListBigmapKeysParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListBigmapKeys returns bigmap keys.
+func (c *Client) ListBigmapKeys(ctx context.Context, id string, params *ListBigmapKeysParams) ([]*BigmapKey, error) {
+	var v []*BigmapKey
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/keys", url.PathEscape(id)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBigmapUpdatesParams holds optional query arguments of ListBigmapUpdates.
+type ListBigmapUpdatesParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListBigmapUpdatesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListBigmapUpdates returns bigmap updates.
+func (c *Client) ListBigmapUpdates(ctx context.Context, id string, params *ListBigmapUpdatesParams) ([]*BigmapUpdate, error) {
+	var v []*BigmapUpdate
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/updates", url.PathEscape(id)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBigmapValuesParams holds optional query arguments of ListBigmapValues.
+type ListBigmapValuesParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListBigmapValuesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListBigmapValues returns bigmap values.
+func (c *Client) ListBigmapValues(ctx context.Context, id string, params *ListBigmapValuesParams) ([]*BigmapValue, error) {
+	var v []*BigmapValue
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/values", url.PathEscape(id)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBigmapValueParams holds optional query arguments of GetBigmapValue.
+type GetBigmapValueParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *GetBigmapValueParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// GetBigmapValue returns bigmap value.
+func (c *Client) GetBigmapValue(ctx context.Context, id string, key string, params *GetBigmapValueParams) (*BigmapValue, error) {
+	v := new(BigmapValue)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/%s", url.PathEscape(id), url.PathEscape(key)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBigmapKeyUpdatesParams holds optional query arguments of ListBigmapKeyUpdates.
+type ListBigmapKeyUpdatesParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListBigmapKeyUpdatesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListBigmapKeyUpdates returns bigmap key updates.
+func (c *Client) ListBigmapKeyUpdates(ctx context.Context, id string, key string, params *ListBigmapKeyUpdatesParams) ([]*BigmapUpdate, error) {
+	var v []*BigmapUpdate
+	if err := c.get(ctx, fmt.Sprintf("/explorer/bigmap/%s/%s/updates", url.PathEscape(id), url.PathEscape(key)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetBlockParams holds optional query arguments of GetBlock.
+type GetBlockParams struct {
+	Meta   bool
+	Rights bool
+}
+
+func (p *GetBlockParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	return q
+}
+
+// GetBlock returns block.
+func (c *Client) GetBlock(ctx context.Context, ident string, params *GetBlockParams) (*Block, error) {
+	v := new(Block)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/block/%s", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListBlockOpsParams holds optional query arguments of ListBlockOps.
+type ListBlockOpsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *ListBlockOpsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// ListBlockOps returns block operations.
+func (c *Client) ListBlockOps(ctx context.Context, ident string, params *ListBlockOpsParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/block/%s/operations", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListCohorts returns address cohorts.
+func (c *Client) ListCohorts(ctx context.Context) ([]*Cohort, error) {
+	var v []*Cohort
+	if err := c.get(ctx, "/explorer/cohort", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// CreateCohort returns created address cohort.
+func (c *Client) CreateCohort(ctx context.Context, body *CohortRequest) (*Cohort, error) {
+	v := new(Cohort)
+	if err := c.post(ctx, "/explorer/cohort", nil, body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetCohort returns address cohort.
+func (c *Client) GetCohort(ctx context.Context, name string) (*Cohort, error) {
+	v := new(Cohort)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/cohort/%s", url.PathEscape(name)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateCohort requests to add or relabel cohort members.
+func (c *Client) UpdateCohort(ctx context.Context, name string, body []*CohortMember) (*Cohort, error) {
+	v := new(Cohort)
+	if err := c.put(ctx, fmt.Sprintf("/explorer/cohort/%s", url.PathEscape(name)), nil, body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RemoveCohortParams holds optional query arguments of RemoveCohort.
+type RemoveCohortParams struct {
+	Address []string
+}
+
+func (p *RemoveCohortParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	for _, v := range p.Address {
+		q.Add("address", v)
+	}
+	return q
+}
+
+// RemoveCohort requests to remove cohort or selected members.
+func (c *Client) RemoveCohort(ctx context.Context, name string, params *RemoveCohortParams) error {
+	return c.delete(ctx, fmt.Sprintf("/explorer/cohort/%s", url.PathEscape(name)), params.values(), nil)
+}
+
+// GetCohortBalance returns cohort balance.
+func (c *Client) GetCohortBalance(ctx context.Context, name string) (*CohortBalance, error) {
+	v := new(CohortBalance)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/cohort/%s/balance", url.PathEscape(name)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListCohortFlowsParams holds optional query arguments of ListCohortFlows.
+type ListCohortFlowsParams struct {
+	Days int64
+}
+
+func (p *ListCohortFlowsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Days != 0 {
+		q.Set("days", strconv.FormatInt(p.Days, 10))
+	}
+	return q
+}
+
+// ListCohortFlows returns cohort daily flows.
+func (c *Client) ListCohortFlows(ctx context.Context, name string, params *ListCohortFlowsParams) ([]*CohortFlowDay, error) {
+	var v []*CohortFlowDay
+	if err := c.get(ctx, fmt.Sprintf("/explorer/cohort/%s/flows", url.PathEscape(name)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetConfig returns blockchain config at height.
+func (c *Client) GetConfig(ctx context.Context, ident string) (*BlockchainConfig, error) {
+	v := new(BlockchainConfig)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/config/%s", url.PathEscape(ident)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetConstant returns global constant.
+func (c *Client) GetConstant(ctx context.Context, ident string) (*Constant, error) {
+	v := new(Constant)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/constant/%s", url.PathEscape(ident)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetContractParams holds optional query arguments of GetContract.
+type GetContractParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *GetContractParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// GetContract returns contract.
+func (c *Client) GetContract(ctx context.Context, ident string, params *GetContractParams) (*Contract, error) {
+	v := new(Contract)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListContractCallsParams holds optional query arguments of ListContractCalls.
+type ListContractCallsParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListContractCallsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListContractCalls returns contract calls.
+func (c *Client) ListContractCalls(ctx context.Context, ident string, params *ListContractCallsParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/calls", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListContractErrorsParams holds optional query arguments of ListContractErrors.
+type ListContractErrorsParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListContractErrorsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListContractErrors returns contract errors.
+func (c *Client) ListContractErrors(ctx context.Context, ident string, params *ListContractErrorsParams) ([]*ContractErrorGroup, error) {
+	var v []*ContractErrorGroup
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/errors", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListContractEventsParams holds optional query arguments of ListContractEvents.
+type ListContractEventsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Tag    string
+	Since  string
+	Until  string
+	From   string
+	To     string
+	Prim   bool
+	Unpack bool
+}
+
+func (p *ListContractEventsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Tag != "" {
+		q.Set("tag", p.Tag)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Until != "" {
+		q.Set("until", p.Until)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	return q
+}
+
+// ListContractEvents returns contract events.
+func (c *Client) ListContractEvents(ctx context.Context, ident string, params *ListContractEventsParams) ([]*ContractEvent, error) {
+	var v []*ContractEvent
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/events", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetContractScriptParams holds optional query arguments of GetContractScript.
+type GetContractScriptParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *GetContractScriptParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// GetContractScript returns contract script.
+func (c *Client) GetContractScript(ctx context.Context, ident string, params *GetContractScriptParams) (*Script, error) {
+	v := new(Script)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/script", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListSimilarContractsParams holds optional query arguments of ListSimilarContracts.
+type ListSimilarContractsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Kind     string
+	Timeline bool
+}
+
+func (p *ListSimilarContractsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Kind != "" {
+		q.Set("kind", p.Kind)
+	}
+	if p.Timeline {
+		q.Set("timeline", "true")
+	}
+	return q
+}
+
+// ListSimilarContracts returns similar contracts.
+func (c *Client) ListSimilarContracts(ctx context.Context, ident string, params *ListSimilarContractsParams) (*SimilarContracts, error) {
+	v := new(SimilarContracts)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/similar", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListContractStatsParams holds optional query arguments of ListContractStats.
+type ListContractStatsParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListContractStatsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListContractStats returns contract daily call statistics.
+func (c *Client) ListContractStats(ctx context.Context, ident string, params *ListContractStatsParams) ([]*ContractCallDay, error) {
+	var v []*ContractCallDay
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/stats", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetContractStorageParams holds optional query arguments of GetContractStorage.
+type GetContractStorageParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *GetContractStorageParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// GetContractStorage returns contract storage.
+func (c *Client) GetContractStorage(ctx context.Context, ident string, params *GetContractStorageParams) (*Storage, error) {
+	v := new(Storage)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/storage", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListContractStorageHistoryParams holds optional query arguments of ListContractStorageHistory.
+type ListContractStorageHistoryParams struct {
+	Limit   int64
+	Offset  int64
+	Cursor  int64
+	Order   string
+	Block   string
+	Since   string
+	Unpack  bool
+	Prim    bool
+	Meta    bool
+	Merge   bool
+	Storage bool
+	Sender  string
+}
+
+func (p *ListContractStorageHistoryParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	return q
+}
+
+// ListContractStorageHistory returns contract storage history.
+func (c *Client) ListContractStorageHistory(ctx context.Context, ident string, params *ListContractStorageHistoryParams) ([]*StorageHistoryEntry, error) {
+	var v []*StorageHistoryEntry
+	if err := c.get(ctx, fmt.Sprintf("/explorer/contract/%s/storage/history", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetContractsParams holds optional query arguments of GetContracts.
+type GetContractsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+	Meta   bool
+}
+
+func (p *GetContractsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	return q
+}
+
+// GetContracts returns contracts by address.
+func (c *Client) GetContracts(ctx context.Context, body *AccountBatchRequest, params *GetContractsParams) ([]*BatchItem, error) {
+	var v []*BatchItem
+	if err := c.post(ctx, "/explorer/contracts", params.values(), body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetCycle returns cycle.
+func (c *Client) GetCycle(ctx context.Context, cycle string) (*Cycle, error) {
+	v := new(Cycle)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/cycle/%s", url.PathEscape(cycle)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListCycleBakersParams holds optional query arguments of ListCycleBakers.
+type ListCycleBakersParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Degraded bool
+}
+
+func (p *ListCycleBakersParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Degraded {
+		q.Set("degraded", "true")
+	}
+	return q
+}
+
+// ListCycleBakers returns baker performance in cycle.
+func (c *Client) ListCycleBakers(ctx context.Context, cycle string, params *ListCycleBakersParams) ([]*CycleBaker, error) {
+	var v []*CycleBaker
+	if err := c.get(ctx, fmt.Sprintf("/explorer/cycle/%s/bakers", url.PathEscape(cycle)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetElection returns election.
+func (c *Client) GetElection(ctx context.Context, ident string) (*Election, error) {
+	v := new(Election)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/election/%s", url.PathEscape(ident)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetElectionTimelineParams holds optional query arguments of GetElectionTimeline.
+type GetElectionTimelineParams struct {
+	Stage   int64
+	Limit   int64
+	Compare int64
+}
+
+func (p *GetElectionTimelineParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Stage != 0 {
+		q.Set("stage", strconv.FormatInt(p.Stage, 10))
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Compare != 0 {
+		q.Set("compare", strconv.FormatInt(p.Compare, 10))
+	}
+	return q
+}
+
+// GetElectionTimeline returns election timeline.
+func (c *Client) GetElectionTimeline(ctx context.Context, ident string, params *GetElectionTimelineParams) (*ElectionTimeline, error) {
+	v := new(ElectionTimeline)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/election/%s/timeline", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListElectionBallotsParams holds optional query arguments of ListElectionBallots.
+type ListElectionBallotsParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListElectionBallotsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListElectionBallots returns election ballots.
+func (c *Client) ListElectionBallots(ctx context.Context, ident string, stage string, params *ListElectionBallotsParams) ([]*Ballot, error) {
+	var v []*Ballot
+	if err := c.get(ctx, fmt.Sprintf("/explorer/election/%s/%s/ballots", url.PathEscape(ident), url.PathEscape(stage)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListElectionVotersParams holds optional query arguments of ListElectionVoters.
+type ListElectionVotersParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListElectionVotersParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListElectionVoters returns election voters.
+func (c *Client) ListElectionVoters(ctx context.Context, ident string, stage string, params *ListElectionVotersParams) ([]*Voter, error) {
+	var v []*Voter
+	if err := c.get(ctx, fmt.Sprintf("/explorer/election/%s/%s/voters", url.PathEscape(ident), url.PathEscape(stage)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetTransferGraphParams holds optional query arguments of GetTransferGraph.
+type GetTransferGraphParams struct {
+	From      string
+	Depth     int64
+	Direction string
+	Since     string
+	Until     string
+	MinVolume float64
+	Limit     int64
+}
+
+func (p *GetTransferGraphParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.Depth != 0 {
+		q.Set("depth", strconv.FormatInt(p.Depth, 10))
+	}
+	if p.Direction != "" {
+		q.Set("direction", p.Direction)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Until != "" {
+		q.Set("until", p.Until)
+	}
+	if p.MinVolume != 0 {
+		q.Set("min_volume", strconv.FormatFloat(p.MinVolume, 'f', -1, 64))
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	return q
+}
+
+// GetTransferGraph returns transfer graph.
+func (c *Client) GetTransferGraph(ctx context.Context, params *GetTransferGraphParams) (*TransferGraph, error) {
+	v := new(TransferGraph)
+	if err := c.get(ctx, "/explorer/graph", params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetGraphQLParams holds optional query arguments of GetGraphQL.
+type GetGraphQLParams struct {
+	Query         string
+	OperationName string
+}
+
+func (p *GetGraphQLParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Query != "" {
+		q.Set("query", p.Query)
+	}
+	if p.OperationName != "" {
+		q.Set("operationName", p.OperationName)
+	}
+	return q
+}
+
+// GetGraphQL returns result of a GraphQL query.
+func (c *Client) GetGraphQL(ctx context.Context, params *GetGraphQLParams) (*GraphQLResponse, error) {
+	v := new(GraphQLResponse)
+	if err := c.get(ctx, "/explorer/graphql", params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// QueryGraphQL returns result of a GraphQL query.
+func (c *Client) QueryGraphQL(ctx context.Context, body *GraphQLRequest) (*GraphQLResponse, error) {
+	v := new(GraphQLResponse)
+	if err := c.post(ctx, "/explorer/graphql", nil, body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetOpParams holds optional query arguments of GetOp.
+type GetOpParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *GetOpParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// GetOp returns operation group.
+func (c *Client) GetOp(ctx context.Context, ident string, params *GetOpParams) ([]*Op, error) {
+	var v []*Op
+	if err := c.get(ctx, fmt.Sprintf("/explorer/op/%s", url.PathEscape(ident)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetOpTraceParams holds optional query arguments of GetOpTrace.
+type GetOpTraceParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *GetOpTraceParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// GetOpTrace returns operation trace.
+func (c *Client) GetOpTrace(ctx context.Context, ident string, params *GetOpTraceParams) (*OpTrace, error) {
+	v := new(OpTrace)
+	if err := c.get(ctx, fmt.Sprintf("/explorer/op/%s/trace", url.PathEscape(ident)), params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetOpsParams holds optional query arguments of GetOps.
+type GetOpsParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Block    string
+	Since    string
+	Unpack   bool
+	Prim     bool
+	Meta     bool
+	Rights   bool
+	Merge    bool
+	Storage  bool
+	Address  string
+	Sender   string
+	Receiver string
+}
+
+func (p *GetOpsParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Block != "" {
+		q.Set("block", p.Block)
+	}
+	if p.Since != "" {
+		q.Set("since", p.Since)
+	}
+	if p.Unpack {
+		q.Set("unpack", "true")
+	}
+	if p.Prim {
+		q.Set("prim", "true")
+	}
+	if p.Meta {
+		q.Set("meta", "true")
+	}
+	if p.Rights {
+		q.Set("rights", "true")
+	}
+	if p.Merge {
+		q.Set("merge", "true")
+	}
+	if p.Storage {
+		q.Set("storage", "true")
+	}
+	if p.Address != "" {
+		q.Set("address", p.Address)
+	}
+	if p.Sender != "" {
+		q.Set("sender", p.Sender)
+	}
+	if p.Receiver != "" {
+		q.Set("receiver", p.Receiver)
+	}
+	return q
+}
+
+// GetOps returns operation groups by hash.
+func (c *Client) GetOps(ctx context.Context, body *OpBatchRequest, params *GetOpsParams) ([]*BatchItem, error) {
+	var v []*BatchItem
+	if err := c.post(ctx, "/explorer/ops", params.values(), body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListPricesParams holds optional query arguments of ListPrices.
+type ListPricesParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Currency string
+	From     string
+	To       string
+}
+
+func (p *ListPricesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Currency != "" {
+		q.Set("currency", p.Currency)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	return q
+}
+
+// ListPrices returns fiat prices.
+func (c *Client) ListPrices(ctx context.Context, params *ListPricesParams) ([]*Price, error) {
+	var v []*Price
+	if err := c.get(ctx, "/explorer/price", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ImportPrices returns number of imported fiat prices.
+func (c *Client) ImportPrices(ctx context.Context, body []*Price) (*PriceImportResult, error) {
+	v := new(PriceImportResult)
+	if err := c.post(ctx, "/explorer/price", nil, body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PutPrices requests to import fiat prices.
+func (c *Client) PutPrices(ctx context.Context, body []*Price) (*PriceImportResult, error) {
+	v := new(PriceImportResult)
+	if err := c.put(ctx, "/explorer/price", nil, body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListProtocols returns protocol deployments.
+func (c *Client) ListProtocols(ctx context.Context) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, "/explorer/protocols", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListRankBalancesParams holds optional query arguments of ListRankBalances.
+type ListRankBalancesParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListRankBalancesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListRankBalances returns accounts by balance.
+func (c *Client) ListRankBalances(ctx context.Context, params *ListRankBalancesParams) ([]*RankListItem, error) {
+	var v []*RankListItem
+	if err := c.get(ctx, "/explorer/rank/balances", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListRankTrafficParams holds optional query arguments of ListRankTraffic.
+type ListRankTrafficParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListRankTrafficParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListRankTraffic returns accounts by traffic.
+func (c *Client) ListRankTraffic(ctx context.Context, params *ListRankTrafficParams) ([]*RankListItem, error) {
+	var v []*RankListItem
+	if err := c.get(ctx, "/explorer/rank/traffic", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListRankVolumeParams holds optional query arguments of ListRankVolume.
+type ListRankVolumeParams struct {
+	Limit  int64
+	Offset int64
+	Cursor int64
+	Order  string
+}
+
+func (p *ListRankVolumeParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// ListRankVolume returns accounts by volume.
+func (c *Client) ListRankVolume(ctx context.Context, params *ListRankVolumeParams) ([]*RankListItem, error) {
+	var v []*RankListItem
+	if err := c.get(ctx, "/explorer/rank/volume", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetStatus returns indexer status.
+func (c *Client) GetStatus(ctx context.Context) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, "/explorer/status", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetTip returns blockchain tip.
+func (c *Client) GetTip(ctx context.Context) (*BlockchainTip, error) {
+	v := new(BlockchainTip)
+	if err := c.get(ctx, "/explorer/tip", nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetLiveness returns liveness probe.
+func (c *Client) GetLiveness(ctx context.Context) (*HealthStatus, error) {
+	v := new(HealthStatus)
+	if err := c.get(ctx, "/health/live", nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetReadiness returns readiness probe.
+func (c *Client) GetReadiness(ctx context.Context) (*HealthStatus, error) {
+	v := new(HealthStatus)
+	if err := c.get(ctx, "/health/ready", nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListMetadataParams holds optional query arguments of ListMetadata.
+type ListMetadataParams struct {
+	Limit    int64
+	Offset   int64
+	Cursor   int64
+	Order    string
+	Short    bool
+	Kind     string
+	Status   string
+	Country  string
+	Standard string
+}
+
+func (p *ListMetadataParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+	if p.Cursor != 0 {
+		q.Set("cursor", strconv.FormatInt(p.Cursor, 10))
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Short {
+		q.Set("short", "true")
+	}
+	if p.Kind != "" {
+		q.Set("kind", p.Kind)
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.Country != "" {
+		q.Set("country", p.Country)
+	}
+	if p.Standard != "" {
+		q.Set("standard", p.Standard)
+	}
+	return q
+}
+
+// ListMetadata returns metadata entries.
+func (c *Client) ListMetadata(ctx context.Context, params *ListMetadataParams) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.get(ctx, "/metadata", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// CreateMetadata returns created or replaced metadata entries.
+func (c *Client) CreateMetadata(ctx context.Context, body []json.RawMessage) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.post(ctx, "/metadata", nil, body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PurgeMetadata requests to remove all metadata.
+func (c *Client) PurgeMetadata(ctx context.Context) error {
+	return c.delete(ctx, "/metadata", nil, nil)
+}
+
+// DescribeMetadata returns title, description and image of an address, block or operation.
+func (c *Client) DescribeMetadata(ctx context.Context, ident string) (*MetadataDescriptor, error) {
+	v := new(MetadataDescriptor)
+	if err := c.get(ctx, fmt.Sprintf("/metadata/describe/%s", url.PathEscape(ident)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DescribeMetadataNum returns title, description and image of an event, cycle or election.
+func (c *Client) DescribeMetadataNum(ctx context.Context, ident string, num string) (*MetadataDescriptor, error) {
+	v := new(MetadataDescriptor)
+	if err := c.get(ctx, fmt.Sprintf("/metadata/describe/%s/%s", url.PathEscape(ident), url.PathEscape(num)), nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListMetadataSchemas returns metadata schema names.
+func (c *Client) ListMetadataSchemas(ctx context.Context) ([]string, error) {
+	var v []string
+	if err := c.get(ctx, "/metadata/schemas", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetMetadataSchema returns metadata JSON schema.
+func (c *Client) GetMetadataSchema(ctx context.Context, schema string) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/metadata/schemas/%s", url.PathEscape(schema)), nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListMetadataAddresses returns addresses with metadata.
+func (c *Client) ListMetadataAddresses(ctx context.Context) ([]string, error) {
+	var v []string
+	if err := c.get(ctx, "/metadata/sitemap", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetMetadata returns metadata for address.
+func (c *Client) GetMetadata(ctx context.Context, ident string) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/metadata/%s", url.PathEscape(ident)), nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateMetadata requests to update metadata for address.
+func (c *Client) UpdateMetadata(ctx context.Context, ident string, body json.RawMessage) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.put(ctx, fmt.Sprintf("/metadata/%s", url.PathEscape(ident)), nil, body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RemoveMetadata requests to remove metadata for address.
+func (c *Client) RemoveMetadata(ctx context.Context, ident string) error {
+	return c.delete(ctx, fmt.Sprintf("/metadata/%s", url.PathEscape(ident)), nil, nil)
+}
+
+// GetAssetMetadata returns metadata for token.
+func (c *Client) GetAssetMetadata(ctx context.Context, ident string, assetId string) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/metadata/%s/%s", url.PathEscape(ident), url.PathEscape(assetId)), nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// UpdateAssetMetadata requests to update metadata for token.
+func (c *Client) UpdateAssetMetadata(ctx context.Context, ident string, assetId string, body json.RawMessage) (json.RawMessage, error) {
+	var v json.RawMessage
+	if err := c.put(ctx, fmt.Sprintf("/metadata/%s/%s", url.PathEscape(ident), url.PathEscape(assetId)), nil, body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RemoveAssetMetadata requests to remove metadata for token.
+func (c *Client) RemoveAssetMetadata(ctx context.Context, ident string, assetId string) error {
+	return c.delete(ctx, fmt.Sprintf("/metadata/%s/%s", url.PathEscape(ident), url.PathEscape(assetId)), nil, nil)
+}
+
+// GetOpenAPI returns openAPI specification.
+func (c *Client) GetOpenAPI(ctx context.Context) (*OpenAPI, error) {
+	v := new(OpenAPI)
+	if err := c.get(ctx, "/openapi.json", nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PingParams holds optional query arguments of Ping.
+type PingParams struct {
+	Sequence   int64
+	ClientTime int64
+}
+
+func (p *PingParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Sequence != 0 {
+		q.Set("sequence", strconv.FormatInt(p.Sequence, 10))
+	}
+	if p.ClientTime != 0 {
+		q.Set("client_time", strconv.FormatInt(p.ClientTime, 10))
+	}
+	return q
+}
+
+// Ping returns server time.
+func (c *Client) Ping(ctx context.Context, params *PingParams) (*Pinger, error) {
+	v := new(Pinger)
+	if err := c.get(ctx, "/ping", params.values(), v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetSeriesParams holds optional query arguments of GetSeries.
+type GetSeriesParams struct {
+	Columns   string
+	Collapse  string
+	Fill      string
+	StartDate string
+	EndDate   string
+	Limit     int64
+	Verbose   bool
+	Order     string
+}
+
+func (p *GetSeriesParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Columns != "" {
+		q.Set("columns", p.Columns)
+	}
+	if p.Collapse != "" {
+		q.Set("collapse", p.Collapse)
+	}
+	if p.Fill != "" {
+		q.Set("fill", p.Fill)
+	}
+	if p.StartDate != "" {
+		q.Set("start_date", p.StartDate)
+	}
+	if p.EndDate != "" {
+		q.Set("end_date", p.EndDate)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Verbose {
+		q.Set("verbose", "true")
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	return q
+}
+
+// GetSeries returns time series.
+func (c *Client) GetSeries(ctx context.Context, series string, params *GetSeriesParams) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/series/%s", url.PathEscape(series)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListCacheStats returns cache statistics.
+func (c *Client) ListCacheStats(ctx context.Context) (map[string]json.RawMessage, error) {
+	var v map[string]json.RawMessage
+	if err := c.get(ctx, "/system/caches", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PurgeCaches requests to purge caches.
+func (c *Client) PurgeCaches(ctx context.Context) error {
+	return c.put(ctx, "/system/caches/purge", nil, nil, nil)
+}
+
+// GetSystemConfig returns runtime configuration.
+func (c *Client) GetSystemConfig(ctx context.Context) (map[string]json.RawMessage, error) {
+	var v map[string]json.RawMessage
+	if err := c.get(ctx, "/system/config", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ReloadConfig requests to reload config file.
+func (c *Client) ReloadConfig(ctx context.Context) (*ConfigReport, error) {
+	v := new(ConfigReport)
+	if err := c.put(ctx, "/system/config/reload", nil, nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// SetLogLevel requests to set log level of subsystem.
+func (c *Client) SetLogLevel(ctx context.Context, subsystem string, level string) error {
+	return c.put(ctx, fmt.Sprintf("/system/log/%s/%s", url.PathEscape(subsystem), url.PathEscape(level)), nil, nil, nil)
+}
+
+// GetSysStats returns host system statistics.
+func (c *Client) GetSysStats(ctx context.Context) (*SysStat, error) {
+	v := new(SysStat)
+	if err := c.get(ctx, "/system/sysstat", nil, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListTableStats returns table statistics.
+func (c *Client) ListTableStats(ctx context.Context) ([]*TableStats, error) {
+	var v []*TableStats
+	if err := c.get(ctx, "/system/tables", nil, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DumpTable requests to dump table part to snapshot path.
+func (c *Client) DumpTable(ctx context.Context, table string, part string) error {
+	return c.put(ctx, fmt.Sprintf("/system/tables/dump/%s/%s", url.PathEscape(table), url.PathEscape(part)), nil, nil, nil)
+}
+
+// FlushTables requests to flush tables to disk.
+func (c *Client) FlushTables(ctx context.Context) error {
+	return c.put(ctx, "/system/tables/flush", nil, nil, nil)
+}
+
+// FlushJournals requests to flush table journals to disk.
+func (c *Client) FlushJournals(ctx context.Context) error {
+	return c.put(ctx, "/system/tables/flush_journal", nil, nil, nil)
+}
+
+// GcTables requests to garbage collect databases.
+func (c *Client) GcTables(ctx context.Context) error {
+	return c.put(ctx, "/system/tables/gc", nil, nil, nil)
+}
+
+// SnapshotTables requests to snapshot databases.
+func (c *Client) SnapshotTables(ctx context.Context) error {
+	return c.put(ctx, "/system/tables/snapshot", nil, nil, nil)
+}
+
+// GetSqlParams holds optional query arguments of GetSql.
+type GetSqlParams struct {
+	Q string
+}
+
+func (p *GetSqlParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	return q
+}
+
+// GetSql returns result rows of a SQL query.
+func (c *Client) GetSql(ctx context.Context, params *GetSqlParams) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.get(ctx, "/tables/sql", params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// QuerySql returns result rows of a SQL query.
+func (c *Client) QuerySql(ctx context.Context, body *SqlRequest) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.post(ctx, "/tables/sql", nil, body, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// GetTableParams holds optional query arguments of GetTable.
+type GetTableParams struct {
+	Columns  string
+	Limit    int64
+	Cursor   string
+	Order    string
+	Verbose  bool
+	GroupBy  string
+	Sum      string
+	Distinct string
+	Count    bool
+	Sort     string
+}
+
+func (p *GetTableParams) values() url.Values {
+	q := make(url.Values)
+	if p == nil {
+		return q
+	}
+	if p.Columns != "" {
+		q.Set("columns", p.Columns)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.FormatInt(p.Limit, 10))
+	}
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Verbose {
+		q.Set("verbose", "true")
+	}
+	if p.GroupBy != "" {
+		q.Set("group_by", p.GroupBy)
+	}
+	if p.Sum != "" {
+		q.Set("sum", p.Sum)
+	}
+	if p.Distinct != "" {
+		q.Set("distinct", p.Distinct)
+	}
+	if p.Count {
+		q.Set("count", "true")
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	return q
+}
+
+// GetTable returns table rows.
+func (c *Client) GetTable(ctx context.Context, table string, params *GetTableParams) ([]json.RawMessage, error) {
+	var v []json.RawMessage
+	if err := c.get(ctx, fmt.Sprintf("/tables/%s", url.PathEscape(table)), params.values(), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
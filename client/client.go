@@ -0,0 +1,127 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package client is a typed Go client for the explorer API. Types and
+// methods in api.go are generated from the OpenAPI specification served
+// at /openapi.json, run `go generate` after changing the API.
+package client
+
+//go:generate go run ./internal/gen -o api.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var UserAgent = "Blockwatch-TzIndex-Client/1.0"
+
+type Client struct {
+	base   *url.URL
+	client *http.Client
+}
+
+// New creates a client for the API at baseURL. When httpClient is nil
+// http.DefaultClient is used.
+func New(baseURL string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{base: u, client: httpClient}, nil
+}
+
+func (e *Error) Error() string {
+	s := fmt.Sprintf("%d %s", e.Status, e.Message)
+	if e.Detail != "" {
+		s += ": " + e.Detail
+	}
+	return s
+}
+
+func (e *ErrorResponse) Error() string {
+	if len(e.Errors) == 0 {
+		return "unknown error"
+	}
+	return e.Errors[0].Error()
+}
+
+// get sends a GET request and decodes the JSON response into result.
+func (c *Client) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, result)
+}
+
+// post sends body as JSON in a POST request and decodes the JSON response
+// into result.
+func (c *Client) post(ctx context.Context, path string, query url.Values, body, result interface{}) error {
+	return c.send(ctx, http.MethodPost, path, query, body, result)
+}
+
+// put sends body as JSON in a PUT request and decodes the JSON response
+// into result.
+func (c *Client) put(ctx context.Context, path string, query url.Values, body, result interface{}) error {
+	return c.send(ctx, http.MethodPut, path, query, body, result)
+}
+
+// delete sends a DELETE request and decodes the JSON response into result.
+func (c *Client) delete(ctx context.Context, path string, query url.Values, result interface{}) error {
+	return c.do(ctx, http.MethodDelete, path, query, nil, result)
+}
+
+// send encodes body as JSON unless it is nil and sends the request.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	if body == nil {
+		return c.do(ctx, method, path, query, nil, result)
+	}
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, query, bytes.NewReader(buf), result)
+}
+
+// do sends a request and decodes the JSON response into result. API errors
+// are returned as *ErrorResponse. When result is nil the response body is
+// discarded.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, result interface{}) error {
+	u := *c.base
+	u.Path += path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		buf, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		e := &ErrorResponse{}
+		if err := json.Unmarshal(buf, e); err != nil || len(e.Errors) == 0 {
+			e.Errors = []*Error{{Status: int64(resp.StatusCode), Message: http.StatusText(resp.StatusCode)}}
+		}
+		return e
+	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Generates API types and methods of the Go client from the OpenAPI
+// specification of the explorer API.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"blockwatch.cc/tzindex/server"
+	_ "blockwatch.cc/tzindex/server/explorer"
+	_ "blockwatch.cc/tzindex/server/series"
+	_ "blockwatch.cc/tzindex/server/system"
+	_ "blockwatch.cc/tzindex/server/tables"
+)
+
+var (
+	output string
+	dump   string
+)
+
+func init() {
+	flag.StringVar(&output, "o", "api.go", "output file")
+	flag.StringVar(&dump, "spec", "", "also write the OpenAPI specification to file")
+}
+
+func main() {
+	flag.Parse()
+	spec := server.BuildOpenAPI(server.NewRouter())
+	if dump != "" {
+		buf, err := json.MarshalIndent(spec, "", "  ")
+		if err != nil {
+			fail(err)
+		}
+		if err := os.WriteFile(dump, buf, 0644); err != nil {
+			fail(err)
+		}
+	}
+	src, err := format.Source(generate(spec))
+	if err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
+
+type generator struct {
+	bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.Buffer, format, args...)
+	g.WriteByte('\n')
+}
+
+func generate(spec *server.OpenAPI) []byte {
+	body := &generator{imports: map[string]bool{"context": true}}
+
+	// types
+	names := make([]string, 0, len(spec.Components.Schemas))
+	for k := range spec.Components.Schemas {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		body.p("type %s %s\n", name, body.goType(spec.Components.Schemas[name], false))
+	}
+
+	// operations, paths with a format suffix may return CSV and are
+	// not supported by the client
+	for _, path := range spec.SortedPaths() {
+		if strings.Contains(path, ".{format}") {
+			continue
+		}
+		for _, method := range []string{"get", "post", "put", "delete"} {
+			op := spec.Paths[path][method]
+			if op == nil || op.OperationId == "" || op.Deprecated {
+				continue
+			}
+			body.operation(method, path, op)
+		}
+	}
+
+	out := &generator{}
+	out.p("// Code generated by client/internal/gen from the OpenAPI specification. DO NOT EDIT.\n")
+	out.p("package client\n")
+	out.p("import (")
+	imports := make([]string, 0, len(body.imports))
+	for k := range body.imports {
+		imports = append(imports, k)
+	}
+	sort.Strings(imports)
+	for _, v := range imports {
+		out.p("%q", v)
+	}
+	out.p(")\n")
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func (g *generator) goType(s *server.OpenAPISchema, field bool) string {
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		if field {
+			return "*" + name
+		}
+		return name
+	}
+	var typ string
+	switch s.Type {
+	case "boolean":
+		typ = "bool"
+	case "integer":
+		typ = "int64"
+	case "number":
+		typ = "float64"
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			typ = "time.Time"
+		case "byte":
+			return "[]byte"
+		default:
+			typ = "string"
+		}
+	case "array":
+		return "[]" + g.goType(s.Items, true)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.goType(s.AdditionalProperties, true)
+		}
+		if len(s.Properties) == 0 {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage"
+		}
+		return g.structType(s)
+	default:
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	}
+	if s.Nullable {
+		return "*" + typ
+	}
+	return typ
+}
+
+func (g *generator) structType(s *server.OpenAPISchema) string {
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s %s `json:\"%s,omitempty\"`\n", exportName(k), g.goType(s.Properties[k], true), k)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func (g *generator) operation(method, path string, op *server.OpenAPIOperation) {
+	var (
+		pathArgs []string
+		query    []*server.OpenAPIParameter
+	)
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			pathArgs = append(pathArgs, p.Name)
+		case "query":
+			query = append(query, p)
+		}
+	}
+	params := op.OperationId + "Params"
+	if len(query) > 0 {
+		g.p("// %s holds optional query arguments of %s.", params, op.OperationId)
+		g.p("type %s struct {", params)
+		for _, p := range query {
+			g.p("%s %s", exportName(p.Name), g.goType(p.Schema, false))
+		}
+		g.p("}\n")
+		g.imports["net/url"] = true
+		g.p("func (p *%s) values() url.Values {", params)
+		g.p("q := make(url.Values)")
+		g.p("if p == nil {\nreturn q\n}")
+		for _, p := range query {
+			name := exportName(p.Name)
+			switch p.Schema.Type {
+			case "boolean":
+				g.p("if p.%s {\nq.Set(%q, \"true\")\n}", name, p.Name)
+			case "integer":
+				g.imports["strconv"] = true
+				g.p("if p.%s != 0 {\nq.Set(%q, strconv.FormatInt(p.%s, 10))\n}", name, p.Name, name)
+			case "number":
+				g.imports["strconv"] = true
+				g.p("if p.%s != 0 {\nq.Set(%q, strconv.FormatFloat(p.%s, 'f', -1, 64))\n}", name, p.Name, name)
+			case "array":
+				v := "v"
+				if p.Schema.Items.Type == "integer" {
+					g.imports["strconv"] = true
+					v = "strconv.FormatInt(v, 10)"
+				}
+				g.p("for _, v := range p.%s {\nq.Add(%q, %s)\n}", name, p.Name, v)
+			default:
+				g.p("if p.%s != \"\" {\nq.Set(%q, p.%s)\n}", name, p.Name, name)
+			}
+		}
+		g.p("return q\n}\n")
+	}
+
+	// method signature
+	args := []string{"ctx context.Context"}
+	for _, v := range pathArgs {
+		args = append(args, localName(v)+" string")
+	}
+	bodyExpr := ""
+	if op.RequestBody != nil {
+		args = append(args, "body "+g.goType(op.RequestBody.Content["application/json"].Schema, true))
+		bodyExpr = ", body"
+	} else if method == "post" || method == "put" {
+		bodyExpr = ", nil"
+	}
+	if len(query) > 0 {
+		args = append(args, "params *"+params)
+	}
+
+	// success response, operations without content only return an error
+	var res *server.OpenAPIResponse
+	for _, code := range []string{"200", "201", "204"} {
+		if res = op.Responses[code]; res != nil {
+			break
+		}
+	}
+
+	// summaries of PUT, DELETE and operations without result describe an
+	// action, others describe the result
+	doc := "returns"
+	if method == "put" || method == "delete" || res == nil || res.Content == nil {
+		doc = "requests to"
+	}
+	if op.Summary != "" {
+		g.p("// %s %s %s.", op.OperationId, doc, strings.ToLower(op.Summary[:1])+op.Summary[1:])
+	}
+	if res == nil || res.Content == nil {
+		g.p("func (c *Client) %s(%s) error {", op.OperationId, strings.Join(args, ", "))
+		g.p("return c.%s(ctx, %s, %s%s, nil)\n}\n", method, g.pathExpr(path, pathArgs), g.queryExpr(query), bodyExpr)
+		return
+	}
+	result := g.goType(res.Content["application/json"].Schema, false)
+	ret := result
+	if !strings.HasPrefix(result, "[]") && !strings.HasPrefix(result, "map[") && result != "json.RawMessage" {
+		ret = "*" + result
+	}
+	g.p("func (c *Client) %s(%s) (%s, error) {", op.OperationId, strings.Join(args, ", "), ret)
+
+	expr, queryExpr := g.pathExpr(path, pathArgs), g.queryExpr(query)
+	if ret == result {
+		g.p("var v %s", result)
+		g.p("if err := c.%s(ctx, %s, %s%s, &v); err != nil {\nreturn nil, err\n}", method, expr, queryExpr, bodyExpr)
+		g.p("return v, nil\n}\n")
+	} else {
+		g.p("v := new(%s)", result)
+		g.p("if err := c.%s(ctx, %s, %s%s, v); err != nil {\nreturn nil, err\n}", method, expr, queryExpr, bodyExpr)
+		g.p("return v, nil\n}\n")
+	}
+}
+
+// pathExpr returns a Go expression for path with escaped path arguments.
+func (g *generator) pathExpr(path string, pathArgs []string) string {
+	if len(pathArgs) == 0 {
+		return fmt.Sprintf("%q", path)
+	}
+	g.imports["net/url"] = true
+	format := path
+	vals := make([]string, 0, len(pathArgs))
+	for _, v := range pathArgs {
+		format = strings.Replace(format, "{"+v+"}", "%s", 1)
+		vals = append(vals, "url.PathEscape("+localName(v)+")")
+	}
+	g.imports["fmt"] = true
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", format, strings.Join(vals, ", "))
+}
+
+func (g *generator) queryExpr(query []*server.OpenAPIParameter) string {
+	if len(query) > 0 {
+		return "params.values()"
+	}
+	return "nil"
+}
+
+// exportName converts a JSON key into an exported Go identifier.
+func exportName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if b.Len() == 0 && unicode.IsDigit(r) {
+				b.WriteByte('X')
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			b.WriteRune(r)
+		default:
+			upper = true
+		}
+	}
+	return b.String()
+}
+
+// localName converts a path variable into a Go parameter name.
+func localName(s string) string {
+	n := []rune(exportName(s))
+	n[0] = unicode.ToLower(n[0])
+	return string(n)
+}
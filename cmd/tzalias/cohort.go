@@ -0,0 +1,132 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"blockwatch.cc/tzgo/tezos"
+	"github.com/echa/log"
+)
+
+type cohortMember struct {
+	Address tezos.Address `json:"address"`
+	Label   string        `json:"label,omitempty"`
+}
+
+type cohort struct {
+	Name     string         `json:"name"`
+	NMembers int            `json:"n_members"`
+	Members  []cohortMember `json:"members,omitempty"`
+}
+
+// cohorts are not supported by the tzpro client, so we call the API directly
+func cohortCall(ctx context.Context, method, path string, body, result interface{}) error {
+	var r io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(apiurl, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// cohort-list [name]
+func listCohorts(ctx context.Context) error {
+	if flags.NArg() > 1 {
+		var c cohort
+		if err := cohortCall(ctx, http.MethodGet, "/explorer/cohort/"+url.PathEscape(flags.Arg(1)), nil, &c); err != nil {
+			return err
+		}
+		print(c)
+		return nil
+	}
+	list := make([]cohort, 0)
+	if err := cohortCall(ctx, http.MethodGet, "/explorer/cohort", nil, &list); err != nil {
+		return err
+	}
+	print(list)
+	return nil
+}
+
+// cohort-add <name> <address[=label]>...
+func addCohort(ctx context.Context) error {
+	if flags.NArg() < 3 {
+		return fmt.Errorf("cohort name and at least one address required")
+	}
+	c := cohort{
+		Name:    strings.TrimPrefix(flags.Arg(1), "@"),
+		Members: make([]cohortMember, 0, flags.NArg()-2),
+	}
+	for _, v := range flags.Args()[2:] {
+		a, label, _ := strings.Cut(v, "=")
+		addr, err := tezos.ParseAddress(a)
+		if err != nil {
+			return fmt.Errorf("%s: %v", a, err)
+		}
+		c.Members = append(c.Members, cohortMember{Address: addr, Label: label})
+	}
+	var res cohort
+	if err := cohortCall(ctx, http.MethodPost, "/explorer/cohort", c, &res); err != nil {
+		return err
+	}
+	log.Infof("Cohort %s has %d members", res.Name, res.NMembers)
+	return nil
+}
+
+// cohort-remove <name> [address]...
+func removeCohort(ctx context.Context) error {
+	if flags.NArg() < 2 {
+		return fmt.Errorf("cohort name required")
+	}
+	name := strings.TrimPrefix(flags.Arg(1), "@")
+	q := url.Values{}
+	for _, v := range flags.Args()[2:] {
+		if _, err := tezos.ParseAddress(v); err != nil {
+			return fmt.Errorf("%s: %v", v, err)
+		}
+		q.Add("address", v)
+	}
+	path := "/explorer/cohort/" + url.PathEscape(name)
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	if err := cohortCall(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		return err
+	}
+	if len(q) > 0 {
+		log.Infof("Removed %d members from cohort %s", len(q["address"]), name)
+	} else {
+		log.Infof("Removed cohort %s", name)
+	}
+	return nil
+}
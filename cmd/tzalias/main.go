@@ -55,6 +55,9 @@ func main() {
 			fmt.Printf("  update          update existing metadata\n")
 			fmt.Printf("  remove          removes existing metadata (DESTRUCTIVE!)\n")
 			fmt.Printf("  purge           drop all metadata (DESTRUCTIVE!)\n")
+			fmt.Printf("  cohort-list     list cohorts or show cohort members [name]\n")
+			fmt.Printf("  cohort-add      add addresses to a cohort <name> <address[=label]>...\n")
+			fmt.Printf("  cohort-remove   remove a cohort or selected members <name> [address]...\n")
 			fmt.Println("\nFields")
 			fmt.Printf("  alias.name            (string) account/token display name\n")
 			fmt.Printf("  alias.kind            (enum) e.g. validator, payout, token, oracle, issuer, registry, ...\n")
@@ -151,6 +154,12 @@ func run() error {
 		return removeAlias(ctx, client)
 	case "validate":
 		return validateAliases(ctx, client)
+	case "cohort-list":
+		return listCohorts(ctx)
+	case "cohort-add":
+		return addCohort(ctx)
+	case "cohort-remove":
+		return removeCohort(ctx)
 	default:
 		return fmt.Errorf("unkown command %s", cmd)
 	}
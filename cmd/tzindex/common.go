@@ -13,6 +13,7 @@ import (
 	"blockwatch.cc/packdb/pack"
 	"blockwatch.cc/tzindex/etl/index"
 	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/etl/replica"
 	"blockwatch.cc/tzindex/rpc"
 	"github.com/echa/config"
 )
@@ -104,9 +105,35 @@ func newRPCClient() (*rpc.Client, error) {
 	return rpcclient, nil
 }
 
-func enabledIndexes() []model.BlockIndexer {
+func replicaConfig(addr string) replica.Config {
+	return replica.Config{
+		Addr:        addr,
+		Retain:      config.GetInt("replication.retain"),
+		DialTimeout: config.GetDuration("replication.dial_timeout"),
+	}
+}
+
+func bigmapColumns() ([]model.BigmapColumn, error) {
+	cols := make([]model.BigmapColumn, 0)
+	for _, v := range config.GetStringSlice("db.bigmap_field.columns") {
+		c, err := model.ParseBigmapColumn(v)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+func enabledIndexes() ([]model.BlockIndexer, error) {
+	cols, err := bigmapColumns()
+	if err != nil {
+		return nil, err
+	}
+	bigmaps := index.NewBigmapIndex(tableOptions("bigmap"))
+	var list []model.BlockIndexer
 	if lightIndex {
-		return []model.BlockIndexer{
+		list = []model.BlockIndexer{
 			index.NewAccountIndex(tableOptions("account"), indexOptions("account")),
 			index.NewBalanceIndex(tableOptions("balance")),
 			index.NewContractIndex(tableOptions("contract"), indexOptions("contract")),
@@ -118,12 +145,12 @@ func enabledIndexes() []model.BlockIndexer {
 			index.NewFlowIndex(tableOptions("flow")),
 			index.NewChainIndex(tableOptions("chain")),
 			index.NewSupplyIndex(tableOptions("supply")),
-			index.NewBigmapIndex(tableOptions("bigmap")),
+			bigmaps,
 			index.NewMetadataIndex(tableOptions("metadata"), indexOptions("metadata")),
 			index.NewTicketIndex(tableOptions("ticket")),
 		}
 	} else {
-		return []model.BlockIndexer{
+		list = []model.BlockIndexer{
 			index.NewAccountIndex(tableOptions("account"), indexOptions("account")),
 			index.NewBalanceIndex(tableOptions("balance")),
 			index.NewContractIndex(tableOptions("contract"), indexOptions("contract")),
@@ -139,9 +166,31 @@ func enabledIndexes() []model.BlockIndexer {
 			index.NewSnapshotIndex(tableOptions("snapshot")),
 			index.NewIncomeIndex(tableOptions("income")),
 			index.NewGovIndex(tableOptions("gov")),
-			index.NewBigmapIndex(tableOptions("bigmap")),
+			index.NewConsensusKeyIndex(tableOptions("consensus_key")),
+			bigmaps,
 			index.NewMetadataIndex(tableOptions("metadata"), indexOptions("metadata")),
 			index.NewTicketIndex(tableOptions("ticket")),
 		}
 	}
+
+	// optional indexes, disabled unless enabled in config
+	if config.GetBool("db.contract_calls.enable") {
+		list = append(list, index.NewContractCallIndex(tableOptions("contract_calls")))
+	}
+	if config.GetBool("db.code_family.enable") {
+		list = append(list, index.NewCodeFamilyIndex(tableOptions("code_family")))
+	}
+	if config.GetBool("db.transfer_edge.enable") {
+		list = append(list, index.NewTransferEdgeIndex(tableOptions("transfer_edge"), indexOptions("transfer_edge")))
+	}
+	if config.GetBool("db.cohort.enable") {
+		list = append(list, index.NewCohortIndex(tableOptions("cohort")))
+	}
+	if config.GetBool("db.price.enable") {
+		list = append(list, index.NewPriceIndex(tableOptions("price")))
+	}
+	if config.GetBool("db.bigmap_field.enable") {
+		list = append(list, index.NewBigmapFieldIndex(tableOptions("bigmap_field"), bigmaps, cols))
+	}
+	return list, nil
 }
@@ -82,6 +82,13 @@ func init() {
     config.SetDefault("db.nosync", false)
     config.SetDefault("db.gc_ratio", 1.0)
     config.SetDefault("db.log_slow_queries", time.Second)
+    config.SetDefault("db.bigmap_field.enable", false)
+    config.SetDefault("db.bigmap_field.columns", []string{})
+    config.SetDefault("db.contract_calls.enable", false)
+    config.SetDefault("db.code_family.enable", false)
+    config.SetDefault("db.transfer_edge.enable", false)
+    config.SetDefault("db.cohort.enable", false)
+    config.SetDefault("db.price.enable", false)
 
     // crawling
     config.SetDefault("crawler.cache_size_log2", 15)
@@ -91,6 +98,12 @@ func init() {
     config.SetDefault("crawler.snapshot.blocks", nil)
     config.SetDefault("crawler.snapshot.interval", 0)
 
+    // replication
+    config.SetDefault("replication.listen", "")  // primary: publish blocks on this address
+    config.SetDefault("replication.primary", "") // replica: follow the primary at this address
+    config.SetDefault("replication.retain", 128) // blocks kept for replay
+    config.SetDefault("replication.dial_timeout", 10*time.Second)
+
     // HTTP API server
     config.SetDefault("server.addr", "127.0.0.1")
     config.SetDefault("server.port", 8000)
@@ -107,6 +120,18 @@ func init() {
     config.SetDefault("server.max_list_count", 500000)
     config.SetDefault("server.default_list_count", 500)
     config.SetDefault("server.max_series_duration", 0)
+    config.SetDefault("server.max_aggregate_groups", 100000)
+    config.SetDefault("server.max_aggregate_rows", 10000000)
+    config.SetDefault("server.max_sql_rows", 1000000)
+    config.SetDefault("server.max_sql_duration", 30*time.Second)
+    config.SetDefault("server.max_graphql_cost", 1000)
+    config.SetDefault("server.max_graphql_depth", 10)
+    config.SetDefault("server.max_batch_size", 1000)
+    config.SetDefault("server.response_cache_size", 0)
+    config.SetDefault("server.health_max_lag_blocks", 10)
+    config.SetDefault("server.health_max_lag_time", 5*time.Minute)
+    config.SetDefault("server.health_max_idle", 10*time.Minute)
+    config.SetDefault("server.health_max_queue", 90)
     config.SetDefault("server.max_explore_count", 100)
     config.SetDefault("server.default_explore_count", 20)
     config.SetDefault("server.cors_enable", false)
@@ -236,8 +261,10 @@ func parseFlags() error {
         if val != "" {
             log.Debugf("Flag %s=%s", key, val)
             config.Set(key, val)
+            cliFlags[key] = val
         } else {
             config.Set(key, true) // assume boolean flag
+            cliFlags[key] = true
         }
     }
     return nil
@@ -14,6 +14,7 @@ import (
 	"blockwatch.cc/tzindex/etl/index"
 	"blockwatch.cc/tzindex/etl/metadata"
 	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/etl/replica"
 	"blockwatch.cc/tzindex/rpc"
 	"blockwatch.cc/tzindex/server"
 	"blockwatch.cc/tzindex/server/explorer"
@@ -44,6 +45,7 @@ func init() {
 	model.UseLogger(blocLog)
 	index.UseLogger(blocLog)
 	metadata.UseLogger(blocLog)
+	replica.UseLogger(blocLog)
 	store.UseLogger(dataLog)
 	pack.UseLogger(dataLog)
 	rpc.UseLogger(jrpcLog)
@@ -80,15 +82,10 @@ func initLogging() {
 
 	// create loggers with configured backend
 	blocLog = logpkg.NewLogger("BLOC") // blockchain
-	blocLog.SetLevel(logpkg.ParseLevel(config.GetString("log.blockchain")))
 	dataLog = logpkg.NewLogger("DATA") // database
-	dataLog.SetLevel(logpkg.ParseLevel(config.GetString("log.db")))
 	jrpcLog = logpkg.NewLogger("JRPC") // json rpc client
-	jrpcLog.SetLevel(logpkg.ParseLevel(config.GetString("log.rpc")))
 	srvrLog = logpkg.NewLogger("SRVR") // api server
-	srvrLog.SetLevel(logpkg.ParseLevel(config.GetString("log.server")))
 	michLog = logpkg.NewLogger("MICH") // micheline
-	michLog.SetLevel(logpkg.ParseLevel(config.GetString("log.micheline")))
 
 	// assign default loggers
 	etl.UseLogger(blocLog)
@@ -96,6 +93,7 @@ func initLogging() {
 	model.UseLogger(blocLog)
 	index.UseLogger(blocLog)
 	metadata.UseLogger(blocLog)
+	replica.UseLogger(blocLog)
 	store.UseLogger(dataLog)
 	pack.UseLogger(dataLog)
 	rpc.UseLogger(jrpcLog)
@@ -118,6 +116,19 @@ func initLogging() {
 	// export to server for http control
 	system.LoggerMap = subsystemLoggers
 
+	initLogLevels()
+}
+
+// initLogLevels sets subsystem log levels from config and command line
+// flags. It is called again when the config file is reloaded.
+func initLogLevels() {
+	log.SetLevel(logpkg.ParseLevel(config.GetString("log.level")))
+	blocLog.SetLevel(logpkg.ParseLevel(config.GetString("log.blockchain")))
+	dataLog.SetLevel(logpkg.ParseLevel(config.GetString("log.db")))
+	jrpcLog.SetLevel(logpkg.ParseLevel(config.GetString("log.rpc")))
+	srvrLog.SetLevel(logpkg.ParseLevel(config.GetString("log.server")))
+	michLog.SetLevel(logpkg.ParseLevel(config.GetString("log.micheline")))
+
 	// handle cli flags
 	switch {
 	case vtrace:
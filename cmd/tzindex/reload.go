@@ -0,0 +1,156 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/metadata"
+	"blockwatch.cc/tzindex/server"
+	"blockwatch.cc/tzindex/server/system"
+	"github.com/echa/config"
+)
+
+var (
+	// command line config overrides, re-applied on reload
+	cliFlags = make(map[string]interface{})
+
+	reloadMutex sync.Mutex
+
+	// config keys (or key prefixes ending in '.' or '_') that are applied
+	// on reload, all other changes require a restart
+	reloadableKeys = []string{
+		"log.level",
+		"log.blockchain",
+		"log.db",
+		"log.rpc",
+		"log.server",
+		"log.micheline",
+		"db.log_slow_queries",
+		"db.gc_ratio",
+		"server.shutdown_timeout",
+		"server.default_list_count",
+		"server.max_list_count",
+		"server.default_explore_count",
+		"server.max_explore_count",
+		"server.max_series_duration",
+		"server.max_aggregate_",
+		"server.max_sql_",
+		"server.max_graphql_",
+		"server.max_batch_size",
+		"server.cors_",
+		"server.cache_",
+		"server.health_",
+		"crawler.snapshot_",
+		"crawler.snapshot.",
+		"metadata.",
+	}
+)
+
+func isReloadable(key string) bool {
+	for _, k := range reloadableKeys {
+		if key == k {
+			return true
+		}
+		if strings.HasSuffix(k, ".") || strings.HasSuffix(k, "_") {
+			if strings.HasPrefix(key, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reloadConfig re-reads the config file and applies all settings that can
+// change at runtime. Changed settings that require a restart are reported.
+// Command line overrides remain in effect. API handlers only see settings
+// through the snapshot passed to srv.Reconfigure and never read the global
+// config store which is not safe for concurrent updates.
+func reloadConfig(srv *server.RestServer, crawler *etl.Crawler) (*system.ConfigReport, error) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	name := config.ConfigName()
+	fresh := config.NewConfig().SetConfigName(name).UseEnv(false)
+	if err := fresh.MustReadConfigFile(); err != nil {
+		return nil, err
+	}
+	for k, v := range cliFlags {
+		fresh.Set(k, v)
+	}
+
+	// replace config file contents and determine changed keys
+	before := flattenConfig("", config.All(), nil)
+	config.SetConfigName(name).Use(fresh.All())
+	after := flattenConfig("", config.All(), nil)
+
+	report := &system.ConfigReport{
+		Applied: make([]string, 0),
+		Restart: make([]string, 0),
+	}
+	for _, key := range diffConfig(before, after) {
+		if isReloadable(key) {
+			report.Applied = append(report.Applied, key)
+		} else {
+			report.Restart = append(report.Restart, key)
+		}
+	}
+
+	// apply runtime settings
+	initLogLevels()
+	pack.QueryLogMinDuration = config.GetDuration("db.log_slow_queries")
+	if err := metadata.LoadExtensions(); err != nil {
+		return nil, err
+	}
+	crawler.SetSnapshotConfig(snapshotConfig())
+	if srv != nil {
+		if err := srv.Reconfigure(httpConfig(), runtimeConfig()); err != nil {
+			return nil, err
+		}
+		srv.PurgeResponseCache()
+	}
+
+	log.Infof("Reloaded config file %s, %d settings changed.", name, len(report.Applied)+len(report.Restart))
+	if len(report.Restart) > 0 {
+		log.Warnf("Changed settings require a restart: %s", strings.Join(report.Restart, ", "))
+	}
+	return report, nil
+}
+
+// flattenConfig converts a config tree into a map of dotted keys.
+func flattenConfig(prefix string, tree map[string]interface{}, flat map[string]interface{}) map[string]interface{} {
+	if flat == nil {
+		flat = make(map[string]interface{})
+	}
+	for k, v := range tree {
+		if sub, ok := v.(map[string]interface{}); ok {
+			flattenConfig(prefix+k+".", sub, flat)
+		} else {
+			flat[prefix+k] = v
+		}
+	}
+	return flat
+}
+
+// diffConfig returns the sorted list of keys with different values.
+func diffConfig(a, b map[string]interface{}) []string {
+	keys := make([]string, 0)
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !reflect.DeepEqual(v, w) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,8 +16,10 @@ import (
 	"blockwatch.cc/packdb/store"
 	"blockwatch.cc/tzindex/etl"
 	"blockwatch.cc/tzindex/etl/metadata"
+	"blockwatch.cc/tzindex/etl/replica"
 	"blockwatch.cc/tzindex/rpc"
 	"blockwatch.cc/tzindex/server"
+	"blockwatch.cc/tzindex/server/system"
 	"github.com/echa/config"
 )
 
@@ -62,30 +65,47 @@ func runServer() error {
 	}
 	defer statedb.Close()
 
-	// open RPC client when requested
+	// open RPC client when requested, replicas receive table changes from
+	// the primary instead of crawling
 	var rpcclient *rpc.Client
-	if !norpc {
+	primary := config.GetString("replication.primary")
+	switch {
+	case norpc:
+		noindex = true
+	case primary != "":
+		noindex = true
+		log.Infof("Running as replica of %s", primary)
+	default:
 		rpcclient, err = newRPCClient()
 		if err != nil {
 			return err
 		}
-	} else {
-		noindex = true
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// enable index storage tables
+	indexes, err := enabledIndexes()
+	if err != nil {
+		return err
+	}
 	indexer := etl.NewIndexer(etl.IndexerConfig{
 		DBPath:    pathname,
 		DBOpts:    DBOpts(engine, false, unsafe),
 		StateDB:   statedb,
-		Indexes:   enabledIndexes(),
+		Indexes:   indexes,
 		LightMode: lightIndex,
 	})
 	defer indexer.Close()
 
+	// record table changes for replicas from the first block on
+	var publisher *replica.Publisher
+	if addr := config.GetString("replication.listen"); addr != "" && !noindex {
+		publisher = replica.NewPublisher(replicaConfig(addr))
+		indexer.AddChangeListener(publisher.Publish)
+	}
+
 	crawler := etl.NewCrawler(etl.CrawlerConfig{
 		DB:            statedb,
 		Indexer:       indexer,
@@ -96,11 +116,7 @@ func runServer() error {
 		EnableMonitor: !nomonitor,
 		StopBlock:     stop,
 		Validate:      validate,
-		Snapshot: &etl.SnapshotConfig{
-			Path:          config.GetString("crawler.snapshot_path"),
-			Blocks:        config.GetInt64Slice("crawler.snapshot_blocks"),
-			BlockInterval: config.GetInt64("crawler.snapshot_interval"),
-		},
+		Snapshot:      snapshotConfig(),
 	})
 	// not indexing means we do not auto-index, but allow access to
 	// existing indexes
@@ -108,47 +124,34 @@ func runServer() error {
 		if err := crawler.Init(ctx, etl.MODE_SYNC); err != nil {
 			return fmt.Errorf("error initializing crawler: %v", err)
 		}
+		if publisher != nil {
+			if err := publisher.Start(crawler.Tip()); err != nil {
+				return err
+			}
+			defer publisher.Stop()
+		}
 		crawler.Start()
 		defer crawler.Stop(ctx)
 	} else {
 		if err := crawler.Init(ctx, etl.MODE_INFO); err != nil {
 			return fmt.Errorf("error initializing crawler: %v", err)
 		}
+		if primary != "" {
+			follower := replica.NewFollower(replicaConfig(primary), crawler)
+			follower.Start()
+			defer follower.Stop()
+		}
 	}
 
 	// setup HTTP server
+	var srv *server.RestServer
 	if !noapi {
-		srv, err := server.New(&server.Config{
+		srv, err = server.New(&server.Config{
 			Crawler: crawler,
 			Indexer: indexer,
 			Client:  rpcclient,
-			Http: server.HttpConfig{
-				Addr:                config.GetString("server.addr"),
-				Port:                config.GetInt("server.port"),
-				MaxWorkers:          config.GetInt("server.workers"),
-				MaxQueue:            config.GetInt("server.queue"),
-				ReadTimeout:         config.GetDuration("server.read_timeout"),
-				HeaderTimeout:       config.GetDuration("server.header_timeout"),
-				WriteTimeout:        config.GetDuration("server.write_timeout"),
-				KeepAlive:           config.GetDuration("server.keepalive"),
-				ShutdownTimeout:     config.GetDuration("server.shutdown_timeout"),
-				DefaultListCount:    config.GetUint("server.default_list_count"),
-				MaxListCount:        config.GetUint("server.max_list_count"),
-				DefaultExploreCount: config.GetUint("server.default_explore_count"),
-				MaxExploreCount:     config.GetUint("server.max_explore_count"),
-				CorsEnable:          cors || config.GetBool("server.cors_enable"),
-				CorsOrigin:          config.GetString("server.cors_origin"),
-				CorsAllowHeaders:    config.GetString("server.cors_allow_headers"),
-				CorsExposeHeaders:   config.GetString("server.cors_expose_headers"),
-				CorsMethods:         config.GetString("server.cors_methods"),
-				CorsMaxAge:          config.GetString("server.cors_maxage"),
-				CorsCredentials:     config.GetString("server.cors_credentials"),
-				CacheEnable:         config.GetBool("server.cache_enable"),
-				CacheControl:        config.GetString("server.cache_control"),
-				CacheExpires:        config.GetDuration("server.cache_expires"),
-				CacheMaxExpires:     config.GetDuration("server.cache_max"),
-				MaxSeriesDuration:   config.GetDuration("server.max_series_duration"),
-			},
+			Http:    httpConfig(),
+			Runtime: runtimeConfig(),
 		})
 		if err != nil {
 			return err
@@ -158,6 +161,10 @@ func runServer() error {
 		defer srv.Stop()
 	}
 
+	// reload config on SIGHUP or API request, stop on all other signals
+	system.ConfigReloader = func() (*system.ConfigReport, error) {
+		return reloadConfig(srv, crawler)
+	}
 	c := make(chan os.Signal, 1)
 	signal.Notify(c,
 		syscall.SIGHUP,
@@ -165,7 +172,82 @@ func runServer() error {
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
 	)
-	<-c
+	for sig := range c {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if _, err := system.ConfigReloader(); err != nil {
+			log.Errorf("Config reload failed: %v", err)
+		}
+	}
 	signal.Stop(c)
 	return nil
 }
+
+func httpConfig() server.HttpConfig {
+	return server.HttpConfig{
+		Addr:                config.GetString("server.addr"),
+		Port:                config.GetInt("server.port"),
+		MaxWorkers:          config.GetInt("server.workers"),
+		MaxQueue:            config.GetInt("server.queue"),
+		ReadTimeout:         config.GetDuration("server.read_timeout"),
+		HeaderTimeout:       config.GetDuration("server.header_timeout"),
+		WriteTimeout:        config.GetDuration("server.write_timeout"),
+		KeepAlive:           config.GetDuration("server.keepalive"),
+		ShutdownTimeout:     config.GetDuration("server.shutdown_timeout"),
+		DefaultListCount:    config.GetUint("server.default_list_count"),
+		MaxListCount:        config.GetUint("server.max_list_count"),
+		DefaultExploreCount: config.GetUint("server.default_explore_count"),
+		MaxExploreCount:     config.GetUint("server.max_explore_count"),
+		CorsEnable:          cors || config.GetBool("server.cors_enable"),
+		CorsOrigin:          config.GetString("server.cors_origin"),
+		CorsAllowHeaders:    config.GetString("server.cors_allow_headers"),
+		CorsExposeHeaders:   config.GetString("server.cors_expose_headers"),
+		CorsMethods:         config.GetString("server.cors_methods"),
+		CorsMaxAge:          config.GetString("server.cors_maxage"),
+		CorsCredentials:     config.GetString("server.cors_credentials"),
+		CacheEnable:         config.GetBool("server.cache_enable"),
+		CacheControl:        config.GetString("server.cache_control"),
+		CacheExpires:        config.GetDuration("server.cache_expires"),
+		CacheMaxExpires:     config.GetDuration("server.cache_max"),
+		MaxSeriesDuration:   config.GetDuration("server.max_series_duration"),
+		MaxAggregateGroups:  config.GetInt("server.max_aggregate_groups"),
+		MaxAggregateRows:    config.GetInt("server.max_aggregate_rows"),
+		MaxSqlRows:          config.GetInt("server.max_sql_rows"),
+		MaxSqlDuration:      config.GetDuration("server.max_sql_duration"),
+		MaxGraphQLCost:      config.GetInt("server.max_graphql_cost"),
+		MaxGraphQLDepth:     config.GetInt("server.max_graphql_depth"),
+		MaxBatchSize:        config.GetInt("server.max_batch_size"),
+		ResponseCacheSize:   config.GetInt("server.response_cache_size"),
+		HealthMaxLagBlocks:  config.GetInt("server.health_max_lag_blocks"),
+		HealthMaxLagTime:    config.GetDuration("server.health_max_lag_time"),
+		HealthMaxIdle:       config.GetDuration("server.health_max_idle"),
+		HealthMaxQueue:      config.GetInt("server.health_max_queue"),
+	}
+}
+
+func snapshotConfig() *etl.SnapshotConfig {
+	return &etl.SnapshotConfig{
+		Path:          config.GetString("crawler.snapshot_path"),
+		Blocks:        config.GetInt64Slice("crawler.snapshot_blocks"),
+		BlockInterval: config.GetInt64("crawler.snapshot_interval"),
+	}
+}
+
+func runtimeConfig() server.RuntimeConfig {
+	// copy the config tree, handlers must not share maps with the config store
+	values := make(map[string]interface{})
+	if buf, err := json.Marshal(config.All()); err == nil {
+		_ = json.Unmarshal(buf, &values)
+	}
+	return server.RuntimeConfig{
+		DatabasePath:     config.GetString("db.path"),
+		DatabaseGCRatio:  config.GetFloat64("db.gc_ratio"),
+		SnapshotPath:     config.GetString("crawler.snapshot_path"),
+		MetadataValidate: config.GetBool("metadata.validate"),
+		MetaTitleSuffix:  config.GetString("metadata.describe.title_suffix"),
+		MetaImageUrl:     config.GetString("metadata.describe.image_url"),
+		MetaLogo:         config.GetString("metadata.describe.logo"),
+		Values:           values,
+	}
+}
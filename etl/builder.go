@@ -796,7 +796,7 @@ func (b *Builder) InitAccounts(ctx context.Context) error {
 
 	// bulk insert to generate ids
 	if len(newacc) > 0 {
-		err := table.Insert(ctx, newacc)
+		err := model.Insert(ctx, table, newacc)
 		if err != nil {
 			return err
 		}
@@ -835,17 +835,18 @@ func (b *Builder) InitAccounts(ctx context.Context) error {
 		b.block.ProposerConsensusKeyId = b.block.BakerId
 	}
 
-	if b.block.ProposerId != b.block.ProposerConsensusKeyId {
-		acc, ok := b.AccountById(b.block.ProposerConsensusKeyId)
+	// resolve active consensus keys when they differ from the baker's own key
+	if addr := b.block.TZ.Block.Metadata.ProposerConsensusKey; addr.IsValid() && b.block.Proposer != nil && !addr.Equal(b.block.Proposer.Address) {
+		acc, ok := b.AccountByAddress(addr)
 		if !ok {
-			return fmt.Errorf("missing proposer consensus key account %d", b.block.ProposerConsensusKeyId)
+			return fmt.Errorf("missing proposer consensus key account %s", addr)
 		}
 		b.block.ProposerConsensusKeyId = acc.RowId
 	}
-	if b.block.BakerId != b.block.BakerConsensusKeyId {
-		acc, ok := b.AccountById(b.block.BakerConsensusKeyId)
+	if addr := b.block.TZ.Block.Metadata.BakerConsensusKey; addr.IsValid() && b.block.Baker != nil && !addr.Equal(b.block.Baker.Address) {
+		acc, ok := b.AccountByAddress(addr)
 		if !ok {
-			return fmt.Errorf("missing baker consensus key account %d", b.block.BakerConsensusKeyId)
+			return fmt.Errorf("missing baker consensus key account %s", addr)
 		}
 		b.block.BakerConsensusKeyId = acc.RowId
 	}
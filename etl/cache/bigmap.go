@@ -122,33 +122,40 @@ func (h BigmapHistory) Get(key tezos.ExprHash) *model.BigmapKV {
 	}
 }
 
+// Range returns items in the half-open interval [from, to).
 func (h BigmapHistory) Range(from, to int) []*model.BigmapKV {
-	if to < 0 || to >= h.Len() {
-		to = h.Len() - 1
+	if from < 0 {
+		from = 0
+	}
+	if to < 0 || to > h.Len() {
+		to = h.Len()
 	}
 	if to <= from {
 		return nil
 	}
 	items := make([]*model.BigmapKV, to-from)
-	for i := 0; i < len(items); i++ {
-		kStart, vStart := int(h.KeyOffsets[i+from]), int(h.ValueOffsets[i+from])
-		kEnd, vEnd := vStart, len(h.Data)
-		if i+from < len(h.KeyOffsets) {
-			vEnd = int(h.KeyOffsets[i+from+1])
-		}
-
-		// log.Infof("Item %d: key [%d:%d] value[%d:%d] max=%d", i, kStart, kEnd, vStart, vEnd, len(h.Data))
-		items[i] = &model.BigmapKV{
-			RowId:    uint64(i + from + 1),
-			BigmapId: h.BigmapId,
-			KeyId:    model.GetKeyId(h.BigmapId, micheline.KeyHash(h.Data[kStart:kEnd])),
-			Key:      h.Data[kStart:kEnd],
-			Value:    h.Data[vStart:vEnd],
-		}
+	for i := range items {
+		items[i] = h.At(i + from)
 	}
 	return items
 }
 
+// At returns the item at position i, row ids start at 1.
+func (h BigmapHistory) At(i int) *model.BigmapKV {
+	kStart, vStart := int(h.KeyOffsets[i]), int(h.ValueOffsets[i])
+	kEnd, vEnd := vStart, len(h.Data)
+	if i+1 < len(h.KeyOffsets) {
+		vEnd = int(h.KeyOffsets[i+1])
+	}
+	return &model.BigmapKV{
+		RowId:    uint64(i + 1),
+		BigmapId: h.BigmapId,
+		KeyId:    model.GetKeyId(h.BigmapId, micheline.KeyHash(h.Data[kStart:kEnd])),
+		Key:      h.Data[kStart:kEnd],
+		Value:    h.Data[vStart:vEnd],
+	}
+}
+
 type BigmapHistoryCache struct {
 	cache *lru.TwoQueueCache // key := int64(bigmap_id<<32 & height)
 	size  int64
@@ -0,0 +1,59 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package cache
+
+import (
+	"bytes"
+	"testing"
+)
+
+// history with keys k0..k2 and values v0..v2
+func testHistory() BigmapHistory {
+	h := BigmapHistory{BigmapId: 1}
+	for _, v := range []string{"0", "1", "2"} {
+		h.KeyOffsets = append(h.KeyOffsets, uint32(len(h.Data)))
+		h.Data = append(h.Data, []byte("k"+v)...)
+		h.ValueOffsets = append(h.ValueOffsets, uint32(len(h.Data)))
+		h.Data = append(h.Data, []byte("v"+v)...)
+	}
+	return h
+}
+
+func TestBigmapHistoryRange(t *testing.T) {
+	h := testHistory()
+	tests := []struct {
+		name     string
+		from, to int
+		keys     []string
+	}{
+		{"all", 0, h.Len(), []string{"k0", "k1", "k2"}},
+		{"negative to", 0, -1, []string{"k0", "k1", "k2"}},
+		{"beyond end", 1, 10, []string{"k1", "k2"}},
+		{"first", 0, 1, []string{"k0"}},
+		{"last", 2, 3, []string{"k2"}},
+		{"middle", 1, 2, []string{"k1"}},
+		{"negative from", -1, 2, []string{"k0", "k1"}},
+		{"empty", 2, 2, nil},
+		{"inverted", 3, 1, nil},
+	}
+	for _, test := range tests {
+		items := h.Range(test.from, test.to)
+		if len(items) != len(test.keys) {
+			t.Errorf("%s: got %d items, want %d", test.name, len(items), len(test.keys))
+			continue
+		}
+		for i, item := range items {
+			if !bytes.Equal(item.Key, []byte(test.keys[i])) {
+				t.Errorf("%s: item %d key %q, want %q", test.name, i, item.Key, test.keys[i])
+			}
+			val := "v" + test.keys[i][1:]
+			if !bytes.Equal(item.Value, []byte(val)) {
+				t.Errorf("%s: item %d value %q, want %q", test.name, i, item.Value, val)
+			}
+			if want := uint64(test.from + i + 1); test.from >= 0 && item.RowId != want {
+				t.Errorf("%s: item %d row id %d, want %d", test.name, i, item.RowId, want)
+			}
+		}
+	}
+}
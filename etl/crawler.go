@@ -102,6 +102,7 @@ type Crawler struct {
 
 	// read-mostly thread-safe access
 	tipStore atomic.Value
+	tipTime  atomic.Value // wall clock time of last tip update
 
 	// coordinated shutdown
 	quit   chan struct{}
@@ -148,6 +149,27 @@ func (c *Crawler) Time() time.Time {
 
 func (c *Crawler) updateTip(tip *model.ChainTip) {
 	c.tipStore.Store(tip)
+	c.tipTime.Store(time.Now().UTC())
+}
+
+// LastUpdate returns the wall clock time when the indexed tip last changed.
+func (c *Crawler) LastUpdate() time.Time {
+	t, _ := c.tipTime.Load().(time.Time)
+	return t
+}
+
+// Lag returns the distance in blocks and block time between the indexed tip
+// and the most recent node head. Blocks is -1 when the node head is unknown.
+func (c *Crawler) Lag() (int64, time.Duration) {
+	head := c.bchead
+	if head == nil || head.Level == 0 {
+		return -1, 0
+	}
+	tip := c.Tip()
+	if head.Level <= tip.BestHeight {
+		return 0, 0
+	}
+	return head.Level - tip.BestHeight, head.Timestamp.Sub(tip.BestTime)
 }
 
 func (c *Crawler) IsHealthy() bool {
@@ -388,6 +410,8 @@ func (c *Crawler) Init(ctx context.Context, mode Mode) error {
 
 	// fetch and index genesis block
 	if firstRun {
+		// record genesis table writes for change listeners
+		ctx = c.indexer.recordChanges(ctx)
 		log.Info("Fetching genesis block.")
 		tzblock, err := c.fetchBlock(ctx, rpc.Genesis)
 		if err != nil {
@@ -427,6 +451,7 @@ func (c *Crawler) Init(ctx context.Context, mode Mode) error {
 			newTip.ChainId = genesis.Params.ChainId
 			newTip.AddDeployment(genesis.Params)
 			c.updateTip(newTip)
+			c.indexer.notifyListeners(genesis, c.builder, newTip, true)
 			c.chainId = genesis.Params.ChainId.Clone()
 		}
 
@@ -823,9 +848,10 @@ func (c *Crawler) syncBlockchain() {
 	c.wg.Add(1)
 	defer c.wg.Done()
 
-	// derive private context
+	// derive private context, block processing records table writes for
+	// change listeners
 	c.ctx, c.cancel = context.WithCancel(context.Background())
-	ctx := c.ctx
+	ctx := c.indexer.recordChanges(c.ctx)
 	defer c.cancel()
 	tip := c.Tip()
 
@@ -841,7 +867,7 @@ func (c *Crawler) syncBlockchain() {
 
 	var (
 		tzblock    *rpc.Bundle
-		ctxNonStop = context.Background()
+		ctxNonStop = c.indexer.recordChanges(context.Background())
 	)
 
 	log.Infof("Starting blockchain sync from height %d.", tip.BestHeight+1)
@@ -1007,6 +1033,7 @@ func (c *Crawler) syncBlockchain() {
 
 		// update chainstate with new version
 		c.updateTip(newTip)
+		c.indexer.notifyListeners(block, c.builder, newTip, true)
 		tip = newTip
 
 		//
@@ -223,17 +223,17 @@ func (idx *AccountIndex) ConnectBlock(ctx context.Context, block *model.Block, b
 	}
 	// send to tables
 	if len(accupd) > 0 {
-		if err := idx.accounts.Update(ctx, accupd); err != nil {
+		if err := model.Update(ctx, idx.accounts, accupd); err != nil {
 			return err
 		}
 	}
 	if len(bkrins) > 0 {
-		if err := idx.bakers.Insert(ctx, bkrins); err != nil {
+		if err := model.Insert(ctx, idx.bakers, bkrins); err != nil {
 			return err
 		}
 	}
 	if len(bkrupd) > 0 {
-		if err := idx.bakers.Update(ctx, bkrupd); err != nil {
+		if err := model.Update(ctx, idx.bakers, bkrupd); err != nil {
 			return err
 		}
 	}
@@ -274,7 +274,7 @@ func (idx *AccountIndex) DisconnectBlock(ctx context.Context, block *model.Block
 		// remove duplicates and sort; returns new slice
 		accdel = vec.UniqueUint64Slice(accdel)
 		// log.Debugf("Rollback removing accounts %#v", del)
-		if err := idx.accounts.DeleteIds(ctx, accdel); err != nil {
+		if err := model.DeleteIds(ctx, idx.accounts, accdel); err != nil {
 			return err
 		}
 	}
@@ -282,7 +282,7 @@ func (idx *AccountIndex) DisconnectBlock(ctx context.Context, block *model.Block
 		// remove duplicates and sort; returns new slice
 		bkrdel = vec.UniqueUint64Slice(bkrdel)
 		// log.Debugf("Rollback removing accounts %#v", del)
-		if err := idx.bakers.DeleteIds(ctx, bkrdel); err != nil {
+		if err := model.DeleteIds(ctx, idx.bakers, bkrdel); err != nil {
 			return err
 		}
 	}
@@ -293,13 +293,13 @@ func (idx *AccountIndex) DisconnectBlock(ctx context.Context, block *model.Block
 
 	// update accounts
 	if len(accupd) > 0 {
-		if err := idx.accounts.Update(ctx, accupd); err != nil {
+		if err := model.Update(ctx, idx.accounts, accupd); err != nil {
 			return err
 		}
 	}
 	// update bakers
 	if len(bkrupd) > 0 {
-		if err := idx.bakers.Update(ctx, bkrupd); err != nil {
+		if err := model.Update(ctx, idx.bakers, bkrupd); err != nil {
 			return err
 		}
 	}
@@ -308,17 +308,13 @@ func (idx *AccountIndex) DisconnectBlock(ctx context.Context, block *model.Block
 
 func (idx *AccountIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting accounts at height %d", height)
-	_, err := pack.NewQuery("etl.account.delete").
-		WithTable(idx.accounts).
-		AndEqual("first_seen", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.accounts, pack.NewQuery("etl.account.delete").
+		AndEqual("first_seen", height))
 	if err != nil {
 		return err
 	}
-	_, err = pack.NewQuery("etl.baker.delete").
-		WithTable(idx.bakers).
-		AndEqual("baker_since", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.bakers, pack.NewQuery("etl.baker.delete").
+		AndEqual("baker_since", height))
 	return err
 }
 
@@ -153,7 +153,7 @@ func (idx *BalanceIndex) ConnectBlock(ctx context.Context, block *model.Block, b
 
     // insert sorted values
     sort.Slice(ins, func(i, j int) bool { return ins[i].(*model.Balance).AccountId < ins[j].(*model.Balance).AccountId })
-    return idx.table.Insert(ctx, ins)
+    return model.Insert(ctx, idx.table, ins)
 }
 
 func (idx *BalanceIndex) DisconnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
@@ -161,10 +161,8 @@ func (idx *BalanceIndex) DisconnectBlock(ctx context.Context, block *model.Block
 }
 
 func (idx *BalanceIndex) DeleteBlock(ctx context.Context, height int64) error {
-    _, err := pack.NewQuery("etl.balance.delete").
-        WithTable(idx.table).
-        AndEqual("valid_from", height).
-        Delete(ctx)
+    _, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.balance.delete").
+        AndEqual("valid_from", height))
     return err
 }
 
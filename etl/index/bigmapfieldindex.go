@@ -0,0 +1,345 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	BigmapFieldPackSizeLog2    = 15 // 32k packs
+	BigmapFieldJournalSizeLog2 = 16 // 64k
+	BigmapFieldCacheSize       = 16
+	BigmapFieldFillLevel       = 100
+
+	BigmapFieldIndexKey = "bigmap_field"
+	BigmapFieldTableKey = "bigmap_fields"
+)
+
+// BigmapFieldIndex stores configured decoded key/value paths of live bigmap
+// entries as secondary columns. The index mirrors the current state of the
+// bigmap_values table and must run after the bigmap index. Columns are
+// built from existing live values on start-up, so adding a column to config
+// takes effect without reindexing.
+type BigmapFieldIndex struct {
+	db      *pack.DB
+	opts    pack.Options
+	table   *pack.Table
+	bigmaps *BigmapIndex
+	columns map[int64][]string // bigmap id -> paths
+}
+
+var _ model.BlockIndexer = (*BigmapFieldIndex)(nil)
+
+func NewBigmapFieldIndex(opts pack.Options, bigmaps *BigmapIndex, cols []model.BigmapColumn) *BigmapFieldIndex {
+	idx := &BigmapFieldIndex{
+		opts:    opts,
+		bigmaps: bigmaps,
+		columns: make(map[int64][]string),
+	}
+	for _, c := range cols {
+		idx.columns[c.BigmapId] = append(idx.columns[c.BigmapId], c.Path)
+	}
+	return idx
+}
+
+func (idx *BigmapFieldIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *BigmapFieldIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.table}
+}
+
+func (idx *BigmapFieldIndex) Key() string {
+	return BigmapFieldIndexKey
+}
+
+func (idx *BigmapFieldIndex) Name() string {
+	return BigmapFieldIndexKey + " index"
+}
+
+// HasColumn returns true when path is stored for bigmap id.
+func (idx *BigmapFieldIndex) HasColumn(id int64, path string) bool {
+	for _, v := range idx.columns[id] {
+		if v == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *BigmapFieldIndex) Create(path, label string, opts interface{}) error {
+	fields, err := pack.Fields(model.BigmapField{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		BigmapFieldTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, BigmapFieldPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, BigmapFieldJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, BigmapFieldCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, BigmapFieldFillLevel),
+		})
+	return err
+}
+
+func (idx *BigmapFieldIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.table, err = idx.db.Table(
+		BigmapFieldTableKey,
+		pack.Options{
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, BigmapFieldJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, BigmapFieldCacheSize),
+		},
+	)
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	if err := idx.syncColumns(context.Background()); err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+// syncColumns drops stored columns no longer configured and builds newly
+// configured columns from live bigmap values.
+func (idx *BigmapFieldIndex) syncColumns(ctx context.Context) error {
+	stored := make(map[model.BigmapColumn]struct{})
+	drop := make([]uint64, 0)
+	field := &model.BigmapField{}
+	err := pack.NewQuery("etl.bigmap_field.scan").
+		WithTable(idx.table).
+		WithFields("I", "B", "p").
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(field); err != nil {
+				return err
+			}
+			if !idx.HasColumn(field.BigmapId, field.Path) {
+				drop = append(drop, field.RowId)
+				return nil
+			}
+			stored[model.BigmapColumn{BigmapId: field.BigmapId, Path: field.Path}] = struct{}{}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	if len(drop) > 0 {
+		log.Infof("Dropping %d values of removed bigmap columns.", len(drop))
+		if err := model.DeleteIds(ctx, idx.table, drop); err != nil {
+			return err
+		}
+	}
+	for id, paths := range idx.columns {
+		build := make([]string, 0)
+		for _, p := range paths {
+			if _, ok := stored[model.BigmapColumn{BigmapId: id, Path: p}]; !ok {
+				build = append(build, p)
+			}
+		}
+		if len(build) == 0 {
+			continue
+		}
+		log.Infof("Building bigmap %d columns %v.", id, build)
+		if err := idx.rebuildBigmap(ctx, id, build); err != nil {
+			return err
+		}
+	}
+	return idx.table.Flush(ctx)
+}
+
+func (idx *BigmapFieldIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *BigmapFieldIndex) Close() error {
+	if idx.table != nil {
+		if err := idx.table.Close(); err != nil {
+			log.Errorf("Closing %s: %s", idx.Name(), err)
+		}
+		idx.table = nil
+	}
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+// ConnectBlock refreshes stored fields of all keys in configured bigmaps
+// the block has touched. The bigmap index has already updated live values.
+func (idx *BigmapFieldIndex) ConnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	return idx.refresh(ctx, block)
+}
+
+// DisconnectBlock refreshes fields from live values after the bigmap index
+// has rolled them back.
+func (idx *BigmapFieldIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	return idx.refresh(ctx, block)
+}
+
+// DeleteBlock has no access to the block's bigmap events, so all configured
+// columns are rebuilt from live values.
+func (idx *BigmapFieldIndex) DeleteBlock(ctx context.Context, height int64) error {
+	for id, paths := range idx.columns {
+		if err := idx.rebuildBigmap(ctx, id, paths); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *BigmapFieldIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	return nil
+}
+
+func (idx *BigmapFieldIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *BigmapFieldIndex) refresh(ctx context.Context, block *model.Block) error {
+	if len(idx.columns) == 0 {
+		return nil
+	}
+
+	// collect touched keys, a nil set marks the entire bigmap
+	touched := make(map[int64]map[uint64]struct{})
+	for _, op := range block.Ops {
+		if len(op.BigmapEvents) == 0 || !op.IsSuccess {
+			continue
+		}
+		for _, diff := range op.BigmapEvents {
+			id := diff.Id
+			if diff.Action == micheline.DiffActionCopy {
+				id = diff.DestId
+			}
+			if _, ok := idx.columns[id]; !ok {
+				continue
+			}
+			keys, ok := touched[id]
+			if ok && keys == nil {
+				continue
+			}
+			switch {
+			case diff.Action == micheline.DiffActionUpdate,
+				diff.Action == micheline.DiffActionRemove && diff.KeyHash.IsValid():
+				if keys == nil {
+					keys = make(map[uint64]struct{})
+					touched[id] = keys
+				}
+				keys[model.GetKeyId(id, diff.KeyHash)] = struct{}{}
+			default:
+				touched[id] = nil
+			}
+		}
+	}
+
+	for id, keys := range touched {
+		if keys == nil {
+			if err := idx.rebuildBigmap(ctx, id, idx.columns[id]); err != nil {
+				return err
+			}
+			continue
+		}
+		ids := make([]uint64, 0, len(keys))
+		for k := range keys {
+			ids = append(ids, k)
+		}
+		if err := idx.rebuildKeys(ctx, id, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildBigmap replaces all stored fields for paths of bigmap id.
+func (idx *BigmapFieldIndex) rebuildBigmap(ctx context.Context, id int64, paths []string) error {
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.bigmap_field.delete").
+		AndEqual("bigmap_id", id).
+		AndIn("path", paths))
+	if err != nil {
+		return fmt.Errorf("bigmap_field: delete bigmap %d: %w", id, err)
+	}
+	q := pack.NewQuery("etl.bigmap_field.build").
+		WithTable(idx.bigmaps.valueTable).
+		AndEqual("bigmap_id", id)
+	return idx.insertFields(ctx, id, paths, q)
+}
+
+// rebuildKeys replaces stored fields of the given keys in bigmap id.
+func (idx *BigmapFieldIndex) rebuildKeys(ctx context.Context, id int64, keys []uint64) error {
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.bigmap_field.delete").
+		AndEqual("bigmap_id", id).
+		AndIn("key_id", keys))
+	if err != nil {
+		return fmt.Errorf("bigmap_field: delete keys in bigmap %d: %w", id, err)
+	}
+	q := pack.NewQuery("etl.bigmap_field.update").
+		WithTable(idx.bigmaps.valueTable).
+		AndEqual("bigmap_id", id).
+		AndIn("key_id", keys)
+	return idx.insertFields(ctx, id, idx.columns[id], q)
+}
+
+func (idx *BigmapFieldIndex) insertFields(ctx context.Context, id int64, paths []string, q pack.Query) error {
+	alloc, err := idx.bigmaps.loadAlloc(ctx, id)
+	if err != nil {
+		return fmt.Errorf("bigmap_field: %w", err)
+	}
+	if alloc.RowId == 0 {
+		// bigmap does not exist (yet)
+		return nil
+	}
+	keyType, valueType := alloc.GetKeyType(), alloc.GetValueType()
+	ins := make([]pack.Item, 0)
+	err = q.Stream(ctx, func(r pack.Row) error {
+		kv := &model.BigmapKV{}
+		if err := r.Decode(kv); err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if f := model.NewBigmapField(kv, p, keyType, valueType); f != nil {
+				ins = append(ins, f)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bigmap_field: scan bigmap %d: %w", id, err)
+	}
+	if len(ins) > 0 {
+		if err := model.Insert(ctx, idx.table, ins); err != nil {
+			return fmt.Errorf("bigmap_field: insert: %w", err)
+		}
+	}
+	return nil
+}
@@ -284,13 +284,13 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				} else {
 					// alloc real bigmap
 					alloc := model.NewBigmapAlloc(op, diff)
-					if err := idx.allocTable.Insert(ctx, alloc); err != nil {
+					if err := model.Insert(ctx, idx.allocTable, alloc); err != nil {
 						return fmt.Errorf("etl.bigmap_alloc.insert: %v", err)
 					}
 					idx.allocCache.Add(alloc.BigmapId, alloc)
 
 					// store as update
-					if err := idx.updateTable.Insert(ctx, alloc.ToUpdate(op)); err != nil {
+					if err := model.Insert(ctx, idx.updateTable, alloc.ToUpdate(op)); err != nil {
 						return fmt.Errorf("etl.bigmap_alloc.insert: %v", err)
 					}
 				}
@@ -367,7 +367,7 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 					tmp[diff.DestId] = bm
 				} else {
 					// store copied data
-					if err := idx.allocTable.Insert(ctx, alloc); err != nil {
+					if err := model.Insert(ctx, idx.allocTable, alloc); err != nil {
 						return fmt.Errorf("etl.bigmap.insert: %v", err)
 					}
 					idx.allocCache.Add(alloc.BigmapId, alloc)
@@ -375,14 +375,14 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 					for i, v := range live {
 						ins[i] = v
 					}
-					if err := idx.valueTable.Insert(ctx, ins); err != nil {
+					if err := model.Insert(ctx, idx.valueTable, ins); err != nil {
 						return fmt.Errorf("etl.bigmap.insert: %v", err)
 					}
 					ins = ins[:0]
 					for _, v := range updates {
 						ins = append(ins, v)
 					}
-					if err := idx.updateTable.Insert(ctx, ins); err != nil {
+					if err := model.Insert(ctx, idx.updateTable, ins); err != nil {
 						return fmt.Errorf("etl.bigmap.insert: %v", err)
 					}
 				}
@@ -395,7 +395,7 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 						bm := tmp[diff.Id]
 						delete(tmp, diff.Id)
 						if bm.Alloc != nil {
-							if err := idx.updateTable.Insert(ctx, bm.Alloc.ToRemove(op)); err != nil {
+							if err := model.Insert(ctx, idx.updateTable, bm.Alloc.ToRemove(op)); err != nil {
 								return fmt.Errorf("etl.bigmap.empty: %v", err)
 							}
 						}
@@ -434,17 +434,17 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 					alloc.Deleted = op.Height
 
 					// add bigmap remove at end
-					if err := idx.updateTable.Insert(ctx, alloc.ToRemove(op)); err != nil {
+					if err := model.Insert(ctx, idx.updateTable, alloc.ToRemove(op)); err != nil {
 						return fmt.Errorf("etl.bigmap.empty: %v", err)
 					}
 
-					if err := idx.allocTable.Update(ctx, alloc); err != nil {
+					if err := model.Update(ctx, idx.allocTable, alloc); err != nil {
 						return fmt.Errorf("etl.bigmap.empty: %v", err)
 					}
-					if err := idx.updateTable.Insert(ctx, updates); err != nil {
+					if err := model.Insert(ctx, idx.updateTable, updates); err != nil {
 						return fmt.Errorf("etl.bigmap.empty: %v", err)
 					}
-					if err := idx.valueTable.DeleteIds(ctx, ids); err != nil {
+					if err := model.DeleteIds(ctx, idx.valueTable, ids); err != nil {
 						return fmt.Errorf("etl.bigmap.empty: %v", err)
 					}
 
@@ -471,7 +471,7 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 					}
 					if pos > -1 {
 						// add remove action
-						if err := idx.updateTable.Insert(ctx, bm.Live[pos].ToUpdateRemove(op)); err != nil {
+						if err := model.Insert(ctx, idx.updateTable, bm.Live[pos].ToUpdateRemove(op)); err != nil {
 							return fmt.Errorf("etl.bigmap.empty: %v", err)
 						}
 						bm.Alloc.NKeys--
@@ -522,7 +522,7 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				}
 
 				if prev != nil {
-					if err := idx.valueTable.DeleteIds(ctx, []uint64{prev.RowId}); err != nil {
+					if err := model.DeleteIds(ctx, idx.valueTable, []uint64{prev.RowId}); err != nil {
 						return fmt.Errorf("etl.bigmap.remove: %v", err)
 					}
 					alloc.NKeys--
@@ -533,10 +533,10 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				}
 				alloc.Updated = op.Height
 				alloc.NUpdates++
-				if err := idx.updateTable.Insert(ctx, model.NewBigmapUpdate(op, diff)); err != nil {
+				if err := model.Insert(ctx, idx.updateTable, model.NewBigmapUpdate(op, diff)); err != nil {
 					return fmt.Errorf("etl.bigmap.remove: %v", err)
 				}
-				if err := idx.allocTable.Update(ctx, alloc); err != nil {
+				if err := model.Update(ctx, idx.allocTable, alloc); err != nil {
 					return fmt.Errorf("etl.bigmap.remove: %v", err)
 				}
 
@@ -574,7 +574,7 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 					}
 
 					// insert to update table
-					if err := idx.updateTable.Insert(ctx, bm.Updates[len(bm.Updates)-1]); err != nil {
+					if err := model.Insert(ctx, idx.updateTable, bm.Updates[len(bm.Updates)-1]); err != nil {
 						return fmt.Errorf("etl.bigmap.update: %v", err)
 					}
 
@@ -615,12 +615,12 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				if prev != nil {
 					// replace
 					live.RowId = prev.RowId
-					if err := idx.valueTable.Update(ctx, live); err != nil {
+					if err := model.Update(ctx, idx.valueTable, live); err != nil {
 						return fmt.Errorf("etl.bigmap.replace: %v", err)
 					}
 				} else {
 					// add
-					if err := idx.valueTable.Insert(ctx, live); err != nil {
+					if err := model.Insert(ctx, idx.valueTable, live); err != nil {
 						return fmt.Errorf("etl.bigmap.insert: %v", err)
 					}
 					alloc.NKeys++
@@ -628,10 +628,10 @@ func (idx *BigmapIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				alloc.Updated = op.Height
 				alloc.NUpdates++
 
-				if err := idx.updateTable.Insert(ctx, model.NewBigmapUpdate(op, diff)); err != nil {
+				if err := model.Insert(ctx, idx.updateTable, model.NewBigmapUpdate(op, diff)); err != nil {
 					return fmt.Errorf("etl.bigmap.update: %v", err)
 				}
-				if err := idx.allocTable.Update(ctx, alloc); err != nil {
+				if err := model.Update(ctx, idx.allocTable, alloc); err != nil {
 					return fmt.Errorf("etl.bigmap.update: %v", err)
 				}
 			}
@@ -722,7 +722,7 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 				live = prev.ToKV()
 				alloc.NKeys++
 				alloc.NUpdates--
-				if err := idx.valueTable.Insert(ctx, live); err != nil {
+				if err := model.Insert(ctx, idx.valueTable, live); err != nil {
 					return fmt.Errorf("etl.bigmap.rollback insert live key: %w", err)
 				}
 			}
@@ -760,7 +760,7 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 				}
 
 				// this was a first-time insert, delete current live key
-				if err := idx.valueTable.DeleteIds(ctx, []uint64{live.RowId}); err != nil {
+				if err := model.DeleteIds(ctx, idx.valueTable, []uint64{live.RowId}); err != nil {
 					return fmt.Errorf("etl.bigmap.rollback delete live key: %w", err)
 				}
 				alloc.NKeys--
@@ -772,7 +772,7 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 			} else {
 				if prev.Action == micheline.DiffActionRemove {
 					// this was an insert after remove, remove current live key
-					if err := idx.valueTable.DeleteIds(ctx, []uint64{live.RowId}); err != nil {
+					if err := model.DeleteIds(ctx, idx.valueTable, []uint64{live.RowId}); err != nil {
 						return fmt.Errorf("etl.bigmap.rollback delete live key: %w", err)
 					}
 					alloc.NKeys--
@@ -787,7 +787,7 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 					// this was an update after update, replace current live key
 					lastLive := prev.ToKV()
 					lastLive.RowId = live.RowId
-					if err := idx.valueTable.Update(ctx, lastLive); err != nil {
+					if err := model.Update(ctx, idx.valueTable, lastLive); err != nil {
 						return fmt.Errorf("etl.bigmap.rollback replace live key: %w", err)
 					}
 					alloc.NUpdates--
@@ -802,10 +802,8 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 	}
 
 	// delete all updates at height
-	_, err = pack.NewQuery("etl.bigmap.delete").
-		WithTable(idx.updateTable).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.updateTable, pack.NewQuery("etl.bigmap.delete").
+		AndEqual("height", height))
 	if err != nil {
 		return err
 	}
@@ -818,15 +816,13 @@ func (idx *BigmapIndex) DeleteBlock(ctx context.Context, height int64) error {
 		}
 		upd = append(upd, v)
 	}
-	if err := idx.allocTable.Update(ctx, upd); err != nil {
+	if err := model.Update(ctx, idx.allocTable, upd); err != nil {
 		return err
 	}
 
 	// delete all allocs from this block
-	_, err = pack.NewQuery("etl.bigmap.delete").
-		WithTable(idx.allocTable).
-		AndEqual("h", height). // alloc height
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.allocTable, pack.NewQuery("etl.bigmap.delete").
+		AndEqual("h", height)) // alloc height
 	if err != nil {
 		return err
 	}
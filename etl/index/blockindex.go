@@ -132,7 +132,7 @@ func (idx *BlockIndex) Close() error {
 func (idx *BlockIndex) ConnectBlock(ctx context.Context, block *model.Block, b model.BlockBuilder) error {
 	// update parent block to write blocks endorsed bitmap
 	if block.Parent != nil && block.Parent.RowId > 0 {
-		if err := idx.table.Update(ctx, []pack.Item{block.Parent}); err != nil {
+		if err := model.Update(ctx, idx.table, []pack.Item{block.Parent}); err != nil {
 			return fmt.Errorf("parent update: %w", err)
 		}
 	}
@@ -162,14 +162,14 @@ func (idx *BlockIndex) ConnectBlock(ctx context.Context, block *model.Block, b m
 			return fmt.Errorf("missing snapshot index block %d for cycle %d index %d", snapHeight, snap.Cycle, snap.Index)
 		}
 		snapBlock.IsCycleSnapshot = true
-		if err := idx.table.Update(ctx, []pack.Item{snapBlock}); err != nil {
+		if err := model.Update(ctx, idx.table, []pack.Item{snapBlock}); err != nil {
 			return fmt.Errorf("snapshot index block %d: %w", snapHeight, err)
 		}
 	}
 
 	// Note: during reorg some blocks may already exist (have a valid row id)
 	// we assume insert will update such rows instead of creating new rows
-	return idx.table.Insert(ctx, []pack.Item{block})
+	return model.Insert(ctx, idx.table, []pack.Item{block})
 }
 
 func (idx *BlockIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -179,19 +179,15 @@ func (idx *BlockIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 
 func (idx *BlockIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting block at height %d", height)
-	_, err := pack.NewQuery("etl.block.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.block.delete").
+		AndEqual("height", height))
 	return err
 }
 
 func (idx *BlockIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting block cycle %d", cycle)
-	_, err := pack.NewQuery("etl.block.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.block.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
@@ -0,0 +1,291 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	ContractCallPackSizeLog2    = 15 // 32k
+	ContractCallJournalSizeLog2 = 16 // 64k
+	ContractCallCacheSize       = 2  // minimum
+	ContractCallFillLevel       = 100
+
+	ContractCallIndexKey = "contract_calls"
+	ContractCallTableKey = "contract_calls"
+)
+
+// ContractCallIndex keeps one statistics row per contract, entrypoint and
+// day. Rows of the current day are cached with their decoded caller sets
+// and are loaded once when the day changes.
+type ContractCallIndex struct {
+	db    *pack.DB
+	opts  pack.Options
+	stats *pack.Table
+	day   time.Time
+	rows  map[contractCallKey]*contractCallRow
+}
+
+type contractCallRow struct {
+	stats   *model.ContractCallStats
+	callers model.CallerSet
+}
+
+var _ model.BlockIndexer = (*ContractCallIndex)(nil)
+
+func NewContractCallIndex(opts pack.Options) *ContractCallIndex {
+	return &ContractCallIndex{opts: opts}
+}
+
+func (idx *ContractCallIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *ContractCallIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.stats}
+}
+
+func (idx *ContractCallIndex) Key() string {
+	return ContractCallIndexKey
+}
+
+func (idx *ContractCallIndex) Name() string {
+	return ContractCallIndexKey + " index"
+}
+
+func (idx *ContractCallIndex) Create(path, label string, opts interface{}) error {
+	statsFields, err := pack.Fields(model.ContractCallStats{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating %s database: %w", idx.Key(), err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		ContractCallTableKey,
+		statsFields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, ContractCallPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, ContractCallJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, ContractCallCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, ContractCallFillLevel),
+		})
+	return err
+}
+
+func (idx *ContractCallIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.stats, err = idx.db.Table(ContractCallTableKey, pack.Options{
+		JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, ContractCallJournalSizeLog2),
+		CacheSize:       util.NonZero(idx.opts.CacheSize, ContractCallCacheSize),
+	})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+func (idx *ContractCallIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *ContractCallIndex) Close() error {
+	for _, v := range idx.Tables() {
+		if v != nil {
+			if err := v.Close(); err != nil {
+				log.Errorf("Closing %s table: %s", v.Name(), err)
+			}
+		}
+	}
+	idx.stats = nil
+	idx.rows = nil
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+type contractCallKey struct {
+	account    model.AccountID
+	entrypoint int
+}
+
+// loadDay makes rows of the given day available in the row cache.
+func (idx *ContractCallIndex) loadDay(ctx context.Context, day time.Time) error {
+	if idx.rows != nil && idx.day.Equal(day) {
+		return nil
+	}
+	rows := make(map[contractCallKey]*contractCallRow)
+	err := pack.NewQuery("etl.contract_calls.day").
+		WithTable(idx.stats).
+		AndEqual("time", day).
+		Stream(ctx, func(r pack.Row) error {
+			s := &model.ContractCallStats{}
+			if err := r.Decode(s); err != nil {
+				return err
+			}
+			callers, err := model.DecodeCallerSet(s.Callers)
+			if err != nil {
+				return fmt.Errorf("row %d: %w", s.RowId, err)
+			}
+			rows[contractCallKey{s.AccountId, s.Entrypoint}] = &contractCallRow{s, callers}
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("contract_calls: load day %s: %w", day.Format("2006-01-02"), err)
+	}
+	idx.day = day
+	idx.rows = rows
+	return nil
+}
+
+func (idx *ContractCallIndex) ConnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	day := model.CallDay(block.Timestamp)
+	ins := make([]pack.Item, 0)
+	upd := make([]pack.Item, 0)
+	dirty := make(map[contractCallKey]struct{})
+	for _, op := range block.Ops {
+		if op.Type != model.OpTypeTransaction || !op.IsContract {
+			continue
+		}
+		if err := idx.loadDay(ctx, day); err != nil {
+			return err
+		}
+		k := contractCallKey{op.ReceiverId, op.Entrypoint}
+		row, ok := idx.rows[k]
+		if !ok {
+			row = &contractCallRow{
+				stats: &model.ContractCallStats{
+					AccountId:  op.ReceiverId,
+					Entrypoint: op.Entrypoint,
+					Timestamp:  day,
+				},
+				callers: make(model.CallerSet),
+			}
+			idx.rows[k] = row
+		}
+		row.stats.Add(op)
+		row.stats.Height = block.Height
+		if _, ok := row.callers[op.SenderId]; !ok {
+			row.callers[op.SenderId] = block.Height
+		}
+		if _, ok := dirty[k]; ok {
+			continue
+		}
+		dirty[k] = struct{}{}
+		if row.stats.RowId == 0 {
+			ins = append(ins, row.stats)
+		} else {
+			upd = append(upd, row.stats)
+		}
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+	for k := range dirty {
+		row := idx.rows[k]
+		row.stats.NCallers = len(row.callers)
+		row.stats.Callers = row.callers.Encode()
+	}
+	if len(ins) > 0 {
+		if err := model.Insert(ctx, idx.stats, ins); err != nil {
+			return fmt.Errorf("contract_calls: insert: %w", err)
+		}
+	}
+	if len(upd) > 0 {
+		if err := model.Update(ctx, idx.stats, upd); err != nil {
+			return fmt.Errorf("contract_calls: update: %w", err)
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock subtracts the block's calls from their daily rows and
+// removes callers first seen in this block. Rows without calls left are
+// deleted.
+func (idx *ContractCallIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	day := model.CallDay(block.Timestamp)
+	dirty := make(map[contractCallKey]struct{})
+	for _, op := range block.Ops {
+		if op.Type != model.OpTypeTransaction || !op.IsContract {
+			continue
+		}
+		if err := idx.loadDay(ctx, day); err != nil {
+			return err
+		}
+		k := contractCallKey{op.ReceiverId, op.Entrypoint}
+		row, ok := idx.rows[k]
+		if !ok {
+			continue
+		}
+		row.stats.Sub(op)
+		if h, ok := row.callers[op.SenderId]; ok && h == block.Height {
+			delete(row.callers, op.SenderId)
+		}
+		dirty[k] = struct{}{}
+	}
+	upd := make([]pack.Item, 0)
+	del := make([]uint64, 0)
+	for k := range dirty {
+		row := idx.rows[k]
+		if row.stats.NCalls <= 0 {
+			del = append(del, row.stats.RowId)
+			delete(idx.rows, k)
+			continue
+		}
+		// remaining calls happened before this block
+		row.stats.Height = util.Min64(row.stats.Height, block.Height-1)
+		row.stats.NCallers = len(row.callers)
+		row.stats.Callers = row.callers.Encode()
+		upd = append(upd, row.stats)
+	}
+	if len(del) > 0 {
+		if err := model.DeleteIds(ctx, idx.stats, del); err != nil {
+			return fmt.Errorf("contract_calls: delete: %w", err)
+		}
+	}
+	if len(upd) > 0 {
+		if err := model.Update(ctx, idx.stats, upd); err != nil {
+			return fmt.Errorf("contract_calls: update: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteBlock is a no-op because rows are only written from ConnectBlock,
+// which does not run for blocks that failed to build.
+func (idx *ContractCallIndex) DeleteBlock(ctx context.Context, height int64) error {
+	return nil
+}
+
+func (idx *ContractCallIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	return nil
+}
+
+func (idx *ContractCallIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -118,7 +118,7 @@ func (idx *ChainIndex) Close() error {
 }
 
 func (idx *ChainIndex) ConnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
-	return idx.table.Insert(ctx, []pack.Item{block.Chain})
+	return model.Insert(ctx, idx.table, []pack.Item{block.Chain})
 }
 
 func (idx *ChainIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -127,19 +127,15 @@ func (idx *ChainIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 
 func (idx *ChainIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting chain state at height %d", height)
-	_, err := pack.NewQuery("etl.chain.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.chain.delete").
+		AndEqual("height", height))
 	return err
 }
 
 func (idx *ChainIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting chain cycle %d", cycle)
-	_, err := pack.NewQuery("etl.chain.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.chain.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
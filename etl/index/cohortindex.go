@@ -0,0 +1,146 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	CohortPackSizeLog2    = 12 // 4096
+	CohortJournalSizeLog2 = 12 // 4096
+	CohortCacheSize       = 2
+	CohortFillLevel       = 100
+	CohortIndexKey        = "cohort"
+	CohortTableKey        = "cohort"
+)
+
+var (
+	ErrNoCohortEntry = errors.New("cohort not found")
+)
+
+// CohortIndex stores user-defined address lists. Like metadata, cohorts are
+// managed via API and do not depend on chain data.
+type CohortIndex struct {
+	db    *pack.DB
+	opts  pack.Options
+	table *pack.Table
+}
+
+var _ model.BlockIndexer = (*CohortIndex)(nil)
+
+func NewCohortIndex(opts pack.Options) *CohortIndex {
+	return &CohortIndex{opts: opts}
+}
+
+func (idx *CohortIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *CohortIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.table}
+}
+
+func (idx *CohortIndex) Key() string {
+	return CohortIndexKey
+}
+
+func (idx *CohortIndex) Name() string {
+	return CohortIndexKey + " index"
+}
+
+func (idx *CohortIndex) Create(path, label string, opts interface{}) error {
+	fields, err := pack.Fields(model.CohortMember{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating %s database: %w", idx.Key(), err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		CohortTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, CohortPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CohortJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, CohortCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, CohortFillLevel),
+		})
+	return err
+}
+
+func (idx *CohortIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.table, err = idx.db.Table(CohortTableKey, pack.Options{
+		JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CohortJournalSizeLog2),
+		CacheSize:       util.NonZero(idx.opts.CacheSize, CohortCacheSize),
+	})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+func (idx *CohortIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *CohortIndex) Close() error {
+	if idx.table != nil {
+		if err := idx.table.Close(); err != nil {
+			log.Errorf("Closing %s: %s", idx.Name(), err)
+		}
+		idx.table = nil
+	}
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+func (idx *CohortIndex) ConnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	// noop
+	return nil
+}
+
+func (idx *CohortIndex) DisconnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	// noop
+	return nil
+}
+
+func (idx *CohortIndex) DeleteBlock(ctx context.Context, height int64) error {
+	// noop
+	return nil
+}
+
+func (idx *CohortIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	// noop
+	return nil
+}
+
+func (idx *CohortIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
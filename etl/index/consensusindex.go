@@ -0,0 +1,176 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	ConsensusKeyPackSizeLog2    = 10 // 1k packs
+	ConsensusKeyJournalSizeLog2 = 10 // 1k
+	ConsensusKeyCacheSize       = 2  // minimum
+	ConsensusKeyFillLevel       = 100
+
+	ConsensusKeyIndexKey = "consensus_key"
+	ConsensusKeyTableKey = "consensus_key"
+)
+
+var (
+	ErrNoConsensusKeyEntry = errors.New("consensus key not indexed")
+)
+
+type ConsensusKeyIndex struct {
+	db    *pack.DB
+	opts  pack.Options
+	table *pack.Table
+}
+
+var _ model.BlockIndexer = (*ConsensusKeyIndex)(nil)
+
+func NewConsensusKeyIndex(opts pack.Options) *ConsensusKeyIndex {
+	return &ConsensusKeyIndex{opts: opts}
+}
+
+func (idx *ConsensusKeyIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *ConsensusKeyIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.table}
+}
+
+func (idx *ConsensusKeyIndex) Key() string {
+	return ConsensusKeyIndexKey
+}
+
+func (idx *ConsensusKeyIndex) Name() string {
+	return ConsensusKeyIndexKey + " index"
+}
+
+func (idx *ConsensusKeyIndex) Create(path, label string, opts interface{}) error {
+	fields, err := pack.Fields(model.ConsensusKey{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating database: %w", err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		ConsensusKeyTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, ConsensusKeyPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, ConsensusKeyJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, ConsensusKeyCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, ConsensusKeyFillLevel),
+		})
+	return err
+}
+
+func (idx *ConsensusKeyIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.table, err = idx.db.Table(
+		ConsensusKeyTableKey,
+		pack.Options{
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, ConsensusKeyJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, ConsensusKeyCacheSize),
+		},
+	)
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+func (idx *ConsensusKeyIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *ConsensusKeyIndex) Close() error {
+	if idx.table != nil {
+		if err := idx.table.Close(); err != nil {
+			log.Errorf("Closing %s: %s", idx.Name(), err)
+		}
+		idx.table = nil
+	}
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+func (idx *ConsensusKeyIndex) ConnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	ins := make([]pack.Item, 0)
+	for _, op := range block.Ops {
+		// don't process failed or unrelated ops
+		if !op.IsSuccess {
+			continue
+		}
+		switch op.Type {
+		case model.OpTypeUpdateConsensusKey:
+			key, err := tezos.ParseKey(op.Data)
+			if err != nil {
+				return fmt.Errorf("consensus_key: decoding key %q: %w", op.Data, err)
+			}
+			ins = append(ins, model.NewConsensusKeyUpdate(op, key, block.Params))
+		case model.OpTypeDrainDelegate:
+			addr, err := tezos.ParseAddress(op.Data)
+			if err != nil {
+				return fmt.Errorf("consensus_key: decoding address %q: %w", op.Data, err)
+			}
+			ins = append(ins, model.NewConsensusKeyDrain(op, addr))
+		}
+	}
+
+	if len(ins) > 0 {
+		// insert, will generate unique row ids
+		if err := model.Insert(ctx, idx.table, ins); err != nil {
+			return fmt.Errorf("consensus_key: insert: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (idx *ConsensusKeyIndex) DisconnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	return idx.DeleteBlock(ctx, block.Height)
+}
+
+func (idx *ConsensusKeyIndex) DeleteBlock(ctx context.Context, height int64) error {
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.consensus_key.delete").
+		AndEqual("height", height))
+	return err
+}
+
+func (idx *ConsensusKeyIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	return nil
+}
+
+func (idx *ConsensusKeyIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
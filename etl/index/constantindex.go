@@ -161,7 +161,7 @@ func (idx *ConstantIndex) ConnectBlock(ctx context.Context, block *model.Block,
 
 	if len(ins) > 0 {
 		// insert, will generate unique row ids
-		if err := idx.table.Insert(ctx, ins); err != nil {
+		if err := model.Insert(ctx, idx.table, ins); err != nil {
 			return fmt.Errorf("constant: insert: %w", err)
 		}
 	}
@@ -175,10 +175,8 @@ func (idx *ConstantIndex) DisconnectBlock(ctx context.Context, block *model.Bloc
 
 func (idx *ConstantIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting contracts at height %d", height)
-	_, err := pack.NewQuery("etl.constant.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.constant.delete").
+		AndEqual("height", height))
 	return err
 }
 
@@ -185,11 +185,11 @@ func (idx *ContractIndex) ConnectBlock(ctx context.Context, block *model.Block,
 	}
 
 	// insert, will generate unique row ids
-	if err := idx.contracts.Insert(ctx, ins); err != nil {
+	if err := model.Insert(ctx, idx.contracts, ins); err != nil {
 		return fmt.Errorf("contract: insert: %w", err)
 	}
 
-	if err := idx.contracts.Update(ctx, upd); err != nil {
+	if err := model.Update(ctx, idx.contracts, upd); err != nil {
 		return fmt.Errorf("contract: update: %w", err)
 	}
 	return nil
@@ -204,7 +204,7 @@ func (idx *ContractIndex) DisconnectBlock(ctx context.Context, block *model.Bloc
 		}
 		upd = append(upd, v)
 	}
-	if err := idx.contracts.Update(ctx, upd); err != nil {
+	if err := model.Update(ctx, idx.contracts, upd); err != nil {
 		return fmt.Errorf("contract: update: %w", err)
 	}
 
@@ -214,10 +214,8 @@ func (idx *ContractIndex) DisconnectBlock(ctx context.Context, block *model.Bloc
 
 func (idx *ContractIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting contracts at height %d", height)
-	_, err := pack.NewQuery("etl.contract.delete").
-		WithTable(idx.contracts).
-		AndEqual("first_seen", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.contracts, pack.NewQuery("etl.contract.delete").
+		AndEqual("first_seen", height))
 	return err
 }
 
@@ -0,0 +1,385 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	TransferEdgePackSizeLog2         = 15 // 32k
+	TransferEdgeJournalSizeLog2      = 16 // 64k
+	TransferEdgeCacheSize            = 2  // minimum
+	TransferEdgeFillLevel            = 100
+	TransferEdgeIndexPackSizeLog2    = 15 // 16k packs (32k split size)
+	TransferEdgeIndexJournalSizeLog2 = 16 // 64k
+	TransferEdgeIndexCacheSize       = 64
+	TransferEdgeIndexFillLevel       = 90
+
+	TransferEdgeIndexKey      = "transfer_edge"
+	TransferEdgeTableKey      = "transfer_edge"
+	TransferEdgeCycleTableKey = "transfer_edge_cycle"
+)
+
+// TransferEdgeIndex maintains aggregated transfer edges between accounts,
+// one row per sender/receiver pair and one row per pair and cycle.
+type TransferEdgeIndex struct {
+	db     *pack.DB
+	opts   pack.Options
+	iopts  pack.Options
+	table  *pack.Table
+	cycles *pack.Table
+}
+
+var _ model.BlockIndexer = (*TransferEdgeIndex)(nil)
+
+func NewTransferEdgeIndex(opts, iopts pack.Options) *TransferEdgeIndex {
+	return &TransferEdgeIndex{opts: opts, iopts: iopts}
+}
+
+func (idx *TransferEdgeIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *TransferEdgeIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.table, idx.cycles}
+}
+
+func (idx *TransferEdgeIndex) Key() string {
+	return TransferEdgeIndexKey
+}
+
+func (idx *TransferEdgeIndex) Name() string {
+	return TransferEdgeIndexKey + " index"
+}
+
+func (idx *TransferEdgeIndex) Create(path, label string, opts interface{}) error {
+	fields, err := pack.Fields(model.TransferEdge{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating %s database: %w", idx.Key(), err)
+	}
+	defer db.Close()
+
+	for _, key := range []string{TransferEdgeTableKey, TransferEdgeCycleTableKey} {
+		table, err := db.CreateTableIfNotExists(
+			key,
+			fields,
+			pack.Options{
+				PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, TransferEdgePackSizeLog2),
+				JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, TransferEdgeJournalSizeLog2),
+				CacheSize:       util.NonZero(idx.opts.CacheSize, TransferEdgeCacheSize),
+				FillLevel:       util.NonZero(idx.opts.FillLevel, TransferEdgeFillLevel),
+			})
+		if err != nil {
+			return err
+		}
+		_, err = table.CreateIndexIfNotExists(
+			"key",
+			fields.Find("K"),   // sender, receiver (and cycle) ids
+			pack.IndexTypeHash, // hash table, index stores hash(field) -> pk value
+			pack.Options{
+				PackSizeLog2:    util.NonZero(idx.iopts.PackSizeLog2, TransferEdgeIndexPackSizeLog2),
+				JournalSizeLog2: util.NonZero(idx.iopts.JournalSizeLog2, TransferEdgeIndexJournalSizeLog2),
+				CacheSize:       util.NonZero(idx.iopts.CacheSize, TransferEdgeIndexCacheSize),
+				FillLevel:       util.NonZero(idx.iopts.FillLevel, TransferEdgeIndexFillLevel),
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *TransferEdgeIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	for _, v := range []struct {
+		key   string
+		table **pack.Table
+	}{
+		{TransferEdgeTableKey, &idx.table},
+		{TransferEdgeCycleTableKey, &idx.cycles},
+	} {
+		*v.table, err = idx.db.Table(
+			v.key,
+			pack.Options{
+				JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, TransferEdgeJournalSizeLog2),
+				CacheSize:       util.NonZero(idx.opts.CacheSize, TransferEdgeCacheSize),
+			},
+			pack.Options{
+				JournalSizeLog2: util.NonZero(idx.iopts.JournalSizeLog2, TransferEdgeIndexJournalSizeLog2),
+				CacheSize:       util.NonZero(idx.iopts.CacheSize, TransferEdgeIndexCacheSize),
+			})
+		if err != nil {
+			idx.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *TransferEdgeIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *TransferEdgeIndex) Close() error {
+	for _, v := range idx.Tables() {
+		if v != nil {
+			if err := v.Close(); err != nil {
+				log.Errorf("Closing %s table: %s", v.Name(), err)
+			}
+		}
+	}
+	idx.table = nil
+	idx.cycles = nil
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+type edgeKey struct {
+	sender   model.AccountID
+	receiver model.AccountID
+}
+
+type edgeDelta struct {
+	n      int
+	volume int64
+}
+
+// blockEdges sums successful transactions in a block per sender/receiver pair.
+func blockEdges(block *model.Block) (map[edgeKey]*edgeDelta, []edgeKey) {
+	deltas := make(map[edgeKey]*edgeDelta)
+	keys := make([]edgeKey, 0)
+	for _, op := range block.Ops {
+		if op.Type != model.OpTypeTransaction || !op.IsSuccess {
+			continue
+		}
+		// internal transactions are sent by the emitting contract
+		sender := op.SenderId
+		if op.IsInternal {
+			sender = op.CreatorId
+		}
+		if sender == 0 || op.ReceiverId == 0 {
+			continue
+		}
+		k := edgeKey{sender, op.ReceiverId}
+		d, ok := deltas[k]
+		if !ok {
+			d = &edgeDelta{}
+			deltas[k] = d
+			keys = append(keys, k)
+		}
+		d.n++
+		d.volume += op.Volume
+	}
+	return deltas, keys
+}
+
+// loadEdges loads existing rows for all pairs in keys from table.
+func loadEdges(ctx context.Context, table *pack.Table, keys []edgeKey, cycle int64) (map[edgeKey]*model.TransferEdge, error) {
+	rkeys := make([][]byte, len(keys))
+	for i, k := range keys {
+		rkeys[i] = model.TransferEdgeKey(k.sender, k.receiver, cycle)
+	}
+	edges := make(map[edgeKey]*model.TransferEdge)
+	err := pack.NewQuery("etl.transfer_edge.load").
+		WithTable(table).
+		AndIn("key", rkeys).
+		Stream(ctx, func(r pack.Row) error {
+			e := &model.TransferEdge{}
+			if err := r.Decode(e); err != nil {
+				return err
+			}
+			edges[edgeKey{e.SenderId, e.ReceiverId}] = e
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+func (idx *TransferEdgeIndex) ConnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	deltas, keys := blockEdges(block)
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, v := range []struct {
+		table *pack.Table
+		cycle int64
+	}{
+		{idx.table, -1},
+		{idx.cycles, block.Cycle},
+	} {
+		edges, err := loadEdges(ctx, v.table, keys, v.cycle)
+		if err != nil {
+			return fmt.Errorf("transfer_edge: load %s: %w", v.table.Name(), err)
+		}
+		ins := make([]pack.Item, 0)
+		upd := make([]pack.Item, 0)
+		for _, k := range keys {
+			d := deltas[k]
+			e, ok := edges[k]
+			if !ok {
+				e = &model.TransferEdge{
+					Key:         model.TransferEdgeKey(k.sender, k.receiver, v.cycle),
+					SenderId:    k.sender,
+					ReceiverId:  k.receiver,
+					Cycle:       v.cycle,
+					FirstHeight: block.Height,
+					FirstTime:   block.Timestamp,
+				}
+				ins = append(ins, e)
+			} else {
+				upd = append(upd, e)
+			}
+			e.NTransfers += d.n
+			e.Volume += d.volume
+			e.LastHeight = block.Height
+			e.LastTime = block.Timestamp
+		}
+		if len(ins) > 0 {
+			if err := model.Insert(ctx, v.table, ins); err != nil {
+				return fmt.Errorf("transfer_edge: insert %s: %w", v.table.Name(), err)
+			}
+		}
+		if len(upd) > 0 {
+			if err := model.Update(ctx, v.table, upd); err != nil {
+				return fmt.Errorf("transfer_edge: update %s: %w", v.table.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock subtracts the block's transfers from pair and cycle rows.
+// Rows without transfers left are deleted. When the block was the last
+// interaction of a pair, the previous one is looked up in the op table.
+func (idx *TransferEdgeIndex) DisconnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	deltas, keys := blockEdges(block)
+	if len(keys) == 0 {
+		return nil
+	}
+	ops, err := builder.Table(OpTableKey)
+	if err != nil {
+		return err
+	}
+	for _, v := range []struct {
+		table *pack.Table
+		cycle int64
+	}{
+		{idx.table, -1},
+		{idx.cycles, block.Cycle},
+	} {
+		edges, err := loadEdges(ctx, v.table, keys, v.cycle)
+		if err != nil {
+			return fmt.Errorf("transfer_edge: load %s: %w", v.table.Name(), err)
+		}
+		upd := make([]pack.Item, 0)
+		del := make([]uint64, 0)
+		for _, k := range keys {
+			e, ok := edges[k]
+			if !ok {
+				continue
+			}
+			d := deltas[k]
+			e.NTransfers -= d.n
+			e.Volume -= d.volume
+			if e.NTransfers <= 0 {
+				del = append(del, e.RowId)
+				continue
+			}
+			if e.LastHeight >= block.Height {
+				// remaining transfers of a cycle row are within the same cycle,
+				// so the pair's previous transfer is also the cycle's last one
+				if err := lastTransfer(ctx, ops, e, block.Height); err != nil {
+					return fmt.Errorf("transfer_edge: last transfer: %w", err)
+				}
+			}
+			upd = append(upd, e)
+		}
+		if len(del) > 0 {
+			if err := model.DeleteIds(ctx, v.table, del); err != nil {
+				return fmt.Errorf("transfer_edge: delete %s: %w", v.table.Name(), err)
+			}
+		}
+		if len(upd) > 0 {
+			if err := model.Update(ctx, v.table, upd); err != nil {
+				return fmt.Errorf("transfer_edge: update %s: %w", v.table.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// lastTransfer sets an edge's last height and time to the most recent
+// successful transaction of its pair before height.
+func lastTransfer(ctx context.Context, ops *pack.Table, e *model.TransferEdge, height int64) error {
+	e.LastHeight, e.LastTime = e.FirstHeight, e.FirstTime
+	op := &model.Op{}
+	err := pack.NewQuery("etl.transfer_edge.last").
+		WithTable(ops).
+		WithFields("height", "time", "sender_id", "creator_id", "is_internal").
+		WithOrder(pack.OrderDesc).
+		AndEqual("type", model.OpTypeTransaction).
+		AndEqual("receiver_id", e.ReceiverId).
+		AndEqual("is_success", true).
+		AndGte("height", e.FirstHeight).
+		AndLt("height", height).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(op); err != nil {
+				return err
+			}
+			sender := op.SenderId
+			if op.IsInternal {
+				sender = op.CreatorId
+			}
+			if sender != e.SenderId {
+				return nil
+			}
+			e.LastHeight, e.LastTime = op.Height, op.Timestamp
+			return io.EOF
+		})
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// DeleteBlock is a no-op because rows are only written from ConnectBlock,
+// which does not run for blocks that failed to build.
+func (idx *TransferEdgeIndex) DeleteBlock(ctx context.Context, height int64) error {
+	return nil
+}
+
+func (idx *TransferEdgeIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	return nil
+}
+
+func (idx *TransferEdgeIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
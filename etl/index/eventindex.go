@@ -142,7 +142,7 @@ func (idx *EventIndex) ConnectBlock(ctx context.Context, block *model.Block, bui
 
     if len(ins) > 0 {
         // insert, will generate unique row ids
-        if err := idx.table.Insert(ctx, ins); err != nil {
+        if err := model.Insert(ctx, idx.table, ins); err != nil {
             return fmt.Errorf("event: insert: %w", err)
         }
     }
@@ -156,10 +156,8 @@ func (idx *EventIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 
 func (idx *EventIndex) DeleteBlock(ctx context.Context, height int64) error {
     // log.Debugf("Rollback deleting contracts at height %d", height)
-    _, err := pack.NewQuery("etl.event.delete").
-        WithTable(idx.table).
-        AndEqual("height", height).
-        Delete(ctx)
+    _, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.event.delete").
+        AndEqual("height", height))
     return err
 }
 
@@ -0,0 +1,353 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	CodeFamilyPackSizeLog2    = 15 // 32k
+	CodeFamilyJournalSizeLog2 = 14 // 16k
+	CodeFamilyCacheSize       = 2  // minimum
+	CodeFamilyFillLevel       = 100
+
+	CodeFamilyIndexKey       = "code_family"
+	CodeFamilyTableKey       = "code_family"
+	CodeFamilyMemberTableKey = "code_family_member"
+)
+
+var (
+	ErrNoCodeFamilyEntry = errors.New("code family not indexed")
+)
+
+var codeFamilyKinds = []model.CodeFamilyKind{
+	model.CodeFamilyKindCode,
+	model.CodeFamilyKindInterface,
+	model.CodeFamilyKindShape,
+}
+
+type CodeFamilyIndex struct {
+	db       *pack.DB
+	opts     pack.Options
+	families *pack.Table
+	members  *pack.Table
+}
+
+var _ model.BlockIndexer = (*CodeFamilyIndex)(nil)
+
+func NewCodeFamilyIndex(opts pack.Options) *CodeFamilyIndex {
+	return &CodeFamilyIndex{opts: opts}
+}
+
+func (idx *CodeFamilyIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *CodeFamilyIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.families, idx.members}
+}
+
+func (idx *CodeFamilyIndex) Key() string {
+	return CodeFamilyIndexKey
+}
+
+func (idx *CodeFamilyIndex) Name() string {
+	return CodeFamilyIndexKey + " index"
+}
+
+func (idx *CodeFamilyIndex) Create(path, label string, opts interface{}) error {
+	familyFields, err := pack.Fields(model.CodeFamily{})
+	if err != nil {
+		return err
+	}
+	memberFields, err := pack.Fields(model.CodeFamilyMember{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating %s database: %w", idx.Key(), err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		CodeFamilyTableKey,
+		familyFields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, CodeFamilyPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CodeFamilyJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, CodeFamilyCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, CodeFamilyFillLevel),
+		})
+	if err != nil {
+		return err
+	}
+	_, err = db.CreateTableIfNotExists(
+		CodeFamilyMemberTableKey,
+		memberFields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, CodeFamilyPackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CodeFamilyJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, CodeFamilyCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, CodeFamilyFillLevel),
+		})
+	return err
+}
+
+func (idx *CodeFamilyIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.families, err = idx.db.Table(CodeFamilyTableKey, pack.Options{
+		JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CodeFamilyJournalSizeLog2),
+		CacheSize:       util.NonZero(idx.opts.CacheSize, CodeFamilyCacheSize),
+	})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	idx.members, err = idx.db.Table(CodeFamilyMemberTableKey, pack.Options{
+		JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, CodeFamilyJournalSizeLog2),
+		CacheSize:       util.NonZero(idx.opts.CacheSize, CodeFamilyCacheSize),
+	})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+func (idx *CodeFamilyIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *CodeFamilyIndex) Close() error {
+	for _, v := range idx.Tables() {
+		if v != nil {
+			if err := v.Close(); err != nil {
+				log.Errorf("Closing %s table: %s", v.Name(), err)
+			}
+		}
+	}
+	idx.families = nil
+	idx.members = nil
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+type codeFamilyKey struct {
+	kind model.CodeFamilyKind
+	hash uint64
+}
+
+// loadFamilies loads existing families for the given keys.
+func (idx *CodeFamilyIndex) loadFamilies(ctx context.Context, keys []codeFamilyKey) (map[codeFamilyKey]*model.CodeFamily, error) {
+	hashes := make([]uint64, 0, len(keys))
+	for _, k := range keys {
+		hashes = append(hashes, k.hash)
+	}
+	families := make(map[codeFamilyKey]*model.CodeFamily)
+	err := pack.NewQuery("etl.code_family.search").
+		WithTable(idx.families).
+		AndIn("hash", hashes).
+		Stream(ctx, func(r pack.Row) error {
+			f := &model.CodeFamily{}
+			if err := r.Decode(f); err != nil {
+				return err
+			}
+			families[codeFamilyKey{f.Kind, f.Hash}] = f
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return families, nil
+}
+
+func (idx *CodeFamilyIndex) ConnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	members := make([]pack.Item, 0)
+	keys := make([]codeFamilyKey, 0)
+	for _, op := range block.Ops {
+		if !op.IsSuccess {
+			continue
+		}
+		switch op.Type {
+		case model.OpTypeOrigination, model.OpTypeMigration:
+		default:
+			continue
+		}
+		contract, ok := builder.ContractById(op.ReceiverId)
+		if !ok || !contract.IsNew {
+			continue
+		}
+		m, err := model.NewCodeFamilyMember(contract, block)
+		if err != nil {
+			return fmt.Errorf("code_family: decoding script for %s: %w", contract.Address, err)
+		}
+		if m == nil {
+			continue
+		}
+		members = append(members, m)
+		for _, kind := range codeFamilyKinds {
+			keys = append(keys, codeFamilyKey{kind, m.Hash(kind)})
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	families, err := idx.loadFamilies(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("code_family: load: %w", err)
+	}
+
+	ins := make([]pack.Item, 0)
+	upd := make([]pack.Item, 0)
+	dirty := make(map[codeFamilyKey]bool)
+	for _, v := range members {
+		m := v.(*model.CodeFamilyMember)
+		for _, kind := range codeFamilyKinds {
+			k := codeFamilyKey{kind, m.Hash(kind)}
+			f, ok := families[k]
+			if !ok {
+				f = &model.CodeFamily{
+					Kind:       kind,
+					Hash:       k.hash,
+					ContractId: m.AccountId,
+					CreatorId:  m.CreatorId,
+					FirstSeen:  m.Height,
+					FirstTime:  m.Timestamp,
+				}
+				families[k] = f
+				ins = append(ins, f)
+			} else if f.RowId > 0 && !dirty[k] {
+				upd = append(upd, f)
+			}
+			dirty[k] = true
+			f.NContracts++
+			f.LastSeen = m.Height
+			f.LastTime = m.Timestamp
+		}
+	}
+
+	if err := model.Insert(ctx, idx.members, members); err != nil {
+		return fmt.Errorf("code_family: insert members: %w", err)
+	}
+	if err := model.Insert(ctx, idx.families, ins); err != nil {
+		return fmt.Errorf("code_family: insert: %w", err)
+	}
+	if err := model.Update(ctx, idx.families, upd); err != nil {
+		return fmt.Errorf("code_family: update: %w", err)
+	}
+	return nil
+}
+
+func (idx *CodeFamilyIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
+	return idx.DeleteBlock(ctx, block.Height)
+}
+
+func (idx *CodeFamilyIndex) DeleteBlock(ctx context.Context, height int64) error {
+	// find members originated at this height
+	counts := make(map[codeFamilyKey]int)
+	keys := make([]codeFamilyKey, 0)
+	m := &model.CodeFamilyMember{}
+	err := pack.NewQuery("etl.code_family_member.search").
+		WithTable(idx.members).
+		AndEqual("height", height).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(m); err != nil {
+				return err
+			}
+			for _, kind := range codeFamilyKinds {
+				k := codeFamilyKey{kind, m.Hash(kind)}
+				if counts[k] == 0 {
+					keys = append(keys, k)
+				}
+				counts[k]++
+			}
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err = model.Delete(ctx, idx.members, pack.NewQuery("etl.code_family_member.delete").
+		AndEqual("height", height))
+	if err != nil {
+		return err
+	}
+
+	// shrink families and remove empty ones
+	families, err := idx.loadFamilies(ctx, keys)
+	if err != nil {
+		return err
+	}
+	upd := make([]pack.Item, 0)
+	del := make([]uint64, 0)
+	for _, k := range keys {
+		f, ok := families[k]
+		if !ok {
+			continue
+		}
+		f.NContracts -= counts[k]
+		if f.NContracts <= 0 {
+			del = append(del, f.RowId)
+			continue
+		}
+		// reset last seen from the most recent remaining member
+		last := &model.CodeFamilyMember{}
+		err := pack.NewQuery("etl.code_family_member.last").
+			WithTable(idx.members).
+			WithDesc().
+			WithLimit(1).
+			AndEqual(k.kind.HashField(), k.hash).
+			Execute(ctx, last)
+		if err != nil {
+			return err
+		}
+		if last.RowId > 0 {
+			f.LastSeen = last.Height
+			f.LastTime = last.Timestamp
+		}
+		upd = append(upd, f)
+	}
+	if err := model.Update(ctx, idx.families, upd); err != nil {
+		return err
+	}
+	if len(del) > 0 {
+		if err := model.DeleteIds(ctx, idx.families, del); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *CodeFamilyIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	return nil
+}
+
+func (idx *CodeFamilyIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
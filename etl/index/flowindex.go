@@ -120,7 +120,7 @@ func (idx *FlowIndex) ConnectBlock(ctx context.Context, block *model.Block, _ mo
 	for _, f := range block.Flows {
 		flows = append(flows, f)
 	}
-	return idx.table.Insert(ctx, flows)
+	return model.Insert(ctx, idx.table, flows)
 }
 
 func (idx *FlowIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -129,19 +129,15 @@ func (idx *FlowIndex) DisconnectBlock(ctx context.Context, block *model.Block, _
 
 func (idx *FlowIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting flows at height %d", height)
-	_, err := pack.NewQuery("etl.flow.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.flow.delete").
+		AndEqual("height", height))
 	return err
 }
 
 func (idx *FlowIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting flow cycle %d", cycle)
-	_, err := pack.NewQuery("etl.flow.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.flow.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
@@ -27,6 +27,13 @@ import (
 
 const (
 	firstVoteBlock int64 = 2 // chain is initialized at block 1 !
+
+	// SupermajorityPct is the minimum yay share of yay and nay stake in
+	// exploration and promotion periods in percent with 2 digits precision.
+	// The 80% threshold is fixed in the amendment rules of all protocols
+	// (see https://tezos.gitlab.io/active/voting.html) and is not part of
+	// protocol constants.
+	SupermajorityPct int64 = 8000
 )
 
 var (
@@ -365,46 +372,36 @@ func (idx *GovIndex) DisconnectBlock(ctx context.Context, block *model.Block, bu
 
 func (idx *GovIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// delete ballots by height
-	_, err := pack.NewQuery("etl.ballots.delete").
-		WithTable(idx.ballotTable).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.ballotTable, pack.NewQuery("etl.ballots.delete").
+		AndEqual("height", height))
 	if err != nil {
 		return err
 	}
 
 	// delete proposals by height
-	_, err = pack.NewQuery("etl.proposals.delete").
-		WithTable(idx.proposalTable).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.proposalTable, pack.NewQuery("etl.proposals.delete").
+		AndEqual("height", height))
 	if err != nil {
 		return err
 	}
 
 	// on vote period start, delete vote by start height
-	_, err = pack.NewQuery("etl.vote.delete").
-		WithTable(idx.voteTable).
-		AndEqual("period_start_height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.voteTable, pack.NewQuery("etl.vote.delete").
+		AndEqual("period_start_height", height))
 	if err != nil {
 		return err
 	}
 
 	// on election start, delete election (Note: will shift row id counter!!)
-	_, err = pack.NewQuery("etl.election.delete").
-		WithTable(idx.electionTable).
-		AndEqual("start_height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.electionTable, pack.NewQuery("etl.election.delete").
+		AndEqual("start_height", height))
 	if err != nil {
 		return err
 	}
 
 	// on vote period end delete snapshot
-	_, err = pack.NewQuery("etl.election.delete").
-		WithTable(idx.rollsTable).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.rollsTable, pack.NewQuery("etl.election.delete").
+		AndEqual("height", height))
 	if err != nil {
 		return err
 	}
@@ -430,7 +427,7 @@ func (idx *GovIndex) openElection(ctx context.Context, block *model.Block, build
 		NoQuorum:     false,
 		NoMajority:   false,
 	}
-	return idx.electionTable.Insert(ctx, election)
+	return model.Insert(ctx, idx.electionTable, election)
 }
 
 func (idx *GovIndex) closeElection(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
@@ -460,7 +457,7 @@ func (idx *GovIndex) closeElection(ctx context.Context, block *model.Block, buil
 	election.IsFailed = vote.IsFailed
 	election.NoQuorum = vote.NoQuorum
 	election.NoMajority = vote.NoMajority
-	return idx.electionTable.Update(ctx, election)
+	return model.Update(ctx, idx.electionTable, election)
 }
 
 func (idx *GovIndex) reopenElection(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
@@ -475,7 +472,7 @@ func (idx *GovIndex) reopenElection(ctx context.Context, block *model.Block, bui
 	}
 	// just update state (will roll forward at end of reorg)
 	election.IsOpen = false
-	return idx.electionTable.Update(ctx, election)
+	return model.Update(ctx, idx.electionTable, election)
 }
 
 func (idx *GovIndex) openVote(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
@@ -536,12 +533,12 @@ func (idx *GovIndex) openVote(ctx context.Context, block *model.Block, builder m
 	vote.QuorumStake = vote.EligibleStake * vote.QuorumPct / 10000
 
 	// insert vote
-	if err := idx.voteTable.Insert(ctx, vote); err != nil {
+	if err := model.Insert(ctx, idx.voteTable, vote); err != nil {
 		return err
 	}
 
 	// update election
-	return idx.electionTable.Update(ctx, election)
+	return model.Update(ctx, idx.electionTable, election)
 }
 
 func (idx *GovIndex) closeVote(ctx context.Context, block *model.Block, builder model.BlockBuilder) (bool, error) {
@@ -595,7 +592,7 @@ func (idx *GovIndex) closeVote(ctx context.Context, block *model.Block, builder
 				}
 				// store winner and update election
 				election.ProposalId = winner
-				if err := idx.electionTable.Update(ctx, election); err != nil {
+				if err := model.Update(ctx, idx.electionTable, election); err != nil {
 					return false, err
 				}
 				vote.ProposalId = winner
@@ -608,7 +605,7 @@ func (idx *GovIndex) closeVote(ctx context.Context, block *model.Block, builder
 
 	case tezos.VotingPeriodExploration, tezos.VotingPeriodPromotion:
 		vote.NoQuorum = vote.TurnoutStake < vote.QuorumStake
-		vote.NoMajority = vote.YayStake < (vote.YayStake+vote.NayStake)*8/10
+		vote.NoMajority = !IsSupermajority(vote.YayStake, vote.NayStake)
 		vote.IsFailed = vote.NoQuorum || vote.NoMajority
 
 	case tezos.VotingPeriodCooldown, tezos.VotingPeriodAdoption:
@@ -618,7 +615,7 @@ func (idx *GovIndex) closeVote(ctx context.Context, block *model.Block, builder
 	vote.EndTime = block.Timestamp
 	vote.IsOpen = false
 
-	if err := idx.voteTable.Update(ctx, vote); err != nil {
+	if err := model.Update(ctx, idx.voteTable, vote); err != nil {
 		return false, err
 	}
 
@@ -642,7 +639,7 @@ func (idx *GovIndex) reopenVote(ctx context.Context, block *model.Block, builder
 
 	// just reset state flag
 	vote.IsOpen = true
-	if err := idx.voteTable.Update(ctx, vote); err != nil {
+	if err := model.Update(ctx, idx.voteTable, vote); err != nil {
 		return false, err
 	}
 
@@ -704,7 +701,7 @@ func (idx *GovIndex) processProposals(ctx context.Context, block *model.Block, b
 
 	// insert unknown proposals to create ids
 	if len(insProposals) > 0 {
-		if err := idx.proposalTable.Insert(ctx, insProposals); err != nil {
+		if err := model.Insert(ctx, idx.proposalTable, insProposals); err != nil {
 			return err
 		}
 		for _, v := range insProposals {
@@ -722,7 +719,7 @@ func (idx *GovIndex) processProposals(ctx context.Context, block *model.Block, b
 		}
 		election.IsEmpty = false
 		election.NumProposals += len(insProposals)
-		if err := idx.electionTable.Update(ctx, election); err != nil {
+		if err := model.Update(ctx, idx.electionTable, election); err != nil {
 			return err
 		}
 	}
@@ -831,7 +828,7 @@ func (idx *GovIndex) processProposals(ctx context.Context, block *model.Block, b
 	} else {
 		vote.TurnoutPct = vote.TurnoutStake * 10000 / vote.EligibleStake
 	}
-	if err := idx.voteTable.Update(ctx, vote); err != nil {
+	if err := model.Update(ctx, idx.voteTable, vote); err != nil {
 		return err
 	}
 
@@ -840,10 +837,10 @@ func (idx *GovIndex) processProposals(ctx context.Context, block *model.Block, b
 	for _, v := range proposalMap {
 		insProposals = append(insProposals, v)
 	}
-	if err := idx.proposalTable.Update(ctx, insProposals); err != nil {
+	if err := model.Update(ctx, idx.proposalTable, insProposals); err != nil {
 		return err
 	}
-	return idx.ballotTable.Insert(ctx, insBallots)
+	return model.Insert(ctx, idx.ballotTable, insBallots)
 }
 
 func (idx *GovIndex) processBallots(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
@@ -935,10 +932,10 @@ func (idx *GovIndex) processBallots(ctx context.Context, block *model.Block, bui
 	} else {
 		vote.TurnoutPct = vote.TurnoutStake * 10000 / vote.EligibleStake
 	}
-	if err := idx.voteTable.Update(ctx, vote); err != nil {
+	if err := model.Update(ctx, idx.voteTable, vote); err != nil {
 		return err
 	}
-	return idx.ballotTable.Insert(ctx, insBallots)
+	return model.Insert(ctx, idx.ballotTable, insBallots)
 }
 
 func (idx *GovIndex) electionByHeight(ctx context.Context, height int64, params *tezos.Params) (*model.Election, error) {
@@ -1042,7 +1039,7 @@ func (idx *GovIndex) makeRollSnapshot(ctx context.Context, block *model.Block, b
 		}
 		ins = append(ins, snap)
 	}
-	return idx.rollsTable.Insert(ctx, ins)
+	return model.Insert(ctx, idx.rollsTable, ins)
 }
 
 func (idx *GovIndex) snapshotByHeight(ctx context.Context, aid model.AccountID, height int64) (int64, int64, error) {
@@ -1140,3 +1137,10 @@ func (idx *GovIndex) Flush(ctx context.Context) error {
 	}
 	return nil
 }
+
+// IsSupermajority reports whether yay stake reaches SupermajorityPct of yay
+// and nay stake. The threshold is split to avoid overflows on large stake.
+func IsSupermajority(yay, nay int64) bool {
+	n := yay + nay
+	return yay >= n/10000*SupermajorityPct+n%10000*SupermajorityPct/10000
+}
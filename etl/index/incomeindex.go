@@ -211,10 +211,8 @@ func (idx *IncomeIndex) DeleteBlock(ctx context.Context, height int64) error {
 
 func (idx *IncomeIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting income for cycle %d", cycle)
-	_, err := pack.NewQuery("etl.income.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.income.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
@@ -365,7 +363,7 @@ func (idx *IncomeIndex) bootstrapIncome(ctx context.Context, block *model.Block,
 		for i, v := range inc {
 			ins[i] = v
 		}
-		if err := idx.table.Insert(ctx, ins); err != nil {
+		if err := model.Insert(ctx, idx.table, ins); err != nil {
 			return err
 		}
 	}
@@ -420,7 +418,7 @@ func (idx *IncomeIndex) updateCycleIncome(ctx context.Context, block *model.Bloc
 			v.UpdateLuck(totalRolls, p)
 			upd[i] = v
 		}
-		if err := idx.table.Update(ctx, upd); err != nil {
+		if err := model.Update(ctx, idx.table, upd); err != nil {
 			return err
 		}
 	}
@@ -664,7 +662,7 @@ func (idx *IncomeIndex) createCycleIncome(ctx context.Context, block *model.Bloc
 	for i, v := range inc {
 		ins[i] = v
 	}
-	return idx.table.Insert(ctx, ins)
+	return model.Insert(ctx, idx.table, ins)
 }
 
 func (idx *IncomeIndex) updateBlockIncome(ctx context.Context, block *model.Block, builder model.BlockBuilder, isRollback bool) error {
@@ -701,7 +699,7 @@ func (idx *IncomeIndex) updateBlockIncome(ctx context.Context, block *model.Bloc
 			in.TotalDeposits = bkr.FrozenDeposits
 			upd = append(upd, in)
 		}
-		if err := idx.table.Update(ctx, upd); err != nil {
+		if err := model.Update(ctx, idx.table, upd); err != nil {
 			return err
 		}
 	}
@@ -934,7 +932,7 @@ func (idx *IncomeIndex) updateBlockIncome(ctx context.Context, block *model.Bloc
 		v.UpdatePerformance(reliability)
 		upd = append(upd, v)
 	}
-	return idx.table.Update(ctx, upd)
+	return model.Update(ctx, idx.table, upd)
 }
 
 func (idx *IncomeIndex) updateNonceRevelations(ctx context.Context, block *model.Block, builder model.BlockBuilder, isRollback bool) error {
@@ -992,7 +990,7 @@ func (idx *IncomeIndex) updateNonceRevelations(ctx context.Context, block *model
 		v.UpdatePerformance(reliability)
 		upd = append(upd, v)
 	}
-	return idx.table.Update(ctx, upd)
+	return model.Update(ctx, idx.table, upd)
 }
 
 func (idx *IncomeIndex) loadIncome(ctx context.Context, cycle int64, id model.AccountID) (*model.Income, error) {
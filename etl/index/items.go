@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// tableItems maps table keys to the model type of their rows.
+var tableItems = map[string]func() pack.Item{
+	AccountTableKey:           func() pack.Item { return &model.Account{} },
+	BakerTableKey:             func() pack.Item { return &model.Baker{} },
+	BalanceTableKey:           func() pack.Item { return &model.Balance{} },
+	BigmapFieldTableKey:       func() pack.Item { return &model.BigmapField{} },
+	BigmapAllocTableKey:       func() pack.Item { return &model.BigmapAlloc{} },
+	BigmapUpdateTableKey:      func() pack.Item { return &model.BigmapUpdate{} },
+	BigmapValueTableKey:       func() pack.Item { return &model.BigmapKV{} },
+	BlockTableKey:             func() pack.Item { return &model.Block{} },
+	ContractCallTableKey:      func() pack.Item { return &model.ContractCallStats{} },
+	ChainTableKey:             func() pack.Item { return &model.Chain{} },
+	CohortTableKey:            func() pack.Item { return &model.CohortMember{} },
+	ConsensusKeyTableKey:      func() pack.Item { return &model.ConsensusKey{} },
+	ConstantTableKey:          func() pack.Item { return &model.Constant{} },
+	ContractTableKey:          func() pack.Item { return &model.Contract{} },
+	TransferEdgeTableKey:      func() pack.Item { return &model.TransferEdge{} },
+	TransferEdgeCycleTableKey: func() pack.Item { return &model.TransferEdge{} },
+	EventTableKey:             func() pack.Item { return &model.Event{} },
+	CodeFamilyTableKey:        func() pack.Item { return &model.CodeFamily{} },
+	CodeFamilyMemberTableKey:  func() pack.Item { return &model.CodeFamilyMember{} },
+	FlowTableKey:              func() pack.Item { return &model.Flow{} },
+	ElectionTableKey:          func() pack.Item { return &model.Election{} },
+	ProposalTableKey:          func() pack.Item { return &model.Proposal{} },
+	VoteTableKey:              func() pack.Item { return &model.Vote{} },
+	BallotTableKey:            func() pack.Item { return &model.Ballot{} },
+	RollsTableKey:             func() pack.Item { return &model.RollSnapshot{} },
+	IncomeTableKey:            func() pack.Item { return &model.Income{} },
+	MetadataTableKey:          func() pack.Item { return &model.Metadata{} },
+	OpTableKey:                func() pack.Item { return &model.Op{} },
+	EndorseOpTableKey:         func() pack.Item { return &model.Endorsement{} },
+	OpErrorTableKey:           func() pack.Item { return &model.OpError{} },
+	PriceTableKey:             func() pack.Item { return &model.Price{} },
+	RightsTableKey:            func() pack.Item { return &model.Right{} },
+	SnapshotTableKey:          func() pack.Item { return &model.Snapshot{} },
+	StorageTableKey:           func() pack.Item { return &model.Storage{} },
+	SupplyTableKey:            func() pack.Item { return &model.Supply{} },
+	TicketTypeTableKey:        func() pack.Item { return &model.TicketType{} },
+	TicketUpdateTableKey:      func() pack.Item { return &model.TicketUpdate{} },
+}
+
+// TableItemFunc returns a constructor for rows of the table with key.
+func TableItemFunc(key string) (func() pack.Item, bool) {
+	fn, ok := tableItems[key]
+	return fn, ok
+}
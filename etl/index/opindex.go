@@ -10,6 +10,7 @@ import (
 
 	"blockwatch.cc/packdb/pack"
 	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
 
 	"blockwatch.cc/tzindex/etl/model"
 )
@@ -27,6 +28,12 @@ const (
 	EndorseOpCacheSize       = 2  // minimum, not essential
 	EndorseOpFillLevel       = 100
 	EndorseOpTableKey        = "endorsement"
+
+	OpErrorPackSizeLog2    = 15 // 32k packs
+	OpErrorJournalSizeLog2 = 15 // 32k
+	OpErrorCacheSize       = 2  // minimum, not essential
+	OpErrorFillLevel       = 100
+	OpErrorTableKey        = "op_error"
 )
 
 var (
@@ -40,6 +47,7 @@ type OpIndex struct {
 	opts    pack.Options
 	table   *pack.Table
 	endorse *pack.Table // separate table, must query explicitly
+	errors  *pack.Table // classified errors of failed ops
 }
 
 var _ model.BlockIndexer = (*OpIndex)(nil)
@@ -53,7 +61,7 @@ func (idx *OpIndex) DB() *pack.DB {
 }
 
 func (idx *OpIndex) Tables() []*pack.Table {
-	return []*pack.Table{idx.table, idx.endorse}
+	return []*pack.Table{idx.table, idx.endorse, idx.errors}
 }
 
 func (idx *OpIndex) Key() string {
@@ -105,6 +113,23 @@ func (idx *OpIndex) Create(path, label string, opts interface{}) error {
 		return err
 	}
 
+	fields, err = pack.Fields(model.OpError{})
+	if err != nil {
+		return err
+	}
+	_, err = db.CreateTableIfNotExists(
+		OpErrorTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    OpErrorPackSizeLog2,
+			JournalSizeLog2: OpErrorJournalSizeLog2,
+			CacheSize:       OpErrorCacheSize,
+			FillLevel:       OpErrorFillLevel,
+		})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -134,6 +159,25 @@ func (idx *OpIndex) Init(path, label string, opts interface{}) error {
 		idx.Close()
 		return err
 	}
+	// op errors were added later, create the table on existing databases
+	fields, err := pack.Fields(model.OpError{})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	idx.errors, err = idx.db.CreateTableIfNotExists(
+		OpErrorTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    OpErrorPackSizeLog2,
+			JournalSizeLog2: OpErrorJournalSizeLog2,
+			CacheSize:       OpErrorCacheSize,
+			FillLevel:       OpErrorFillLevel,
+		})
+	if err != nil {
+		idx.Close()
+		return err
+	}
 	return nil
 }
 
@@ -151,6 +195,7 @@ func (idx *OpIndex) Close() error {
 	}
 	idx.table = nil
 	idx.endorse = nil
+	idx.errors = nil
 	if idx.db != nil {
 		if err := idx.db.Close(); err != nil {
 			return err
@@ -200,7 +245,7 @@ func (idx *OpIndex) ConnectBlock(ctx context.Context, block *model.Block, b mode
 		}
 	}
 	if len(endorse) > 0 {
-		if err := idx.endorse.Insert(ctx, endorse); err != nil {
+		if err := model.Insert(ctx, idx.endorse, endorse); err != nil {
 			return err
 		}
 		// assign op ids back to the original ops
@@ -209,7 +254,20 @@ func (idx *OpIndex) ConnectBlock(ctx context.Context, block *model.Block, b mode
 			block.Ops[ed.OpN].RowId = ed.RowId
 		}
 	}
-	return idx.table.Insert(ctx, ops)
+	if err := model.Insert(ctx, idx.table, ops); err != nil {
+		return err
+	}
+
+	// classify errors of failed ops, op ids are known after insert
+	errs := make([]pack.Item, 0)
+	for _, v := range ops {
+		op := v.(*model.Op)
+		if op.IsSuccess || op.Status == tezos.OpStatusApplied || op.Status == tezos.OpStatusInvalid {
+			continue
+		}
+		errs = append(errs, model.NewOpError(op))
+	}
+	return model.Insert(ctx, idx.errors, errs)
 }
 
 func (idx *OpIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -218,17 +276,18 @@ func (idx *OpIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ m
 
 func (idx *OpIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting ops at height %d", height)
-	_, err := pack.NewQuery("etl.op.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.op.delete").
+		AndEqual("height", height))
+	if err != nil {
+		return err
+	}
+	_, err = model.Delete(ctx, idx.endorse, pack.NewQuery("etl.endorse.delete").
+		AndEqual("height", height))
 	if err != nil {
 		return err
 	}
-	_, err = pack.NewQuery("etl.endorse.delete").
-		WithTable(idx.endorse).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.errors, pack.NewQuery("etl.op_error.delete").
+		AndEqual("height", height))
 	return err
 }
 
@@ -255,18 +314,19 @@ func (idx *OpIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	if err != nil {
 		return err
 	}
-	_, err = pack.NewQuery("etl.op.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err = model.Delete(ctx, idx.table, pack.NewQuery("etl.op.delete").
+		AndEqual("cycle", cycle))
 	if err != nil {
 		return err
 	}
 	if first > 0 && last >= first {
-		_, err = pack.NewQuery("etl.endorse.delete").
-			WithTable(idx.endorse).
-			AndRange("height", first, last).
-			Delete(ctx)
+		_, err = model.Delete(ctx, idx.endorse, pack.NewQuery("etl.endorse.delete").
+			AndRange("height", first, last))
+		if err != nil {
+			return err
+		}
+		_, err = model.Delete(ctx, idx.errors, pack.NewQuery("etl.op_error.delete").
+			AndRange("height", first, last))
 	}
 	return err
 }
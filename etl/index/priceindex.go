@@ -0,0 +1,146 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+const (
+	PricePackSizeLog2    = 15 // 32k
+	PriceJournalSizeLog2 = 14 // 16k
+	PriceCacheSize       = 2
+	PriceFillLevel       = 100
+	PriceIndexKey        = "price"
+	PriceTableKey        = "price"
+)
+
+var (
+	ErrNoPriceEntry = errors.New("price not found")
+)
+
+// PriceIndex stores imported fiat prices. Like metadata, prices are managed
+// via API and do not depend on chain data.
+type PriceIndex struct {
+	db    *pack.DB
+	opts  pack.Options
+	table *pack.Table
+}
+
+var _ model.BlockIndexer = (*PriceIndex)(nil)
+
+func NewPriceIndex(opts pack.Options) *PriceIndex {
+	return &PriceIndex{opts: opts}
+}
+
+func (idx *PriceIndex) DB() *pack.DB {
+	return idx.db
+}
+
+func (idx *PriceIndex) Tables() []*pack.Table {
+	return []*pack.Table{idx.table}
+}
+
+func (idx *PriceIndex) Key() string {
+	return PriceIndexKey
+}
+
+func (idx *PriceIndex) Name() string {
+	return PriceIndexKey + " index"
+}
+
+func (idx *PriceIndex) Create(path, label string, opts interface{}) error {
+	fields, err := pack.Fields(model.Price{})
+	if err != nil {
+		return err
+	}
+	db, err := pack.CreateDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return fmt.Errorf("creating %s database: %w", idx.Key(), err)
+	}
+	defer db.Close()
+
+	_, err = db.CreateTableIfNotExists(
+		PriceTableKey,
+		fields,
+		pack.Options{
+			PackSizeLog2:    util.NonZero(idx.opts.PackSizeLog2, PricePackSizeLog2),
+			JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, PriceJournalSizeLog2),
+			CacheSize:       util.NonZero(idx.opts.CacheSize, PriceCacheSize),
+			FillLevel:       util.NonZero(idx.opts.FillLevel, PriceFillLevel),
+		})
+	return err
+}
+
+func (idx *PriceIndex) Init(path, label string, opts interface{}) error {
+	var err error
+	idx.db, err = pack.OpenDatabase(path, idx.Key(), label, opts)
+	if err != nil {
+		return err
+	}
+	idx.table, err = idx.db.Table(PriceTableKey, pack.Options{
+		JournalSizeLog2: util.NonZero(idx.opts.JournalSizeLog2, PriceJournalSizeLog2),
+		CacheSize:       util.NonZero(idx.opts.CacheSize, PriceCacheSize),
+	})
+	if err != nil {
+		idx.Close()
+		return err
+	}
+	return nil
+}
+
+func (idx *PriceIndex) FinalizeSync(_ context.Context) error {
+	return nil
+}
+
+func (idx *PriceIndex) Close() error {
+	if idx.table != nil {
+		if err := idx.table.Close(); err != nil {
+			log.Errorf("Closing %s: %s", idx.Name(), err)
+		}
+		idx.table = nil
+	}
+	if idx.db != nil {
+		if err := idx.db.Close(); err != nil {
+			return err
+		}
+		idx.db = nil
+	}
+	return nil
+}
+
+func (idx *PriceIndex) ConnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	// noop
+	return nil
+}
+
+func (idx *PriceIndex) DisconnectBlock(ctx context.Context, block *model.Block, builder model.BlockBuilder) error {
+	// noop
+	return nil
+}
+
+func (idx *PriceIndex) DeleteBlock(ctx context.Context, height int64) error {
+	// noop
+	return nil
+}
+
+func (idx *PriceIndex) DeleteCycle(ctx context.Context, cycle int64) error {
+	// noop
+	return nil
+}
+
+func (idx *PriceIndex) Flush(ctx context.Context) error {
+	for _, v := range idx.Tables() {
+		if err := v.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -169,7 +169,7 @@ func (idx *RightsIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 		}
 
 		// write back to table
-		if err := idx.table.Update(ctx, upd); err != nil {
+		if err := model.Update(ctx, idx.table, upd); err != nil {
 			return err
 		}
 	}
@@ -185,7 +185,7 @@ func (idx *RightsIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 			if p.IsSeedRequired(block.Height) {
 				right.Seed.Set(int((block.Height - start) / p.BlocksPerCommitment))
 			}
-			if err := idx.table.Update(ctx, right); err != nil {
+			if err := model.Update(ctx, idx.table, right); err != nil {
 				return err
 			}
 			// update reliability
@@ -220,7 +220,7 @@ func (idx *RightsIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 				// on protocol upgrades we may see extra endorsers, create rights here
 				right = model.NewRight(id, cycle, int(param.BlocksPerCycle), int(param.BlocksPerCycle/param.BlocksPerCommitment))
 				log.Debugf("rights: add extra endorsing right for account %d on block %d (-1)", id, block.Height)
-				if err := idx.table.Insert(ctx, right); err != nil {
+				if err := model.Insert(ctx, idx.table, right); err != nil {
 					return fmt.Errorf("rights: adding extra exdorsing right: %v", err)
 				}
 				// don't add to cache
@@ -234,7 +234,7 @@ func (idx *RightsIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 		}
 
 		// write back to table
-		if err := idx.table.Update(ctx, upd); err != nil {
+		if err := model.Update(ctx, idx.table, upd); err != nil {
 			return err
 		}
 	}
@@ -355,7 +355,7 @@ func (idx *RightsIndex) ConnectBlock(ctx context.Context, block *model.Block, bu
 
 		// insert full cycle worth of rights
 		log.Debugf("Inserting %d records with %d baking and %d endorsing rights", len(ins), bcnt, ecnt)
-		if err := idx.table.Insert(ctx, ins); err != nil {
+		if err := model.Insert(ctx, idx.table, ins); err != nil {
 			return err
 		}
 		ins = ins[:0]
@@ -393,7 +393,7 @@ func (idx *RightsIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 		if err != nil {
 			return fmt.Errorf("rights: SOC disconnect for block %d: %w", block.Height, err)
 		}
-		if err := idx.table.Update(ctx, upd); err != nil {
+		if err := model.Update(ctx, idx.table, upd); err != nil {
 			return err
 		}
 		return idx.DeleteCycle(ctx, block.Cycle+block.Params.PreservedCycles)
@@ -425,7 +425,7 @@ func (idx *RightsIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 		if err != nil {
 			return fmt.Errorf("rights: MOC disconnect for block %d: %w", block.Height, err)
 		}
-		return idx.table.Update(ctx, upd)
+		return model.Update(ctx, idx.table, upd)
 	}
 }
 
@@ -435,10 +435,8 @@ func (idx *RightsIndex) DeleteBlock(ctx context.Context, height int64) error {
 
 func (idx *RightsIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting rights for cycle %d", cycle)
-	_, err := pack.NewQuery("etl.rights.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.rights.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
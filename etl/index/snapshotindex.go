@@ -206,7 +206,7 @@ func (idx *SnapshotIndex) ConnectBlock(ctx context.Context, block *model.Block,
 	// sort by account id for improved table compression
 	sort.Slice(ins, func(i, j int) bool { return ins[i].(*model.Snapshot).AccountId < ins[j].(*model.Snapshot).AccountId })
 
-	err = idx.table.Insert(ctx, ins)
+	err = model.Insert(ctx, idx.table, ins)
 	for _, v := range ins {
 		v.(*model.Snapshot).Free()
 	}
@@ -265,7 +265,7 @@ func (idx *SnapshotIndex) ConnectBlock(ctx context.Context, block *model.Block,
 	// sort by account id for improved table compression
 	sort.Slice(ins, func(i, j int) bool { return ins[i].(*model.Snapshot).AccountId < ins[j].(*model.Snapshot).AccountId })
 
-	err = idx.table.Insert(ctx, ins)
+	err = model.Insert(ctx, idx.table, ins)
 	for _, v := range ins {
 		v.(*model.Snapshot).Free()
 	}
@@ -282,19 +282,15 @@ func (idx *SnapshotIndex) DisconnectBlock(ctx context.Context, block *model.Bloc
 
 func (idx *SnapshotIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting snapshots at height %d", height)
-	_, err := pack.NewQuery("etl.snapshot.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.snapshot.delete").
+		AndEqual("height", height))
 	return err
 }
 
 func (idx *SnapshotIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting snapshots for cycle %d", cycle)
-	_, err := pack.NewQuery("etl.snapshot.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.snapshot.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
@@ -332,7 +328,7 @@ func (idx *SnapshotIndex) updateCycleSnapshot(ctx context.Context, block *model.
 	}
 
 	// store update
-	if err := idx.table.Update(ctx, upd); err != nil {
+	if err := model.Update(ctx, idx.table, upd); err != nil {
 		return err
 	}
 	for _, v := range upd {
@@ -340,12 +336,10 @@ func (idx *SnapshotIndex) updateCycleSnapshot(ctx context.Context, block *model.
 	}
 
 	// delete non-selected snapshots
-	_, err = pack.NewQuery("snapshot.delete").
-		WithTable(idx.table).
+	_, err = model.Delete(ctx, idx.table, pack.NewQuery("snapshot.delete").
 		WithoutCache().
 		AndEqual("cycle", snap.Base).
-		AndEqual("is_selected", false).
-		Delete(ctx)
+		AndEqual("is_selected", false))
 	if err != nil {
 		return err
 	}
@@ -137,7 +137,7 @@ func (idx *StorageIndex) ConnectBlock(ctx context.Context, block *model.Block, b
     }
 
     // insert, will generate unique row ids
-    return idx.storages.Insert(ctx, ins)
+    return model.Insert(ctx, idx.storages, ins)
 }
 
 func (idx *StorageIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -145,10 +145,8 @@ func (idx *StorageIndex) DisconnectBlock(ctx context.Context, block *model.Block
 }
 
 func (idx *StorageIndex) DeleteBlock(ctx context.Context, height int64) error {
-    _, err := pack.NewQuery("etl.op.delete").
-        WithTable(idx.storages).
-        AndEqual("height", height).
-        Delete(ctx)
+    _, err := model.Delete(ctx, idx.storages, pack.NewQuery("etl.op.delete").
+        AndEqual("height", height))
     return err
 }
 
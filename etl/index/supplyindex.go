@@ -118,7 +118,7 @@ func (idx *SupplyIndex) Close() error {
 }
 
 func (idx *SupplyIndex) ConnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
-	return idx.table.Insert(ctx, []pack.Item{block.Supply})
+	return model.Insert(ctx, idx.table, []pack.Item{block.Supply})
 }
 
 func (idx *SupplyIndex) DisconnectBlock(ctx context.Context, block *model.Block, _ model.BlockBuilder) error {
@@ -127,19 +127,15 @@ func (idx *SupplyIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 
 func (idx *SupplyIndex) DeleteBlock(ctx context.Context, height int64) error {
 	// log.Debugf("Rollback deleting supply state at height %d", height)
-	_, err := pack.NewQuery("etl.supply.delete").
-		WithTable(idx.table).
-		AndEqual("height", height).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.supply.delete").
+		AndEqual("height", height))
 	return err
 }
 
 func (idx *SupplyIndex) DeleteCycle(ctx context.Context, cycle int64) error {
 	// log.Debugf("Rollback deleting supply for cycle %d", cycle)
-	_, err := pack.NewQuery("etl.supply.delete").
-		WithTable(idx.table).
-		AndEqual("cycle", cycle).
-		Delete(ctx)
+	_, err := model.Delete(ctx, idx.table, pack.NewQuery("etl.supply.delete").
+		AndEqual("cycle", cycle))
 	return err
 }
 
@@ -191,7 +191,7 @@ func (idx *TicketIndex) ConnectBlock(ctx context.Context, block *model.Block, b
 
     // batch insert all updates
     if len(ins) > 0 {
-        if err := idx.tables[TicketUpdateTableKey].Insert(ctx, ins); err != nil {
+        if err := model.Insert(ctx, idx.tables[TicketUpdateTableKey], ins); err != nil {
             return fmt.Errorf("ticket: insert: %w", err)
         }
     }
@@ -218,7 +218,7 @@ func (idx *TicketIndex) findOrCreateTicketType(ctx context.Context, t rpc.Ticket
         tt.Type = t.Type
         tt.Content = t.Content
         tt.Hash = key
-        if err := idx.tables[TicketTypeTableKey].Insert(ctx, tt); err != nil {
+        if err := model.Insert(ctx, idx.tables[TicketTypeTableKey], tt); err != nil {
             return nil, err
         }
     }
@@ -231,10 +231,8 @@ func (idx *TicketIndex) DisconnectBlock(ctx context.Context, block *model.Block,
 }
 
 func (idx *TicketIndex) DeleteBlock(ctx context.Context, height int64) error {
-    _, err := pack.NewQuery("etl.delete").
-        WithTable(idx.tables[TicketUpdateTableKey]).
-        AndEqual("height", height).
-        Delete(ctx)
+    _, err := model.Delete(ctx, idx.tables[TicketUpdateTableKey], pack.NewQuery("etl.delete").
+        AndEqual("height", height))
     return err
 }
 
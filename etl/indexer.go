@@ -46,8 +46,22 @@ type Indexer struct {
 	tips           map[string]*IndexTip
 	tables         map[string]*pack.Table
 	lightMode      bool
+	lmu            sync.RWMutex
+	listeners      []BlockListener
+	changes        *model.ChangeSet // table writes since the last notification
+	clisteners     []ChangeListener
 }
 
+// BlockListener is called after a block has been connected to or disconnected
+// from all indexes and the crawler has published the resulting chain tip.
+// Listeners run synchronously on the crawler goroutine and must not block.
+type BlockListener func(block *model.Block, builder model.BlockBuilder, connected bool)
+
+// ChangeListener is called after block listeners with all table writes made
+// since the previous call and the resulting chain tip. Writes made through
+// the API between blocks are included with the next block.
+type ChangeListener func(block *model.Block, tip *model.ChainTip, changes []model.TableChange, connected bool)
+
 func NewIndexer(cfg IndexerConfig) *Indexer {
 	return &Indexer{
 		dbpath:         cfg.DBPath,
@@ -65,6 +79,48 @@ func NewIndexer(cfg IndexerConfig) *Indexer {
 	}
 }
 
+// AddBlockListener registers fn to be notified about block updates.
+func (m *Indexer) AddBlockListener(fn BlockListener) {
+	m.lmu.Lock()
+	defer m.lmu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// AddChangeListener registers fn to be notified about table writes and
+// enables recording them. Must be called before the crawler starts.
+func (m *Indexer) AddChangeListener(fn ChangeListener) {
+	m.lmu.Lock()
+	defer m.lmu.Unlock()
+	if m.changes == nil {
+		m.changes = model.NewChangeSet()
+	}
+	m.clisteners = append(m.clisteners, fn)
+}
+
+// recordChanges returns a context that records table writes for change
+// listeners. Without listeners ctx is returned unchanged.
+func (m *Indexer) recordChanges(ctx context.Context) context.Context {
+	if m.changes == nil {
+		return ctx
+	}
+	return model.WithChangeSet(ctx, m.changes)
+}
+
+func (m *Indexer) notifyListeners(block *model.Block, builder model.BlockBuilder, tip *model.ChainTip, connected bool) {
+	m.lmu.RLock()
+	defer m.lmu.RUnlock()
+	for _, fn := range m.listeners {
+		fn(block, builder, connected)
+	}
+	if m.changes == nil {
+		return
+	}
+	changes := m.changes.Take()
+	for _, fn := range m.clisteners {
+		fn(block, tip, changes, connected)
+	}
+}
+
 func (m *Indexer) ParamsByHeight(height int64) *tezos.Params {
 	return m.reg.GetParamsByHeight(height)
 }
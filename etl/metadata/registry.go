@@ -8,9 +8,15 @@ import (
 	"fmt"
 	"github.com/echa/config"
 	"sort"
+	"sync"
 )
 
-var registry *Registry
+var (
+	registry *Registry
+
+	// extensions loaded from config mapped to the schema they replaced
+	extensions = make(map[string]Schema)
+)
 
 func RegisterSchema(s Schema) {
 	if registry == nil {
@@ -42,6 +48,7 @@ func ListSchemas() []string {
 }
 
 type Registry struct {
+	mu      sync.RWMutex
 	schemas map[string]Schema
 }
 
@@ -52,15 +59,27 @@ func NewRegistry() *Registry {
 }
 
 func (r *Registry) Register(s Schema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.schemas[s.Namespace()] = s
 }
 
-func (r Registry) Get(name string) (Schema, bool) {
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.schemas, name)
+}
+
+func (r *Registry) Get(name string) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	s, ok := r.schemas[name]
 	return s, ok
 }
 
-func (r Registry) ListSchemas() []string {
+func (r *Registry) ListSchemas() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	l := make([]string, 0)
 	for n := range r.schemas {
 		l = append(l, n)
@@ -69,12 +88,16 @@ func (r Registry) ListSchemas() []string {
 	return l
 }
 
+// LoadExtensions registers metadata extensions from config. When called
+// again, extensions no longer present in config are removed and built-in
+// schemas they replaced are restored. Callers must serialize calls.
 func LoadExtensions() error {
 	// set a fallback type that's compatible with ForEach()
 	config.SetDefault("metadata.extensions", []interface{}{})
 
 	// extract all extensions
-	return config.ForEach("metadata.extensions", func(c *config.Config) error {
+	loaded := make(map[string]Schema)
+	err := config.ForEach("metadata.extensions", func(c *config.Config) error {
 		ns := c.GetString("namespace")
 		buf, err := json.Marshal(c.GetInterface("schema"))
 		if err != nil {
@@ -84,8 +107,34 @@ func LoadExtensions() error {
 		if err != nil {
 			return fmt.Errorf("metadata: loading extension %s: %w", ns, err)
 		}
-		RegisterSchema(ext)
-		log.Infof("Registered %s metadata extension.", ns)
+		loaded[ns] = ext
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// drop or restore extensions removed from config
+	for ns, prev := range extensions {
+		if _, ok := loaded[ns]; ok {
+			continue
+		}
+		if prev != nil {
+			RegisterSchema(prev)
+		} else if registry != nil {
+			registry.Unregister(ns)
+		}
+		delete(extensions, ns)
+		log.Infof("Removed %s metadata extension.", ns)
+	}
+
+	for ns, ext := range loaded {
+		if _, ok := extensions[ns]; !ok {
+			prev, _ := GetSchema(ns)
+			extensions[ns] = prev
+		}
+		RegisterSchema(ext)
+		log.Infof("Registered %s metadata extension.", ns)
+	}
+	return nil
 }
@@ -36,7 +36,7 @@ func (b *Builder) MigrateAthens(ctx context.Context, oldparams, params *tezos.Pa
         acc.IsDirty = true
 
         // insert into db
-        if err := account.Insert(ctx, acc); err != nil {
+        if err := model.Insert(ctx, account, acc); err != nil {
             return err
         }
 
@@ -261,14 +261,14 @@ func (b *Builder) BuildGenesisBlock(ctx context.Context) (*model.Block, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := table.Insert(ctx, accounts); err != nil {
+	if err := model.Insert(ctx, table, accounts); err != nil {
 		return nil, err
 	}
 	table, err = b.idx.Table(index.ContractTableKey)
 	if err != nil {
 		return nil, err
 	}
-	if err := table.Insert(ctx, contracts); err != nil {
+	if err := model.Insert(ctx, table, contracts); err != nil {
 		return nil, err
 	}
 
@@ -90,7 +90,7 @@ func (b *Builder) MigrateIthaca(ctx context.Context, oldparams, params *tezos.Pa
             return fmt.Errorf("cannot scan snapshot table: %v", err)
         }
         // store update
-        if err := snap.Update(ctx, upd); err != nil {
+        if err := model.Update(ctx, snap, upd); err != nil {
             return fmt.Errorf("cannot update snapshot table: %v", err)
         }
     }
@@ -152,10 +152,8 @@ func (b *Builder) RebuildIthacaSnapshotsRightsAndIncome(ctx context.Context, par
 
     log.Infof("Migrate v%03d: removing deprecated past snapshots", params.Version)
     for cycle := startCycle; cycle <= endCycle; cycle++ {
-        _, _ = pack.NewQuery("migrate.snapshot.delete").
-            WithTable(snaps).
-            AndEqual("cycle", cycle-params.PreservedCycles-1).
-            Delete(ctx)
+        _, _ = model.Delete(ctx, snaps, pack.NewQuery("migrate.snapshot.delete").
+            AndEqual("cycle", cycle-params.PreservedCycles-1))
     }
     if err := snaps.Flush(ctx); err != nil {
         return fmt.Errorf("migrate: flushing snapshots after clear: %w", err)
@@ -233,7 +231,7 @@ func (b *Builder) RebuildIthacaSnapshotsRightsAndIncome(ctx context.Context, par
 
             // sort and insert
             sort.Slice(ins, func(i, j int) bool { return ins[i].(*model.Snapshot).AccountId < ins[j].(*model.Snapshot).AccountId })
-            err = snaps.Insert(ctx, ins)
+            err = model.Insert(ctx, snaps, ins)
             ins = ins[:0]
             if err != nil {
                 return fmt.Errorf("migrate: insert baker snapshot for cycle %d: %w", cycle, err)
@@ -279,7 +277,7 @@ func (b *Builder) RebuildIthacaSnapshotsRightsAndIncome(ctx context.Context, par
 
             // sort and insert
             sort.Slice(ins, func(i, j int) bool { return ins[i].(*model.Snapshot).AccountId < ins[j].(*model.Snapshot).AccountId })
-            err = snaps.Insert(ctx, ins)
+            err = model.Insert(ctx, snaps, ins)
             ins = ins[:0]
             if err != nil {
                 return fmt.Errorf("migrate: insert delegator snapshot for cycle %d: %w", cycle, err)
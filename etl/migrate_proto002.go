@@ -72,7 +72,7 @@ func (b *Builder) FixOriginationBug(ctx context.Context, params *tezos.Params) e
 
 	bakers, err := b.idx.Table(index.BakerTableKey)
 	if err == nil {
-		_ = bakers.DeleteIds(ctx, delIds)
+		_ = model.DeleteIds(ctx, bakers, delIds)
 	}
 	log.Infof("Migrate v%03d: dropped %d non-bakers", params.Version, len(drop))
 
@@ -0,0 +1,174 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// Kinds of decoded bigmap field values. Numbers and timestamps store a
+// comparable float value, all kinds store their string representation.
+const (
+	BigmapFieldText = iota
+	BigmapFieldNum
+	BigmapFieldTime
+	BigmapFieldBool
+)
+
+// BigmapColumn configures a decoded key or value path of a bigmap that is
+// stored as secondary column for fast filtering of live bigmap values.
+type BigmapColumn struct {
+	BigmapId int64
+	Path     string
+}
+
+// ParseBigmapColumn parses a column spec in format `bigmap_id:path`, e.g.
+// `511:value.balance`.
+func ParseBigmapColumn(s string) (BigmapColumn, error) {
+	id, path, ok := strings.Cut(s, ":")
+	if !ok {
+		return BigmapColumn{}, fmt.Errorf("invalid bigmap column '%s', expected bigmap_id:path", s)
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < 0 {
+		return BigmapColumn{}, fmt.Errorf("invalid bigmap id in column '%s'", s)
+	}
+	if !IsBigmapPath(path) || !strings.Contains(path, ".") {
+		return BigmapColumn{}, fmt.Errorf("invalid bigmap path in column '%s'", s)
+	}
+	return BigmapColumn{BigmapId: n, Path: path}, nil
+}
+
+func (c BigmapColumn) String() string {
+	return strconv.FormatInt(c.BigmapId, 10) + ":" + c.Path
+}
+
+// BigmapField is the decoded value of a configured bigmap column for a
+// single live key. Rows mirror the bigmap_values table.
+type BigmapField struct {
+	RowId    uint64  `pack:"I,pk"      json:"row_id"`
+	BigmapId int64   `pack:"B,bloom"   json:"bigmap_id"`
+	KeyId    uint64  `pack:"K,bloom=3" json:"key_id"`
+	Path     string  `pack:"p,snappy"  json:"path"`
+	Height   int64   `pack:"h"         json:"height"`
+	Kind     int     `pack:"k,i8"      json:"kind"`
+	Num      float64 `pack:"n"         json:"num"`
+	Text     string  `pack:"t,snappy"  json:"text"`
+}
+
+// Ensure BigmapField implements the pack.Item interface.
+var _ pack.Item = (*BigmapField)(nil)
+
+func (f *BigmapField) ID() uint64 {
+	return f.RowId
+}
+
+func (f *BigmapField) SetID(id uint64) {
+	f.RowId = id
+}
+
+// NewBigmapField decodes path from a live bigmap entry. It returns nil when
+// the path does not exist in the entry's key or value.
+func NewBigmapField(kv *BigmapKV, path string, keyType, valueType micheline.Type) *BigmapField {
+	val, ok := kv.GetPath(path, keyType, valueType)
+	if !ok {
+		return nil
+	}
+	f := &BigmapField{
+		BigmapId: kv.BigmapId,
+		KeyId:    kv.KeyId,
+		Path:     path,
+		Height:   kv.Height,
+		Text:     bigmapValueString(val),
+	}
+	f.Kind, f.Num = bigmapFieldNum(val)
+	return f
+}
+
+func bigmapFieldNum(val interface{}) (int, float64) {
+	switch t := val.(type) {
+	case tezos.Z:
+		return BigmapFieldNum, t.Float64(0)
+	case time.Time:
+		return BigmapFieldTime, float64(t.Unix())
+	case bool:
+		if t {
+			return BigmapFieldBool, 1
+		}
+		return BigmapFieldBool, 0
+	default:
+		return BigmapFieldText, 0
+	}
+}
+
+// FieldCondition translates the filter into conditions on a bigmap field
+// table that select a superset of matching rows of the filter's argument
+// kind. Rows of other kinds must be checked with Match. Returns false when
+// the filter mode cannot be evaluated on stored fields.
+func (f BigmapPathFilter) FieldCondition() (int, pack.UnboundCondition, bool) {
+	// numeric and time arguments compare by value
+	for _, kind := range []int{BigmapFieldNum, BigmapFieldTime} {
+		nums, ok := parseFieldNums(kind, f.Value)
+		if !ok {
+			continue
+		}
+		// float conversion is monotonic, so non-strict comparisons on
+		// converted values never drop a matching row
+		var c pack.UnboundCondition
+		switch f.Mode {
+		case pack.FilterModeEqual:
+			c = pack.Equal("num", nums[0])
+		case pack.FilterModeGt, pack.FilterModeGte:
+			c = pack.Gte("num", nums[0])
+		case pack.FilterModeLt, pack.FilterModeLte:
+			c = pack.Lte("num", nums[0])
+		case pack.FilterModeRange:
+			c = pack.Range("num", nums[0], nums[1])
+		case pack.FilterModeIn:
+			c = pack.In("num", nums)
+		default:
+			return 0, c, false
+		}
+		return kind, c, true
+	}
+
+	// other arguments compare by string representation
+	switch f.Mode {
+	case pack.FilterModeEqual:
+		return BigmapFieldText, pack.Equal("text", f.Value[0]), true
+	case pack.FilterModeIn:
+		return BigmapFieldText, pack.In("text", f.Value), true
+	default:
+		return 0, pack.UnboundCondition{}, false
+	}
+}
+
+func parseFieldNums(kind int, vals []string) ([]float64, bool) {
+	nums := make([]float64, len(vals))
+	for i, v := range vals {
+		switch kind {
+		case BigmapFieldNum:
+			z, err := tezos.ParseZ(v)
+			if err != nil {
+				return nil, false
+			}
+			nums[i] = z.Float64(0)
+		case BigmapFieldTime:
+			tm, err := util.ParseTime(v)
+			if err != nil {
+				return nil, false
+			}
+			nums[i] = float64(tm.Time().Unix())
+		}
+	}
+	return nums, true
+}
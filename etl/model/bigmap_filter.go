@@ -0,0 +1,238 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// BigmapPathFilter is a condition on a decoded bigmap key or value field
+// addressed by a dot separated path relative to the bigmap's key or value
+// type, e.g. `value.balance` or `key.owner`. Path segments are type
+// annotations or positional indexes when a type has no annotations.
+type BigmapPathFilter struct {
+	Path  string // full path including key/value prefix
+	Mode  pack.FilterMode
+	Value []string
+
+	field string // path below key or value
+	isKey bool
+	re    *regexp.Regexp
+}
+
+// IsBigmapPath returns true when a query argument addresses a decoded
+// bigmap key or value field.
+func IsBigmapPath(s string) bool {
+	return s == "key" || s == "value" || strings.HasPrefix(s, "key.") || strings.HasPrefix(s, "value.")
+}
+
+// ParseBigmapPathFilter parses a query argument like `value.balance.gt` and
+// its argument into a filter. The last path segment is used as filter mode
+// when it is a valid mode name, otherwise mode defaults to equal.
+func ParseBigmapPathFilter(key, val string) (BigmapPathFilter, error) {
+	f := BigmapPathFilter{
+		Path: key,
+		Mode: pack.FilterModeEqual,
+	}
+	if !IsBigmapPath(key) {
+		return f, fmt.Errorf("invalid bigmap path '%s'", key)
+	}
+	if n := strings.LastIndexByte(key, '.'); n > 0 {
+		if mode := pack.ParseFilterMode(key[n+1:]); mode.IsValid() {
+			f.Mode = mode
+			f.Path = key[:n]
+		}
+	}
+	f.isKey = strings.HasPrefix(f.Path, "key")
+	f.field = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(f.Path, "key"), "value"), ".")
+
+	switch f.Mode {
+	case pack.FilterModeIn, pack.FilterModeNotIn:
+		f.Value = strings.Split(val, ",")
+	case pack.FilterModeRange:
+		f.Value = strings.Split(val, ",")
+		if len(f.Value) != 2 {
+			return f, fmt.Errorf("range filter on '%s' requires exactly 2 values", f.Path)
+		}
+	case pack.FilterModeRegexp:
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return f, fmt.Errorf("invalid regexp '%s': %w", val, err)
+		}
+		f.re = re
+		f.Value = []string{val}
+	default:
+		f.Value = []string{val}
+	}
+	return f, nil
+}
+
+func (f BigmapPathFilter) IsKey() bool {
+	return f.isKey
+}
+
+func (f BigmapPathFilter) Field() string {
+	return f.field
+}
+
+// Match evaluates the filter against a decoded key or value. Numbers compare
+// numerically, timestamps by time and everything else by string value.
+// Missing paths never match.
+func (f BigmapPathFilter) Match(v *micheline.Value) bool {
+	if v == nil {
+		return false
+	}
+	val, ok := v.GetValue(f.field)
+	if !ok {
+		return false
+	}
+	switch f.Mode {
+	case pack.FilterModeEqual:
+		return compareBigmapValue(val, f.Value[0]) == 0
+	case pack.FilterModeNotEqual:
+		return compareBigmapValue(val, f.Value[0]) != 0
+	case pack.FilterModeGt:
+		return compareBigmapValue(val, f.Value[0]) > 0
+	case pack.FilterModeGte:
+		return compareBigmapValue(val, f.Value[0]) >= 0
+	case pack.FilterModeLt:
+		return compareBigmapValue(val, f.Value[0]) < 0
+	case pack.FilterModeLte:
+		return compareBigmapValue(val, f.Value[0]) <= 0
+	case pack.FilterModeIn, pack.FilterModeNotIn:
+		var found bool
+		for _, s := range f.Value {
+			if compareBigmapValue(val, s) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (f.Mode == pack.FilterModeIn)
+	case pack.FilterModeRange:
+		return compareBigmapValue(val, f.Value[0]) >= 0 && compareBigmapValue(val, f.Value[1]) <= 0
+	case pack.FilterModeRegexp:
+		return f.re.MatchString(bigmapValueString(val))
+	default:
+		return false
+	}
+}
+
+// compareBigmapValue compares a decoded Micheline value against a filter
+// argument and returns -1, 0 or 1. Unparsable arguments compare as strings.
+func compareBigmapValue(val interface{}, arg string) int {
+	switch t := val.(type) {
+	case tezos.Z:
+		if z, err := tezos.ParseZ(arg); err == nil {
+			return t.Cmp(z)
+		}
+	case time.Time:
+		if tm, err := util.ParseTime(arg); err == nil {
+			switch {
+			case t.Before(tm.Time()):
+				return -1
+			case t.After(tm.Time()):
+				return 1
+			default:
+				return 0
+			}
+		}
+	case bool:
+		if b, err := strconv.ParseBool(arg); err == nil {
+			switch {
+			case t == b:
+				return 0
+			case !t:
+				return -1
+			default:
+				return 1
+			}
+		}
+	}
+	return strings.Compare(bigmapValueString(val), arg)
+}
+
+func bigmapValueString(val interface{}) string {
+	switch t := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// BigmapPathFilterList is a conjunction of path filters evaluated against
+// bigmap key/value pairs. Keys and values are only decoded when a filter
+// refers to them.
+type BigmapPathFilterList []BigmapPathFilter
+
+func (l BigmapPathFilterList) Len() int {
+	return len(l)
+}
+
+func (l BigmapPathFilterList) Match(kv *BigmapKV, keyType, valueType micheline.Type) bool {
+	if len(l) == 0 {
+		return true
+	}
+	var key, val *micheline.Value
+	for _, f := range l {
+		if f.isKey {
+			if key == nil {
+				key = kv.GetKeyValue(keyType)
+			}
+			if !f.Match(key) {
+				return false
+			}
+		} else {
+			if val == nil {
+				v := kv.GetValue(valueType)
+				val = &v
+			}
+			if !f.Match(val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetKeyValue returns the bigmap key as typed value for path lookups.
+func (b *BigmapKV) GetKeyValue(typ micheline.Type) *micheline.Value {
+	key, err := b.GetKey(typ)
+	if err != nil {
+		return nil
+	}
+	return micheline.NewValuePtr(typ, key.Prim())
+}
+
+// GetPath returns the decoded key or value field at path for use as
+// secondary column.
+func (b *BigmapKV) GetPath(path string, keyType, valueType micheline.Type) (interface{}, bool) {
+	var v *micheline.Value
+	switch {
+	case path == "key" || strings.HasPrefix(path, "key."):
+		v = b.GetKeyValue(keyType)
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "key"), ".")
+	case path == "value" || strings.HasPrefix(path, "value."):
+		val := b.GetValue(valueType)
+		v = &val
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "value"), ".")
+	}
+	if v == nil {
+		return nil, false
+	}
+	return v.GetValue(path)
+}
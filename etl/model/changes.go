@@ -0,0 +1,190 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"blockwatch.cc/packdb/pack"
+)
+
+type ChangeKind byte
+
+const (
+	ChangeInsert ChangeKind = iota + 1
+	ChangeUpdate
+	ChangeDelete
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeInsert:
+		return "insert"
+	case ChangeUpdate:
+		return "update"
+	case ChangeDelete:
+		return "delete"
+	default:
+		return "invalid"
+	}
+}
+
+// TableChange is a single write to a table. Inserted and updated rows are
+// stored as binary encoded package including their row ids, deletes only
+// keep row ids.
+type TableChange struct {
+	Table string
+	Kind  ChangeKind
+	Data  []byte
+	Ids   []uint64
+}
+
+// Items decodes inserted or updated rows. The alloc func must return an empty
+// model of the table's row type.
+func (c TableChange) Items(alloc func() pack.Item) ([]pack.Item, error) {
+	if c.Kind == ChangeDelete || len(c.Data) == 0 {
+		return nil, nil
+	}
+	pkg := pack.NewPackage()
+	if err := pkg.UnmarshalBinary(c.Data); err != nil {
+		return nil, fmt.Errorf("decoding %s %s: %w", c.Table, c.Kind, err)
+	}
+	items := make([]pack.Item, pkg.Len())
+	for i := range items {
+		item := alloc()
+		if err := pkg.ReadAt(i, item); err != nil {
+			return nil, fmt.Errorf("decoding %s %s row %d: %w", c.Table, c.Kind, i, err)
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// ChangeSet records table writes made through Insert, Update, DeleteIds and
+// Delete with a context carrying the change set. It is safe for concurrent
+// use.
+type ChangeSet struct {
+	mu      sync.Mutex
+	changes []TableChange
+}
+
+func NewChangeSet() *ChangeSet {
+	return &ChangeSet{}
+}
+
+// Take returns all recorded changes in write order and resets the set.
+func (s *ChangeSet) Take() []TableChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.changes
+	s.changes = nil
+	return c
+}
+
+func (s *ChangeSet) add(c TableChange) {
+	s.mu.Lock()
+	s.changes = append(s.changes, c)
+	s.mu.Unlock()
+}
+
+func (s *ChangeSet) addItems(t *pack.Table, kind ChangeKind, val interface{}) error {
+	var items []pack.Item
+	switch v := val.(type) {
+	case []pack.Item:
+		items = v
+	case pack.Item:
+		items = []pack.Item{v}
+	default:
+		return fmt.Errorf("type %T does not implement Item interface", val)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	pkg := pack.NewPackage()
+	if err := pkg.Init(items[0], len(items)); err != nil {
+		return err
+	}
+	for _, v := range items {
+		if err := pkg.Push(v); err != nil {
+			return err
+		}
+	}
+	buf, err := pkg.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("encoding %s %s: %w", t.Name(), kind, err)
+	}
+	s.add(TableChange{Table: t.Name(), Kind: kind, Data: buf})
+	return nil
+}
+
+type changeSetKey struct{}
+
+// WithChangeSet returns a context that records table writes to s.
+func WithChangeSet(ctx context.Context, s *ChangeSet) context.Context {
+	return context.WithValue(ctx, changeSetKey{}, s)
+}
+
+func changeSetFromContext(ctx context.Context) *ChangeSet {
+	s, _ := ctx.Value(changeSetKey{}).(*ChangeSet)
+	return s
+}
+
+// Insert inserts val into t and records the inserted rows. Rows are recorded
+// after insert, so they carry their new row ids.
+func Insert(ctx context.Context, t *pack.Table, val interface{}) error {
+	if err := t.Insert(ctx, val); err != nil {
+		return err
+	}
+	if s := changeSetFromContext(ctx); s != nil {
+		return s.addItems(t, ChangeInsert, val)
+	}
+	return nil
+}
+
+// Update updates val in t and records the updated rows.
+func Update(ctx context.Context, t *pack.Table, val interface{}) error {
+	if err := t.Update(ctx, val); err != nil {
+		return err
+	}
+	if s := changeSetFromContext(ctx); s != nil {
+		return s.addItems(t, ChangeUpdate, val)
+	}
+	return nil
+}
+
+// DeleteIds deletes rows by id from t and records their ids.
+func DeleteIds(ctx context.Context, t *pack.Table, ids []uint64) error {
+	if err := t.DeleteIds(ctx, ids); err != nil {
+		return err
+	}
+	if s := changeSetFromContext(ctx); s != nil && len(ids) > 0 {
+		s.add(TableChange{Table: t.Name(), Kind: ChangeDelete, Ids: append([]uint64(nil), ids...)})
+	}
+	return nil
+}
+
+// Delete deletes all rows matching q from t. When recording, matching row
+// ids are resolved first and deleted by id.
+func Delete(ctx context.Context, t *pack.Table, q pack.Query) (int64, error) {
+	s := changeSetFromContext(ctx)
+	if s == nil {
+		return t.Delete(ctx, q)
+	}
+	q.Fields = []string{t.Fields().Pk().Name}
+	res, err := t.Query(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	ids := append([]uint64(nil), res.PkColumn()...)
+	res.Close()
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := DeleteIds(ctx, t, ids); err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	_ "blockwatch.cc/packdb/store/bolt"
+	bolt "go.etcd.io/bbolt"
+)
+
+func testPriceTable(t *testing.T, name string) *pack.Table {
+	t.Helper()
+	fields, err := pack.Fields(Price{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := pack.CreateDatabase(t.TempDir(), name, "test", &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := db.CreateTableIfNotExists("price", fields, pack.Options{
+		PackSizeLog2:    12,
+		JournalSizeLog2: 10,
+		CacheSize:       2,
+		FillLevel:       100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		table.Close()
+		db.Close()
+	})
+	return table
+}
+
+func testPrices(t *testing.T, table *pack.Table) map[uint64]Price {
+	t.Helper()
+	prices := make(map[uint64]Price)
+	err := pack.NewQuery("test").WithTable(table).Stream(context.Background(), func(r pack.Row) error {
+		var p Price
+		if err := r.Decode(&p); err != nil {
+			return err
+		}
+		prices[p.RowId] = p
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prices
+}
+
+func TestChangeSetReplay(t *testing.T) {
+	primary := testPriceTable(t, "primary")
+	replica := testPriceTable(t, "replica")
+	now := time.Unix(1672531200, 0).UTC()
+
+	// writes without a change set are not recorded
+	set := NewChangeSet()
+	if err := Insert(context.Background(), primary, &Price{Timestamp: now, Currency: "CHF", Price: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(set.Take()); n != 0 {
+		t.Fatalf("recorded %d changes without change set", n)
+	}
+	if err := Insert(context.Background(), replica, &Price{Timestamp: now, Currency: "CHF", Price: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithChangeSet(context.Background(), set)
+	ins := []pack.Item{
+		&Price{Timestamp: now, Currency: "USD", Price: 1.1},
+		&Price{Timestamp: now, Currency: "EUR", Price: 1.0},
+		&Price{Timestamp: now.Add(time.Hour), Currency: "EUR", Price: 1.2},
+	}
+	if err := Insert(ctx, primary, ins); err != nil {
+		t.Fatal(err)
+	}
+	upd := ins[0].(*Price)
+	upd.Price = 1.15
+	if err := Update(ctx, primary, upd); err != nil {
+		t.Fatal(err)
+	}
+	n, err := Delete(ctx, primary, pack.NewQuery("test").AndEqual("currency", "EUR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d rows, want 2", n)
+	}
+	if err := DeleteIds(ctx, primary, []uint64{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := set.Take()
+	kinds := []ChangeKind{ChangeInsert, ChangeUpdate, ChangeDelete, ChangeDelete}
+	if len(changes) != len(kinds) {
+		t.Fatalf("recorded %d changes, want %d", len(changes), len(kinds))
+	}
+	for i, c := range changes {
+		if c.Table != "price" || c.Kind != kinds[i] {
+			t.Errorf("change %d: got %s %s, want price %s", i, c.Table, c.Kind, kinds[i])
+		}
+	}
+	if len(set.Take()) != 0 {
+		t.Errorf("change set not reset after take")
+	}
+
+	// apply on the replica like etl.Indexer does
+	for _, c := range changes {
+		if c.Kind == ChangeDelete {
+			err = replica.DeleteIds(context.Background(), c.Ids)
+		} else {
+			var items []pack.Item
+			items, err = c.Items(func() pack.Item { return &Price{} })
+			if err == nil && c.Kind == ChangeInsert {
+				err = replica.Insert(context.Background(), items)
+			} else if err == nil {
+				err = replica.Update(context.Background(), items)
+			}
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.Kind, err)
+		}
+	}
+
+	want, got := testPrices(t, primary), testPrices(t, replica)
+	if len(want) != 1 {
+		t.Fatalf("primary has %d rows, want 1", len(want))
+	}
+	if len(got) != len(want) {
+		t.Fatalf("replica has %d rows, want %d", len(got), len(want))
+	}
+	for id, p := range want {
+		if q, ok := got[id]; !ok || q.Currency != p.Currency || q.Price != p.Price || !q.Timestamp.Equal(p.Timestamp) {
+			t.Errorf("row %d: got %+v, want %+v", id, q, p)
+		}
+	}
+}
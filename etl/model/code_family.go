@@ -0,0 +1,179 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"math/big"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/micheline"
+)
+
+type CodeFamilyKind byte
+
+const (
+	CodeFamilyKindCode      CodeFamilyKind = iota // identical code
+	CodeFamilyKindInterface                       // identical entrypoint interface
+	CodeFamilyKindShape                           // identical code except constants
+	CodeFamilyKindInvalid
+)
+
+func ParseCodeFamilyKind(s string) CodeFamilyKind {
+	switch s {
+	case "code":
+		return CodeFamilyKindCode
+	case "interface":
+		return CodeFamilyKindInterface
+	case "shape":
+		return CodeFamilyKindShape
+	default:
+		return CodeFamilyKindInvalid
+	}
+}
+
+func (k CodeFamilyKind) IsValid() bool {
+	return k < CodeFamilyKindInvalid
+}
+
+func (k CodeFamilyKind) String() string {
+	switch k {
+	case CodeFamilyKindCode:
+		return "code"
+	case CodeFamilyKindInterface:
+		return "interface"
+	case CodeFamilyKindShape:
+		return "shape"
+	default:
+		return ""
+	}
+}
+
+func (k CodeFamilyKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// HashField returns the member table column that stores hashes of kind.
+func (k CodeFamilyKind) HashField() string {
+	switch k {
+	case CodeFamilyKindCode:
+		return "code_hash"
+	case CodeFamilyKindInterface:
+		return "iface_hash"
+	case CodeFamilyKindShape:
+		return "shape_hash"
+	default:
+		return ""
+	}
+}
+
+// CodeFamily groups all contracts that share the same code, interface or
+// code shape hash. Families are created when the first member is originated
+// and track the number of members, the first deployment and the deployment
+// time range.
+type CodeFamily struct {
+	RowId      uint64         `pack:"I,pk"      json:"row_id"`
+	Kind       CodeFamilyKind `pack:"k"         json:"kind"`
+	Hash       uint64         `pack:"H,bloom"   json:"hash"`
+	NContracts int            `pack:"n,i32"     json:"n_contracts"`
+	ContractId AccountID      `pack:"A"         json:"contract_id"` // first member
+	CreatorId  AccountID      `pack:"C"         json:"creator_id"`  // first deployer
+	FirstSeen  int64          `pack:"f,i32"     json:"first_seen"`
+	LastSeen   int64          `pack:"l,i32"     json:"last_seen"`
+	FirstTime  time.Time      `pack:"F"         json:"first_seen_time"`
+	LastTime   time.Time      `pack:"L"         json:"last_seen_time"`
+}
+
+// Ensure CodeFamily implements the pack.Item interface.
+var _ pack.Item = (*CodeFamily)(nil)
+
+func (f *CodeFamily) ID() uint64 {
+	return f.RowId
+}
+
+func (f *CodeFamily) SetID(id uint64) {
+	f.RowId = id
+}
+
+// CodeFamilyMember links a contract to its code, interface and shape hashes.
+type CodeFamilyMember struct {
+	RowId         uint64    `pack:"I,pk"      json:"row_id"`
+	AccountId     AccountID `pack:"A,bloom"   json:"account_id"`
+	CreatorId     AccountID `pack:"C"         json:"creator_id"`
+	CodeHash      uint64    `pack:"c,bloom"   json:"code_hash"`
+	InterfaceHash uint64    `pack:"i,bloom"   json:"iface_hash"`
+	ShapeHash     uint64    `pack:"s,bloom"   json:"shape_hash"`
+	Height        int64     `pack:"h,i32"     json:"height"`
+	Timestamp     time.Time `pack:"T"         json:"time"`
+}
+
+// Ensure CodeFamilyMember implements the pack.Item interface.
+var _ pack.Item = (*CodeFamilyMember)(nil)
+
+func (m *CodeFamilyMember) ID() uint64 {
+	return m.RowId
+}
+
+func (m *CodeFamilyMember) SetID(id uint64) {
+	m.RowId = id
+}
+
+func (m CodeFamilyMember) Hash(kind CodeFamilyKind) uint64 {
+	switch kind {
+	case CodeFamilyKindCode:
+		return m.CodeHash
+	case CodeFamilyKindInterface:
+		return m.InterfaceHash
+	case CodeFamilyKindShape:
+		return m.ShapeHash
+	default:
+		return 0
+	}
+}
+
+func NewCodeFamilyMember(c *Contract, block *Block) (*CodeFamilyMember, error) {
+	script, err := c.LoadScript()
+	if err != nil || script == nil {
+		return nil, err
+	}
+	return &CodeFamilyMember{
+		AccountId:     c.AccountId,
+		CreatorId:     c.CreatorId,
+		CodeHash:      c.CodeHash,
+		InterfaceHash: c.InterfaceHash,
+		ShapeHash:     CodeShapeHash(script.Code.Code),
+		Height:        block.Height,
+		Timestamp:     block.Timestamp,
+	}, nil
+}
+
+// CodeShapeHash hashes contract code with all int, string and bytes literals
+// cleared, so contracts that only differ in embedded constants (addresses,
+// amounts, metadata) share the same shape.
+func CodeShapeHash(code micheline.Prim) uint64 {
+	return stripCodeConstants(code).Hash64()
+}
+
+func stripCodeConstants(p micheline.Prim) micheline.Prim {
+	switch p.Type {
+	case micheline.PrimInt:
+		return micheline.Prim{Type: micheline.PrimInt, Int: big.NewInt(0)}
+	case micheline.PrimString:
+		return micheline.Prim{Type: micheline.PrimString}
+	case micheline.PrimBytes:
+		return micheline.Prim{Type: micheline.PrimBytes}
+	}
+	c := micheline.Prim{
+		Type:   p.Type,
+		OpCode: p.OpCode,
+		Anno:   p.Anno,
+	}
+	if len(p.Args) > 0 {
+		c.Args = make(micheline.PrimList, len(p.Args))
+		for i, v := range p.Args {
+			c.Args[i] = stripCodeConstants(v)
+		}
+	}
+	return c
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// CohortMember assigns an address to a named cohort (address list). A cohort
+// exists as long as it has at least one member. Addresses are stored as is
+// and may refer to accounts that are not yet known to the indexer.
+type CohortMember struct {
+	RowId     uint64        `pack:"I,pk"      json:"row_id"`
+	Name      string        `pack:"n,bloom"   json:"name"`
+	Address   tezos.Address `pack:"H"         json:"address"`
+	Label     string        `pack:"l,snappy"  json:"label"`
+	CreatedAt time.Time     `pack:"c"         json:"created_at"`
+}
+
+// Ensure CohortMember implements the pack.Item interface.
+var _ pack.Item = (*CohortMember)(nil)
+
+func (m *CohortMember) ID() uint64 {
+	return m.RowId
+}
+
+func (m *CohortMember) SetID(id uint64) {
+	m.RowId = id
+}
+
+// IsValidCohortName checks cohort names are non-empty, at most 64 characters
+// and only use lowercase letters, digits, dash and underscore.
+func IsValidCohortName(s string) bool {
+	if len(s) == 0 || len(s) > 64 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// ConsensusKey records consensus key rotations and drain events of bakers.
+// Update rows carry the new key and the cycle at which it becomes active,
+// drain rows carry the consensus key that signed the drain, the destination
+// account and the drained amount.
+type ConsensusKey struct {
+	RowId           uint64        `pack:"I,pk"      json:"row_id"`
+	BakerId         AccountID     `pack:"B,bloom"   json:"baker_id"`
+	Type            OpType        `pack:"t"         json:"type"`
+	Key             tezos.Key     `pack:"k"         json:"key"`
+	Address         tezos.Address `pack:"a,snappy"  json:"address"`
+	Height          int64         `pack:"h,i32"     json:"height"`
+	Cycle           int64         `pack:"c,i16"     json:"cycle"`
+	ActivationCycle int64         `pack:"C,i16"     json:"activation_cycle"`
+	Timestamp       time.Time     `pack:"T"         json:"time"`
+	OpId            OpID          `pack:"o"         json:"op_id"`
+	ReceiverId      AccountID     `pack:"R"         json:"receiver_id"`
+	Amount          int64         `pack:"v"         json:"amount"`
+}
+
+// Ensure ConsensusKey implements the pack.Item interface.
+var _ pack.Item = (*ConsensusKey)(nil)
+
+func (k *ConsensusKey) ID() uint64 {
+	return k.RowId
+}
+
+func (k *ConsensusKey) SetID(id uint64) {
+	k.RowId = id
+}
+
+func (k ConsensusKey) IsUpdate() bool {
+	return k.Type == OpTypeUpdateConsensusKey
+}
+
+func (k ConsensusKey) IsDrain() bool {
+	return k.Type == OpTypeDrainDelegate
+}
+
+// assuming the op was successful!
+func NewConsensusKeyUpdate(op *Op, key tezos.Key, p *tezos.Params) *ConsensusKey {
+	return &ConsensusKey{
+		BakerId:         op.SenderId,
+		Type:            op.Type,
+		Key:             key,
+		Address:         key.Address(),
+		Height:          op.Height,
+		Cycle:           op.Cycle,
+		ActivationCycle: op.Cycle + p.PreservedCycles + 1,
+		Timestamp:       op.Timestamp,
+		OpId:            op.RowId,
+	}
+}
+
+// assuming the op was successful!
+func NewConsensusKeyDrain(op *Op, key tezos.Address) *ConsensusKey {
+	return &ConsensusKey{
+		BakerId:         op.SenderId,
+		Type:            op.Type,
+		Address:         key,
+		Height:          op.Height,
+		Cycle:           op.Cycle,
+		ActivationCycle: op.Cycle,
+		Timestamp:       op.Timestamp,
+		OpId:            op.RowId,
+		ReceiverId:      op.ReceiverId,
+		Amount:          op.Volume,
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+)
+
+// ContractCallStats aggregates calls to a single contract entrypoint within
+// one (UTC) day. Callers holds the set of unique senders seen on that day
+// together with the height of their first call so that disconnected blocks
+// can be rolled back.
+type ContractCallStats struct {
+	RowId      uint64    `pack:"I,pk"      json:"row_id"`
+	AccountId  AccountID `pack:"A,bloom"   json:"account_id"`
+	Entrypoint int       `pack:"e,i16"     json:"entrypoint_id"`
+	Height     int64     `pack:"h,i32"     json:"height"` // last block with calls
+	Timestamp  time.Time `pack:"T"         json:"time"`   // day
+	NCalls     int       `pack:"n,i32"     json:"n_calls"`
+	NFailed    int       `pack:"f,i32"     json:"n_failed"`
+	NCallers   int       `pack:"u,i32"     json:"n_callers"`
+	GasUsed    int64     `pack:"g"         json:"gas_used"`
+	Fee        int64     `pack:"F"         json:"fee"`
+	Volume     int64     `pack:"v"         json:"volume"`
+	Callers    []byte    `pack:"c,snappy"  json:"-"`
+}
+
+// Ensure ContractCallStats implements the pack.Item interface.
+var _ pack.Item = (*ContractCallStats)(nil)
+
+func (s *ContractCallStats) ID() uint64 {
+	return s.RowId
+}
+
+func (s *ContractCallStats) SetID(id uint64) {
+	s.RowId = id
+}
+
+func (s ContractCallStats) Time() time.Time {
+	return s.Timestamp
+}
+
+func (s *ContractCallStats) Add(op *Op) {
+	s.NCalls++
+	if !op.IsSuccess {
+		s.NFailed++
+	}
+	s.GasUsed += op.GasUsed
+	s.Fee += op.Fee
+	s.Volume += op.Volume
+}
+
+func (s *ContractCallStats) Sub(op *Op) {
+	s.NCalls--
+	if !op.IsSuccess {
+		s.NFailed--
+	}
+	s.GasUsed -= op.GasUsed
+	s.Fee -= op.Fee
+	s.Volume -= op.Volume
+}
+
+// CallerSet maps unique callers of a day to the height of their first call.
+type CallerSet map[AccountID]int64
+
+// DecodeCallerSet decodes a caller set stored as sorted list of uvarint
+// encoded (account id delta, height) pairs.
+func DecodeCallerSet(buf []byte) (CallerSet, error) {
+	set := make(CallerSet)
+	var last uint64
+	for len(buf) > 0 {
+		id, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid caller id")
+		}
+		buf = buf[n:]
+		height, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid caller height")
+		}
+		buf = buf[n:]
+		last += id
+		set[AccountID(last)] = int64(height)
+	}
+	return set, nil
+}
+
+func (s CallerSet) Encode() []byte {
+	ids := make([]uint64, 0, len(s))
+	for id := range s {
+		ids = append(ids, id.Value())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	buf := make([]byte, 0, 2*binary.MaxVarintLen32*len(ids))
+	var last uint64
+	for _, id := range ids {
+		buf = binary.AppendUvarint(buf, id-last)
+		buf = binary.AppendUvarint(buf, uint64(s[AccountID(id)]))
+		last = id
+	}
+	return buf
+}
+
+// CallDay returns the UTC day a call at time t is accounted to.
+func CallDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
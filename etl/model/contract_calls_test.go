@@ -0,0 +1,34 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"testing"
+)
+
+func TestCallerSetEncode(t *testing.T) {
+	set := CallerSet{
+		7:       100,
+		3:       101,
+		1 << 40: 102,
+	}
+	dec, err := DecodeCallerSet(set.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dec) != len(set) {
+		t.Fatalf("got %d callers, want %d", len(dec), len(set))
+	}
+	for id, h := range set {
+		if dec[id] != h {
+			t.Errorf("caller %d: got height %d, want %d", id, dec[id], h)
+		}
+	}
+	if dec, err := DecodeCallerSet(nil); err != nil || len(dec) != 0 {
+		t.Errorf("empty set: got %v, %v", dec, err)
+	}
+	if _, err := DecodeCallerSet([]byte{0x80}); err == nil {
+		t.Errorf("truncated set: expected error")
+	}
+}
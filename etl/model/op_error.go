@@ -0,0 +1,183 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/rpc"
+)
+
+// Error classes group protocol specific error ids into stable categories.
+const (
+	ErrorClassScriptRejected   = "script_rejected"
+	ErrorClassGasExhausted     = "gas_exhausted"
+	ErrorClassBalanceTooLow    = "balance_too_low"
+	ErrorClassStorageExhausted = "storage_exhausted"
+	ErrorClassCounter          = "counter"
+	ErrorClassBadParameter     = "bad_parameter"
+	ErrorClassNoContract       = "non_existing_contract"
+	ErrorClassRuntime          = "runtime_error"
+	ErrorClassBacktracked      = "backtracked"
+	ErrorClassSkipped          = "skipped"
+	ErrorClassOther            = "other"
+)
+
+// ErrorClasses lists all error classes in display order.
+var ErrorClasses = []string{
+	ErrorClassScriptRejected,
+	ErrorClassGasExhausted,
+	ErrorClassBalanceTooLow,
+	ErrorClassStorageExhausted,
+	ErrorClassCounter,
+	ErrorClassBadParameter,
+	ErrorClassNoContract,
+	ErrorClassRuntime,
+	ErrorClassBacktracked,
+	ErrorClassSkipped,
+	ErrorClassOther,
+}
+
+// normalized error id -> class, ordered by specificity (lower wins)
+var errorClassMap = map[string]struct {
+	class    string
+	priority int
+}{
+	"michelson_v1.script_rejected":            {ErrorClassScriptRejected, 0},
+	"gas_exhausted.operation":                 {ErrorClassGasExhausted, 1},
+	"gas_exhausted.block":                     {ErrorClassGasExhausted, 1},
+	"gas_limit_too_high":                      {ErrorClassGasExhausted, 1},
+	"contract.balance_too_low":                {ErrorClassBalanceTooLow, 1},
+	"tez.subtraction_underflow":               {ErrorClassBalanceTooLow, 2},
+	"storage_exhausted.operation":             {ErrorClassStorageExhausted, 1},
+	"storage_limit_too_high":                  {ErrorClassStorageExhausted, 1},
+	"contract.counter_in_the_past":            {ErrorClassCounter, 1},
+	"contract.counter_in_the_future":          {ErrorClassCounter, 1},
+	"michelson_v1.bad_contract_parameter":     {ErrorClassBadParameter, 1},
+	"michelson_v1.invalid_constant":           {ErrorClassBadParameter, 2},
+	"michelson_v1.invalid_primitive":          {ErrorClassBadParameter, 2},
+	"michelson_v1.no_such_entrypoint":         {ErrorClassBadParameter, 1},
+	"bad_entrypoint":                          {ErrorClassBadParameter, 1},
+	"contract.non_existing_contract":          {ErrorClassNoContract, 1},
+	"contract.empty_transaction":              {ErrorClassOther, 3},
+	"michelson_v1.runtime_error":              {ErrorClassRuntime, 9},
+	"michelson_v1.script_overflow":            {ErrorClassRuntime, 3},
+	"michelson_v1.ill_typed_contract":         {ErrorClassBadParameter, 3},
+	"michelson_v1.ill_typed_data":             {ErrorClassBadParameter, 3},
+	"contract.manager.unregistered_delegate":  {ErrorClassOther, 3},
+	"delegate.already_active":                 {ErrorClassOther, 3},
+	"contract.manager.inconsistent_hash":      {ErrorClassOther, 3},
+	"implicit_account_with_wrong_balance":     {ErrorClassBalanceTooLow, 3},
+	"cannot_pay_storage_fee":                  {ErrorClassStorageExhausted, 2},
+	"operation.insufficient_balance_for_fees": {ErrorClassBalanceTooLow, 2},
+}
+
+// NormalizeErrorId strips the protocol prefix from Tezos error ids, e.g.
+// `proto.016-PtMumbai.michelson_v1.script_rejected` becomes
+// `michelson_v1.script_rejected`.
+func NormalizeErrorId(id string) string {
+	if strings.HasPrefix(id, "proto.") {
+		if n := strings.IndexByte(id[6:], '.'); n >= 0 {
+			return id[6+n+1:]
+		}
+	}
+	return id
+}
+
+// ClassifyErrorId returns the error class for a normalized error id.
+func ClassifyErrorId(id string) string {
+	if c, ok := errorClassMap[id]; ok {
+		return c.class
+	}
+	return ErrorClassOther
+}
+
+// OpError holds the classified primary error of a failed operation.
+type OpError struct {
+	RowId      uint64         `pack:"I,pk"      json:"row_id"`
+	OpId       OpID           `pack:"o"         json:"op_id"`
+	Type       OpType         `pack:"t"         json:"type"`
+	Status     tezos.OpStatus `pack:"?"         json:"status"`
+	Height     int64          `pack:"h,i32"     json:"height"`
+	Timestamp  time.Time      `pack:"T"         json:"time"`
+	SenderId   AccountID      `pack:"S,bloom"   json:"sender_id"`
+	ReceiverId AccountID      `pack:"R,bloom"   json:"receiver_id"`
+	Entrypoint int            `pack:"E,i16"     json:"entrypoint_id"`
+	Class      string         `pack:"c,snappy"  json:"class"`
+	ErrorId    string         `pack:"e,snappy"  json:"error_id"`
+	FailWith   string         `pack:"w,snappy"  json:"failwith"` // Micheline JSON
+	Fee        int64          `pack:"f"         json:"fee"`
+}
+
+// Ensure OpError implements the pack.Item interface.
+var _ pack.Item = (*OpError)(nil)
+
+func (e *OpError) ID() uint64 {
+	return e.RowId
+}
+
+func (e *OpError) SetID(id uint64) {
+	e.RowId = id
+}
+
+func (e OpError) Time() time.Time {
+	return e.Timestamp
+}
+
+// NewOpError classifies the errors of a failed operation. The primary error
+// is the most specific known error in the error trace, FAILWITH payloads are
+// taken from script_rejected errors. Backtracked and skipped operations carry
+// no errors and are classified by status.
+func NewOpError(op *Op) *OpError {
+	e := &OpError{
+		OpId:       op.RowId,
+		Type:       op.Type,
+		Status:     op.Status,
+		Height:     op.Height,
+		Timestamp:  op.Timestamp,
+		SenderId:   op.SenderId,
+		ReceiverId: op.ReceiverId,
+		Entrypoint: op.Entrypoint,
+		Fee:        op.Fee,
+	}
+	switch op.Status {
+	case tezos.OpStatusBacktracked:
+		e.Class = ErrorClassBacktracked
+		e.ErrorId = ErrorClassBacktracked
+		return e
+	case tezos.OpStatusSkipped:
+		e.Class = ErrorClassSkipped
+		e.ErrorId = ErrorClassSkipped
+		return e
+	}
+
+	e.Class = ErrorClassOther
+	var errs []rpc.OperationError
+	if len(op.Errors) == 0 || json.Unmarshal(op.Errors, &errs) != nil || len(errs) == 0 {
+		return e
+	}
+	best := -1
+	for i, v := range errs {
+		id := NormalizeErrorId(v.ID)
+		if i == 0 {
+			e.ErrorId = id
+		}
+		c, ok := errorClassMap[id]
+		if ok && (best < 0 || c.priority < best) {
+			best = c.priority
+			e.ErrorId = id
+			e.Class = c.class
+		}
+		if v.With != nil && e.FailWith == "" {
+			if buf, err := v.With.MarshalJSON(); err == nil {
+				e.FailWith = string(buf)
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"testing"
+
+	"blockwatch.cc/tzgo/tezos"
+)
+
+func TestNormalizeErrorId(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"proto.016-PtMumbai.michelson_v1.script_rejected", "michelson_v1.script_rejected"},
+		{"proto.015-PtLimaPt.gas_exhausted.operation", "gas_exhausted.operation"},
+		{"michelson_v1.runtime_error", "michelson_v1.runtime_error"},
+		{"proto.broken", "proto.broken"},
+	}
+	for _, test := range tests {
+		if got := NormalizeErrorId(test.id); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.id, got, test.want)
+		}
+	}
+	if ClassifyErrorId("unknown") != ErrorClassOther {
+		t.Errorf("unknown error id not classified as other")
+	}
+}
+
+func TestNewOpError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   tezos.OpStatus
+		errors   string
+		class    string
+		id       string
+		failwith string
+	}{
+		{
+			name:   "backtracked",
+			status: tezos.OpStatusBacktracked,
+			class:  ErrorClassBacktracked,
+			id:     ErrorClassBacktracked,
+		},
+		{
+			name:   "skipped",
+			status: tezos.OpStatusSkipped,
+			class:  ErrorClassSkipped,
+			id:     ErrorClassSkipped,
+		},
+		{
+			name:   "no errors",
+			status: tezos.OpStatusFailed,
+			class:  ErrorClassOther,
+		},
+		{
+			name:   "invalid errors",
+			status: tezos.OpStatusFailed,
+			errors: `{`,
+			class:  ErrorClassOther,
+		},
+		{
+			name:   "unknown error keeps first id",
+			status: tezos.OpStatusFailed,
+			errors: `[{"kind":"temporary","id":"proto.016-PtMumbai.foo"},{"kind":"temporary","id":"proto.016-PtMumbai.bar"}]`,
+			class:  ErrorClassOther,
+			id:     "foo",
+		},
+		{
+			name:     "script rejected wins over runtime error",
+			status:   tezos.OpStatusFailed,
+			errors:   `[{"kind":"temporary","id":"proto.016-PtMumbai.michelson_v1.runtime_error"},{"kind":"temporary","id":"proto.016-PtMumbai.michelson_v1.script_rejected","with":{"string":"NOT_OWNER"}}]`,
+			class:    ErrorClassScriptRejected,
+			id:       "michelson_v1.script_rejected",
+			failwith: `{"string":"NOT_OWNER"}`,
+		},
+		{
+			name:   "more specific balance error",
+			status: tezos.OpStatusFailed,
+			errors: `[{"kind":"temporary","id":"proto.016-PtMumbai.tez.subtraction_underflow"},{"kind":"temporary","id":"proto.016-PtMumbai.contract.balance_too_low"}]`,
+			class:  ErrorClassBalanceTooLow,
+			id:     "contract.balance_too_low",
+		},
+		{
+			name:   "gas exhausted",
+			status: tezos.OpStatusFailed,
+			errors: `[{"kind":"temporary","id":"proto.016-PtMumbai.gas_exhausted.operation"}]`,
+			class:  ErrorClassGasExhausted,
+			id:     "gas_exhausted.operation",
+		},
+	}
+	for _, test := range tests {
+		op := &Op{
+			RowId:    12,
+			Type:     OpTypeTransaction,
+			Status:   test.status,
+			Height:   100,
+			SenderId: 3,
+			Fee:      1000,
+		}
+		if test.errors != "" {
+			op.Errors = []byte(test.errors)
+		}
+		e := NewOpError(op)
+		if e.Class != test.class || e.ErrorId != test.id || e.FailWith != test.failwith {
+			t.Errorf("%s: got class=%s id=%s failwith=%s", test.name, e.Class, e.ErrorId, e.FailWith)
+		}
+		if e.OpId != op.RowId || e.Height != op.Height || e.SenderId != op.SenderId || e.Fee != op.Fee || e.Status != op.Status {
+			t.Errorf("%s: operation fields not copied: %+v", test.name, e)
+		}
+	}
+}
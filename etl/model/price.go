@@ -0,0 +1,47 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+)
+
+// Price is an externally sourced fiat price for one tez at a point in time.
+// Prices are imported via API and are independent of chain data.
+type Price struct {
+	RowId     uint64    `pack:"I,pk"     json:"row_id"`
+	Timestamp time.Time `pack:"T"        json:"time"`
+	Currency  string    `pack:"c,bloom"  json:"currency"` // ISO 4217 code, uppercase
+	Price     float64   `pack:"p"        json:"price"`
+}
+
+// Ensure Price implements the pack.Item interface.
+var _ pack.Item = (*Price)(nil)
+
+func (p *Price) ID() uint64 {
+	return p.RowId
+}
+
+func (p *Price) SetID(id uint64) {
+	p.RowId = id
+}
+
+func (p Price) Time() time.Time {
+	return p.Timestamp
+}
+
+// IsValidCurrency checks for 3 to 5 character uppercase currency codes.
+func IsValidCurrency(s string) bool {
+	if len(s) < 3 || len(s) > 5 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
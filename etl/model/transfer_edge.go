@@ -0,0 +1,54 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package model
+
+import (
+	"encoding/binary"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+)
+
+// TransferEdge aggregates all successful transactions from a sender to a
+// receiver. Internal transactions are attributed to the emitting contract.
+// The same type is stored in two tables: one row per pair with lifetime
+// totals (Cycle is -1) and one row per pair and cycle for height range
+// queries. Key identifies the row and is hash indexed for updates.
+type TransferEdge struct {
+	RowId       uint64    `pack:"I,pk"      json:"row_id"`
+	Key         []byte    `pack:"K"         json:"-"`
+	SenderId    AccountID `pack:"S,bloom"   json:"sender_id"`
+	ReceiverId  AccountID `pack:"R,bloom"   json:"receiver_id"`
+	Cycle       int64     `pack:"c,i16"     json:"cycle"`
+	NTransfers  int       `pack:"n,i32"     json:"n_transfers"`
+	Volume      int64     `pack:"v"         json:"volume"`
+	FirstHeight int64     `pack:"f,i32"     json:"first_height"`
+	LastHeight  int64     `pack:"l,i32"     json:"last_height"`
+	FirstTime   time.Time `pack:"F"         json:"first_time"`
+	LastTime    time.Time `pack:"L"         json:"last_time"`
+}
+
+// Ensure TransferEdge implements the pack.Item interface.
+var _ pack.Item = (*TransferEdge)(nil)
+
+func (e *TransferEdge) ID() uint64 {
+	return e.RowId
+}
+
+func (e *TransferEdge) SetID(id uint64) {
+	e.RowId = id
+}
+
+// TransferEdgeKey returns the row key for a sender/receiver pair. Use cycle
+// -1 for lifetime total rows.
+func TransferEdgeKey(sender, receiver AccountID, cycle int64) []byte {
+	var buf [24]byte
+	binary.BigEndian.PutUint64(buf[:], sender.Value())
+	binary.BigEndian.PutUint64(buf[8:], receiver.Value())
+	if cycle < 0 {
+		return buf[:16]
+	}
+	binary.BigEndian.PutUint64(buf[16:], uint64(cycle))
+	return buf[:]
+}
@@ -10,23 +10,24 @@ import (
 )
 
 type ListRequest struct {
-	Account     *model.Account
-	Mode        pack.FilterMode
-	Typs        model.OpTypeList
-	Since       int64
-	Until       int64
-	Offset      uint
-	Limit       uint
-	Cursor      uint64
-	Order       pack.OrderType
-	SenderId    model.AccountID
-	ReceiverId  model.AccountID
-	Entrypoints []int64
-	Period      int64
-	BigmapId    int64
-	BigmapKey   tezos.ExprHash
-	OpId        model.OpID
-	WithStorage bool
+	Account      *model.Account
+	Mode         pack.FilterMode
+	Typs         model.OpTypeList
+	Since        int64
+	Until        int64
+	Offset       uint
+	Limit        uint
+	Cursor       uint64
+	Order        pack.OrderType
+	SenderId     model.AccountID
+	ReceiverId   model.AccountID
+	Entrypoints  []int64
+	Period       int64
+	BigmapId     int64
+	BigmapKey    tezos.ExprHash
+	BigmapFilter model.BigmapPathFilterList
+	OpId         model.OpID
+	WithStorage  bool
 }
 
 func (r ListRequest) WithDelegation() bool {
@@ -87,6 +87,36 @@ func (m *Indexer) LookupAccountIds(ctx context.Context, ids []uint64) ([]*model.
     return accs, nil
 }
 
+// LookupAccounts loads accounts for a list of addresses in a single query.
+// Unknown addresses are skipped, results are in table order.
+func (m *Indexer) LookupAccounts(ctx context.Context, addrs []tezos.Address) ([]*model.Account, error) {
+    if len(addrs) == 0 {
+        return nil, nil
+    }
+    table, err := m.Table(index.AccountTableKey)
+    if err != nil {
+        return nil, err
+    }
+    keys := make([][]byte, 0, len(addrs))
+    for _, v := range addrs {
+        if v.IsValid() {
+            keys = append(keys, v.Bytes22())
+        }
+    }
+    accs := make([]*model.Account, 0, len(keys))
+    if len(keys) == 0 {
+        return accs, nil
+    }
+    err = pack.NewQuery("accounts_by_hash").
+        WithTable(table).
+        AndIn("address", keys).
+        Execute(ctx, &accs)
+    if err != nil {
+        return nil, err
+    }
+    return accs, nil
+}
+
 func (m *Indexer) FindActivatedAccount(ctx context.Context, addr tezos.Address) (*model.Account, error) {
     table, err := m.Table(index.OpTableKey)
     if err != nil {
@@ -7,6 +7,7 @@ import (
     "context"
 
     "blockwatch.cc/packdb/pack"
+    "blockwatch.cc/packdb/vec"
     "blockwatch.cc/tzgo/tezos"
     "blockwatch.cc/tzindex/etl/index"
     "blockwatch.cc/tzindex/etl/model"
@@ -63,6 +64,55 @@ func (m *Indexer) LookupBakerId(ctx context.Context, id model.AccountID) (*model
     return bkr, nil
 }
 
+// LookupBakers loads bakers and their accounts for a list of addresses in
+// two queries. Unknown addresses are skipped, results are in table order.
+func (m *Indexer) LookupBakers(ctx context.Context, addrs []tezos.Address) ([]*model.Baker, error) {
+    if len(addrs) == 0 {
+        return nil, nil
+    }
+    table, err := m.Table(index.BakerTableKey)
+    if err != nil {
+        return nil, err
+    }
+    keys := make([][]byte, 0, len(addrs))
+    for _, v := range addrs {
+        if v.IsValid() {
+            keys = append(keys, v.Bytes22())
+        }
+    }
+    bkrs := make([]*model.Baker, 0, len(keys))
+    if len(keys) == 0 {
+        return bkrs, nil
+    }
+    err = pack.NewQuery("bakers_by_hash").
+        WithTable(table).
+        AndIn("address", keys).
+        Execute(ctx, &bkrs)
+    if err != nil || len(bkrs) == 0 {
+        return bkrs, err
+    }
+    ids := make([]uint64, len(bkrs))
+    for i, v := range bkrs {
+        ids[i] = v.AccountId.Value()
+    }
+    accs, err := m.LookupAccountIds(ctx, vec.UniqueUint64Slice(ids))
+    if err != nil {
+        return nil, err
+    }
+    accMap := make(map[model.AccountID]*model.Account, len(accs))
+    for _, v := range accs {
+        accMap[v.RowId] = v
+    }
+    res := bkrs[:0]
+    for _, v := range bkrs {
+        if acc, ok := accMap[v.AccountId]; ok {
+            v.Account = acc
+            res = append(res, v)
+        }
+    }
+    return res, nil
+}
+
 func (m *Indexer) ListBakers(ctx context.Context, activeOnly bool) ([]*model.Baker, error) {
     table, err := m.Table(index.BakerTableKey)
     if err != nil {
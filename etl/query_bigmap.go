@@ -4,12 +4,15 @@
 package etl
 
 import (
+    "bytes"
     "context"
     "io"
     "time"
 
     "blockwatch.cc/packdb/pack"
     "blockwatch.cc/packdb/util"
+    "blockwatch.cc/tzgo/micheline"
+    "blockwatch.cc/tzindex/etl/cache"
     "blockwatch.cc/tzindex/etl/index"
     "blockwatch.cc/tzindex/etl/model"
 )
@@ -95,13 +98,17 @@ func (m *Indexer) ListHistoricBigmapKeys(ctx context.Context, r ListRequest) ([]
     if r.Cursor > 0 {
         r.Offset = uint(r.Cursor - 1)
     }
+    // with path filters, filter the full history first and paginate after
+    if r.BigmapFilter.Len() > 0 && !r.BigmapKey.IsValid() {
+        return m.filterBigmapHistory(ctx, hist, r)
+    }
+
     var from, to int
     if r.Order == pack.OrderAsc {
         from, to = int(r.Offset), int(r.Offset+r.Limit)
     } else {
-        l := hist.Len()
-        from = util.Max(0, l-int(r.Offset-r.Limit))
-        to = from + int(r.Limit)
+        to = hist.Len() - int(r.Offset)
+        from = util.Max(0, to-int(r.Limit))
     }
 
     // get from cache
@@ -144,10 +151,28 @@ func (m *Indexer) ListBigmapKeys(ctx context.Context, r ListRequest) ([]*model.B
         // assume hash collisions
         q = q.WithDesc().AndEqual("key_id", model.GetKeyId(r.BigmapId, r.BigmapKey))
     }
+    var alloc *model.BigmapAlloc
+    if r.BigmapFilter.Len() > 0 {
+        alloc, err = m.LookupBigmapType(ctx, r.BigmapId)
+        if err != nil {
+            return nil, err
+        }
+        // narrow down candidate keys using stored columns
+        keys, ok, err := m.FindBigmapFieldKeys(ctx, r.BigmapId, r.BigmapFilter)
+        if err != nil {
+            return nil, err
+        }
+        if ok {
+            if len(keys) == 0 {
+                return []*model.BigmapKV{}, nil
+            }
+            q = q.AndIn("key_id", keys)
+        }
+    }
     items := make([]*model.BigmapKV, 0)
     err = q.Stream(ctx, func(row pack.Row) error {
-        // skip before decoding
-        if r.Offset > 0 {
+        // skip before decoding (filtered lists skip after matching)
+        if r.Offset > 0 && alloc == nil {
             r.Offset--
             return nil
         }
@@ -162,6 +187,17 @@ func (m *Indexer) ListBigmapKeys(ctx context.Context, r ListRequest) ([]*model.B
             return nil
         }
 
+        // match decoded key/value fields
+        if alloc != nil {
+            if !r.BigmapFilter.Match(b, alloc.GetKeyType(), alloc.GetValueType()) {
+                return nil
+            }
+            if r.Offset > 0 {
+                r.Offset--
+                return nil
+            }
+        }
+
         // log.Infof("Found item %s %d %d key %x", b.Action, b.BigmapId, b.RowId, b.Key)
         items = append(items, b)
         if len(items) == int(r.Limit) {
@@ -175,6 +211,268 @@ func (m *Indexer) ListBigmapKeys(ctx context.Context, r ListRequest) ([]*model.B
     return items, nil
 }
 
+// FindBigmapFieldKeys returns key ids of live entries in bigmap id which may
+// match all filters that can be evaluated on stored bigmap columns. Callers
+// must still match decoded entries. ok is false when no filter uses a stored
+// column.
+func (m *Indexer) FindBigmapFieldKeys(ctx context.Context, id int64, filters model.BigmapPathFilterList) ([]uint64, bool, error) {
+    var fields *index.BigmapFieldIndex
+    for _, v := range m.indexes {
+        if idx, ok := v.(*index.BigmapFieldIndex); ok {
+            fields = idx
+            break
+        }
+    }
+    if fields == nil {
+        return nil, false, nil
+    }
+    table, err := m.Table(index.BigmapFieldTableKey)
+    if err != nil {
+        return nil, false, nil
+    }
+    var (
+        keys map[uint64]struct{}
+        used bool
+    )
+    for _, f := range filters {
+        if !fields.HasColumn(id, f.Path) {
+            continue
+        }
+        kind, cond, ok := f.FieldCondition()
+        if !ok {
+            continue
+        }
+        // rows of other kinds compare differently and stay candidates
+        match := make(map[uint64]struct{})
+        field := &model.BigmapField{}
+        err := pack.NewQuery("list_bigmap_fields").
+            WithTable(table).
+            WithFields("K").
+            AndEqual("bigmap_id", id).
+            AndEqual("path", f.Path).
+            OrCondition(
+                pack.And(pack.Equal("kind", kind), cond),
+                pack.NotEqual("kind", kind),
+            ).
+            Stream(ctx, func(r pack.Row) error {
+                if err := r.Decode(field); err != nil {
+                    return err
+                }
+                if !used {
+                    match[field.KeyId] = struct{}{}
+                } else if _, ok := keys[field.KeyId]; ok {
+                    match[field.KeyId] = struct{}{}
+                }
+                return nil
+            })
+        if err != nil {
+            return nil, false, err
+        }
+        keys, used = match, true
+        if len(keys) == 0 {
+            break
+        }
+    }
+    if !used {
+        return nil, false, nil
+    }
+    ids := make([]uint64, 0, len(keys))
+    for k := range keys {
+        ids = append(ids, k)
+    }
+    return ids, true, nil
+}
+
+// filterBigmapHistory applies path filters to a historic bigmap state
+// and returns the requested page of matching items. Entries are decoded
+// one at a time and the scan stops once the page is full.
+func (m *Indexer) filterBigmapHistory(ctx context.Context, hist *cache.BigmapHistory, r ListRequest) ([]*model.BigmapKV, error) {
+    alloc, err := m.LookupBigmapType(ctx, r.BigmapId)
+    if err != nil {
+        return nil, err
+    }
+    keyType, valueType := alloc.GetKeyType(), alloc.GetValueType()
+
+    // cursor refers to position in the unfiltered history (row ids start at 1)
+    pos, step := 0, 1
+    if r.Order == pack.OrderDesc {
+        pos, step = hist.Len()-1, -1
+    }
+    if r.Cursor > 0 {
+        r.Offset = 0
+        if r.Order == pack.OrderDesc {
+            pos = util.Min(pos, int(r.Cursor)-2)
+        } else {
+            pos = int(r.Cursor)
+        }
+    }
+    items := make([]*model.BigmapKV, 0)
+    for ; pos >= 0 && pos < hist.Len(); pos += step {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+        v := hist.At(pos)
+        if !r.BigmapFilter.Match(v, keyType, valueType) {
+            continue
+        }
+        if r.Offset > 0 {
+            r.Offset--
+            continue
+        }
+        items = append(items, v)
+        if len(items) == int(r.Limit) {
+            break
+        }
+    }
+    return items, nil
+}
+
+// BigmapKeyDiff is the net change of a single bigmap key between two heights.
+// Old is nil when the key did not exist at the start height, New is nil when
+// the key was removed.
+type BigmapKeyDiff struct {
+    KeyId    uint64
+    Key      []byte
+    Old      []byte
+    New      []byte
+    Height   int64 // last update height
+    NUpdates int
+}
+
+func (d BigmapKeyDiff) IsAdded() bool   { return d.Old == nil && d.New != nil }
+func (d BigmapKeyDiff) IsRemoved() bool { return d.Old != nil && d.New == nil }
+func (d BigmapKeyDiff) IsChanged() bool { return d.Old != nil && d.New != nil }
+
+// bigmapDiffer folds a bigmap's update history into net key changes.
+type bigmapDiffer struct {
+    events []*model.BigmapUpdate
+    diffs  map[uint64]*BigmapKeyDiff
+    keys   []uint64
+}
+
+func newBigmapDiffer() *bigmapDiffer {
+    return &bigmapDiffer{
+        events: make([]*model.BigmapUpdate, 0),
+        diffs:  make(map[uint64]*BigmapKeyDiff),
+        keys:   make([]uint64, 0),
+    }
+}
+
+// update applies an update inside the diff range. Updates must arrive in
+// height order and must not be reused by the caller.
+func (b *bigmapDiffer) update(upd *model.BigmapUpdate) {
+    switch upd.Action {
+    case micheline.DiffActionAlloc, micheline.DiffActionCopy:
+        b.events = append(b.events, upd)
+        return
+    case micheline.DiffActionRemove:
+        if upd.KeyId == 0 {
+            b.events = append(b.events, upd)
+            return
+        }
+    }
+    d, ok := b.diffs[upd.KeyId]
+    if !ok {
+        d = &BigmapKeyDiff{
+            KeyId: upd.KeyId,
+            Key:   upd.Key,
+        }
+        b.diffs[upd.KeyId] = d
+        b.keys = append(b.keys, upd.KeyId)
+    }
+    d.Height = upd.Height
+    d.NUpdates++
+    if upd.Action == micheline.DiffActionUpdate {
+        d.New = upd.Value
+    } else {
+        d.New = nil
+    }
+}
+
+// origin applies an update at or before the start height to find the
+// original value of changed keys. Updates must arrive in height order.
+func (b *bigmapDiffer) origin(upd *model.BigmapUpdate) {
+    d, ok := b.diffs[upd.KeyId]
+    if !ok || !bytes.Equal(d.Key, upd.Key) {
+        return
+    }
+    switch upd.Action {
+    case micheline.DiffActionUpdate:
+        d.Old = append(d.Old[:0], upd.Value...)
+    case micheline.DiffActionRemove:
+        d.Old = nil
+    }
+}
+
+// list returns net key changes in order of first update.
+func (b *bigmapDiffer) list() []*BigmapKeyDiff {
+    list := make([]*BigmapKeyDiff, 0, len(b.keys))
+    for _, k := range b.keys {
+        d := b.diffs[k]
+        if d.Old == nil && d.New == nil {
+            continue
+        }
+        if d.Old != nil && d.New != nil && bytes.Equal(d.Old, d.New) {
+            continue
+        }
+        list = append(list, d)
+    }
+    return list
+}
+
+// ListBigmapDiff returns the net key changes of a bigmap between heights
+// from (exclusive) and to (inclusive) together with bigmap level events
+// (alloc, copy and full removal). Keys copied from another bigmap appear
+// as regular updates. Keys that end up with their original value are
+// not reported.
+func (m *Indexer) ListBigmapDiff(ctx context.Context, id, from, to int64) ([]*BigmapKeyDiff, []*model.BigmapUpdate, error) {
+    table, err := m.Table(index.BigmapUpdateTableKey)
+    if err != nil {
+        return nil, nil, err
+    }
+    diff := newBigmapDiffer()
+    err = pack.NewQuery("diff_bigmap").
+        WithTable(table).
+        WithOrder(pack.OrderAsc).
+        AndEqual("bigmap_id", id).
+        AndGt("height", from).
+        AndLte("height", to).
+        Stream(ctx, func(r pack.Row) error {
+            upd := &model.BigmapUpdate{}
+            if err := r.Decode(upd); err != nil {
+                return err
+            }
+            diff.update(upd)
+            return nil
+        })
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // load values of changed keys at start height
+    if len(diff.keys) > 0 && from > 0 {
+        upd := &model.BigmapUpdate{}
+        err = pack.NewQuery("diff_bigmap_origin").
+            WithTable(table).
+            WithFields("key_id", "action", "key", "value").
+            WithOrder(pack.OrderAsc).
+            AndEqual("bigmap_id", id).
+            AndIn("key_id", diff.keys).
+            AndLte("height", from).
+            Stream(ctx, func(r pack.Row) error {
+                if err := r.Decode(upd); err != nil {
+                    return err
+                }
+                diff.origin(upd)
+                return nil
+            })
+        if err != nil {
+            return nil, nil, err
+        }
+    }
+    return diff.list(), diff.events, nil
+}
+
 func (m *Indexer) ListBigmapUpdates(ctx context.Context, r ListRequest) ([]model.BigmapUpdate, error) {
     table, err := m.Table(index.BigmapUpdateTableKey)
     if err != nil {
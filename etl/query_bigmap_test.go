@@ -0,0 +1,85 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"testing"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+func testBigmapUpdate(action micheline.DiffAction, height int64, key uint64, val string) *model.BigmapUpdate {
+	upd := &model.BigmapUpdate{
+		Action: action,
+		KeyId:  key,
+		Height: height,
+	}
+	if key > 0 {
+		upd.Key = []byte{byte(key)}
+	}
+	if val != "" {
+		upd.Value = []byte(val)
+	}
+	return upd
+}
+
+func TestBigmapDiffer(t *testing.T) {
+	diff := newBigmapDiffer()
+
+	// history before the start height
+	origin := []*model.BigmapUpdate{
+		testBigmapUpdate(micheline.DiffActionUpdate, 1, 1, "a0"),
+		testBigmapUpdate(micheline.DiffActionUpdate, 1, 2, "b0"),
+		testBigmapUpdate(micheline.DiffActionUpdate, 2, 3, "c0"),
+		testBigmapUpdate(micheline.DiffActionUpdate, 2, 4, "x"),
+		testBigmapUpdate(micheline.DiffActionRemove, 3, 4, ""),
+	}
+
+	// updates inside the diff range
+	for _, upd := range []*model.BigmapUpdate{
+		testBigmapUpdate(micheline.DiffActionAlloc, 10, 0, ""),
+		testBigmapUpdate(micheline.DiffActionUpdate, 10, 1, "a1"), // changed
+		testBigmapUpdate(micheline.DiffActionUpdate, 11, 1, "a2"),
+		testBigmapUpdate(micheline.DiffActionRemove, 11, 2, ""),   // removed
+		testBigmapUpdate(micheline.DiffActionUpdate, 10, 3, "c1"), // reverted
+		testBigmapUpdate(micheline.DiffActionUpdate, 12, 3, "c0"),
+		testBigmapUpdate(micheline.DiffActionUpdate, 12, 4, "d1"), // added after removal
+		testBigmapUpdate(micheline.DiffActionUpdate, 12, 5, "e1"), // added and removed
+		testBigmapUpdate(micheline.DiffActionRemove, 13, 5, ""),
+		testBigmapUpdate(micheline.DiffActionRemove, 13, 0, ""),
+	} {
+		diff.update(upd)
+	}
+	for _, upd := range origin {
+		diff.origin(upd)
+	}
+
+	if len(diff.events) != 2 {
+		t.Errorf("got %d events, want 2", len(diff.events))
+	}
+	list := diff.list()
+	want := []struct {
+		key      uint64
+		old, new string
+		height   int64
+		n        int
+	}{
+		{1, "a0", "a2", 11, 2},
+		{2, "b0", "", 11, 1},
+		{4, "", "d1", 12, 1},
+	}
+	if len(list) != len(want) {
+		t.Fatalf("got %d diffs, want %d", len(list), len(want))
+	}
+	for i, w := range want {
+		d := list[i]
+		if d.KeyId != w.key || string(d.Old) != w.old || string(d.New) != w.new || d.Height != w.height || d.NUpdates != w.n {
+			t.Errorf("diff %d = key=%d old=%q new=%q height=%d n=%d", i, d.KeyId, d.Old, d.New, d.Height, d.NUpdates)
+		}
+	}
+	if !list[0].IsChanged() || !list[1].IsRemoved() || !list[2].IsAdded() {
+		t.Errorf("unexpected diff kinds")
+	}
+}
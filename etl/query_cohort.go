@@ -0,0 +1,119 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// ListCohortMembers lists members of the named cohort in insertion order.
+// An empty name lists members of all cohorts.
+func (m *Indexer) ListCohortMembers(ctx context.Context, name string) ([]*model.CohortMember, error) {
+	table, err := m.Table(index.CohortTableKey)
+	if err != nil {
+		return nil, err
+	}
+	q := pack.NewQuery("api.cohort.list").
+		WithTable(table).
+		WithoutCache()
+	if name != "" {
+		q = q.AndEqual("name", name)
+	}
+	list := make([]*model.CohortMember, 0)
+	if err := q.Execute(ctx, &list); err != nil {
+		return nil, err
+	}
+	if name != "" && len(list) == 0 {
+		return nil, index.ErrNoCohortEntry
+	}
+	return list, nil
+}
+
+// AddCohortMembers adds new members to the named cohort. Addresses that are
+// already members are skipped.
+func (m *Indexer) AddCohortMembers(ctx context.Context, name string, members []*model.CohortMember) error {
+	table, err := m.Table(index.CohortTableKey)
+	if err != nil {
+		return err
+	}
+	ctx = m.recordChanges(ctx)
+	existing, err := m.ListCohortMembers(ctx, name)
+	if err != nil && err != index.ErrNoCohortEntry {
+		return err
+	}
+	seen := make(map[string]struct{}, len(existing))
+	for _, v := range existing {
+		seen[v.Address.String()] = struct{}{}
+	}
+	ins := make([]pack.Item, 0, len(members))
+	now := time.Now().UTC()
+	for _, v := range members {
+		key := v.Address.String()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		v.Name = name
+		v.CreatedAt = now
+		ins = append(ins, v)
+	}
+	if len(ins) == 0 {
+		return nil
+	}
+	if err := model.Insert(ctx, table, ins); err != nil {
+		return err
+	}
+	return table.Flush(ctx)
+}
+
+// RemoveCohortMembers removes addresses from the named cohort. Without
+// addresses the entire cohort is removed.
+func (m *Indexer) RemoveCohortMembers(ctx context.Context, name string, addrs []tezos.Address) error {
+	table, err := m.Table(index.CohortTableKey)
+	if err != nil {
+		return err
+	}
+	ctx = m.recordChanges(ctx)
+	q := pack.NewQuery("api.cohort.delete").
+		WithTable(table).
+		AndEqual("name", name)
+	if len(addrs) > 0 {
+		keys := make([][]byte, len(addrs))
+		for i, v := range addrs {
+			keys[i] = v.Bytes22()
+		}
+		q = q.AndIn("address", keys)
+	}
+	if _, err := model.Delete(ctx, table, q); err != nil {
+		return err
+	}
+	return table.Flush(ctx)
+}
+
+// ListBalanceFlows lists spendable balance flows of the given accounts
+// within a time range.
+func (m *Indexer) ListBalanceFlows(ctx context.Context, ids []uint64, from, to time.Time) ([]*model.Flow, error) {
+	table, err := m.Table(index.FlowTableKey)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*model.Flow, 0)
+	err = pack.NewQuery("api.flow.cohort").
+		WithTable(table).
+		WithFields("time", "account_id", "counterparty_id", "amount_in", "amount_out").
+		AndIn("account_id", ids).
+		AndEqual("category", model.FlowCategoryBalance).
+		AndRange("time", from, to).
+		Execute(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
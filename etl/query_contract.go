@@ -5,6 +5,7 @@ package etl
 
 import (
     "context"
+    "io"
     "sort"
 
     "blockwatch.cc/packdb/pack"
@@ -55,6 +56,36 @@ func (m *Indexer) LookupContractId(ctx context.Context, id model.AccountID) (*mo
     return cc, nil
 }
 
+// LookupContracts loads contracts for a list of addresses in a single query.
+// Unknown addresses are skipped, results are in table order.
+func (m *Indexer) LookupContracts(ctx context.Context, addrs []tezos.Address) ([]*model.Contract, error) {
+    if len(addrs) == 0 {
+        return nil, nil
+    }
+    table, err := m.Table(index.ContractTableKey)
+    if err != nil {
+        return nil, err
+    }
+    keys := make([][]byte, 0, len(addrs))
+    for _, v := range addrs {
+        if v.IsValid() {
+            keys = append(keys, v.Bytes22())
+        }
+    }
+    ccs := make([]*model.Contract, 0, len(keys))
+    if len(keys) == 0 {
+        return ccs, nil
+    }
+    err = pack.NewQuery("contracts_by_hash").
+        WithTable(table).
+        AndIn("address", keys).
+        Execute(ctx, &ccs)
+    if err != nil {
+        return nil, err
+    }
+    return ccs, nil
+}
+
 func (m *Indexer) LookupContractType(ctx context.Context, id model.AccountID) (micheline.Type, micheline.Type, uint64, error) {
     elem, ok := m.contract_types.Get(id)
     if !ok {
@@ -242,3 +273,256 @@ func (m *Indexer) ListOpEvents(ctx context.Context, id uint64, sndr model.Accoun
     }
     return events, nil
 }
+
+// StorageVersion is a contract storage snapshot together with the operation
+// that produced it and the snapshot it replaced.
+type StorageVersion struct {
+    *model.Storage
+    Prev   *model.Storage // nil for the first version
+    OpHash tezos.OpHash
+    OpId   model.OpID
+}
+
+func (m *Indexer) ListStorageHistory(ctx context.Context, r ListRequest) ([]*StorageVersion, error) {
+    table, err := m.Table(index.StorageTableKey)
+    if err != nil {
+        return nil, err
+    }
+    // cursor and offset are mutually exclusive
+    if r.Cursor > 0 {
+        r.Offset = 0
+    }
+    q := pack.NewQuery("api.storage.history").
+        WithTable(table).
+        AndEqual("account_id", r.Account.RowId).
+        WithOrder(r.Order).
+        WithLimit(int(r.Limit)).
+        WithOffset(int(r.Offset))
+    if r.Since > 0 {
+        q = q.AndGt("height", r.Since)
+    }
+    if r.Until > 0 {
+        q = q.AndLte("height", r.Until)
+    }
+    if r.Cursor > 0 {
+        if r.Order == pack.OrderDesc {
+            q = q.AndLt("I", r.Cursor)
+        } else {
+            q = q.AndGt("I", r.Cursor)
+        }
+    }
+    list := make([]*model.Storage, 0)
+    if err := q.Execute(ctx, &list); err != nil {
+        return nil, err
+    }
+    if len(list) == 0 {
+        return nil, nil
+    }
+
+    // link each version to its predecessor, only the oldest version in
+    // the result requires an extra lookup
+    sort.Slice(list, func(i, j int) bool { return list[i].RowId < list[j].RowId })
+    versions := make([]*StorageVersion, len(list))
+    for i, v := range list {
+        versions[i] = &StorageVersion{Storage: v}
+        if i > 0 {
+            versions[i].Prev = list[i-1]
+        }
+    }
+    prev := &model.Storage{}
+    err = pack.NewQuery("api.storage.prev").
+        WithTable(table).
+        WithDesc().
+        WithLimit(1).
+        AndEqual("account_id", r.Account.RowId).
+        AndLt("I", list[0].RowId).
+        Execute(ctx, prev)
+    if err != nil {
+        return nil, err
+    }
+    if prev.RowId > 0 {
+        versions[0].Prev = prev
+    }
+
+    // find the operations that produced each version
+    heights := make([]int64, 0, len(list))
+    for _, v := range list {
+        if len(heights) == 0 || heights[len(heights)-1] != v.Height {
+            heights = append(heights, v.Height)
+        }
+    }
+    optable, err := m.Table(index.OpTableKey)
+    if err != nil {
+        return nil, err
+    }
+    ops := make([]*model.Op, 0, len(list))
+    err = pack.NewQuery("api.storage.ops").
+        WithTable(optable).
+        WithFields("I", "H", "h", "s").
+        AndEqual("receiver_id", r.Account.RowId).
+        AndEqual("is_success", true).
+        AndIn("height", heights).
+        Stream(ctx, func(row pack.Row) error {
+            op := &model.Op{}
+            if err := row.Decode(op); err != nil {
+                return err
+            }
+            ops = append(ops, op)
+            return nil
+        })
+    if err != nil {
+        return nil, err
+    }
+    matchStorageOps(versions, ops)
+
+    if r.Order == pack.OrderDesc {
+        for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+            versions[i], versions[j] = versions[j], versions[i]
+        }
+    }
+    return versions, nil
+}
+
+// matchStorageOps links storage versions to the operations that produced
+// them by height and storage hash. The same storage hash may repeat within
+// a block, so versions and ops (both in row order) are paired in order.
+func matchStorageOps(versions []*StorageVersion, ops []*model.Op) {
+    type opKey struct {
+        height int64
+        hash   uint64
+    }
+    opMap := make(map[opKey][]*model.Op)
+    for _, op := range ops {
+        k := opKey{op.Height, op.StorageHash}
+        opMap[k] = append(opMap[k], op)
+    }
+    for _, v := range versions {
+        k := opKey{v.Height, v.Hash}
+        ops := opMap[k]
+        if len(ops) == 0 {
+            continue
+        }
+        v.OpHash = ops[0].Hash
+        v.OpId = ops[0].RowId
+        opMap[k] = ops[1:]
+    }
+}
+
+func (m *Indexer) ListContractCallStats(ctx context.Context, r ListRequest) ([]*model.ContractCallStats, error) {
+    table, err := m.Table(index.ContractCallTableKey)
+    if err != nil {
+        return nil, err
+    }
+    q := pack.NewQuery("api.contract_calls.list").
+        WithTable(table).
+        AndEqual("account_id", r.Account.RowId).
+        WithOrder(r.Order)
+    if r.Since > 0 {
+        q = q.AndGt("height", r.Since)
+    }
+    if r.Until > 0 {
+        q = q.AndLte("height", r.Until)
+    }
+    switch len(r.Entrypoints) {
+    case 0:
+        // all entrypoints
+    case 1:
+        q = q.And("entrypoint_id", r.Mode, r.Entrypoints[0])
+    default:
+        q = q.And("entrypoint_id", r.Mode, r.Entrypoints)
+    }
+    list := make([]*model.ContractCallStats, 0)
+    if err := q.Execute(ctx, &list); err != nil {
+        return nil, err
+    }
+    return list, nil
+}
+
+func (m *Indexer) ListContractErrors(ctx context.Context, r ListRequest) ([]*model.OpError, error) {
+    table, err := m.Table(index.OpErrorTableKey)
+    if err != nil {
+        return nil, err
+    }
+    q := pack.NewQuery("api.op_error.list").
+        WithTable(table).
+        AndEqual("receiver_id", r.Account.RowId).
+        WithOrder(r.Order)
+    if r.Since > 0 {
+        q = q.AndGt("height", r.Since)
+    }
+    if r.Until > 0 {
+        q = q.AndLte("height", r.Until)
+    }
+    switch len(r.Entrypoints) {
+    case 0:
+        // all entrypoints
+    case 1:
+        q = q.And("entrypoint_id", r.Mode, r.Entrypoints[0])
+    default:
+        q = q.And("entrypoint_id", r.Mode, r.Entrypoints)
+    }
+    list := make([]*model.OpError, 0)
+    if err := q.Execute(ctx, &list); err != nil {
+        return nil, err
+    }
+    return list, nil
+}
+
+// ListContractEvents lists events emitted by a contract. Events are filtered
+// by tag and height range in the database and by the optional match function
+// while streaming. Offset, cursor and limit apply to matching events only.
+func (m *Indexer) ListContractEvents(ctx context.Context, r ListRequest, tags []string, match func(*model.Event) bool) ([]*model.Event, error) {
+    table, err := m.Table(index.EventTableKey)
+    if err != nil {
+        return nil, err
+    }
+    q := pack.NewQuery("api.event.list").
+        WithTable(table).
+        AndEqual("account_id", r.Account.RowId).
+        WithOrder(r.Order)
+    if r.Since > 0 {
+        q = q.AndGt("height", r.Since)
+    }
+    if r.Until > 0 {
+        q = q.AndLte("height", r.Until)
+    }
+    if r.Cursor > 0 {
+        if r.Order == pack.OrderDesc {
+            q = q.AndLt("I", r.Cursor)
+        } else {
+            q = q.AndGt("I", r.Cursor)
+        }
+    }
+    switch len(tags) {
+    case 0:
+        // all tags
+    case 1:
+        q = q.AndEqual("tag", tags[0])
+    default:
+        q = q.AndIn("tag", tags)
+    }
+    list := make([]*model.Event, 0)
+    offset := r.Offset
+    err = q.Stream(ctx, func(row pack.Row) error {
+        ev := &model.Event{}
+        if err := row.Decode(ev); err != nil {
+            return err
+        }
+        if match != nil && !match(ev) {
+            return nil
+        }
+        if offset > 0 {
+            offset--
+            return nil
+        }
+        list = append(list, ev)
+        if r.Limit > 0 && uint(len(list)) >= r.Limit {
+            return io.EOF
+        }
+        return nil
+    })
+    if err != nil && err != io.EOF {
+        return nil, err
+    }
+    return list, nil
+}
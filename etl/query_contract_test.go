@@ -0,0 +1,70 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"testing"
+
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+func TestMatchStorageOps(t *testing.T) {
+	version := func(height int64, hash uint64) *StorageVersion {
+		return &StorageVersion{Storage: &model.Storage{Height: height, Hash: hash}}
+	}
+	op := func(id model.OpID, height int64, hash uint64) *model.Op {
+		return &model.Op{RowId: id, Height: height, StorageHash: hash}
+	}
+	tests := []struct {
+		name     string
+		versions []*StorageVersion
+		ops      []*model.Op
+		want     []model.OpID
+	}{
+		{
+			name:     "one per block",
+			versions: []*StorageVersion{version(1, 10), version(2, 20)},
+			ops:      []*model.Op{op(1, 1, 10), op(2, 2, 20)},
+			want:     []model.OpID{1, 2},
+		},
+		{
+			name:     "different hashes in block",
+			versions: []*StorageVersion{version(1, 10), version(1, 11)},
+			ops:      []*model.Op{op(1, 1, 10), op(2, 1, 11)},
+			want:     []model.OpID{1, 2},
+		},
+		{
+			name:     "repeated hash in block",
+			versions: []*StorageVersion{version(1, 10), version(1, 11), version(1, 10)},
+			ops:      []*model.Op{op(1, 1, 10), op(2, 1, 11), op(3, 1, 10)},
+			want:     []model.OpID{1, 2, 3},
+		},
+		{
+			name:     "repeated hash across blocks",
+			versions: []*StorageVersion{version(1, 10), version(2, 10)},
+			ops:      []*model.Op{op(1, 1, 10), op(2, 2, 10)},
+			want:     []model.OpID{1, 2},
+		},
+		{
+			name:     "missing op",
+			versions: []*StorageVersion{version(1, 10), version(1, 10)},
+			ops:      []*model.Op{op(1, 1, 10)},
+			want:     []model.OpID{1, 0},
+		},
+		{
+			name:     "unrelated ops",
+			versions: []*StorageVersion{version(2, 10)},
+			ops:      []*model.Op{op(1, 1, 10), op(2, 2, 11), op(3, 2, 10)},
+			want:     []model.OpID{3},
+		},
+	}
+	for _, test := range tests {
+		matchStorageOps(test.versions, test.ops)
+		for i, v := range test.versions {
+			if v.OpId != test.want[i] {
+				t.Errorf("%s: version %d op %d, want %d", test.name, i, v.OpId, test.want[i])
+			}
+		}
+	}
+}
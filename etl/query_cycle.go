@@ -0,0 +1,249 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+	"sort"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// Denunciation is a double baking or double (pre)endorsement evidence
+// included in a cycle with its offender, accuser and losses.
+type Denunciation struct {
+	Type          model.OpType
+	Height        int64
+	OpId          model.OpID
+	OffenderId    model.AccountID
+	AccuserId     model.AccountID
+	LostDeposits  int64
+	LostRewards   int64
+	LostFees      int64
+	AccuserReward int64
+}
+
+// CycleBaker is the per-baker breakdown of cycle health data.
+type CycleBaker struct {
+	Cycle              int64
+	AccountId          model.AccountID
+	NBakingRights      int64
+	NBlocksBaked       int64 // on own rights
+	NBlocksStolen      int64 // baked without round 0 right
+	NBlocksMissed      int64
+	NEndorsingRights   int64 // blocks with endorsing rights
+	NBlocksEndorsed    int64
+	NBlocksNotEndorsed int64
+	NEndorsingSlots    int64 // endorsing power from income sheet
+	NSlotsEndorsed     int64
+	NSeedsRequired     int64
+	NSeedsRevealed     int64
+	NSeedsUnrevealed   int64 // reveal window closed without a reveal
+	NSeedsPending      int64 // reveal window still open
+	NVdfRevelations    int64
+	NDoubleBaking      int64 // as offender
+	NDoubleEndorsement int64 // as offender
+	NAccusations       int64 // as accuser
+	LostDeposits       int64
+	LostRewards        int64
+	LostFees           int64
+	AccusationRewards  int64
+	Reliability        int64 // 0..10000
+	Denunciations      []*Denunciation
+}
+
+// IsDegraded returns true when the baker missed any rights, did not reveal
+// a required seed nonce or was denounced in the cycle. Seeds that can still
+// be revealed do not count.
+func (b CycleBaker) IsDegraded() bool {
+	return b.NBlocksMissed > 0 ||
+		b.NBlocksNotEndorsed > 0 ||
+		b.NSeedsUnrevealed > 0 ||
+		b.NDoubleBaking > 0 ||
+		b.NDoubleEndorsement > 0
+}
+
+// ListCycleBakers builds the per-baker health breakdown of a cycle from rights,
+// income and baker operations. Rights are only evaluated up to the current
+// best height, so an active cycle reports misses observed so far.
+func (m *Indexer) ListCycleBakers(ctx context.Context, cycle int64) ([]*CycleBaker, []*Denunciation, error) {
+	// use params that were active at cycle start (future safe, returns latest)
+	p := m.reg.GetParamsLatest()
+	p = m.ParamsByHeight(p.CycleStartHeight(cycle))
+	start, end := p.CycleStartHeight(cycle), p.CycleEndHeight(cycle)
+
+	// endorsements for the last block are included in the next block
+	best := m.BestHeight()
+	bakePos := int(util.Min64(best, end) - start)
+	endorsePos := int(util.Min64(best-1, end) - start)
+	revealClosed := best > p.CycleEndHeight(cycle+1)
+
+	bakers := make(map[model.AccountID]*CycleBaker)
+	get := func(id model.AccountID) *CycleBaker {
+		b, ok := bakers[id]
+		if !ok {
+			b = &CycleBaker{
+				Cycle:         cycle,
+				AccountId:     id,
+				Denunciations: make([]*Denunciation, 0),
+			}
+			bakers[id] = b
+		}
+		return b
+	}
+
+	// rights
+	rights, err := m.Table(index.RightsTableKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	right := &model.Right{}
+	err = pack.NewQuery("cycle_bakers.rights").
+		WithTable(rights).
+		AndEqual("cycle", cycle).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(right); err != nil {
+				return err
+			}
+			b := get(right.AccountId)
+			for i := 0; i <= bakePos; i++ {
+				if right.Bake.IsSet(i) {
+					b.NBakingRights++
+				}
+				switch {
+				case right.Bake.IsSet(i) && right.Baked.IsSet(i):
+					b.NBlocksBaked++
+				case right.IsLost(i):
+					b.NBlocksMissed++
+				case right.IsStolen(i):
+					b.NBlocksStolen++
+				}
+			}
+			for i := 0; i <= endorsePos; i++ {
+				if right.Endorse.IsSet(i) {
+					b.NEndorsingRights++
+					if right.IsMissed(i) {
+						b.NBlocksNotEndorsed++
+					} else {
+						b.NBlocksEndorsed++
+					}
+				}
+			}
+			// seed nonces are revealed in the next cycle, missing reveals are
+			// final only when that cycle has ended
+			for i := 0; i < right.Seed.Size(); i++ {
+				if !right.IsSeedRequired(i) {
+					continue
+				}
+				b.NSeedsRequired++
+				switch {
+				case right.IsSeedRevealed(i):
+					b.NSeedsRevealed++
+				case revealClosed:
+					b.NSeedsUnrevealed++
+				default:
+					b.NSeedsPending++
+				}
+			}
+			if endorsePos >= 0 {
+				b.Reliability = right.Reliability(endorsePos)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// endorsing power
+	if income, err := m.Table(index.IncomeTableKey); err == nil {
+		in := &model.Income{}
+		err = pack.NewQuery("cycle_bakers.income").
+			WithTable(income).
+			WithFields("account_id", "n_endorsing_rights", "n_slots_endorsed").
+			AndEqual("cycle", cycle).
+			Stream(ctx, func(r pack.Row) error {
+				if err := r.Decode(in); err != nil {
+					return err
+				}
+				b, ok := bakers[in.AccountId]
+				if !ok {
+					return nil
+				}
+				b.NEndorsingSlots = in.NEndorsingRights
+				b.NSlotsEndorsed = in.NSlotsEndorsed
+				return nil
+			})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// denunciations and vdf revelations
+	ops, err := m.Table(index.OpTableKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	denunciations := make([]*Denunciation, 0)
+	op := &model.Op{}
+	err = pack.NewQuery("cycle_bakers.ops").
+		WithTable(ops).
+		WithFields("row_id", "type", "height", "sender_id", "receiver_id", "volume", "reward", "deposit", "fee").
+		AndEqual("cycle", cycle).
+		AndIn("type", []uint8{
+			uint8(model.OpTypeDoubleBaking),
+			uint8(model.OpTypeDoubleEndorsement),
+			uint8(model.OpTypeDoublePreendorsement),
+			uint8(model.OpTypeVdfRevelation),
+		}).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(op); err != nil {
+				return err
+			}
+			if op.Type == model.OpTypeVdfRevelation {
+				get(op.SenderId).NVdfRevelations++
+				return nil
+			}
+			// offender losses are stored as negative amounts
+			d := &Denunciation{
+				Type:          op.Type,
+				Height:        op.Height,
+				OpId:          op.RowId,
+				OffenderId:    op.ReceiverId,
+				AccuserId:     op.SenderId,
+				LostDeposits:  -op.Deposit,
+				LostRewards:   -op.Reward,
+				LostFees:      -op.Fee,
+				AccuserReward: op.Volume,
+			}
+			denunciations = append(denunciations, d)
+			offender := get(d.OffenderId)
+			if d.Type == model.OpTypeDoubleBaking {
+				offender.NDoubleBaking++
+			} else {
+				offender.NDoubleEndorsement++
+			}
+			offender.LostDeposits += d.LostDeposits
+			offender.LostRewards += d.LostRewards
+			offender.LostFees += d.LostFees
+			offender.Denunciations = append(offender.Denunciations, d)
+			accuser := get(d.AccuserId)
+			accuser.NAccusations++
+			accuser.AccusationRewards += d.AccuserReward
+			accuser.Denunciations = append(accuser.Denunciations, d)
+			return nil
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]*CycleBaker, 0, len(bakers))
+	for _, v := range bakers {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].AccountId < list[j].AccountId })
+	return list, denunciations, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// StreamTransferEdges calls fn for each transfer edge sent (outgoing) or
+// received by any of the given accounts. Without a height range edges are
+// lifetime totals with one row per pair. With a range, per-cycle rows are
+// returned for all cycles overlapping the range, so the range is widened to
+// whole cycles and a pair may be returned once per cycle. The edge passed to
+// fn is reused between calls.
+func (m *Indexer) StreamTransferEdges(ctx context.Context, ids []uint64, outgoing bool, r ListRequest, fn func(*model.TransferEdge) error) error {
+	key := index.TransferEdgeTableKey
+	if r.Since > 0 || r.Until > 0 {
+		key = index.TransferEdgeCycleTableKey
+	}
+	table, err := m.Table(key)
+	if err != nil {
+		return err
+	}
+	field := "receiver_id"
+	if outgoing {
+		field = "sender_id"
+	}
+	q := pack.NewQuery("api.transfer_edge.stream").
+		WithTable(table).
+		WithOrder(r.Order)
+	if len(ids) == 1 {
+		q = q.AndEqual(field, ids[0])
+	} else {
+		q = q.AndIn(field, ids)
+	}
+	if r.Since > 0 {
+		q = q.AndGte("cycle", m.ParamsByHeight(r.Since+1).CycleFromHeight(r.Since+1))
+	}
+	if r.Until > 0 {
+		q = q.AndLte("cycle", m.ParamsByHeight(r.Until).CycleFromHeight(r.Until))
+	}
+	edge := &model.TransferEdge{}
+	return q.Stream(ctx, func(row pack.Row) error {
+		if err := row.Decode(edge); err != nil {
+			return err
+		}
+		return fn(edge)
+	})
+}
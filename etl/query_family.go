@@ -0,0 +1,82 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+func (m *Indexer) LookupCodeFamilyMember(ctx context.Context, id model.AccountID) (*model.CodeFamilyMember, error) {
+	table, err := m.Table(index.CodeFamilyMemberTableKey)
+	if err != nil {
+		return nil, err
+	}
+	member := &model.CodeFamilyMember{}
+	err = pack.NewQuery("api.code_family_member.lookup").
+		WithTable(table).
+		AndEqual("account_id", id).
+		Execute(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	if member.RowId == 0 {
+		return nil, index.ErrNoCodeFamilyEntry
+	}
+	return member, nil
+}
+
+func (m *Indexer) LookupCodeFamily(ctx context.Context, kind model.CodeFamilyKind, hash uint64) (*model.CodeFamily, error) {
+	table, err := m.Table(index.CodeFamilyTableKey)
+	if err != nil {
+		return nil, err
+	}
+	family := &model.CodeFamily{}
+	err = pack.NewQuery("api.code_family.lookup").
+		WithTable(table).
+		AndEqual("hash", hash).
+		AndEqual("kind", kind).
+		Execute(ctx, family)
+	if err != nil {
+		return nil, err
+	}
+	if family.RowId == 0 {
+		return nil, index.ErrNoCodeFamilyEntry
+	}
+	return family, nil
+}
+
+// ListCodeFamilyMembers lists contracts sharing hash of the given kind in
+// origination order. A zero limit returns all members.
+func (m *Indexer) ListCodeFamilyMembers(ctx context.Context, kind model.CodeFamilyKind, hash uint64, r ListRequest) ([]*model.CodeFamilyMember, error) {
+	table, err := m.Table(index.CodeFamilyMemberTableKey)
+	if err != nil {
+		return nil, err
+	}
+	// cursor and offset are mutually exclusive
+	if r.Cursor > 0 {
+		r.Offset = 0
+	}
+	q := pack.NewQuery("api.code_family_member.list").
+		WithTable(table).
+		AndEqual(kind.HashField(), hash).
+		WithOrder(r.Order).
+		WithLimit(int(r.Limit)).
+		WithOffset(int(r.Offset))
+	if r.Cursor > 0 {
+		if r.Order == pack.OrderDesc {
+			q = q.AndLt("I", r.Cursor)
+		} else {
+			q = q.AndGt("I", r.Cursor)
+		}
+	}
+	list := make([]*model.CodeFamilyMember, 0)
+	if err := q.Execute(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -20,8 +20,9 @@ func (m *Indexer) UpdateMetadata(ctx context.Context, md *model.Metadata) error
     if err != nil {
         return err
     }
+    ctx = m.recordChanges(ctx)
     if md.RowId > 0 {
-        return table.Update(ctx, md)
+        return model.Update(ctx, table, md)
     }
     err = pack.NewQuery("api.metadata.search").
         WithTable(table).
@@ -40,7 +41,7 @@ func (m *Indexer) UpdateMetadata(ctx context.Context, md *model.Metadata) error
     if err != nil {
         return err
     }
-    return table.Update(ctx, md)
+    return model.Update(ctx, table, md)
 }
 
 func (m *Indexer) RemoveMetadata(ctx context.Context, md *model.Metadata) error {
@@ -51,15 +52,16 @@ func (m *Indexer) RemoveMetadata(ctx context.Context, md *model.Metadata) error
     if err != nil {
         return err
     }
+    ctx = m.recordChanges(ctx)
     if md.RowId > 0 {
-        return table.DeleteIds(ctx, []uint64{md.RowId})
+        return model.DeleteIds(ctx, table, []uint64{md.RowId})
     }
     q := pack.NewQuery("api.metadata.delete").
         WithTable(table).
         AndEqual("address", md.Address.Bytes22()).
         AndEqual("asset_id", md.AssetId).
         AndEqual("is_asset", md.IsAsset)
-    _, err = table.Delete(ctx, q)
+    _, err = model.Delete(ctx, table, q)
     return err
 }
 
@@ -68,10 +70,11 @@ func (m *Indexer) PurgeMetadata(ctx context.Context) error {
     if err != nil {
         return err
     }
+    ctx = m.recordChanges(ctx)
     q := pack.NewQuery("api.metadata.purge").
         WithTable(table).
         AndGte("row_id", 0)
-    if _, err := table.Delete(ctx, q); err != nil {
+    if _, err := model.Delete(ctx, table, q); err != nil {
         return err
     }
     return table.Flush(ctx)
@@ -82,6 +85,7 @@ func (m *Indexer) UpsertMetadata(ctx context.Context, entries []*model.Metadata)
     if err != nil {
         return err
     }
+    ctx = m.recordChanges(ctx)
 
     // copy slice ptrs
     match := make([]*model.Metadata, len(entries))
@@ -135,7 +139,7 @@ func (m *Indexer) UpsertMetadata(ctx context.Context, entries []*model.Metadata)
 
     // update
     if len(upd) > 0 {
-        if err := table.Update(ctx, upd); err != nil {
+        if err := model.Update(ctx, table, upd); err != nil {
             return err
         }
     }
@@ -146,7 +150,7 @@ func (m *Indexer) UpsertMetadata(ctx context.Context, entries []*model.Metadata)
         for i, v := range match {
             ins[i] = v
         }
-        if err := table.Insert(ctx, ins); err != nil {
+        if err := model.Insert(ctx, table, ins); err != nil {
             return err
         }
     }
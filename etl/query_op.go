@@ -72,6 +72,29 @@ func (m *Indexer) LookupOpHash(ctx context.Context, opid model.OpID) tezos.OpHas
     return o.Hash
 }
 
+// LookupEventOpHash returns the hash of an operation by its external id
+// (height << 16 | op_n).
+func (m *Indexer) LookupEventOpHash(ctx context.Context, id uint64) tezos.OpHash {
+    table, err := m.Table(index.OpTableKey)
+    if err != nil {
+        return tezos.OpHash{}
+    }
+    type XOp struct {
+        Hash tezos.OpHash `pack:"H"`
+    }
+    o := &XOp{}
+    err = pack.NewQuery("find_tx").
+        WithTable(table).
+        AndEqual("height", int64(id>>16)).
+        AndEqual("op_n", int64(id&0xFFFF)).
+        WithLimit(1).
+        Execute(ctx, o)
+    if err != nil {
+        return tezos.OpHash{}
+    }
+    return o.Hash
+}
+
 func (m *Indexer) LookupEndorsement(ctx context.Context, opIdent string) ([]*model.Op, error) {
     table, err := m.Table(index.EndorseOpTableKey)
     if err != nil {
@@ -145,6 +168,34 @@ func (m *Indexer) LookupOpIds(ctx context.Context, ids []uint64) ([]*model.Op, e
     return ops, nil
 }
 
+// LookupOpHashes loads all operations for a list of operation hashes in a
+// single query. Unknown hashes are skipped, results are in table order.
+func (m *Indexer) LookupOpHashes(ctx context.Context, hashes []tezos.OpHash, r ListRequest) ([]*model.Op, error) {
+    if len(hashes) == 0 {
+        return nil, nil
+    }
+    table, err := m.Table(index.OpTableKey)
+    if err != nil {
+        return nil, err
+    }
+    keys := make([][]byte, len(hashes))
+    for i, v := range hashes {
+        keys[i] = v.Hash.Hash
+    }
+    ops := make([]*model.Op, 0, len(hashes))
+    err = pack.NewQuery("ops_by_hash").
+        WithTable(table).
+        AndIn("hash", keys).
+        Execute(ctx, &ops)
+    if err != nil {
+        return nil, err
+    }
+    if r.WithStorage {
+        m.joinStorage(ctx, ops)
+    }
+    return ops, nil
+}
+
 // Note: offset and limit count in atomar operations
 func (m *Indexer) ListBlockOps(ctx context.Context, r ListRequest) ([]*model.Op, error) {
     table, err := m.Table(index.OpTableKey)
@@ -0,0 +1,127 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// AddPrices imports fiat prices. Existing prices for the same currency and
+// timestamp are overwritten. Returns the number of inserted and updated rows.
+func (m *Indexer) AddPrices(ctx context.Context, prices []*model.Price) (int, int, error) {
+	table, err := m.Table(index.PriceTableKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	ctx = m.recordChanges(ctx)
+
+	// group by currency and find the time range per currency
+	type span struct {
+		from, to time.Time
+		byTime   map[int64]*model.Price
+	}
+	spans := make(map[string]*span)
+	for _, v := range prices {
+		s, ok := spans[v.Currency]
+		if !ok {
+			s = &span{from: v.Timestamp, to: v.Timestamp, byTime: make(map[int64]*model.Price)}
+			spans[v.Currency] = s
+		}
+		if v.Timestamp.Before(s.from) {
+			s.from = v.Timestamp
+		}
+		if v.Timestamp.After(s.to) {
+			s.to = v.Timestamp
+		}
+		s.byTime[v.Timestamp.Unix()] = v
+	}
+
+	// update existing entries in place
+	upd := make([]pack.Item, 0)
+	for currency, s := range spans {
+		existing, err := m.ListPrices(ctx, currency, s.from, s.to)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, v := range existing {
+			p, ok := s.byTime[v.Timestamp.Unix()]
+			if !ok {
+				continue
+			}
+			v.Price = p.Price
+			upd = append(upd, v)
+			delete(s.byTime, v.Timestamp.Unix())
+		}
+	}
+	if len(upd) > 0 {
+		if err := model.Update(ctx, table, upd); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	ins := make([]pack.Item, 0, len(prices)-len(upd))
+	for _, s := range spans {
+		for _, v := range s.byTime {
+			ins = append(ins, v)
+		}
+	}
+	if len(ins) > 0 {
+		if err := model.Insert(ctx, table, ins); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(ins), len(upd), table.Flush(ctx)
+}
+
+// ListPrices lists prices for a currency within a time range in time order.
+// Zero from or to times leave the range open.
+func (m *Indexer) ListPrices(ctx context.Context, currency string, from, to time.Time) ([]*model.Price, error) {
+	table, err := m.Table(index.PriceTableKey)
+	if err != nil {
+		return nil, err
+	}
+	q := pack.NewQuery("api.price.list").
+		WithTable(table).
+		WithoutCache().
+		AndEqual("currency", currency)
+	if !from.IsZero() {
+		q = q.AndGte("time", from)
+	}
+	if !to.IsZero() {
+		q = q.AndLte("time", to)
+	}
+	list := make([]*model.Price, 0)
+	if err := q.Execute(ctx, &list); err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Timestamp.Before(list[j].Timestamp) })
+	return list, nil
+}
+
+// StreamAccountFlows calls fn for all flows of an account until a point in
+// time in row id order, which is time order. Flows are reused between calls.
+func (m *Indexer) StreamAccountFlows(ctx context.Context, id model.AccountID, to time.Time, fn func(*model.Flow) error) error {
+    table, err := m.Table(index.FlowTableKey)
+    if err != nil {
+        return err
+    }
+    flow := &model.Flow{}
+    return pack.NewQuery("api.flow.statement").
+        WithTable(table).
+        WithoutCache().
+        AndEqual("account_id", id).
+        AndLte("time", to).
+        Stream(ctx, func(r pack.Row) error {
+            if err := r.Decode(flow); err != nil {
+                return err
+            }
+            return fn(flow)
+        })
+}
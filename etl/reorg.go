@@ -156,6 +156,7 @@ func (c *Crawler) reorganize(ctx context.Context, formerBest, newBest *model.Blo
 
 			// update chain tip
 			c.updateTip(newTip)
+			c.indexer.notifyListeners(block, c.builder, newTip, false)
 			tip = newTip
 
 			// cleanup, do not touch parent because we need it during next iteration
@@ -245,6 +246,7 @@ func (c *Crawler) reorganize(ctx context.Context, formerBest, newBest *model.Blo
 
 		// update chainstate with new version
 		c.updateTip(newTip)
+		c.indexer.notifyListeners(block, c.builder, newTip, true)
 		tip = newTip
 
 		// cleanup and prepare for next block (forward attach keeps parent relation in builder)
@@ -0,0 +1,254 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package etl
+
+import (
+	"context"
+	"fmt"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/store"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// ApplyChanges applies table writes a primary indexer recorded for a
+// connected or disconnected block and advances the chain tip to tip. Params
+// is set when the block activated a new protocol. Replicas call it instead of
+// crawling, tables of indexes that are not enabled are skipped.
+func (c *Crawler) ApplyChanges(ctx context.Context, tip *model.ChainTip, params *tezos.Params, changes []model.TableChange, connected bool) error {
+	m := c.indexer
+
+	// register new protocols before migration writes are applied
+	if params != nil {
+		if err := m.connectReplicaProtocol(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	// keep the disconnected block for listeners
+	var (
+		block *model.Block
+		err   error
+	)
+	if !connected {
+		block, err = m.BlockByHeight(ctx, tip.BestHeight+1)
+		if err != nil {
+			return fmt.Errorf("loading disconnected block %d: %w", tip.BestHeight+1, err)
+		}
+	}
+
+	builder, err := m.applyChanges(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	// update index tips
+	for _, v := range m.tips {
+		hash := tip.BestHash.Clone()
+		v.Hash = &hash
+		v.Height = tip.BestHeight
+	}
+
+	// update live caches, like on the primary caches are not rolled back on
+	// disconnect because cached data will be overwritten by roll-forward
+	if connected {
+		block, err = m.BlockByHeight(ctx, tip.BestHeight)
+		if err != nil {
+			return fmt.Errorf("loading connected block %d: %w", tip.BestHeight, err)
+		}
+		if err := m.updateBlocks(ctx, block); err != nil {
+			return err
+		}
+		if err := m.updateAddrs(ctx, builder.Accounts()); err != nil {
+			return err
+		}
+		if err := m.updateProposals(ctx, block); err != nil {
+			return err
+		}
+	}
+
+	// make writes durable, disconnects flush like a reorg on the primary
+	if connected {
+		err = m.FlushJournals(ctx)
+	} else {
+		err = m.Flush(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("flushing tables: %w", err)
+	}
+	if err := m.Finalize(ctx); err != nil {
+		log.Errorf("finalizing tables: %v", err)
+	}
+	err = c.db.Update(func(dbTx store.Tx) error {
+		return dbStoreChainTip(dbTx, tip)
+	})
+	if err != nil {
+		return fmt.Errorf("updating state database for block %d: %w", tip.BestHeight, err)
+	}
+	c.updateTip(tip)
+	c.chainId = tip.ChainId.Clone()
+	c.setState(STATE_SYNCHRONIZED, MONITOR_DISABLE)
+
+	if connected && !m.lightMode {
+		if err := m.updateRights(ctx, block.Height); err != nil {
+			log.Errorf("updating rights cache: %s", err)
+		}
+	}
+
+	m.notifyListeners(block, builder, tip, connected)
+	return nil
+}
+
+// connectReplicaProtocol registers params unless already known from an
+// earlier run.
+func (m *Indexer) connectReplicaProtocol(ctx context.Context, params *tezos.Params) error {
+	if _, err := m.reg.GetParams(params.Protocol); err == nil {
+		return nil
+	}
+	var prev *tezos.Params
+	if params.StartHeight > 0 {
+		prev = m.reg.GetParamsLatest()
+	}
+	return m.ConnectProtocol(ctx, params, prev)
+}
+
+// applyChanges writes changes to tables in order and collects written
+// accounts, bakers and contracts.
+func (m *Indexer) applyChanges(ctx context.Context, changes []model.TableChange) (*replicaBuilder, error) {
+	b := newReplicaBuilder(m)
+	for _, v := range changes {
+		table, ok := m.tables[v.Table]
+		if !ok {
+			// optional index not enabled
+			continue
+		}
+		if v.Kind == model.ChangeDelete {
+			if err := table.DeleteIds(ctx, v.Ids); err != nil {
+				return nil, fmt.Errorf("%s delete: %w", v.Table, err)
+			}
+			continue
+		}
+		alloc, ok := index.TableItemFunc(v.Table)
+		if !ok {
+			return nil, fmt.Errorf("unknown row type for table %s", v.Table)
+		}
+		items, err := v.Items(alloc)
+		if err != nil {
+			return nil, err
+		}
+		switch v.Kind {
+		case model.ChangeInsert:
+			err = table.Insert(ctx, items)
+		case model.ChangeUpdate:
+			err = table.Update(ctx, items)
+		default:
+			err = fmt.Errorf("invalid change kind %d", v.Kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", v.Table, v.Kind, err)
+		}
+		b.add(items, v.Kind == model.ChangeInsert)
+	}
+	return b, nil
+}
+
+// replicaBuilder exposes accounts, bakers and contracts written by a
+// replicated block to caches and block listeners.
+type replicaBuilder struct {
+	idx       *Indexer
+	accounts  map[model.AccountID]*model.Account
+	bakers    map[model.AccountID]*model.Baker
+	contracts map[model.AccountID]*model.Contract
+}
+
+var _ model.BlockBuilder = (*replicaBuilder)(nil)
+
+func newReplicaBuilder(idx *Indexer) *replicaBuilder {
+	return &replicaBuilder{
+		idx:       idx,
+		accounts:  make(map[model.AccountID]*model.Account),
+		bakers:    make(map[model.AccountID]*model.Baker),
+		contracts: make(map[model.AccountID]*model.Contract),
+	}
+}
+
+func (b *replicaBuilder) add(items []pack.Item, isNew bool) {
+	for _, v := range items {
+		switch x := v.(type) {
+		case *model.Account:
+			// keep the new flag when an account is updated after insert
+			prev, ok := b.accounts[x.RowId]
+			x.IsNew = isNew || (ok && prev.IsNew)
+			b.accounts[x.RowId] = x
+		case *model.Baker:
+			b.bakers[x.AccountId] = x
+		case *model.Contract:
+			b.contracts[x.AccountId] = x
+		}
+	}
+}
+
+func (b *replicaBuilder) AccountByAddress(addr tezos.Address) (*model.Account, bool) {
+	for _, v := range b.accounts {
+		if v.Address.Equal(addr) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (b *replicaBuilder) AccountById(id model.AccountID) (*model.Account, bool) {
+	acc, ok := b.accounts[id]
+	return acc, ok
+}
+
+func (b *replicaBuilder) BakerByAddress(addr tezos.Address) (*model.Baker, bool) {
+	for _, v := range b.bakers {
+		if v.Address.Equal(addr) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (b *replicaBuilder) BakerById(id model.AccountID) (*model.Baker, bool) {
+	bkr, ok := b.bakers[id]
+	return bkr, ok
+}
+
+func (b *replicaBuilder) ContractById(id model.AccountID) (*model.Contract, bool) {
+	con, ok := b.contracts[id]
+	return con, ok
+}
+
+func (b *replicaBuilder) Accounts() map[model.AccountID]*model.Account {
+	return b.accounts
+}
+
+func (b *replicaBuilder) Bakers() map[model.AccountID]*model.Baker {
+	return b.bakers
+}
+
+func (b *replicaBuilder) Contracts() map[model.AccountID]*model.Contract {
+	return b.contracts
+}
+
+func (b *replicaBuilder) Constants() micheline.ConstantDict {
+	return nil
+}
+
+func (b *replicaBuilder) Params(height int64) *tezos.Params {
+	return b.idx.ParamsByHeight(height)
+}
+
+func (b *replicaBuilder) Table(key string) (*pack.Table, error) {
+	return b.idx.Table(key)
+}
+
+func (b *replicaBuilder) IsLightMode() bool {
+	return b.idx.lightMode
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package replica
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// reconnectDelay is the wait time between connection attempts.
+const reconnectDelay = 5 * time.Second
+
+// errApply stops following after a batch could not be applied.
+var errApply = errors.New("replica: apply failed")
+
+// Follower runs on a replica. It subscribes to a primary with the replica's
+// chain tip and applies published batches to target in order.
+type Follower struct {
+	cfg    Config
+	target Target
+	quit   chan struct{}
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func NewFollower(cfg Config, target Target) *Follower {
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	return &Follower{
+		cfg:    cfg,
+		target: target,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start connects to the primary in the background and keeps reconnecting
+// until Stop is called.
+func (f *Follower) Start() {
+	f.wg.Add(1)
+	go f.run()
+}
+
+// Stop closes the connection to the primary and waits for a batch in
+// progress to finish.
+func (f *Follower) Stop() {
+	close(f.quit)
+	f.mu.Lock()
+	if f.conn != nil {
+		f.conn.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+func (f *Follower) run() {
+	defer f.wg.Done()
+	for {
+		err := f.follow()
+		if errors.Is(err, errApply) {
+			log.Errorf("%v. Stopping replication at height %d.", err, f.target.Tip().BestHeight)
+			return
+		}
+		if err != nil {
+			log.Warnf("replica: %v. Reconnecting in %s...", err, reconnectDelay)
+		}
+		select {
+		case <-f.quit:
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// follow applies batches from the primary until the connection breaks.
+func (f *Follower) follow() error {
+	conn, err := net.DialTimeout("tcp", f.cfg.Addr, f.cfg.DialTimeout)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	select {
+	case <-f.quit:
+		f.mu.Unlock()
+		conn.Close()
+		return nil
+	default:
+	}
+	f.conn = conn
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.conn.Close()
+		f.conn = nil
+		f.mu.Unlock()
+	}()
+
+	tip := f.target.Tip()
+	if err := gob.NewEncoder(conn).Encode(&message{Type: msgSubscribe, Hash: tipHash(tip)}); err != nil {
+		return err
+	}
+	log.Infof("Following primary %s from height %d.", f.cfg.Addr, tip.BestHeight)
+
+	dec := gob.NewDecoder(conn)
+	for {
+		var m message
+		if err := dec.Decode(&m); err != nil {
+			select {
+			case <-f.quit:
+				return nil
+			default:
+			}
+			return fmt.Errorf("reading from primary %s: %w", f.cfg.Addr, err)
+		}
+		switch m.Type {
+		case msgError:
+			return fmt.Errorf("primary %s: %s", f.cfg.Addr, m.Error)
+		case msgBatch:
+			if m.Batch == nil {
+				continue
+			}
+			if err := f.apply(m.Batch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// apply applies a batch that continues the replica's chain tip.
+func (f *Follower) apply(b *Batch) error {
+	if have := tipHash(f.target.Tip()); have != b.Prev {
+		return fmt.Errorf("batch for block %d continues tip %q, replica is at %q", b.Height, b.Prev, have)
+	}
+	tip := &model.ChainTip{}
+	if err := json.Unmarshal(b.Tip, tip); err != nil {
+		return fmt.Errorf("%w: decoding tip %d: %v", errApply, b.Height, err)
+	}
+	var params *tezos.Params
+	if len(b.Params) > 0 {
+		params = &tezos.Params{}
+		if err := json.Unmarshal(b.Params, params); err != nil {
+			return fmt.Errorf("%w: decoding params %d: %v", errApply, b.Height, err)
+		}
+	}
+	if err := f.target.ApplyChanges(context.Background(), tip, params, b.Changes, b.Connected); err != nil {
+		return fmt.Errorf("%w: block %d: %v", errApply, b.Height, err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package replica
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// Publisher runs on a primary indexer. It is registered as change listener
+// and sends the table changes of each block to connected replicas.
+type Publisher struct {
+	cfg Config
+	ln  net.Listener
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	head    string // chain tip hash after the last batch
+	batches []*Batch
+	subs    map[*subscriber]struct{}
+}
+
+func NewPublisher(cfg Config) *Publisher {
+	if cfg.Retain <= 0 {
+		cfg.Retain = DefaultRetain
+	}
+	return &Publisher{
+		cfg:  cfg,
+		subs: make(map[*subscriber]struct{}),
+	}
+}
+
+// Start listens for replicas. Tip is the primary's chain tip, it is ignored
+// when blocks were published before.
+func (p *Publisher) Start(tip *model.ChainTip) error {
+	ln, err := net.Listen("tcp", p.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("replica: %w", err)
+	}
+	p.mu.Lock()
+	if len(p.batches) == 0 {
+		p.head = tipHash(tip)
+	}
+	p.mu.Unlock()
+	p.ln = ln
+	p.wg.Add(1)
+	go p.accept()
+	log.Infof("Publishing table changes to replicas on %s.", ln.Addr())
+	return nil
+}
+
+// Stop closes the listener and all replica connections.
+func (p *Publisher) Stop() {
+	if p.ln == nil {
+		return
+	}
+	p.ln.Close()
+	p.mu.Lock()
+	for s := range p.subs {
+		s.close()
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// Addr returns the listen address.
+func (p *Publisher) Addr() net.Addr {
+	return p.ln.Addr()
+}
+
+// Publish sends the table changes of a connected or disconnected block to
+// all replicas. Its signature matches etl.ChangeListener.
+func (p *Publisher) Publish(block *model.Block, tip *model.ChainTip, changes []model.TableChange, connected bool) {
+	b := &Batch{
+		Hash:      tipHash(tip),
+		Height:    block.Height,
+		Connected: connected,
+		Changes:   changes,
+	}
+	var err error
+	if b.Tip, err = json.Marshal(tip); err != nil {
+		log.Errorf("replica: encoding tip %d: %v", tip.BestHeight, err)
+		return
+	}
+	if connected && block.Params != nil && (block.Height == 0 || block.IsProtocolUpgrade()) {
+		if b.Params, err = json.Marshal(block.Params); err != nil {
+			log.Errorf("replica: encoding params %d: %v", block.Height, err)
+			return
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b.Prev, p.head = p.head, b.Hash
+	p.batches = append(p.batches, b)
+	if n := len(p.batches) - p.cfg.Retain; n > 0 {
+		p.batches = append([]*Batch(nil), p.batches[n:]...)
+	}
+	for s := range p.subs {
+		if !s.push(&message{Type: msgBatch, Batch: b}) {
+			log.Warnf("replica: dropping slow replica %s", s.conn.RemoteAddr())
+			s.close()
+		}
+	}
+}
+
+func (p *Publisher) accept() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Errorf("replica: %v", err)
+			}
+			return
+		}
+		log.Infof("Replica %s connected.", conn.RemoteAddr())
+		s := &subscriber{
+			pub:  p,
+			conn: conn,
+			out:  make(chan *message, 2*p.cfg.Retain+64),
+			done: make(chan struct{}),
+		}
+		p.wg.Add(2)
+		go s.write()
+		go s.read()
+	}
+}
+
+// subscriber is a connected replica. Batches are queued without blocking
+// the crawler, replicas that fall behind by more than the queue size are
+// disconnected and resume from retained batches after reconnecting.
+type subscriber struct {
+	pub  *Publisher
+	conn net.Conn
+	out  chan *message
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *subscriber) push(m *message) bool {
+	select {
+	case s.out <- m:
+		return true
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.conn.Close()
+		delete(s.pub.subs, s)
+	})
+}
+
+func (s *subscriber) closeLocked() {
+	s.pub.mu.Lock()
+	s.close()
+	s.pub.mu.Unlock()
+}
+
+func (s *subscriber) write() {
+	defer s.pub.wg.Done()
+	enc := gob.NewEncoder(s.conn)
+	for {
+		select {
+		case <-s.done:
+			return
+		case m := <-s.out:
+			err := enc.Encode(m)
+			if err == nil && m.Type == msgError {
+				err = errors.New(m.Error)
+			}
+			if err != nil {
+				log.Debugf("replica: write to %s: %v", s.conn.RemoteAddr(), err)
+				s.closeLocked()
+				return
+			}
+		}
+	}
+}
+
+func (s *subscriber) read() {
+	defer s.pub.wg.Done()
+	defer s.closeLocked()
+	dec := gob.NewDecoder(s.conn)
+	for {
+		var m message
+		if err := dec.Decode(&m); err != nil {
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				log.Debugf("replica: read from %s: %v", s.conn.RemoteAddr(), err)
+			}
+			log.Infof("Replica %s disconnected.", s.conn.RemoteAddr())
+			return
+		}
+		if m.Type == msgSubscribe {
+			s.subscribe(m.Hash)
+		}
+	}
+}
+
+// subscribe queues retained batches after the replica's chain tip hash and
+// registers s for live batches. Replicas outside the retained window get an
+// error.
+func (s *subscriber) subscribe(hash string) {
+	p := s.pub
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := -1
+	if hash == p.head {
+		start = len(p.batches)
+	} else {
+		for i := len(p.batches) - 1; i >= 0; i-- {
+			if p.batches[i].Hash == hash {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 && len(p.batches) > 0 && p.batches[0].Prev == hash {
+			start = 0
+		}
+	}
+	if start < 0 {
+		msg := fmt.Sprintf("replica tip %q is not within the last %d published blocks", hash, len(p.batches))
+		log.Warnf("Replica %s: %s.", s.conn.RemoteAddr(), msg)
+		s.push(&message{Type: msgError, Error: msg})
+		return
+	}
+	for _, b := range p.batches[start:] {
+		s.push(&message{Type: msgBatch, Batch: b})
+	}
+	select {
+	case <-s.done:
+	default:
+		p.subs[s] = struct{}{}
+	}
+}
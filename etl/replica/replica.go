@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package replica streams table changes from a primary indexer to read-only
+// API replicas.
+//
+// The primary records every table write it makes while connecting or
+// disconnecting a block and publishes them over TCP in one batch per block
+// together with the resulting chain tip. Replicas do not crawl. They apply
+// batches to their own tables in order, advance index and chain tips and
+// update their caches. Reorgs arrive as batches of disconnected blocks
+// followed by the new branch.
+package replica
+
+import (
+	"context"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// DefaultRetain is the default number of blocks kept for reconnecting
+// replicas.
+const DefaultRetain = 128
+
+// Config configures both ends of a replication stream.
+type Config struct {
+	Addr        string        // primary: listen address, replica: primary address
+	Retain      int           // primary: number of blocks kept for replay
+	DialTimeout time.Duration // replica: primary connect timeout
+}
+
+// Batch holds the table changes of a connected or disconnected block. Prev
+// and Hash are the chain tip hashes before and after the batch, replicas use
+// them to resume and to detect gaps.
+type Batch struct {
+	Prev      string
+	Hash      string
+	Height    int64 // block height
+	Connected bool
+	Tip       []byte // JSON chain tip after the batch
+	Params    []byte // JSON protocol params when the block activated a protocol
+	Changes   []model.TableChange
+}
+
+// Target applies published batches on a replica. *etl.Crawler implements it.
+type Target interface {
+	Tip() *model.ChainTip
+	ApplyChanges(ctx context.Context, tip *model.ChainTip, params *tezos.Params, changes []model.TableChange, connected bool) error
+}
+
+const (
+	msgSubscribe = iota + 1 // replica: send batches after chain tip Hash
+	msgBatch                // primary: block batch
+	msgError                // primary: subscription failed
+)
+
+// message is the gob encoded wire format in both directions.
+type message struct {
+	Type  int
+	Hash  string
+	Error string
+	Batch *Batch
+}
+
+// tipHash returns the hash of tip or an empty string before genesis.
+func tipHash(tip *model.ChainTip) string {
+	if tip == nil || tip.BestHeight < 0 || !tip.BestHash.IsValid() {
+		return ""
+	}
+	return tip.BestHash.String()
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package replica
+
+import (
+	"context"
+	"encoding/gob"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+type applied struct {
+	Height    int64
+	Connected bool
+	Params    *tezos.Params
+	Changes   []model.TableChange
+}
+
+// testTarget records applied batches instead of writing tables.
+type testTarget struct {
+	mu      sync.Mutex
+	tip     *model.ChainTip
+	applied []applied
+}
+
+func (t *testTarget) Tip() *model.ChainTip {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tip
+}
+
+func (t *testTarget) ApplyChanges(_ context.Context, tip *model.ChainTip, params *tezos.Params, changes []model.TableChange, connected bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tip = tip
+	t.applied = append(t.applied, applied{tip.BestHeight, connected, params, changes})
+	return nil
+}
+
+func (t *testTarget) Applied() []applied {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]applied(nil), t.applied...)
+}
+
+func testTip(height int64, fork byte) *model.ChainTip {
+	buf := make([]byte, 32)
+	buf[0], buf[1] = byte(height), fork
+	return &model.ChainTip{
+		BestHeight: height,
+		BestHash:   tezos.NewBlockHash(buf),
+	}
+}
+
+func testChange(height int64) []model.TableChange {
+	return []model.TableChange{{
+		Table: "block",
+		Kind:  model.ChangeDelete,
+		Ids:   []uint64{uint64(height)},
+	}}
+}
+
+func waitApplied(t *testing.T, tg *testTarget, n int) []applied {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(tg.Applied()) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("replica applied %d batches, want %d", len(tg.Applied()), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return tg.Applied()
+}
+
+func checkApplied(t *testing.T, got []applied, heights []int64, connected []bool) {
+	t.Helper()
+	if len(got) != len(heights) {
+		t.Fatalf("applied %d batches, want %d", len(got), len(heights))
+	}
+	for i, v := range got {
+		if v.Height != heights[i] || v.Connected != connected[i] {
+			t.Errorf("batch %d: height=%d connected=%t, want height=%d connected=%t",
+				i, v.Height, v.Connected, heights[i], connected[i])
+		}
+		// disconnects roll back the block above the new tip
+		block := v.Height
+		if !v.Connected {
+			block++
+		}
+		if len(v.Changes) != 1 || v.Changes[0].Ids[0] != uint64(block) {
+			t.Errorf("batch %d: unexpected changes %v", i, v.Changes)
+		}
+	}
+}
+
+func TestReplicaReplayAndLive(t *testing.T) {
+	pub := NewPublisher(Config{Addr: "127.0.0.1:0", Retain: 4})
+	if err := pub.Start(&model.ChainTip{BestHeight: -1}); err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Stop()
+	cfg := Config{Addr: pub.Addr().String()}
+
+	// genesis carries protocol params
+	params := tezos.NewParams()
+	params.Protocol = tezos.ProtoGenesis
+	pub.Publish(&model.Block{Height: 0, Params: params}, testTip(0, 0), testChange(0), true)
+	for i := int64(1); i <= 3; i++ {
+		pub.Publish(&model.Block{Height: i}, testTip(i, 0), testChange(i), true)
+	}
+
+	// empty replica replays from genesis
+	empty := &testTarget{tip: &model.ChainTip{BestHeight: -1}}
+	f1 := NewFollower(cfg, empty)
+	f1.Start()
+	defer f1.Stop()
+	got := waitApplied(t, empty, 4)
+	checkApplied(t, got, []int64{0, 1, 2, 3}, []bool{true, true, true, true})
+	if got[0].Params == nil || !got[0].Params.Protocol.Equal(tezos.ProtoGenesis) {
+		t.Errorf("genesis params not replicated: %v", got[0].Params)
+	}
+	if got[1].Params != nil {
+		t.Errorf("unexpected params at height 1")
+	}
+
+	// replica seeded at height 1 resumes after its tip
+	seeded := &testTarget{tip: testTip(1, 0)}
+	f2 := NewFollower(cfg, seeded)
+	f2.Start()
+	defer f2.Stop()
+	checkApplied(t, waitApplied(t, seeded, 2), []int64{2, 3}, []bool{true, true})
+
+	// reorg: disconnect 3 and connect a new 3
+	pub.Publish(&model.Block{Height: 3}, testTip(2, 0), testChange(3), false)
+	pub.Publish(&model.Block{Height: 3}, testTip(3, 1), testChange(3), true)
+	checkApplied(t, waitApplied(t, seeded, 4), []int64{2, 3, 2, 3}, []bool{true, true, false, true})
+	if h := seeded.Tip().BestHash; !h.Equal(testTip(3, 1).BestHash) {
+		t.Errorf("replica tip %s after reorg, want %s", h, testTip(3, 1).BestHash)
+	}
+	waitApplied(t, empty, 6)
+}
+
+func TestReplicaUnknownTip(t *testing.T) {
+	pub := NewPublisher(Config{Addr: "127.0.0.1:0", Retain: 2})
+	if err := pub.Start(testTip(10, 0)); err != nil {
+		t.Fatal(err)
+	}
+	defer pub.Stop()
+	for i := int64(11); i <= 13; i++ {
+		pub.Publish(&model.Block{Height: i}, testTip(i, 0), testChange(i), true)
+	}
+
+	// batches after height 10 fell out of the retained window, 12 is on a fork
+	for _, tip := range []*model.ChainTip{testTip(10, 0), testTip(12, 1)} {
+		conn, err := net.Dial("tcp", pub.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gob.NewEncoder(conn).Encode(&message{Type: msgSubscribe, Hash: tipHash(tip)}); err != nil {
+			t.Fatal(err)
+		}
+		var m message
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := gob.NewDecoder(conn).Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+		if m.Type != msgError || m.Error == "" {
+			t.Errorf("tip %d: got message type %d, want error", tip.BestHeight, m.Type)
+		}
+	}
+}
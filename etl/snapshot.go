@@ -161,3 +161,11 @@ func (c *Crawler) snapshot_locked(ctx context.Context) error {
 	log.Infof("Successfully finished database snapshots in %s.", time.Since(start))
 	return nil
 }
+
+// SetSnapshotConfig replaces the snapshot schedule. A nil config disables
+// snapshots.
+func (c *Crawler) SetSnapshotConfig(cfg *SnapshotConfig) {
+	c.Lock()
+	defer c.Unlock()
+	c.snap = cfg
+}
@@ -0,0 +1,374 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/cache"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// per-entry memory overhead used for size accounting
+const cacheEntryOverhead = 256
+
+// ResponseCache is an in-memory LRU cache for GET responses of the explorer
+// and table APIs bounded by the total size of cached responses. Entries are
+// tagged with the account addresses they depend on and invalidated when a
+// block touches one of these accounts. Entries without a tag depend on
+// chain state in general and are dropped on every new block.
+type ResponseCache struct {
+	mu    sync.Mutex
+	max   int64
+	size  int64
+	lru   *list.List
+	items map[string]*list.Element
+	tags  map[string]map[string]struct{}
+	cycle int64
+	gen   uint64
+	stats cache.Stats
+}
+
+type cacheEntry struct {
+	key     string
+	tag     string
+	gen     uint64
+	status  int
+	header  http.Header
+	trailer http.Header
+	body    []byte
+	etag    string
+	expires time.Time
+	size    int64
+}
+
+func NewResponseCache(maxBytes int64) *ResponseCache {
+	return &ResponseCache{
+		max:   maxBytes,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+		tags:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *ResponseCache) Get(key string, now time.Time) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		atomic.AddInt64(&c.stats.Misses, 1)
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.expires.IsZero() && !now.Before(e.expires) {
+		c.remove(el)
+		atomic.AddInt64(&c.stats.Misses, 1)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	atomic.AddInt64(&c.stats.Hits, 1)
+	return e, true
+}
+
+func (c *ResponseCache) Add(e *cacheEntry) {
+	e.size = int64(len(e.key)+len(e.body)) + cacheEntryOverhead
+	if e.size > c.max/8 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// skip responses computed before the last invalidation
+	if e.gen != c.gen {
+		return
+	}
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+		atomic.AddInt64(&c.stats.Updates, 1)
+	} else {
+		atomic.AddInt64(&c.stats.Inserts, 1)
+	}
+	c.items[e.key] = c.lru.PushFront(e)
+	keys, ok := c.tags[e.tag]
+	if !ok {
+		keys = make(map[string]struct{})
+		c.tags[e.tag] = keys
+	}
+	keys[e.key] = struct{}{}
+	c.size += e.size
+	for c.size > c.max {
+		c.remove(c.lru.Back())
+		atomic.AddInt64(&c.stats.Evictions, 1)
+	}
+}
+
+// Generation returns a counter that changes on every invalidation.
+func (c *ResponseCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *ResponseCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.items, e.key)
+	if keys, ok := c.tags[e.tag]; ok {
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(c.tags, e.tag)
+		}
+	}
+	c.size -= e.size
+}
+
+// Invalidate drops all untagged entries and entries tagged with one of the
+// given addresses.
+func (c *ResponseCache) Invalidate(addrs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, tag := range append(addrs, "") {
+		for key := range c.tags[tag] {
+			c.remove(c.items[key])
+		}
+	}
+}
+
+func (c *ResponseCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.lru.Init()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+	c.size = 0
+}
+
+func (c *ResponseCache) Stats() cache.Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats.Get()
+	s.Size = c.lru.Len()
+	s.Bytes = c.size
+	return s
+}
+
+// OnBlock is an indexer block listener. It drops the entire cache on reorgs
+// and at cycle start, and otherwise only entries affected by accounts the
+// block has touched.
+func (c *ResponseCache) OnBlock(block *model.Block, builder model.BlockBuilder, connected bool) {
+	c.mu.Lock()
+	cycle := c.cycle
+	c.cycle = block.Cycle
+	c.mu.Unlock()
+	if !connected || cycle != block.Cycle {
+		c.Purge()
+		return
+	}
+	addrs := make([]string, 0, len(builder.Accounts()))
+	for _, acc := range builder.Accounts() {
+		addrs = append(addrs, acc.Address.String())
+	}
+	for _, cc := range builder.Contracts() {
+		addrs = append(addrs, cc.Address.String())
+	}
+	c.Invalidate(addrs)
+}
+
+// isCacheableRequest returns true for GET requests to the explorer and
+// table APIs.
+func isCacheableRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0] {
+	case "explorer", "metadata", "tables":
+		return r.URL.Path != "/explorer/status"
+	default:
+		return false
+	}
+}
+
+// cacheKey normalizes the request URL by sorting query arguments.
+func cacheKey(r *http.Request) string {
+	q := r.URL.Query().Encode()
+	if q == "" {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + q
+}
+
+// cacheTag returns the account address a request depends on or an empty
+// string when the request depends on general chain state. Only base object
+// routes are tagged. Sub-resources like rewards, forecasts or counterparties
+// depend on other accounts and the chain tip, so they stay untagged and are
+// dropped on every new block.
+func cacheTag(path string) string {
+	fields := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	var ident string
+	switch {
+	case len(fields) == 3 && fields[0] == "explorer":
+		switch fields[1] {
+		case "account", "contract", "bakers":
+			ident = fields[2]
+		}
+	case len(fields) == 2 && fields[0] == "metadata":
+		ident = fields[1]
+	}
+	if a, err := tezos.ParseAddress(ident); err == nil {
+		return a.String()
+	}
+	return ""
+}
+
+func etagOf(body []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(body)
+	return `"` + strconv.FormatUint(h.Sum64(), 16) + `"`
+}
+
+// matchETag checks an If-None-Match request header against etag.
+func matchETag(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheRecorder captures the body of streamed responses up to a size limit
+// while passing all writes through to the client.
+type cacheRecorder struct {
+	http.ResponseWriter
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (w *cacheRecorder) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(b) > w.max {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sendCacheableResponse writes a regular response with ETag header, answers
+// matching conditional requests with 304 Not Modified and stores successful
+// responses in the response cache.
+func (api *Context) sendCacheableResponse() {
+	api.recorder.overflow = true
+	body, err := api.marshalResponse()
+	if err != nil || api.status < 200 || api.status > 299 {
+		api.writeResponseHeaders("", "")
+		api.writeResponseBody()
+		return
+	}
+	status := api.status
+	etag := etagOf(body)
+	api.ResponseWriter.Header().Set("ETag", etag)
+	if matchETag(api.Request.Header.Get("If-None-Match"), etag) {
+		api.status = http.StatusNotModified
+		api.writeResponseHeaders("", "")
+	} else {
+		api.writeResponseHeaders("", "")
+		_, _ = api.ResponseWriter.Write(body)
+	}
+
+	var expires time.Time
+	if res, ok := api.result.(Resource); ok {
+		expires = res.Expires()
+	}
+	api.storeResponse(status, body, etag, expires)
+}
+
+// cacheStream stores a completed streaming response unless it was too large.
+func (api *Context) cacheStream() {
+	if api.recorder.overflow || api.status < 200 || api.status > 299 {
+		return
+	}
+	body := api.recorder.buf.Bytes()
+	api.storeResponse(api.status, body, etagOf(body), time.Time{})
+}
+
+func (api *Context) storeResponse(status int, body []byte, etag string, expires time.Time) {
+	e := &cacheEntry{
+		key:     api.cacheKey,
+		tag:     cacheTag(api.Request.URL.Path),
+		gen:     api.cacheGen,
+		status:  status,
+		header:  make(http.Header),
+		trailer: make(http.Header),
+		body:    body,
+		etag:    etag,
+		expires: expires,
+	}
+	for k, v := range api.ResponseWriter.Header() {
+		switch k {
+		case "X-Request-Id", "Date", "Access-Control-Allow-Origin", headerRuntime, trailerRuntime:
+			// set per request
+		case trailerCursor, trailerCount, trailerError:
+			e.trailer[k] = append([]string(nil), v...)
+		default:
+			e.header[k] = append([]string(nil), v...)
+		}
+	}
+	srv.cache.Add(e)
+}
+
+// sendCachedResponse replays a cached response with updated per-request
+// headers.
+func (api *Context) sendCachedResponse(e *cacheEntry) {
+	w := api.ResponseWriter
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("X-Request-Id", api.RequestID)
+	h.Set("X-Network-Id", api.Tip.ChainId.String())
+	h.Set("X-Chain-Height", strconv.FormatInt(api.Tip.BestHeight, 10))
+	h.Set("Date", api.Now.Format(http.TimeFormat))
+	h.Set("ETag", e.etag)
+	if hc := api.Cfg.Http; hc.CorsEnable {
+		if hc.CorsOrigin == "*" {
+			h.Set("Access-Control-Allow-Origin", api.Request.Header.Get("Origin"))
+		} else {
+			h.Set("Access-Control-Allow-Origin", hc.CorsOrigin)
+		}
+	}
+	api.Performance.WriteResponseHeader(w)
+	if matchETag(api.Request.Header.Get("If-None-Match"), e.etag) {
+		h.Del("Trailer")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+	if len(e.trailer) > 0 {
+		for k, v := range e.trailer {
+			h[k] = v
+		}
+		api.Performance.WriteResponseTrailer(w)
+	}
+}
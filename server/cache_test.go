@@ -0,0 +1,194 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testCacheAddr = "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"
+
+func testCacheEntry(c *ResponseCache, key, tag string, size int) *cacheEntry {
+	return &cacheEntry{
+		key:  key,
+		tag:  tag,
+		gen:  c.Generation(),
+		body: make([]byte, size),
+	}
+}
+
+func TestResponseCacheLRU(t *testing.T) {
+	// three small entries fit, the fourth evicts the least recently used
+	c := NewResponseCache(8 * (cacheEntryOverhead + 100))
+	max := int(c.max/8) - cacheEntryOverhead - 1
+	for _, k := range []string{"a", "b", "c"} {
+		c.Add(testCacheEntry(c, k, "", max))
+	}
+	now := time.Now()
+	if _, ok := c.Get("a", now); !ok {
+		t.Fatalf("missing entry a")
+	}
+	for _, k := range []string{"d", "e", "f", "g", "h", "i"} {
+		c.Add(testCacheEntry(c, k, "", max))
+	}
+	if _, ok := c.Get("b", now); ok {
+		t.Errorf("entry b not evicted")
+	}
+	if _, ok := c.Get("a", now); !ok {
+		t.Errorf("recently used entry a evicted")
+	}
+	if c.size > c.max {
+		t.Errorf("cache size %d exceeds max %d", c.size, c.max)
+	}
+
+	// entries larger than 1/8 of the cache are not stored
+	c.Add(testCacheEntry(c, "big", "", int(c.max)))
+	if _, ok := c.Get("big", now); ok {
+		t.Errorf("oversized entry cached")
+	}
+}
+
+func TestResponseCacheExpiry(t *testing.T) {
+	c := NewResponseCache(1 << 20)
+	now := time.Now()
+	e := testCacheEntry(c, "a", "", 10)
+	e.expires = now.Add(time.Second)
+	c.Add(e)
+	if _, ok := c.Get("a", now); !ok {
+		t.Errorf("entry expired early")
+	}
+	if _, ok := c.Get("a", now.Add(time.Second)); ok {
+		t.Errorf("expired entry returned")
+	}
+	if c.lru.Len() != 0 || c.size != 0 {
+		t.Errorf("expired entry not removed: len=%d size=%d", c.lru.Len(), c.size)
+	}
+}
+
+func TestResponseCacheInvalidate(t *testing.T) {
+	c := NewResponseCache(1 << 20)
+	c.Add(testCacheEntry(c, "general", "", 10))
+	c.Add(testCacheEntry(c, "acc1", "tz1a", 10))
+	c.Add(testCacheEntry(c, "acc1/ops", "tz1a", 10))
+	c.Add(testCacheEntry(c, "acc2", "tz1b", 10))
+
+	// responses computed before an invalidation are not stored
+	stale := testCacheEntry(c, "stale", "", 10)
+	c.Invalidate([]string{"tz1a"})
+	c.Add(stale)
+
+	now := time.Now()
+	for key, want := range map[string]bool{
+		"general":  false,
+		"acc1":     false,
+		"acc1/ops": false,
+		"acc2":     true,
+		"stale":    false,
+	} {
+		if _, ok := c.Get(key, now); ok != want {
+			t.Errorf("%s: cached = %t, want %t", key, ok, want)
+		}
+	}
+	if len(c.tags) != 1 {
+		t.Errorf("got %d tags, want 1", len(c.tags))
+	}
+
+	c.Purge()
+	if _, ok := c.Get("acc2", now); ok || c.size != 0 {
+		t.Errorf("purge left entries")
+	}
+}
+
+func TestCacheTag(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/explorer/account/" + testCacheAddr, testCacheAddr},
+		{"/explorer/account/" + testCacheAddr + "/operations", ""},
+		{"/explorer/account/" + testCacheAddr + "/rewards", ""},
+		{"/explorer/bakers/" + testCacheAddr, testCacheAddr},
+		{"/explorer/bakers/" + testCacheAddr + "/forecast", ""},
+		{"/explorer/contract/" + testCacheAddr + "/similar", ""},
+		{"/metadata/" + testCacheAddr, testCacheAddr},
+		{"/explorer/account/nope", ""},
+		{"/explorer/block/head", ""},
+		{"/tables/op", ""},
+	}
+	for _, test := range tests {
+		if got := cacheTag(test.path); got != test.want {
+			t.Errorf("%s: got tag %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestCacheableRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		url    string
+		want   bool
+	}{
+		{http.MethodGet, "/explorer/block/head", true},
+		{http.MethodGet, "/tables/op?limit=1", true},
+		{http.MethodGet, "/metadata/" + testCacheAddr, true},
+		{http.MethodGet, "/explorer/status", false},
+		{http.MethodGet, "/series/op", false},
+		{http.MethodPost, "/tables/sql", false},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		if got := isCacheableRequest(r); got != test.want {
+			t.Errorf("%s %s: got %t, want %t", test.method, test.url, got, test.want)
+		}
+	}
+
+	// query arguments are normalized
+	a := httptest.NewRequest(http.MethodGet, "/tables/op?b=2&a=1", nil)
+	b := httptest.NewRequest(http.MethodGet, "/tables/op?a=1&b=2", nil)
+	if cacheKey(a) != cacheKey(b) {
+		t.Errorf("cache keys differ: %s %s", cacheKey(a), cacheKey(b))
+	}
+}
+
+func TestETag(t *testing.T) {
+	etag := etagOf([]byte("body"))
+	if etag != etagOf([]byte("body")) || etag == etagOf([]byte("other")) {
+		t.Errorf("etag is not a content hash")
+	}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{etag, true},
+		{"W/" + etag, true},
+		{`"x", ` + etag, true},
+		{"*", true},
+		{`"x"`, false},
+	}
+	for _, test := range tests {
+		if got := matchETag(test.header, etag); got != test.want {
+			t.Errorf("%q: got %t, want %t", test.header, got, test.want)
+		}
+	}
+}
+
+func TestCacheRecorder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &cacheRecorder{ResponseWriter: rec, max: 4}
+	_, _ = w.Write([]byte("abc"))
+	if w.overflow || w.buf.String() != "abc" {
+		t.Errorf("recorded %q overflow=%t", w.buf.String(), w.overflow)
+	}
+	_, _ = w.Write([]byte("de"))
+	if !w.overflow || w.buf.Len() != 0 {
+		t.Errorf("recorder did not overflow")
+	}
+	if rec.Body.String() != "abcde" {
+		t.Errorf("client got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl/index"
+)
+
+// CohortPrefix marks filter values that refer to a named address list.
+const CohortPrefix = "@"
+
+// ExpandCohorts replaces `@name` references in URL query filter values with
+// the member addresses of the named cohort, so that table and series filters
+// like `sender.in=@cex_wallets` work without knowing about cohorts. Equality
+// filters are turned into list filters, i.e. `sender=@name` becomes
+// `sender.in=addr1,addr2,..`. Exclusion filters on empty cohorts are dropped.
+// Panics on unknown cohorts and on equality filters with empty cohorts.
+// Values are left unchanged when the cohort index is disabled.
+func (c *Context) ExpandCohorts() {
+	if _, err := c.Indexer.Index(index.CohortIndexKey); err != nil {
+		return
+	}
+	query := c.Request.URL.Query()
+	if expandCohorts(query, c.cohortMembers) {
+		c.Request.URL.RawQuery = query.Encode()
+	}
+}
+
+// cohortMembers returns the member addresses of a named cohort.
+func (c *Context) cohortMembers(name string) []string {
+	members, err := c.Indexer.ListCohortMembers(c, name)
+	if err != nil {
+		switch err {
+		case index.ErrNoCohortEntry:
+			panic(ENotFound(EC_RESOURCE_NOTFOUND, fmt.Sprintf("no such cohort '%s'", name), err))
+		default:
+			panic(EInternal(EC_DATABASE, err.Error(), nil))
+		}
+	}
+	addrs := make([]string, len(members))
+	for i, m := range members {
+		addrs[i] = m.Address.String()
+	}
+	return addrs
+}
+
+// expandCohorts rewrites cohort references in query filter values using
+// members to resolve cohort names and reports whether query has changed.
+func expandCohorts(query url.Values, members func(name string) []string) bool {
+	var changed bool
+	for key, vals := range query {
+		if len(vals) == 0 || !strings.Contains(vals[0], CohortPrefix) {
+			continue
+		}
+		tokens := strings.Split(vals[0], ",")
+		addrs := make([]string, 0, len(tokens))
+		var hasCohort bool
+		for _, t := range tokens {
+			if !strings.HasPrefix(t, CohortPrefix) {
+				addrs = append(addrs, t)
+				continue
+			}
+			hasCohort = true
+			addrs = append(addrs, members(strings.TrimPrefix(t, CohortPrefix))...)
+		}
+		if !hasCohort {
+			continue
+		}
+
+		// rewrite filter mode to a list mode, field names may contain dots
+		// (e.g. `value.owner`), so only a known mode suffix is split off
+		field, mode := key, pack.FilterModeEqual
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			if m := pack.ParseFilterMode(key[i+1:]); i+1 < len(key) && m.IsValid() {
+				field, mode = key[:i], m
+			}
+		}
+		switch mode {
+		case pack.FilterModeEqual, pack.FilterModeIn:
+			mode = pack.FilterModeIn
+		case pack.FilterModeNotEqual, pack.FilterModeNotIn:
+			mode = pack.FilterModeNotIn
+		default:
+			panic(EBadRequest(EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for cohort", mode), nil))
+		}
+		query.Del(key)
+		changed = true
+
+		// empty lists cannot be expressed as filter, excluding nobody
+		// is a no-op, matching nobody is rejected
+		if len(addrs) == 0 {
+			if mode == pack.FilterModeNotIn {
+				continue
+			}
+			panic(EBadRequest(EC_PARAM_INVALID, fmt.Sprintf("empty cohort in filter '%s'", key), nil))
+		}
+		query.Set(field+"."+mode.String(), strings.Join(addrs, ","))
+	}
+	return changed
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"net/url"
+	"testing"
+)
+
+func testCohortMembers(name string) []string {
+	switch name {
+	case "cex":
+		return []string{"tz1a", "tz1b"}
+	case "empty":
+		return nil
+	default:
+		panic(ENotFound(EC_RESOURCE_NOTFOUND, "no such cohort '"+name+"'", nil))
+	}
+}
+
+func TestExpandCohorts(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    string
+		changed bool
+	}{
+		{"sender=@cex", "sender.in=tz1a%2Ctz1b", true},
+		{"sender.eq=@cex", "sender.in=tz1a%2Ctz1b", true},
+		{"sender.in=tz1x,@cex", "sender.in=tz1x%2Ctz1a%2Ctz1b", true},
+		{"receiver.ne=@cex", "receiver.nin=tz1a%2Ctz1b", true},
+		{"receiver.nin=@cex,@empty", "receiver.nin=tz1a%2Ctz1b", true},
+		{"sender=tz1x&limit=10", "limit=10&sender=tz1x", false},
+		{"sender=@cex&limit=10", "limit=10&sender.in=tz1a%2Ctz1b", true},
+		{"value.owner=@cex", "value.owner.in=tz1a%2Ctz1b", true},
+		{"value.owner.ne=@cex", "value.owner.nin=tz1a%2Ctz1b", true},
+		{"sender.nin=@empty", "", true},
+		{"sender.in=tz1x,@empty", "sender.in=tz1x", true},
+	}
+	for _, test := range tests {
+		q, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := expandCohorts(q, testCohortMembers)
+		if changed != test.changed {
+			t.Errorf("%s: changed = %t, want %t", test.query, changed, test.changed)
+		}
+		if got := q.Encode(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestExpandCohortsErrors(t *testing.T) {
+	for _, query := range []string{
+		"sender.gt=@cex",
+		"sender.rg=@cex",
+		"sender=@nope",
+		"value.owner.gt=@cex",
+		"sender=@empty",
+		"sender.in=@empty",
+	} {
+		func() {
+			defer func() {
+				if e := recover(); e == nil {
+					t.Errorf("%s: expected panic", query)
+				} else if _, ok := e.(*Error); !ok {
+					t.Errorf("%s: unexpected panic %v", query, e)
+				}
+			}()
+			q, _ := url.ParseQuery(query)
+			expandCohorts(q, testCohortMembers)
+		}()
+	}
+}
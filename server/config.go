@@ -18,6 +18,21 @@ type Config struct {
 	Indexer *etl.Indexer
 	Client  *rpc.Client
 	Http    HttpConfig
+	Runtime RuntimeConfig
+}
+
+// RuntimeConfig is a snapshot of settings outside the HTTP section used by
+// API handlers. Handlers must use this snapshot instead of the global config
+// store because the latter is replaced without synchronization on reload.
+type RuntimeConfig struct {
+	DatabasePath     string
+	DatabaseGCRatio  float64
+	SnapshotPath     string
+	MetadataValidate bool
+	MetaTitleSuffix  string
+	MetaImageUrl     string
+	MetaLogo         string
+	Values           map[string]interface{} // copy of the full config tree
 }
 
 func (c Config) ClampList(count uint) uint {
@@ -84,6 +99,18 @@ type HttpConfig struct {
 	DefaultExploreCount uint          `json:"default_explore_count"`
 	MaxExploreCount     uint          `json:"max_explore_count"`
 	MaxSeriesDuration   time.Duration `json:"max_series_duration"`
+	MaxAggregateGroups  int           `json:"max_aggregate_groups"`
+	MaxAggregateRows    int           `json:"max_aggregate_rows"`
+	MaxSqlRows          int           `json:"max_sql_rows"`
+	MaxSqlDuration      time.Duration `json:"max_sql_duration"`
+	MaxGraphQLCost      int           `json:"max_graphql_cost"`
+	MaxGraphQLDepth     int           `json:"max_graphql_depth"`
+	MaxBatchSize        int           `json:"max_batch_size"`
+	ResponseCacheSize   int           `json:"response_cache_size"`
+	HealthMaxLagBlocks  int           `json:"health_max_lag_blocks"`
+	HealthMaxLagTime    time.Duration `json:"health_max_lag_time"`
+	HealthMaxIdle       time.Duration `json:"health_max_idle"`
+	HealthMaxQueue      int           `json:"health_max_queue"`
 	CorsEnable          bool          `json:"cors_enable"`
 	CorsOrigin          string        `json:"cors_origin"`
 	CorsAllowHeaders    string        `json:"cors_allow_headers"`
@@ -117,6 +144,17 @@ func NewHttpConfig() HttpConfig {
 		DefaultExploreCount: 20,
 		MaxExploreCount:     100,
 		MaxSeriesDuration:   90 * 24 * time.Hour,
+		MaxAggregateGroups:  100000,
+		MaxAggregateRows:    10000000,
+		MaxSqlRows:          1000000,
+		MaxSqlDuration:      30 * time.Second,
+		MaxGraphQLCost:      1000,
+		MaxGraphQLDepth:     10,
+		MaxBatchSize:        1000,
+		HealthMaxLagBlocks:  10,
+		HealthMaxLagTime:    5 * time.Minute,
+		HealthMaxIdle:       10 * time.Minute,
+		HealthMaxQueue:      90,
 		CacheExpires:        30 * time.Second,
 		CacheMaxExpires:     24 * time.Hour,
 	}
@@ -179,3 +217,19 @@ func (cfg *HttpConfig) Check() error {
 
 	return nil
 }
+
+// Update copies all settings from n that can change while the server is
+// running. Listener, worker pool and timeout settings as well as the
+// response cache size require a restart and are kept.
+func (cfg *HttpConfig) Update(n HttpConfig) {
+	n.Addr = cfg.Addr
+	n.Port = cfg.Port
+	n.MaxWorkers = cfg.MaxWorkers
+	n.MaxQueue = cfg.MaxQueue
+	n.ReadTimeout = cfg.ReadTimeout
+	n.HeaderTimeout = cfg.HeaderTimeout
+	n.WriteTimeout = cfg.WriteTimeout
+	n.KeepAlive = cfg.KeepAlive
+	n.ResponseCacheSize = cfg.ResponseCacheSize
+	*cfg = n
+}
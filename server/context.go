@@ -14,6 +14,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"reflect"
 	"runtime"
 	"runtime/debug"
@@ -76,6 +77,9 @@ type Context struct {
 	result     interface{}
 	err        *Error
 	done       chan *Error
+	cacheKey   string
+	cacheGen   uint64
+	recorder   *cacheRecorder
 }
 
 func NewContext(ctx context.Context, r *http.Request, w http.ResponseWriter, f ApiCall, srv *RestServer) *Context {
@@ -103,7 +107,7 @@ func NewContext(ctx context.Context, r *http.Request, w http.ResponseWriter, f A
 		Context:        ctx,
 		Now:            now,
 		RequestID:      requestId,
-		Cfg:            srv.cfg,
+		Cfg:            srv.Config(),
 		Server:         srv,
 		Crawler:        srv.cfg.Crawler,
 		Indexer:        srv.cfg.Indexer,
@@ -149,6 +153,23 @@ func (api *Context) ParseRequestArgs(args interface{}) {
 	if req, ok := args.(ParsableRequest); ok {
 		req.Parse(api)
 	}
+	if req, ok := args.(QueryParsableRequest); ok {
+		req.ParseQuery(api, r.URL.Query())
+	}
+}
+
+// ParseQueryArgs decodes args from query values instead of the request URL,
+// e.g. for nested lookups of a single request. Panics like ParseRequestArgs.
+func (api *Context) ParseQueryArgs(args interface{}, query url.Values) {
+	if err := schemaDecoder.Decode(args, query); err != nil {
+		panic(EBadRequest(EC_BAD_URL_QUERY, err.Error(), nil))
+	}
+	if req, ok := args.(ParsableRequest); ok {
+		req.Parse(api)
+	}
+	if req, ok := args.(QueryParsableRequest); ok {
+		req.ParseQuery(api, query)
+	}
 }
 
 // this is executed in a goroutine per call, panics on error
@@ -262,11 +283,15 @@ func (api *Context) sendResponse() {
 		if api.err != nil && (api.err.Cause == nil || api.err.Cause != context.Canceled) {
 			api.ResponseWriter.Header().Set(trailerError, api.err.String())
 			api.Performance.WriteResponseTrailer(api.ResponseWriter)
+		} else if api.err == nil && api.cacheKey != "" {
+			api.cacheStream()
 		}
 		return
 	}
 
-	if api.err == nil {
+	if api.err == nil && api.cacheKey != "" {
+		api.sendCacheableResponse()
+	} else if api.err == nil {
 		// make sure to set response headers before writing body
 		api.writeResponseHeaders("", "")
 
@@ -322,6 +347,26 @@ func (api *Context) StreamResponseHeaders(status int, contentType string) {
 	}
 }
 
+// CaptureStream runs fn with the response writer replaced by w, so that a
+// streaming handler can be post-processed. Stream state is reset afterwards
+// and any error raised by a streaming trailer is returned.
+func (api *Context) CaptureStream(w http.ResponseWriter, fn func()) error {
+	orig := api.ResponseWriter
+	api.ResponseWriter = w
+	defer func() {
+		api.ResponseWriter = orig
+		api.isStreamed = false
+		api.status = 0
+	}()
+	fn()
+	if api.err != nil {
+		err := api.err
+		api.err = nil
+		return err
+	}
+	return nil
+}
+
 func (api *Context) StreamTrailer(cursor string, count int, err error) {
 	h := api.ResponseWriter.Header()
 	h.Set(trailerCursor, cursor)
@@ -402,9 +447,9 @@ func (api *Context) writeResponseHeaders(contentType, trailers string) {
 	// Set cache headers ONLY if ALL of the following applies
 	// - caching is enabled in config
 	// - request method is GET, HEAD or OPTIONS
-	// - return status is 2xx
+	// - return status is 2xx or 304
 	//
-	cacheStatus := api.status >= 200 && api.status <= 299
+	cacheStatus := api.status >= 200 && api.status <= 299 || api.status == http.StatusNotModified
 	cacheMethod := false
 	switch api.Request.Method {
 	case http.MethodGet, http.MethodHead, http.MethodOptions:
@@ -447,34 +492,50 @@ func (api *Context) writeResponseHeaders(contentType, trailers string) {
 }
 
 func (api *Context) writeResponseBody() {
-	if api.result != nil {
-		switch t := api.result.(type) {
-		case string:
-			_, _ = api.ResponseWriter.Write([]byte(t))
-		case *string:
-			_, _ = api.ResponseWriter.Write([]byte(*t))
-		case []byte:
-			_, _ = api.ResponseWriter.Write(t)
-		default:
-			// marshal and write the result to the HTTP body
-			if b, err := json.Marshal(api.result); err != nil {
-				path := strings.Join([]string{
-					api.Request.Method,
-					api.Request.RequestURI,
-					api.Request.Proto,
-				}, " ")
-				api.Log.Errorf("Response Error %s: %v in struct %T", path, err, api.result)
-				e := EInternal(EC_MARSHAL_FAILED, "cannot marshal response", err).(*Error)
-				e.SetScope(api.name)
-				if api.isStreamed {
-					api.ResponseWriter.Header().Set(trailerError, e.String())
-				} else {
-					_, _ = api.ResponseWriter.Write(e.Marshal())
-				}
-			} else {
-				_, _ = api.ResponseWriter.Write(append(b, '\n'))
-			}
+	b, err := api.marshalResponse()
+	if err != nil {
+		api.writeMarshalError(err)
+		return
+	}
+	if b != nil {
+		_, _ = api.ResponseWriter.Write(b)
+	}
+}
+
+// marshalResponse returns the HTTP body for the handler result.
+func (api *Context) marshalResponse() ([]byte, error) {
+	switch t := api.result.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(t), nil
+	case *string:
+		return []byte(*t), nil
+	case []byte:
+		return t, nil
+	default:
+		// marshal the result to JSON
+		b, err := json.Marshal(api.result)
+		if err != nil {
+			return nil, err
 		}
+		return append(b, '\n'), nil
+	}
+}
+
+func (api *Context) writeMarshalError(err error) {
+	path := strings.Join([]string{
+		api.Request.Method,
+		api.Request.RequestURI,
+		api.Request.Proto,
+	}, " ")
+	api.Log.Errorf("Response Error %s: %v in struct %T", path, err, api.result)
+	e := EInternal(EC_MARSHAL_FAILED, "cannot marshal response", err).(*Error)
+	e.SetScope(api.name)
+	if api.isStreamed {
+		api.ResponseWriter.Header().Set(trailerError, e.String())
+	} else {
+		_, _ = api.ResponseWriter.Write(e.Marshal())
 	}
 }
 
@@ -72,6 +72,12 @@ func NewDispatcher(maxWorkers int, maxQueue int) *Dispatcher {
 	return &Dispatcher{pool: pool, maxWorkers: maxWorkers, maxQueue: maxQueue}
 }
 
+// Queue returns the number of requests waiting for a worker and the queue
+// capacity.
+func (d *Dispatcher) Queue() (int, int) {
+	return len(jobQueue), cap(jobQueue)
+}
+
 func (d *Dispatcher) Run() {
 	// starting n number of workers
 	for i := 0; i < d.maxWorkers; i++ {
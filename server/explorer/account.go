@@ -190,9 +190,17 @@ func (b Account) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{ident}/contracts", server.C(ReadDeployedContracts)).Methods("GET")
 	r.HandleFunc("/{ident}/operations", server.C(ListAccountOperations)).Methods("GET")
 	r.HandleFunc("/{ident}/metadata", server.C(ReadMetadata)).Methods("GET")
+	r.HandleFunc("/{ident}/rewards", server.C(ListAccountRewards)).Methods("GET")
 
 	// LEGACY: keep here for dapp and wallet compatibility
 	r.HandleFunc("/{ident}/op", server.C(ReadAccountOps)).Methods("GET")
+
+	if server.HasIndex(index.TransferEdgeIndexKey) {
+		r.HandleFunc("/{ident}/counterparties", server.C(ListAccountCounterparties)).Methods("GET")
+	}
+	if server.HasIndex(index.PriceIndexKey) {
+		r.HandleFunc("/{ident}/statement", server.C(ReadAccountStatement)).Methods("GET")
+	}
 	return nil
 }
 
@@ -210,7 +218,12 @@ func (r *AccountRequest) WithMerge() bool   { return false }
 func (r *AccountRequest) WithStorage() bool { return false }
 
 func loadAccount(ctx *server.Context) *model.Account {
-	if accIdent, ok := mux.Vars(ctx.Request)["ident"]; !ok || accIdent == "" {
+	return lookupAccount(ctx, mux.Vars(ctx.Request)["ident"])
+}
+
+// lookupAccount loads an account by address, panics on error.
+func lookupAccount(ctx *server.Context, accIdent string) *model.Account {
+	if accIdent == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing account address", nil))
 	} else {
 		addr, err := tezos.ParseAddress(accIdent)
@@ -251,8 +264,10 @@ func ReadAccount(ctx *server.Context) (interface{}, int) {
 func ReadDeployedContracts(ctx *server.Context) (interface{}, int) {
 	args := &AccountRequest{}
 	ctx.ParseRequestArgs(args)
-	acc := loadAccount(ctx)
+	return listDeployedContracts(ctx, loadAccount(ctx), args), http.StatusOK
+}
 
+func listDeployedContracts(ctx *server.Context, acc *model.Account, args *AccountRequest) []*Contract {
 	ccs, err := ctx.Indexer.ListContracts(ctx, etl.ListRequest{
 		Account: acc,
 		Offset:  args.Offset,
@@ -282,7 +297,7 @@ func ReadDeployedContracts(ctx *server.Context) (interface{}, int) {
 	for _, v := range ccs {
 		resp = append(resp, NewContract(ctx, v, accMap[v.AccountId], args))
 	}
-	return resp, http.StatusOK
+	return resp
 }
 
 // LEGACY
@@ -342,8 +357,10 @@ func ListAccountOperations(ctx *server.Context) (interface{}, int) {
 		},
 	}
 	ctx.ParseRequestArgs(args)
-	acc := loadAccount(ctx)
+	return listAccountOperations(ctx, loadAccount(ctx), args), http.StatusOK
+}
 
+func listAccountOperations(ctx *server.Context, acc *model.Account, args *OpsRequest) OpList {
 	r := etl.ListRequest{
 		Account:     acc,
 		Mode:        args.TypeMode,
@@ -381,5 +398,5 @@ func ListAccountOperations(ctx *server.Context) (interface{}, int) {
 	for _, v := range ops {
 		resp.Append(NewOp(ctx, v, nil, nil, args, cache), args.WithMerge())
 	}
-	return resp, http.StatusOK
+	return resp
 }
@@ -238,6 +238,8 @@ func (b BakerList) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{ident}/income/{cycle}", server.C(GetBakerIncome)).Methods("GET")
 	r.HandleFunc("/{ident}/rights/{cycle}", server.C(GetBakerRights)).Methods("GET")
 	r.HandleFunc("/{ident}/snapshot/{cycle}", server.C(GetBakerSnapshot)).Methods("GET")
+	r.HandleFunc("/{ident}/keys", server.C(ListBakerKeys)).Methods("GET")
+	r.HandleFunc("/{ident}/forecast", server.C(GetBakerForecast)).Methods("GET")
 	r.HandleFunc("/{ident}/metadata", server.C(ReadMetadata)).Methods("GET")
 	return nil
 }
@@ -264,6 +266,10 @@ func (l BakerList) Expires() time.Time           { return l.expires }
 func ListBakers(ctx *server.Context) (interface{}, int) {
 	args := &BakerListRequest{}
 	ctx.ParseRequestArgs(args)
+	return listBakers(ctx, args), http.StatusOK
+}
+
+func listBakers(ctx *server.Context, args *BakerListRequest) *BakerList {
 
 	// load suggest account
 	var suggest *model.Account
@@ -504,7 +510,7 @@ func ListBakers(ctx *server.Context) (interface{}, int) {
 			resp.list[i].Stats.AvgContribution64 = &p[2]
 		}
 	}
-	return resp, http.StatusOK
+	return resp
 }
 
 func loadBaker(ctx *server.Context) *model.Baker {
@@ -636,8 +642,10 @@ func ListBakerDelegations(ctx *server.Context) (interface{}, int) {
 		},
 	}
 	ctx.ParseRequestArgs(args)
-	acc := loadAccount(ctx)
+	return listBakerDelegations(ctx, loadAccount(ctx), args), http.StatusOK
+}
 
+func listBakerDelegations(ctx *server.Context, acc *model.Account, args *OpsRequest) OpList {
 	r := etl.ListRequest{
 		Account: acc,
 		// ReceiverId: acc.RowId,
@@ -669,7 +677,7 @@ func ListBakerDelegations(ctx *server.Context) (interface{}, int) {
 	for _, v := range ops {
 		resp.Append(NewOp(ctx, v, nil, nil, args, cache), args.WithMerge())
 	}
-	return resp, http.StatusOK
+	return resp
 }
 
 type ExplorerRights struct {
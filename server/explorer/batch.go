@@ -0,0 +1,238 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockwatch.cc/packdb/vec"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+func init() {
+	server.Register(Batch{})
+}
+
+var _ server.RESTful = (*Batch)(nil)
+
+// Batch serves bulk lookups of accounts, contracts and operations. Results
+// are returned in input order with an error for each identifier that could
+// not be resolved.
+type Batch struct{}
+
+func (b Batch) LastModified() time.Time {
+	return time.Time{}
+}
+
+func (b Batch) Expires() time.Time {
+	return time.Time{}
+}
+
+func (b Batch) RESTPrefix() string {
+	return "/explorer/batch"
+}
+
+func (b Batch) RESTPath(r *mux.Router) string {
+	return b.RESTPrefix()
+}
+
+func (b Batch) RegisterDirectRoutes(r *mux.Router) error {
+	r.HandleFunc("/explorer/accounts", server.C(ReadAccountBatch)).Methods("POST")
+	r.HandleFunc("/explorer/contracts", server.C(ReadContractBatch)).Methods("POST")
+	r.HandleFunc("/explorer/ops", server.C(ReadOpBatch)).Methods("POST")
+	return nil
+}
+
+func (b Batch) RegisterRoutes(r *mux.Router) error {
+	return nil
+}
+
+// Batch requests take options from the URL query and identifiers from
+// the JSON body.
+type AccountBatchRequest struct {
+	AccountRequest `json:"-"`
+	Addresses      []string `schema:"-" json:"addresses"`
+}
+
+type OpBatchRequest struct {
+	OpsRequest `json:"-"`
+	Hashes     []string `schema:"-" json:"hashes"`
+}
+
+// BatchItem is the result for a single identifier of a batch lookup.
+type BatchItem struct {
+	Ident  string        `json:"ident"`
+	Result interface{}   `json:"result,omitempty"`
+	Error  *server.Error `json:"error,omitempty"`
+}
+
+func batchError(fn server.ErrorWrapper, code int, detail string) *server.Error {
+	return fn(code, detail, nil).(*server.Error)
+}
+
+func checkBatchSize(ctx *server.Context, n int) {
+	if n == 0 {
+		panic(server.EBadRequest(server.EC_PARAM_REQUIRED, "empty identifier list", nil))
+	}
+	if max := ctx.Cfg.Http.MaxBatchSize; max > 0 && n > max {
+		panic(server.ERequestTooLarge(server.EC_PARAM_INVALID, "too many identifiers", nil))
+	}
+}
+
+// parseBatchAddresses decodes addresses and returns the list of valid and
+// unique addresses. Malformed addresses are marked as failed in items.
+func parseBatchAddresses(idents []string, items []BatchItem) []tezos.Address {
+	addrs := make([]tezos.Address, 0, len(idents))
+	seen := make(map[string]bool)
+	for i, v := range idents {
+		items[i].Ident = v
+		a, err := tezos.ParseAddress(v)
+		if err != nil {
+			items[i].Error = batchError(server.EBadRequest, server.EC_RESOURCE_ID_MALFORMED, "invalid address")
+			continue
+		}
+		if !seen[v] {
+			seen[v] = true
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+func ReadAccountBatch(ctx *server.Context) (interface{}, int) {
+	args := &AccountBatchRequest{}
+	ctx.ParseRequestArgs(args)
+	checkBatchSize(ctx, len(args.Addresses))
+
+	items := make([]BatchItem, len(args.Addresses))
+	addrs := parseBatchAddresses(args.Addresses, items)
+	accs, err := ctx.Indexer.LookupAccounts(ctx, addrs)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+	}
+	byAddr := make(map[string]*model.Account, len(accs))
+	for _, v := range accs {
+		byAddr[v.Address.String()] = v
+	}
+	for i := range items {
+		if items[i].Error != nil {
+			continue
+		}
+		if acc, ok := byAddr[items[i].Ident]; ok {
+			items[i].Result = NewAccount(ctx, acc, args)
+		} else {
+			items[i].Error = batchError(server.ENotFound, server.EC_RESOURCE_NOTFOUND, "no such account")
+		}
+	}
+	return items, http.StatusOK
+}
+
+func ReadContractBatch(ctx *server.Context) (interface{}, int) {
+	args := &AccountBatchRequest{}
+	ctx.ParseRequestArgs(args)
+	checkBatchSize(ctx, len(args.Addresses))
+
+	items := make([]BatchItem, len(args.Addresses))
+	addrs := parseBatchAddresses(args.Addresses, items)
+	ccs, err := ctx.Indexer.LookupContracts(ctx, addrs)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+	}
+
+	// load related accounts
+	ids := make([]uint64, 0, len(ccs))
+	for _, v := range ccs {
+		ids = append(ids, v.AccountId.Value())
+	}
+	ids = vec.UniqueUint64Slice(ids)
+	accMap := make(map[model.AccountID]*model.Account, len(ids))
+	if len(ids) > 0 {
+		accs, err := ctx.Indexer.LookupAccountIds(ctx, ids)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read contract accounts", err))
+		}
+		for _, v := range accs {
+			accMap[v.RowId] = v
+		}
+	}
+
+	byAddr := make(map[string]*model.Contract, len(ccs))
+	for _, v := range ccs {
+		byAddr[v.Address.String()] = v
+	}
+	for i := range items {
+		if items[i].Error != nil {
+			continue
+		}
+		cc, ok := byAddr[items[i].Ident]
+		if !ok || accMap[cc.AccountId] == nil {
+			items[i].Error = batchError(server.ENotFound, server.EC_RESOURCE_NOTFOUND, "no such contract")
+			continue
+		}
+		items[i].Result = NewContract(ctx, cc, accMap[cc.AccountId], args)
+	}
+	return items, http.StatusOK
+}
+
+func ReadOpBatch(ctx *server.Context) (interface{}, int) {
+	args := &OpBatchRequest{
+		OpsRequest: OpsRequest{
+			Storage: true,
+		},
+	}
+	ctx.ParseRequestArgs(args)
+	checkBatchSize(ctx, len(args.Hashes))
+
+	items := make([]BatchItem, len(args.Hashes))
+	hashes := make([]tezos.OpHash, 0, len(args.Hashes))
+	seen := make(map[string]bool)
+	for i, v := range args.Hashes {
+		items[i].Ident = v
+		h, err := tezos.ParseOpHash(v)
+		if err != nil {
+			items[i].Error = batchError(server.EBadRequest, server.EC_RESOURCE_ID_MALFORMED, "invalid operation hash")
+			continue
+		}
+		if !seen[v] {
+			seen[v] = true
+			hashes = append(hashes, h)
+		}
+	}
+
+	ops, err := ctx.Indexer.LookupOpHashes(ctx, hashes, etl.ListRequest{
+		WithStorage: args.WithStorage(),
+	})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+	}
+	byHash := make(map[string][]*model.Op, len(hashes))
+	for _, v := range ops {
+		key := v.Hash.String()
+		byHash[key] = append(byHash[key], v)
+	}
+
+	cache := make(map[int64]interface{})
+	for i := range items {
+		if items[i].Error != nil {
+			continue
+		}
+		list, ok := byHash[items[i].Ident]
+		if !ok {
+			items[i].Error = batchError(server.ENotFound, server.EC_RESOURCE_NOTFOUND, "no such operation")
+			continue
+		}
+		resp := make(OpList, 0, len(list))
+		for _, v := range list {
+			resp.Append(NewOp(ctx, v, nil, nil, args, cache), args.WithMerge())
+		}
+		items[i].Result = resp
+	}
+	return items, http.StatusOK
+}
@@ -5,8 +5,10 @@ package explorer
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -96,6 +98,7 @@ func (b Bigmap) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{id}/keys", server.C(ListBigmapKeys)).Methods("GET")
 	r.HandleFunc("/{id}/values", server.C(ListBigmapValues)).Methods("GET")
 	r.HandleFunc("/{id}/updates", server.C(ListBigmapUpdates)).Methods("GET")
+	r.HandleFunc("/{id}/diff", server.C(DiffBigmap)).Methods("GET")
 	r.HandleFunc("/{id}/{key}/updates", server.C(ListBigmapKeyUpdates)).Methods("GET")
 	r.HandleFunc("/{id}/{key}", server.C(ReadBigmapValue)).Methods("GET")
 	return nil
@@ -189,7 +192,12 @@ func (l BigmapUpdateList) Expires() time.Time           { return l.expires }
 var _ server.Resource = (*BigmapUpdateList)(nil)
 
 func loadBigmap(ctx *server.Context) *model.BigmapAlloc {
-	if id, ok := mux.Vars(ctx.Request)["id"]; !ok || id == "" {
+	return lookupBigmap(ctx, mux.Vars(ctx.Request)["id"])
+}
+
+// lookupBigmap loads a bigmap allocation by id, panics on error.
+func lookupBigmap(ctx *server.Context, id string) *model.BigmapAlloc {
+	if id == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing bigmap id", nil))
 	} else {
 		i, err := strconv.ParseInt(id, 10, 64)
@@ -225,6 +233,25 @@ func parseBigmapKey(ctx *server.Context, typ micheline.OpCode) tezos.ExprHash {
 	}
 }
 
+// parseBigmapFilters collects decoded key/value path filters like
+// `value.balance.gt=1000` or `key.owner=tz1...` from query arguments.
+func parseBigmapFilters(query url.Values) model.BigmapPathFilterList {
+	list := make(model.BigmapPathFilterList, 0)
+	for key, val := range query {
+		if !model.IsBigmapPath(key) {
+			continue
+		}
+		for _, v := range val {
+			f, err := model.ParseBigmapPathFilter(key, v)
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+			}
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
 func ReadBigmap(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
@@ -235,15 +262,19 @@ func ReadBigmap(ctx *server.Context) (interface{}, int) {
 func ListBigmapKeys(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
-	alloc := loadBigmap(ctx)
+	filters := parseBigmapFilters(ctx.Request.URL.Query())
+	return listBigmapKeys(ctx, loadBigmap(ctx), filters, args), http.StatusOK
+}
 
+func listBigmapKeys(ctx *server.Context, alloc *model.BigmapAlloc, filters model.BigmapPathFilterList, args *ContractRequest) *BigmapKeyList {
 	r := etl.ListRequest{
-		BigmapId: alloc.BigmapId,
-		Since:    args.BlockHeight,
-		Cursor:   args.Cursor,
-		Offset:   args.Offset,
-		Limit:    ctx.Cfg.ClampExplore(args.Limit),
-		Order:    args.Order,
+		BigmapId:     alloc.BigmapId,
+		BigmapFilter: filters,
+		Since:        args.BlockHeight,
+		Cursor:       args.Cursor,
+		Offset:       args.Offset,
+		Limit:        ctx.Cfg.ClampExplore(args.Limit),
+		Order:        args.Order,
 	}
 
 	var (
@@ -297,21 +328,25 @@ func ListBigmapKeys(ctx *server.Context) (interface{}, int) {
 		resp.list = append(resp.list, key)
 	}
 
-	return resp, http.StatusOK
+	return resp
 }
 
 func ListBigmapValues(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
-	alloc := loadBigmap(ctx)
+	filters := parseBigmapFilters(ctx.Request.URL.Query())
+	return listBigmapValues(ctx, loadBigmap(ctx), filters, args), http.StatusOK
+}
 
+func listBigmapValues(ctx *server.Context, alloc *model.BigmapAlloc, filters model.BigmapPathFilterList, args *ContractRequest) *BigmapValueList {
 	r := etl.ListRequest{
-		BigmapId: alloc.BigmapId,
-		Since:    args.BlockHeight,
-		Cursor:   args.Cursor,
-		Offset:   args.Offset,
-		Limit:    ctx.Cfg.ClampExplore(args.Limit),
-		Order:    args.Order,
+		BigmapId:     alloc.BigmapId,
+		BigmapFilter: filters,
+		Since:        args.BlockHeight,
+		Cursor:       args.Cursor,
+		Offset:       args.Offset,
+		Limit:        ctx.Cfg.ClampExplore(args.Limit),
+		Order:        args.Order,
 	}
 
 	var (
@@ -375,7 +410,7 @@ func ListBigmapValues(ctx *server.Context) (interface{}, int) {
 		resp.list = append(resp.list, val)
 	}
 
-	return resp, http.StatusOK
+	return resp
 }
 
 func ReadBigmapValue(ctx *server.Context) (interface{}, int) {
@@ -455,7 +490,10 @@ func ReadBigmapValue(ctx *server.Context) (interface{}, int) {
 func ListBigmapUpdates(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
-	alloc := loadBigmap(ctx)
+	return listBigmapUpdates(ctx, loadBigmap(ctx), args), http.StatusOK
+}
+
+func listBigmapUpdates(ctx *server.Context, alloc *model.BigmapAlloc, args *ContractRequest) *BigmapUpdateList {
 	r := etl.ListRequest{
 		BigmapId: alloc.BigmapId,
 		Since:    args.SinceHeight + 1,
@@ -587,7 +625,7 @@ func ListBigmapUpdates(ctx *server.Context) (interface{}, int) {
 		resp.modified = v.Timestamp
 	}
 
-	return resp, http.StatusOK
+	return resp
 }
 
 func ListBigmapKeyUpdates(ctx *server.Context) (interface{}, int) {
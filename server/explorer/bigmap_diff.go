@@ -0,0 +1,219 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"net/http"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/server"
+)
+
+type BigmapDiffRequest struct {
+	ListRequest // offset, limit, order
+
+	From   string `schema:"from"`   // block hash or height (exclusive)
+	To     string `schema:"to"`     // block hash or height (inclusive), default head
+	Unpack bool   `schema:"unpack"` // unpack packed key/values
+	Prim   bool   `schema:"prim"`   // for prim/value rendering
+
+	// decoded values
+	FromHeight int64 `schema:"-"`
+	ToHeight   int64 `schema:"-"`
+}
+
+func (r *BigmapDiffRequest) Parse(ctx *server.Context) {
+	if len(r.From) == 0 {
+		panic(server.EBadRequest(server.EC_PARAM_REQUIRED, "missing from block", nil))
+	}
+	r.FromHeight = parseBlockIdent(ctx, r.From)
+	r.ToHeight = ctx.Tip.BestHeight
+	if len(r.To) > 0 {
+		r.ToHeight = parseBlockIdent(ctx, r.To)
+	}
+	if r.FromHeight >= r.ToHeight {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "from block must be lower than to block", nil))
+	}
+}
+
+func parseBlockIdent(ctx *server.Context, ident string) int64 {
+	_, height, err := ctx.Indexer.LookupBlockId(ctx.Context, ident)
+	if err != nil {
+		switch err {
+		case index.ErrNoBlockEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such block", err))
+		case index.ErrInvalidBlockHeight:
+			panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, "invalid block height", err))
+		case index.ErrInvalidBlockHash:
+			panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, "invalid block hash", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+	return height
+}
+
+type BigmapKeyChange struct {
+	Action       string           `json:"action"` // added, removed, changed
+	Key          micheline.Key    `json:"key"`
+	KeyHash      tezos.ExprHash   `json:"hash"`
+	OldValue     *micheline.Value `json:"old_value,omitempty"`
+	NewValue     *micheline.Value `json:"new_value,omitempty"`
+	Height       int64            `json:"height"`
+	NUpdates     int              `json:"n_updates"`
+	KeyPrim      *micheline.Prim  `json:"key_prim,omitempty"`
+	OldValuePrim *micheline.Prim  `json:"old_value_prim,omitempty"`
+	NewValuePrim *micheline.Prim  `json:"new_value_prim,omitempty"`
+}
+
+type BigmapDiff struct {
+	Contract      tezos.Address     `json:"contract"`
+	BigmapId      int64             `json:"bigmap_id"`
+	FromHeight    int64             `json:"from_height"`
+	ToHeight      int64             `json:"to_height"`
+	AllocHeight   int64             `json:"alloc_height,omitempty"`   // allocated in range
+	CopiedFrom    *int64            `json:"copied_from,omitempty"`    // copied in range
+	DeletedHeight int64             `json:"deleted_height,omitempty"` // deleted in range
+	NAdded        int               `json:"n_added"`
+	NRemoved      int               `json:"n_removed"`
+	NChanged      int               `json:"n_changed"`
+	Changes       []BigmapKeyChange `json:"changes"`
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (d BigmapDiff) LastModified() time.Time { return d.modified }
+func (d BigmapDiff) Expires() time.Time      { return d.expires }
+
+var _ server.Resource = (*BigmapDiff)(nil)
+
+func DiffBigmap(ctx *server.Context) (interface{}, int) {
+	args := &BigmapDiffRequest{}
+	ctx.ParseRequestArgs(args)
+	alloc := loadBigmap(ctx)
+
+	diffs, events, err := ctx.Indexer.ListBigmapDiff(ctx.Context, alloc.BigmapId, args.FromHeight, args.ToHeight)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read bigmap", err))
+	}
+
+	resp := &BigmapDiff{
+		Contract:   ctx.Indexer.LookupAddress(ctx, alloc.AccountId),
+		BigmapId:   alloc.BigmapId,
+		FromHeight: args.FromHeight,
+		ToHeight:   args.ToHeight,
+		Changes:    make([]BigmapKeyChange, 0),
+		modified:   ctx.Indexer.LookupBlockTime(ctx, args.ToHeight),
+		expires:    ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	for _, v := range events {
+		switch v.Action {
+		case micheline.DiffActionAlloc:
+			resp.AllocHeight = v.Height
+		case micheline.DiffActionCopy:
+			resp.AllocHeight = v.Height
+			src := int64(v.KeyId)
+			resp.CopiedFrom = &src
+		case micheline.DiffActionRemove:
+			resp.DeletedHeight = v.Height
+		}
+	}
+
+	for _, v := range diffs {
+		switch {
+		case v.IsAdded():
+			resp.NAdded++
+		case v.IsRemoved():
+			resp.NRemoved++
+		case v.IsChanged():
+			resp.NChanged++
+		}
+	}
+
+	if args.Order == pack.OrderDesc {
+		for i, j := 0, len(diffs)-1; i < j; i, j = i+1, j-1 {
+			diffs[i], diffs[j] = diffs[j], diffs[i]
+		}
+	}
+	if int(args.Offset) >= len(diffs) {
+		diffs = diffs[:0]
+	} else {
+		diffs = diffs[args.Offset:]
+	}
+	if limit := int(ctx.Cfg.ClampExplore(args.Limit)); len(diffs) > limit {
+		diffs = diffs[:limit]
+	}
+
+	keyType, valType := alloc.GetKeyType(), alloc.GetValueType()
+	for _, v := range diffs {
+		change, err := newBigmapKeyChange(v, keyType, valType, args)
+		if err != nil {
+			log.Errorf("explorer: decode bigmap key: %v", err)
+			continue
+		}
+		resp.Changes = append(resp.Changes, change)
+	}
+
+	return resp, http.StatusOK
+}
+
+func newBigmapKeyChange(d *etl.BigmapKeyDiff, keyType, valType micheline.Type, args *BigmapDiffRequest) (BigmapKeyChange, error) {
+	key, err := micheline.DecodeKey(keyType, d.Key)
+	if err != nil {
+		return BigmapKeyChange{}, err
+	}
+	change := BigmapKeyChange{
+		Key:      key,
+		KeyHash:  key.Hash(),
+		Height:   d.Height,
+		NUpdates: d.NUpdates,
+	}
+	switch {
+	case d.IsAdded():
+		change.Action = "added"
+	case d.IsRemoved():
+		change.Action = "removed"
+	default:
+		change.Action = "changed"
+	}
+	decode := func(buf []byte) *micheline.Value {
+		if buf == nil {
+			return nil
+		}
+		prim := micheline.Prim{}
+		if err := prim.UnmarshalBinary(buf); err != nil {
+			return nil
+		}
+		val := micheline.NewValue(valType, prim)
+		if args.Unpack && val.IsPackedAny() {
+			if up, err := val.UnpackAll(); err == nil {
+				val = up
+			}
+		}
+		return &val
+	}
+	change.OldValue = decode(d.Old)
+	change.NewValue = decode(d.New)
+	if args.Unpack && change.Key.IsPacked() {
+		if up, err := change.Key.Unpack(); err == nil {
+			change.Key = up
+		}
+	}
+	if args.Prim {
+		change.KeyPrim = key.PrimPtr()
+		if change.OldValue != nil {
+			change.OldValuePrim = &change.OldValue.Value
+		}
+		if change.NewValue != nil {
+			change.NewValuePrim = &change.NewValue.Value
+		}
+	}
+	return change, nil
+}
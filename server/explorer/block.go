@@ -272,7 +272,12 @@ func (b Block) RegisterRoutes(r *mux.Router) error {
 }
 
 func loadBlock(ctx *server.Context) *model.Block {
-	if blockIdent, ok := mux.Vars(ctx.Request)["ident"]; !ok || blockIdent == "" {
+	return lookupBlock(ctx, mux.Vars(ctx.Request)["ident"])
+}
+
+// lookupBlock loads a block by hash or height, panics on error.
+func lookupBlock(ctx *server.Context, blockIdent string) *model.Block {
+	if blockIdent == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing block identifier", nil))
 	} else {
 		block, err := ctx.Indexer.LookupBlock(ctx, blockIdent)
@@ -316,19 +321,21 @@ func ReadBlock(ctx *server.Context) (interface{}, int) {
 func ReadBlockOps(ctx *server.Context) (interface{}, int) {
 	args := &BlockRequest{}
 	ctx.ParseRequestArgs(args)
-	b := NewBlock(ctx, loadBlock(ctx), args)
-	b.Ops = listBlockOps(ctx)
+	block := loadBlock(ctx)
+	b := NewBlock(ctx, block, args)
+	opArgs := &OpsRequest{}
+	ctx.ParseRequestArgs(opArgs)
+	b.Ops = listBlockOps(ctx, block, opArgs)
 	return b, http.StatusOK
 }
 
 func ListBlockOps(ctx *server.Context) (interface{}, int) {
-	return listBlockOps(ctx), http.StatusOK
-}
-
-func listBlockOps(ctx *server.Context) OpList {
 	args := &OpsRequest{}
 	ctx.ParseRequestArgs(args)
-	block := loadBlock(ctx)
+	return listBlockOps(ctx, loadBlock(ctx), args), http.StatusOK
+}
+
+func listBlockOps(ctx *server.Context, block *model.Block, args *OpsRequest) OpList {
 
 	// don't use offset/limit because we mix in endorsements
 	r := etl.ListRequest{
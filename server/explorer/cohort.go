@@ -0,0 +1,410 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+const maxCohortFlowDays = 366
+
+func init() {
+	server.Register(Cohort{})
+}
+
+var (
+	_ server.RESTful  = (*Cohort)(nil)
+	_ server.Resource = (*Cohort)(nil)
+)
+
+type CohortMember struct {
+	Address   tezos.Address `json:"address"`
+	Label     string        `json:"label,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Cohort struct {
+	Name     string         `json:"name"`
+	NMembers int            `json:"n_members"`
+	Members  []CohortMember `json:"members,omitempty"`
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (c Cohort) LastModified() time.Time { return c.modified }
+func (c Cohort) Expires() time.Time      { return c.expires }
+
+func (c Cohort) RESTPrefix() string {
+	return "/explorer/cohort"
+}
+
+func (c Cohort) RESTPath(r *mux.Router) string {
+	path, _ := r.Get("cohort").URLPath("name", c.Name)
+	return path.String()
+}
+
+func (c Cohort) RegisterDirectRoutes(r *mux.Router) error {
+	if !server.HasIndex(index.CohortIndexKey) {
+		return nil
+	}
+	r.HandleFunc(c.RESTPrefix(), server.C(ListCohorts)).Methods("GET")
+	r.HandleFunc(c.RESTPrefix(), server.C(CreateCohort)).Methods("POST")
+	return nil
+}
+
+func (c Cohort) RegisterRoutes(r *mux.Router) error {
+	if !server.HasIndex(index.CohortIndexKey) {
+		return nil
+	}
+	r.HandleFunc("/{name}", server.C(ReadCohort)).Methods("GET").Name("cohort")
+	r.HandleFunc("/{name}", server.C(UpdateCohort)).Methods("PUT")
+	r.HandleFunc("/{name}", server.C(RemoveCohort)).Methods("DELETE")
+	r.HandleFunc("/{name}/balance", server.C(ReadCohortBalance)).Methods("GET")
+	r.HandleFunc("/{name}/flows", server.C(ReadCohortFlows)).Methods("GET")
+	return nil
+}
+
+type CohortList struct {
+	list     []*Cohort
+	modified time.Time
+	expires  time.Time
+}
+
+func (l CohortList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l CohortList) LastModified() time.Time      { return l.modified }
+func (l CohortList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*CohortList)(nil)
+
+func getCohortName(ctx *server.Context) string {
+	name, ok := mux.Vars(ctx.Request)["name"]
+	if !ok || name == "" {
+		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing cohort name", nil))
+	}
+	name = strings.TrimPrefix(name, server.CohortPrefix)
+	if !model.IsValidCohortName(name) {
+		panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, "invalid cohort name", nil))
+	}
+	return name
+}
+
+func loadCohortMembers(ctx *server.Context, name string) []*model.CohortMember {
+	members, err := ctx.Indexer.ListCohortMembers(ctx, name)
+	if err != nil {
+		switch err {
+		case index.ErrNoCohortEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such cohort", err))
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access cohort table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+	return members
+}
+
+func newCohort(ctx *server.Context, name string, members []*model.CohortMember) *Cohort {
+	c := &Cohort{
+		Name:     name,
+		NMembers: len(members),
+		Members:  make([]CohortMember, len(members)),
+		expires:  ctx.Now.Add(ctx.Cfg.Http.CacheMaxExpires),
+	}
+	for i, v := range members {
+		c.Members[i] = CohortMember{
+			Address:   v.Address,
+			Label:     v.Label,
+			CreatedAt: v.CreatedAt,
+		}
+		if v.CreatedAt.After(c.modified) {
+			c.modified = v.CreatedAt
+		}
+	}
+	return c
+}
+
+func ListCohorts(ctx *server.Context) (interface{}, int) {
+	members := loadCohortMembers(ctx, "")
+	cohorts := make(map[string]*Cohort)
+	resp := &CohortList{
+		list:    make([]*Cohort, 0),
+		expires: ctx.Now.Add(ctx.Cfg.Http.CacheMaxExpires),
+	}
+	for _, v := range members {
+		c, ok := cohorts[v.Name]
+		if !ok {
+			c = &Cohort{Name: v.Name}
+			cohorts[v.Name] = c
+			resp.list = append(resp.list, c)
+		}
+		c.NMembers++
+		if v.CreatedAt.After(c.modified) {
+			c.modified = v.CreatedAt
+		}
+		if v.CreatedAt.After(resp.modified) {
+			resp.modified = v.CreatedAt
+		}
+	}
+	sort.Slice(resp.list, func(i, j int) bool { return resp.list[i].Name < resp.list[j].Name })
+	return resp, http.StatusOK
+}
+
+func ReadCohort(ctx *server.Context) (interface{}, int) {
+	name := getCohortName(ctx)
+	return newCohort(ctx, name, loadCohortMembers(ctx, name)), http.StatusOK
+}
+
+type CohortRequest struct {
+	Name    string         `json:"name"`
+	Members []CohortMember `json:"members"`
+}
+
+func addCohortMembers(ctx *server.Context, name string, members []CohortMember) {
+	if len(members) == 0 {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "empty member list", nil))
+	}
+	ins := make([]*model.CohortMember, len(members))
+	for i, v := range members {
+		if !v.Address.IsValid() {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address at position %d", i), nil))
+		}
+		ins[i] = &model.CohortMember{
+			Address: v.Address.Clone(),
+			Label:   v.Label,
+		}
+	}
+	if err := ctx.Indexer.AddCohortMembers(ctx, name, ins); err != nil {
+		switch err {
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access cohort table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, "cannot update cohort", err))
+		}
+	}
+}
+
+// create a new cohort or add members to an existing cohort
+func CreateCohort(ctx *server.Context) (interface{}, int) {
+	args := &CohortRequest{}
+	ctx.ParseRequestArgs(args)
+	args.Name = strings.TrimPrefix(args.Name, server.CohortPrefix)
+	if !model.IsValidCohortName(args.Name) {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid cohort name", nil))
+	}
+	addCohortMembers(ctx, args.Name, args.Members)
+	ctx.Server.PurgeResponseCache()
+	return newCohort(ctx, args.Name, loadCohortMembers(ctx, args.Name)), http.StatusCreated
+}
+
+// add members to a cohort
+func UpdateCohort(ctx *server.Context) (interface{}, int) {
+	name := getCohortName(ctx)
+	members := make([]CohortMember, 0)
+	ctx.ParseRequestArgs(&members)
+	addCohortMembers(ctx, name, members)
+	ctx.Server.PurgeResponseCache()
+	return newCohort(ctx, name, loadCohortMembers(ctx, name)), http.StatusOK
+}
+
+type CohortRemoveRequest struct {
+	Address []tezos.Address `schema:"address"` // remove only these members
+}
+
+// remove a cohort or selected members
+func RemoveCohort(ctx *server.Context) (interface{}, int) {
+	name := getCohortName(ctx)
+	args := &CohortRemoveRequest{}
+	ctx.ParseRequestArgs(args)
+	_ = loadCohortMembers(ctx, name)
+	if err := ctx.Indexer.RemoveCohortMembers(ctx, name, args.Address); err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot remove cohort", err))
+	}
+	ctx.Server.PurgeResponseCache()
+	return nil, http.StatusNoContent
+}
+
+type CohortMemberBalance struct {
+	Address          tezos.Address `json:"address"`
+	Label            string        `json:"label,omitempty"`
+	Balance          float64       `json:"balance"`
+	SpendableBalance float64       `json:"spendable_balance"`
+	FrozenBond       float64       `json:"frozen_bond"`
+	LastSeen         int64         `json:"last_seen"`
+}
+
+type CohortBalance struct {
+	Name             string                `json:"name"`
+	NMembers         int                   `json:"n_members"`
+	NFunded          int                   `json:"n_funded"`
+	Balance          float64               `json:"total_balance"`
+	SpendableBalance float64               `json:"spendable_balance"`
+	FrozenBond       float64               `json:"frozen_bond"`
+	Members          []CohortMemberBalance `json:"members"`
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (b CohortBalance) LastModified() time.Time { return b.modified }
+func (b CohortBalance) Expires() time.Time      { return b.expires }
+
+var _ server.Resource = (*CohortBalance)(nil)
+
+// total current balance across all cohort members
+func ReadCohortBalance(ctx *server.Context) (interface{}, int) {
+	name := getCohortName(ctx)
+	members := loadCohortMembers(ctx, name)
+	p := ctx.Params
+	resp := &CohortBalance{
+		Name:     name,
+		NMembers: len(members),
+		Members:  make([]CohortMemberBalance, 0, len(members)),
+		modified: ctx.Tip.BestTime,
+		expires:  ctx.Tip.BestTime.Add(p.BlockTime()),
+	}
+	var total, spendable, frozen int64
+	for _, m := range members {
+		mb := CohortMemberBalance{
+			Address: m.Address,
+			Label:   m.Label,
+		}
+		acc, err := ctx.Indexer.LookupAccount(ctx, m.Address)
+		switch err {
+		case nil:
+			total += acc.Balance()
+			spendable += acc.SpendableBalance
+			frozen += acc.FrozenBond
+			mb.Balance = p.ConvertValue(acc.Balance())
+			mb.SpendableBalance = p.ConvertValue(acc.SpendableBalance)
+			mb.FrozenBond = p.ConvertValue(acc.FrozenBond)
+			mb.LastSeen = acc.LastSeen
+			if acc.Balance() > 0 {
+				resp.NFunded++
+			}
+		case index.ErrNoAccountEntry:
+			// not yet on chain
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+		resp.Members = append(resp.Members, mb)
+	}
+	resp.Balance = p.ConvertValue(total)
+	resp.SpendableBalance = p.ConvertValue(spendable)
+	resp.FrozenBond = p.ConvertValue(frozen)
+	return resp, http.StatusOK
+}
+
+type CohortFlowRequest struct {
+	Days int `schema:"days"` // number of days until today (default 30)
+}
+
+type CohortFlowDay struct {
+	Day     time.Time `json:"day"`
+	Inflow  float64   `json:"inflow"`
+	Outflow float64   `json:"outflow"`
+	Net     float64   `json:"net"`
+	NIn     int       `json:"n_in"`
+	NOut    int       `json:"n_out"`
+}
+
+type CohortFlowList struct {
+	list     []CohortFlowDay
+	modified time.Time
+	expires  time.Time
+}
+
+func (l CohortFlowList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l CohortFlowList) LastModified() time.Time      { return l.modified }
+func (l CohortFlowList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*CohortFlowList)(nil)
+
+// daily spendable balance flows into and out of a cohort; transfers between
+// cohort members are excluded
+func ReadCohortFlows(ctx *server.Context) (interface{}, int) {
+	name := getCohortName(ctx)
+	args := &CohortFlowRequest{}
+	ctx.ParseRequestArgs(args)
+	if args.Days <= 0 {
+		args.Days = 30
+	}
+	if args.Days > maxCohortFlowDays {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("days exceeds maximum of %d", maxCohortFlowDays), nil))
+	}
+	members := loadCohortMembers(ctx, name)
+
+	// resolve member account ids, skip unknown accounts
+	ids := make([]uint64, 0, len(members))
+	isMember := make(map[model.AccountID]struct{}, len(members))
+	for _, m := range members {
+		acc, err := ctx.Indexer.LookupAccount(ctx, m.Address)
+		if err != nil {
+			if err == index.ErrNoAccountEntry {
+				continue
+			}
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+		ids = append(ids, acc.RowId.Value())
+		isMember[acc.RowId] = struct{}{}
+	}
+
+	p := ctx.Params
+	end := ctx.Tip.BestTime.UTC()
+	start := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -args.Days+1)
+	resp := &CohortFlowList{
+		list:     make([]CohortFlowDay, args.Days),
+		modified: ctx.Tip.BestTime,
+		expires:  ctx.Tip.BestTime.Add(p.BlockTime()),
+	}
+	for i := range resp.list {
+		resp.list[i].Day = start.AddDate(0, 0, i)
+	}
+	if len(ids) == 0 {
+		return resp, http.StatusOK
+	}
+
+	flows, err := ctx.Indexer.ListBalanceFlows(ctx, ids, start, end)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read flows", err))
+	}
+	in := make([]int64, args.Days)
+	out := make([]int64, args.Days)
+	for _, f := range flows {
+		if _, ok := isMember[f.CounterPartyId]; ok && f.CounterPartyId > 0 {
+			continue
+		}
+		i := int(f.Timestamp.Sub(start) / (24 * time.Hour))
+		if i < 0 || i >= args.Days {
+			continue
+		}
+		if f.AmountIn > 0 {
+			in[i] += f.AmountIn
+			resp.list[i].NIn++
+		}
+		if f.AmountOut > 0 {
+			out[i] += f.AmountOut
+			resp.list[i].NOut++
+		}
+	}
+	for i := range resp.list {
+		resp.list[i].Inflow = p.ConvertValue(in[i])
+		resp.list[i].Outflow = p.ConvertValue(out[i])
+		resp.list[i].Net = p.ConvertValue(in[i] - out[i])
+	}
+	return resp, http.StatusOK
+}
@@ -0,0 +1,270 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"net/http"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+type BakerKey struct {
+	Key               tezos.Key     `json:"key"`
+	Address           tezos.Address `json:"address"`
+	IsInitial         bool          `json:"is_initial"`
+	IsActive          bool          `json:"is_active"`
+	IsPending         bool          `json:"is_pending"`
+	IsSuperseded      bool          `json:"is_superseded,omitempty"`
+	Height            int64         `json:"height"`
+	Cycle             int64         `json:"cycle"`
+	Timestamp         time.Time     `json:"time"`
+	ActivationCycle   int64         `json:"activation_cycle"`
+	DeactivationCycle int64         `json:"deactivation_cycle,omitempty"`
+	OpHash            *tezos.OpHash `json:"op_hash,omitempty"`
+	BlocksBaked       int64         `json:"blocks_baked"`
+	BlocksProposed    int64         `json:"blocks_proposed"`
+	FirstBlock        int64         `json:"first_block,omitempty"`
+	LastBlock         int64         `json:"last_block,omitempty"`
+
+	// internal
+	id model.AccountID
+}
+
+func (k *BakerKey) addBlock(height int64) {
+	if k.FirstBlock == 0 || k.FirstBlock > height {
+		k.FirstBlock = height
+	}
+	if k.LastBlock < height {
+		k.LastBlock = height
+	}
+}
+
+type BakerDrain struct {
+	ConsensusKey tezos.Address `json:"consensus_key"`
+	Destination  tezos.Address `json:"destination"`
+	Amount       float64       `json:"amount"`
+	Height       int64         `json:"height"`
+	Cycle        int64         `json:"cycle"`
+	Timestamp    time.Time     `json:"time"`
+	OpHash       tezos.OpHash  `json:"op_hash"`
+}
+
+type BakerKeyHistory struct {
+	Baker      tezos.Address `json:"baker"`
+	ActiveKey  tezos.Key     `json:"active_key"`
+	PendingKey *tezos.Key    `json:"pending_key,omitempty"`
+	Keys       []*BakerKey   `json:"keys"`
+	Drains     []BakerDrain  `json:"drains"`
+}
+
+func ListBakerKeys(ctx *server.Context) (interface{}, int) {
+	bkr := loadBaker(ctx)
+	tip := getTip(ctx)
+
+	table, err := ctx.Indexer.Table(index.ConsensusKeyTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing consensus key table", err))
+	}
+	blocks, err := ctx.Indexer.Table(index.BlockTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing block table", err))
+	}
+
+	list := make([]*model.ConsensusKey, 0)
+	err = pack.NewQuery("api.list_baker_keys").
+		WithTable(table).
+		AndEqual("baker_id", bkr.AccountId).
+		WithOrder(pack.OrderAsc).
+		Execute(ctx, &list)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read consensus keys", err))
+	}
+
+	// the baker's own key is active from registration until the first rotation
+	resp := &BakerKeyHistory{
+		Baker:  bkr.Address,
+		Keys:   make([]*BakerKey, 0, len(list)+1),
+		Drains: make([]BakerDrain, 0),
+	}
+	resp.Keys = append(resp.Keys, &BakerKey{
+		Key:             bkr.Account.Pubkey,
+		Address:         bkr.Address,
+		IsInitial:       true,
+		Height:          bkr.BakerSince,
+		Cycle:           ctx.Params.CycleFromHeight(bkr.BakerSince),
+		Timestamp:       ctx.Indexer.LookupBlockTime(ctx.Context, bkr.BakerSince),
+		ActivationCycle: ctx.Params.CycleFromHeight(bkr.BakerSince),
+		id:              bkr.AccountId,
+	})
+
+	for _, v := range list {
+		switch {
+		case v.IsUpdate():
+			key := &BakerKey{
+				Key:             v.Key,
+				Address:         v.Address,
+				Height:          v.Height,
+				Cycle:           v.Cycle,
+				Timestamp:       v.Timestamp,
+				ActivationCycle: v.ActivationCycle,
+			}
+			if h := ctx.Indexer.LookupOpHash(ctx.Context, v.OpId); h.IsValid() {
+				key.OpHash = &h
+			}
+			// the baker's own key may be re-activated
+			if v.Address.Equal(bkr.Address) {
+				key.id = bkr.AccountId
+			} else if acc, err := ctx.Indexer.LookupAccount(ctx.Context, v.Address); err == nil {
+				key.id = acc.RowId
+			}
+			resp.Keys = append(resp.Keys, key)
+		case v.IsDrain():
+			resp.Drains = append(resp.Drains, BakerDrain{
+				ConsensusKey: v.Address,
+				Destination:  ctx.Indexer.LookupAddress(ctx, v.ReceiverId),
+				Amount:       ctx.Params.ConvertValue(v.Amount),
+				Height:       v.Height,
+				Cycle:        v.Cycle,
+				Timestamp:    v.Timestamp,
+				OpHash:       ctx.Indexer.LookupOpHash(ctx.Context, v.OpId),
+			})
+		}
+	}
+
+	// a later update activating in the same or an earlier cycle replaces
+	// a pending update, the replaced key never becomes active
+	keys := make([]*BakerKey, 0, len(resp.Keys))
+	for i, v := range resp.Keys {
+		for _, w := range resp.Keys[i+1:] {
+			if w.ActivationCycle <= v.ActivationCycle {
+				v.IsSuperseded = true
+				break
+			}
+		}
+		if !v.IsSuperseded {
+			keys = append(keys, v)
+		}
+	}
+
+	// identify active and pending keys
+	active := 0
+	for i, v := range keys {
+		if v.ActivationCycle <= tip.Cycle {
+			active = i
+		}
+	}
+	for i, v := range keys {
+		switch {
+		case i == active:
+			v.IsActive = true
+			resp.ActiveKey = v.Key
+		case i > active:
+			v.IsPending = true
+			k := v.Key
+			resp.PendingKey = &k
+		}
+		if i < active {
+			v.DeactivationCycle = keys[i+1].ActivationCycle
+		}
+	}
+
+	// blocks before the first rotation are signed with the baker's own key,
+	// only later blocks are scanned and earlier activity is taken from
+	// baker counters
+	initial := resp.Keys[0]
+	from := tip.Height + 1
+	for _, v := range keys {
+		if !v.IsInitial {
+			from = ctx.Params.CycleStartHeight(v.ActivationCycle)
+			break
+		}
+	}
+	if from > bkr.BakerSince {
+		if h := findBakerBlock(ctx, blocks, bkr.AccountId, bkr.BakerSince, from, pack.OrderAsc); h > 0 {
+			initial.addBlock(h)
+		}
+		if h := findBakerBlock(ctx, blocks, bkr.AccountId, bkr.BakerSince, from, pack.OrderDesc); h > 0 {
+			initial.addBlock(h)
+		}
+	}
+	var nBaked, nProposed int64
+	if from <= tip.Height {
+		// attribute baked and proposed blocks to the signing consensus key
+		byId := make(map[model.AccountID]*BakerKey)
+		for _, v := range resp.Keys {
+			if v.id > 0 {
+				byId[v.id] = v
+			}
+		}
+		b := &model.Block{}
+		err = pack.NewQuery("api.list_baker_key_blocks").
+			WithTable(blocks).
+			WithFields("height",
+				"baker_id",
+				"proposer_id",
+				"baker_consensus_key_id",
+				"proposer_consensus_key_id").
+			AndGte("height", from).
+			OrCondition(
+				pack.Equal("baker_id", bkr.AccountId),
+				pack.Equal("proposer_id", bkr.AccountId),
+			).
+			Stream(ctx.Context, func(r pack.Row) error {
+				if err := r.Decode(b); err != nil {
+					return err
+				}
+				if b.BakerId == bkr.AccountId {
+					nBaked++
+					if key, ok := byId[b.BakerConsensusKeyId]; ok {
+						key.BlocksBaked++
+						key.addBlock(b.Height)
+					}
+				}
+				if b.ProposerId == bkr.AccountId {
+					nProposed++
+					if key, ok := byId[b.ProposerConsensusKeyId]; ok {
+						key.BlocksProposed++
+						key.addBlock(b.Height)
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read blocks", err))
+		}
+	}
+	initial.BlocksBaked += util.Max64(bkr.BlocksBaked-nBaked, 0)
+	initial.BlocksProposed += util.Max64(bkr.BlocksProposed-nProposed, 0)
+
+	return resp, http.StatusOK
+}
+
+// findBakerBlock returns the height of the first or last block baked or
+// proposed by a baker in the height range [from, to) or zero.
+func findBakerBlock(ctx *server.Context, table *pack.Table, id model.AccountID, from, to int64, order pack.OrderType) int64 {
+	b := &model.Block{}
+	err := pack.NewQuery("api.find_baker_block").
+		WithTable(table).
+		WithFields("height", "baker_id", "proposer_id").
+		WithOrder(order).
+		WithLimit(1).
+		AndRange("height", from, to-1).
+		OrCondition(
+			pack.Equal("baker_id", id),
+			pack.Equal("proposer_id", id),
+		).
+		Stream(ctx.Context, func(r pack.Row) error {
+			return r.Decode(b)
+		})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read blocks", err))
+	}
+	return b.Height
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -136,8 +137,17 @@ func (b Contract) RegisterDirectRoutes(r *mux.Router) error {
 func (b Contract) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{ident}", server.C(ReadContract)).Methods("GET").Name("contract")
 	r.HandleFunc("/{ident}/calls", server.C(ReadContractCalls)).Methods("GET")
+	r.HandleFunc("/{ident}/errors", server.C(ListContractErrors)).Methods("GET")
+	r.HandleFunc("/{ident}/events", server.C(ListContractEvents)).Methods("GET")
 	r.HandleFunc("/{ident}/script", server.C(ReadContractScript)).Methods("GET")
 	r.HandleFunc("/{ident}/storage", server.C(ReadContractStorage)).Methods("GET")
+	r.HandleFunc("/{ident}/storage/history", server.C(ListContractStorageHistory)).Methods("GET")
+	if server.HasIndex(index.ContractCallIndexKey) {
+		r.HandleFunc("/{ident}/stats", server.C(ReadContractStats)).Methods("GET")
+	}
+	if server.HasIndex(index.CodeFamilyIndexKey) {
+		r.HandleFunc("/{ident}/similar", server.C(ListSimilarContracts)).Methods("GET")
+	}
 	return nil
 
 }
@@ -214,8 +224,12 @@ func (r *ContractRequest) Parse(ctx *server.Context) {
 		r.SinceHeight = height
 		r.SinceHash = hash
 	}
+}
+
+// implement QueryParsableRequest interface
+func (r *ContractRequest) ParseQuery(ctx *server.Context, query url.Values) {
 	// filter by entrypoint condition
-	for key, val := range ctx.Request.URL.Query() {
+	for key, val := range query {
 		keys := strings.Split(key, ".")
 		if keys[0] != "entrypoint" {
 			continue
@@ -248,7 +262,12 @@ func (r *ContractRequest) Parse(ctx *server.Context) {
 }
 
 func loadContract(ctx *server.Context) *model.Contract {
-	if ccIdent, ok := mux.Vars(ctx.Request)["ident"]; !ok || ccIdent == "" {
+	return lookupContract(ctx, mux.Vars(ctx.Request)["ident"])
+}
+
+// lookupContract loads a contract by address, panics on error.
+func lookupContract(ctx *server.Context, ccIdent string) *model.Contract {
+	if ccIdent == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing contract address", nil))
 	} else {
 		addr, err := tezos.ParseAddress(ccIdent)
@@ -293,7 +312,10 @@ var (
 func ReadContractCalls(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
-	cc := loadContract(ctx)
+	return listContractCalls(ctx, loadContract(ctx), args), http.StatusOK
+}
+
+func listContractCalls(ctx *server.Context, cc *model.Contract, args *ContractRequest) OpList {
 	acc, err := ctx.Indexer.LookupAccountId(ctx, cc.AccountId)
 	if err != nil {
 		switch err {
@@ -324,12 +346,30 @@ func ReadContractCalls(ctx *server.Context) (interface{}, int) {
 		}
 	}
 
-	// parse entrypoint filter
-	// - name (eg. "default")
-	// - branch (eg. "/R/R/L")
-	// - id (eg. 5)
-	r.Entrypoints = make([]int64, 0)
-	if len(args.EntrypointCond) > 0 {
+	r.Entrypoints = parseEntrypointCond(cc, args.EntrypointCond)
+
+	ops, err := ctx.Indexer.ListContractCalls(ctx, r)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read contract calls", err))
+	}
+
+	// we reuse explorer ops here
+	resp := make(OpList, 0)
+	cache := make(map[int64]interface{})
+	for _, v := range ops {
+		resp.Append(NewOp(ctx, v, nil, cc, args, cache), args.WithMerge())
+	}
+
+	return resp
+}
+
+// parseEntrypointCond resolves an entrypoint filter list to entrypoint ids
+// - name (eg. "default")
+// - branch (eg. "/R/R/L")
+// - id (eg. 5)
+func parseEntrypointCond(cc *model.Contract, cond string) []int64 {
+	list := make([]int64, 0)
+	if len(cond) > 0 {
 		pTyp, _, err := cc.LoadType()
 		if err != nil {
 			panic(server.EInternal(server.EC_SERVER, "script type unmarshal failed", err))
@@ -340,7 +380,7 @@ func ReadContractCalls(ctx *server.Context) (interface{}, int) {
 		}
 
 		// parse entrypoint list
-		for _, v := range strings.Split(args.EntrypointCond, ",") {
+		for _, v := range strings.Split(cond, ",") {
 			// ignore matching errors
 			switch {
 			case numRE.MatchString(v):
@@ -348,36 +388,23 @@ func ReadContractCalls(ctx *server.Context) (interface{}, int) {
 				if err != nil {
 					panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, fmt.Sprintf("invalid entrypoint id %s", v), err))
 				}
-				r.Entrypoints = append(r.Entrypoints, int64(ep))
+				list = append(list, int64(ep))
 			case branchRE.MatchString(v):
 				e, ok := scriptEntrypoints.FindBranch(v)
 				if !ok {
 					panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, fmt.Sprintf("missing entrypoint branch %s", v), err))
 				}
-				r.Entrypoints = append(r.Entrypoints, int64(e.Id))
+				list = append(list, int64(e.Id))
 			default:
 				e, ok := scriptEntrypoints[v]
 				if !ok {
 					panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, fmt.Sprintf("missing entrypoint %s", v), err))
 				}
-				r.Entrypoints = append(r.Entrypoints, int64(e.Id))
+				list = append(list, int64(e.Id))
 			}
 		}
 	}
-
-	ops, err := ctx.Indexer.ListContractCalls(ctx, r)
-	if err != nil {
-		panic(server.EInternal(server.EC_DATABASE, "cannot read contract calls", err))
-	}
-
-	// we reuse explorer ops here
-	resp := make(OpList, 0)
-	cache := make(map[int64]interface{})
-	for _, v := range ops {
-		resp.Append(NewOp(ctx, v, nil, cc, args, cache), args.WithMerge())
-	}
-
-	return resp, http.StatusOK
+	return list
 }
 
 type Script struct {
@@ -458,7 +485,14 @@ func ReadContractScript(ctx *server.Context) (interface{}, int) {
 func ReadContractStorage(ctx *server.Context) (interface{}, int) {
 	args := &ContractRequest{}
 	ctx.ParseRequestArgs(args)
-	cc := loadContract(ctx)
+	if store := readContractStorage(ctx, loadContract(ctx), args); store != nil {
+		return store, http.StatusOK
+	}
+	return nil, http.StatusNoContent
+}
+
+// readContractStorage returns nil for contracts without script.
+func readContractStorage(ctx *server.Context, cc *model.Contract, args *ContractRequest) *Storage {
 
 	if args.BlockHeight > 0 && args.BlockHeight < cc.FirstSeen {
 		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "empty storage before origination", nil))
@@ -476,7 +510,7 @@ func ReadContractStorage(ctx *server.Context) (interface{}, int) {
 
 	// empty script before babylon and for rollups is OK
 	if script == nil {
-		return nil, http.StatusNoContent
+		return nil
 	}
 
 	// type is always the most recently upgraded type stored in contract table
@@ -539,5 +573,5 @@ func ReadContractStorage(ctx *server.Context) (interface{}, int) {
 	// 	})
 	// }
 
-	return NewStorage(ctx, data, typ, mod, args), http.StatusOK
+	return NewStorage(ctx, data, typ, mod, args)
 }
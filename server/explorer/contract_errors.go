@@ -0,0 +1,150 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// ContractErrorGroup summarizes failed calls that share the same error class,
+// error id, FAILWITH payload and entrypoint.
+type ContractErrorGroup struct {
+	Class        string          `json:"class"`
+	ErrorId      string          `json:"error_id"`
+	FailWith     json.RawMessage `json:"failwith,omitempty"`
+	Entrypoint   string          `json:"entrypoint"`
+	EntrypointId int             `json:"entrypoint_id"`
+	Count        int             `json:"count"`
+	Fee          float64         `json:"fee"`
+	FirstHeight  int64           `json:"first_height"`
+	LastHeight   int64           `json:"last_height"`
+	FirstTime    time.Time       `json:"first_time"`
+	LastTime     time.Time       `json:"last_time"`
+	LastOp       tezos.OpHash    `json:"last_op"`
+
+	lastOpId model.OpID
+}
+
+type ContractErrorList struct {
+	list     []*ContractErrorGroup
+	modified time.Time
+	expires  time.Time
+}
+
+func (l ContractErrorList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l ContractErrorList) LastModified() time.Time      { return l.modified }
+func (l ContractErrorList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*ContractErrorList)(nil)
+
+// list failed calls to a contract grouped by error, most frequent first
+func ListContractErrors(ctx *server.Context) (interface{}, int) {
+	args := &ContractRequest{}
+	ctx.ParseRequestArgs(args)
+	cc := loadContract(ctx)
+	acc, err := ctx.Indexer.LookupAccountId(ctx, cc.AccountId)
+	if err != nil {
+		switch err {
+		case index.ErrNoAccountEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such contract", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+
+	r := etl.ListRequest{
+		Account:     acc,
+		Mode:        args.EntrypointMode,
+		Since:       args.SinceHeight,
+		Until:       args.BlockHeight,
+		Entrypoints: parseEntrypointCond(cc, args.EntrypointCond),
+	}
+	errs, err := ctx.Indexer.ListContractErrors(ctx, r)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read contract errors", err))
+	}
+
+	// resolve entrypoint names
+	names := make(map[int]string)
+	if pTyp, _, err := cc.LoadType(); err == nil {
+		if eps, err := pTyp.Entrypoints(false); err == nil {
+			for _, v := range eps {
+				names[v.Id] = v.Name
+			}
+		}
+	}
+
+	// group errors, the list is sorted by height
+	type groupKey struct {
+		class      string
+		errorId    string
+		failWith   string
+		entrypoint int
+	}
+	groups := make(map[groupKey]*ContractErrorGroup)
+	list := make([]*ContractErrorGroup, 0)
+	for _, v := range errs {
+		k := groupKey{v.Class, v.ErrorId, v.FailWith, v.Entrypoint}
+		g, ok := groups[k]
+		if !ok {
+			g = &ContractErrorGroup{
+				Class:        v.Class,
+				ErrorId:      v.ErrorId,
+				Entrypoint:   names[v.Entrypoint],
+				EntrypointId: v.Entrypoint,
+				FirstHeight:  v.Height,
+				FirstTime:    v.Timestamp,
+			}
+			if len(v.FailWith) > 0 {
+				g.FailWith = json.RawMessage(v.FailWith)
+			}
+			if g.Entrypoint == "" {
+				g.Entrypoint = strconv.Itoa(v.Entrypoint)
+			}
+			groups[k] = g
+			list = append(list, g)
+		}
+		g.Count++
+		g.Fee += ctx.Params.ConvertValue(v.Fee)
+		g.LastHeight = v.Height
+		g.LastTime = v.Timestamp
+		g.lastOpId = v.OpId
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Count == list[j].Count {
+			return list[i].LastHeight > list[j].LastHeight
+		}
+		return list[i].Count > list[j].Count
+	})
+
+	// apply offset and limit to groups
+	if int(args.Offset) >= len(list) {
+		list = list[:0]
+	} else {
+		list = list[args.Offset:]
+	}
+	if limit := int(ctx.Cfg.ClampExplore(args.Limit)); len(list) > limit {
+		list = list[:limit]
+	}
+	for _, g := range list {
+		g.LastOp = ctx.Indexer.LookupOpHash(ctx, g.lastOpId)
+	}
+
+	resp := &ContractErrorList{
+		list:     list,
+		modified: ctx.Indexer.LookupBlockTime(ctx.Context, acc.LastSeen),
+		expires:  ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	return resp, http.StatusOK
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+const eventPayloadPrefix = "payload."
+
+type ContractEventsRequest struct {
+	ListRequest // offset, limit, cursor, order
+
+	Tag    util.StringList `schema:"tag"`    // one or more event tags
+	Since  string          `schema:"since"`  // block hash or height, exclusive
+	Until  string          `schema:"until"`  // block hash or height, inclusive
+	From   util.Time       `schema:"from"`   // start time, inclusive
+	To     util.Time       `schema:"to"`     // end time, inclusive
+	Prim   bool            `schema:"prim"`   // include Micheline type and payload
+	Unpack bool            `schema:"unpack"` // unpack packed payload values
+
+	// decoded values
+	SinceHeight int64                      `schema:"-"`
+	UntilHeight int64                      `schema:"-"`
+	Filters     model.BigmapPathFilterList `schema:"-"`
+	types       typeCache
+}
+
+// event types keyed by their binary encoding (type hashes ignore annotations)
+type typeCache map[string]micheline.Type
+
+func (r *ContractEventsRequest) WithPrim() bool    { return r != nil && r.Prim }
+func (r *ContractEventsRequest) WithUnpack() bool  { return r != nil && r.Unpack }
+func (r *ContractEventsRequest) WithHeight() int64 { return 0 }
+func (r *ContractEventsRequest) WithMeta() bool    { return false }
+func (r *ContractEventsRequest) WithRights() bool  { return false }
+func (r *ContractEventsRequest) WithMerge() bool   { return false }
+func (r *ContractEventsRequest) WithStorage() bool { return false }
+
+func (r *ContractEventsRequest) Parse(ctx *server.Context) {
+	if len(r.Since) > 0 {
+		r.SinceHeight = parseBlockIdent(ctx, r.Since)
+	}
+	if len(r.Until) > 0 {
+		r.UntilHeight = parseBlockIdent(ctx, r.Until)
+	}
+	// time range is translated into a height range
+	if !r.From.IsZero() {
+		h := ctx.Indexer.LookupBlockHeightFromTime(ctx.Context, r.From.Time())
+		if ctx.Indexer.LookupBlockTime(ctx.Context, h).Before(r.From.Time()) {
+			h++
+		}
+		r.SinceHeight = util.Max64(r.SinceHeight, h-1)
+	}
+	if !r.To.IsZero() {
+		h := ctx.Indexer.LookupBlockHeightFromTime(ctx.Context, r.To.Time())
+		if r.UntilHeight == 0 || h < r.UntilHeight {
+			r.UntilHeight = h
+		}
+	}
+	// payload filters use bigmap value path semantics
+	for key, vals := range ctx.Request.URL.Query() {
+		if !strings.HasPrefix(key, eventPayloadPrefix) {
+			continue
+		}
+		path := "value." + key[len(eventPayloadPrefix):]
+		for _, v := range vals {
+			f, err := model.ParseBigmapPathFilter(path, v)
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+			}
+			r.Filters = append(r.Filters, f)
+		}
+	}
+	r.types = make(typeCache)
+}
+
+// value returns the typed event payload.
+func (r *ContractEventsRequest) value(ev *model.Event) (*micheline.Value, micheline.Type, micheline.Prim) {
+	typ, ok := r.types[string(ev.Type)]
+	if !ok {
+		var p micheline.Prim
+		_ = p.UnmarshalBinary(ev.Type)
+		typ = micheline.NewType(p)
+		r.types[string(ev.Type)] = typ
+	}
+	var prim micheline.Prim
+	if err := prim.UnmarshalBinary(ev.Payload); err != nil {
+		return nil, typ, prim
+	}
+	if r.Unpack && prim.IsPackedAny() {
+		if up, err := prim.UnpackAll(); err == nil {
+			prim = up
+		}
+	}
+	return micheline.NewValuePtr(typ, prim), typ, prim
+}
+
+// decode translates an event payload into a Go value using the event type.
+func (r *ContractEventsRequest) decode(ev *model.Event) (interface{}, micheline.Type, micheline.Prim) {
+	val, typ, prim := r.value(ev)
+	if val == nil {
+		return nil, typ, prim
+	}
+	m, err := val.Map()
+	if err != nil {
+		return nil, typ, prim
+	}
+	return m, typ, prim
+}
+
+func (r *ContractEventsRequest) match(ev *model.Event) bool {
+	if len(r.Filters) == 0 {
+		return true
+	}
+	val, _, _ := r.value(ev)
+	if val == nil {
+		return false
+	}
+	for _, f := range r.Filters {
+		if !f.Match(val) {
+			return false
+		}
+	}
+	return true
+}
+
+type ContractEvent struct {
+	Id       uint64          `json:"id"`
+	Contract tezos.Address   `json:"contract"`
+	Tag      string          `json:"tag"`
+	TypeHash util.U64String  `json:"type_hash"`
+	Payload  interface{}     `json:"payload"`
+	Height   int64           `json:"height"`
+	Time     time.Time       `json:"time"`
+	OpId     uint64          `json:"op_id"`
+	OpHash   tezos.OpHash    `json:"op_hash"`
+	Type     *micheline.Prim `json:"type,omitempty"`
+	Prim     *micheline.Prim `json:"prim,omitempty"`
+}
+
+type ContractEventList struct {
+	list     []*ContractEvent
+	modified time.Time
+	expires  time.Time
+}
+
+func (l ContractEventList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l ContractEventList) LastModified() time.Time      { return l.modified }
+func (l ContractEventList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*ContractEventList)(nil)
+
+func ListContractEvents(ctx *server.Context) (interface{}, int) {
+	args := &ContractEventsRequest{}
+	ctx.ParseRequestArgs(args)
+	acc := loadAccount(ctx)
+
+	r := etl.ListRequest{
+		Account: acc,
+		Since:   args.SinceHeight,
+		Until:   args.UntilHeight,
+		Offset:  args.Offset,
+		Limit:   ctx.Cfg.ClampExplore(args.Limit),
+		Cursor:  args.Cursor,
+		Order:   args.Order,
+	}
+	events, err := ctx.Indexer.ListContractEvents(ctx, r, args.Tag, args.match)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read contract events", err))
+	}
+
+	resp := &ContractEventList{
+		list:     make([]*ContractEvent, 0, len(events)),
+		modified: ctx.Tip.BestTime,
+		expires:  ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	for _, v := range events {
+		val, typ, prim := args.decode(v)
+		ev := &ContractEvent{
+			Id:       v.ID(),
+			Contract: acc.Address,
+			Tag:      v.Tag,
+			TypeHash: util.U64String(v.TypeHash),
+			Payload:  val,
+			Height:   v.Height,
+			Time:     ctx.Indexer.LookupBlockTime(ctx.Context, v.Height),
+			OpId:     v.OpId,
+			OpHash:   ctx.Indexer.LookupEventOpHash(ctx, v.OpId),
+		}
+		if args.WithPrim() || val == nil {
+			ev.Type = &typ.Prim
+			ev.Prim = &prim
+		}
+		resp.list = append(resp.list, ev)
+	}
+	return resp, http.StatusOK
+}
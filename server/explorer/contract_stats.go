@@ -0,0 +1,128 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// ContractCallDay holds daily call statistics for a single entrypoint.
+type ContractCallDay struct {
+	Day          time.Time `json:"day"`
+	Entrypoint   string    `json:"entrypoint"`
+	EntrypointId int       `json:"entrypoint_id"`
+	NCalls       int       `json:"n_calls"`
+	NFailed      int       `json:"n_failed"`
+	NCallers     int       `json:"n_callers"`
+	GasUsed      int64     `json:"gas_used"`
+	Fee          float64   `json:"fee"`
+	Volume       float64   `json:"volume"`
+}
+
+type ContractCallStatsList struct {
+	list     []ContractCallDay
+	modified time.Time
+	expires  time.Time
+}
+
+func (l ContractCallStatsList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l ContractCallStatsList) LastModified() time.Time      { return l.modified }
+func (l ContractCallStatsList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*ContractCallStatsList)(nil)
+
+// list per-entrypoint daily call statistics
+func ReadContractStats(ctx *server.Context) (interface{}, int) {
+	args := &ContractRequest{}
+	ctx.ParseRequestArgs(args)
+	cc := loadContract(ctx)
+	acc, err := ctx.Indexer.LookupAccountId(ctx, cc.AccountId)
+	if err != nil {
+		switch err {
+		case index.ErrNoAccountEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such contract", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+
+	r := etl.ListRequest{
+		Account:     acc,
+		Mode:        args.EntrypointMode,
+		Since:       args.SinceHeight,
+		Until:       args.BlockHeight,
+		Order:       args.Order,
+		Entrypoints: parseEntrypointCond(cc, args.EntrypointCond),
+	}
+	stats, err := ctx.Indexer.ListContractCallStats(ctx, r)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read contract call stats", err))
+	}
+
+	// resolve entrypoint names
+	names := make(map[int]string)
+	if pTyp, _, err := cc.LoadType(); err == nil {
+		if eps, err := pTyp.Entrypoints(false); err == nil {
+			for _, v := range eps {
+				names[v.Id] = v.Name
+			}
+		}
+	}
+
+	// rows are daily per entrypoint, sort by day and entrypoint
+	days := make([]ContractCallDay, 0, len(stats))
+	for _, v := range stats {
+		d := ContractCallDay{
+			Day:          model.CallDay(v.Timestamp),
+			Entrypoint:   names[v.Entrypoint],
+			EntrypointId: v.Entrypoint,
+			NCalls:       v.NCalls,
+			NFailed:      v.NFailed,
+			NCallers:     v.NCallers,
+			GasUsed:      v.GasUsed,
+			Fee:          ctx.Params.ConvertValue(v.Fee),
+			Volume:       ctx.Params.ConvertValue(v.Volume),
+		}
+		if d.Entrypoint == "" {
+			d.Entrypoint = strconv.Itoa(v.Entrypoint)
+		}
+		days = append(days, d)
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		if days[i].Day.Equal(days[j].Day) {
+			return days[i].EntrypointId < days[j].EntrypointId
+		}
+		if args.Order == pack.OrderDesc {
+			return days[i].Day.After(days[j].Day)
+		}
+		return days[i].Day.Before(days[j].Day)
+	})
+
+	// apply offset and limit to daily rows
+	if int(args.Offset) >= len(days) {
+		days = days[:0]
+	} else {
+		days = days[args.Offset:]
+	}
+	if limit := int(ctx.Cfg.ClampExplore(args.Limit)); len(days) > limit {
+		days = days[:limit]
+	}
+
+	resp := &ContractCallStatsList{
+		list:     days,
+		modified: ctx.Indexer.LookupBlockTime(ctx.Context, acc.LastSeen),
+		expires:  ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	return resp, http.StatusOK
+}
@@ -7,14 +7,18 @@ import (
 	"encoding/json"
 	"github.com/gorilla/mux"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
 	"blockwatch.cc/packdb/vec"
+	"blockwatch.cc/tzgo/tezos"
 
+	"blockwatch.cc/tzindex/etl"
 	"blockwatch.cc/tzindex/etl/index"
 	"blockwatch.cc/tzindex/etl/model"
 	"blockwatch.cc/tzindex/rpc"
@@ -436,12 +440,18 @@ func (c Cycle) RegisterDirectRoutes(r *mux.Router) error {
 
 func (c Cycle) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{cycle}", server.C(ReadCycle)).Methods("GET").Name("cycle")
+	r.HandleFunc("/{cycle}/bakers", server.C(ListCycleBakers)).Methods("GET")
 	return nil
 }
 
 func parseCycle(ctx *server.Context) int64 {
+	return parseCycleIdent(ctx, mux.Vars(ctx.Request)["cycle"])
+}
+
+// parseCycleIdent parses a cycle number or `head`, panics on error.
+func parseCycleIdent(ctx *server.Context, id string) int64 {
 	// from number or string
-	if id, ok := mux.Vars(ctx.Request)["cycle"]; !ok || id == "" {
+	if id == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing cycle identifier", nil))
 	} else {
 		switch {
@@ -459,7 +469,10 @@ func parseCycle(ctx *server.Context) int64 {
 }
 
 func ReadCycle(ctx *server.Context) (interface{}, int) {
-	id := parseCycle(ctx)
+	return readCycle(ctx, parseCycle(ctx)), http.StatusOK
+}
+
+func readCycle(ctx *server.Context, id int64) *Cycle {
 	p := ctx.Params
 	tiptime := ctx.Tip.BestTime
 
@@ -487,5 +500,143 @@ func ReadCycle(ctx *server.Context) (interface{}, int) {
 		cycle.expires = tiptime.Add(ctx.Cfg.Http.CacheMaxExpires)
 	}
 
-	return cycle, http.StatusOK
+	return cycle
+}
+
+type CycleDenunciation struct {
+	Type          string        `json:"type"`
+	Height        int64         `json:"height"`
+	OpHash        tezos.OpHash  `json:"op_hash"`
+	Offender      tezos.Address `json:"offender"`
+	Accuser       tezos.Address `json:"accuser"`
+	LostDeposits  float64       `json:"lost_deposits"`
+	LostRewards   float64       `json:"lost_rewards"`
+	LostFees      float64       `json:"lost_fees"`
+	AccuserReward float64       `json:"accuser_reward"`
+}
+
+type CycleBaker struct {
+	Baker              tezos.Address        `json:"baker"`
+	IsDegraded         bool                 `json:"is_degraded"`
+	NBakingRights      int64                `json:"n_baking_rights"`
+	NBlocksBaked       int64                `json:"n_blocks_baked"`
+	NBlocksStolen      int64                `json:"n_blocks_stolen"`
+	NBlocksMissed      int64                `json:"n_blocks_missed"`
+	NEndorsingRights   int64                `json:"n_endorsing_rights"`
+	NBlocksEndorsed    int64                `json:"n_blocks_endorsed"`
+	NBlocksNotEndorsed int64                `json:"n_blocks_not_endorsed"`
+	NEndorsingSlots    int64                `json:"n_endorsing_slots"`
+	NSlotsEndorsed     int64                `json:"n_slots_endorsed"`
+	NSeedsRequired     int64                `json:"n_seeds_required"`
+	NSeedsRevealed     int64                `json:"n_seeds_revealed"`
+	NSeedsUnrevealed   int64                `json:"n_seeds_unrevealed"`
+	NSeedsPending      int64                `json:"n_seeds_pending"`
+	NVdfRevelations    int64                `json:"n_vdf_revelations"`
+	NDoubleBaking      int64                `json:"n_double_baking"`
+	NDoubleEndorsement int64                `json:"n_double_endorsement"`
+	NAccusations       int64                `json:"n_accusations"`
+	LostDeposits       float64              `json:"lost_deposits"`
+	LostRewards        float64              `json:"lost_rewards"`
+	LostFees           float64              `json:"lost_fees"`
+	AccusationRewards  float64              `json:"accusation_rewards"`
+	Reliability        float64              `json:"reliability"`
+	Denunciations      []*CycleDenunciation `json:"denunciations,omitempty"`
+}
+
+type CycleBakersRequest struct {
+	ListRequest
+	Degraded bool `schema:"degraded"` // only bakers with misses or penalties
+}
+
+func NewCycleDenunciation(ctx *server.Context, d *etl.Denunciation) *CycleDenunciation {
+	p := ctx.Params
+	return &CycleDenunciation{
+		Type:          d.Type.String(),
+		Height:        d.Height,
+		OpHash:        ctx.Indexer.LookupOpHash(ctx, d.OpId),
+		Offender:      ctx.Indexer.LookupAddress(ctx, d.OffenderId),
+		Accuser:       ctx.Indexer.LookupAddress(ctx, d.AccuserId),
+		LostDeposits:  p.ConvertValue(d.LostDeposits),
+		LostRewards:   p.ConvertValue(d.LostRewards),
+		LostFees:      p.ConvertValue(d.LostFees),
+		AccuserReward: p.ConvertValue(d.AccuserReward),
+	}
+}
+
+func ListCycleBakers(ctx *server.Context) (interface{}, int) {
+	args := &CycleBakersRequest{
+		ListRequest: ListRequest{
+			Order: pack.OrderDesc,
+		},
+	}
+	ctx.ParseRequestArgs(args)
+	id := parseCycle(ctx)
+	p := ctx.Params
+
+	bakers, _, err := ctx.Indexer.ListCycleBakers(ctx, id)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot list cycle bakers", err))
+	}
+
+	if args.Degraded {
+		var n int
+		for _, v := range bakers {
+			if v.IsDegraded() {
+				bakers[n] = v
+				n++
+			}
+		}
+		bakers = bakers[:n]
+	}
+
+	// most degraded bakers first
+	sort.SliceStable(bakers, func(i, j int) bool {
+		mi := bakers[i].NBlocksMissed + bakers[i].NBlocksNotEndorsed
+		mj := bakers[j].NBlocksMissed + bakers[j].NBlocksNotEndorsed
+		return mi > mj
+	})
+	if args.Order == pack.OrderAsc {
+		for i, j := 0, len(bakers)-1; i < j; i, j = i+1, j-1 {
+			bakers[i], bakers[j] = bakers[j], bakers[i]
+		}
+	}
+
+	offset := util.Min(int(args.Offset), len(bakers))
+	limit := util.Min(int(ctx.Cfg.ClampExplore(args.Limit)), len(bakers)-offset)
+	bakers = bakers[offset : offset+limit]
+
+	resp := make([]*CycleBaker, 0, len(bakers))
+	for _, v := range bakers {
+		b := &CycleBaker{
+			Baker:              ctx.Indexer.LookupAddress(ctx, v.AccountId),
+			IsDegraded:         v.IsDegraded(),
+			NBakingRights:      v.NBakingRights,
+			NBlocksBaked:       v.NBlocksBaked,
+			NBlocksStolen:      v.NBlocksStolen,
+			NBlocksMissed:      v.NBlocksMissed,
+			NEndorsingRights:   v.NEndorsingRights,
+			NBlocksEndorsed:    v.NBlocksEndorsed,
+			NBlocksNotEndorsed: v.NBlocksNotEndorsed,
+			NEndorsingSlots:    v.NEndorsingSlots,
+			NSlotsEndorsed:     v.NSlotsEndorsed,
+			NSeedsRequired:     v.NSeedsRequired,
+			NSeedsRevealed:     v.NSeedsRevealed,
+			NSeedsUnrevealed:   v.NSeedsUnrevealed,
+			NSeedsPending:      v.NSeedsPending,
+			NVdfRevelations:    v.NVdfRevelations,
+			NDoubleBaking:      v.NDoubleBaking,
+			NDoubleEndorsement: v.NDoubleEndorsement,
+			NAccusations:       v.NAccusations,
+			LostDeposits:       p.ConvertValue(v.LostDeposits),
+			LostRewards:        p.ConvertValue(v.LostRewards),
+			LostFees:           p.ConvertValue(v.LostFees),
+			AccusationRewards:  p.ConvertValue(v.AccusationRewards),
+			Reliability:        float64(v.Reliability) / 100,
+		}
+		for _, d := range v.Denunciations {
+			b.Denunciations = append(b.Denunciations, NewCycleDenunciation(ctx, d))
+		}
+		resp = append(resp, b)
+	}
+	return resp, http.StatusOK
 }
@@ -0,0 +1,216 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+type ForecastRequest struct {
+	Cycles int64 `schema:"cycles"` // number of future cycles, default all with rights
+	Limit  uint  `schema:"limit"`  // max slots per cycle and right type
+}
+
+type ForecastSlot struct {
+	Height    int64     `json:"height"`
+	Timestamp time.Time `json:"time"`
+}
+
+type ForecastCycle struct {
+	Cycle                   int64          `json:"cycle"`
+	StartHeight             int64          `json:"start_height"`
+	EndHeight               int64          `json:"end_height"`
+	StartTime               time.Time      `json:"start_time"`
+	EndTime                 time.Time      `json:"end_time"`
+	ActiveStake             float64        `json:"active_stake"`
+	StakeShare              float64        `json:"stake_share"`
+	NBakingRights           int64          `json:"n_baking_rights"`
+	NEndorsingRights        int64          `json:"n_endorsing_rights"`
+	BakingSlots             []ForecastSlot `json:"baking_slots"`
+	EndorsingSlots          []ForecastSlot `json:"endorsing_slots"`
+	ExpectedBakingReward    float64        `json:"expected_baking_reward"`
+	ExpectedEndorsingReward float64        `json:"expected_endorsing_reward"`
+	ExpectedReward          float64        `json:"expected_reward"`
+	ExpectedDeposit         float64        `json:"expected_deposit"`
+}
+
+type BakerForecast struct {
+	Baker             tezos.Address   `json:"baker"`
+	Height            int64           `json:"height"`
+	Cycle             int64           `json:"cycle"`
+	Timestamp         time.Time       `json:"time"`
+	BlockTime         int64           `json:"block_time"`
+	NextBakeHeight    int64           `json:"next_bake_height"`
+	NextBakeTime      *time.Time      `json:"next_bake_time"`
+	NextEndorseHeight int64           `json:"next_endorse_height"`
+	NextEndorseTime   *time.Time      `json:"next_endorse_time"`
+	ExpectedReward    float64         `json:"expected_reward"`
+	Cycles            []ForecastCycle `json:"cycles"`
+}
+
+func GetBakerForecast(ctx *server.Context) (interface{}, int) {
+	args := &ForecastRequest{}
+	ctx.ParseRequestArgs(args)
+	bkr := loadBaker(ctx)
+	tip := getTip(ctx)
+	p := ctx.Params
+	limit := int(ctx.Cfg.ClampExplore(args.Limit))
+
+	rightsTable, err := ctx.Indexer.Table(index.RightsTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing rights table", err))
+	}
+	incomeTable, err := ctx.Indexer.Table(index.IncomeTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing income table", err))
+	}
+
+	// rights and income exist up to PreservedCycles ahead
+	first, last := tip.Cycle, tip.Cycle+p.PreservedCycles
+	if args.Cycles > 0 && first+args.Cycles-1 < last {
+		last = first + args.Cycles - 1
+	}
+
+	rights := make([]*model.Right, 0)
+	err = pack.NewQuery("api.forecast_rights").
+		WithTable(rightsTable).
+		AndEqual("account_id", bkr.AccountId).
+		AndRange("cycle", first, last).
+		Execute(ctx, &rights)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read rights", err))
+	}
+	rightsByCycle := make(map[int64]*model.Right)
+	for _, v := range rights {
+		rightsByCycle[v.Cycle] = v
+	}
+
+	// load own income sheets and sum total active stake per cycle
+	incomeByCycle := make(map[int64]*model.Income)
+	totalStake := make(map[int64]int64)
+	in := &model.Income{}
+	err = pack.NewQuery("api.forecast_income").
+		WithTable(incomeTable).
+		WithFields("cycle", "account_id", "active_stake", "n_baking_rights", "n_endorsing_rights").
+		AndRange("cycle", first, last).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(in); err != nil {
+				return err
+			}
+			totalStake[in.Cycle] += in.ActiveStake
+			if in.AccountId == bkr.AccountId {
+				own := *in
+				incomeByCycle[in.Cycle] = &own
+			}
+			return nil
+		})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read income", err))
+	}
+
+	resp := &BakerForecast{
+		Baker:     bkr.Address,
+		Height:    tip.Height,
+		Cycle:     tip.Cycle,
+		Timestamp: tip.Timestamp,
+		BlockTime: int64(p.BlockTime() / time.Second),
+		Cycles:    make([]ForecastCycle, 0, last-first+1),
+	}
+	resp.NextBakeHeight, resp.NextEndorseHeight = ctx.Indexer.NextRights(ctx, bkr.AccountId, tip.Height)
+	resp.NextBakeTime = ctx.Indexer.LookupBlockTimePtr(ctx, resp.NextBakeHeight)
+	resp.NextEndorseTime = ctx.Indexer.LookupBlockTimePtr(ctx, resp.NextEndorseHeight)
+
+	for c := first; c <= last; c++ {
+		start, end := p.CycleStartHeight(c), p.CycleEndHeight(c)
+		fc := ForecastCycle{
+			Cycle:          c,
+			StartHeight:    start,
+			EndHeight:      end,
+			StartTime:      ctx.Indexer.LookupBlockTime(ctx, start),
+			EndTime:        ctx.Indexer.LookupBlockTime(ctx, end),
+			BakingSlots:    make([]ForecastSlot, 0),
+			EndorsingSlots: make([]ForecastSlot, 0),
+		}
+
+		// list upcoming slots only
+		if right, ok := rightsByCycle[c]; ok {
+			for _, pos := range right.Bake.Indexes(nil) {
+				h := start + int64(pos)
+				fc.NBakingRights++
+				if h > tip.Height && len(fc.BakingSlots) < limit {
+					fc.BakingSlots = append(fc.BakingSlots, ForecastSlot{
+						Height:    h,
+						Timestamp: ctx.Indexer.LookupBlockTime(ctx, h),
+					})
+				}
+			}
+			for _, pos := range right.Endorse.Indexes(nil) {
+				h := start + int64(pos)
+				fc.NEndorsingRights++
+				if h > tip.Height && len(fc.EndorsingSlots) < limit {
+					fc.EndorsingSlots = append(fc.EndorsingSlots, ForecastSlot{
+						Height:    h,
+						Timestamp: ctx.Indexer.LookupBlockTime(ctx, h),
+					})
+				}
+			}
+		}
+
+		// income sheets count endorsing power (slots) instead of blocks
+		var stake int64
+		if inc, ok := incomeByCycle[c]; ok {
+			stake = inc.ActiveStake
+			fc.NBakingRights = inc.NBakingRights
+			fc.NEndorsingRights = inc.NEndorsingRights
+		}
+		fc.ActiveStake = p.ConvertValue(stake)
+		if total := totalStake[c]; total > 0 {
+			fc.StakeShare = float64(stake) / float64(total)
+		}
+
+		bake, endorse, deposit := estimateCycleIncome(p, stake, totalStake[c], fc.NBakingRights, fc.NEndorsingRights)
+		fc.ExpectedBakingReward = p.ConvertValue(bake)
+		fc.ExpectedEndorsingReward = p.ConvertValue(endorse)
+		fc.ExpectedReward = p.ConvertValue(bake + endorse)
+		fc.ExpectedDeposit = p.ConvertValue(deposit)
+		resp.ExpectedReward += fc.ExpectedReward
+		resp.Cycles = append(resp.Cycles, fc)
+	}
+
+	return resp, http.StatusOK
+}
+
+// estimateCycleIncome returns expected baking and endorsing rewards and the
+// deposit requirement for a cycle from protocol reward constants. Since Ithaca
+// baking rewards include the bonus for a fully endorsed block and endorsing
+// rewards are paid in proportion to active stake at cycle end.
+func estimateCycleIncome(p *tezos.Params, stake, totalStake, nBake, nEndorse int64) (int64, int64, int64) {
+	if p.Version < 12 {
+		bake := p.BlockReward * nBake
+		endorse := p.EndorsementReward * nEndorse
+		deposit := p.BlockSecurityDeposit*nBake + p.EndorsementSecurityDeposit*nEndorse
+		return bake, endorse, deposit
+	}
+	bonus := p.BakingRewardBonusPerSlot * int64(p.ConsensusCommitteeSize-p.ConsensusThreshold)
+	bake := (p.BakingRewardFixedPortion + bonus) * nBake
+
+	// protect against int64 overflow
+	var endorse int64
+	if totalStake > 0 {
+		e := big.NewInt(stake)
+		e.Mul(e, big.NewInt(p.EndorsingRewardPerSlot*int64(p.ConsensusCommitteeSize)*p.BlocksPerCycle))
+		e.Div(e, big.NewInt(totalStake))
+		endorse = e.Int64()
+	}
+	deposit := stake * int64(p.FrozenDepositsPercentage) / 100
+	return bake, endorse, deposit
+}
@@ -266,6 +266,7 @@ func (b Election) RegisterDirectRoutes(r *mux.Router) error {
 
 func (b Election) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{ident}", server.C(ReadElection)).Methods("GET").Name("election")
+	r.HandleFunc("/{ident}/timeline", server.C(GetElectionTimeline)).Methods("GET")
 	r.HandleFunc("/{ident}/{stage}/ballots", server.C(ListBallots)).Methods("GET")
 	r.HandleFunc("/{ident}/{stage}/voters", server.C(ListVoters)).Methods("GET")
 	return nil
@@ -273,8 +274,14 @@ func (b Election) RegisterRoutes(r *mux.Router) error {
 }
 
 func loadElection(ctx *server.Context) *model.Election {
+	return lookupElection(ctx, mux.Vars(ctx.Request)["ident"])
+}
+
+// lookupElection loads an election by number, proposal or `head`, panics
+// on error.
+func lookupElection(ctx *server.Context, id string) *model.Election {
 	// from number or block height
-	if id, ok := mux.Vars(ctx.Request)["ident"]; !ok || id == "" {
+	if id == "" {
 		panic(server.EBadRequest(server.EC_RESOURCE_ID_MISSING, "missing election identifier", nil))
 	} else {
 		var (
@@ -347,7 +354,10 @@ func loadStage(ctx *server.Context, election *model.Election, maxPeriods int) in
 }
 
 func ReadElection(ctx *server.Context) (interface{}, int) {
-	election := loadElection(ctx)
+	return readElection(ctx, loadElection(ctx)), http.StatusOK
+}
+
+func readElection(ctx *server.Context, election *model.Election) *Election {
 	votes, err := ctx.Indexer.VotesByElection(ctx, election.RowId)
 	if err != nil {
 		switch err {
@@ -412,7 +422,7 @@ func ReadElection(ctx *server.Context) (interface{}, int) {
 			ee.AdoptionPeriod.Proposals = []*Proposal{winner}
 		}
 	}
-	return ee, http.StatusOK
+	return ee
 }
 
 type Voter struct {
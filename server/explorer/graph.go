@@ -0,0 +1,439 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+const maxGraphDepth = 3
+
+func init() {
+	server.Register(TransferGraph{})
+}
+
+var _ server.RESTful = (*TransferGraph)(nil)
+
+// Transfer edges with a height range are read from per-cycle edges, so
+// ranges are widened to full cycles. Responses report the effective range.
+const (
+	headerSinceHeight = "X-Since-Height"
+	headerUntilHeight = "X-Until-Height"
+)
+
+// cycleRange widens an exclusive since and inclusive until height to the
+// cycle boundaries used by per-cycle transfer edges. Zero heights are
+// unbounded.
+func cycleRange(ctx *server.Context, since, until int64) (int64, int64) {
+	if since > 0 {
+		p := ctx.Indexer.ParamsByHeight(since + 1)
+		since = p.CycleStartHeight(p.CycleFromHeight(since+1)) - 1
+	}
+	if until > 0 {
+		p := ctx.Indexer.ParamsByHeight(until)
+		until = util.Min64(p.CycleEndHeight(p.CycleFromHeight(until)), ctx.Tip.BestHeight)
+	}
+	return since, until
+}
+
+type CounterpartyRequest struct {
+	ListRequest // offset, limit, order (default desc)
+
+	Since string `schema:"since"` // block hash or height, rounded down to its cycle start
+	Until string `schema:"until"` // block hash or height, rounded up to its cycle end
+	Sort  string `schema:"sort"`  // volume (default), count, last
+
+	// decoded values, widened to cycle boundaries
+	SinceHeight int64 `schema:"-"`
+	UntilHeight int64 `schema:"-"`
+}
+
+func (r *CounterpartyRequest) Parse(ctx *server.Context) {
+	if len(r.Since) > 0 {
+		r.SinceHeight = parseBlockIdent(ctx, r.Since)
+	}
+	if len(r.Until) > 0 {
+		r.UntilHeight = parseBlockIdent(ctx, r.Until)
+	}
+	r.SinceHeight, r.UntilHeight = cycleRange(ctx, r.SinceHeight, r.UntilHeight)
+	switch r.Sort {
+	case "":
+		r.Sort = "volume"
+	case "volume", "count", "last":
+	default:
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid sort order '%s'", r.Sort), nil))
+	}
+}
+
+type Counterparty struct {
+	Address        tezos.Address `json:"address"`
+	NSent          int           `json:"n_sent"`
+	NReceived      int           `json:"n_received"`
+	VolumeSent     float64       `json:"volume_sent"`
+	VolumeReceived float64       `json:"volume_received"`
+	FirstHeight    int64         `json:"first_height"`
+	LastHeight     int64         `json:"last_height"`
+	FirstTime      time.Time     `json:"first_time"`
+	LastTime       time.Time     `json:"last_time"`
+
+	id model.AccountID
+}
+
+func (c *Counterparty) add(e *model.TransferEdge, sent bool, p *tezos.Params) {
+	if sent {
+		c.NSent += e.NTransfers
+		c.VolumeSent += p.ConvertValue(e.Volume)
+	} else {
+		c.NReceived += e.NTransfers
+		c.VolumeReceived += p.ConvertValue(e.Volume)
+	}
+	if c.FirstHeight == 0 || e.FirstHeight < c.FirstHeight {
+		c.FirstHeight = e.FirstHeight
+		c.FirstTime = e.FirstTime
+	}
+	if e.LastHeight > c.LastHeight {
+		c.LastHeight = e.LastHeight
+		c.LastTime = e.LastTime
+	}
+}
+
+type CounterpartyList struct {
+	list     []*Counterparty
+	modified time.Time
+	expires  time.Time
+}
+
+func (l CounterpartyList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l CounterpartyList) LastModified() time.Time      { return l.modified }
+func (l CounterpartyList) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*CounterpartyList)(nil)
+
+// list all accounts an account has sent to or received from
+func ListAccountCounterparties(ctx *server.Context) (interface{}, int) {
+	args := &CounterpartyRequest{}
+	ctx.ParseRequestArgs(args)
+	acc := loadAccount(ctx)
+
+	r := etl.ListRequest{
+		Since: args.SinceHeight,
+		Until: args.UntilHeight,
+	}
+	// fold pair or per-cycle edges into one entry per counterparty
+	parties := make(map[model.AccountID]*Counterparty)
+	list := make([]*Counterparty, 0)
+	get := func(id model.AccountID) *Counterparty {
+		c, ok := parties[id]
+		if !ok {
+			c = &Counterparty{id: id}
+			parties[id] = c
+			list = append(list, c)
+		}
+		return c
+	}
+	ids := []uint64{acc.RowId.Value()}
+	err := ctx.Indexer.StreamTransferEdges(ctx, ids, true, r, func(e *model.TransferEdge) error {
+		get(e.ReceiverId).add(e, true, ctx.Params)
+		return nil
+	})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read transfer edges", err))
+	}
+	err = ctx.Indexer.StreamTransferEdges(ctx, ids, false, r, func(e *model.TransferEdge) error {
+		get(e.SenderId).add(e, false, ctx.Params)
+		return nil
+	})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read transfer edges", err))
+	}
+
+	// sort descending unless ascending order is requested explicitly
+	desc := ctx.Request.URL.Query().Get("order") != "asc"
+	sort.SliceStable(list, func(i, j int) bool {
+		var less bool
+		switch args.Sort {
+		case "count":
+			less = list[i].NSent+list[i].NReceived < list[j].NSent+list[j].NReceived
+		case "last":
+			less = list[i].LastHeight < list[j].LastHeight
+		default:
+			less = list[i].VolumeSent+list[i].VolumeReceived < list[j].VolumeSent+list[j].VolumeReceived
+		}
+		if desc {
+			return !less
+		}
+		return less
+	})
+
+	// apply offset and limit
+	if int(args.Offset) >= len(list) {
+		list = list[:0]
+	} else {
+		list = list[args.Offset:]
+	}
+	if limit := int(ctx.Cfg.ClampExplore(args.Limit)); len(list) > limit {
+		list = list[:limit]
+	}
+	for _, c := range list {
+		c.Address = ctx.Indexer.LookupAddress(ctx, c.id)
+	}
+
+	// report the effective range since the list has no envelope
+	if args.SinceHeight > 0 {
+		ctx.ResponseWriter.Header().Set(headerSinceHeight, strconv.FormatInt(args.SinceHeight, 10))
+	}
+	if args.UntilHeight > 0 {
+		ctx.ResponseWriter.Header().Set(headerUntilHeight, strconv.FormatInt(args.UntilHeight, 10))
+	}
+	resp := &CounterpartyList{
+		list:     list,
+		modified: ctx.Indexer.LookupBlockTime(ctx.Context, acc.LastSeen),
+		expires:  ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	return resp, http.StatusOK
+}
+
+type GraphRequest struct {
+	From      tezos.Address `schema:"from"`       // start address
+	Depth     int           `schema:"depth"`      // max hops (1..3)
+	Direction string        `schema:"direction"`  // out (default), in, both
+	Since     string        `schema:"since"`      // block hash or height, rounded down to its cycle start
+	Until     string        `schema:"until"`      // block hash or height, rounded up to its cycle end
+	MinVolume float64       `schema:"min_volume"` // skip edges below this tez volume
+	Limit     uint          `schema:"limit"`      // max number of nodes
+
+	// decoded values, widened to cycle boundaries
+	SinceHeight int64 `schema:"-"`
+	UntilHeight int64 `schema:"-"`
+}
+
+func (r *GraphRequest) Parse(ctx *server.Context) {
+	if !r.From.IsValid() {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "missing or invalid from address", nil))
+	}
+	if r.Depth <= 0 {
+		r.Depth = 1
+	}
+	if r.Depth > maxGraphDepth {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("depth exceeds maximum of %d", maxGraphDepth), nil))
+	}
+	switch r.Direction {
+	case "":
+		r.Direction = "out"
+	case "out", "in", "both":
+	default:
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid direction '%s'", r.Direction), nil))
+	}
+	if len(r.Since) > 0 {
+		r.SinceHeight = parseBlockIdent(ctx, r.Since)
+	}
+	if len(r.Until) > 0 {
+		r.UntilHeight = parseBlockIdent(ctx, r.Until)
+	}
+	r.SinceHeight, r.UntilHeight = cycleRange(ctx, r.SinceHeight, r.UntilHeight)
+}
+
+type GraphNode struct {
+	Address tezos.Address `json:"address"`
+	Depth   int           `json:"depth"`
+}
+
+type GraphEdge struct {
+	From        tezos.Address `json:"from"`
+	To          tezos.Address `json:"to"`
+	NTransfers  int           `json:"n_transfers"`
+	Volume      float64       `json:"volume"`
+	FirstHeight int64         `json:"first_height"`
+	LastHeight  int64         `json:"last_height"`
+
+	from, to model.AccountID
+	volume   int64
+}
+
+type TransferGraph struct {
+	Nodes       []*GraphNode `json:"nodes"`
+	Edges       []*GraphEdge `json:"edges"`
+	Truncated   bool         `json:"truncated"`
+	SinceHeight int64        `json:"since_height,omitempty"` // effective range, exclusive
+	UntilHeight int64        `json:"until_height,omitempty"` // effective range, inclusive
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (g TransferGraph) LastModified() time.Time { return g.modified }
+func (g TransferGraph) Expires() time.Time      { return g.expires }
+
+var _ server.Resource = (*TransferGraph)(nil)
+
+func (g TransferGraph) RESTPrefix() string {
+	return "/explorer/graph"
+}
+
+func (g TransferGraph) RESTPath(r *mux.Router) string {
+	return g.RESTPrefix()
+}
+
+func (g TransferGraph) RegisterDirectRoutes(r *mux.Router) error {
+	if server.HasIndex(index.TransferEdgeIndexKey) {
+		r.HandleFunc(g.RESTPrefix(), server.C(ReadTransferGraph)).Methods("GET")
+	}
+	return nil
+}
+
+func (g TransferGraph) RegisterRoutes(r *mux.Router) error {
+	return nil
+}
+
+// ReadTransferGraph runs a bounded breadth-first search over transfer edges
+// starting at a single account. Only edges between discovered nodes are
+// returned. When the node limit is reached the search stops expanding and
+// the result is marked as truncated.
+func ReadTransferGraph(ctx *server.Context) (interface{}, int) {
+	args := &GraphRequest{}
+	ctx.ParseRequestArgs(args)
+
+	root, err := ctx.Indexer.LookupAccount(ctx, args.From)
+	if err != nil {
+		switch err {
+		case index.ErrNoAccountEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such account", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+
+	maxNodes := int(ctx.Cfg.ClampExplore(args.Limit))
+	r := etl.ListRequest{
+		Since: args.SinceHeight,
+		Until: args.UntilHeight,
+	}
+	minVolume := ctx.Params.ConvertAmount(args.MinVolume)
+
+	resp := &TransferGraph{
+		Nodes:       []*GraphNode{{Address: root.Address, Depth: 0}},
+		Edges:       make([]*GraphEdge, 0),
+		SinceHeight: args.SinceHeight,
+		UntilHeight: args.UntilHeight,
+		modified:    ctx.Tip.BestTime,
+		expires:     ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	depths := map[model.AccountID]int{root.RowId: 0}
+	type edgeKey struct{ from, to model.AccountID }
+	edges := make(map[edgeKey]*GraphEdge)
+	rows := make(map[uint64]struct{})
+	frontier := []uint64{root.RowId.Value()}
+
+	for depth := 1; depth <= args.Depth && len(frontier) > 0; depth++ {
+		next := make([]uint64, 0)
+		visit := func(e *model.TransferEdge) error {
+			// follow tez transfers only, skip plain contract calls
+			if e.Volume == 0 {
+				return nil
+			}
+			if _, ok := rows[e.RowId]; ok {
+				return nil
+			}
+			rows[e.RowId] = struct{}{}
+
+			// add the far end of the edge as new node
+			for _, id := range []model.AccountID{e.SenderId, e.ReceiverId} {
+				if _, ok := depths[id]; ok {
+					continue
+				}
+				if len(depths) >= maxNodes {
+					resp.Truncated = true
+					continue
+				}
+				depths[id] = depth
+				next = append(next, id.Value())
+			}
+			_, ok1 := depths[e.SenderId]
+			_, ok2 := depths[e.ReceiverId]
+			if !ok1 || !ok2 {
+				return nil
+			}
+
+			k := edgeKey{e.SenderId, e.ReceiverId}
+			ge, ok := edges[k]
+			if !ok {
+				ge = &GraphEdge{
+					from:        e.SenderId,
+					to:          e.ReceiverId,
+					FirstHeight: e.FirstHeight,
+				}
+				edges[k] = ge
+				resp.Edges = append(resp.Edges, ge)
+			}
+			ge.NTransfers += e.NTransfers
+			ge.volume += e.Volume
+			if e.FirstHeight < ge.FirstHeight {
+				ge.FirstHeight = e.FirstHeight
+			}
+			if e.LastHeight > ge.LastHeight {
+				ge.LastHeight = e.LastHeight
+			}
+			return nil
+		}
+		if args.Direction != "in" {
+			if err := ctx.Indexer.StreamTransferEdges(ctx, frontier, true, r, visit); err != nil {
+				panic(server.EInternal(server.EC_DATABASE, "cannot read transfer edges", err))
+			}
+		}
+		if args.Direction != "out" {
+			if err := ctx.Indexer.StreamTransferEdges(ctx, frontier, false, r, visit); err != nil {
+				panic(server.EInternal(server.EC_DATABASE, "cannot read transfer edges", err))
+			}
+		}
+		frontier = next
+	}
+
+	// drop edges below the volume threshold and resolve addresses
+	list := resp.Edges[:0]
+	for _, e := range resp.Edges {
+		if e.volume < minVolume {
+			continue
+		}
+		e.From = ctx.Indexer.LookupAddress(ctx, e.from)
+		e.To = ctx.Indexer.LookupAddress(ctx, e.to)
+		e.Volume = ctx.Params.ConvertValue(e.volume)
+		list = append(list, e)
+	}
+	resp.Edges = list
+
+	ids := make([]model.AccountID, 0, len(depths))
+	for id := range depths {
+		if id != root.RowId {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if depths[ids[i]] == depths[ids[j]] {
+			return ids[i] < ids[j]
+		}
+		return depths[ids[i]] < depths[ids[j]]
+	})
+	for _, id := range ids {
+		resp.Nodes = append(resp.Nodes, &GraphNode{
+			Address: ctx.Indexer.LookupAddress(ctx, id),
+			Depth:   depths[id],
+		})
+	}
+
+	return resp, http.StatusOK
+}
@@ -0,0 +1,916 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockwatch.cc/tzindex/server"
+)
+
+// max size of a GraphQL request body
+const maxGraphQLRequest = 256 * 1024
+
+func init() {
+	server.Register(GraphQL{})
+}
+
+var _ server.RESTful = (*GraphQL)(nil)
+
+// GraphQL serves explorer objects through a GraphQL query interface. Object
+// types mirror explorer API resources. Accounts, contracts, bakers, ops and
+// blocks are loaded with batch index lookups, references from list elements
+// are collected and loaded once per list. Other nested lists and objects are
+// fetched with the index lookups that back the REST API. The schema can be
+// queried with `__schema` and `__type` introspection.
+type GraphQL struct{}
+
+func (g GraphQL) LastModified() time.Time {
+	return time.Time{}
+}
+
+func (g GraphQL) Expires() time.Time {
+	return time.Time{}
+}
+
+func (g GraphQL) RESTPrefix() string {
+	return "/explorer/graphql"
+}
+
+func (g GraphQL) RESTPath(r *mux.Router) string {
+	return g.RESTPrefix()
+}
+
+func (g GraphQL) RegisterDirectRoutes(r *mux.Router) error {
+	r.HandleFunc(g.RESTPrefix(), server.C(QueryGraphQL)).Methods("GET", "POST")
+	return nil
+}
+
+func (g GraphQL) RegisterRoutes(r *mux.Router) error {
+	return nil
+}
+
+type GraphQLRequest struct {
+	Query         string                 `schema:"query"         json:"query"`
+	OperationName string                 `schema:"operationName" json:"operationName"`
+	Variables     map[string]interface{} `schema:"-"             json:"variables"`
+}
+
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// gqlResolver loads a nested object or list with a batch loader or a
+// fetcher. Route variables are taken from arguments (`$name`) or fields of
+// the parent object, loaders use the `ident` variable. Remaining arguments
+// are passed as URL query.
+type gqlResolver struct {
+	Type   string
+	List   bool
+	Load   gqlLoader
+	Fetch  gqlFetcher
+	Vars   map[string]string
+	Fanout string // list argument resolved per element
+}
+
+type gqlType struct {
+	name      string
+	fields    map[string]bool         // JSON fields, nil for free-form objects
+	types     map[string]reflect.Type // Go types of JSON fields
+	nested    map[string]string       // JSON fields holding typed objects
+	resolvers map[string]*gqlResolver
+}
+
+var gqlSchema map[string]*gqlType
+
+func init() {
+	ident := func(src string) map[string]string { return map[string]string{"ident": src} }
+	gqlSchema = map[string]*gqlType{
+		"Query": {
+			name: "Query",
+			resolvers: map[string]*gqlResolver{
+				"tip":      {Type: "Tip", Fetch: gqlFetchTip},
+				"account":  {Type: "Account", Load: gqlLoadAccounts, Vars: ident("$address")},
+				"accounts": {Type: "Account", List: true, Load: gqlLoadAccounts, Vars: ident("$addresses"), Fanout: "addresses"},
+				"baker":    {Type: "Baker", Load: gqlLoadBakers, Vars: ident("$address")},
+				"bakers":   {Type: "Baker", List: true, Fetch: gqlFetchBakers},
+				"contract": {Type: "Contract", Load: gqlLoadContracts, Vars: ident("$address")},
+				"op":       {Type: "Op", List: true, Load: gqlLoadOps, Vars: ident("$hash")},
+				"block":    {Type: "Block", Load: gqlLoadBlocks, Vars: ident("$ident")},
+				"bigmap":   {Type: "Bigmap", Fetch: gqlFetchBigmap, Vars: map[string]string{"id": "$id"}},
+				"cycle":    {Type: "Cycle", Fetch: gqlFetchCycle, Vars: map[string]string{"cycle": "$id"}},
+				"election": {Type: "Election", Fetch: gqlFetchElection, Vars: ident("$id")},
+				"metadata": {Type: "Metadata", Fetch: gqlFetchMetadata, Vars: map[string]string{"ident": "$address", "asset_id": "$asset_id?"}},
+			},
+		},
+		"Tip": newGqlType("Tip", BlockchainTip{}, nil),
+		"Account": newGqlType("Account", Account{}, map[string]*gqlResolver{
+			"operations": {Type: "Op", List: true, Fetch: gqlFetchAccountOperations, Vars: ident("address")},
+			"contracts":  {Type: "Contract", List: true, Fetch: gqlFetchDeployedContracts, Vars: ident("address")},
+			"metadata":   {Type: "Metadata", Fetch: gqlFetchMetadata, Vars: ident("address")},
+			"baker":      {Type: "Baker", Load: gqlLoadBakers, Vars: ident("baker")},
+			"creator":    {Type: "Account", Load: gqlLoadAccounts, Vars: ident("creator")},
+			"contract":   {Type: "Contract", Load: gqlLoadContracts, Vars: ident("address")},
+		}),
+		"Baker": newGqlType("Baker", Baker{}, map[string]*gqlResolver{
+			"account":     {Type: "Account", Load: gqlLoadAccounts, Vars: ident("address")},
+			"delegations": {Type: "Op", List: true, Fetch: gqlFetchBakerDelegations, Vars: ident("address")},
+			"metadata":    {Type: "Metadata", Fetch: gqlFetchMetadata, Vars: ident("address")},
+		}),
+		"Contract": newGqlType("Contract", Contract{}, map[string]*gqlResolver{
+			"account":  {Type: "Account", Load: gqlLoadAccounts, Vars: ident("address")},
+			"creator":  {Type: "Account", Load: gqlLoadAccounts, Vars: ident("creator")},
+			"baker":    {Type: "Baker", Load: gqlLoadBakers, Vars: ident("baker")},
+			"calls":    {Type: "Op", List: true, Fetch: gqlFetchContractCalls, Vars: ident("address")},
+			"storage":  {Type: "", Fetch: gqlFetchContractStorage, Vars: ident("address")},
+			"metadata": {Type: "Metadata", Fetch: gqlFetchMetadata, Vars: ident("address")},
+		}),
+		"Op": newGqlType("Op", Op{}, map[string]*gqlResolver{
+			"sender":   {Type: "Account", Load: gqlLoadAccounts, Vars: ident("sender")},
+			"receiver": {Type: "Account", Load: gqlLoadAccounts, Vars: ident("receiver")},
+			"creator":  {Type: "Account", Load: gqlLoadAccounts, Vars: ident("creator")},
+			"baker":    {Type: "Baker", Load: gqlLoadBakers, Vars: ident("baker")},
+			"block":    {Type: "Block", Load: gqlLoadBlocks, Vars: ident("block")},
+		}),
+		"Block": newGqlType("Block", Block{}, map[string]*gqlResolver{
+			"operations": {Type: "Op", List: true, Fetch: gqlFetchBlockOps, Vars: ident("hash")},
+			"baker":      {Type: "Baker", Load: gqlLoadBakers, Vars: ident("baker")},
+			"proposer":   {Type: "Baker", Load: gqlLoadBakers, Vars: ident("proposer")},
+			"cycle":      {Type: "Cycle", Fetch: gqlFetchCycle, Vars: map[string]string{"cycle": "cycle"}},
+		}),
+		"Bigmap": newGqlType("Bigmap", Bigmap{}, map[string]*gqlResolver{
+			"contract": {Type: "Contract", Load: gqlLoadContracts, Vars: ident("contract")},
+			"keys":     {Type: "", List: true, Fetch: gqlFetchBigmapKeys, Vars: map[string]string{"id": "bigmap_id"}},
+			"values":   {Type: "", List: true, Fetch: gqlFetchBigmapValues, Vars: map[string]string{"id": "bigmap_id"}},
+			"updates":  {Type: "", List: true, Fetch: gqlFetchBigmapUpdates, Vars: map[string]string{"id": "bigmap_id"}},
+		}),
+		"Cycle":    newGqlType("Cycle", Cycle{}, nil),
+		"Election": newGqlType("Election", Election{}, nil),
+		"Metadata": {name: "Metadata"},
+	}
+	gqlSchema["Op"].nested = map[string]string{"batch": "Op", "internal": "Op"}
+}
+
+func newGqlType(name string, proto interface{}, resolvers map[string]*gqlResolver) *gqlType {
+	types := jsonFieldTypes(reflect.TypeOf(proto))
+	fields := make(map[string]bool, len(types))
+	for k := range types {
+		fields[k] = true
+	}
+	return &gqlType{
+		name:      name,
+		fields:    fields,
+		types:     types,
+		resolvers: resolvers,
+	}
+}
+
+// jsonFieldTypes lists JSON keys of a struct type including embedded structs.
+func jsonFieldTypes(typ reflect.Type) map[string]reflect.Type {
+	names := make(map[string]reflect.Type)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFieldTypes(ft) {
+					names[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names[name] = f.Type
+	}
+	return names
+}
+
+func QueryGraphQL(ctx *server.Context) (interface{}, int) {
+	args := &GraphQLRequest{}
+	if ctx.Request.Method == http.MethodGet {
+		ctx.ParseRequestArgs(args)
+		if v := ctx.Request.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &args.Variables); err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid variables", err))
+			}
+		}
+	} else {
+		buf, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxGraphQLRequest+1))
+		if err != nil {
+			panic(server.EBadRequest(server.EC_DEMARSHAL_FAILED, "cannot read request", err))
+		}
+		if len(buf) > maxGraphQLRequest {
+			panic(server.ERequestTooLarge(server.EC_PARAM_INVALID, "request too large", nil))
+		}
+		if strings.HasPrefix(ctx.Request.Header.Get("Content-Type"), "application/graphql") {
+			args.Query = string(buf)
+		} else {
+			dec := json.NewDecoder(bytes.NewReader(buf))
+			dec.UseNumber()
+			if err := dec.Decode(args); err != nil {
+				panic(server.EBadRequest(server.EC_DEMARSHAL_FAILED, err.Error(), nil))
+			}
+		}
+	}
+	if args.Query == "" {
+		panic(server.EBadRequest(server.EC_PARAM_REQUIRED, "missing query", nil))
+	}
+
+	doc, err := parseGraphQL(args.Query)
+	if err != nil {
+		return &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}, http.StatusOK
+	}
+	op, err := doc.operation(args.OperationName)
+	if err != nil {
+		return &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}, http.StatusOK
+	}
+
+	g := &gqlExec{
+		ctx:   ctx,
+		doc:   doc,
+		vars:  make(map[string]interface{}),
+		cache: make(map[string]interface{}),
+	}
+	for k, v := range op.Variables {
+		g.vars[k] = v
+	}
+	for k, v := range args.Variables {
+		g.vars[k] = gqlNormalize(v)
+	}
+
+	// validate fields and limit query cost before running any resolver
+	cost, err := g.check(gqlSchema["Query"], op.Selection, 1, 1)
+	if err == nil {
+		if max := int64(ctx.Cfg.Http.MaxGraphQLCost); max > 0 && cost > max {
+			err = fmt.Errorf("query complexity %d exceeds limit %d", cost, max)
+		}
+	}
+	if err != nil {
+		return &GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}}, http.StatusOK
+	}
+
+	data := g.object(gqlSchema["Query"], nil, op.Selection, nil)
+	return &GraphQLResponse{Data: data, Errors: g.errors}, http.StatusOK
+}
+
+func (d *gqlDocument) operation(name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(d.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with multiple operations")
+		}
+		return d.Operations[0], nil
+	}
+	for _, v := range d.Operations {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation '%s'", name)
+}
+
+// gqlNormalize converts JSON decoded variables into query values.
+func gqlNormalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case float64:
+		if x == float64(int64(x)) {
+			return int64(x)
+		}
+		return x
+	case []interface{}:
+		for i := range x {
+			x[i] = gqlNormalize(x[i])
+		}
+		return x
+	case map[string]interface{}:
+		for k := range x {
+			x[k] = gqlNormalize(x[k])
+		}
+		return x
+	}
+	return v
+}
+
+type gqlExec struct {
+	ctx    *server.Context
+	doc    *gqlDocument
+	vars   map[string]interface{}
+	cache  map[string]interface{} // loaded objects by call
+	errors []GraphQLError
+}
+
+// gqlObject is a result object that keeps fields in query order.
+type gqlObject struct {
+	keys []string
+	vals map[string]interface{}
+}
+
+func (o *gqlObject) set(k string, v interface{}) {
+	if _, ok := o.vals[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.vals[k] = v
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(strconv.Quote(k))
+		buf.WriteByte(':')
+		val, err := json.Marshal(o.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// value replaces variables in argument values.
+func (g *gqlExec) value(v interface{}) interface{} {
+	switch x := v.(type) {
+	case gqlVariable:
+		return g.vars[string(x)]
+	case gqlEnum:
+		return string(x)
+	case []interface{}:
+		res := make([]interface{}, len(x))
+		for i := range x {
+			res[i] = g.value(x[i])
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(x))
+		for k := range x {
+			res[k] = g.value(x[k])
+		}
+		return res
+	}
+	return v
+}
+
+func (g *gqlExec) include(dirs []gqlDirective) bool {
+	for _, d := range dirs {
+		cond, _ := g.value(d.Args["if"]).(bool)
+		switch d.Name {
+		case "skip":
+			if cond {
+				return false
+			}
+		case "include":
+			if !cond {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collect flattens fragments and merges fields with the same response key.
+func (g *gqlExec) collect(typ *gqlType, sel []*gqlSelection, visited map[string]bool) ([]*gqlSelection, error) {
+	var (
+		res   []*gqlSelection
+		byKey = make(map[string]*gqlSelection)
+	)
+	var walk func(sel []*gqlSelection) error
+	walk = func(sel []*gqlSelection) error {
+		for _, s := range sel {
+			if !g.include(s.Directives) {
+				continue
+			}
+			switch {
+			case s.Fragment != "":
+				f, ok := g.doc.Fragments[s.Fragment]
+				if !ok {
+					return fmt.Errorf("unknown fragment '%s'", s.Fragment)
+				}
+				if visited[f.Name] {
+					return fmt.Errorf("fragment '%s' contains a cycle", f.Name)
+				}
+				if typ != nil && f.On != typ.name {
+					continue
+				}
+				visited[f.Name] = true
+				err := walk(f.Selection)
+				delete(visited, f.Name)
+				if err != nil {
+					return err
+				}
+			case s.Field == "":
+				if s.On != "" && typ != nil && s.On != typ.name {
+					continue
+				}
+				if err := walk(s.Selection); err != nil {
+					return err
+				}
+			default:
+				if prev, ok := byKey[s.Key()]; ok {
+					if prev.Field != s.Field {
+						return fmt.Errorf("fields '%s' and '%s' conflict on response key '%s'", prev.Field, s.Field, s.Key())
+					}
+					prev.Selection = append(prev.Selection, s.Selection...)
+					continue
+				}
+				c := *s
+				c.Selection = append([]*gqlSelection(nil), s.Selection...)
+				byKey[s.Key()] = &c
+				res = append(res, &c)
+			}
+		}
+		return nil
+	}
+	err := walk(sel)
+	return res, err
+}
+
+// check validates a selection against the schema and returns its estimated
+// cost as number of objects loaded by resolvers.
+func (g *gqlExec) check(typ *gqlType, sel []*gqlSelection, depth int, mult int64) (int64, error) {
+	if max := g.ctx.Cfg.Http.MaxGraphQLDepth; max > 0 && depth > max {
+		return 0, fmt.Errorf("query depth exceeds limit %d", max)
+	}
+	fields, err := g.collect(typ, sel, make(map[string]bool))
+	if err != nil {
+		return 0, err
+	}
+	var cost int64
+	for _, f := range fields {
+		if f.Field == "__typename" {
+			continue
+		}
+		if typ.name == "Query" && (f.Field == "__schema" || f.Field == "__type") {
+			continue
+		}
+		res, hasResolver := typ.resolvers[f.Field]
+		isField := typ.fields == nil || typ.fields[f.Field]
+		switch {
+		case hasResolver && (len(f.Selection) > 0 || !isField):
+			if len(f.Selection) == 0 && res.Type != "" {
+				return 0, fmt.Errorf("field '%s' of type '%s' must have a selection of subfields", f.Field, res.Type)
+			}
+			calls := mult
+			if res.Fanout != "" {
+				list, _ := g.value(f.Args[res.Fanout]).([]interface{})
+				calls *= int64(len(list))
+			}
+			n := calls
+			if res.List && res.Fanout == "" {
+				limit, _ := g.value(f.Args["limit"]).(int64)
+				n *= int64(g.ctx.Cfg.ClampExplore(uint(limit)))
+			}
+			cost += calls
+			if rtyp := gqlSchema[res.Type]; rtyp != nil && rtyp.fields != nil {
+				c, err := g.check(rtyp, f.Selection, depth+1, n)
+				if err != nil {
+					return 0, err
+				}
+				cost += c
+			}
+		case isField:
+			if name, ok := typ.nested[f.Field]; ok && len(f.Selection) > 0 {
+				c, err := g.check(gqlSchema[name], f.Selection, depth+1, mult)
+				if err != nil {
+					return 0, err
+				}
+				cost += c
+			}
+		default:
+			return 0, fmt.Errorf("cannot query field '%s' on type '%s'", f.Field, typ.name)
+		}
+	}
+	return cost, nil
+}
+
+func (g *gqlExec) fail(path []interface{}, err error) {
+	g.errors = append(g.errors, GraphQLError{
+		Message: err.Error(),
+		Path:    append([]interface{}(nil), path...),
+	})
+}
+
+func (g *gqlExec) object(typ *gqlType, val map[string]interface{}, sel []*gqlSelection, path []interface{}) *gqlObject {
+	obj := &gqlObject{vals: make(map[string]interface{})}
+	fields, err := g.collect(typ, sel, make(map[string]bool))
+	if err != nil {
+		g.fail(path, err)
+		return nil
+	}
+	for _, f := range fields {
+		p := append(path, f.Key())
+		if f.Field == "__typename" {
+			if typ != nil {
+				obj.set(f.Key(), typ.name)
+			} else {
+				obj.set(f.Key(), "Object")
+			}
+			continue
+		}
+		if typ != nil && typ.name == "Query" {
+			switch f.Field {
+			case "__schema":
+				obj.set(f.Key(), g.complete(nil, gqlIntrospectSchema(), f.Selection, p))
+				continue
+			case "__type":
+				obj.set(f.Key(), g.complete(nil, gqlIntrospectType(gqlString(g.value(f.Args["name"]))), f.Selection, p))
+				continue
+			}
+		}
+		var (
+			res *gqlResolver
+			ok  bool
+		)
+		if typ != nil {
+			res, ok = typ.resolvers[f.Field]
+		}
+		raw, isField := val[f.Field]
+		if ok && (len(f.Selection) > 0 || !isField) {
+			obj.set(f.Key(), g.resolve(res, val, f, p))
+			continue
+		}
+		var nested *gqlType
+		if typ != nil {
+			nested = gqlSchema[typ.nested[f.Field]]
+		}
+		obj.set(f.Key(), g.complete(nested, raw, f.Selection, p))
+	}
+	return obj
+}
+
+// complete applies a sub-selection to a loaded JSON value.
+func (g *gqlExec) complete(typ *gqlType, val interface{}, sel []*gqlSelection, path []interface{}) interface{} {
+	if len(sel) == 0 || val == nil {
+		return val
+	}
+	switch x := val.(type) {
+	case map[string]interface{}:
+		return g.object(typ, x, sel, path)
+	case []interface{}:
+		g.prefetch(typ, x, sel)
+		res := make([]interface{}, len(x))
+		for i, v := range x {
+			res[i] = g.complete(typ, v, sel, append(path, i))
+		}
+		return res
+	default:
+		g.fail(path, fmt.Errorf("field '%s' has no subfields", path[len(path)-1]))
+		return nil
+	}
+}
+
+func (g *gqlExec) resolve(res *gqlResolver, parent map[string]interface{}, f *gqlSelection, path []interface{}) interface{} {
+	args := make(map[string]interface{}, len(f.Args))
+	for k, v := range f.Args {
+		args[k] = g.value(v)
+	}
+
+	// route variables from arguments or parent fields
+	vars := make(map[string]string)
+	var fanout []interface{}
+	for k, src := range res.Vars {
+		if !strings.HasPrefix(src, "$") {
+			s := gqlString(parent[src])
+			if s == "" {
+				return nil
+			}
+			vars[k] = s
+			continue
+		}
+		name := strings.TrimPrefix(src, "$")
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		v, ok := args[name]
+		delete(args, name)
+		if !ok || v == nil {
+			if optional {
+				continue
+			}
+			g.fail(path, fmt.Errorf("missing argument '%s'", name))
+			return nil
+		}
+		if name == res.Fanout {
+			list, ok := v.([]interface{})
+			if !ok {
+				g.fail(path, fmt.Errorf("argument '%s' must be a list", name))
+				return nil
+			}
+			fanout = list
+			vars[k] = ""
+			continue
+		}
+		vars[k] = gqlString(v)
+	}
+
+	// remaining arguments are passed as query parameters
+	query, err := gqlQuery(args)
+	if err != nil {
+		g.fail(path, err)
+		return nil
+	}
+
+	typ := gqlSchema[res.Type]
+	if res.Load != nil {
+		idents := []string{vars["ident"]}
+		if res.Fanout != "" {
+			idents = make([]string, len(fanout))
+			for i, item := range fanout {
+				idents[i] = gqlString(item)
+			}
+		}
+		vals, err := g.load(res, idents, query)
+		if err != nil {
+			g.fail(path, err)
+			return nil
+		}
+		if res.Fanout != "" {
+			list := make([]interface{}, len(idents))
+			for i, id := range idents {
+				list[i] = g.complete(typ, vals[id], f.Selection, append(path, i))
+			}
+			return list
+		}
+		val := vals[idents[0]]
+		if val == nil && res.List {
+			return []interface{}{}
+		}
+		return g.complete(typ, val, f.Selection, path)
+	}
+
+	if res.Fanout != "" {
+		list := make([]interface{}, 0, len(fanout))
+		for i, item := range fanout {
+			for k, src := range res.Vars {
+				if strings.TrimPrefix(src, "$") == res.Fanout {
+					vars[k] = gqlString(item)
+				}
+			}
+			p := append(path, i)
+			val, err := g.fetch(res.Fetch, vars, query)
+			if err != nil {
+				g.fail(p, err)
+			}
+			list = append(list, g.complete(typ, val, f.Selection, p))
+		}
+		return list
+	}
+
+	val, err := g.fetch(res.Fetch, vars, query)
+	if err != nil {
+		g.fail(path, err)
+		return nil
+	}
+	if val == nil && res.List {
+		return []interface{}{}
+	}
+	return g.complete(typ, val, f.Selection, path)
+}
+
+func gqlString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case []interface{}:
+		s := make([]string, len(x))
+		for i := range x {
+			s[i] = gqlString(x[i])
+		}
+		return strings.Join(s, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// gqlQuery converts arguments into URL query parameters.
+func gqlQuery(args map[string]interface{}) (url.Values, error) {
+	query := make(url.Values)
+	for k, v := range args {
+		if v == nil {
+			continue
+		}
+		if _, ok := v.(map[string]interface{}); ok {
+			return nil, fmt.Errorf("unsupported object argument '%s'", k)
+		}
+		query.Set(k, gqlString(v))
+	}
+	return query, nil
+}
+
+// gqlJSON converts an API result into its generic JSON representation.
+func gqlJSON(res interface{}) (interface{}, error) {
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var val interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// gqlRecover converts a handler panic into an error. Not found errors
+// return nil.
+func gqlRecover(e interface{}) error {
+	switch x := e.(type) {
+	case *server.Error:
+		if x.Status == http.StatusNotFound {
+			return nil
+		}
+		if x.Detail != "" {
+			return fmt.Errorf("%s: %s", x.Message, x.Detail)
+		}
+		return fmt.Errorf("%s", x.Message)
+	case error:
+		return x
+	default:
+		return fmt.Errorf("%v", x)
+	}
+}
+
+// load returns objects for identifiers from the request cache and loads
+// missing objects with the resolver's batch loader. Loads are split into
+// batches of at most server.max_batch_size identifiers.
+func (g *gqlExec) load(res *gqlResolver, idents []string, query url.Values) (map[string]interface{}, error) {
+	prefix := fmt.Sprintf("%x?%s#", reflect.ValueOf(res.Load).Pointer(), query.Encode())
+	vals := make(map[string]interface{}, len(idents))
+	missing := make([]string, 0, len(idents))
+	for _, id := range idents {
+		if _, ok := vals[id]; ok {
+			continue
+		}
+		v, ok := g.cache[prefix+id]
+		if !ok {
+			missing = append(missing, id)
+		}
+		vals[id] = v
+	}
+	batch := g.ctx.Cfg.Http.MaxBatchSize
+	if batch <= 0 {
+		batch = len(missing)
+	}
+	for len(missing) > 0 {
+		n := batch
+		if n > len(missing) {
+			n = len(missing)
+		}
+		loaded, err := g.loadBatch(res.Load, missing[:n], query)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing[:n] {
+			val, err := gqlJSON(loaded[id])
+			if err != nil {
+				return nil, err
+			}
+			g.cache[prefix+id] = val
+			vals[id] = val
+		}
+		missing = missing[n:]
+	}
+	return vals, nil
+}
+
+func (g *gqlExec) loadBatch(fn gqlLoader, idents []string, query url.Values) (res map[string]interface{}, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			res, err = nil, gqlRecover(e)
+		}
+	}()
+	return fn(g.ctx, idents, query)
+}
+
+// prefetch batch loads objects referenced by parent fields of all list
+// elements, so that resolving single elements is served from cache. Load
+// errors are reported when elements are resolved.
+func (g *gqlExec) prefetch(typ *gqlType, list []interface{}, sel []*gqlSelection) {
+	if typ == nil || len(typ.resolvers) == 0 || len(list) < 2 {
+		return
+	}
+	fields, err := g.collect(typ, sel, make(map[string]bool))
+	if err != nil {
+		return
+	}
+	for _, f := range fields {
+		res, ok := typ.resolvers[f.Field]
+		if !ok || res.Load == nil || (len(f.Selection) == 0 && typ.fields[f.Field]) {
+			continue
+		}
+		src := res.Vars["ident"]
+		if strings.HasPrefix(src, "$") {
+			continue
+		}
+		args := make(map[string]interface{}, len(f.Args))
+		for k, v := range f.Args {
+			args[k] = g.value(v)
+		}
+		query, err := gqlQuery(args)
+		if err != nil {
+			continue
+		}
+		idents := make([]string, 0, len(list))
+		for _, v := range list {
+			if obj, ok := v.(map[string]interface{}); ok {
+				if id := gqlString(obj[src]); id != "" {
+					idents = append(idents, id)
+				}
+			}
+		}
+		if len(idents) > 0 {
+			_, _ = g.load(res, idents, query)
+		}
+	}
+}
+
+// fetch runs a fetcher and returns its JSON result. Results are cached for
+// the duration of the request so that repeated references to the same
+// object load it only once. Missing objects resolve to null.
+func (g *gqlExec) fetch(fn gqlFetcher, vars map[string]string, query url.Values) (val interface{}, err error) {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%x?", reflect.ValueOf(fn).Pointer())
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(vars[k]))
+		b.WriteByte('&')
+	}
+	b.WriteString(query.Encode())
+	key := b.String()
+	if v, ok := g.cache[key]; ok {
+		return v, nil
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			val, err = nil, gqlRecover(e)
+			if err == nil {
+				g.cache[key] = nil
+			}
+		}
+	}()
+
+	if val, err = gqlJSON(fn(g.ctx, vars, query)); err != nil {
+		return nil, err
+	}
+	g.cache[key] = val
+	return val, nil
+}
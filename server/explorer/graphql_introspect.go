@@ -0,0 +1,276 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Introspection results are built once from gqlSchema and served by the
+// `__schema` and `__type` fields on Query. Struct fields are typed from their
+// Go types, values with custom JSON encoding and free-form maps use the JSON
+// scalar. Identifier arguments use ID which accepts strings and integers.
+var (
+	gqlIntrospectOnce  sync.Once
+	gqlIntrospectTypes map[string]map[string]interface{}
+	gqlIntrospectRoot  map[string]interface{}
+)
+
+var (
+	gqlTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	gqlJSONMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	gqlTimeType      = reflect.TypeOf(time.Time{})
+)
+
+var gqlScalars = map[string]string{
+	"String":  "",
+	"Int":     "",
+	"Float":   "",
+	"Boolean": "",
+	"ID":      "",
+	"JSON":    "Arbitrary JSON value.",
+}
+
+// list arguments accepted by resolvers that fetch lists
+var gqlListArgs = map[string]string{
+	"limit":  "Int",
+	"offset": "Int",
+	"cursor": "Int",
+	"order":  "String",
+}
+
+func gqlIntrospectSchema() interface{} {
+	gqlIntrospectOnce.Do(gqlBuildIntrospection)
+	return gqlIntrospectRoot
+}
+
+func gqlIntrospectType(name string) interface{} {
+	gqlIntrospectOnce.Do(gqlBuildIntrospection)
+	if t, ok := gqlIntrospectTypes[name]; ok {
+		return t
+	}
+	return nil
+}
+
+func gqlBuildIntrospection() {
+	gqlIntrospectTypes = make(map[string]map[string]interface{})
+	for name, desc := range gqlScalars {
+		t := gqlTypeDef("SCALAR", name, desc)
+		gqlIntrospectTypes[name] = t
+	}
+	for name, typ := range gqlSchema {
+		desc := ""
+		if typ.fields == nil && name != "Query" {
+			desc = "Free-form object, any key may be selected."
+		}
+		t := gqlTypeDef("OBJECT", name, desc)
+		t["fields"] = gqlFieldDefs(typ)
+		t["interfaces"] = []interface{}{}
+		gqlIntrospectTypes[name] = t
+	}
+
+	names := make([]string, 0, len(gqlIntrospectTypes))
+	for k := range gqlIntrospectTypes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	types := make([]interface{}, len(names))
+	for i, v := range names {
+		types[i] = gqlIntrospectTypes[v]
+	}
+
+	cond := gqlInputDef("if", gqlNonNull(gqlNamed("SCALAR", "Boolean")))
+	directives := make([]interface{}, 0, 2)
+	for _, v := range []string{"include", "skip"} {
+		directives = append(directives, map[string]interface{}{
+			"name":         v,
+			"description":  nil,
+			"isRepeatable": false,
+			"locations":    []interface{}{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+			"args":         []interface{}{cond},
+		})
+	}
+
+	gqlIntrospectRoot = map[string]interface{}{
+		"description":      nil,
+		"queryType":        map[string]interface{}{"name": "Query", "kind": "OBJECT"},
+		"mutationType":     nil,
+		"subscriptionType": nil,
+		"types":            types,
+		"directives":       directives,
+	}
+}
+
+func gqlTypeDef(kind, name, desc string) map[string]interface{} {
+	t := map[string]interface{}{
+		"kind":           kind,
+		"name":           name,
+		"description":    nil,
+		"specifiedByURL": nil,
+		"fields":         nil,
+		"inputFields":    nil,
+		"interfaces":     nil,
+		"enumValues":     nil,
+		"possibleTypes":  nil,
+		"ofType":         nil,
+	}
+	if desc != "" {
+		t["description"] = desc
+	}
+	return t
+}
+
+func gqlFieldDefs(typ *gqlType) []interface{} {
+	defs := make(map[string]map[string]interface{})
+	for name, ft := range typ.types {
+		var ref map[string]interface{}
+		if nested, ok := typ.nested[name]; ok {
+			ref = gqlGoTypeRef(ft, nested)
+		} else {
+			ref = gqlGoTypeRef(ft, "")
+		}
+		defs[name] = gqlFieldDef(name, ref, nil)
+	}
+	for name, res := range typ.resolvers {
+		var ref map[string]interface{}
+		if res.Type == "" {
+			ref = gqlNamed("SCALAR", "JSON")
+		} else {
+			ref = gqlNamed("OBJECT", res.Type)
+		}
+		if res.List {
+			ref = gqlList(ref)
+		}
+		defs[name] = gqlFieldDef(name, ref, gqlArgDefs(res))
+	}
+	names := make([]string, 0, len(defs))
+	for k := range defs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	fields := make([]interface{}, len(names))
+	for i, v := range names {
+		fields[i] = defs[v]
+	}
+	return fields
+}
+
+// gqlArgDefs lists arguments read from route variables. List resolvers also
+// accept list arguments, other arguments are passed on as query parameters.
+func gqlArgDefs(res *gqlResolver) []interface{} {
+	args := make(map[string]map[string]interface{})
+	for _, src := range res.Vars {
+		if !strings.HasPrefix(src, "$") {
+			continue
+		}
+		name := strings.TrimPrefix(src, "$")
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		ref := gqlNamed("SCALAR", "ID")
+		if name == res.Fanout {
+			ref = gqlList(gqlNonNull(ref))
+		}
+		if !optional {
+			ref = gqlNonNull(ref)
+		}
+		args[name] = gqlInputDef(name, ref)
+	}
+	if res.List && res.Fanout == "" && res.Fetch != nil {
+		for name, typ := range gqlListArgs {
+			args[name] = gqlInputDef(name, gqlNamed("SCALAR", typ))
+		}
+	}
+	names := make([]string, 0, len(args))
+	for k := range args {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	list := make([]interface{}, len(names))
+	for i, v := range names {
+		list[i] = args[v]
+	}
+	return list
+}
+
+func gqlFieldDef(name string, ref map[string]interface{}, args []interface{}) map[string]interface{} {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return map[string]interface{}{
+		"name":              name,
+		"description":       nil,
+		"args":              args,
+		"type":              ref,
+		"isDeprecated":      false,
+		"deprecationReason": nil,
+	}
+}
+
+func gqlInputDef(name string, ref map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":              name,
+		"description":       nil,
+		"type":              ref,
+		"defaultValue":      nil,
+		"isDeprecated":      false,
+		"deprecationReason": nil,
+	}
+}
+
+func gqlNamed(kind, name string) map[string]interface{} {
+	return map[string]interface{}{"kind": kind, "name": name, "ofType": nil}
+}
+
+func gqlList(ref map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"kind": "LIST", "name": nil, "ofType": ref}
+}
+
+func gqlNonNull(ref map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"kind": "NON_NULL", "name": nil, "ofType": ref}
+}
+
+// gqlGoTypeRef maps a Go type to a type reference. Named object types are
+// used for the elements of nested fields.
+func gqlGoTypeRef(typ reflect.Type, object string) map[string]interface{} {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == gqlTimeType:
+		return gqlNamed("SCALAR", "String")
+	case typ.Implements(gqlJSONMarshaler) || reflect.PtrTo(typ).Implements(gqlJSONMarshaler):
+		return gqlNamed("SCALAR", "JSON")
+	case typ.Implements(gqlTextMarshaler) || reflect.PtrTo(typ).Implements(gqlTextMarshaler):
+		return gqlNamed("SCALAR", "String")
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return gqlNamed("SCALAR", "String")
+		}
+		return gqlList(gqlGoTypeRef(typ.Elem(), object))
+	}
+	if object != "" {
+		return gqlNamed("OBJECT", object)
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return gqlNamed("SCALAR", "String")
+	case reflect.Bool:
+		return gqlNamed("SCALAR", "Boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gqlNamed("SCALAR", "Int")
+	case reflect.Float32, reflect.Float64:
+		return gqlNamed("SCALAR", "Float")
+	default:
+		return gqlNamed("SCALAR", "JSON")
+	}
+}
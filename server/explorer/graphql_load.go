@@ -0,0 +1,290 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/vec"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// gqlLoader loads objects for a list of unique identifiers with batch
+// lookups and returns explorer objects by identifier. Unknown identifiers
+// are omitted. Loaders may panic with server errors like API handlers.
+type gqlLoader func(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error)
+
+func gqlAddresses(idents []string) ([]tezos.Address, error) {
+	addrs := make([]tezos.Address, len(idents))
+	for i, v := range idents {
+		a, err := tezos.ParseAddress(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address '%s'", v)
+		}
+		addrs[i] = a
+	}
+	return addrs, nil
+}
+
+func gqlLoadAccounts(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error) {
+	args := &AccountRequest{}
+	ctx.ParseQueryArgs(args, query)
+	addrs, err := gqlAddresses(idents)
+	if err != nil {
+		return nil, err
+	}
+	accs, err := ctx.Indexer.LookupAccounts(ctx, addrs)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{}, len(accs))
+	for _, v := range accs {
+		res[v.Address.String()] = NewAccount(ctx, v, args)
+	}
+
+	// cross-lookup activated accounts from blinded addresses
+	for _, a := range addrs {
+		if a.Type != tezos.AddressTypeBlinded || res[a.String()] != nil {
+			continue
+		}
+		acc, err := ctx.Indexer.FindActivatedAccount(ctx, a)
+		switch err {
+		case nil:
+			res[a.String()] = NewAccount(ctx, acc, args)
+		case index.ErrNoAccountEntry:
+		default:
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func gqlLoadContracts(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error) {
+	args := &AccountRequest{}
+	ctx.ParseQueryArgs(args, query)
+	addrs, err := gqlAddresses(idents)
+	if err != nil {
+		return nil, err
+	}
+	ccs, err := ctx.Indexer.LookupContracts(ctx, addrs)
+	if err != nil || len(ccs) == 0 {
+		return nil, err
+	}
+	ids := make([]uint64, len(ccs))
+	for i, v := range ccs {
+		ids[i] = v.AccountId.Value()
+	}
+	accs, err := ctx.Indexer.LookupAccountIds(ctx, vec.UniqueUint64Slice(ids))
+	if err != nil {
+		return nil, err
+	}
+	accMap := make(map[model.AccountID]*model.Account, len(accs))
+	for _, v := range accs {
+		accMap[v.RowId] = v
+	}
+	res := make(map[string]interface{}, len(ccs))
+	for _, v := range ccs {
+		if acc, ok := accMap[v.AccountId]; ok {
+			res[v.Address.String()] = NewContract(ctx, v, acc, args)
+		}
+	}
+	return res, nil
+}
+
+func gqlLoadBakers(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error) {
+	args := &AccountRequest{}
+	ctx.ParseQueryArgs(args, query)
+	addrs, err := gqlAddresses(idents)
+	if err != nil {
+		return nil, err
+	}
+	bkrs, err := ctx.Indexer.LookupBakers(ctx, addrs)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]interface{}, len(bkrs))
+	for _, v := range bkrs {
+		res[v.Address.String()] = NewBaker(ctx, v, args)
+	}
+	return res, nil
+}
+
+// gqlLoadOps resolves operation hashes into lists of all operations sharing
+// the hash, like the REST operation endpoint.
+func gqlLoadOps(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error) {
+	args := &OpsRequest{
+		Storage: true,
+	}
+	ctx.ParseQueryArgs(args, query)
+	hashes := make([]tezos.OpHash, len(idents))
+	for i, v := range idents {
+		h, err := tezos.ParseOpHash(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation hash '%s'", v)
+		}
+		hashes[i] = h
+	}
+	ops, err := ctx.Indexer.LookupOpHashes(ctx, hashes, etl.ListRequest{
+		WithStorage: args.WithStorage(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	lists := make(map[string]OpList, len(hashes))
+	cache := make(map[int64]interface{})
+	for _, v := range ops {
+		key := v.Hash.String()
+		list := lists[key]
+		list.Append(NewOp(ctx, v, nil, nil, args, cache), args.WithMerge())
+		lists[key] = list
+	}
+	res := make(map[string]interface{}, len(lists))
+	for k, v := range lists {
+		res[k] = v
+	}
+	return res, nil
+}
+
+// gqlLoadBlocks resolves block hashes or heights. Blocks have no batch
+// lookup, recent blocks are served from the indexer's block cache.
+func gqlLoadBlocks(ctx *server.Context, idents []string, query url.Values) (map[string]interface{}, error) {
+	args := &BlockRequest{}
+	ctx.ParseQueryArgs(args, query)
+	res := make(map[string]interface{}, len(idents))
+	for _, v := range idents {
+		block, err := ctx.Indexer.LookupBlock(ctx, v)
+		switch err {
+		case nil:
+			res[v] = NewBlock(ctx, block, args)
+		case index.ErrNoBlockEntry:
+		case index.ErrInvalidBlockHeight, index.ErrInvalidBlockHash:
+			return nil, fmt.Errorf("invalid block '%s'", v)
+		default:
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// gqlFetcher resolves a single object or list from route variables and
+// query arguments with index lookups. Fetchers panic with server errors
+// like API handlers.
+type gqlFetcher func(ctx *server.Context, vars map[string]string, query url.Values) interface{}
+
+func gqlFetchTip(ctx *server.Context, _ map[string]string, _ url.Values) interface{} {
+	return getTip(ctx)
+}
+
+func gqlFetchBakers(ctx *server.Context, _ map[string]string, query url.Values) interface{} {
+	args := &BakerListRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listBakers(ctx, args)
+}
+
+func gqlFetchBakerDelegations(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &OpsRequest{
+		ListRequest: ListRequest{
+			Order: pack.OrderDesc,
+		},
+	}
+	ctx.ParseQueryArgs(args, query)
+	return listBakerDelegations(ctx, lookupAccount(ctx, vars["ident"]), args)
+}
+
+func gqlFetchAccountOperations(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &OpsRequest{
+		ListRequest: ListRequest{
+			Order: pack.OrderDesc,
+		},
+	}
+	ctx.ParseQueryArgs(args, query)
+	return listAccountOperations(ctx, lookupAccount(ctx, vars["ident"]), args)
+}
+
+func gqlFetchDeployedContracts(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &AccountRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listDeployedContracts(ctx, lookupAccount(ctx, vars["ident"]), args)
+}
+
+func gqlFetchContractCalls(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listContractCalls(ctx, lookupContract(ctx, vars["ident"]), args)
+}
+
+func gqlFetchContractStorage(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return readContractStorage(ctx, lookupContract(ctx, vars["ident"]), args)
+}
+
+func gqlFetchBlockOps(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &OpsRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listBlockOps(ctx, lookupBlock(ctx, vars["ident"]), args)
+}
+
+func gqlFetchCycle(ctx *server.Context, vars map[string]string, _ url.Values) interface{} {
+	return readCycle(ctx, parseCycleIdent(ctx, vars["cycle"]))
+}
+
+func gqlFetchElection(ctx *server.Context, vars map[string]string, _ url.Values) interface{} {
+	return readElection(ctx, lookupElection(ctx, vars["ident"]))
+}
+
+func gqlFetchBigmap(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return NewBigmap(ctx, lookupBigmap(ctx, vars["id"]), args)
+}
+
+func gqlFetchBigmapKeys(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listBigmapKeys(ctx, lookupBigmap(ctx, vars["id"]), parseBigmapFilters(query), args)
+}
+
+func gqlFetchBigmapValues(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listBigmapValues(ctx, lookupBigmap(ctx, vars["id"]), parseBigmapFilters(query), args)
+}
+
+func gqlFetchBigmapUpdates(ctx *server.Context, vars map[string]string, query url.Values) interface{} {
+	args := &ContractRequest{}
+	ctx.ParseQueryArgs(args, query)
+	return listBigmapUpdates(ctx, lookupBigmap(ctx, vars["id"]), args)
+}
+
+// gqlFetchMetadata returns metadata for an address and optional asset id.
+func gqlFetchMetadata(ctx *server.Context, vars map[string]string, _ url.Values) interface{} {
+	addr, err := tezos.ParseAddress(vars["ident"])
+	if err != nil {
+		panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, "invalid address", err))
+	}
+	var (
+		assetId    int64
+		useAssetId bool
+	)
+	if aid, ok := vars["asset_id"]; ok {
+		assetId, err = strconv.ParseInt(aid, 10, 64)
+		if err != nil {
+			panic(server.EBadRequest(server.EC_RESOURCE_ID_MALFORMED, "invalid asset id", err))
+		}
+		useAssetId = true
+	}
+	meta, ok := lookupMetadataByAddress(ctx, addr, assetId, useAssetId)
+	if !ok {
+		return nil
+	}
+	return meta
+}
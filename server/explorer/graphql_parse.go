@@ -0,0 +1,448 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parser for the executable subset of GraphQL query documents: queries with
+// variables, aliases, arguments, fragments, inline fragments and the
+// @include/@skip directives. Mutations and subscriptions are rejected.
+
+type gqlTokenKind byte
+
+const (
+	gqlTokenEOF gqlTokenKind = iota
+	gqlTokenName
+	gqlTokenInt
+	gqlTokenFloat
+	gqlTokenString
+	gqlTokenPunct
+)
+
+type gqlToken struct {
+	kind gqlTokenKind
+	text string
+	pos  int
+}
+
+func (t gqlToken) String() string {
+	switch t.kind {
+	case gqlTokenEOF:
+		return "end of document"
+	case gqlTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func gqlLex(s string) ([]gqlToken, error) {
+	tokens := make([]gqlToken, 0, 64)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(s) && (s[i] == '_' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= '0' && s[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{gqlTokenName, s[start:i], start})
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			kind := gqlTokenInt
+			i++
+			for i < len(s) {
+				d := s[i]
+				if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (s[i-1] == 'e' || s[i-1] == 'E')) {
+					kind = gqlTokenFloat
+				} else if d < '0' || d > '9' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, gqlToken{kind, s[start:i], start})
+		case c == '"':
+			start := i
+			str, n, err := gqlLexString(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at position %d", err, start)
+			}
+			tokens = append(tokens, gqlToken{gqlTokenString, str, start})
+			i += n
+		case strings.HasPrefix(s[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlTokenPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$()=:@[]{}|&", c) >= 0:
+			tokens = append(tokens, gqlToken{gqlTokenPunct, s[i : i+1], i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+		}
+	}
+	tokens = append(tokens, gqlToken{kind: gqlTokenEOF, pos: len(s)})
+	return tokens, nil
+}
+
+// gqlLexString reads a quoted or block string and returns its value and
+// encoded length.
+func gqlLexString(s string) (string, int, error) {
+	if strings.HasPrefix(s, `"""`) {
+		end := strings.Index(s[3:], `"""`)
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated block string")
+		}
+		return strings.TrimSpace(s[3 : 3+end]), end + 6, nil
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\n':
+			return "", 0, fmt.Errorf("unterminated string")
+		case '"':
+			str, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string")
+			}
+			return str, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+type gqlDocument struct {
+	Operations []*gqlOperation
+	Fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	Name      string
+	Variables map[string]interface{} // defaults
+	Selection []*gqlSelection
+}
+
+type gqlFragment struct {
+	Name      string
+	On        string
+	Selection []*gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread (Fragment set) or an inline
+// fragment (Field empty).
+type gqlSelection struct {
+	Alias      string
+	Field      string
+	Args       map[string]interface{}
+	Directives []gqlDirective
+	Fragment   string
+	On         string
+	Selection  []*gqlSelection
+}
+
+func (s *gqlSelection) Key() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Field
+}
+
+type gqlDirective struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// query values
+type gqlVariable string
+type gqlEnum string
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+type gqlError string
+
+func (e gqlError) Error() string { return string(e) }
+
+func parseGraphQL(s string) (doc *gqlDocument, err error) {
+	tokens, err := gqlLex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens}
+	defer func() {
+		if e := recover(); e != nil {
+			perr, ok := e.(gqlError)
+			if !ok {
+				panic(e)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	doc = p.parseDocument()
+	return doc, nil
+}
+
+func (p *gqlParser) fail(format string, args ...interface{}) {
+	panic(gqlError(fmt.Sprintf(format, args...)))
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != gqlTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *gqlParser) isPunct(s string) bool {
+	t := p.peek()
+	return t.kind == gqlTokenPunct && t.text == s
+}
+
+func (p *gqlParser) accept(s string) bool {
+	if p.isPunct(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(s string) {
+	if !p.accept(s) {
+		t := p.peek()
+		p.fail("expected '%s' at position %d, found %s", s, t.pos, t)
+	}
+}
+
+func (p *gqlParser) isName(s string) bool {
+	t := p.peek()
+	return t.kind == gqlTokenName && t.text == s
+}
+
+func (p *gqlParser) name() string {
+	t := p.next()
+	if t.kind != gqlTokenName {
+		p.fail("expected name at position %d, found %s", t.pos, t)
+	}
+	return t.text
+}
+
+func (p *gqlParser) parseDocument() *gqlDocument {
+	doc := &gqlDocument{Fragments: make(map[string]*gqlFragment)}
+	for p.peek().kind != gqlTokenEOF {
+		switch {
+		case p.isPunct("{"):
+			doc.Operations = append(doc.Operations, &gqlOperation{
+				Selection: p.parseSelectionSet(),
+			})
+		case p.isName("query"):
+			p.pos++
+			op := &gqlOperation{}
+			if p.peek().kind == gqlTokenName {
+				op.Name = p.name()
+			}
+			if p.isPunct("(") {
+				op.Variables = p.parseVariableDefinitions()
+			}
+			p.parseDirectives()
+			op.Selection = p.parseSelectionSet()
+			doc.Operations = append(doc.Operations, op)
+		case p.isName("fragment"):
+			p.pos++
+			f := &gqlFragment{Name: p.name()}
+			if !p.isName("on") {
+				p.fail("expected type condition for fragment '%s'", f.Name)
+			}
+			p.pos++
+			f.On = p.name()
+			p.parseDirectives()
+			f.Selection = p.parseSelectionSet()
+			if _, ok := doc.Fragments[f.Name]; ok {
+				p.fail("duplicate fragment '%s'", f.Name)
+			}
+			doc.Fragments[f.Name] = f
+		case p.isName("mutation"), p.isName("subscription"):
+			p.fail("%s operations are not supported", p.peek().text)
+		default:
+			t := p.peek()
+			p.fail("unexpected %s at position %d", t, t.pos)
+		}
+	}
+	if len(doc.Operations) == 0 {
+		p.fail("document contains no operation")
+	}
+	return doc
+}
+
+func (p *gqlParser) parseVariableDefinitions() map[string]interface{} {
+	vars := make(map[string]interface{})
+	p.expect("(")
+	for !p.accept(")") {
+		p.expect("$")
+		name := p.name()
+		p.expect(":")
+		p.parseType()
+		var def interface{}
+		if p.accept("=") {
+			def = p.parseValue(true)
+		}
+		vars[name] = def
+		p.parseDirectives()
+	}
+	return vars
+}
+
+// parseType skips over a variable type; values are checked by resolvers.
+func (p *gqlParser) parseType() {
+	if p.accept("[") {
+		p.parseType()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	p.accept("!")
+}
+
+func (p *gqlParser) parseDirectives() []gqlDirective {
+	var dirs []gqlDirective
+	for p.accept("@") {
+		d := gqlDirective{Name: p.name()}
+		if p.isPunct("(") {
+			d.Args = p.parseArguments()
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
+
+func (p *gqlParser) parseArguments() map[string]interface{} {
+	args := make(map[string]interface{})
+	p.expect("(")
+	for !p.accept(")") {
+		name := p.name()
+		p.expect(":")
+		if _, ok := args[name]; ok {
+			p.fail("duplicate argument '%s'", name)
+		}
+		args[name] = p.parseValue(false)
+	}
+	return args
+}
+
+func (p *gqlParser) parseSelectionSet() []*gqlSelection {
+	var sel []*gqlSelection
+	p.expect("{")
+	for !p.accept("}") {
+		if p.peek().kind == gqlTokenEOF {
+			p.fail("unterminated selection set")
+		}
+		s := &gqlSelection{}
+		if p.accept("...") {
+			switch {
+			case p.isName("on"):
+				p.pos++
+				s.On = p.name()
+				s.Directives = p.parseDirectives()
+				s.Selection = p.parseSelectionSet()
+			case p.peek().kind == gqlTokenName:
+				s.Fragment = p.name()
+				s.Directives = p.parseDirectives()
+			default:
+				s.Directives = p.parseDirectives()
+				s.Selection = p.parseSelectionSet()
+			}
+			sel = append(sel, s)
+			continue
+		}
+		s.Field = p.name()
+		if p.accept(":") {
+			s.Alias = s.Field
+			s.Field = p.name()
+		}
+		if p.isPunct("(") {
+			s.Args = p.parseArguments()
+		}
+		s.Directives = p.parseDirectives()
+		if p.isPunct("{") {
+			s.Selection = p.parseSelectionSet()
+		}
+		sel = append(sel, s)
+	}
+	if len(sel) == 0 {
+		p.fail("empty selection set")
+	}
+	return sel
+}
+
+func (p *gqlParser) parseValue(isConst bool) interface{} {
+	t := p.next()
+	switch t.kind {
+	case gqlTokenInt:
+		i, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			p.fail("invalid integer %s at position %d", t.text, t.pos)
+		}
+		return i
+	case gqlTokenFloat:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			p.fail("invalid float %s at position %d", t.text, t.pos)
+		}
+		return f
+	case gqlTokenString:
+		return t.text
+	case gqlTokenName:
+		switch t.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return gqlEnum(t.text)
+	case gqlTokenPunct:
+		switch t.text {
+		case "$":
+			if isConst {
+				p.fail("unexpected variable at position %d", t.pos)
+			}
+			return gqlVariable(p.name())
+		case "[":
+			list := make([]interface{}, 0)
+			for !p.accept("]") {
+				if p.peek().kind == gqlTokenEOF {
+					p.fail("unterminated list")
+				}
+				list = append(list, p.parseValue(isConst))
+			}
+			return list
+		case "{":
+			obj := make(map[string]interface{})
+			for !p.accept("}") {
+				name := p.name()
+				p.expect(":")
+				obj[name] = p.parseValue(isConst)
+			}
+			return obj
+		}
+	}
+	p.fail("unexpected %s at position %d", t, t.pos)
+	return nil
+}
@@ -0,0 +1,367 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"blockwatch.cc/tzindex/server"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	doc, err := parseGraphQL(`
+		# comment
+		query Ops($hash: String!, $limit: Int = 10, $meta: Boolean) {
+			op(hash: $hash) {
+				...OpFields
+				s: sender @include(if: $meta) { address }
+				... on Op { volume }
+			}
+			accounts(addresses: ["tz1a", "tz1b"], limit: -5, ratio: 1.5e2, order: desc, f: {a: null}) { address }
+		}
+		fragment OpFields on Op { hash, height }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := doc.operation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.Name != "Ops" || op.Variables["limit"] != int64(10) {
+		t.Errorf("operation = %s vars=%v", op.Name, op.Variables)
+	}
+	if _, ok := op.Variables["meta"]; !ok {
+		t.Errorf("missing variable without default")
+	}
+	if len(op.Selection) != 2 {
+		t.Fatalf("got %d root fields, want 2", len(op.Selection))
+	}
+
+	sel := op.Selection[0]
+	if sel.Field != "op" || sel.Args["hash"] != gqlVariable("hash") || len(sel.Selection) != 3 {
+		t.Errorf("op field = %+v", sel)
+	}
+	if sel.Selection[0].Fragment != "OpFields" {
+		t.Errorf("fragment spread = %+v", sel.Selection[0])
+	}
+	s := sel.Selection[1]
+	if s.Key() != "s" || s.Field != "sender" || len(s.Directives) != 1 || s.Directives[0].Name != "include" {
+		t.Errorf("aliased field = %+v", s)
+	}
+	if sel.Selection[2].On != "Op" || sel.Selection[2].Field != "" {
+		t.Errorf("inline fragment = %+v", sel.Selection[2])
+	}
+
+	args := op.Selection[1].Args
+	want := map[string]interface{}{
+		"addresses": []interface{}{"tz1a", "tz1b"},
+		"limit":     int64(-5),
+		"ratio":     150.0,
+		"order":     gqlEnum("desc"),
+		"f":         map[string]interface{}{"a": nil},
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("arguments = %#v, want %#v", args, want)
+	}
+
+	f := doc.Fragments["OpFields"]
+	if f == nil || f.On != "Op" || len(f.Selection) != 2 {
+		t.Errorf("fragment = %+v", f)
+	}
+}
+
+func TestParseGraphQLStrings(t *testing.T) {
+	doc, err := parseGraphQL(`{ a(x: "q\"uote\n", y: """ block "text" """) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := doc.Operations[0].Selection[0].Args
+	if args["x"] != "q\"uote\n" || args["y"] != `block "text"` {
+		t.Errorf("strings = %q %q", args["x"], args["y"])
+	}
+}
+
+func TestParseGraphQLErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"{}",
+		"{ a",
+		"{ a(x: ) }",
+		"{ a(x: 1, x: 2) }",
+		"mutation { a }",
+		"subscription { a }",
+		"query ($v: Int = $w) { a }",
+		"fragment F { a } { b }",
+		"fragment F on T { a } fragment F on T { b } { c }",
+		`{ a(x: "open) }`,
+		"{ a(x: [1, 2) }",
+		"{ a } %",
+	} {
+		if _, err := parseGraphQL(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestGraphQLOperation(t *testing.T) {
+	doc, err := parseGraphQL("query A { a } query B { b }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.operation(""); err == nil {
+		t.Errorf("expected error for missing operation name")
+	}
+	if op, err := doc.operation("B"); err != nil || op.Selection[0].Field != "b" {
+		t.Errorf("operation B = %v, %v", op, err)
+	}
+	if _, err := doc.operation("C"); err == nil {
+		t.Errorf("expected error for unknown operation")
+	}
+}
+
+func testGqlExec(t *testing.T, query string, vars map[string]interface{}) (*gqlExec, *gqlOperation) {
+	t.Helper()
+	doc, err := parseGraphQL(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := doc.operation("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &gqlExec{
+		ctx: &server.Context{Cfg: &server.Config{Http: server.HttpConfig{
+			DefaultExploreCount: 10,
+			MaxExploreCount:     100,
+			MaxGraphQLDepth:     4,
+			MaxBatchSize:        2,
+		}}},
+		doc:   doc,
+		vars:  make(map[string]interface{}),
+		cache: make(map[string]interface{}),
+	}
+	for k, v := range op.Variables {
+		g.vars[k] = v
+	}
+	for k, v := range vars {
+		g.vars[k] = v
+	}
+	return g, op
+}
+
+func TestGraphQLCheck(t *testing.T) {
+	tests := []struct {
+		query string
+		cost  int64
+		fail  bool
+	}{
+		{`{ account(address: "tz1") { address } }`, 1, false},
+		{`{ accounts(addresses: ["a", "b", "c"]) { address } }`, 3, false},
+		{`{ account(address: "a") { operations(limit: 5) { hash } } }`, 2, false},
+		{`{ account(address: "a") { operations(limit: 5) { sender { address } } } }`, 7, false},
+		{`{ account(address: "a") { nope } }`, 0, true},
+		{`{ account(address: "a") { operations } }`, 0, true},
+		{`{ account(address: "a") { creator { creator { creator { address } } } } }`, 0, true},
+		{`{ account(address: "a") { ...F } } fragment F on Account { ...F }`, 0, true},
+		{`{ account(address: "a") { x: address, x: baker { address } } }`, 0, true},
+		{`{ tip { __typename status } }`, 1, false},
+	}
+	for _, test := range tests {
+		g, op := testGqlExec(t, test.query, nil)
+		cost, err := g.check(gqlSchema["Query"], op.Selection, 1, 1)
+		switch {
+		case test.fail && err == nil:
+			t.Errorf("%s: expected error", test.query)
+		case !test.fail && err != nil:
+			t.Errorf("%s: %s", test.query, err)
+		case !test.fail && cost != test.cost:
+			t.Errorf("%s: cost %d, want %d", test.query, cost, test.cost)
+		}
+	}
+}
+
+func TestGraphQLDirectives(t *testing.T) {
+	g, op := testGqlExec(t, `query ($on: Boolean = true) { a @include(if: $on) b @skip(if: $on) c @include(if: false) }`, nil)
+	fields, err := g.collect(nil, op.Selection, make(map[string]bool))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].Field != "a" {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+// testGqlLoader records calls and returns objects with an address field.
+type testGqlLoader struct {
+	calls [][]string
+}
+
+func (l *testGqlLoader) load(_ *server.Context, idents []string, _ url.Values) (map[string]interface{}, error) {
+	l.calls = append(l.calls, append([]string(nil), idents...))
+	res := make(map[string]interface{})
+	for _, v := range idents {
+		if v != "missing" {
+			res[v] = map[string]string{"address": v}
+		}
+	}
+	return res, nil
+}
+
+func TestGraphQLBatchLoad(t *testing.T) {
+	l := &testGqlLoader{}
+	typ := &gqlType{
+		name:   "Test",
+		fields: map[string]bool{"address": true, "sender": true},
+		resolvers: map[string]*gqlResolver{
+			"sender": {Type: "Account", Load: l.load, Vars: map[string]string{"ident": "sender"}},
+		},
+	}
+	g, op := testGqlExec(t, `{ sender { address } }`, nil)
+	list := []interface{}{
+		map[string]interface{}{"sender": "a"},
+		map[string]interface{}{"sender": "b"},
+		map[string]interface{}{"sender": "a"},
+		map[string]interface{}{"sender": "missing"},
+		map[string]interface{}{"sender": "c"},
+	}
+	res := g.complete(typ, list, op.Selection, nil)
+	if len(g.errors) > 0 {
+		t.Fatalf("errors: %v", g.errors)
+	}
+
+	// all references are loaded up front in batches of max_batch_size
+	want := [][]string{{"a", "b"}, {"missing", "c"}}
+	if !reflect.DeepEqual(l.calls, want) {
+		t.Errorf("loader calls = %v, want %v", l.calls, want)
+	}
+	buf, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := `[{"sender":{"address":"a"}},{"sender":{"address":"b"}},{"sender":{"address":"a"}},{"sender":null},{"sender":{"address":"c"}}]`
+	if string(buf) != exp {
+		t.Errorf("result = %s, want %s", buf, exp)
+	}
+}
+
+func TestGraphQLFanoutLoad(t *testing.T) {
+	l := &testGqlLoader{}
+	res := &gqlResolver{Type: "Account", List: true, Load: l.load, Vars: map[string]string{"ident": "$addresses"}, Fanout: "addresses"}
+	g, op := testGqlExec(t, `{ accounts(addresses: ["x", "y", "x"]) { address } }`, nil)
+	val := g.resolve(res, nil, op.Selection[0], nil)
+	list, ok := val.([]interface{})
+	if !ok || len(list) != 3 {
+		t.Fatalf("result = %#v", val)
+	}
+	if len(l.calls) != 1 {
+		t.Errorf("loader called %d times, want 1", len(l.calls))
+	}
+	got := append([]string(nil), l.calls[0]...)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("loaded idents = %v", got)
+	}
+
+	// repeated lookups are served from cache
+	g.resolve(res, nil, op.Selection[0], nil)
+	if len(l.calls) != 1 {
+		t.Errorf("cached lookup called loader again")
+	}
+}
+
+func TestGqlString(t *testing.T) {
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{nil, ""},
+		{"a", "a"},
+		{int64(-3), "-3"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{json.Number("12"), "12"},
+		{[]interface{}{"a", int64(1)}, "a,1"},
+	}
+	for _, test := range tests {
+		if got := gqlString(test.val); got != test.want {
+			t.Errorf("%#v: got %q, want %q", test.val, got, test.want)
+		}
+	}
+	if _, err := gqlQuery(map[string]interface{}{"o": map[string]interface{}{}}); err == nil {
+		t.Errorf("expected error for object argument")
+	}
+}
+
+func TestGraphQLIntrospection(t *testing.T) {
+	g, op := testGqlExec(t, `{
+		__schema { queryType { name } directives { name } }
+		__type(name: "Account") { kind name fields { name type { kind name ofType { name } } } }
+		missing: __type(name: "Nope") { name }
+	}`, nil)
+	if _, err := g.check(gqlSchema["Query"], op.Selection, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	res := g.object(gqlSchema["Query"], nil, op.Selection, nil)
+	if len(g.errors) > 0 {
+		t.Fatalf("errors: %v", g.errors)
+	}
+	buf, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Schema struct {
+			QueryType  struct{ Name string }
+			Directives []struct{ Name string }
+		} `json:"__schema"`
+		Type struct {
+			Kind   string
+			Name   string
+			Fields []struct {
+				Name string
+				Type struct {
+					Kind   string
+					Name   *string
+					OfType *struct{ Name string }
+				}
+			}
+		} `json:"__type"`
+		Missing interface{} `json:"missing"`
+	}
+	if err := json.Unmarshal(buf, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Schema.QueryType.Name != "Query" || len(out.Schema.Directives) != 2 {
+		t.Errorf("schema = %s", buf)
+	}
+	if out.Type.Kind != "OBJECT" || out.Type.Name != "Account" {
+		t.Errorf("type = %s", buf)
+	}
+	kinds := make(map[string]string)
+	for _, f := range out.Type.Fields {
+		switch {
+		case f.Type.Name != nil:
+			kinds[f.Name] = *f.Type.Name
+		case f.Type.OfType != nil:
+			kinds[f.Name] = f.Type.Kind + ":" + f.Type.OfType.Name
+		}
+	}
+	for k, v := range map[string]string{
+		"address":    "String",
+		"operations": "LIST:Op",
+		"baker":      "Baker",
+	} {
+		if kinds[k] != v {
+			t.Errorf("field %s: type %q, want %q", k, kinds[k], v)
+		}
+	}
+	if out.Missing != nil {
+		t.Errorf("unknown type = %v, want null", out.Missing)
+	}
+}
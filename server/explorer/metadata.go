@@ -121,6 +121,17 @@ func allMetadataById(ctx *server.Context) metaByIdMap {
 	return metaMap
 }
 
+// lookupPayoutIds returns account ids of all payout addresses that declare
+// the baker in their `payout.from` metadata.
+func lookupPayoutIds(ctx *server.Context, id model.AccountID) []uint64 {
+	payMap := payoutByBakerMapStore.Load().(payoutByBakerMap)
+	if len(payMap) == 0 {
+		_ = loadMetadata(ctx)
+		payMap = payoutByBakerMapStore.Load().(payoutByBakerMap)
+	}
+	return payMap[id.Value()]
+}
+
 func cacheSingleMetadata(ctx *server.Context, meta *Metadata, remove bool) {
 	if meta == nil {
 		return
@@ -299,6 +310,9 @@ type Metadata struct {
 	MinDelegation  float64
 	NonDelegatable bool
 	IsPayout       bool
+	Fee            float64
+	PayoutDelay    bool
+	MinPayout      float64
 }
 
 var _ Sortable = (*Metadata)(nil)
@@ -389,6 +403,9 @@ func NewMetadata(m *model.Metadata) *Metadata {
 			md.MinDelegation = c.GetFloat64("baker.min_delegation")
 			md.NonDelegatable = c.GetBool("baker.non_delegatable")
 			md.IsPayout = len(c.GetStringSlice("payout.from")) > 0
+			md.Fee = c.GetFloat64("baker.fee")
+			md.PayoutDelay = c.GetBool("baker.payout_delay")
+			md.MinPayout = c.GetFloat64("baker.min_payout")
 		}
 	}
 
@@ -665,7 +682,7 @@ func CreateMetadata(ctx *server.Context) (interface{}, int) {
 	ctx.ParseRequestArgs(&meta)
 
 	// 1  validate
-	if config.GetBool("metadata.validate") {
+	if ctx.Cfg.Runtime.MetadataValidate {
 		for i := range meta {
 			if err := meta[i].Validate(ctx); err != nil {
 				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("%s/%d: %v", meta[i].Address, meta[i].AssetId, err), nil))
@@ -709,6 +726,7 @@ func CreateMetadata(ctx *server.Context) (interface{}, int) {
 		toCache[i] = NewMetadata(v)
 	}
 	cacheMultiMetadata(ctx, toCache)
+	ctx.Server.PurgeResponseCache()
 
 	return toCache, http.StatusCreated
 }
@@ -745,6 +763,7 @@ func UpdateMetadata(ctx *server.Context) (interface{}, int) {
 	// cache and return the updated version
 	upd = NewMetadata(md)
 	cacheSingleMetadata(ctx, upd, false)
+	ctx.Server.PurgeResponseCache()
 	return upd, http.StatusOK
 }
 
@@ -755,6 +774,7 @@ func RemoveMetadata(ctx *server.Context) (interface{}, int) {
 		panic(server.EInternal(server.EC_DATABASE, "cannot remove metadata", err))
 	}
 	cacheSingleMetadata(ctx, meta, true)
+	ctx.Server.PurgeResponseCache()
 	return nil, http.StatusNoContent
 }
 
@@ -764,6 +784,7 @@ func PurgeMetadata(ctx *server.Context) (interface{}, int) {
 		panic(server.EInternal(server.EC_DATABASE, "cannot purge metadata", err))
 	}
 	purgeMetadataStore()
+	ctx.Server.PurgeResponseCache()
 	return nil, http.StatusNoContent
 }
 
@@ -837,12 +858,12 @@ func DescribeMetadata(ctx *server.Context) (interface{}, int) {
 	}
 
 	// add title suffix to everything
-	if s := config.GetString("metadata.describe.title_suffix"); s != "" {
+	if s := ctx.Cfg.Runtime.MetaTitleSuffix; s != "" {
 		desc.Title += " " + s
 	}
 
 	// image url prefix in config
-	if s := config.GetString("metadata.describe.image_url"); s != "" {
+	if s := ctx.Cfg.Runtime.MetaImageUrl; s != "" {
 		if !strings.HasPrefix(desc.Image, "http") {
 			if !strings.HasPrefix(desc.Image, "/") && !strings.HasSuffix(s, "/") {
 				s += "/"
@@ -857,7 +878,7 @@ func DescribeMetadata(ctx *server.Context) (interface{}, int) {
 func DescribeAddress(ctx *server.Context, addr tezos.Address) MetadataDescriptor {
 	d := MetadataDescriptor{
 		Title: addr.Short(),
-		Image: config.GetString("metadata.describe.logo"),
+		Image: ctx.Cfg.Runtime.MetaLogo,
 	}
 	meta, ok := lookupMetadataByAddress(ctx, addr, 0, false)
 	if ok {
@@ -915,7 +936,7 @@ func DescribeBlock(ctx *server.Context, ident string) MetadataDescriptor {
 	block := loadBlock(ctx)
 	d := MetadataDescriptor{
 		Title: fmt.Sprintf("Tezos Block %s", util.PrettyInt64(block.Height)),
-		Image: config.GetString("metadata.describe.logo"),
+		Image: ctx.Cfg.Runtime.MetaLogo,
 	}
 	bakerName := ctx.Indexer.LookupAddress(ctx, block.BakerId).String()
 	if meta, ok := lookupMetadataById(ctx, block.BakerId, 0, false); ok {
@@ -932,7 +953,7 @@ func DescribeBlock(ctx *server.Context, ident string) MetadataDescriptor {
 
 func DescribeOp(ctx *server.Context, ops []*model.Op) MetadataDescriptor {
 	d := MetadataDescriptor{
-		Image: config.GetString("metadata.describe.logo"),
+		Image: ctx.Cfg.Runtime.MetaLogo,
 	}
 
 	// use the first op by default, unless its a batched reveal
@@ -1169,7 +1190,7 @@ func DescribeCycle(ctx *server.Context, cycle int64) MetadataDescriptor {
 	c := lookupOrBuildCycle(ctx, cycle)
 	d := MetadataDescriptor{
 		Title: fmt.Sprintf("Tezos Cycle %s on TzStats", util.PrettyInt64(cycle)),
-		Image: config.GetString("metadata.describe.logo"),
+		Image: ctx.Cfg.Runtime.MetaLogo,
 		Description: fmt.Sprintf("Tezos Cycle %s – Running from %s to %s.",
 			util.PrettyInt64(cycle),
 			c.StartTime.Format(metaDateTime),
@@ -1215,7 +1236,7 @@ func DescribeElection(ctx *server.Context, id int64) MetadataDescriptor {
 	if winner == nil {
 		return MetadataDescriptor{
 			Title: "Tezos Election on TzStats",
-			Image: config.GetString("metadata.describe.logo"),
+			Image: ctx.Cfg.Runtime.MetaLogo,
 			Description: fmt.Sprintf("Tezos Election – From %s to %s.",
 				election.StartTime.Format(metaDateTime),
 				election.EndTime.Format(metaDateTime),
@@ -1224,7 +1245,7 @@ func DescribeElection(ctx *server.Context, id int64) MetadataDescriptor {
 	} else {
 		return MetadataDescriptor{
 			Title: fmt.Sprintf("Tezos Election %s on TzStats", winner.Hash.String()[:8]),
-			Image: config.GetString("metadata.describe.logo"),
+			Image: ctx.Cfg.Runtime.MetaLogo,
 			Description: fmt.Sprintf("Tezos Election %s – From %s to %s.",
 				winner.Hash.String(),
 				election.StartTime.Format(metaDateTime),
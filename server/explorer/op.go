@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -697,6 +698,7 @@ func (o Op) RegisterDirectRoutes(r *mux.Router) error {
 
 func (t Op) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/{ident}", server.C(ReadOp)).Methods("GET").Name("op")
+	r.HandleFunc("/{ident}/trace", server.C(ReadOpTrace)).Methods("GET")
 	return nil
 
 }
@@ -779,8 +781,12 @@ func (r *OpsRequest) Parse(ctx *server.Context) {
 		r.SinceHeight = b.Height
 		r.SinceHash = b.Hash.Clone()
 	}
+}
+
+// implement QueryParsableRequest interface
+func (r *OpsRequest) ParseQuery(ctx *server.Context, query url.Values) {
 	// filter by type condition
-	for key, val := range ctx.Request.URL.Query() {
+	for key, val := range query {
 		keys := strings.Split(key, ".")
 		if keys[0] != "type" {
 			continue
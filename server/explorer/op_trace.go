@@ -0,0 +1,361 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// StorageSummary summarizes storage and bigmap changes of a single call.
+type StorageSummary struct {
+	StorageHash   string  `json:"storage_hash,omitempty"`
+	StoragePaid   int64   `json:"storage_paid"`
+	BigmapUpdates int     `json:"bigmap_updates"`
+	BigmapRemoves int     `json:"bigmap_removes"`
+	BigmapAllocs  int     `json:"bigmap_allocs"`
+	BigmapCopies  int     `json:"bigmap_copies"`
+	BigmapIds     []int64 `json:"bigmap_ids,omitempty"`
+}
+
+// TokenTransfer is a FA1.2 or FA2 transfer decoded from `transfer` call
+// parameters. TokenId is empty for FA1.2 tokens.
+type TokenTransfer struct {
+	Token   tezos.Address `json:"token"`
+	TokenId *tezos.Z      `json:"token_id,omitempty"`
+	From    tezos.Address `json:"from"`
+	To      tezos.Address `json:"to"`
+	Amount  tezos.Z       `json:"amount"`
+}
+
+// TraceNode is a single operation in an execution tree. Calls lists all
+// internal operations emitted by this node in execution order.
+type TraceNode struct {
+	Id             uint64          `json:"id"`
+	Type           model.OpType    `json:"type"`
+	Status         string          `json:"status"`
+	IsSuccess      bool            `json:"is_success"`
+	IsInternal     bool            `json:"is_internal"`
+	Depth          int             `json:"depth"`
+	Sender         tezos.Address   `json:"sender"`
+	Receiver       tezos.Address   `json:"receiver,omitempty"`
+	Entrypoint     string          `json:"entrypoint,omitempty"`
+	Amount         float64         `json:"amount"`
+	Fee            float64         `json:"fee,omitempty"`
+	GasUsed        int64           `json:"gas_used"`
+	Storage        *StorageSummary `json:"storage,omitempty"`
+	Parameters     *Parameters     `json:"parameters,omitempty"`
+	TokenTransfers []TokenTransfer `json:"token_transfers,omitempty"`
+	TicketUpdates  []*TicketUpdate `json:"ticket_updates,omitempty"`
+	Events         []*Event        `json:"events,omitempty"`
+	Errors         json.RawMessage `json:"errors,omitempty"`
+	Calls          []*TraceNode    `json:"calls,omitempty"`
+
+	receiverId model.AccountID
+}
+
+type OpTrace struct {
+	Hash          tezos.OpHash    `json:"hash"`
+	BlockHash     tezos.BlockHash `json:"block"`
+	Height        int64           `json:"height"`
+	Timestamp     time.Time       `json:"time"`
+	Status        string          `json:"status"`
+	IsSuccess     bool            `json:"is_success"`
+	GasUsed       int64           `json:"gas_used"`
+	StoragePaid   int64           `json:"storage_paid"`
+	Fee           float64         `json:"fee"`
+	NCalls        int             `json:"n_calls"`
+	MaxDepth      int             `json:"max_depth"`
+	Calls         []*TraceNode    `json:"calls"`
+	Confirmations int64           `json:"confirmations"`
+
+	expires time.Time `json:"-"`
+}
+
+func (t OpTrace) LastModified() time.Time { return t.Timestamp }
+func (t OpTrace) Expires() time.Time      { return t.expires }
+
+var _ server.Resource = (*OpTrace)(nil)
+
+// ReadOpTrace reconstructs the execution tree of an operation group.
+func ReadOpTrace(ctx *server.Context) (interface{}, int) {
+	args := &OpsRequest{
+		Storage: true,
+	}
+	ctx.ParseRequestArgs(args)
+	ops := loadOps(ctx, args)
+
+	first := ops[0]
+	resp := &OpTrace{
+		Hash:          first.Hash,
+		BlockHash:     ctx.Indexer.LookupBlockHash(ctx.Context, first.Height),
+		Height:        first.Height,
+		Timestamp:     first.Timestamp,
+		Status:        first.Status.String(),
+		IsSuccess:     true,
+		Confirmations: util.Max64(0, ctx.Tip.BestHeight-first.Height),
+		expires:       ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	for _, op := range ops {
+		if op.IsEvent {
+			continue
+		}
+		resp.NCalls++
+		resp.GasUsed += op.GasUsed
+		resp.StoragePaid += op.StoragePaid
+		resp.Fee += ctx.Params.ConvertValue(op.Fee)
+		if !op.IsSuccess {
+			resp.IsSuccess = false
+			if op.Status != tezos.OpStatusApplied {
+				resp.Status = op.Status.String()
+			}
+		}
+	}
+	resp.Calls, resp.MaxDepth = buildTraceTree(ops, func(op *model.Op) *TraceNode {
+		return newTraceNode(ctx, op, args)
+	})
+	return resp, http.StatusOK
+}
+
+// buildTraceTree arranges the operations of a group in execution order into
+// call trees, one per top-level operation, and returns the maximum depth.
+//
+// Internal operation positions are not stored, so parents are derived from
+// execution order: since Florence internal operations run depth-first, hence
+// the parent of an internal op is the closest ancestor on the current call
+// path whose receiver emitted the op. Pre-Florence (breadth-first) groups fall
+// back to the most recent node with a matching receiver.
+func buildTraceTree(ops []*model.Op, newNode func(*model.Op) *TraceNode) ([]*TraceNode, int) {
+	var (
+		roots    = make([]*TraceNode, 0)
+		path     []*TraceNode // current call path, root first
+		all      []*TraceNode // all nodes in current root's tree
+		maxDepth int
+	)
+	for _, op := range ops {
+		if op.IsEvent {
+			continue
+		}
+		node := newNode(op)
+		node.receiverId = op.ReceiverId
+		if !op.IsInternal || len(path) == 0 {
+			roots = append(roots, node)
+			path = append(path[:0], node)
+			all = append(all[:0], node)
+			continue
+		}
+
+		// unwind the call path to the emitting contract
+		var parent *TraceNode
+		for i := len(path) - 1; i >= 0; i-- {
+			if path[i].receiverId == op.CreatorId {
+				parent = path[i]
+				path = path[:i+1]
+				break
+			}
+		}
+		if parent == nil {
+			for i := len(all) - 1; i >= 0; i-- {
+				if all[i].receiverId == op.CreatorId {
+					parent = all[i]
+					break
+				}
+			}
+		}
+		if parent == nil {
+			parent = path[0]
+			path = path[:1]
+		}
+		node.Depth = parent.Depth + 1
+		parent.Calls = append(parent.Calls, node)
+		path = append(path, node)
+		all = append(all, node)
+		if node.Depth > maxDepth {
+			maxDepth = node.Depth
+		}
+	}
+	return roots, maxDepth
+}
+
+func newTraceNode(ctx *server.Context, op *model.Op, args *OpsRequest) *TraceNode {
+	node := &TraceNode{
+		Id:         op.Id(),
+		Type:       op.Type,
+		Status:     op.Status.String(),
+		IsSuccess:  op.IsSuccess,
+		IsInternal: op.IsInternal,
+		Amount:     ctx.Params.ConvertValue(op.Volume),
+		GasUsed:    op.GasUsed,
+		receiverId: op.ReceiverId,
+	}
+	if !op.IsInternal {
+		node.Fee = ctx.Params.ConvertValue(op.Fee)
+	}
+	if len(op.Errors) > 0 {
+		node.Errors = json.RawMessage(op.Errors)
+	}
+
+	// internal ops are emitted by the contract in creator id
+	if op.IsInternal {
+		node.Sender = ctx.Indexer.LookupAddress(ctx, op.CreatorId)
+	} else if op.SenderId > 0 {
+		node.Sender = ctx.Indexer.LookupAddress(ctx, op.SenderId)
+	}
+	if op.ReceiverId > 0 {
+		node.Receiver = ctx.Indexer.LookupAddress(ctx, op.ReceiverId)
+	}
+
+	if op.IsContract {
+		pTyp, _, _, err := ctx.Indexer.LookupContractType(ctx.Context, op.ReceiverId)
+		if err != nil {
+			log.Errorf("explorer: trace %s: loading contract type for %s: %v", op.Hash, node.Receiver, err)
+		}
+		if len(op.Parameters) > 0 && pTyp.IsValid() {
+			node.Parameters = NewParameters(ctx, op.Parameters, pTyp, op.Hash, args)
+			node.Entrypoint = node.Parameters.Entrypoint
+			if node.Entrypoint == "transfer" {
+				node.TokenTransfers = decodeTokenTransfers(op.Parameters, pTyp, node.Receiver)
+			}
+		}
+	}
+
+	if op.StorageHash != 0 || op.StoragePaid > 0 || len(op.BigmapUpdates) > 0 {
+		s := &StorageSummary{
+			StoragePaid: op.StoragePaid,
+		}
+		if op.StorageHash != 0 {
+			s.StorageHash = util.U64String(op.StorageHash).Hex()
+		}
+		seen := make(map[int64]struct{})
+		for _, v := range op.BigmapUpdates {
+			switch v.Action {
+			case micheline.DiffActionUpdate:
+				s.BigmapUpdates++
+			case micheline.DiffActionRemove:
+				s.BigmapRemoves++
+			case micheline.DiffActionAlloc:
+				s.BigmapAllocs++
+			case micheline.DiffActionCopy:
+				s.BigmapCopies++
+			}
+			if _, ok := seen[v.BigmapId]; !ok {
+				seen[v.BigmapId] = struct{}{}
+				s.BigmapIds = append(s.BigmapIds, v.BigmapId)
+			}
+		}
+		node.Storage = s
+	}
+
+	for _, ev := range op.Events {
+		node.Events = append(node.Events, NewEvent(ctx, ev, args))
+	}
+	for _, up := range op.TicketUpdates {
+		node.TicketUpdates = append(node.TicketUpdates, NewTicketUpdate(ctx, up, args))
+	}
+	return node
+}
+
+// decodeTokenTransfers decodes FA1.2 `(pair address (pair address nat))` and
+// FA2 `(list (pair address (list (pair address (pair nat nat)))))` transfer
+// parameters. Unknown shapes are ignored.
+func decodeTokenTransfers(data []byte, typ micheline.Type, token tezos.Address) []TokenTransfer {
+	p := &micheline.Parameters{}
+	if err := p.UnmarshalBinary(data); err != nil {
+		return nil
+	}
+	_, prim, err := p.MapEntrypoint(typ)
+	if err != nil {
+		return nil
+	}
+
+	// FA1.2
+	if args := flattenPair(prim); len(args) == 3 {
+		from, ok1 := primAddress(args[0])
+		to, ok2 := primAddress(args[1])
+		if ok1 && ok2 && args[2].Type == micheline.PrimInt {
+			return []TokenTransfer{{
+				Token:  token,
+				From:   from,
+				To:     to,
+				Amount: tezos.NewBigZ(args[2].Int),
+			}}
+		}
+		return nil
+	}
+
+	// FA2
+	if prim.Type != micheline.PrimSequence {
+		return nil
+	}
+	list := make([]TokenTransfer, 0)
+	for _, batch := range prim.Args {
+		args := flattenPair(batch)
+		if len(args) != 2 || args[1].Type != micheline.PrimSequence {
+			return nil
+		}
+		from, ok := primAddress(args[0])
+		if !ok {
+			return nil
+		}
+		for _, tx := range args[1].Args {
+			txArgs := flattenPair(tx)
+			if len(txArgs) != 3 || txArgs[1].Type != micheline.PrimInt || txArgs[2].Type != micheline.PrimInt {
+				return nil
+			}
+			to, ok := primAddress(txArgs[0])
+			if !ok {
+				return nil
+			}
+			id := tezos.NewBigZ(txArgs[1].Int)
+			list = append(list, TokenTransfer{
+				Token:   token,
+				TokenId: &id,
+				From:    from,
+				To:      to,
+				Amount:  tezos.NewBigZ(txArgs[2].Int),
+			})
+		}
+	}
+	return list
+}
+
+// flattenPair unfolds right-combed pairs into a flat argument list.
+func flattenPair(p micheline.Prim) []micheline.Prim {
+	if !p.IsPair() {
+		return []micheline.Prim{p}
+	}
+	args := make([]micheline.Prim, 0, len(p.Args)+1)
+	for i, v := range p.Args {
+		if i == len(p.Args)-1 {
+			args = append(args, flattenPair(v)...)
+		} else {
+			args = append(args, v)
+		}
+	}
+	return args
+}
+
+func primAddress(p micheline.Prim) (tezos.Address, bool) {
+	var a tezos.Address
+	switch p.Type {
+	case micheline.PrimString:
+		if err := a.UnmarshalText([]byte(p.String)); err != nil {
+			return a, false
+		}
+	case micheline.PrimBytes:
+		if err := a.UnmarshalBinary(p.Bytes); err != nil {
+			return a, false
+		}
+	default:
+		return a, false
+	}
+	return a, true
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+// testTraceOp creates an op with id, emitting contract and receiver. Ops
+// without creator are top-level.
+func testTraceOp(id int, creator, receiver model.AccountID) *model.Op {
+	return &model.Op{
+		RowId:      model.OpID(id),
+		IsInternal: creator > 0,
+		CreatorId:  creator,
+		ReceiverId: receiver,
+	}
+}
+
+// formatTraceTree renders trees as `id(child,child),id` with node depths
+// checked along the way.
+func formatTraceTree(t *testing.T, nodes []*TraceNode, depth int) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		if n.Depth != depth {
+			t.Errorf("node %d depth %d, want %d", n.Id, n.Depth, depth)
+		}
+		parts[i] = strconv.FormatUint(n.Id, 10)
+		if len(n.Calls) > 0 {
+			parts[i] += "(" + formatTraceTree(t, n.Calls, depth+1) + ")"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestBuildTraceTree(t *testing.T) {
+	const (
+		user model.AccountID = iota + 1
+		a
+		b
+		c
+		d
+	)
+	tests := []struct {
+		name     string
+		ops      []*model.Op
+		want     string
+		maxDepth int
+	}{
+		{
+			name: "single call",
+			ops:  []*model.Op{testTraceOp(1, 0, a)},
+			want: "1",
+		},
+		{
+			// user -> a -> b -> c, then a -> d
+			name: "nested",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				testTraceOp(2, a, b),
+				testTraceOp(3, b, c),
+				testTraceOp(4, a, d),
+			},
+			want:     "1(2(3),4)",
+			maxDepth: 2,
+		},
+		{
+			// user -> a -> b -> a -> c
+			name: "re-entrant",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				testTraceOp(2, a, b),
+				testTraceOp(3, b, a),
+				testTraceOp(4, a, c),
+			},
+			want:     "1(2(3(4)))",
+			maxDepth: 3,
+		},
+		{
+			// pre-Florence: a emits b and c, then b's call to d and c's
+			// call to user execute
+			name: "breadth-first",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				testTraceOp(2, a, b),
+				testTraceOp(3, a, c),
+				testTraceOp(4, b, d),
+				testTraceOp(5, c, user),
+			},
+			want:     "1(2(4),3(5))",
+			maxDepth: 2,
+		},
+		{
+			name: "batch",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				testTraceOp(2, a, b),
+				testTraceOp(3, 0, c),
+				testTraceOp(4, c, b),
+			},
+			want:     "1(2),3(4)",
+			maxDepth: 1,
+		},
+		{
+			name: "unknown creator",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				testTraceOp(2, a, b),
+				testTraceOp(3, d, c),
+			},
+			want:     "1(2,3)",
+			maxDepth: 1,
+		},
+		{
+			name: "events",
+			ops: []*model.Op{
+				testTraceOp(1, 0, a),
+				{RowId: 2, IsInternal: true, IsEvent: true, CreatorId: a},
+				testTraceOp(3, a, b),
+			},
+			want:     "1(3)",
+			maxDepth: 1,
+		},
+	}
+	for _, test := range tests {
+		roots, maxDepth := buildTraceTree(test.ops, func(op *model.Op) *TraceNode {
+			return &TraceNode{Id: uint64(op.RowId)}
+		})
+		if got := formatTraceTree(t, roots, 0); got != test.want {
+			t.Errorf("%s: tree %s, want %s", test.name, got, test.want)
+		}
+		if maxDepth != test.maxDepth {
+			t.Errorf("%s: max depth %d, want %d", test.name, maxDepth, test.maxDepth)
+		}
+	}
+}
+
+func TestFlattenPair(t *testing.T) {
+	x, y, z := micheline.NewString("x"), micheline.NewString("y"), micheline.NewString("z")
+	tests := []struct {
+		name string
+		prim micheline.Prim
+		want []micheline.Prim
+	}{
+		{"scalar", x, []micheline.Prim{x}},
+		{"pair", micheline.NewPair(x, y), []micheline.Prim{x, y}},
+		{"right comb", micheline.NewPair(x, micheline.NewPair(y, z)), []micheline.Prim{x, y, z}},
+		{"flat comb", micheline.NewCode(micheline.D_PAIR, x, y, z), []micheline.Prim{x, y, z}},
+		{"left pair", micheline.NewPair(micheline.NewPair(x, y), z), []micheline.Prim{micheline.NewPair(x, y), z}},
+	}
+	for _, test := range tests {
+		got := flattenPair(test.prim)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %d args, want %d", test.name, len(got), len(test.want))
+			continue
+		}
+		for i := range got {
+			if !got[i].IsEqual(test.want[i]) {
+				t.Errorf("%s: arg %d %s, want %s", test.name, i, got[i].Dump(), test.want[i].Dump())
+			}
+		}
+	}
+}
+
+func TestDecodeTokenTransfers(t *testing.T) {
+	var (
+		token = tezos.MustParseAddress("KT1PWx2mnDueood7fEmfbBDKx1D9BAnnXitn")
+		alice = tezos.MustParseAddress("tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb")
+		bob   = tezos.MustParseAddress("tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6")
+		nat   = func(n int64) micheline.Prim { return micheline.NewNat(big.NewInt(n)) }
+		addr  = micheline.NewPrim(micheline.T_ADDRESS)
+		unit  = micheline.NewCodeAnno(micheline.T_UNIT, "%other")
+	)
+	fa12Type := micheline.NewType(micheline.NewCode(micheline.T_OR,
+		micheline.NewPairType(addr, micheline.NewPairType(addr, micheline.NewPrim(micheline.T_NAT)), "%transfer"),
+		unit,
+	))
+	fa2Type := micheline.NewType(micheline.NewCode(micheline.T_OR,
+		micheline.NewCodeAnno(micheline.T_LIST, "%transfer",
+			micheline.NewPairType(addr, micheline.NewCode(micheline.T_LIST,
+				micheline.NewPairType(addr, micheline.NewPairType(micheline.NewPrim(micheline.T_NAT), micheline.NewPrim(micheline.T_NAT))),
+			)),
+		),
+		unit,
+	))
+	params := func(val micheline.Prim) []byte {
+		buf, err := micheline.Parameters{Entrypoint: "transfer", Value: val}.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf
+	}
+	tx := func(from, to tezos.Address, id *int64, amount int64) TokenTransfer {
+		tr := TokenTransfer{Token: token, From: from, To: to, Amount: tezos.NewZ(amount)}
+		if id != nil {
+			z := tezos.NewZ(*id)
+			tr.TokenId = &z
+		}
+		return tr
+	}
+	id0, id7 := int64(0), int64(7)
+
+	tests := []struct {
+		name string
+		typ  micheline.Type
+		data []byte
+		want []TokenTransfer
+	}{
+		{
+			name: "fa1.2",
+			typ:  fa12Type,
+			data: params(micheline.NewPair(micheline.NewAddress(alice), micheline.NewPair(micheline.NewString(bob.String()), nat(100)))),
+			want: []TokenTransfer{tx(alice, bob, nil, 100)},
+		},
+		{
+			name: "fa1.2 invalid amount",
+			typ:  fa12Type,
+			data: params(micheline.NewPair(micheline.NewAddress(alice), micheline.NewPair(micheline.NewAddress(bob), micheline.NewString("100")))),
+			want: nil,
+		},
+		{
+			name: "fa2 batches",
+			typ:  fa2Type,
+			data: params(micheline.NewSeq(
+				micheline.NewPair(micheline.NewAddress(alice), micheline.NewSeq(
+					micheline.NewPair(micheline.NewAddress(bob), micheline.NewPair(nat(0), nat(5))),
+					micheline.NewPair(micheline.NewAddress(alice), micheline.NewPair(nat(7), nat(1))),
+				)),
+				micheline.NewPair(micheline.NewAddress(bob), micheline.NewSeq(
+					micheline.NewPair(micheline.NewAddress(alice), micheline.NewPair(nat(7), nat(2))),
+				)),
+			)),
+			want: []TokenTransfer{
+				tx(alice, bob, &id0, 5),
+				tx(alice, alice, &id7, 1),
+				tx(bob, alice, &id7, 2),
+			},
+		},
+		{
+			name: "fa2 empty",
+			typ:  fa2Type,
+			data: params(micheline.NewSeq()),
+			want: []TokenTransfer{},
+		},
+		{
+			name: "fa2 invalid receiver",
+			typ:  fa2Type,
+			data: params(micheline.NewSeq(
+				micheline.NewPair(micheline.NewAddress(alice), micheline.NewSeq(
+					micheline.NewPair(nat(1), micheline.NewPair(nat(0), nat(5))),
+				)),
+			)),
+			want: nil,
+		},
+		{
+			name: "invalid data",
+			typ:  fa12Type,
+			data: []byte{0xff},
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		got := decodeTokenTransfers(test.data, test.typ, token)
+		if (got == nil) != (test.want == nil) || len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i, v := range got {
+			w := test.want[i]
+			if !v.Token.Equal(w.Token) || !v.From.Equal(w.From) || !v.To.Equal(w.To) || !v.Amount.Equal(w.Amount) ||
+				(v.TokenId == nil) != (w.TokenId == nil) || (v.TokenId != nil && !v.TokenId.Equal(*w.TokenId)) {
+				t.Errorf("%s: transfer %d %+v, want %+v", test.name, i, v, w)
+			}
+		}
+	}
+}
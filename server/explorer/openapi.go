@@ -0,0 +1,124 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"blockwatch.cc/tzindex/server"
+)
+
+// API descriptions for the generated OpenAPI specification and client.
+func init() {
+	get := func(path string, doc server.ApiDoc) { server.Describe("GET", path, doc) }
+	post := func(path string, doc server.ApiDoc) { server.Describe("POST", path, doc) }
+	put := func(path string, doc server.ApiDoc) { server.Describe("PUT", path, doc) }
+	del := func(path string, doc server.ApiDoc) { server.Describe("DELETE", path, doc) }
+
+	// chain
+	get("/explorer/tip", server.ApiDoc{Id: "GetTip", Summary: "Blockchain tip", Result: BlockchainTip{}})
+	get("/explorer/config/{ident}", server.ApiDoc{Id: "GetConfig", Summary: "Blockchain config at height", Result: BlockchainConfig{}})
+	get("/explorer/status", server.ApiDoc{Id: "GetStatus", Summary: "Indexer status"})
+	get("/explorer/protocols", server.ApiDoc{Id: "ListProtocols", Summary: "Protocol deployments"})
+	get("/ping", server.ApiDoc{Id: "Ping", Summary: "Server time", Args: PingRequest{}, Result: Pinger{}})
+
+	// accounts
+	get("/explorer/account/{ident}", server.ApiDoc{Id: "GetAccount", Summary: "Account", Args: AccountRequest{}, Result: Account{}})
+	get("/explorer/account/{ident}/contracts", server.ApiDoc{Id: "ListAccountContracts", Summary: "Contracts deployed by account", Args: AccountRequest{}, Result: []Contract{}})
+	get("/explorer/account/{ident}/counterparties", server.ApiDoc{Id: "ListAccountCounterparties", Summary: "Account counterparties", Args: CounterpartyRequest{}, Result: []Counterparty{}})
+	get("/explorer/account/{ident}/operations", server.ApiDoc{Id: "ListAccountOps", Summary: "Account operations", Args: OpsRequest{}, Result: []Op{}})
+	get("/explorer/account/{ident}/op", server.ApiDoc{Id: "ListAccountOpsAlias", Summary: "Account operations", Args: OpsRequest{}, Result: []Op{}, Deprecated: true})
+	get("/explorer/account/{ident}/metadata", server.ApiDoc{Id: "GetAccountMetadata", Summary: "Account metadata", Result: Metadata{}})
+	get("/explorer/account/{ident}/rewards", server.ApiDoc{Id: "ListAccountRewards", Summary: "Account rewards per cycle", Args: AccountRewardsRequest{}, Result: []AccountReward{}})
+	get("/explorer/account/{ident}/statement", server.ApiDoc{Id: "GetAccountStatement", Summary: "Account statement", Args: StatementRequest{}, Result: AccountStatement{}})
+
+	// bakers
+	get("/explorer/bakers", server.ApiDoc{Id: "ListBakers", Summary: "Bakers", Args: BakerListRequest{}, Result: []Baker{}})
+	get("/explorer/bakers/{ident}", server.ApiDoc{Id: "GetBaker", Summary: "Baker", Args: AccountRequest{}, Result: Baker{}})
+	get("/explorer/bakers/{ident}/votes", server.ApiDoc{Id: "ListBakerVotes", Summary: "Baker ballots", Args: OpsRequest{}, Result: []Ballot{}})
+	get("/explorer/bakers/{ident}/endorsements", server.ApiDoc{Id: "ListBakerEndorsements", Summary: "Baker endorsements", Args: OpsRequest{}, Result: []Op{}})
+	get("/explorer/bakers/{ident}/delegations", server.ApiDoc{Id: "ListBakerDelegations", Summary: "Baker delegations", Args: OpsRequest{}, Result: []Op{}})
+	get("/explorer/bakers/{ident}/income/{cycle}", server.ApiDoc{Id: "GetBakerIncome", Summary: "Baker income in cycle", Result: ExplorerIncome{}})
+	get("/explorer/bakers/{ident}/rights/{cycle}", server.ApiDoc{Id: "GetBakerRights", Summary: "Baker rights in cycle", Result: ExplorerRights{}})
+	get("/explorer/bakers/{ident}/snapshot/{cycle}", server.ApiDoc{Id: "GetBakerSnapshot", Summary: "Baker snapshot for cycle", Result: ExplorerSnapshot{}})
+	get("/explorer/bakers/{ident}/metadata", server.ApiDoc{Id: "GetBakerMetadata", Summary: "Baker metadata", Result: Metadata{}})
+	get("/explorer/bakers/{ident}/keys", server.ApiDoc{Id: "ListBakerKeys", Summary: "Baker consensus keys", Result: BakerKeyHistory{}})
+	get("/explorer/bakers/{ident}/forecast", server.ApiDoc{Id: "GetBakerForecast", Summary: "Baker forecast", Args: ForecastRequest{}, Result: BakerForecast{}})
+
+	// contracts
+	get("/explorer/contract/{ident}", server.ApiDoc{Id: "GetContract", Summary: "Contract", Args: AccountRequest{}, Result: Contract{}})
+	get("/explorer/contract/{ident}/calls", server.ApiDoc{Id: "ListContractCalls", Summary: "Contract calls", Args: ContractRequest{}, Result: []Op{}})
+	get("/explorer/contract/{ident}/stats", server.ApiDoc{Id: "ListContractStats", Summary: "Contract daily call statistics", Args: ContractRequest{}, Result: []ContractCallDay{}})
+	get("/explorer/contract/{ident}/errors", server.ApiDoc{Id: "ListContractErrors", Summary: "Contract errors", Args: ContractRequest{}, Result: []ContractErrorGroup{}})
+	get("/explorer/contract/{ident}/events", server.ApiDoc{Id: "ListContractEvents", Summary: "Contract events", Args: ContractEventsRequest{}, Result: []ContractEvent{}})
+	get("/explorer/contract/{ident}/script", server.ApiDoc{Id: "GetContractScript", Summary: "Contract script", Args: ContractRequest{}, Result: Script{}})
+	get("/explorer/contract/{ident}/similar", server.ApiDoc{Id: "ListSimilarContracts", Summary: "Similar contracts", Args: SimilarRequest{}, Result: SimilarContracts{}})
+	get("/explorer/contract/{ident}/storage", server.ApiDoc{Id: "GetContractStorage", Summary: "Contract storage", Args: ContractRequest{}, Result: Storage{}})
+	get("/explorer/contract/{ident}/storage/history", server.ApiDoc{Id: "ListContractStorageHistory", Summary: "Contract storage history", Args: ContractRequest{}, Result: []StorageHistoryEntry{}})
+	get("/explorer/constant/{ident}", server.ApiDoc{Id: "GetConstant", Summary: "Global constant", Result: Constant{}})
+
+	// bigmaps
+	get("/explorer/bigmap/{id}", server.ApiDoc{Id: "GetBigmap", Summary: "Bigmap", Args: ContractRequest{}, Result: Bigmap{}})
+	get("/explorer/bigmap/{id}/keys", server.ApiDoc{Id: "ListBigmapKeys", Summary: "Bigmap keys", Args: ContractRequest{}, Result: []BigmapKey{}})
+	get("/explorer/bigmap/{id}/values", server.ApiDoc{Id: "ListBigmapValues", Summary: "Bigmap values", Args: ContractRequest{}, Result: []BigmapValue{}})
+	get("/explorer/bigmap/{id}/updates", server.ApiDoc{Id: "ListBigmapUpdates", Summary: "Bigmap updates", Args: ContractRequest{}, Result: []BigmapUpdate{}})
+	get("/explorer/bigmap/{id}/diff", server.ApiDoc{Id: "GetBigmapDiff", Summary: "Bigmap diff between blocks", Args: BigmapDiffRequest{}, Result: BigmapDiff{}})
+	get("/explorer/bigmap/{id}/{key}/updates", server.ApiDoc{Id: "ListBigmapKeyUpdates", Summary: "Bigmap key updates", Args: ContractRequest{}, Result: []BigmapUpdate{}})
+	get("/explorer/bigmap/{id}/{key}", server.ApiDoc{Id: "GetBigmapValue", Summary: "Bigmap value", Args: ContractRequest{}, Result: BigmapValue{}})
+
+	// batch lookups
+	post("/explorer/accounts", server.ApiDoc{Id: "GetAccounts", Summary: "Accounts by address", Args: AccountBatchRequest{}, Body: AccountBatchRequest{}, Result: []BatchItem{}})
+	post("/explorer/contracts", server.ApiDoc{Id: "GetContracts", Summary: "Contracts by address", Args: AccountBatchRequest{}, Body: AccountBatchRequest{}, Result: []BatchItem{}})
+	post("/explorer/ops", server.ApiDoc{Id: "GetOps", Summary: "Operation groups by hash", Args: OpBatchRequest{}, Body: OpBatchRequest{}, Result: []BatchItem{}})
+
+	// blocks and operations
+	get("/explorer/block/{ident}", server.ApiDoc{Id: "GetBlock", Summary: "Block", Args: BlockRequest{}, Result: Block{}})
+	get("/explorer/block/{ident}/operations", server.ApiDoc{Id: "ListBlockOps", Summary: "Block operations", Args: OpsRequest{}, Result: []Op{}})
+	get("/explorer/block/{ident}/op", server.ApiDoc{Id: "ListBlockOpsAlias", Summary: "Block operations", Args: OpsRequest{}, Result: []Op{}, Deprecated: true})
+	get("/explorer/op/{ident}", server.ApiDoc{Id: "GetOp", Summary: "Operation group", Args: OpsRequest{}, Result: []Op{}})
+	get("/explorer/op/{ident}/trace", server.ApiDoc{Id: "GetOpTrace", Summary: "Operation trace", Args: OpsRequest{}, Result: OpTrace{}})
+
+	// cycles and governance
+	get("/explorer/cycle/{cycle}", server.ApiDoc{Id: "GetCycle", Summary: "Cycle", Result: Cycle{}})
+	get("/explorer/cycle/{cycle}/bakers", server.ApiDoc{Id: "ListCycleBakers", Summary: "Baker performance in cycle", Args: CycleBakersRequest{}, Result: []CycleBaker{}})
+	get("/explorer/election/{ident}", server.ApiDoc{Id: "GetElection", Summary: "Election", Result: Election{}})
+	get("/explorer/election/{ident}/timeline", server.ApiDoc{Id: "GetElectionTimeline", Summary: "Election timeline", Args: ElectionTimelineRequest{}, Result: ElectionTimeline{}})
+	get("/explorer/election/{ident}/{stage}/ballots", server.ApiDoc{Id: "ListElectionBallots", Summary: "Election ballots", Args: ListRequest{}, Result: []Ballot{}})
+	get("/explorer/election/{ident}/{stage}/voters", server.ApiDoc{Id: "ListElectionVoters", Summary: "Election voters", Args: ListRequest{}, Result: []Voter{}})
+
+	// rankings, prices and cohorts
+	get("/explorer/rank/traffic", server.ApiDoc{Id: "ListRankTraffic", Summary: "Accounts by traffic", Args: ListRequest{}, Result: []RankListItem{}})
+	get("/explorer/rank/volume", server.ApiDoc{Id: "ListRankVolume", Summary: "Accounts by volume", Args: ListRequest{}, Result: []RankListItem{}})
+	get("/explorer/rank/balances", server.ApiDoc{Id: "ListRankBalances", Summary: "Accounts by balance", Args: ListRequest{}, Result: []RankListItem{}})
+	get("/explorer/price", server.ApiDoc{Id: "ListPrices", Summary: "Fiat prices", Args: PriceRequest{}, Result: []Price{}})
+	post("/explorer/price", server.ApiDoc{Id: "ImportPrices", Summary: "Number of imported fiat prices", Body: []Price{}, Result: PriceImportResult{}})
+	put("/explorer/price", server.ApiDoc{Id: "PutPrices", Summary: "Import fiat prices", Body: []Price{}, Result: PriceImportResult{}})
+	get("/explorer/graph", server.ApiDoc{Id: "GetTransferGraph", Summary: "Transfer graph", Args: GraphRequest{}, Result: TransferGraph{}})
+	get("/explorer/cohort", server.ApiDoc{Id: "ListCohorts", Summary: "Address cohorts", Result: []Cohort{}})
+	post("/explorer/cohort", server.ApiDoc{Id: "CreateCohort", Summary: "Created address cohort", Body: CohortRequest{}, Result: Cohort{}, Status: 201})
+	get("/explorer/cohort/{name}", server.ApiDoc{Id: "GetCohort", Summary: "Address cohort", Result: Cohort{}})
+	put("/explorer/cohort/{name}", server.ApiDoc{Id: "UpdateCohort", Summary: "Add or relabel cohort members", Body: []CohortMember{}, Result: Cohort{}})
+	del("/explorer/cohort/{name}", server.ApiDoc{Id: "RemoveCohort", Summary: "Remove cohort or selected members", Args: CohortRemoveRequest{}, Status: 204})
+	get("/explorer/cohort/{name}/balance", server.ApiDoc{Id: "GetCohortBalance", Summary: "Cohort balance", Result: CohortBalance{}})
+	get("/explorer/cohort/{name}/flows", server.ApiDoc{Id: "ListCohortFlows", Summary: "Cohort daily flows", Args: CohortFlowRequest{}, Result: []CohortFlowDay{}})
+
+	// graphql
+	get("/explorer/graphql", server.ApiDoc{Id: "GetGraphQL", Summary: "Result of a GraphQL query", Args: GraphQLRequest{}, Result: GraphQLResponse{}})
+	post("/explorer/graphql", server.ApiDoc{Id: "QueryGraphQL", Summary: "Result of a GraphQL query", Body: GraphQLRequest{}, Result: GraphQLResponse{}})
+
+	// metadata
+	get("/metadata", server.ApiDoc{Id: "ListMetadata", Summary: "Metadata entries", Args: MetadataListRequest{}, Result: []Metadata{}})
+	post("/metadata", server.ApiDoc{Id: "CreateMetadata", Summary: "Created or replaced metadata entries", Body: []Metadata{}, Result: []Metadata{}, Status: 201})
+	del("/metadata", server.ApiDoc{Id: "PurgeMetadata", Summary: "Remove all metadata", Status: 204})
+	get("/metadata/{ident}", server.ApiDoc{Id: "GetMetadata", Summary: "Metadata for address", Result: Metadata{}})
+	put("/metadata/{ident}", server.ApiDoc{Id: "UpdateMetadata", Summary: "Update metadata for address", Body: Metadata{}, Result: Metadata{}})
+	del("/metadata/{ident}", server.ApiDoc{Id: "RemoveMetadata", Summary: "Remove metadata for address", Status: 204})
+	get("/metadata/{ident}/{asset_id}", server.ApiDoc{Id: "GetAssetMetadata", Summary: "Metadata for token", Result: Metadata{}})
+	put("/metadata/{ident}/{asset_id}", server.ApiDoc{Id: "UpdateAssetMetadata", Summary: "Update metadata for token", Body: Metadata{}, Result: Metadata{}})
+	del("/metadata/{ident}/{asset_id}", server.ApiDoc{Id: "RemoveAssetMetadata", Summary: "Remove metadata for token", Status: 204})
+	get("/metadata/describe/{ident}", server.ApiDoc{Id: "DescribeMetadata", Summary: "Title, description and image of an address, block or operation", Result: MetadataDescriptor{}})
+	get("/metadata/describe/{ident}/{num}", server.ApiDoc{Id: "DescribeMetadataNum", Summary: "Title, description and image of an event, cycle or election", Result: MetadataDescriptor{}})
+	get("/metadata/schemas", server.ApiDoc{Id: "ListMetadataSchemas", Summary: "Metadata schema names", Result: []string{}})
+	get("/metadata/schemas/{schema}", server.ApiDoc{Id: "GetMetadataSchema", Summary: "Metadata JSON schema"})
+	get("/metadata/schemas/{schema}.json", server.ApiDoc{Id: "GetMetadataSchemaAlias", Summary: "Metadata JSON schema", Deprecated: true})
+	get("/metadata/sitemap", server.ApiDoc{Id: "ListMetadataAddresses", Summary: "Addresses with metadata", Result: []string{}})
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+const maxPriceImport = 1 << 20
+
+func init() {
+	server.Register(Price{})
+}
+
+var (
+	_ server.RESTful  = (*Price)(nil)
+	_ server.Resource = (*PriceList)(nil)
+)
+
+type Price struct {
+	Time     time.Time `json:"time"`
+	Currency string    `json:"currency"`
+	Price    float64   `json:"price"`
+}
+
+func (p Price) RESTPrefix() string {
+	return "/explorer/price"
+}
+
+func (p Price) RESTPath(r *mux.Router) string {
+	return p.RESTPrefix()
+}
+
+func (p Price) RegisterDirectRoutes(r *mux.Router) error {
+	if !server.HasIndex(index.PriceIndexKey) {
+		return nil
+	}
+	r.HandleFunc(p.RESTPrefix(), server.C(ListPrices)).Methods("GET")
+	r.HandleFunc(p.RESTPrefix(), server.C(ImportPrices)).Methods("POST", "PUT")
+	return nil
+}
+
+func (p Price) RegisterRoutes(r *mux.Router) error {
+	return nil
+}
+
+type PriceList struct {
+	list     []Price
+	modified time.Time
+	expires  time.Time
+}
+
+func (l PriceList) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l PriceList) LastModified() time.Time      { return l.modified }
+func (l PriceList) Expires() time.Time           { return l.expires }
+
+type PriceRequest struct {
+	ListRequest
+	Currency string    `schema:"currency"`
+	From     util.Time `schema:"from"`
+	To       util.Time `schema:"to"`
+}
+
+func (r *PriceRequest) Parse(ctx *server.Context) {
+	r.Currency = strings.ToUpper(r.Currency)
+	if !model.IsValidCurrency(r.Currency) {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "missing or invalid currency", nil))
+	}
+}
+
+func ListPrices(ctx *server.Context) (interface{}, int) {
+	args := &PriceRequest{}
+	ctx.ParseRequestArgs(args)
+	prices, err := ctx.Indexer.ListPrices(ctx, args.Currency, args.From.Time(), args.To.Time())
+	if err != nil {
+		switch err {
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access price table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+	if args.Order == pack.OrderDesc {
+		sort.SliceStable(prices, func(i, j int) bool { return prices[i].Timestamp.After(prices[j].Timestamp) })
+	}
+	resp := &PriceList{
+		list:    make([]Price, 0),
+		expires: ctx.Now.Add(ctx.Cfg.Http.CacheMaxExpires),
+	}
+	limit := ctx.Cfg.ClampExplore(args.Limit)
+	for i, v := range prices {
+		if uint(i) < args.Offset {
+			continue
+		}
+		if uint(len(resp.list)) >= limit {
+			break
+		}
+		resp.list = append(resp.list, Price{
+			Time:     v.Timestamp,
+			Currency: v.Currency,
+			Price:    v.Price,
+		})
+		if v.Timestamp.After(resp.modified) {
+			resp.modified = v.Timestamp
+		}
+	}
+	return resp, http.StatusOK
+}
+
+type PriceImportResult struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+}
+
+// ImportPrices imports prices from a JSON list or a CSV file with columns
+// timestamp, currency, price. A CSV header row is optional. Timestamps may
+// be RFC3339 strings or UNIX timestamps.
+func ImportPrices(ctx *server.Context) (interface{}, int) {
+	var prices []*model.Price
+	mt, _, _ := mime.ParseMediaType(ctx.Request.Header.Get("Content-Type"))
+	switch mt {
+	case "text/csv":
+		prices = parsePriceCSV(io.LimitReader(ctx.Request.Body, maxPriceImport*64))
+	default:
+		list := make([]Price, 0)
+		ctx.ParseRequestArgs(&list)
+		prices = make([]*model.Price, len(list))
+		for i, v := range list {
+			prices[i] = &model.Price{
+				Timestamp: v.Time.UTC(),
+				Currency:  strings.ToUpper(v.Currency),
+				Price:     v.Price,
+			}
+		}
+	}
+	if len(prices) == 0 {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "empty price list", nil))
+	}
+	if len(prices) > maxPriceImport {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("too many prices, max %d", maxPriceImport), nil))
+	}
+	for i, v := range prices {
+		if !model.IsValidCurrency(v.Currency) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid currency '%s' at position %d", v.Currency, i), nil))
+		}
+		if v.Timestamp.IsZero() || v.Price < 0 {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid price at position %d", i), nil))
+		}
+	}
+	ins, upd, err := ctx.Indexer.AddPrices(ctx, prices)
+	if err != nil {
+		switch err {
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access price table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, "cannot import prices", err))
+		}
+	}
+	ctx.Server.PurgeResponseCache()
+	return PriceImportResult{Inserted: ins, Updated: upd}, http.StatusOK
+}
+
+func parsePriceCSV(r io.Reader) []*model.Price {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+	prices := make([]*model.Price, 0)
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid csv data", err))
+		}
+		tm, err := util.ParseTime(rec[0])
+		if err != nil || tm.IsZero() {
+			if line == 1 {
+				// skip header
+				continue
+			}
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid timestamp at line %d", line), err))
+		}
+		price, err := strconv.ParseFloat(rec[2], 64)
+		if err != nil {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid price at line %d", line), err))
+		}
+		prices = append(prices, &model.Price{
+			Timestamp: tm.Time().UTC(),
+			Currency:  strings.ToUpper(rec[1]),
+			Price:     price,
+		})
+	}
+	return prices
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/packdb/vec"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+const (
+	RewardStatusPending  = "pending"   // payout cycle has not ended yet
+	RewardStatusPaid     = "paid"      // received at least the estimated reward on time
+	RewardStatusPartial  = "partial"   // received less than the estimated reward
+	RewardStatusLate     = "late"      // received after the expected payout cycle
+	RewardStatusMissing  = "missing"   // nothing received after the expected payout cycle
+	RewardStatusBelowMin = "below_min" // estimated reward is below baker's min payout
+	RewardStatusNone     = "none"      // not delegated or no baker income
+)
+
+type RewardPayout struct {
+	Sender    tezos.Address `json:"sender"`
+	Amount    float64       `json:"amount"`
+	Height    int64         `json:"height"`
+	Cycle     int64         `json:"cycle"`
+	Timestamp time.Time     `json:"time"`
+	OpHash    tezos.OpHash  `json:"op_hash"`
+	IsLate    bool          `json:"is_late"`
+}
+
+type AccountReward struct {
+	Cycle           int64          `json:"cycle"`
+	SnapshotCycle   int64          `json:"snapshot_cycle"`
+	Baker           tezos.Address  `json:"baker"`
+	Balance         float64        `json:"balance"`
+	StakingBalance  float64        `json:"staking_balance"`
+	StakeShare      float64        `json:"stake_share"`
+	BakerIncome     float64        `json:"baker_income"`
+	BakerFee        float64        `json:"baker_fee"`
+	EstimatedReward float64        `json:"estimated_reward"`
+	Received        float64        `json:"received"`
+	PayoutCycle     int64          `json:"payout_cycle"`
+	PayoutDelay     bool           `json:"payout_delay"`
+	Status          string         `json:"status"`
+	Payouts         []RewardPayout `json:"payouts"`
+
+	// internal
+	bakerId   model.AccountID
+	estimated int64
+	received  int64
+	minPayout int64
+}
+
+type AccountRewardsRequest struct {
+	ListRequest // offset, limit, cursor (cycle), order
+}
+
+// ListAccountRewards reconciles a delegator's expected share of baker income with
+// payouts received from the baker or its registered payout addresses. Expected
+// payouts are due in the cycle after a reward cycle ends, or PreservedCycles
+// later when a baker announces `payout_delay` in its metadata.
+func ListAccountRewards(ctx *server.Context) (interface{}, int) {
+	args := &AccountRewardsRequest{
+		ListRequest: ListRequest{
+			Order: pack.OrderDesc,
+		},
+	}
+	ctx.ParseRequestArgs(args)
+	acc := loadAccount(ctx)
+	limit := int64(ctx.Cfg.ClampExplore(args.Limit))
+
+	// select reward cycles in request order
+	tipCycle := ctx.Params.CycleFromHeight(ctx.Tip.BestHeight)
+	cycles := make([]int64, 0, limit)
+	if args.Order == pack.OrderAsc {
+		start := ctx.Params.CycleFromHeight(acc.FirstSeen) + int64(args.Offset)
+		if args.Cursor > 0 {
+			start = util.Max64(start, int64(args.Cursor)+1)
+		}
+		for c := start; c <= tipCycle && int64(len(cycles)) < limit; c++ {
+			cycles = append(cycles, c)
+		}
+	} else {
+		start := tipCycle - int64(args.Offset)
+		if args.Cursor > 0 {
+			start = util.Min64(start, int64(args.Cursor)-1)
+		}
+		for c := start; c >= 0 && int64(len(cycles)) < limit; c-- {
+			cycles = append(cycles, c)
+		}
+	}
+	resp := make([]*AccountReward, 0, len(cycles))
+	if len(cycles) == 0 {
+		return resp, http.StatusOK
+	}
+
+	// map reward cycles to snapshot base cycles
+	baseCycles := make(map[int64]int64)
+	minCycle, maxCycle := cycles[0], cycles[len(cycles)-1]
+	if minCycle > maxCycle {
+		minCycle, maxCycle = maxCycle, minCycle
+	}
+	minBase, maxBase := maxCycle, int64(0)
+	for _, c := range cycles {
+		b := util.Max64(0, ctx.Params.ForCycle(c).SnapshotBaseCycle(c))
+		baseCycles[c] = b
+		minBase, maxBase = util.Min64(minBase, b), util.Max64(maxBase, b)
+	}
+
+	snapshotTable, err := ctx.Indexer.Table(index.SnapshotTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing snapshot table", err))
+	}
+	incomeTable, err := ctx.Indexer.Table(index.IncomeTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing income table", err))
+	}
+	flowTable, err := ctx.Indexer.Table(index.FlowTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing flow table", err))
+	}
+	opTable, err := ctx.Indexer.Table(index.OpTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing op table", err))
+	}
+
+	// load delegator snapshots
+	self := make([]*model.Snapshot, 0)
+	err = pack.NewQuery("api.rewards.delegator").
+		WithTable(snapshotTable).
+		AndEqual("account_id", acc.RowId).
+		AndRange("cycle", minBase, maxBase).
+		AndEqual("is_selected", true).
+		AndEqual("is_baker", false).
+		WithFields("cycle", "baker_id", "balance").
+		Execute(ctx.Context, &self)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read snapshot", err))
+	}
+	selfMap := make(map[int64]*model.Snapshot)
+	bakerIds := make([]uint64, 0)
+	for _, v := range self {
+		selfMap[v.Cycle] = v
+		bakerIds = append(bakerIds, v.BakerId.Value())
+	}
+	bakerIds = vec.UniqueUint64Slice(bakerIds)
+
+	// load baker snapshots and income
+	type bakerCycle struct {
+		id    model.AccountID
+		cycle int64
+	}
+	bakerSnaps := make(map[bakerCycle]*model.Snapshot)
+	incomes := make(map[bakerCycle]*model.Income)
+	if len(bakerIds) > 0 {
+		snaps := make([]*model.Snapshot, 0)
+		err = pack.NewQuery("api.rewards.bakers").
+			WithTable(snapshotTable).
+			AndIn("account_id", bakerIds).
+			AndRange("cycle", minBase, maxBase).
+			AndEqual("is_selected", true).
+			AndEqual("is_baker", true).
+			WithFields("cycle", "account_id", "balance", "delegated").
+			Execute(ctx.Context, &snaps)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read snapshot", err))
+		}
+		for _, v := range snaps {
+			bakerSnaps[bakerCycle{v.AccountId, v.Cycle}] = v
+		}
+
+		list := make([]*model.Income, 0)
+		err = pack.NewQuery("api.rewards.income").
+			WithTable(incomeTable).
+			AndIn("account_id", bakerIds).
+			AndRange("cycle", minCycle, maxCycle).
+			WithFields("cycle", "account_id", "total_income", "total_loss").
+			Execute(ctx.Context, &list)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read income", err))
+		}
+		for _, v := range list {
+			incomes[bakerCycle{v.AccountId, v.Cycle}] = v
+		}
+	}
+
+	// compose reward estimates
+	senders := make(map[model.AccountID]map[uint64]struct{})
+	for _, c := range cycles {
+		base := baseCycles[c]
+		r := &AccountReward{
+			Cycle:         c,
+			SnapshotCycle: base,
+			Status:        RewardStatusNone,
+			Payouts:       make([]RewardPayout, 0),
+		}
+		resp = append(resp, r)
+		snap, ok := selfMap[base]
+		if !ok {
+			continue
+		}
+		r.bakerId = snap.BakerId
+		r.Baker = ctx.Indexer.LookupAddress(ctx, snap.BakerId)
+		r.Balance = ctx.Params.ConvertValue(snap.Balance)
+		r.PayoutCycle = c + 1
+		if md, ok := lookupMetadataById(ctx, snap.BakerId, 0, false); ok {
+			r.BakerFee = md.Fee
+			r.PayoutDelay = md.PayoutDelay
+			r.minPayout = ctx.Params.ConvertAmount(md.MinPayout)
+			if md.PayoutDelay {
+				r.PayoutCycle += ctx.Params.ForCycle(c).PreservedCycles
+			}
+		}
+		if bs, ok := bakerSnaps[bakerCycle{snap.BakerId, base}]; ok {
+			staking := bs.Balance + bs.Delegated
+			r.StakingBalance = ctx.Params.ConvertValue(staking)
+			if staking > 0 {
+				r.StakeShare = float64(snap.Balance) / float64(staking)
+			}
+		}
+		if inc, ok := incomes[bakerCycle{snap.BakerId, c}]; ok {
+			net := util.Max64(0, inc.TotalIncome-inc.TotalLoss)
+			r.BakerIncome = ctx.Params.ConvertValue(net)
+			r.estimated = int64(float64(net) * r.StakeShare * (1 - r.BakerFee))
+			r.EstimatedReward = ctx.Params.ConvertValue(r.estimated)
+		}
+
+		// collect baker and payout addresses as eligible senders
+		if _, ok := senders[snap.BakerId]; !ok {
+			m := map[uint64]struct{}{snap.BakerId.Value(): {}}
+			for _, id := range lookupPayoutIds(ctx, snap.BakerId) {
+				m[id] = struct{}{}
+			}
+			senders[snap.BakerId] = m
+		}
+	}
+
+	// load payouts received from any eligible sender since the earliest
+	// expected payout cycle
+	senderIds := make([]uint64, 0)
+	for _, m := range senders {
+		for id := range m {
+			senderIds = append(senderIds, id)
+		}
+	}
+	flows := make([]*model.Flow, 0)
+	if len(senderIds) > 0 {
+		err = pack.NewQuery("api.rewards.payouts").
+			WithTable(flowTable).
+			AndEqual("account_id", acc.RowId).
+			AndIn("counterparty_id", vec.UniqueUint64Slice(senderIds)).
+			AndEqual("category", model.FlowCategoryBalance).
+			AndEqual("operation", model.FlowTypeTransaction).
+			AndGt("amount_in", 0).
+			AndGte("height", ctx.Params.CycleStartHeight(minCycle+1)).
+			WithFields("height", "cycle", "time", "op_n", "counterparty_id", "amount_in").
+			Execute(ctx.Context, &flows)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read payouts", err))
+		}
+	}
+
+	// resolve op hashes
+	type opPos struct {
+		height int64
+		n      int
+	}
+	opHashes := make(map[opPos]tezos.OpHash)
+	if len(flows) > 0 {
+		heights := make([]int64, len(flows))
+		for i, v := range flows {
+			heights[i] = v.Height
+		}
+		ops := make([]*model.Op, 0)
+		err = pack.NewQuery("api.rewards.ops").
+			WithTable(opTable).
+			AndIn("height", vec.UniqueInt64Slice(heights)).
+			AndEqual("receiver_id", acc.RowId).
+			WithFields("height", "op_n", "hash").
+			Execute(ctx.Context, &ops)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read payout ops", err))
+		}
+		for _, v := range ops {
+			opHashes[opPos{v.Height, v.OpN}] = v.Hash
+		}
+	}
+
+	// reconcile in ascending cycle order: first match payouts received in the
+	// expected payout cycle, then assign remaining payouts to the oldest
+	// unpaid cycle of the same baker
+	sorted := make([]*AccountReward, len(resp))
+	copy(sorted, resp)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cycle < sorted[j].Cycle })
+	used := make([]bool, len(flows))
+	assign := func(r *AccountReward, i int, late bool) {
+		f := flows[i]
+		used[i] = true
+		r.received += f.AmountIn
+		r.Payouts = append(r.Payouts, RewardPayout{
+			Sender:    ctx.Indexer.LookupAddress(ctx, f.CounterPartyId),
+			Amount:    ctx.Params.ConvertValue(f.AmountIn),
+			Height:    f.Height,
+			Cycle:     f.Cycle,
+			Timestamp: f.Timestamp,
+			OpHash:    opHashes[opPos{f.Height, f.OpN}],
+			IsLate:    late,
+		})
+	}
+	for _, r := range sorted {
+		if r.bakerId == 0 {
+			continue
+		}
+		for i, f := range flows {
+			if used[i] || f.Cycle != r.PayoutCycle {
+				continue
+			}
+			if _, ok := senders[r.bakerId][f.CounterPartyId.Value()]; ok {
+				assign(r, i, false)
+			}
+		}
+	}
+	for i, f := range flows {
+		if used[i] {
+			continue
+		}
+		for _, r := range sorted {
+			if r.bakerId == 0 || r.received > 0 || r.PayoutCycle >= f.Cycle {
+				continue
+			}
+			if _, ok := senders[r.bakerId][f.CounterPartyId.Value()]; ok {
+				assign(r, i, true)
+				break
+			}
+		}
+	}
+
+	// set status
+	for _, r := range resp {
+		r.Received = ctx.Params.ConvertValue(r.received)
+		if r.bakerId == 0 {
+			continue
+		}
+		var isLate bool
+		for _, p := range r.Payouts {
+			isLate = isLate || p.IsLate
+		}
+		switch {
+		case r.received > 0 && isLate:
+			r.Status = RewardStatusLate
+		case r.received > 0 && r.received < r.estimated*99/100:
+			r.Status = RewardStatusPartial
+		case r.received > 0:
+			r.Status = RewardStatusPaid
+		case r.estimated == 0:
+			r.Status = RewardStatusNone
+		case r.minPayout > 0 && r.estimated < r.minPayout:
+			r.Status = RewardStatusBelowMin
+		case r.PayoutCycle >= tipCycle:
+			r.Status = RewardStatusPending
+		default:
+			r.Status = RewardStatusMissing
+		}
+	}
+
+	return resp, http.StatusOK
+}
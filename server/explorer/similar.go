@@ -0,0 +1,182 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+type SimilarRequest struct {
+	ListRequest // offset, limit, cursor, order
+
+	Kind     string `schema:"kind"`     // code, interface, shape (default)
+	Timeline bool   `schema:"timeline"` // include monthly deployment counts
+
+	// decoded values
+	FamilyKind model.CodeFamilyKind `schema:"-"`
+}
+
+func (r *SimilarRequest) Parse(ctx *server.Context) {
+	r.FamilyKind = model.CodeFamilyKindShape
+	if len(r.Kind) > 0 {
+		r.FamilyKind = model.ParseCodeFamilyKind(r.Kind)
+		if !r.FamilyKind.IsValid() {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid family kind '%s'", r.Kind), nil))
+		}
+	}
+}
+
+type CodeFamilyDeployments struct {
+	Month time.Time `json:"month"`
+	Count int       `json:"count"`
+}
+
+type CodeFamily struct {
+	Kind          model.CodeFamilyKind    `json:"kind"`
+	Hash          string                  `json:"hash"`
+	NContracts    int                     `json:"n_contracts"`
+	FirstContract tezos.Address           `json:"first_contract"`
+	FirstDeployer tezos.Address           `json:"first_deployer"`
+	FirstSeen     int64                   `json:"first_seen"`
+	LastSeen      int64                   `json:"last_seen"`
+	FirstSeenTime time.Time               `json:"first_seen_time"`
+	LastSeenTime  time.Time               `json:"last_seen_time"`
+	Timeline      []CodeFamilyDeployments `json:"timeline,omitempty"`
+}
+
+type SimilarContract struct {
+	Address       tezos.Address `json:"address"`
+	Creator       tezos.Address `json:"creator"`
+	Height        int64         `json:"height"`
+	Time          time.Time     `json:"time"`
+	SameCode      bool          `json:"same_code"`
+	SameInterface bool          `json:"same_interface"`
+	SameShape     bool          `json:"same_shape"`
+}
+
+type SimilarContracts struct {
+	Contract  tezos.Address     `json:"contract"`
+	Families  []CodeFamily      `json:"families"`
+	Contracts []SimilarContract `json:"contracts"`
+	Cursor    uint64            `json:"cursor,omitempty"`
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (s SimilarContracts) LastModified() time.Time { return s.modified }
+func (s SimilarContracts) Expires() time.Time      { return s.expires }
+
+var _ server.Resource = (*SimilarContracts)(nil)
+
+// list contracts with identical code, interface or code shape
+func ListSimilarContracts(ctx *server.Context) (interface{}, int) {
+	args := &SimilarRequest{}
+	ctx.ParseRequestArgs(args)
+	cc := loadContract(ctx)
+
+	self, err := ctx.Indexer.LookupCodeFamilyMember(ctx, cc.AccountId)
+	if err != nil {
+		switch err {
+		case index.ErrNoCodeFamilyEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no code family for contract", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+
+	resp := &SimilarContracts{
+		Contract:  cc.Address,
+		Families:  make([]CodeFamily, 0, 3),
+		Contracts: make([]SimilarContract, 0),
+		modified:  ctx.Tip.BestTime,
+		expires:   ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+
+	for _, kind := range []model.CodeFamilyKind{
+		model.CodeFamilyKindCode,
+		model.CodeFamilyKindInterface,
+		model.CodeFamilyKindShape,
+	} {
+		f, err := ctx.Indexer.LookupCodeFamily(ctx, kind, self.Hash(kind))
+		if err != nil {
+			if err == index.ErrNoCodeFamilyEntry {
+				continue
+			}
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+		family := CodeFamily{
+			Kind:          f.Kind,
+			Hash:          util.U64String(f.Hash).Hex(),
+			NContracts:    f.NContracts,
+			FirstContract: ctx.Indexer.LookupAddress(ctx, f.ContractId),
+			FirstDeployer: ctx.Indexer.LookupAddress(ctx, f.CreatorId),
+			FirstSeen:     f.FirstSeen,
+			LastSeen:      f.LastSeen,
+			FirstSeenTime: f.FirstTime,
+			LastSeenTime:  f.LastTime,
+		}
+		if args.Timeline {
+			family.Timeline = loadCodeFamilyTimeline(ctx, f)
+		}
+		resp.Families = append(resp.Families, family)
+	}
+
+	// list members of the requested family
+	r := etl.ListRequest{
+		Offset: args.Offset,
+		Limit:  ctx.Cfg.ClampExplore(args.Limit),
+		Cursor: args.Cursor,
+		Order:  args.Order,
+	}
+	members, err := ctx.Indexer.ListCodeFamilyMembers(ctx, args.FamilyKind, self.Hash(args.FamilyKind), r)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read code family", err))
+	}
+	for _, m := range members {
+		resp.Cursor = m.RowId
+		if m.AccountId == self.AccountId {
+			continue
+		}
+		resp.Contracts = append(resp.Contracts, SimilarContract{
+			Address:       ctx.Indexer.LookupAddress(ctx, m.AccountId),
+			Creator:       ctx.Indexer.LookupAddress(ctx, m.CreatorId),
+			Height:        m.Height,
+			Time:          m.Timestamp,
+			SameCode:      m.CodeHash == self.CodeHash,
+			SameInterface: m.InterfaceHash == self.InterfaceHash,
+			SameShape:     m.ShapeHash == self.ShapeHash,
+		})
+	}
+
+	return resp, http.StatusOK
+}
+
+// loadCodeFamilyTimeline counts family deployments per calendar month.
+func loadCodeFamilyTimeline(ctx *server.Context, f *model.CodeFamily) []CodeFamilyDeployments {
+	members, err := ctx.Indexer.ListCodeFamilyMembers(ctx, f.Kind, f.Hash, etl.ListRequest{})
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read code family", err))
+	}
+	timeline := make([]CodeFamilyDeployments, 0)
+	for _, m := range members {
+		yy, mm, _ := m.Timestamp.UTC().Date()
+		month := time.Date(yy, mm, 1, 0, 0, 0, 0, time.UTC)
+		if n := len(timeline); n > 0 && timeline[n-1].Month.Equal(month) {
+			timeline[n-1].Count++
+			continue
+		}
+		timeline = append(timeline, CodeFamilyDeployments{Month: month, Count: 1})
+	}
+	return timeline
+}
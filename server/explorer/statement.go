@@ -0,0 +1,480 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/encoding/csv"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// Statement entry kinds
+const (
+	StatementReceive = "receive"
+	StatementSend    = "send"
+	StatementFee     = "fee"
+	StatementReward  = "reward"
+	StatementBurn    = "burn"
+)
+
+var statementColumns = []string{
+	"time",
+	"height",
+	"op_n",
+	"op_c",
+	"op_i",
+	"kind",
+	"operation",
+	"category",
+	"counterparty",
+	"amount_in",
+	"amount_out",
+	"holdings",
+	"currency",
+	"price",
+	"price_missing",
+	"value",
+	"cost_basis",
+	"gain",
+}
+
+type StatementRequest struct {
+	Currency string    `schema:"currency"`
+	From     util.Time `schema:"from"`
+	To       util.Time `schema:"to"`
+	Format   string    `schema:"format"` // json (default), csv
+}
+
+func (r *StatementRequest) Parse(ctx *server.Context) {
+	r.Currency = strings.ToUpper(r.Currency)
+	if r.Currency == "" {
+		r.Currency = "USD"
+	}
+	if !model.IsValidCurrency(r.Currency) {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid currency", nil))
+	}
+	if r.To.IsZero() || r.To.Time().After(ctx.Tip.BestTime) {
+		r.To = util.NewTime(ctx.Tip.BestTime)
+	}
+	if r.From.After(r.To) {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "from is after to", nil))
+	}
+	switch r.Format {
+	case "":
+		r.Format = "json"
+	case "json", "csv":
+	default:
+		panic(server.EBadRequest(server.EC_CONTENTTYPE_UNSUPPORTED, fmt.Sprintf("unsupported format '%s'", r.Format), nil))
+	}
+}
+
+// StatementEntry is a single ledger line. Amounts are in tez, fiat values
+// use the most recent price at or before block time. Entries before the first
+// known price have no price and value and are flagged with PriceMissing.
+// Gain covers only the part of an out-flow with a known cost basis.
+type StatementEntry struct {
+	Time         time.Time     `json:"time"`
+	Height       int64         `json:"height"`
+	OpN          int           `json:"op_n"`
+	OpC          int           `json:"op_c"`
+	OpI          int           `json:"op_i"`
+	Kind         string        `json:"kind"`
+	Operation    string        `json:"operation"`
+	Category     string        `json:"category"`
+	Counterparty tezos.Address `json:"counterparty,omitempty"`
+	AmountIn     float64       `json:"amount_in"`
+	AmountOut    float64       `json:"amount_out"`
+	Holdings     float64       `json:"holdings"`
+	Currency     string        `json:"currency"`
+	Price        float64       `json:"price"`
+	PriceMissing bool          `json:"price_missing,omitempty"` // flow before the first known price
+	Value        float64       `json:"value"`
+	CostBasis    float64       `json:"cost_basis"`
+	Gain         float64       `json:"gain"`
+}
+
+func (e StatementEntry) MarshalCSV() ([]string, error) {
+	res := make([]string, len(statementColumns))
+	for i, v := range statementColumns {
+		switch v {
+		case "time":
+			res[i] = strconv.Quote(e.Time.Format(time.RFC3339))
+		case "height":
+			res[i] = strconv.FormatInt(e.Height, 10)
+		case "op_n":
+			res[i] = strconv.Itoa(e.OpN)
+		case "op_c":
+			res[i] = strconv.Itoa(e.OpC)
+		case "op_i":
+			res[i] = strconv.Itoa(e.OpI)
+		case "kind":
+			res[i] = strconv.Quote(e.Kind)
+		case "operation":
+			res[i] = strconv.Quote(e.Operation)
+		case "category":
+			res[i] = strconv.Quote(e.Category)
+		case "counterparty":
+			if e.Counterparty.IsValid() {
+				res[i] = strconv.Quote(e.Counterparty.String())
+			} else {
+				res[i] = strconv.Quote("")
+			}
+		case "amount_in":
+			res[i] = strconv.FormatFloat(e.AmountIn, 'f', -1, 64)
+		case "amount_out":
+			res[i] = strconv.FormatFloat(e.AmountOut, 'f', -1, 64)
+		case "holdings":
+			res[i] = strconv.FormatFloat(e.Holdings, 'f', -1, 64)
+		case "currency":
+			res[i] = strconv.Quote(e.Currency)
+		case "price":
+			res[i] = strconv.FormatFloat(e.Price, 'f', -1, 64)
+		case "price_missing":
+			res[i] = strconv.FormatBool(e.PriceMissing)
+		case "value":
+			res[i] = strconv.FormatFloat(e.Value, 'f', 2, 64)
+		case "cost_basis":
+			res[i] = strconv.FormatFloat(e.CostBasis, 'f', 2, 64)
+		case "gain":
+			res[i] = strconv.FormatFloat(e.Gain, 'f', 2, 64)
+		}
+	}
+	return res, nil
+}
+
+type StatementTotals struct {
+	Received       float64 `json:"received"`
+	Sent           float64 `json:"sent"`
+	Fees           float64 `json:"fees"`
+	Rewards        float64 `json:"rewards"`
+	Burned         float64 `json:"burned"`
+	ReceivedValue  float64 `json:"received_value"`
+	SentValue      float64 `json:"sent_value"`
+	FeesValue      float64 `json:"fees_value"`
+	RewardsValue   float64 `json:"rewards_value"`
+	BurnedValue    float64 `json:"burned_value"`
+	RealizedGain   float64 `json:"realized_gain"`
+	UncoveredOut   float64 `json:"uncovered_out"` // out-flows without known acquisition lot or price
+	PriceMissing   int     `json:"price_missing"` // entries before the first known price
+	OpeningBalance float64 `json:"opening_holdings"`
+	ClosingBalance float64 `json:"closing_holdings"`
+	OpeningCost    float64 `json:"opening_cost_basis"`
+	ClosingCost    float64 `json:"closing_cost_basis"`
+}
+
+type AccountStatement struct {
+	Address  tezos.Address    `json:"address"`
+	Currency string           `json:"currency"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Totals   StatementTotals  `json:"totals"`
+	Entries  []StatementEntry `json:"entries"`
+
+	modified time.Time `json:"-"`
+	expires  time.Time `json:"-"`
+}
+
+func (s AccountStatement) LastModified() time.Time { return s.modified }
+func (s AccountStatement) Expires() time.Time      { return s.expires }
+
+var _ server.Resource = (*AccountStatement)(nil)
+
+// statementKind classifies a flow into a ledger entry kind. Movements between
+// sub-accounts of the same account (deposits, unfreeze, delegation) are not
+// part of the ledger and return an empty kind.
+func statementKind(f *model.Flow) string {
+	switch f.Category {
+	case model.FlowCategoryBalance:
+		switch {
+		case f.IsFee:
+			return StatementFee
+		case f.IsBurned:
+			return StatementBurn
+		case f.IsUnfrozen, f.IsShielded, f.IsUnshielded:
+			return ""
+		}
+		switch f.Operation {
+		case model.FlowTypeInternal, model.FlowTypeDeposit, model.FlowTypeDepositsLimit:
+			return ""
+		case model.FlowTypeBaking, model.FlowTypeEndorsement, model.FlowTypeReward,
+			model.FlowTypeBonus, model.FlowTypeNonceRevelation, model.FlowTypeSubsidy,
+			model.FlowTypeRollupReward, model.FlowTypeAirdrop:
+			if f.AmountIn > 0 {
+				return StatementReward
+			}
+		case model.FlowTypePenalty, model.FlowTypeRollupPenalty:
+			if f.AmountOut > 0 {
+				return StatementBurn
+			}
+		}
+		if f.AmountIn > 0 {
+			return StatementReceive
+		}
+		if f.AmountOut > 0 {
+			return StatementSend
+		}
+	case model.FlowCategoryRewards, model.FlowCategoryFees:
+		// pre-Ithaca rewards and baker fees are earned when frozen
+		if f.IsFrozen && f.AmountIn > 0 {
+			return StatementReward
+		}
+		if f.IsBurned && f.AmountOut > 0 {
+			return StatementBurn
+		}
+	case model.FlowCategoryDeposits, model.FlowCategoryBond:
+		// slashed deposits and rollup bonds
+		if f.IsBurned && f.AmountOut > 0 {
+			return StatementBurn
+		}
+	}
+	return ""
+}
+
+// costLot is a FIFO acquisition lot in base units. Lots acquired before the
+// first known price have no cost basis.
+type costLot struct {
+	amount int64
+	price  float64
+	priced bool
+}
+
+type priceFeed struct {
+	prices []*model.Price
+}
+
+// at returns the most recent price at or before t. Returns false when t is
+// before the first price.
+func (p priceFeed) at(t time.Time) (float64, bool) {
+	i := sort.Search(len(p.prices), func(i int) bool { return p.prices[i].Timestamp.After(t) })
+	if i == 0 {
+		return 0, false
+	}
+	return p.prices[i-1].Price, true
+}
+
+// statementLedger tracks holdings and open FIFO acquisition lots while flows
+// are posted in time order.
+type statementLedger struct {
+	params   *tezos.Params
+	feed     priceFeed
+	lots     []costLot
+	head     int // first open lot
+	holdings int64
+}
+
+// cost returns the cost basis of open lots.
+func (l *statementLedger) cost() float64 {
+	var c float64
+	for _, v := range l.lots[l.head:] {
+		c += l.params.ConvertValue(v.amount) * v.price
+	}
+	return c
+}
+
+// post books a flow and returns its ledger entry and the out-flow amount
+// not covered by known lots or by lots without price. Returns false when
+// the flow is not part of the ledger.
+func (l *statementLedger) post(f *model.Flow) (StatementEntry, int64, bool) {
+	kind := statementKind(f)
+	if kind == "" {
+		return StatementEntry{}, 0, false
+	}
+	p := l.params
+	price, ok := l.feed.at(f.Timestamp)
+	e := StatementEntry{
+		Time:         f.Timestamp,
+		Height:       f.Height,
+		OpN:          f.OpN,
+		OpC:          f.OpC,
+		OpI:          f.OpI,
+		Kind:         kind,
+		Operation:    f.Operation.String(),
+		Category:     f.Category.String(),
+		AmountIn:     p.ConvertValue(f.AmountIn),
+		AmountOut:    p.ConvertValue(f.AmountOut),
+		Price:        price,
+		PriceMissing: !ok,
+	}
+
+	// acquire or dispose lots
+	var (
+		uncovered int64
+		inCost    float64
+	)
+	if f.AmountIn > 0 {
+		l.lots = append(l.lots, costLot{amount: f.AmountIn, price: price, priced: ok})
+		l.holdings += f.AmountIn
+		e.Value = e.AmountIn * price
+		e.CostBasis = e.Value
+		inCost = e.CostBasis
+	}
+	if f.AmountOut > 0 {
+		rest := f.AmountOut
+		for rest > 0 && l.head < len(l.lots) {
+			lot := &l.lots[l.head]
+			n := util.Min64(rest, lot.amount)
+			if lot.priced {
+				e.CostBasis += p.ConvertValue(n) * lot.price
+			} else {
+				uncovered += n
+			}
+			lot.amount -= n
+			rest -= n
+			if lot.amount == 0 {
+				l.head++
+			}
+		}
+		uncovered += rest
+		l.holdings = util.Max64(0, l.holdings-f.AmountOut)
+		e.Value = e.AmountOut * price
+		if covered := f.AmountOut - uncovered; ok && covered > 0 {
+			// realize gains only on the part with known cost basis
+			e.Gain = e.Value*float64(covered)/float64(f.AmountOut) - (e.CostBasis - inCost)
+		}
+
+		// drop consumed lots once they make up half the list
+		if l.head > 0 && l.head >= len(l.lots)/2 {
+			n := copy(l.lots, l.lots[l.head:])
+			l.lots = l.lots[:n]
+			l.head = 0
+		}
+	}
+	e.Holdings = p.ConvertValue(l.holdings)
+	return e, uncovered, true
+}
+
+func (t *StatementTotals) add(e StatementEntry, uncovered float64) {
+	switch e.Kind {
+	case StatementReceive:
+		t.Received += e.AmountIn
+		t.ReceivedValue += e.Value
+	case StatementReward:
+		t.Rewards += e.AmountIn
+		t.RewardsValue += e.Value
+	case StatementSend:
+		t.Sent += e.AmountOut
+		t.SentValue += e.Value
+	case StatementFee:
+		t.Fees += e.AmountOut
+		t.FeesValue += e.Value
+	case StatementBurn:
+		t.Burned += e.AmountOut
+		t.BurnedValue += e.Value
+	}
+	t.RealizedGain += e.Gain
+	t.UncoveredOut += uncovered
+	if e.PriceMissing {
+		t.PriceMissing++
+	}
+}
+
+// ReadAccountStatement builds a fiat-denominated ledger of balance changes
+// with FIFO cost basis. Acquisition lots are built from the full account
+// history, so out-flows within the requested range consume lots acquired
+// before the range start. Flows are streamed, only open lots and entries
+// within the requested range are kept in memory and CSV entries are written
+// as they are produced.
+func ReadAccountStatement(ctx *server.Context) (interface{}, int) {
+	args := &StatementRequest{}
+	ctx.ParseRequestArgs(args)
+	acc := loadAccount(ctx)
+
+	prices, err := ctx.Indexer.ListPrices(ctx, args.Currency, time.Time{}, args.To.Time())
+	if err != nil {
+		switch err {
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access price table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+	if len(prices) == 0 {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("no %s prices available", args.Currency), nil))
+	}
+
+	p := ctx.Params
+	from := args.From.Time()
+	resp := &AccountStatement{
+		Address:  acc.Address,
+		Currency: args.Currency,
+		From:     from,
+		To:       args.To.Time(),
+		Entries:  make([]StatementEntry, 0),
+		modified: ctx.Tip.BestTime,
+		expires:  ctx.Tip.BestTime.Add(p.BlockTime()),
+	}
+	if !from.IsZero() {
+		resp.From = from.UTC()
+	}
+
+	// csv ledgers are streamed while flows are read
+	var (
+		enc   *csv.Encoder
+		count int
+	)
+	if args.Format == "csv" {
+		ctx.StreamResponseHeaders(http.StatusOK, "text/csv")
+		enc = csv.NewEncoder(ctx.ResponseWriter)
+		if err := enc.EncodeHeader(statementColumns, nil); err != nil {
+			ctx.StreamTrailer("", 0, err)
+			return nil, -1
+		}
+	}
+
+	var (
+		ledger = &statementLedger{params: p, feed: priceFeed{prices}}
+		opened bool
+	)
+	err = ctx.Indexer.StreamAccountFlows(ctx, acc.RowId, args.To.Time(), func(f *model.Flow) error {
+		inRange := !f.Timestamp.Before(from)
+		if inRange && !opened {
+			resp.Totals.OpeningBalance = p.ConvertValue(ledger.holdings)
+			resp.Totals.OpeningCost = ledger.cost()
+			opened = true
+		}
+		e, uncovered, ok := ledger.post(f)
+		if !ok || !inRange {
+			return nil
+		}
+		e.Currency = args.Currency
+		if f.CounterPartyId > 0 && f.CounterPartyId != f.AccountId {
+			e.Counterparty = ctx.Indexer.LookupAddress(ctx, f.CounterPartyId)
+		}
+		resp.Totals.add(e, p.ConvertValue(uncovered))
+		if enc != nil {
+			count++
+			return enc.EncodeRecord(e)
+		}
+		resp.Entries = append(resp.Entries, e)
+		return nil
+	})
+	if enc != nil {
+		if err != nil && err != io.EOF {
+			log.Debugf("Stream encode: %v", err)
+		}
+		ctx.StreamTrailer("", count, err)
+		return nil, -1
+	}
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read flows", err))
+	}
+	if !opened {
+		resp.Totals.OpeningBalance = p.ConvertValue(ledger.holdings)
+		resp.Totals.OpeningCost = ledger.cost()
+	}
+	resp.Totals.ClosingBalance = p.ConvertValue(ledger.holdings)
+	resp.Totals.ClosingCost = ledger.cost()
+	return resp, http.StatusOK
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/model"
+)
+
+func TestStatementKind(t *testing.T) {
+	tests := []struct {
+		name string
+		flow model.Flow
+		want string
+	}{
+		{"receive", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeTransaction, AmountIn: 1}, StatementReceive},
+		{"send", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeTransaction, AmountOut: 1}, StatementSend},
+		{"fee", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeTransaction, AmountOut: 1, IsFee: true}, StatementFee},
+		{"burn", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeTransaction, AmountOut: 1, IsBurned: true}, StatementBurn},
+		{"baking reward", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeBaking, AmountIn: 1}, StatementReward},
+		{"penalty", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypePenalty, AmountOut: 1}, StatementBurn},
+		{"unfreeze", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeInternal, AmountIn: 1, IsUnfrozen: true}, ""},
+		{"deposit", model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeDeposit, AmountOut: 1}, ""},
+		{"frozen reward", model.Flow{Category: model.FlowCategoryRewards, Operation: model.FlowTypeBaking, AmountIn: 1, IsFrozen: true}, StatementReward},
+		{"unfrozen reward", model.Flow{Category: model.FlowCategoryRewards, Operation: model.FlowTypeInternal, AmountOut: 1, IsUnfrozen: true}, ""},
+		{"slashed deposit", model.Flow{Category: model.FlowCategoryDeposits, Operation: model.FlowTypePenalty, AmountOut: 1, IsBurned: true}, StatementBurn},
+		{"frozen deposit", model.Flow{Category: model.FlowCategoryDeposits, Operation: model.FlowTypeDeposit, AmountIn: 1, IsFrozen: true}, ""},
+	}
+	for _, test := range tests {
+		if got := statementKind(&test.flow); got != test.want {
+			t.Errorf("%s: got kind %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func testLedger(prices ...float64) *statementLedger {
+	feed := priceFeed{}
+	for i, v := range prices {
+		feed.prices = append(feed.prices, &model.Price{
+			Timestamp: time.Unix(int64(i)*3600, 0),
+			Price:     v,
+		})
+	}
+	return &statementLedger{params: tezos.NewParams(), feed: feed}
+}
+
+func testFlow(hour int64, in, out int64) *model.Flow {
+	return &model.Flow{
+		Timestamp: time.Unix(hour*3600, 0),
+		Category:  model.FlowCategoryBalance,
+		Operation: model.FlowTypeTransaction,
+		AmountIn:  in,
+		AmountOut: out,
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestStatementLedgerFIFO(t *testing.T) {
+	// prices 1, 2, 4 per tez in hours 0, 1, 2
+	l := testLedger(1, 2, 4)
+
+	// buy 10 tez at 1 and 10 tez at 2
+	for _, f := range []*model.Flow{testFlow(0, 10e6, 0), testFlow(1, 10e6, 0)} {
+		e, _, ok := l.post(f)
+		if !ok || e.Kind != StatementReceive {
+			t.Fatalf("receive: ok=%t kind=%s", ok, e.Kind)
+		}
+	}
+	if !approx(l.cost(), 30) {
+		t.Errorf("cost basis = %f, want 30", l.cost())
+	}
+
+	// sell 15 tez at 4: consumes the first lot and half of the second
+	e, uncovered, ok := l.post(testFlow(2, 0, 15e6))
+	if !ok || e.Kind != StatementSend || uncovered != 0 {
+		t.Fatalf("send: ok=%t kind=%s uncovered=%d", ok, e.Kind, uncovered)
+	}
+	if !approx(e.Value, 60) || !approx(e.CostBasis, 20) || !approx(e.Gain, 40) {
+		t.Errorf("send: value=%f cost=%f gain=%f, want 60/20/40", e.Value, e.CostBasis, e.Gain)
+	}
+	if !approx(e.Holdings, 5) || !approx(l.cost(), 10) {
+		t.Errorf("after send: holdings=%f cost=%f, want 5/10", e.Holdings, l.cost())
+	}
+	if l.head != 0 || len(l.lots) != 1 || l.lots[0].amount != 5e6 {
+		t.Errorf("consumed lots not compacted: head=%d lots=%v", l.head, l.lots)
+	}
+
+	// sell 8 tez at 4: 5 covered at 2, 3 uncovered without gain
+	e, uncovered, _ = l.post(testFlow(2, 0, 8e6))
+	if uncovered != 3e6 {
+		t.Errorf("uncovered = %d, want 3e6", uncovered)
+	}
+	if !approx(e.CostBasis, 10) || !approx(e.Gain, 10) {
+		t.Errorf("oversell: cost=%f gain=%f, want 10/10", e.CostBasis, e.Gain)
+	}
+	if e.Holdings != 0 || l.cost() != 0 {
+		t.Errorf("after oversell: holdings=%f cost=%f, want 0", e.Holdings, l.cost())
+	}
+
+	// flows outside the ledger do not change state
+	if _, _, ok := l.post(&model.Flow{Category: model.FlowCategoryBalance, Operation: model.FlowTypeDeposit, AmountOut: 1}); ok {
+		t.Errorf("deposit posted to ledger")
+	}
+}
+
+func TestPriceFeed(t *testing.T) {
+	feed := testLedger(1, 2, 4).feed
+	tests := []struct {
+		t    time.Time
+		want float64
+		ok   bool
+	}{
+		{time.Unix(-10, 0), 0, false},
+		{time.Unix(0, 0), 1, true},
+		{time.Unix(3599, 0), 1, true},
+		{time.Unix(3600, 0), 2, true},
+		{time.Unix(100000, 0), 4, true},
+	}
+	for _, test := range tests {
+		if got, ok := feed.at(test.t); got != test.want || ok != test.ok {
+			t.Errorf("at %d: got %f/%t, want %f/%t", test.t.Unix(), got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestStatementMissingPrice(t *testing.T) {
+	// prices start in hour 1
+	l := testLedger(0, 2)
+	l.feed.prices = l.feed.prices[1:]
+
+	// receive 10 tez before the first price
+	e, _, _ := l.post(testFlow(0, 10e6, 0))
+	if !e.PriceMissing || e.Price != 0 || e.Value != 0 {
+		t.Errorf("receive: missing=%t price=%f value=%f", e.PriceMissing, e.Price, e.Value)
+	}
+
+	// sell 4 tez at 2: the unpriced lot has no cost basis
+	e, uncovered, _ := l.post(testFlow(1, 0, 4e6))
+	if e.PriceMissing || !approx(e.Value, 8) || e.CostBasis != 0 || e.Gain != 0 || uncovered != 4e6 {
+		t.Errorf("send: missing=%t value=%f cost=%f gain=%f uncovered=%d", e.PriceMissing, e.Value, e.CostBasis, e.Gain, uncovered)
+	}
+
+	var tot StatementTotals
+	tot.add(StatementEntry{Kind: StatementReceive, AmountIn: 10, PriceMissing: true}, 0)
+	tot.add(e, 4)
+	if tot.PriceMissing != 1 || tot.UncoveredOut != 4 {
+		t.Errorf("totals = %+v", tot)
+	}
+}
+
+func TestStatementTotals(t *testing.T) {
+	var tot StatementTotals
+	tot.add(StatementEntry{Kind: StatementReceive, AmountIn: 2, Value: 4}, 0)
+	tot.add(StatementEntry{Kind: StatementSend, AmountOut: 1, Value: 3, Gain: 1}, 0.5)
+	tot.add(StatementEntry{Kind: StatementFee, AmountOut: 0.1, Value: 0.2, Gain: -0.1}, 0)
+	if tot.Received != 2 || tot.Sent != 1 || tot.Fees != 0.1 || !approx(tot.RealizedGain, 0.9) || tot.UncoveredOut != 0.5 {
+		t.Errorf("totals = %+v", tot)
+	}
+}
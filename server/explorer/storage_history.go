@@ -0,0 +1,215 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/server"
+)
+
+// StoragePathChange is a single changed leaf or subtree in decoded storage.
+// Paths use the same dot notation as bigmap path filters.
+type StoragePathChange struct {
+	Path   string      `json:"path"`
+	Action string      `json:"action"` // added, removed, changed
+	Old    interface{} `json:"old,omitempty"`
+	New    interface{} `json:"new,omitempty"`
+}
+
+type StorageHistoryEntry struct {
+	Id          uint64              `json:"id"`
+	Height      int64               `json:"height"`
+	Time        time.Time           `json:"time"`
+	OpHash      tezos.OpHash        `json:"op_hash"`
+	StorageHash string              `json:"storage_hash"`
+	Changes     []StoragePathChange `json:"changes"`
+	Storage     interface{}         `json:"storage,omitempty"`
+	Prim        *micheline.Prim     `json:"prim,omitempty"`
+}
+
+type StorageHistory struct {
+	list     []StorageHistoryEntry
+	modified time.Time
+	expires  time.Time
+}
+
+func (l StorageHistory) MarshalJSON() ([]byte, error) { return json.Marshal(l.list) }
+func (l StorageHistory) LastModified() time.Time      { return l.modified }
+func (l StorageHistory) Expires() time.Time           { return l.expires }
+
+var _ server.Resource = (*StorageHistory)(nil)
+
+// list storage versions with decoded changes against the previous version
+func ListContractStorageHistory(ctx *server.Context) (interface{}, int) {
+	args := &ContractRequest{}
+	ctx.ParseRequestArgs(args)
+	cc := loadContract(ctx)
+
+	if cc.Address.IsRollup() {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no script", nil))
+	}
+	acc, err := ctx.Indexer.LookupAccountId(ctx, cc.AccountId)
+	if err != nil {
+		switch err {
+		case index.ErrNoAccountEntry:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such contract", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+
+	// type is always the most recently upgraded type stored in contract table
+	script, err := cc.LoadScript()
+	if err != nil {
+		panic(server.EInternal(server.EC_SERVER, "script unmarshal failed", err))
+	}
+	if script == nil {
+		return nil, http.StatusNoContent
+	}
+	typ := script.StorageType()
+
+	r := etl.ListRequest{
+		Account: acc,
+		Since:   args.SinceHeight,
+		Until:   args.BlockHeight,
+		Offset:  args.Offset,
+		Limit:   ctx.Cfg.ClampExplore(args.Limit),
+		Cursor:  args.Cursor,
+		Order:   args.Order,
+	}
+	versions, err := ctx.Indexer.ListStorageHistory(ctx, r)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot read storage history", err))
+	}
+
+	resp := &StorageHistory{
+		list:     make([]StorageHistoryEntry, 0, len(versions)),
+		modified: ctx.Indexer.LookupBlockTime(ctx.Context, cc.LastSeen),
+		expires:  ctx.Tip.BestTime.Add(ctx.Params.BlockTime()),
+	}
+	for _, v := range versions {
+		entry := StorageHistoryEntry{
+			Id:          v.RowId.Value(),
+			Height:      v.Height,
+			Time:        ctx.Indexer.LookupBlockTime(ctx.Context, v.Height),
+			OpHash:      v.OpHash,
+			StorageHash: util.U64String(v.Hash).Hex(),
+			Changes:     make([]StoragePathChange, 0),
+		}
+		next, nextPrim := decodeStorageVersion(v.Storage.Storage, typ, args)
+		var prev interface{}
+		if v.Prev != nil {
+			prev, _ = decodeStorageVersion(v.Prev.Storage, typ, args)
+		}
+		entry.Changes = diffStorageValues(entry.Changes, "", prev, next)
+		if args.WithStorage() {
+			entry.Storage = next
+		}
+		if args.WithPrim() {
+			entry.Prim = nextPrim
+		}
+		resp.list = append(resp.list, entry)
+	}
+
+	return resp, http.StatusOK
+}
+
+func decodeStorageVersion(data []byte, typ micheline.Type, args *ContractRequest) (interface{}, *micheline.Prim) {
+	prim := micheline.Prim{}
+	if err := prim.UnmarshalBinary(data); err != nil {
+		log.Errorf("storage unmarshal: %v", err)
+		return nil, nil
+	}
+	if args.WithUnpack() && prim.IsPackedAny() {
+		if up, err := prim.UnpackAll(); err == nil {
+			prim = up
+		}
+	}
+	val := micheline.NewValue(typ, prim)
+	m, err := val.Map()
+	if err != nil {
+		// storage written before a type migration may not match the
+		// current type, fall back to type deduction
+		val.FixType()
+		if m, err = val.Map(); err != nil {
+			log.Errorf("storage translate: %v", err)
+			return nil, &prim
+		}
+	}
+	return m, &prim
+}
+
+// diffStorageValues walks two decoded storage trees in parallel and appends
+// a change for every path that differs. Records and maps are compared by
+// key, lists by position. Subtrees that exist on only one side are reported
+// once at their root.
+func diffStorageValues(changes []StoragePathChange, path string, a, b interface{}) []StoragePathChange {
+	switch {
+	case a == nil && b == nil:
+		return changes
+	case a == nil:
+		return append(changes, StoragePathChange{Path: path, Action: "added", New: b})
+	case b == nil:
+		return append(changes, StoragePathChange{Path: path, Action: "removed", Old: a})
+	}
+	switch x := a.(type) {
+	case map[string]interface{}:
+		if y, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(x)+len(y))
+			for k := range x {
+				keys = append(keys, k)
+			}
+			for k := range y {
+				if _, ok := x[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				changes = diffStorageValues(changes, joinStoragePath(path, k), x[k], y[k])
+			}
+			return changes
+		}
+	case []interface{}:
+		if y, ok := b.([]interface{}); ok {
+			n := len(x)
+			if len(y) > n {
+				n = len(y)
+			}
+			for i := 0; i < n; i++ {
+				var xv, yv interface{}
+				if i < len(x) {
+					xv = x[i]
+				}
+				if i < len(y) {
+					yv = y[i]
+				}
+				changes = diffStorageValues(changes, joinStoragePath(path, strconv.Itoa(i)), xv, yv)
+			}
+			return changes
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, StoragePathChange{Path: path, Action: "changed", Old: a, New: b})
+	}
+	return changes
+}
+
+func joinStoragePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
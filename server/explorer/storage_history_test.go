@@ -0,0 +1,90 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffStorageValues(t *testing.T) {
+	type m = map[string]interface{}
+	type l = []interface{}
+	tests := []struct {
+		name string
+		a, b interface{}
+		want []StoragePathChange
+	}{
+		{
+			name: "equal",
+			a:    m{"x": "1", "y": l{"a"}},
+			b:    m{"x": "1", "y": l{"a"}},
+			want: nil,
+		},
+		{
+			name: "added path",
+			a:    m{"x": "1"},
+			b:    m{"x": "1", "y": m{"z": "2"}},
+			want: []StoragePathChange{{Path: "y", Action: "added", New: m{"z": "2"}}},
+		},
+		{
+			name: "removed path",
+			a:    m{"x": "1", "y": "2"},
+			b:    m{"x": "1"},
+			want: []StoragePathChange{{Path: "y", Action: "removed", Old: "2"}},
+		},
+		{
+			name: "changed nested path",
+			a:    m{"x": m{"y": "1", "z": "2"}},
+			b:    m{"x": m{"y": "1", "z": "3"}},
+			want: []StoragePathChange{{Path: "x.z", Action: "changed", Old: "2", New: "3"}},
+		},
+		{
+			name: "changed type",
+			a:    m{"x": m{"y": "1"}},
+			b:    m{"x": "1"},
+			want: []StoragePathChange{{Path: "x", Action: "changed", Old: m{"y": "1"}, New: "1"}},
+		},
+		{
+			name: "list grows",
+			a:    m{"x": l{"a"}},
+			b:    m{"x": l{"a", "b", "c"}},
+			want: []StoragePathChange{
+				{Path: "x.1", Action: "added", New: "b"},
+				{Path: "x.2", Action: "added", New: "c"},
+			},
+		},
+		{
+			name: "list shrinks",
+			a:    m{"x": l{"a", "b"}},
+			b:    m{"x": l{"c"}},
+			want: []StoragePathChange{
+				{Path: "x.0", Action: "changed", Old: "a", New: "c"},
+				{Path: "x.1", Action: "removed", Old: "b"},
+			},
+		},
+		{
+			name: "sorted keys",
+			a:    m{"b": "1", "a": "1"},
+			b:    m{"c": "1"},
+			want: []StoragePathChange{
+				{Path: "a", Action: "removed", Old: "1"},
+				{Path: "b", Action: "removed", Old: "1"},
+				{Path: "c", Action: "added", New: "1"},
+			},
+		},
+		{
+			name: "scalar root",
+			a:    "1",
+			b:    "2",
+			want: []StoragePathChange{{Path: "", Action: "changed", Old: "1", New: "2"}},
+		},
+	}
+	for _, test := range tests {
+		got := diffStorageValues(nil, "", test.a, test.b)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package explorer
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+type ElectionTimelineRequest struct {
+	Stage   int  `schema:"stage"`   // 1 .. 5, default all stages
+	Limit   uint `schema:"limit"`   // max non-voters per stage
+	Compare int  `schema:"compare"` // number of previous elections, default 0
+}
+
+// TimelinePoint is the cumulative state of a voting period after all
+// ballots in a block were counted.
+type TimelinePoint struct {
+	Height           int64     `json:"height"`
+	Timestamp        time.Time `json:"time"`
+	Progress         int64     `json:"progress_pct"`
+	TurnoutRolls     int64     `json:"turnout_rolls"`
+	TurnoutStake     float64   `json:"turnout_stake"`
+	TurnoutVoters    int64     `json:"turnout_voters"`
+	TurnoutPct       int64     `json:"turnout_pct"`
+	YayStake         float64   `json:"yay_stake"`
+	NayStake         float64   `json:"nay_stake"`
+	PassStake        float64   `json:"pass_stake"`
+	SupermajorityPct int64     `json:"supermajority_pct"`
+	QuorumReached    bool      `json:"quorum_reached"`
+	MajorityReached  bool      `json:"supermajority_reached"`
+}
+
+type NonVoter struct {
+	Address tezos.Address `json:"address"`
+	Rolls   int64         `json:"rolls"`
+	Stake   float64       `json:"stake"`
+}
+
+type StageTimeline struct {
+	VotingPeriod     int64                  `json:"voting_period"`
+	VotingPeriodKind tezos.VotingPeriodKind `json:"voting_period_kind"`
+	StartHeight      int64                  `json:"period_start_block"`
+	EndHeight        int64                  `json:"period_end_block"`
+	EligibleStake    float64                `json:"eligible_stake"`
+	EligibleVoters   int64                  `json:"eligible_voters"`
+	QuorumPct        int64                  `json:"quorum_pct"`
+	QuorumStake      float64                `json:"quorum_stake"`
+	IsOpen           bool                   `json:"is_open"`
+	QuorumHeight     int64                  `json:"quorum_height,omitempty"`
+	MajorityHeight   int64                  `json:"supermajority_height,omitempty"`
+	Points           []*TimelinePoint       `json:"timeline"`
+	NonVoters        []NonVoter             `json:"non_voters"`
+	NonVoterStake    float64                `json:"non_voter_stake"`
+}
+
+// ElectionComparison summarizes the final result of a previous election's
+// voting periods together with turnout at quarter marks of each period.
+type ElectionComparison struct {
+	ElectionId model.ElectionID   `json:"election_id"`
+	Proposal   tezos.ProtocolHash `json:"proposal"`
+	StartTime  time.Time          `json:"start_time"`
+	IsFailed   bool               `json:"is_failed"`
+	Stages     []StageComparison  `json:"stages"`
+}
+
+type StageComparison struct {
+	VotingPeriodKind tezos.VotingPeriodKind `json:"voting_period_kind"`
+	QuorumPct        int64                  `json:"quorum_pct"`
+	TurnoutPct       int64                  `json:"turnout_pct"`
+	TurnoutQuarters  [3]int64               `json:"turnout_quarters_pct"`
+	SupermajorityPct int64                  `json:"supermajority_pct"`
+	IsFailed         bool                   `json:"is_failed"`
+}
+
+type ElectionTimeline struct {
+	ElectionId model.ElectionID     `json:"election_id"`
+	Proposal   tezos.ProtocolHash   `json:"proposal"`
+	IsOpen     bool                 `json:"is_open"`
+	Stages     []*StageTimeline     `json:"stages"`
+	History    []ElectionComparison `json:"history"`
+
+	// internal
+	modified time.Time
+	expires  time.Time
+}
+
+func (t ElectionTimeline) LastModified() time.Time { return t.modified }
+func (t ElectionTimeline) Expires() time.Time      { return t.expires }
+
+var _ server.Resource = (*ElectionTimeline)(nil)
+
+func GetElectionTimeline(ctx *server.Context) (interface{}, int) {
+	args := &ElectionTimelineRequest{}
+	ctx.ParseRequestArgs(args)
+	election := loadElection(ctx)
+	params := ctx.Indexer.ParamsByHeight(election.StartHeight)
+	if args.Stage < 0 || args.Stage > params.NumVotingPeriods {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid voting period identifier", nil))
+	}
+	limit := int(ctx.Cfg.ClampExplore(args.Limit))
+
+	votes, err := ctx.Indexer.VotesByElection(ctx, election.RowId)
+	if err != nil {
+		switch err {
+		case etl.ErrNoTable:
+			panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "cannot access vote table", err))
+		default:
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+	}
+	table, err := ctx.Indexer.Table(index.BallotTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_DATABASE, "missing ballot table", err))
+	}
+
+	resp := &ElectionTimeline{
+		ElectionId: election.RowId,
+		Proposal:   ctx.Indexer.LookupProposalHash(ctx, election.ProposalId),
+		IsOpen:     election.IsOpen,
+		Stages:     make([]*StageTimeline, 0, len(votes)),
+		History:    make([]ElectionComparison, 0),
+	}
+	if election.IsOpen {
+		resp.expires = ctx.Tip.BestTime.Add(params.BlockTime())
+	} else {
+		resp.expires = ctx.Now.Add(ctx.Cfg.Http.CacheMaxExpires)
+	}
+
+	for i, v := range votes {
+		if args.Stage > 0 && i != args.Stage-1 {
+			continue
+		}
+		points, err := buildStageTimeline(ctx, table, v)
+		if err != nil {
+			panic(server.EInternal(server.EC_DATABASE, "cannot read ballots", err))
+		}
+		stage := &StageTimeline{
+			VotingPeriod:     v.VotingPeriod,
+			VotingPeriodKind: v.VotingPeriodKind,
+			StartHeight:      v.StartHeight,
+			EndHeight:        v.EndHeight,
+			EligibleStake:    ctx.Params.ConvertValue(v.EligibleStake),
+			EligibleVoters:   v.EligibleVoters,
+			QuorumPct:        v.QuorumPct,
+			QuorumStake:      ctx.Params.ConvertValue(v.QuorumStake),
+			IsOpen:           v.IsOpen,
+			Points:           points,
+			NonVoters:        make([]NonVoter, 0),
+		}
+		for _, p := range points {
+			if stage.QuorumHeight == 0 && p.QuorumReached {
+				stage.QuorumHeight = p.Height
+			}
+			if stage.MajorityHeight == 0 && p.MajorityReached && p.QuorumReached {
+				stage.MajorityHeight = p.Height
+			}
+			resp.modified = util.MaxTime(resp.modified, p.Timestamp)
+		}
+
+		// cooldown and adoption periods have no voters
+		if isBallotStage(v.VotingPeriodKind) || v.VotingPeriodKind == tezos.VotingPeriodProposal {
+			voters, err := ctx.Indexer.ListVoters(ctx, etl.ListRequest{
+				Since:  v.StartHeight,
+				Period: v.VotingPeriod,
+			})
+			if err != nil {
+				panic(server.EInternal(server.EC_DATABASE, "cannot read voters", err))
+			}
+			sort.Slice(voters, func(i, j int) bool { return voters[i].Stake > voters[j].Stake })
+			var missing int64
+			for _, vv := range voters {
+				if vv.HasVoted || vv.Stake == 0 {
+					continue
+				}
+				missing += vv.Stake
+				if len(stage.NonVoters) < limit {
+					stage.NonVoters = append(stage.NonVoters, NonVoter{
+						Address: ctx.Indexer.LookupAddress(ctx, vv.RowId),
+						Rolls:   vv.Rolls,
+						Stake:   ctx.Params.ConvertValue(vv.Stake),
+					})
+				}
+			}
+			stage.NonVoterStake = ctx.Params.ConvertValue(missing)
+		}
+		resp.Stages = append(resp.Stages, stage)
+	}
+
+	// compare with previous elections
+	for id := election.RowId - 1; id > 0 && len(resp.History) < args.Compare; id-- {
+		prev, err := ctx.Indexer.ElectionById(ctx, id)
+		if err != nil {
+			if err == index.ErrNoElectionEntry {
+				continue
+			}
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+		prevVotes, err := ctx.Indexer.VotesByElection(ctx, prev.RowId)
+		if err != nil && err != index.ErrNoVoteEntry {
+			panic(server.EInternal(server.EC_DATABASE, err.Error(), nil))
+		}
+		cmp := ElectionComparison{
+			ElectionId: prev.RowId,
+			Proposal:   ctx.Indexer.LookupProposalHash(ctx, prev.ProposalId),
+			StartTime:  prev.StartTime,
+			IsFailed:   prev.IsFailed,
+			Stages:     make([]StageComparison, 0, len(prevVotes)),
+		}
+		for _, v := range prevVotes {
+			sc := StageComparison{
+				VotingPeriodKind: v.VotingPeriodKind,
+				QuorumPct:        v.QuorumPct,
+				TurnoutPct:       v.TurnoutPct,
+				IsFailed:         v.IsFailed,
+			}
+			if isBallotStage(v.VotingPeriodKind) {
+				sc.SupermajorityPct = supermajorityPct(v.YayStake, v.NayStake)
+			}
+			points, err := buildStageTimeline(ctx, table, v)
+			if err != nil {
+				panic(server.EInternal(server.EC_DATABASE, "cannot read ballots", err))
+			}
+			for _, p := range points {
+				for q := range sc.TurnoutQuarters {
+					if p.Progress <= int64(q+1)*2500 {
+						sc.TurnoutQuarters[q] = p.TurnoutPct
+					}
+				}
+			}
+			cmp.Stages = append(cmp.Stages, sc)
+		}
+		resp.History = append(resp.History, cmp)
+	}
+
+	return resp, http.StatusOK
+}
+
+func isBallotStage(k tezos.VotingPeriodKind) bool {
+	return k == tezos.VotingPeriodExploration || k == tezos.VotingPeriodPromotion
+}
+
+// supermajorityPct returns the yay share of yay and nay votes in percent
+// with 2 digits precision.
+func supermajorityPct(yay, nay int64) int64 {
+	if yay+nay == 0 {
+		return 0
+	}
+	return int64(float64(yay) * 10000 / float64(yay+nay))
+}
+
+// buildStageTimeline replays all ballots of a voting period in block order
+// and returns cumulative participation after each block containing ballots.
+// In proposal periods a baker may vote for multiple proposals, so turnout
+// counts each voter only once.
+func buildStageTimeline(ctx *server.Context, table *pack.Table, v *model.Vote) ([]*TimelinePoint, error) {
+	points := make([]*TimelinePoint, 0)
+	seen := make(map[model.AccountID]struct{})
+	length := v.EndHeight - v.StartHeight + 1
+	var (
+		cur                                  *TimelinePoint
+		rolls, stake, voters, yay, nay, pass int64
+		ballot                               = &model.Ballot{}
+	)
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		cur.TurnoutRolls = rolls
+		cur.TurnoutStake = ctx.Params.ConvertValue(stake)
+		cur.TurnoutVoters = voters
+		if v.EligibleStake > 0 {
+			cur.TurnoutPct = stake * 10000 / v.EligibleStake
+		}
+		if length > 0 {
+			cur.Progress = (cur.Height - v.StartHeight + 1) * 10000 / length
+		}
+		cur.QuorumReached = stake >= v.QuorumStake
+		if isBallotStage(v.VotingPeriodKind) {
+			cur.YayStake = ctx.Params.ConvertValue(yay)
+			cur.NayStake = ctx.Params.ConvertValue(nay)
+			cur.PassStake = ctx.Params.ConvertValue(pass)
+			cur.SupermajorityPct = supermajorityPct(yay, nay)
+			cur.MajorityReached = yay+nay > 0 && index.IsSupermajority(yay, nay)
+		}
+		points = append(points, cur)
+	}
+	err := pack.NewQuery("api.election_timeline").
+		WithTable(table).
+		WithFields("height", "time", "source_id", "rolls", "stake", "ballot").
+		AndEqual("voting_period", v.VotingPeriod).
+		WithOrder(pack.OrderAsc).
+		Stream(ctx, func(r pack.Row) error {
+			if err := r.Decode(ballot); err != nil {
+				return err
+			}
+			if cur == nil || cur.Height != ballot.Height {
+				flush()
+				cur = &TimelinePoint{
+					Height:    ballot.Height,
+					Timestamp: ballot.Time,
+				}
+			}
+			if _, ok := seen[ballot.SourceId]; !ok {
+				seen[ballot.SourceId] = struct{}{}
+				rolls += ballot.Rolls
+				stake += ballot.Stake
+				voters++
+			}
+			switch ballot.Ballot {
+			case tezos.BallotVoteYay:
+				yay += ballot.Stake
+			case tezos.BallotVoteNay:
+				nay += ballot.Stake
+			case tezos.BallotVotePass:
+				pass += ballot.Stake
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	return points, nil
+}
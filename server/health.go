@@ -0,0 +1,142 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"blockwatch.cc/tzindex/etl"
+)
+
+func init() {
+	Describe("GET", "/health/live", ApiDoc{Id: "GetLiveness", Summary: "Liveness probe", Result: HealthStatus{}})
+	Describe("GET", "/health/ready", ApiDoc{Id: "GetReadiness", Summary: "Readiness probe", Result: HealthStatus{}})
+	Describe("HEAD", "/health/live", ApiDoc{Id: "HeadLiveness", Summary: "Liveness probe status"})
+	Describe("HEAD", "/health/ready", ApiDoc{Id: "HeadReadiness", Summary: "Readiness probe status"})
+}
+
+// HealthStatus is the response body of liveness and readiness probes.
+type HealthStatus struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+const (
+	healthOk   = "ok"
+	healthFail = "fail"
+	healthSkip = "skip"
+)
+
+func (h *HealthStatus) add(name string, ok bool, format string, args ...interface{}) {
+	c := HealthCheck{
+		Name:   name,
+		Status: healthOk,
+		Detail: fmt.Sprintf(format, args...),
+	}
+	if !ok {
+		c.Status = healthFail
+		h.Status = healthFail
+	}
+	h.Checks = append(h.Checks, c)
+}
+
+func (h *HealthStatus) skip(name, detail string) {
+	h.Checks = append(h.Checks, HealthCheck{Name: name, Status: healthSkip, Detail: detail})
+}
+
+// checkLiveness fails only when the crawler loop has stopped on an error.
+// Lag and idle time depend on the upstream node and are readiness checks,
+// otherwise a stalled node would get a healthy indexer restarted.
+func checkLiveness(h *HealthStatus, c *etl.Crawler) {
+	state := c.Status().Status
+	h.add("crawler_state", state != etl.STATE_FAILED, "crawler is %s", state)
+}
+
+// checkReadiness fails when the indexer cannot serve current chain state
+// or the API is saturated.
+func checkReadiness(h *HealthStatus, c *etl.Crawler, d *Dispatcher, hc HttpConfig) {
+	state := c.Status().Status
+	h.add("crawler_connected", state != etl.STATE_CONNECTING, "crawler is %s", state)
+
+	switch {
+	case hc.HealthMaxIdle <= 0:
+		h.skip("index_idle", "disabled")
+	case state != etl.STATE_SYNCHRONIZED && state != etl.STATE_SYNCHRONIZING:
+		h.skip("index_idle", fmt.Sprintf("crawler is %s", state))
+	default:
+		idle := time.Since(c.LastUpdate()).Truncate(time.Second)
+		h.add("index_idle", idle <= hc.HealthMaxIdle, "last block indexed %s ago (max %s)", idle, hc.HealthMaxIdle)
+	}
+
+	blocks, lag := c.Lag()
+	switch {
+	case blocks < 0:
+		h.skip("lag_blocks", "node head unknown")
+		h.skip("lag_time", "node head unknown")
+	default:
+		if hc.HealthMaxLagBlocks > 0 {
+			h.add("lag_blocks", blocks <= int64(hc.HealthMaxLagBlocks), "%d blocks behind node (max %d)", blocks, hc.HealthMaxLagBlocks)
+		} else {
+			h.skip("lag_blocks", "disabled")
+		}
+		if hc.HealthMaxLagTime > 0 {
+			h.add("lag_time", lag <= hc.HealthMaxLagTime, "%s behind node (max %s)", lag, hc.HealthMaxLagTime)
+		} else {
+			h.skip("lag_time", "disabled")
+		}
+	}
+
+	switch {
+	case hc.HealthMaxQueue <= 0:
+		h.skip("api_queue", "disabled")
+	case d == nil:
+		h.add("api_queue", false, "dispatcher not running")
+	default:
+		n, max := d.Queue()
+		pct := 0
+		if max > 0 {
+			pct = n * 100 / max
+		}
+		h.add("api_queue", pct < hc.HealthMaxQueue, "%d of %d queue slots used (max %d%%)", n, max, hc.HealthMaxQueue)
+	}
+}
+
+// GetLiveness and GetReadiness bypass the request dispatcher so probes are
+// answered even when all API workers are busy.
+func GetLiveness(w http.ResponseWriter, r *http.Request) {
+	h := &HealthStatus{Status: healthOk}
+	checkLiveness(h, srv.cfg.Crawler)
+	writeHealth(w, r, h)
+}
+
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	h := &HealthStatus{Status: healthOk}
+	checkLiveness(h, srv.cfg.Crawler)
+	checkReadiness(h, srv.cfg.Crawler, srv.dispatcher, srv.Config().Http)
+	writeHealth(w, r, h)
+}
+
+func writeHealth(w http.ResponseWriter, r *http.Request, h *HealthStatus) {
+	status := http.StatusOK
+	if h.Status != healthOk {
+		status = http.StatusServiceUnavailable
+	}
+	hdr := w.Header()
+	hdr.Set("Content-Type", jsonContentType)
+	hdr.Set("Cache-Control", "max-age=0, no-cache, no-store, must-revalidate")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		buf, _ := json.Marshal(h)
+		_, _ = w.Write(append(buf, '\n'))
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ApiDoc describes request arguments and response type of an API route
+// for the generated OpenAPI specification. Args is a struct with `schema`
+// tags as decoded by ParseRequestArgs, Body and Result are JSON types.
+// Status defaults to 200, responses with status 204 have no content.
+type ApiDoc struct {
+	Id         string // operation id, used as client method name
+	Summary    string
+	Args       interface{}
+	Body       interface{}
+	Result     interface{}
+	Status     int
+	Deprecated bool // alias of another route, skipped by the client
+}
+
+var apiDocs = map[string]ApiDoc{}
+
+func init() {
+	Describe("GET", "/openapi.json", ApiDoc{Id: "GetOpenAPI", Summary: "OpenAPI specification", Result: OpenAPI{}})
+}
+
+// Describe registers documentation for the route with the given method and
+// full path template.
+func Describe(method, path string, doc ApiDoc) {
+	apiDocs[method+" "+path] = doc
+}
+
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas"`
+}
+
+type OpenAPIOperation struct {
+	OperationId string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIBody struct {
+	Required bool                    `json:"required,omitempty"`
+	Content  map[string]OpenAPIMedia `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                  `json:"description"`
+	Content     map[string]OpenAPIMedia `json:"content,omitempty"`
+}
+
+type OpenAPIMedia struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	openapiOnce sync.Once
+	openapiSpec *OpenAPI
+
+	// strips regular expressions from mux path variables
+	routeVarRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+)
+
+func GetOpenAPI(ctx *Context) (interface{}, int) {
+	openapiOnce.Do(func() {
+		openapiSpec = BuildOpenAPI(ctx.Server.router)
+	})
+	return openapiSpec, http.StatusOK
+}
+
+// BuildOpenAPI generates an OpenAPI 3 specification from all routes on
+// router. Routes without ApiDoc are listed with a generic JSON response.
+func BuildOpenAPI(router *mux.Router) *OpenAPI {
+	b := &openapiBuilder{
+		spec: &OpenAPI{
+			OpenAPI: "3.0.3",
+			Info: OpenAPIInfo{
+				Title:   "Blockwatch Tezos Indexer API",
+				Version: ApiVersion,
+			},
+			Paths: make(map[string]map[string]*OpenAPIOperation),
+			Components: OpenAPIComponents{
+				Schemas: make(map[string]*OpenAPISchema),
+			},
+		},
+		names: make(map[reflect.Type]string),
+	}
+	errSchema := b.schema(reflect.TypeOf(ErrorResponse{}))
+
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil || strings.HasPrefix(tpl, "/debug") {
+			return nil
+		}
+		// skip prefix routes like the OPTIONS and not found handlers
+		if re, err := route.GetPathRegexp(); err != nil || !strings.HasSuffix(re, "$") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		path := routeVarRegexp.ReplaceAllString(tpl, "{$1}")
+		for _, m := range methods {
+			b.addOperation(m, path, tpl, errSchema)
+		}
+		return nil
+	})
+	return b.spec
+}
+
+type openapiBuilder struct {
+	spec  *OpenAPI
+	names map[reflect.Type]string
+}
+
+func (b *openapiBuilder) addOperation(method, path, tpl string, errSchema *OpenAPISchema) {
+	doc := apiDocs[method+" "+tpl]
+	op := &OpenAPIOperation{
+		OperationId: doc.Id,
+		Summary:     doc.Summary,
+		Deprecated:  doc.Deprecated,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+	if fields := strings.Split(strings.TrimPrefix(path, "/"), "/"); len(fields) > 0 {
+		tag := fields[0]
+		if tag == "explorer" && len(fields) > 1 && !strings.HasPrefix(fields[1], "{") {
+			tag = fields[1]
+		}
+		op.Tags = []string{tag}
+	}
+
+	// path parameters
+	for _, m := range routeVarRegexp.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, &OpenAPIParameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+
+	// query parameters
+	if doc.Args != nil {
+		b.addQueryParams(op, reflect.TypeOf(doc.Args))
+	}
+
+	// request body
+	if doc.Body != nil {
+		op.RequestBody = &OpenAPIBody{
+			Required: true,
+			Content: map[string]OpenAPIMedia{
+				"application/json": {Schema: b.schema(reflect.TypeOf(doc.Body))},
+			},
+		}
+	}
+
+	// responses
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &OpenAPIResponse{Description: "success"}
+	if status != http.StatusNoContent && method != http.MethodHead {
+		res := &OpenAPISchema{}
+		if doc.Result != nil {
+			res = b.schema(reflect.TypeOf(doc.Result))
+		}
+		success.Content = map[string]OpenAPIMedia{"application/json": {Schema: res}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	op.Responses["default"] = &OpenAPIResponse{
+		Description: "error",
+		Content:     map[string]OpenAPIMedia{"application/json": {Schema: errSchema}},
+	}
+
+	ops, ok := b.spec.Paths[path]
+	if !ok {
+		ops = make(map[string]*OpenAPIOperation)
+		b.spec.Paths[path] = ops
+	}
+	ops[strings.ToLower(method)] = op
+}
+
+func (b *openapiBuilder) addQueryParams(op *OpenAPIOperation, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("schema"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			b.addQueryParams(op, f.Type)
+			continue
+		}
+		if !f.IsExported() || name == "" {
+			continue
+		}
+		s := b.schema(f.Type)
+		if s.Ref != "" {
+			// complex types are decoded from their text form
+			s = &OpenAPISchema{Type: "string"}
+		}
+		s.Nullable = false
+		op.Parameters = append(op.Parameters, &OpenAPIParameter{
+			Name:   name,
+			In:     "query",
+			Schema: s,
+		})
+	}
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	rawMessageType      = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func implements(typ, iface reflect.Type) bool {
+	return typ.Implements(iface) || reflect.PtrTo(typ).Implements(iface)
+}
+
+func (b *openapiBuilder) schema(typ reflect.Type) *OpenAPISchema {
+	if typ.Kind() == reflect.Ptr {
+		s := b.schema(typ.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+	switch {
+	case typ == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case typ == rawMessageType:
+		return &OpenAPISchema{}
+	case implements(typ, textMarshalerType) || implements(typ, textUnmarshalerType):
+		return &OpenAPISchema{Type: "string"}
+	case implements(typ, jsonMarshalerType):
+		// custom encoding, type cannot be inferred
+		return &OpenAPISchema{}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: b.schema(typ.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: b.schema(typ.Elem())}
+	case reflect.Struct:
+		return b.structSchema(typ)
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+func (b *openapiBuilder) structSchema(typ reflect.Type) *OpenAPISchema {
+	if typ.Name() == "" {
+		s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		b.addProperties(s, typ)
+		return s
+	}
+	name, ok := b.names[typ]
+	if !ok {
+		name = typ.Name()
+		if _, exists := b.spec.Components.Schemas[name]; exists {
+			pkg := typ.PkgPath()
+			pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		b.names[typ] = name
+		s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+		b.spec.Components.Schemas[name] = s
+		b.addProperties(s, typ)
+	}
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (b *openapiBuilder) addProperties(s *OpenAPISchema, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !implements(ft, jsonMarshalerType) {
+				b.addProperties(s, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		var prop *OpenAPISchema
+		for _, opt := range tag[1:] {
+			if opt == "string" {
+				prop = &OpenAPISchema{Type: "string"}
+			}
+		}
+		if prop == nil {
+			prop = b.schema(f.Type)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// SortedPaths returns spec paths in lexical order.
+func (o *OpenAPI) SortedPaths() []string {
+	paths := make([]string, 0, len(o.Paths))
+	for k := range o.Paths {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"net/http/pprof"
+	"net/url"
 	"time"
+
+	"blockwatch.cc/tzindex/etl"
 )
 
 var (
@@ -19,6 +22,12 @@ type ParsableRequest interface {
 	Parse(ctx *Context)
 }
 
+// QueryParsableRequest is implemented by requests that read filters with
+// dynamic argument names like `type.in` from the raw query.
+type QueryParsableRequest interface {
+	ParseQuery(ctx *Context, query url.Values)
+}
+
 type Options interface {
 	WithPrim() bool
 	WithUnpack() bool
@@ -47,6 +56,20 @@ func Register(model RESTful) {
 	models[model.RESTPrefix()] = model
 }
 
+// routeIndexer decides which optional routes are registered, nil registers all
+var routeIndexer *etl.Indexer
+
+// HasIndex reports whether the index with key is enabled. Models call it from
+// RegisterRoutes to skip routes backed by optional indexes. Without an indexer
+// (e.g. when generating the API spec) all routes are registered.
+func HasIndex(key string) bool {
+	if routeIndexer == nil {
+		return true
+	}
+	_, err := routeIndexer.Index(key)
+	return err == nil
+}
+
 // Generate a new API router with support for HTTP OPTIONS
 func NewRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
@@ -78,6 +101,13 @@ func NewRouter() *mux.Router {
 	router.Handle("/debug/pprof/mutex", pprof.Handler("mutex"))
 	router.PathPrefix("/debug/vars").Handler(expvar.Handler())
 
+	// health probes, answered without going through the dispatcher
+	router.HandleFunc("/health/live", GetLiveness).Methods("GET", "HEAD")
+	router.HandleFunc("/health/ready", GetReadiness).Methods("GET", "HEAD")
+
+	// machine-readable API description
+	router.HandleFunc("/openapi.json", C(GetOpenAPI)).Methods("GET")
+
 	router.PathPrefix("/").HandlerFunc(C(NotFound))
 
 	// configure schema (URL parameter) decoding
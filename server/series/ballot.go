@@ -0,0 +1,435 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package series
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	ballotSeriesNames = util.StringList([]string{
+		"time",
+		"count",
+		"rolls",
+		"stake",
+		"yay_count",
+		"yay_stake",
+		"nay_count",
+		"nay_stake",
+		"pass_count",
+		"pass_stake",
+	})
+)
+
+// BallotModel wraps ballots to provide the series time interface
+// (the ballot time field shadows the method name).
+type BallotModel struct {
+	model.Ballot
+}
+
+func (m *BallotModel) Time() time.Time {
+	return m.Ballot.Time
+}
+
+// configurable marshalling helper
+type BallotSeries struct {
+	Timestamp time.Time `json:"time"`
+	Count     int       `json:"count"`
+	Rolls     int64     `json:"rolls"`
+	Stake     int64     `json:"stake"`
+	YayCount  int       `json:"yay_count"`
+	YayStake  int64     `json:"yay_stake"`
+	NayCount  int       `json:"nay_count"`
+	NayStake  int64     `json:"nay_stake"`
+	PassCount int       `json:"pass_count"`
+	PassStake int64     `json:"pass_stake"`
+
+	columns util.StringList // cond. cols & order when brief
+	params  *tezos.Params
+	verbose bool
+	null    bool
+}
+
+var _ SeriesBucket = (*BallotSeries)(nil)
+
+func (s *BallotSeries) Init(params *tezos.Params, columns []string, verbose bool) {
+	s.params = params
+	s.columns = columns
+	s.verbose = verbose
+}
+
+func (s *BallotSeries) IsEmpty() bool {
+	return s.Count == 0
+}
+
+func (s *BallotSeries) Add(m SeriesModel) {
+	o := m.(*BallotModel)
+	s.Rolls += o.Rolls
+	s.Stake += o.Stake
+	switch o.Ballot.Ballot {
+	case tezos.BallotVoteYay:
+		s.YayCount++
+		s.YayStake += o.Stake
+	case tezos.BallotVoteNay:
+		s.NayCount++
+		s.NayStake += o.Stake
+	case tezos.BallotVotePass:
+		s.PassCount++
+		s.PassStake += o.Stake
+	}
+	s.Count++
+}
+
+func (s *BallotSeries) Reset() {
+	s.Timestamp = time.Time{}
+	s.Count = 0
+	s.Rolls = 0
+	s.Stake = 0
+	s.YayCount = 0
+	s.YayStake = 0
+	s.NayCount = 0
+	s.NayStake = 0
+	s.PassCount = 0
+	s.PassStake = 0
+	s.null = false
+}
+
+func (s *BallotSeries) Null(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	s.null = true
+	return s
+}
+
+func (s *BallotSeries) Zero(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	return s
+}
+
+func (s *BallotSeries) SetTime(ts time.Time) SeriesBucket {
+	s.Timestamp = ts
+	return s
+}
+
+func (s *BallotSeries) Time() time.Time {
+	return s.Timestamp
+}
+
+func (s *BallotSeries) Clone() SeriesBucket {
+	c := *s
+	return &c
+}
+
+func (s *BallotSeries) Interpolate(m SeriesBucket, ts time.Time) SeriesBucket {
+	o := m.(*BallotSeries)
+	weight := float64(ts.Sub(s.Timestamp)) / float64(o.Timestamp.Sub(s.Timestamp))
+	if math.IsInf(weight, 1) {
+		weight = 1
+	}
+	switch weight {
+	case 0:
+		return s
+	default:
+		return &BallotSeries{
+			Timestamp: ts,
+			Rolls:     s.Rolls + int64(weight*float64(o.Rolls-s.Rolls)),
+			Stake:     s.Stake + int64(weight*float64(o.Stake-s.Stake)),
+			YayStake:  s.YayStake + int64(weight*float64(o.YayStake-s.YayStake)),
+			NayStake:  s.NayStake + int64(weight*float64(o.NayStake-s.NayStake)),
+			PassStake: s.PassStake + int64(weight*float64(o.PassStake-s.PassStake)),
+			columns:   s.columns,
+			params:    s.params,
+			verbose:   s.verbose,
+			null:      false,
+		}
+	}
+}
+
+func (b *BallotSeries) MarshalJSON() ([]byte, error) {
+	if b.verbose {
+		return b.MarshalJSONVerbose()
+	} else {
+		return b.MarshalJSONBrief()
+	}
+}
+
+func (b *BallotSeries) MarshalJSONVerbose() ([]byte, error) {
+	ballot := struct {
+		Timestamp time.Time `json:"time"`
+		Count     int       `json:"count"`
+		Rolls     int64     `json:"rolls"`
+		Stake     float64   `json:"stake"`
+		YayCount  int       `json:"yay_count"`
+		YayStake  float64   `json:"yay_stake"`
+		NayCount  int       `json:"nay_count"`
+		NayStake  float64   `json:"nay_stake"`
+		PassCount int       `json:"pass_count"`
+		PassStake float64   `json:"pass_stake"`
+	}{
+		Timestamp: b.Timestamp,
+		Count:     b.Count,
+		Rolls:     b.Rolls,
+		Stake:     b.params.ConvertValue(b.Stake),
+		YayCount:  b.YayCount,
+		YayStake:  b.params.ConvertValue(b.YayStake),
+		NayCount:  b.NayCount,
+		NayStake:  b.params.ConvertValue(b.NayStake),
+		PassCount: b.PassCount,
+		PassStake: b.params.ConvertValue(b.PassStake),
+	}
+	return json.Marshal(ballot)
+}
+
+func (b *BallotSeries) MarshalJSONBrief() ([]byte, error) {
+	dec := b.params.Decimals
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range b.columns {
+		if b.null {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(b.Timestamp), 10)
+			default:
+				buf = append(buf, null...)
+			}
+		} else {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(b.Timestamp), 10)
+			case "count":
+				buf = strconv.AppendInt(buf, int64(b.Count), 10)
+			case "rolls":
+				buf = strconv.AppendInt(buf, b.Rolls, 10)
+			case "stake":
+				buf = strconv.AppendFloat(buf, b.params.ConvertValue(b.Stake), 'f', dec, 64)
+			case "yay_count":
+				buf = strconv.AppendInt(buf, int64(b.YayCount), 10)
+			case "yay_stake":
+				buf = strconv.AppendFloat(buf, b.params.ConvertValue(b.YayStake), 'f', dec, 64)
+			case "nay_count":
+				buf = strconv.AppendInt(buf, int64(b.NayCount), 10)
+			case "nay_stake":
+				buf = strconv.AppendFloat(buf, b.params.ConvertValue(b.NayStake), 'f', dec, 64)
+			case "pass_count":
+				buf = strconv.AppendInt(buf, int64(b.PassCount), 10)
+			case "pass_stake":
+				buf = strconv.AppendFloat(buf, b.params.ConvertValue(b.PassStake), 'f', dec, 64)
+			default:
+				continue
+			}
+		}
+		if i < len(b.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (b *BallotSeries) MarshalCSV() ([]string, error) {
+	dec := b.params.Decimals
+	res := make([]string, len(b.columns))
+	for i, v := range b.columns {
+		if b.null {
+			switch v {
+			case "time":
+				res[i] = strconv.Quote(b.Timestamp.Format(time.RFC3339))
+			default:
+				continue
+			}
+		}
+		switch v {
+		case "time":
+			res[i] = strconv.Quote(b.Timestamp.Format(time.RFC3339))
+		case "count":
+			res[i] = strconv.FormatInt(int64(b.Count), 10)
+		case "rolls":
+			res[i] = strconv.FormatInt(b.Rolls, 10)
+		case "stake":
+			res[i] = strconv.FormatFloat(b.params.ConvertValue(b.Stake), 'f', dec, 64)
+		case "yay_count":
+			res[i] = strconv.FormatInt(int64(b.YayCount), 10)
+		case "yay_stake":
+			res[i] = strconv.FormatFloat(b.params.ConvertValue(b.YayStake), 'f', dec, 64)
+		case "nay_count":
+			res[i] = strconv.FormatInt(int64(b.NayCount), 10)
+		case "nay_stake":
+			res[i] = strconv.FormatFloat(b.params.ConvertValue(b.NayStake), 'f', dec, 64)
+		case "pass_count":
+			res[i] = strconv.FormatInt(int64(b.PassCount), 10)
+		case "pass_stake":
+			res[i] = strconv.FormatFloat(b.params.ConvertValue(b.PassStake), 'f', dec, 64)
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func (s *BallotSeries) BuildQuery(ctx *server.Context, args *SeriesRequest) pack.Query {
+	// access table
+	table, err := ctx.Indexer.Table(index.BallotTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Series), err))
+	}
+
+	// time is auto-added from parser
+	if len(args.Columns) == 1 {
+		// use all series columns
+		args.Columns = ballotSeriesNames
+	}
+	for _, v := range args.Columns {
+		if !ballotSeriesNames.Contains(v) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid time-series column '%s'", v), nil))
+		}
+	}
+
+	// build table query, vote columns are derived from the ballot
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields("time", "rolls", "stake", "ballot").
+		WithOrder(args.Order).
+		AndRange("time", args.From.Time(), args.To.Time())
+
+	// build dynamic filter conditions from query (will panic on error)
+	for key, val := range ctx.Request.URL.Query() {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "collapse", "start_date", "end_date", "limit", "order", "verbose", "filename", "fill":
+			// skip these fields
+			continue
+
+		case "source":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				// single-address lookup and compile condition
+				addr, err := tezos.ParseAddress(val[0])
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				// Note: when not found we insert an always false condition
+				if acc == nil || acc.RowId == 0 {
+					q = q.And("source_id", mode, uint64(math.MaxUint64))
+				} else {
+					q = q.And("source_id", mode, acc.RowId)
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				// multi-address lookup and compile condition
+				ids := make([]uint64, 0)
+				for _, v := range strings.Split(val[0], ",") {
+					addr, err := tezos.ParseAddress(v)
+					if err != nil {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+					if err != nil && err != index.ErrNoAccountEntry {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					// skip not found account
+					if acc == nil || acc.RowId == 0 {
+						continue
+					}
+					ids = append(ids, acc.RowId.Value())
+				}
+				q = q.And("source_id", mode, ids)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "proposal":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				p, err := tezos.ParseProtocolHash(val[0])
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid proposal '%s'", val[0]), err))
+				}
+				prop, err := ctx.Indexer.LookupProposal(ctx, p)
+				if err != nil && err != index.ErrNoProposalEntry {
+					panic(server.EInternal(server.EC_DATABASE, fmt.Sprintf("cannot lookup proposal '%s'", val[0]), err))
+				}
+				// Note: when not found we insert an always false condition
+				if prop == nil || prop.RowId == 0 {
+					q = q.And("proposal_id", mode, uint64(math.MaxUint64))
+				} else {
+					q = q.And("proposal_id", mode, prop.RowId)
+				}
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "ballot":
+			ballots := make([]uint8, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				ballot := tezos.ParseBallotVote(v)
+				if !ballot.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid ballot vote '%s'", v), nil))
+				}
+				ballots = append(ballots, uint8(ballot))
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And("ballot", mode, ballots[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And("ballot", mode, ballots)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "election", "election_id", "voting_period", "voting_period_kind":
+			field := prefix
+			if prefix == "election" {
+				field = "election_id"
+				key = strings.Replace(key, prefix, field, 1)
+			}
+			for _, v := range val {
+				if prefix == "voting_period_kind" {
+					fvals := make([]string, 0)
+					for _, vv := range strings.Split(v, ",") {
+						fval := tezos.ParseVotingPeriod(vv)
+						if !fval.IsValid() {
+							panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, vv), nil))
+						}
+						fvals = append(fvals, strconv.Itoa(fval.Num()))
+					}
+					v = strings.Join(fvals, ",")
+				}
+				if cond, err := pack.ParseCondition(key, v, table.Fields()); err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+				} else {
+					q = q.AndCondition(cond)
+				}
+			}
+
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+		}
+	}
+
+	return q
+}
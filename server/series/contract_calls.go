@@ -0,0 +1,379 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package series
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	contractCallSeriesNames = util.StringList([]string{
+		"time",
+		"n_calls",
+		"n_failed",
+		"sum_daily_callers",
+		"gas_used",
+		"fee",
+		"volume",
+	})
+)
+
+// configurable marshalling helper
+//
+// Note: callers are only unique per day, sum_daily_callers adds up daily
+// unique callers and counts callers active on several days more than once.
+type ContractCallSeries struct {
+	Timestamp       time.Time `json:"time"`
+	NCalls          int       `json:"n_calls"`
+	NFailed         int       `json:"n_failed"`
+	SumDailyCallers int       `json:"sum_daily_callers"`
+	GasUsed         int64     `json:"gas_used"`
+	Fee             int64     `json:"fee"`
+	Volume          int64     `json:"volume"`
+
+	columns util.StringList // cond. cols & order when brief
+	params  *tezos.Params
+	verbose bool
+	null    bool
+}
+
+var _ SeriesBucket = (*ContractCallSeries)(nil)
+
+func (s *ContractCallSeries) Init(params *tezos.Params, columns []string, verbose bool) {
+	s.params = params
+	s.columns = columns
+	s.verbose = verbose
+}
+
+func (s *ContractCallSeries) IsEmpty() bool {
+	return s.NCalls == 0
+}
+
+func (s *ContractCallSeries) Add(m SeriesModel) {
+	o := m.(*model.ContractCallStats)
+	s.NCalls += o.NCalls
+	s.NFailed += o.NFailed
+	s.SumDailyCallers += o.NCallers
+	s.GasUsed += o.GasUsed
+	s.Fee += o.Fee
+	s.Volume += o.Volume
+}
+
+func (s *ContractCallSeries) Reset() {
+	s.Timestamp = time.Time{}
+	s.NCalls = 0
+	s.NFailed = 0
+	s.SumDailyCallers = 0
+	s.GasUsed = 0
+	s.Fee = 0
+	s.Volume = 0
+	s.null = false
+}
+
+func (s *ContractCallSeries) Null(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	s.null = true
+	return s
+}
+
+func (s *ContractCallSeries) Zero(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	return s
+}
+
+func (s *ContractCallSeries) SetTime(ts time.Time) SeriesBucket {
+	s.Timestamp = ts
+	return s
+}
+
+func (s *ContractCallSeries) Time() time.Time {
+	return s.Timestamp
+}
+
+func (s *ContractCallSeries) Clone() SeriesBucket {
+	c := *s
+	return &c
+}
+
+func (s *ContractCallSeries) Interpolate(m SeriesBucket, ts time.Time) SeriesBucket {
+	o := m.(*ContractCallSeries)
+	weight := float64(ts.Sub(s.Timestamp)) / float64(o.Timestamp.Sub(s.Timestamp))
+	if math.IsInf(weight, 1) {
+		weight = 1
+	}
+	switch weight {
+	case 0:
+		return s
+	default:
+		return &ContractCallSeries{
+			Timestamp:       ts,
+			NCalls:          s.NCalls + int(weight*float64(o.NCalls-s.NCalls)),
+			NFailed:         s.NFailed + int(weight*float64(o.NFailed-s.NFailed)),
+			SumDailyCallers: s.SumDailyCallers + int(weight*float64(o.SumDailyCallers-s.SumDailyCallers)),
+			GasUsed:         s.GasUsed + int64(weight*float64(o.GasUsed-s.GasUsed)),
+			Fee:             s.Fee + int64(weight*float64(o.Fee-s.Fee)),
+			Volume:          s.Volume + int64(weight*float64(o.Volume-s.Volume)),
+			columns:         s.columns,
+			params:          s.params,
+			verbose:         s.verbose,
+			null:            false,
+		}
+	}
+}
+
+func (s *ContractCallSeries) MarshalJSON() ([]byte, error) {
+	if s.verbose {
+		return s.MarshalJSONVerbose()
+	} else {
+		return s.MarshalJSONBrief()
+	}
+}
+
+func (s *ContractCallSeries) MarshalJSONVerbose() ([]byte, error) {
+	calls := struct {
+		Timestamp       time.Time `json:"time"`
+		NCalls          int       `json:"n_calls"`
+		NFailed         int       `json:"n_failed"`
+		SumDailyCallers int       `json:"sum_daily_callers"`
+		GasUsed         int64     `json:"gas_used"`
+		Fee             float64   `json:"fee"`
+		Volume          float64   `json:"volume"`
+	}{
+		Timestamp:       s.Timestamp,
+		NCalls:          s.NCalls,
+		NFailed:         s.NFailed,
+		SumDailyCallers: s.SumDailyCallers,
+		GasUsed:         s.GasUsed,
+		Fee:             s.params.ConvertValue(s.Fee),
+		Volume:          s.params.ConvertValue(s.Volume),
+	}
+	return json.Marshal(calls)
+}
+
+func (s *ContractCallSeries) MarshalJSONBrief() ([]byte, error) {
+	dec := s.params.Decimals
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			default:
+				buf = append(buf, null...)
+			}
+		} else {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			case "n_calls":
+				buf = strconv.AppendInt(buf, int64(s.NCalls), 10)
+			case "n_failed":
+				buf = strconv.AppendInt(buf, int64(s.NFailed), 10)
+			case "sum_daily_callers":
+				buf = strconv.AppendInt(buf, int64(s.SumDailyCallers), 10)
+			case "gas_used":
+				buf = strconv.AppendInt(buf, s.GasUsed, 10)
+			case "fee":
+				buf = strconv.AppendFloat(buf, s.params.ConvertValue(s.Fee), 'f', dec, 64)
+			case "volume":
+				buf = strconv.AppendFloat(buf, s.params.ConvertValue(s.Volume), 'f', dec, 64)
+			default:
+				continue
+			}
+		}
+		if i < len(s.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (s *ContractCallSeries) MarshalCSV() ([]string, error) {
+	dec := s.params.Decimals
+	res := make([]string, len(s.columns))
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+			default:
+				continue
+			}
+		}
+		switch v {
+		case "time":
+			res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+		case "n_calls":
+			res[i] = strconv.FormatInt(int64(s.NCalls), 10)
+		case "n_failed":
+			res[i] = strconv.FormatInt(int64(s.NFailed), 10)
+		case "sum_daily_callers":
+			res[i] = strconv.FormatInt(int64(s.SumDailyCallers), 10)
+		case "gas_used":
+			res[i] = strconv.FormatInt(s.GasUsed, 10)
+		case "fee":
+			res[i] = strconv.FormatFloat(s.params.ConvertValue(s.Fee), 'f', dec, 64)
+		case "volume":
+			res[i] = strconv.FormatFloat(s.params.ConvertValue(s.Volume), 'f', dec, 64)
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func (s *ContractCallSeries) BuildQuery(ctx *server.Context, args *SeriesRequest) pack.Query {
+	// access table
+	table, err := ctx.Indexer.Table(index.ContractCallTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Series), err))
+	}
+
+	// time is auto-added from parser
+	if len(args.Columns) == 1 {
+		// use all series columns
+		args.Columns = contractCallSeriesNames
+	}
+	for _, v := range args.Columns {
+		if !contractCallSeriesNames.Contains(v) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid time-series column '%s'", v), nil))
+		}
+	}
+
+	// build table query, all stats columns are needed for bucket state
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields("time", "n_calls", "n_failed", "n_callers", "gas_used", "fee", "volume").
+		WithOrder(args.Order).
+		AndRange("time", args.From.Time(), args.To.Time())
+
+	// entrypoint names can only be resolved for a single contract
+	var contract *model.Contract
+
+	// build dynamic filter conditions from query (will panic on error)
+	query := ctx.Request.URL.Query()
+	for key, val := range query {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "collapse", "start_date", "end_date", "limit", "order", "verbose", "filename", "fill":
+			// skip these fields
+			continue
+
+		case "contract":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				// single-address lookup and compile condition
+				addr, err := tezos.ParseAddress(val[0])
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				cc, err := ctx.Indexer.LookupContract(ctx, addr)
+				if err != nil && err != index.ErrNoContractEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				// Note: when not found we insert an always false condition
+				if cc == nil || cc.RowId == 0 {
+					q = q.And("account_id", mode, uint64(math.MaxUint64))
+				} else {
+					q = q.And("account_id", mode, cc.AccountId)
+					if mode == pack.FilterModeEqual {
+						contract = cc
+					}
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				// multi-address lookup and compile condition
+				ids := make([]uint64, 0)
+				for _, v := range strings.Split(val[0], ",") {
+					addr, err := tezos.ParseAddress(v)
+					if err != nil {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+					if err != nil && err != index.ErrNoAccountEntry {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					// skip not found account
+					if acc == nil || acc.RowId == 0 {
+						continue
+					}
+					ids = append(ids, acc.RowId.Value())
+				}
+				q = q.And("account_id", mode, ids)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "entrypoint":
+			// handled below once the contract is known
+
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+		}
+	}
+
+	// entrypoints are ids or names when filtering a single contract
+	for key, val := range query {
+		keys := strings.Split(key, ".")
+		if keys[0] != "entrypoint" {
+			continue
+		}
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+		}
+		var eps micheline.Entrypoints
+		if contract != nil {
+			if pTyp, _, err := contract.LoadType(); err == nil {
+				eps, _ = pTyp.Entrypoints(false)
+			}
+		}
+		ids := make([]int, 0)
+		for _, v := range strings.Split(val[0], ",") {
+			if id, err := strconv.Atoi(v); err == nil {
+				ids = append(ids, id)
+				continue
+			}
+			ep, ok := eps[v]
+			if !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown entrypoint '%s'", v), nil))
+			}
+			ids = append(ids, ep.Id)
+		}
+		switch mode {
+		case pack.FilterModeEqual, pack.FilterModeNotEqual:
+			q = q.And("entrypoint_id", mode, ids[0])
+		case pack.FilterModeIn, pack.FilterModeNotIn:
+			q = q.And("entrypoint_id", mode, ids)
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, keys[0]), nil))
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,352 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package series
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	eventSeriesNames = util.StringList([]string{
+		"time",
+		"n_events",
+		"n_contracts",
+		"n_tags",
+	})
+)
+
+// events carry no timestamp, block time is looked up by height
+type EventModel struct {
+	model.Event
+	idx *etl.Indexer
+	ctx context.Context
+}
+
+func (m *EventModel) Time() time.Time {
+	return m.idx.LookupBlockTime(m.ctx, m.Height)
+}
+
+// configurable marshalling helper
+type EventSeries struct {
+	Timestamp  time.Time `json:"time"`
+	NEvents    int       `json:"n_events"`
+	NContracts int       `json:"n_contracts"`
+	NTags      int       `json:"n_tags"`
+
+	contracts map[model.AccountID]struct{}
+	tags      map[string]struct{}
+	columns   util.StringList // cond. cols & order when brief
+	params    *tezos.Params
+	verbose   bool
+	null      bool
+}
+
+var _ SeriesBucket = (*EventSeries)(nil)
+
+func (s *EventSeries) Init(params *tezos.Params, columns []string, verbose bool) {
+	s.params = params
+	s.columns = columns
+	s.verbose = verbose
+	s.contracts = make(map[model.AccountID]struct{})
+	s.tags = make(map[string]struct{})
+}
+
+func (s *EventSeries) IsEmpty() bool {
+	return s.NEvents == 0
+}
+
+func (s *EventSeries) Add(m SeriesModel) {
+	o := m.(*EventModel)
+	s.NEvents++
+	if _, ok := s.contracts[o.AccountId]; !ok {
+		s.contracts[o.AccountId] = struct{}{}
+		s.NContracts++
+	}
+	if _, ok := s.tags[o.Tag]; !ok {
+		s.tags[o.Tag] = struct{}{}
+		s.NTags++
+	}
+}
+
+func (s *EventSeries) Reset() {
+	s.Timestamp = time.Time{}
+	s.NEvents = 0
+	s.NContracts = 0
+	s.NTags = 0
+	s.contracts = make(map[model.AccountID]struct{})
+	s.tags = make(map[string]struct{})
+	s.null = false
+}
+
+func (s *EventSeries) Null(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	s.null = true
+	return s
+}
+
+func (s *EventSeries) Zero(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	return s
+}
+
+func (s *EventSeries) SetTime(ts time.Time) SeriesBucket {
+	s.Timestamp = ts
+	return s
+}
+
+func (s *EventSeries) Time() time.Time {
+	return s.Timestamp
+}
+
+func (s *EventSeries) Clone() SeriesBucket {
+	c := *s
+	c.contracts = make(map[model.AccountID]struct{}, len(s.contracts))
+	for k := range s.contracts {
+		c.contracts[k] = struct{}{}
+	}
+	c.tags = make(map[string]struct{}, len(s.tags))
+	for k := range s.tags {
+		c.tags[k] = struct{}{}
+	}
+	return &c
+}
+
+func (s *EventSeries) Interpolate(m SeriesBucket, ts time.Time) SeriesBucket {
+	o := m.(*EventSeries)
+	weight := float64(ts.Sub(s.Timestamp)) / float64(o.Timestamp.Sub(s.Timestamp))
+	if math.IsInf(weight, 1) {
+		weight = 1
+	}
+	switch weight {
+	case 0:
+		return s
+	default:
+		return &EventSeries{
+			Timestamp:  ts,
+			NEvents:    s.NEvents + int(weight*float64(o.NEvents-s.NEvents)),
+			NContracts: s.NContracts + int(weight*float64(o.NContracts-s.NContracts)),
+			NTags:      s.NTags + int(weight*float64(o.NTags-s.NTags)),
+			contracts:  make(map[model.AccountID]struct{}),
+			tags:       make(map[string]struct{}),
+			columns:    s.columns,
+			params:     s.params,
+			verbose:    s.verbose,
+			null:       false,
+		}
+	}
+}
+
+func (s *EventSeries) MarshalJSON() ([]byte, error) {
+	if s.verbose {
+		return s.MarshalJSONVerbose()
+	} else {
+		return s.MarshalJSONBrief()
+	}
+}
+
+func (s *EventSeries) MarshalJSONVerbose() ([]byte, error) {
+	events := struct {
+		Timestamp  time.Time `json:"time"`
+		NEvents    int       `json:"n_events"`
+		NContracts int       `json:"n_contracts"`
+		NTags      int       `json:"n_tags"`
+	}{
+		Timestamp:  s.Timestamp,
+		NEvents:    s.NEvents,
+		NContracts: s.NContracts,
+		NTags:      s.NTags,
+	}
+	return json.Marshal(events)
+}
+
+func (s *EventSeries) MarshalJSONBrief() ([]byte, error) {
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			default:
+				buf = append(buf, null...)
+			}
+		} else {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			case "n_events":
+				buf = strconv.AppendInt(buf, int64(s.NEvents), 10)
+			case "n_contracts":
+				buf = strconv.AppendInt(buf, int64(s.NContracts), 10)
+			case "n_tags":
+				buf = strconv.AppendInt(buf, int64(s.NTags), 10)
+			default:
+				continue
+			}
+		}
+		if i < len(s.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (s *EventSeries) MarshalCSV() ([]string, error) {
+	res := make([]string, len(s.columns))
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+			default:
+				continue
+			}
+		}
+		switch v {
+		case "time":
+			res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+		case "n_events":
+			res[i] = strconv.FormatInt(int64(s.NEvents), 10)
+		case "n_contracts":
+			res[i] = strconv.FormatInt(int64(s.NContracts), 10)
+		case "n_tags":
+			res[i] = strconv.FormatInt(int64(s.NTags), 10)
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func (s *EventSeries) BuildQuery(ctx *server.Context, args *SeriesRequest) pack.Query {
+	// access table
+	table, err := ctx.Indexer.Table(index.EventTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Series), err))
+	}
+
+	// time is auto-added from parser
+	if len(args.Columns) == 1 {
+		// use all series columns
+		args.Columns = eventSeriesNames
+	}
+	for _, v := range args.Columns {
+		if !eventSeriesNames.Contains(v) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid time-series column '%s'", v), nil))
+		}
+	}
+
+	// build table query, events have no time column so we query by height
+	from := ctx.Indexer.LookupBlockHeightFromTime(ctx.Context, args.From.Time())
+	to := ctx.Indexer.LookupBlockHeightFromTime(ctx.Context, args.To.Time())
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields("height", "account_id", "tag").
+		WithOrder(args.Order).
+		AndRange("height", from, to)
+
+	// build dynamic filter conditions from query (will panic on error)
+	for key, val := range ctx.Request.URL.Query() {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "collapse", "start_date", "end_date", "limit", "order", "verbose", "filename", "fill":
+			// skip these fields
+			continue
+
+		case "contract", "address":
+			field := "account_id"
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				// single-address lookup and compile condition
+				addr, err := tezos.ParseAddress(val[0])
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				// Note: when not found we insert an always false condition
+				if acc == nil || acc.RowId == 0 {
+					q = q.And(field, mode, uint64(math.MaxUint64))
+				} else {
+					q = q.And(field, mode, acc.RowId)
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				// multi-address lookup and compile condition
+				ids := make([]uint64, 0)
+				for _, v := range strings.Split(val[0], ",") {
+					addr, err := tezos.ParseAddress(v)
+					if err != nil {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+					if err != nil && err != index.ErrNoAccountEntry {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					// skip not found account
+					if acc == nil || acc.RowId == 0 {
+						continue
+					}
+					ids = append(ids, acc.RowId.Value())
+				}
+				q = q.And(field, mode, ids)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "tag":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And(prefix, mode, val[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(prefix, mode, strings.Split(val[0], ","))
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "type_hash":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				var h util.U64String
+				if err := h.UnmarshalText([]byte(val[0])); err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid type hash '%s'", val[0]), err))
+				}
+				q = q.And(prefix, mode, h.U64())
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+		}
+	}
+
+	return q
+}
@@ -0,0 +1,433 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package series
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	failureSeriesNames = util.StringList([]string{
+		"time",
+		"n_failed",
+		"script_rejected",
+		"gas_exhausted",
+		"balance_too_low",
+		"storage_exhausted",
+		"other",
+		"fee",
+	})
+)
+
+// configurable marshalling helper
+//
+// Note: other counts all failures that are not in one of the explicit classes,
+// including backtracked and skipped operations.
+type FailureSeries struct {
+	Timestamp        time.Time `json:"time"`
+	NFailed          int       `json:"n_failed"`
+	ScriptRejected   int       `json:"script_rejected"`
+	GasExhausted     int       `json:"gas_exhausted"`
+	BalanceTooLow    int       `json:"balance_too_low"`
+	StorageExhausted int       `json:"storage_exhausted"`
+	Other            int       `json:"other"`
+	Fee              int64     `json:"fee"`
+
+	columns util.StringList // cond. cols & order when brief
+	params  *tezos.Params
+	verbose bool
+	null    bool
+}
+
+var _ SeriesBucket = (*FailureSeries)(nil)
+
+func (s *FailureSeries) Init(params *tezos.Params, columns []string, verbose bool) {
+	s.params = params
+	s.columns = columns
+	s.verbose = verbose
+}
+
+func (s *FailureSeries) IsEmpty() bool {
+	return s.NFailed == 0
+}
+
+func (s *FailureSeries) Add(m SeriesModel) {
+	o := m.(*model.OpError)
+	s.NFailed++
+	switch o.Class {
+	case model.ErrorClassScriptRejected:
+		s.ScriptRejected++
+	case model.ErrorClassGasExhausted:
+		s.GasExhausted++
+	case model.ErrorClassBalanceTooLow:
+		s.BalanceTooLow++
+	case model.ErrorClassStorageExhausted:
+		s.StorageExhausted++
+	default:
+		s.Other++
+	}
+	s.Fee += o.Fee
+}
+
+func (s *FailureSeries) Reset() {
+	s.Timestamp = time.Time{}
+	s.NFailed = 0
+	s.ScriptRejected = 0
+	s.GasExhausted = 0
+	s.BalanceTooLow = 0
+	s.StorageExhausted = 0
+	s.Other = 0
+	s.Fee = 0
+	s.null = false
+}
+
+func (s *FailureSeries) Null(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	s.null = true
+	return s
+}
+
+func (s *FailureSeries) Zero(ts time.Time) SeriesBucket {
+	s.Reset()
+	s.Timestamp = ts
+	return s
+}
+
+func (s *FailureSeries) SetTime(ts time.Time) SeriesBucket {
+	s.Timestamp = ts
+	return s
+}
+
+func (s *FailureSeries) Time() time.Time {
+	return s.Timestamp
+}
+
+func (s *FailureSeries) Clone() SeriesBucket {
+	c := *s
+	return &c
+}
+
+func (s *FailureSeries) Interpolate(m SeriesBucket, ts time.Time) SeriesBucket {
+	o := m.(*FailureSeries)
+	weight := float64(ts.Sub(s.Timestamp)) / float64(o.Timestamp.Sub(s.Timestamp))
+	if math.IsInf(weight, 1) {
+		weight = 1
+	}
+	switch weight {
+	case 0:
+		return s
+	default:
+		return &FailureSeries{
+			Timestamp:        ts,
+			NFailed:          s.NFailed + int(weight*float64(o.NFailed-s.NFailed)),
+			ScriptRejected:   s.ScriptRejected + int(weight*float64(o.ScriptRejected-s.ScriptRejected)),
+			GasExhausted:     s.GasExhausted + int(weight*float64(o.GasExhausted-s.GasExhausted)),
+			BalanceTooLow:    s.BalanceTooLow + int(weight*float64(o.BalanceTooLow-s.BalanceTooLow)),
+			StorageExhausted: s.StorageExhausted + int(weight*float64(o.StorageExhausted-s.StorageExhausted)),
+			Other:            s.Other + int(weight*float64(o.Other-s.Other)),
+			Fee:              s.Fee + int64(weight*float64(o.Fee-s.Fee)),
+			columns:          s.columns,
+			params:           s.params,
+			verbose:          s.verbose,
+			null:             false,
+		}
+	}
+}
+
+func (s *FailureSeries) MarshalJSON() ([]byte, error) {
+	if s.verbose {
+		return s.MarshalJSONVerbose()
+	} else {
+		return s.MarshalJSONBrief()
+	}
+}
+
+func (s *FailureSeries) MarshalJSONVerbose() ([]byte, error) {
+	failures := struct {
+		Timestamp        time.Time `json:"time"`
+		NFailed          int       `json:"n_failed"`
+		ScriptRejected   int       `json:"script_rejected"`
+		GasExhausted     int       `json:"gas_exhausted"`
+		BalanceTooLow    int       `json:"balance_too_low"`
+		StorageExhausted int       `json:"storage_exhausted"`
+		Other            int       `json:"other"`
+		Fee              float64   `json:"fee"`
+	}{
+		Timestamp:        s.Timestamp,
+		NFailed:          s.NFailed,
+		ScriptRejected:   s.ScriptRejected,
+		GasExhausted:     s.GasExhausted,
+		BalanceTooLow:    s.BalanceTooLow,
+		StorageExhausted: s.StorageExhausted,
+		Other:            s.Other,
+		Fee:              s.params.ConvertValue(s.Fee),
+	}
+	return json.Marshal(failures)
+}
+
+func (s *FailureSeries) MarshalJSONBrief() ([]byte, error) {
+	dec := s.params.Decimals
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			default:
+				buf = append(buf, null...)
+			}
+		} else {
+			switch v {
+			case "time":
+				buf = strconv.AppendInt(buf, util.UnixMilliNonZero(s.Timestamp), 10)
+			case "n_failed":
+				buf = strconv.AppendInt(buf, int64(s.NFailed), 10)
+			case "script_rejected":
+				buf = strconv.AppendInt(buf, int64(s.ScriptRejected), 10)
+			case "gas_exhausted":
+				buf = strconv.AppendInt(buf, int64(s.GasExhausted), 10)
+			case "balance_too_low":
+				buf = strconv.AppendInt(buf, int64(s.BalanceTooLow), 10)
+			case "storage_exhausted":
+				buf = strconv.AppendInt(buf, int64(s.StorageExhausted), 10)
+			case "other":
+				buf = strconv.AppendInt(buf, int64(s.Other), 10)
+			case "fee":
+				buf = strconv.AppendFloat(buf, s.params.ConvertValue(s.Fee), 'f', dec, 64)
+			default:
+				continue
+			}
+		}
+		if i < len(s.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (s *FailureSeries) MarshalCSV() ([]string, error) {
+	dec := s.params.Decimals
+	res := make([]string, len(s.columns))
+	for i, v := range s.columns {
+		if s.null {
+			switch v {
+			case "time":
+				res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+			default:
+				continue
+			}
+		}
+		switch v {
+		case "time":
+			res[i] = strconv.Quote(s.Timestamp.Format(time.RFC3339))
+		case "n_failed":
+			res[i] = strconv.FormatInt(int64(s.NFailed), 10)
+		case "script_rejected":
+			res[i] = strconv.FormatInt(int64(s.ScriptRejected), 10)
+		case "gas_exhausted":
+			res[i] = strconv.FormatInt(int64(s.GasExhausted), 10)
+		case "balance_too_low":
+			res[i] = strconv.FormatInt(int64(s.BalanceTooLow), 10)
+		case "storage_exhausted":
+			res[i] = strconv.FormatInt(int64(s.StorageExhausted), 10)
+		case "other":
+			res[i] = strconv.FormatInt(int64(s.Other), 10)
+		case "fee":
+			res[i] = strconv.FormatFloat(s.params.ConvertValue(s.Fee), 'f', dec, 64)
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func (s *FailureSeries) BuildQuery(ctx *server.Context, args *SeriesRequest) pack.Query {
+	// access table
+	table, err := ctx.Indexer.Table(index.OpErrorTableKey)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Series), err))
+	}
+
+	// time is auto-added from parser
+	if len(args.Columns) == 1 {
+		// use all series columns
+		args.Columns = failureSeriesNames
+	}
+	for _, v := range args.Columns {
+		if !failureSeriesNames.Contains(v) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid time-series column '%s'", v), nil))
+		}
+	}
+
+	// build table query, class is needed for bucket state
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields("time", "class", "fee").
+		WithOrder(args.Order).
+		AndRange("time", args.From.Time(), args.To.Time())
+
+	// entrypoint names can only be resolved for a single contract
+	var contract *model.Contract
+
+	// build dynamic filter conditions from query (will panic on error)
+	query := ctx.Request.URL.Query()
+	for key, val := range query {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "collapse", "start_date", "end_date", "limit", "order", "verbose", "filename", "fill":
+			// skip these fields
+			continue
+
+		case "contract", "receiver", "sender":
+			field := "receiver_id"
+			if prefix == "sender" {
+				field = "sender_id"
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				// single-address lookup and compile condition
+				addr, err := tezos.ParseAddress(val[0])
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", val[0]), err))
+				}
+				// Note: when not found we insert an always false condition
+				if acc == nil || acc.RowId == 0 {
+					q = q.And(field, mode, uint64(math.MaxUint64))
+				} else {
+					q = q.And(field, mode, acc.RowId)
+					if mode == pack.FilterModeEqual && field == "receiver_id" && acc.IsContract {
+						contract, _ = ctx.Indexer.LookupContractId(ctx, acc.RowId)
+					}
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				// multi-address lookup and compile condition
+				ids := make([]uint64, 0)
+				for _, v := range strings.Split(val[0], ",") {
+					addr, err := tezos.ParseAddress(v)
+					if err != nil {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+					if err != nil && err != index.ErrNoAccountEntry {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+					}
+					// skip not found account
+					if acc == nil || acc.RowId == 0 {
+						continue
+					}
+					ids = append(ids, acc.RowId.Value())
+				}
+				q = q.And(field, mode, ids)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "class", "error_id":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And(prefix, mode, val[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(prefix, mode, strings.Split(val[0], ","))
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "type":
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				typ := model.ParseOpType(val[0])
+				if !typ.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid operation type '%s'", val[0]), nil))
+				}
+				q = q.And("type", mode, typ)
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				typs := make([]uint8, 0)
+				for _, t := range strings.Split(val[0], ",") {
+					typ := model.ParseOpType(t)
+					if !typ.IsValid() {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid operation type '%s'", t), nil))
+					}
+					typs = append(typs, uint8(typ))
+				}
+				q = q.And("type", mode, typs)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+
+		case "entrypoint":
+			// handled below once the contract is known
+
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+		}
+	}
+
+	// entrypoints are ids or names when filtering a single contract
+	for key, val := range query {
+		keys := strings.Split(key, ".")
+		if keys[0] != "entrypoint" {
+			continue
+		}
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+		}
+		var eps micheline.Entrypoints
+		if contract != nil {
+			if pTyp, _, err := contract.LoadType(); err == nil {
+				eps, _ = pTyp.Entrypoints(false)
+			}
+		}
+		ids := make([]int, 0)
+		for _, v := range strings.Split(val[0], ",") {
+			if id, err := strconv.Atoi(v); err == nil {
+				ids = append(ids, id)
+				continue
+			}
+			ep, ok := eps[v]
+			if !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown entrypoint '%s'", v), nil))
+			}
+			ids = append(ids, ep.Id)
+		}
+		switch mode {
+		case pack.FilterModeEqual, pack.FilterModeNotEqual:
+			q = q.And("entrypoint_id", mode, ids[0])
+		case pack.FilterModeIn, pack.FilterModeNotIn:
+			q = q.And("entrypoint_id", mode, ids)
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, keys[0]), nil))
+		}
+	}
+
+	return q
+}
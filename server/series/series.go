@@ -243,6 +243,8 @@ type SeriesBucket interface {
 
 func init() {
 	server.Register(SeriesRequest{})
+	server.Describe("GET", "/series/{series}", server.ApiDoc{Id: "GetSeries", Summary: "Time series", Args: SeriesRequest{}, Result: []json.RawMessage{}})
+	server.Describe("GET", "/series/{series}.{format}", server.ApiDoc{Id: "GetSeriesFormat", Summary: "Time series as JSON or CSV", Args: SeriesRequest{}, Result: []json.RawMessage{}})
 }
 
 var _ server.RESTful = (*SeriesRequest)(nil)
@@ -299,6 +301,9 @@ func (t SeriesRequest) RegisterRoutes(r *mux.Router) error {
 }
 
 func (r *SeriesRequest) Parse(ctx *server.Context) {
+
+	// resolve `@name` cohort references in filter values
+	ctx.ExpandCohorts()
 	// prevent duplicate columns
 	if len(r.Columns) > 0 {
 		seen := make(map[string]struct{})
@@ -394,6 +399,21 @@ func StreamSeries(ctx *server.Context) (interface{}, int) {
 	case "supply":
 		args.bucket = &SupplySeries{}
 		args.model = &model.Supply{}
+	case "ballot":
+		args.bucket = &BallotSeries{}
+		args.model = &BallotModel{}
+	case "contract_calls":
+		args.bucket = &ContractCallSeries{}
+		args.model = &model.ContractCallStats{}
+	case "failure":
+		args.bucket = &FailureSeries{}
+		args.model = &model.OpError{}
+	case "event":
+		args.bucket = &EventSeries{}
+		args.model = &EventModel{
+			ctx: ctx.Context,
+			idx: ctx.Indexer,
+		}
 	case "balance":
 		args.FillMode = FillModeLast
 		args.bucket = &BalanceSeries{}
@@ -16,6 +16,8 @@ import (
 
 	logpkg "github.com/echa/log"
 	"github.com/gorilla/mux"
+
+	"blockwatch.cc/tzindex/etl/cache"
 )
 
 type RestServer struct {
@@ -23,6 +25,8 @@ type RestServer struct {
 	srv        *http.Server
 	dispatcher *Dispatcher
 	cfg        *Config
+	active     atomic.Value // *Config, replaced on reload
+	cache      *ResponseCache
 	shutdown   atomic.Value
 	offline    atomic.Value
 }
@@ -45,7 +49,8 @@ func New(cfg *Config) (*RestServer, error) {
 
 	debugHttp = log.Level() == logpkg.LevelTrace
 
-	// setup router
+	// setup router, skip routes of disabled indexes
+	routeIndexer = cfg.Indexer
 	r := NewRouter()
 	r.NotFoundHandler = http.HandlerFunc(C(NotFound))
 	http.Handle("/", r)
@@ -73,9 +78,49 @@ func New(cfg *Config) (*RestServer, error) {
 	}
 	srv.shutdown.Store(false)
 	srv.offline.Store(false)
+	srv.active.Store(cfg)
+
+	// setup response cache and invalidate entries on block updates
+	if sz := cfg.Http.ResponseCacheSize; sz > 0 && cfg.Indexer != nil {
+		srv.cache = NewResponseCache(int64(sz) << 20)
+		cfg.Indexer.AddBlockListener(srv.cache.OnBlock)
+	}
 	return srv, nil
 }
 
+// PurgeResponseCache drops all cached responses. Handlers that change data
+// outside of block processing must call it after a successful update.
+func (s *RestServer) PurgeResponseCache() {
+	if s != nil && s.cache != nil {
+		s.cache.Purge()
+	}
+}
+
+func (s *RestServer) ResponseCacheStats() (cache.Stats, bool) {
+	if s == nil || s.cache == nil {
+		return cache.Stats{}, false
+	}
+	return s.cache.Stats(), true
+}
+
+// Config returns the currently active server configuration.
+func (s *RestServer) Config() *Config {
+	return s.active.Load().(*Config)
+}
+
+// Reconfigure applies HTTP and runtime settings that can change at runtime.
+// HTTP settings that require a restart keep their current value.
+func (s *RestServer) Reconfigure(hc HttpConfig, rc RuntimeConfig) error {
+	if err := hc.Check(); err != nil {
+		return err
+	}
+	next := *s.Config()
+	next.Http.Update(hc)
+	next.Runtime = rc
+	s.active.Store(&next)
+	return nil
+}
+
 func (s *RestServer) IsShutdown() bool {
 	return s.shutdown.Load().(bool)
 }
@@ -106,7 +151,7 @@ func (s *RestServer) Start() {
 func (s *RestServer) Stop() {
 	log.Info("Stopping HTTP server.")
 	s.shutdown.Store(true)
-	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Http.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config().Http.ShutdownTimeout)
 	defer cancel()
 	if err := s.srv.Shutdown(ctx); err != nil {
 		log.Error(err)
@@ -138,7 +183,7 @@ func wrapper(f ApiCall) func(http.ResponseWriter, *http.Request) {
 		)
 
 		// use configured request timeout as default
-		timeout := srv.cfg.Http.WriteTimeout
+		timeout := srv.Config().Http.WriteTimeout
 
 		// skip timeout on internal routes /debug and /system
 		if strings.HasPrefix(r.URL.Path, "/") {
@@ -157,6 +202,21 @@ func wrapper(f ApiCall) func(http.ResponseWriter, *http.Request) {
 
 		api := NewContext(ctx, r, w, f, srv)
 
+		// serve from response cache or record the response for caching
+		if c := srv.cache; c != nil && isCacheableRequest(r) {
+			key := cacheKey(r)
+			if e, ok := c.Get(key, api.Now); ok {
+				api.sendCachedResponse(e)
+				return
+			}
+			api.cacheKey = key
+			// read generation before tip, listeners run after tip updates
+			api.cacheGen = c.Generation()
+			api.Tip = srv.cfg.Crawler.Tip()
+			api.recorder = &cacheRecorder{ResponseWriter: w, max: int(c.max / 8)}
+			api.ResponseWriter = api.recorder
+		}
+
 		// schedule call processing, will return 429 on full queue
 		select {
 		case jobQueue <- api:
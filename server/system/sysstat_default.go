@@ -13,7 +13,7 @@ import (
 	"time"
 )
 
-func GetSysStat(ctx context.Context) (SysStat, error) {
+func GetSysStat(ctx context.Context, dbpath string) (SysStat, error) {
 	s := SysStat{
 		Timestamp: time.Now().UTC(),
 	}
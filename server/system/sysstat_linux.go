@@ -15,13 +15,12 @@ import (
 	"time"
 
 	"blockwatch.cc/packdb/util"
-	"github.com/echa/config"
 	"github.com/echa/goprocinfo/linux"
 )
 
 // all sizes in bytes
 // https://man7.org/linux/man-pages/man5/proc.5.html
-func GetSysStat(ctx context.Context) (SysStat, error) {
+func GetSysStat(ctx context.Context, dbpath string) (SysStat, error) {
 	s := SysStat{
 		Timestamp: time.Now().UTC(),
 	}
@@ -70,7 +69,7 @@ func GetSysStat(ctx context.Context) (SysStat, error) {
 	s.CpuTotal = s.CpuUser + s.CpuSys
 
 	// Disk
-	pDisk, _ := linux.ReadDisk(config.GetString("database.path"))
+	pDisk, _ := linux.ReadDisk(dbpath)
 	s.DiskSize = pDisk.All
 	s.DiskUsed = pDisk.Used
 	s.DiskFree = pDisk.Free
@@ -15,14 +15,38 @@ import (
 	"blockwatch.cc/tzindex/server"
 	"blockwatch.cc/tzindex/server/explorer"
 
-	"github.com/echa/config"
 	logpkg "github.com/echa/log"
 )
 
 var LoggerMap map[string]logpkg.Logger
 
+// ConfigReloader re-reads the config file and applies runtime settings. It
+// is set by the main program.
+var ConfigReloader func() (*ConfigReport, error)
+
+// ConfigReport lists changed config keys after a reload.
+type ConfigReport struct {
+	Applied []string `json:"applied"`
+	Restart []string `json:"restart_required"`
+}
+
 func init() {
 	server.Register(SystemRequest{})
+
+	get := func(path string, doc server.ApiDoc) { server.Describe("GET", path, doc) }
+	put := func(path string, doc server.ApiDoc) { server.Describe("PUT", path, doc) }
+	get("/system/config", server.ApiDoc{Id: "GetSystemConfig", Summary: "Runtime configuration", Result: map[string]interface{}{}})
+	get("/system/tables", server.ApiDoc{Id: "ListTableStats", Summary: "Table statistics", Result: []pack.TableStats{}})
+	get("/system/caches", server.ApiDoc{Id: "ListCacheStats", Summary: "Cache statistics", Result: map[string]interface{}{}})
+	get("/system/sysstat", server.ApiDoc{Id: "GetSysStats", Summary: "Host system statistics", Result: SysStat{}})
+	put("/system/tables/snapshot", server.ApiDoc{Id: "SnapshotTables", Summary: "Snapshot databases", Status: 204})
+	put("/system/tables/flush", server.ApiDoc{Id: "FlushTables", Summary: "Flush tables to disk", Status: 204})
+	put("/system/tables/flush_journal", server.ApiDoc{Id: "FlushJournals", Summary: "Flush table journals to disk", Status: 204})
+	put("/system/tables/gc", server.ApiDoc{Id: "GcTables", Summary: "Garbage collect databases", Status: 204})
+	put("/system/tables/dump/{table}/{part}", server.ApiDoc{Id: "DumpTable", Summary: "Dump table part to snapshot path", Status: 204})
+	put("/system/caches/purge", server.ApiDoc{Id: "PurgeCaches", Summary: "Purge caches", Status: 204})
+	put("/system/log/{subsystem}/{level}", server.ApiDoc{Id: "SetLogLevel", Summary: "Set log level of subsystem", Status: 204})
+	put("/system/config/reload", server.ApiDoc{Id: "ReloadConfig", Summary: "Reload config file", Result: ConfigReport{}})
 }
 
 var _ server.RESTful = (*SystemRequest)(nil)
@@ -65,6 +89,7 @@ func (t SystemRequest) RegisterRoutes(r *mux.Router) error {
 	r.HandleFunc("/tables/dump/{table}/{part}", server.C(DumpTable)).Methods("PUT")
 	r.HandleFunc("/caches/purge", server.C(PurgeCaches)).Methods("PUT")
 	r.HandleFunc("/log/{subsystem}/{level}", server.C(UpdateLog)).Methods("PUT")
+	r.HandleFunc("/config/reload", server.C(ReloadConfig)).Methods("PUT")
 	return nil
 }
 
@@ -77,11 +102,14 @@ func GetCacheStats(ctx *server.Context) (interface{}, int) {
 	for n, v := range ctx.Indexer.CacheStats() {
 		cs[n] = v
 	}
+	if s, ok := ctx.Server.ResponseCacheStats(); ok {
+		cs["responses"] = s
+	}
 	return cs, http.StatusOK
 }
 
 func GetSysStats(ctx *server.Context) (interface{}, int) {
-	s, err := GetSysStat(ctx.Context)
+	s, err := GetSysStat(ctx.Context, ctx.Cfg.Runtime.DatabasePath)
 	if err != nil {
 		panic(server.EInternal(server.EC_SERVER, "sysstat failed", err))
 	}
@@ -89,12 +117,24 @@ func GetSysStats(ctx *server.Context) (interface{}, int) {
 }
 
 func GetConfig(ctx *server.Context) (interface{}, int) {
-	return config.All(), http.StatusOK
+	return ctx.Cfg.Runtime.Values, http.StatusOK
+}
+
+func ReloadConfig(ctx *server.Context) (interface{}, int) {
+	if ConfigReloader == nil {
+		panic(server.ENotImplemented(server.EC_SERVER, "config reload not supported", nil))
+	}
+	report, err := ConfigReloader()
+	if err != nil {
+		panic(server.EInternal(server.EC_SERVER, "config reload failed", err))
+	}
+	return report, http.StatusOK
 }
 
 func PurgeCaches(ctx *server.Context) (interface{}, int) {
 	explorer.PurgeCaches()
 	ctx.Indexer.PurgeCaches()
+	ctx.Server.PurgeResponseCache()
 	return nil, http.StatusNoContent
 }
 
@@ -120,7 +160,7 @@ func FlushJournals(ctx *server.Context) (interface{}, int) {
 }
 
 func GcDatabases(ctx *server.Context) (interface{}, int) {
-	if err := ctx.Indexer.GC(ctx.Context, config.GetFloat64("database.gc_ratio")); err != nil {
+	if err := ctx.Indexer.GC(ctx.Context, ctx.Cfg.Runtime.DatabaseGCRatio); err != nil {
 		panic(server.EInternal(server.EC_DATABASE, "gc failed", err))
 	}
 	return nil, http.StatusNoContent
@@ -165,7 +205,7 @@ func DumpTable(ctx *server.Context) (interface{}, int) {
 		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, "no such table", nil))
 	}
 
-	spath := config.GetString("crawler.snapshot_path")
+	spath := ctx.Cfg.Runtime.SnapshotPath
 	if spath == "" {
 		panic(server.EForbidden(server.EC_ACCESS_READONLY, "snapshots and dumps disabled, set crawler.snapshot_path to enable", nil))
 	}
@@ -0,0 +1,653 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"blockwatch.cc/packdb/encoding/csv"
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/server"
+)
+
+// query arguments that control aggregation and must not reach table filters
+var aggregateArgs = []string{"group_by", "sum", "distinct", "count", "sort"}
+
+func (t *TableRequest) IsAggregate() bool {
+	return len(t.GroupBy) > 0 || len(t.Sum) > 0 || len(t.Distinct) > 0 || t.Count
+}
+
+func (t *TableRequest) parseAggregate() {
+	if len(t.Columns) > 0 {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "columns cannot be combined with aggregation", nil))
+	}
+	if len(t.Cursor) > 0 {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "cursor cannot be combined with aggregation", nil))
+	}
+	if len(t.GroupBy) > 0 && len(t.Sum) == 0 && len(t.Distinct) == 0 {
+		// count rows per group when no other aggregate is requested
+		t.Count = true
+	}
+	seen := make(map[string]struct{})
+	for _, v := range t.aggregateColumns() {
+		if _, ok := seen[v]; ok {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("duplicate aggregate column %s", v), nil))
+		}
+		seen[v] = struct{}{}
+	}
+	if t.Sort != "" {
+		if _, ok := seen[t.Sort]; !ok {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid sort column '%s'", t.Sort), nil))
+		}
+	}
+}
+
+// aggregateColumns returns the names of result columns in output order.
+func (t *TableRequest) aggregateColumns() []string {
+	cols := make([]string, 0, len(t.GroupBy)+len(t.Sum)+len(t.Distinct)+1)
+	cols = append(cols, t.GroupBy...)
+	if t.Count {
+		cols = append(cols, "count")
+	}
+	for _, v := range t.Sum {
+		cols = append(cols, "sum_"+v)
+	}
+	for _, v := range t.Distinct {
+		cols = append(cols, "distinct_"+v)
+	}
+	return cols
+}
+
+// sourceColumns returns table columns that must be read to aggregate.
+func (t *TableRequest) sourceColumns() util.StringList {
+	var cols util.StringList
+	for _, v := range t.GroupBy {
+		cols.AddUnique(v)
+	}
+	for _, v := range t.Sum {
+		cols.AddUnique(v)
+	}
+	for _, v := range t.Distinct {
+		cols.AddUnique(v)
+	}
+	if len(cols) == 0 {
+		// count only
+		cols.AddUnique("row_id")
+	}
+	return cols
+}
+
+// aggregate holds the state of a single group.
+type aggregate struct {
+	keys     []interface{}
+	count    int64
+	sums     []aggregateSum
+	distinct []map[string]struct{}
+}
+
+type aggregateSum struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+func (s *aggregateSum) Add(v interface{}) bool {
+	switch n := v.(type) {
+	case nil:
+		return true
+	case int64:
+		if s.isInt {
+			s.i += n
+		} else {
+			s.f += float64(n)
+		}
+		return true
+	case uint64:
+		if s.isInt && n <= math.MaxInt64 {
+			s.i += int64(n)
+			return true
+		}
+		s.promote()
+		s.f += float64(n)
+		return true
+	case float64:
+		s.promote()
+		s.f += n
+		return true
+	case bool:
+		if n {
+			if s.isInt {
+				s.i++
+			} else {
+				s.f++
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// promote switches an integer sum to floating point.
+func (s *aggregateSum) promote() {
+	if s.isInt {
+		s.isInt = false
+		s.f = float64(s.i)
+	}
+}
+
+func (s aggregateSum) Value() interface{} {
+	if s.isInt {
+		return s.i
+	}
+	return s.f
+}
+
+// Aggregator groups table rows and computes counts, sums and distinct
+// value counts per group. Table handlers feed decoded rows through the CSV
+// encoder interface. Group and distinct values are taken from the row's CSV
+// cells, so they match what the table API returns (addresses, type names).
+// Sums read the raw struct field with the column's JSON name and add up
+// integers exactly. Tez amounts are summed in mutez and converted once when
+// results are built. Columns without a struct field fall back to parsing
+// their CSV cell. Groups and distinct values are bounded by maxCells,
+// scanned rows by maxRows.
+type Aggregator struct {
+	req      *TableRequest
+	params   *tezos.Params
+	colIdx   map[string]int // source column positions in decoded rows
+	groups   map[string]*aggregate
+	list     []*aggregate
+	cells    int
+	rows     int
+	maxCells int
+	maxRows  int
+	keybuf   bytes.Buffer
+	row      []interface{}
+	sums     []interface{}
+	amount   []int8 // per sum column: 1 tez amount, -1 other, 0 unknown
+	typ      reflect.Type
+	fields   [][]int // per sum column: struct field index or nil
+}
+
+func NewAggregator(ctx *server.Context, req *TableRequest, src []string) *Aggregator {
+	a := &Aggregator{
+		req:      req,
+		params:   ctx.Params,
+		colIdx:   make(map[string]int, len(src)),
+		groups:   make(map[string]*aggregate),
+		maxCells: ctx.Cfg.Http.MaxAggregateGroups,
+		maxRows:  ctx.Cfg.Http.MaxAggregateRows,
+		sums:     make([]interface{}, len(req.Sum)),
+		amount:   make([]int8, len(req.Sum)),
+	}
+	for i, v := range src {
+		a.colIdx[v] = i
+	}
+	return a
+}
+
+// EncodeHeader implements the table CSV encoder interface. Aggregate
+// source columns are fixed when the aggregator is created.
+func (a *Aggregator) EncodeHeader([]string, interface{}) error {
+	return nil
+}
+
+// EncodeRecord implements the table CSV encoder interface. It renders the
+// row to CSV cells for group and distinct values and reads summed columns
+// from the row's struct fields.
+func (a *Aggregator) EncodeRecord(v interface{}) error {
+	m, ok := v.(csv.Marshaler)
+	if !ok {
+		return server.EInternal(server.EC_SERVER, fmt.Sprintf("cannot aggregate %T rows", v), nil)
+	}
+	cells, err := m.MarshalCSV()
+	if err != nil {
+		return err
+	}
+	if cap(a.row) < len(cells) {
+		a.row = make([]interface{}, len(cells))
+	}
+	a.row = a.row[:len(cells)]
+	for i, c := range cells {
+		a.row[i] = parseAggregateValue(c)
+	}
+	if len(a.req.Sum) == 0 {
+		return a.Add(a.row, nil)
+	}
+
+	rv := reflect.ValueOf(v)
+	if a.typ != rv.Type() {
+		a.typ = rv.Type()
+		a.fields = make([][]int, len(a.req.Sum))
+		for i, col := range a.req.Sum {
+			a.fields[i] = aggregateField(a.typ, col)
+		}
+	}
+	for i, col := range a.req.Sum {
+		pos, ok := a.colIdx[col]
+		if !ok || pos >= len(cells) {
+			return server.EInternal(server.EC_SERVER, "short table row", nil)
+		}
+		a.sums[i] = a.row[pos]
+		if a.fields[i] == nil {
+			continue
+		}
+		raw, ok := aggregateRawValue(rv, a.fields[i])
+		if !ok {
+			continue
+		}
+		a.sums[i] = raw
+		if a.amount[i] == 0 {
+			a.amount[i] = a.classify(raw, cells[pos])
+		}
+	}
+	return a.Add(a.row, a.sums)
+}
+
+// classify detects tez amount columns from the first row: integer fields
+// the table renders as converted decimal values are amounts in mutez.
+func (a *Aggregator) classify(raw interface{}, cell string) int8 {
+	n, ok := raw.(int64)
+	if !ok || a.params == nil {
+		return -1
+	}
+	if cell == strconv.FormatFloat(a.params.ConvertValue(n), 'f', a.params.Decimals, 64) {
+		return 1
+	}
+	return -1
+}
+
+// aggregateField returns the index of the struct field named col in JSON,
+// searching embedded structs. It returns nil when typ has no such field.
+func aggregateField(typ reflect.Type, col string) []int {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous {
+			if idx := aggregateField(f.Type, col); idx != nil {
+				return append([]int{i}, idx...)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == col {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+// aggregateRawValue reads a numeric or boolean field as int64, uint64,
+// float64 or bool.
+func aggregateRawValue(v reflect.Value, idx []int) (interface{}, bool) {
+	for _, i := range idx {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Bool:
+		return v.Bool(), true
+	default:
+		return nil, false
+	}
+}
+
+// parseAggregateValue converts a CSV table cell into a typed value.
+// Quoted cells are strings, empty cells are null.
+func parseAggregateValue(s string) interface{} {
+	switch {
+	case s == "":
+		return nil
+	case s[0] == '"':
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+		return s
+	case s == "true":
+		return true
+	case s == "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return u
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+// Add aggregates a row of source column values. Summed values are read from
+// sums when set, otherwise from row.
+func (a *Aggregator) Add(row, sums []interface{}) error {
+	a.rows++
+	if a.maxRows > 0 && a.rows > a.maxRows {
+		return server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("aggregation exceeds %d rows, please narrow filters", a.maxRows), nil)
+	}
+	if len(row) < len(a.colIdx) {
+		return server.EInternal(server.EC_SERVER, "short table row", nil)
+	}
+
+	// identify group
+	a.keybuf.Reset()
+	for _, v := range a.req.GroupBy {
+		fmt.Fprint(&a.keybuf, row[a.colIdx[v]])
+		a.keybuf.WriteByte(0)
+	}
+	g, ok := a.groups[a.keybuf.String()]
+	if !ok {
+		if err := a.grow(); err != nil {
+			return err
+		}
+		g = &aggregate{
+			keys:     make([]interface{}, len(a.req.GroupBy)),
+			sums:     make([]aggregateSum, len(a.req.Sum)),
+			distinct: make([]map[string]struct{}, len(a.req.Distinct)),
+		}
+		for i, v := range a.req.GroupBy {
+			g.keys[i] = row[a.colIdx[v]]
+		}
+		for i := range g.sums {
+			g.sums[i].isInt = true
+		}
+		for i := range g.distinct {
+			g.distinct[i] = make(map[string]struct{})
+		}
+		a.groups[a.keybuf.String()] = g
+		a.list = append(a.list, g)
+	}
+
+	// aggregate values
+	g.count++
+	for i, v := range a.req.Sum {
+		val := row[a.colIdx[v]]
+		if sums != nil {
+			val = sums[i]
+		}
+		if !g.sums[i].Add(val) {
+			return server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("cannot sum non-numeric column '%s'", v), nil)
+		}
+	}
+	for i, v := range a.req.Distinct {
+		key := fmt.Sprint(row[a.colIdx[v]])
+		if _, ok := g.distinct[i][key]; !ok {
+			if err := a.grow(); err != nil {
+				return err
+			}
+			g.distinct[i][key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (a *Aggregator) grow() error {
+	a.cells++
+	if a.maxCells > 0 && a.cells > a.maxCells {
+		return server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("aggregation exceeds %d groups or distinct values, please narrow filters", a.maxCells), nil)
+	}
+	return nil
+}
+
+// Rows returns aggregated results in output column order.
+func (a *Aggregator) Rows() [][]interface{} {
+	rows := make([][]interface{}, len(a.list))
+	for i, g := range a.list {
+		row := make([]interface{}, 0, len(g.keys)+1+len(g.sums)+len(g.distinct))
+		row = append(row, g.keys...)
+		if a.req.Count {
+			row = append(row, g.count)
+		}
+		for k, v := range g.sums {
+			if a.amount[k] == 1 && v.isInt {
+				// convert mutez sums once
+				row = append(row, a.params.ConvertValue(v.i))
+				continue
+			}
+			row = append(row, v.Value())
+		}
+		for _, v := range g.distinct {
+			row = append(row, len(v))
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// compareAggregateValues orders numbers numerically and everything else by
+// string representation. Nulls sort first.
+func compareAggregateValues(x, y interface{}) int {
+	switch {
+	case x == nil && y == nil:
+		return 0
+	case x == nil:
+		return -1
+	case y == nil:
+		return 1
+	}
+	fx, okx := aggregateFloat(x)
+	fy, oky := aggregateFloat(y)
+	if okx && oky {
+		switch {
+		case fx < fy:
+			return -1
+		case fx > fy:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(x), fmt.Sprint(y))
+}
+
+func aggregateFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// AggregateRow is a single aggregated result row.
+type AggregateRow struct {
+	columns []string
+	values  []interface{}
+	verbose bool
+}
+
+func (r AggregateRow) MarshalJSON() ([]byte, error) {
+	if !r.verbose {
+		return json.Marshal(r.values)
+	}
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	for i, v := range r.columns {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, v)
+		buf = append(buf, ':')
+		val, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, val...)
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+func (r AggregateRow) MarshalCSV() ([]string, error) {
+	res := make([]string, len(r.values))
+	for i, v := range r.values {
+		switch val := v.(type) {
+		case nil:
+			res[i] = ""
+		case string:
+			res[i] = strconv.Quote(val)
+		case float64:
+			res[i] = strconv.FormatFloat(val, 'f', -1, 64)
+		default:
+			res[i] = fmt.Sprint(val)
+		}
+	}
+	return res, nil
+}
+
+// StreamAggregate runs the regular table handler with aggregate source
+// columns, aggregates its rows in memory and streams sorted results using
+// the requested format.
+func StreamAggregate(ctx *server.Context, args *TableRequest) (interface{}, int) {
+	src := args.sourceColumns()
+	agg := NewAggregator(ctx, args, src)
+
+	// hide aggregation arguments from table filters
+	q := ctx.Request.URL.Query()
+	for _, v := range aggregateArgs {
+		delete(q, v)
+	}
+	ctx.Request.URL.RawQuery = q.Encode()
+
+	// the table handler sends rows to the aggregator instead of encoding
+	// them, its response headers and trailer are discarded
+	inner := *args
+	inner.Columns = src
+	inner.Format = "csv"
+	inner.Verbose = false
+	inner.Limit = 0
+	inner.Order = pack.OrderAsc
+	inner.GroupBy = nil
+	inner.Sum = nil
+	inner.Distinct = nil
+	inner.Count = false
+	inner.agg = agg
+	w := &discardWriter{header: make(http.Header)}
+	err := ctx.CaptureStream(w, func() {
+		streamTable(ctx, &inner)
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// sort and limit results
+	cols := args.aggregateColumns()
+	rows := agg.Rows()
+	sortIdx := -1
+	for i, v := range cols {
+		if v == args.Sort {
+			sortIdx = i
+			break
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		var c int
+		if sortIdx >= 0 {
+			c = compareAggregateValues(rows[i][sortIdx], rows[j][sortIdx])
+		} else {
+			for k := range args.GroupBy {
+				if c = compareAggregateValues(rows[i][k], rows[j][k]); c != 0 {
+					break
+				}
+			}
+		}
+		if args.Order == pack.OrderDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+	if args.Limit > 0 && len(rows) > int(args.Limit) {
+		rows = rows[:args.Limit]
+	}
+
+	// prepare response stream
+	ctx.StreamResponseHeaders(http.StatusOK, mimetypes[args.Format])
+
+	var count int
+	switch args.Format {
+	case "json":
+		enc := json.NewEncoder(ctx.ResponseWriter)
+		enc.SetIndent("", "")
+		enc.SetEscapeHTML(false)
+
+		// open JSON array
+		_, _ = io.WriteString(ctx.ResponseWriter, "[")
+		for i, v := range rows {
+			if i > 0 {
+				_, _ = io.WriteString(ctx.ResponseWriter, ",")
+			}
+			if err = enc.Encode(AggregateRow{cols, v, args.Verbose}); err != nil {
+				break
+			}
+			count++
+		}
+		// close JSON bracket
+		_, _ = io.WriteString(ctx.ResponseWriter, "]")
+
+	case "csv":
+		enc := csv.NewEncoder(ctx.ResponseWriter)
+		err = enc.EncodeHeader(cols, nil)
+		if err == nil {
+			for _, v := range rows {
+				if err = enc.EncodeRecord(AggregateRow{cols, v, false}); err != nil {
+					break
+				}
+				count++
+			}
+		}
+	}
+
+	// write error (except EOF) and count as http trailer
+	ctx.StreamTrailer("", count, err)
+
+	// streaming return
+	return nil, -1
+}
+
+// discardWriter drops a streamed response.
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) WriteHeader(int)             {}
+func (d *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
@@ -0,0 +1,191 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/server"
+)
+
+func TestParseAggregateValue(t *testing.T) {
+	tests := []struct {
+		cell string
+		want interface{}
+	}{
+		{"", nil},
+		{`"tz1abc"`, "tz1abc"},
+		{`"12"`, "12"},
+		{"true", true},
+		{"false", false},
+		{"-12", int64(-12)},
+		{"18446744073709551615", uint64(18446744073709551615)},
+		{"1.500000", 1.5},
+		{"abc", "abc"},
+	}
+	for _, test := range tests {
+		if got := parseAggregateValue(test.cell); got != test.want {
+			t.Errorf("%q: got %#v, want %#v", test.cell, got, test.want)
+		}
+	}
+}
+
+func TestAggregateSum(t *testing.T) {
+	s := aggregateSum{isInt: true}
+	for _, v := range []interface{}{int64(2), uint64(3), true, false, nil} {
+		if !s.Add(v) {
+			t.Fatalf("cannot add %#v", v)
+		}
+	}
+	if s.Value() != int64(6) {
+		t.Errorf("integer sum = %#v, want 6", s.Value())
+	}
+	s.Add(0.5)
+	s.Add(int64(1))
+	if s.Value() != 7.5 {
+		t.Errorf("promoted sum = %#v, want 7.5", s.Value())
+	}
+	if s.Add("x") {
+		t.Errorf("added string value")
+	}
+}
+
+// testAggregateRow is a table row model with fixed CSV cells.
+type testAggregateRow []string
+
+func (r testAggregateRow) MarshalCSV() ([]string, error) {
+	return r, nil
+}
+
+func testAggregator(req *TableRequest, maxCells, maxRows int) *Aggregator {
+	ctx := &server.Context{
+		Cfg: &server.Config{Http: server.HttpConfig{
+			MaxAggregateGroups: maxCells,
+			MaxAggregateRows:   maxRows,
+		}},
+		Params: tezos.NewParams(),
+	}
+	return NewAggregator(ctx, req, req.sourceColumns())
+}
+
+func TestAggregator(t *testing.T) {
+	req := &TableRequest{
+		GroupBy:  []string{"sender"},
+		Sum:      []string{"volume"},
+		Distinct: []string{"receiver"},
+		Count:    true,
+	}
+	agg := testAggregator(req, 0, 0)
+	for _, row := range []testAggregateRow{
+		{`"a"`, "1.000000", `"x"`},
+		{`"b"`, "2.000000", `"x"`},
+		{`"a"`, "0.500000", `"y"`},
+		{`"a"`, "", `"x"`},
+	} {
+		if err := agg.EncodeRecord(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := [][]interface{}{
+		{"a", int64(3), 1.5, 2},
+		{"b", int64(1), 2.0, 1},
+	}
+	if got := agg.Rows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if err := agg.EncodeRecord(struct{}{}); err == nil {
+		t.Errorf("expected error for row without CSV marshaller")
+	}
+	if err := agg.EncodeRecord(testAggregateRow{`"c"`, `"text"`, `"x"`}); err == nil {
+		t.Errorf("expected error for non-numeric sum")
+	}
+}
+
+type testFlowModel struct {
+	Sender string `json:"sender"`
+	Amount int64  `json:"amount"`
+	Count  int64  `json:"count"`
+}
+
+type testFlowRow struct {
+	testFlowModel
+	params *tezos.Params
+}
+
+func (r testFlowRow) MarshalCSV() ([]string, error) {
+	return []string{
+		strconv.Quote(r.Sender),
+		strconv.FormatFloat(r.params.ConvertValue(r.Amount), 'f', r.params.Decimals, 64),
+		strconv.FormatInt(r.Count, 10),
+	}, nil
+}
+
+func TestAggregatorRawColumns(t *testing.T) {
+	req := &TableRequest{
+		GroupBy: []string{"sender"},
+		Sum:     []string{"amount", "count"},
+	}
+	agg := testAggregator(req, 0, 0)
+	p := tezos.NewParams()
+	for _, row := range []testFlowModel{
+		{"a", 100000, 1},
+		{"a", 200000, 2},
+		{"b", 1, 3},
+	} {
+		if err := agg.EncodeRecord(testFlowRow{row, p}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 0.1 + 0.2 tez sums to exactly 0.3 tez
+	want := [][]interface{}{
+		{"a", 0.3, int64(3)},
+		{"b", 0.000001, int64(3)},
+	}
+	if got := agg.Rows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestAggregatorLimits(t *testing.T) {
+	req := &TableRequest{GroupBy: []string{"sender"}, Count: true}
+	agg := testAggregator(req, 2, 0)
+	for _, v := range []string{`"a"`, `"b"`, `"a"`} {
+		if err := agg.EncodeRecord(testAggregateRow{v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := agg.EncodeRecord(testAggregateRow{`"c"`}); err == nil {
+		t.Errorf("expected group limit error")
+	}
+
+	agg = testAggregator(req, 0, 2)
+	for i, v := range []string{`"a"`, `"a"`, `"a"`} {
+		err := agg.EncodeRecord(testAggregateRow{v})
+		if (i < 2) != (err == nil) {
+			t.Errorf("row %d: unexpected result %v", i, err)
+		}
+	}
+}
+
+func TestCompareAggregateValues(t *testing.T) {
+	tests := []struct {
+		x, y interface{}
+		want int
+	}{
+		{nil, nil, 0},
+		{nil, int64(1), -1},
+		{int64(2), 1.5, 1},
+		{uint64(3), int64(3), 0},
+		{int64(10), int64(9), 1},
+		{"10", "9", -1},
+	}
+	for _, test := range tests {
+		if got := compareAggregateValues(test.x, test.y); got != test.want {
+			t.Errorf("compare(%#v, %#v) = %d, want %d", test.x, test.y, got, test.want)
+		}
+	}
+}
@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 
-	"blockwatch.cc/packdb/encoding/csv"
 	"blockwatch.cc/packdb/pack"
 	"blockwatch.cc/packdb/util"
 	"blockwatch.cc/tzgo/tezos"
@@ -340,7 +339,7 @@ func StreamBalanceTable(ctx *server.Context, args *TableRequest) (interface{}, i
 		// ctx.Log.Tracef("JSON encoded %d rows", count)
 
 	case "csv":
-		enc := csv.NewEncoder(ctx.ResponseWriter)
+		enc := newCsvEncoder(ctx, args)
 		// use custom header columns and order
 		if len(args.Columns) > 0 {
 			err = enc.EncodeHeader(args.Columns, nil)
@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 
-	"blockwatch.cc/packdb/encoding/csv"
 	"blockwatch.cc/packdb/pack"
 	"blockwatch.cc/packdb/util"
 	"blockwatch.cc/tzgo/tezos"
@@ -43,12 +42,34 @@ func init() {
 	bigmapValueAllAliases = append(bigmapValueAllAliases, "time")
 }
 
+// isBigmapPathColumn returns true for decoded key/value path columns and
+// filters like `value.balance.gt`. Plain `key` and `value` with or without
+// filter mode refer to binary data.
+func isBigmapPathColumn(s string) bool {
+	if n := strings.LastIndexByte(s, '.'); n > 0 && pack.ParseFilterMode(s[n+1:]).IsValid() {
+		s = s[:n]
+	}
+	return model.IsBigmapPath(s) && strings.Contains(s, ".")
+}
+
 // configurable marshalling helper
 type BigmapValueItem struct {
 	model.BigmapKV
 	verbose bool            // cond. marshal
 	columns util.StringList // cond. cols & order when brief
+	paths   util.StringList // decoded key/value path columns
 	ctx     *server.Context
+	types   func(int64) *model.BigmapAlloc
+}
+
+// pathValue decodes a key/value path column, missing paths return nil.
+func (b *BigmapValueItem) pathValue(path string) interface{} {
+	alloc := b.types(b.BigmapId)
+	if alloc == nil {
+		return nil
+	}
+	val, _ := b.GetPath(path, alloc.GetKeyType(), alloc.GetValueType())
+	return val
 }
 
 func (b *BigmapValueItem) MarshalJSON() ([]byte, error) {
@@ -61,14 +82,15 @@ func (b *BigmapValueItem) MarshalJSON() ([]byte, error) {
 
 func (b *BigmapValueItem) MarshalJSONVerbose() ([]byte, error) {
 	bigmap := struct {
-		RowId    uint64    `json:"row_id"`
-		BigmapId int64     `json:"bigmap_id"`
-		Height   int64     `json:"height"`
-		KeyId    uint64    `json:"key_id"`
-		KeyHash  string    `json:"hash"`
-		Key      string    `json:"key"`
-		Value    string    `json:"value"`
-		Time     time.Time `json:"time"`
+		RowId    uint64                 `json:"row_id"`
+		BigmapId int64                  `json:"bigmap_id"`
+		Height   int64                  `json:"height"`
+		KeyId    uint64                 `json:"key_id"`
+		KeyHash  string                 `json:"hash"`
+		Key      string                 `json:"key"`
+		Value    string                 `json:"value"`
+		Time     time.Time              `json:"time"`
+		Fields   map[string]interface{} `json:"fields,omitempty"`
 	}{
 		RowId:    b.RowId,
 		BigmapId: b.BigmapId,
@@ -79,6 +101,12 @@ func (b *BigmapValueItem) MarshalJSONVerbose() ([]byte, error) {
 		Value:    hex.EncodeToString(b.Value),
 		Time:     b.ctx.Indexer.LookupBlockTime(b.ctx.Context, b.Height),
 	}
+	if len(b.paths) > 0 {
+		bigmap.Fields = make(map[string]interface{}, len(b.paths))
+		for _, v := range b.paths {
+			bigmap.Fields[v] = b.pathValue(v)
+		}
+	}
 	return json.Marshal(bigmap)
 }
 
@@ -104,7 +132,14 @@ func (b *BigmapValueItem) MarshalJSONBrief() ([]byte, error) {
 		case "time":
 			buf = strconv.AppendInt(buf, b.ctx.Indexer.LookupBlockTimeMs(b.ctx.Context, b.Height), 10)
 		default:
-			continue
+			if !b.paths.Contains(v) {
+				continue
+			}
+			val, err := json.Marshal(b.pathValue(v))
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, val...)
 		}
 		if i < len(b.columns)-1 {
 			buf = append(buf, ',')
@@ -135,7 +170,12 @@ func (b *BigmapValueItem) MarshalCSV() ([]string, error) {
 		case "time":
 			res[i] = strconv.FormatInt(b.ctx.Indexer.LookupBlockTimeMs(b.ctx.Context, b.Height), 10)
 		default:
-			continue
+			if !b.paths.Contains(v) {
+				continue
+			}
+			if val := b.pathValue(v); val != nil {
+				res[i] = strconv.Quote(fmt.Sprint(val))
+			}
 		}
 	}
 	return res, nil
@@ -150,19 +190,29 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 
 	// translate long column names to short names used in pack tables
 	var (
-		srcNames     []string
+		srcNames     util.StringList
+		paths        util.StringList
+		filters      model.BigmapPathFilterList
 		needBigmapId bool
 	)
 	if len(args.Columns) > 0 {
 		// resolve short column names
 		srcNames = make([]string, 0, len(args.Columns))
 		for _, v := range args.Columns {
+			// decoded key/value paths require type info and binary data
+			if isBigmapPathColumn(v) {
+				paths.AddUnique(v)
+				for _, n := range []string{"B", "k", "v"} {
+					srcNames.AddUnique(n)
+				}
+				continue
+			}
 			n, ok := bigmapValueSourceNames[v]
 			if !ok {
 				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", v), nil))
 			}
 			if n != "-" {
-				srcNames = append(srcNames, n)
+				srcNames.AddUnique(n)
 			}
 		}
 	} else {
@@ -174,6 +224,15 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 	// prepare lookup caches
 	// accMap := make(map[model.AccountID]tezos.Address)
 	bigmapIds := make([]int64, 0)
+	allocMap := make(map[int64]*model.BigmapAlloc)
+	types := func(id int64) *model.BigmapAlloc {
+		alloc, ok := allocMap[id]
+		if !ok {
+			alloc, _ = ctx.Indexer.LookupBigmapType(ctx, id)
+			allocMap[id] = alloc
+		}
+		return alloc
+	}
 
 	// pre-parse bigmap ids required for hash lookups
 	// this only works for EQ/IN, panic on other conditions
@@ -220,11 +279,34 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 		}
 	}
 
+	// decoded key/value filters are matched while streaming
+	for key, val := range ctx.Request.URL.Query() {
+		if !isBigmapPathColumn(key) {
+			continue
+		}
+		for _, v := range val {
+			f, err := model.ParseBigmapPathFilter(key, v)
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+			}
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) > 0 && len(srcNames) > 0 {
+		for _, n := range []string{"B", "k", "v"} {
+			srcNames.AddUnique(n)
+		}
+	}
+
 	// build table query
+	limit := int(args.Limit)
+	if len(filters) > 0 {
+		limit = 0
+	}
 	q := pack.NewQuery(ctx.RequestID).
 		WithTable(table).
 		WithFields(srcNames...).
-		WithLimit(int(args.Limit)).
+		WithLimit(limit).
 		WithOrder(args.Order)
 
 	// build dynamic filter conditions from query (will panic on error)
@@ -239,6 +321,9 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
 			}
 		}
+		if isBigmapPathColumn(key) {
+			continue
+		}
 		switch prefix {
 		case "columns", "limit", "order", "verbose", "filename":
 			// skip these fields
@@ -372,6 +457,21 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 		}
 	}
 
+	// on a single bigmap, narrow down candidate keys using stored columns
+	if len(filters) > 0 {
+		if val := ctx.Request.URL.Query().Get("bigmap_id"); val != "" {
+			if id, err := strconv.ParseInt(val, 10, 64); err == nil {
+				keys, ok, err := ctx.Indexer.FindBigmapFieldKeys(ctx, id, filters)
+				if err != nil {
+					panic(server.EInternal(server.EC_DATABASE, "bigmap column lookup failed", err))
+				}
+				if ok {
+					q = q.AndIn("key_id", keys)
+				}
+			}
+		}
+	}
+
 	var (
 		count  int
 		lastId uint64
@@ -387,7 +487,18 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 	bigmap := &BigmapValueItem{
 		verbose: args.Verbose,
 		columns: args.Columns,
+		paths:   paths,
 		ctx:     ctx,
+		types:   types,
+	}
+
+	// match decoded fields on bigmaps with known type
+	match := func() bool {
+		if len(filters) == 0 {
+			return true
+		}
+		alloc := types(bigmap.BigmapId)
+		return alloc != nil && filters.Match(&bigmap.BigmapKV, alloc.GetKeyType(), alloc.GetValueType())
 	}
 
 	// prepare response stream
@@ -412,14 +523,17 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 		// run query and stream results
 		var needComma bool
 		err = table.Stream(ctx, q, func(r pack.Row) error {
+			if err := r.Decode(bigmap); err != nil {
+				return err
+			}
+			if !match() {
+				return nil
+			}
 			if needComma {
 				_, _ = io.WriteString(ctx.ResponseWriter, ",")
 			} else {
 				needComma = true
 			}
-			if err := r.Decode(bigmap); err != nil {
-				return err
-			}
 			if err := enc.Encode(bigmap); err != nil {
 				return err
 			}
@@ -435,7 +549,7 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 		// ctx.Log.Tracef("JSON encoded %d rows", count)
 
 	case "csv":
-		enc := csv.NewEncoder(ctx.ResponseWriter)
+		enc := newCsvEncoder(ctx, args)
 		// use custom header columns and order
 		if len(args.Columns) > 0 {
 			err = enc.EncodeHeader(args.Columns, nil)
@@ -446,6 +560,9 @@ func StreamBigmapValueTable(ctx *server.Context, args *TableRequest) (interface{
 				if err := r.Decode(bigmap); err != nil {
 					return err
 				}
+				if !match() {
+					return nil
+				}
 				if err := enc.EncodeRecord(bigmap); err != nil {
 					return err
 				}
@@ -0,0 +1,400 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	// long -> short form
+	codeFamilySourceNames map[string]string
+	// all aliases as list
+	codeFamilyAllAliases []string
+)
+
+func init() {
+	fields, err := pack.Fields(&model.CodeFamily{})
+	if err != nil {
+		log.Fatalf("code family field type error: %v\n", err)
+	}
+	codeFamilySourceNames = fields.NameMapReverse()
+	codeFamilyAllAliases = fields.Aliases()
+
+	// add extra translations
+	codeFamilySourceNames["contract"] = "A"
+	codeFamilySourceNames["creator"] = "C"
+	codeFamilyAllAliases = append(codeFamilyAllAliases, "contract", "creator")
+}
+
+// configurable marshalling helper
+type CodeFamily struct {
+	model.CodeFamily
+	verbose bool            // cond. marshal
+	columns util.StringList // cond. cols & order when brief
+	ctx     *server.Context
+}
+
+func (f *CodeFamily) MarshalJSON() ([]byte, error) {
+	if f.verbose {
+		return f.MarshalJSONVerbose()
+	} else {
+		return f.MarshalJSONBrief()
+	}
+}
+
+func (f *CodeFamily) MarshalJSONVerbose() ([]byte, error) {
+	family := struct {
+		RowId      uint64 `json:"row_id"`
+		Kind       string `json:"kind"`
+		Hash       string `json:"hash"`
+		NContracts int    `json:"n_contracts"`
+		ContractId uint64 `json:"contract_id"`
+		Contract   string `json:"contract"`
+		CreatorId  uint64 `json:"creator_id"`
+		Creator    string `json:"creator"`
+		FirstSeen  int64  `json:"first_seen"`
+		LastSeen   int64  `json:"last_seen"`
+		FirstTime  int64  `json:"first_seen_time"`
+		LastTime   int64  `json:"last_seen_time"`
+	}{
+		RowId:      f.RowId,
+		Kind:       f.Kind.String(),
+		Hash:       util.U64String(f.Hash).Hex(),
+		NContracts: f.NContracts,
+		ContractId: f.ContractId.Value(),
+		Contract:   f.ctx.Indexer.LookupAddress(f.ctx, f.ContractId).String(),
+		CreatorId:  f.CreatorId.Value(),
+		Creator:    f.ctx.Indexer.LookupAddress(f.ctx, f.CreatorId).String(),
+		FirstSeen:  f.FirstSeen,
+		LastSeen:   f.LastSeen,
+		FirstTime:  util.UnixMilliNonZero(f.FirstTime),
+		LastTime:   util.UnixMilliNonZero(f.LastTime),
+	}
+	return json.Marshal(family)
+}
+
+func (f *CodeFamily) MarshalJSONBrief() ([]byte, error) {
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range f.columns {
+		switch v {
+		case "row_id":
+			buf = strconv.AppendUint(buf, f.RowId, 10)
+		case "kind":
+			buf = strconv.AppendQuote(buf, f.Kind.String())
+		case "hash":
+			buf = strconv.AppendQuote(buf, util.U64String(f.Hash).Hex())
+		case "n_contracts":
+			buf = strconv.AppendInt(buf, int64(f.NContracts), 10)
+		case "contract_id":
+			buf = strconv.AppendUint(buf, f.ContractId.Value(), 10)
+		case "contract":
+			buf = strconv.AppendQuote(buf, f.ctx.Indexer.LookupAddress(f.ctx, f.ContractId).String())
+		case "creator_id":
+			buf = strconv.AppendUint(buf, f.CreatorId.Value(), 10)
+		case "creator":
+			buf = strconv.AppendQuote(buf, f.ctx.Indexer.LookupAddress(f.ctx, f.CreatorId).String())
+		case "first_seen":
+			buf = strconv.AppendInt(buf, f.FirstSeen, 10)
+		case "last_seen":
+			buf = strconv.AppendInt(buf, f.LastSeen, 10)
+		case "first_seen_time":
+			buf = strconv.AppendInt(buf, util.UnixMilliNonZero(f.FirstTime), 10)
+		case "last_seen_time":
+			buf = strconv.AppendInt(buf, util.UnixMilliNonZero(f.LastTime), 10)
+		default:
+			continue
+		}
+		if i < len(f.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (f *CodeFamily) MarshalCSV() ([]string, error) {
+	res := make([]string, len(f.columns))
+	for i, v := range f.columns {
+		switch v {
+		case "row_id":
+			res[i] = strconv.FormatUint(f.RowId, 10)
+		case "kind":
+			res[i] = strconv.Quote(f.Kind.String())
+		case "hash":
+			res[i] = strconv.Quote(util.U64String(f.Hash).Hex())
+		case "n_contracts":
+			res[i] = strconv.FormatInt(int64(f.NContracts), 10)
+		case "contract_id":
+			res[i] = strconv.FormatUint(f.ContractId.Value(), 10)
+		case "contract":
+			res[i] = strconv.Quote(f.ctx.Indexer.LookupAddress(f.ctx, f.ContractId).String())
+		case "creator_id":
+			res[i] = strconv.FormatUint(f.CreatorId.Value(), 10)
+		case "creator":
+			res[i] = strconv.Quote(f.ctx.Indexer.LookupAddress(f.ctx, f.CreatorId).String())
+		case "first_seen":
+			res[i] = strconv.FormatInt(f.FirstSeen, 10)
+		case "last_seen":
+			res[i] = strconv.FormatInt(f.LastSeen, 10)
+		case "first_seen_time":
+			res[i] = strconv.Quote(f.FirstTime.Format(time.RFC3339))
+		case "last_seen_time":
+			res[i] = strconv.Quote(f.LastTime.Format(time.RFC3339))
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func StreamCodeFamilyTable(ctx *server.Context, args *TableRequest) (interface{}, int) {
+	// access table
+	table, err := ctx.Indexer.Table(args.Table)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Table), err))
+	}
+
+	// translate long column names to short names used in pack tables
+	var srcNames []string
+	if len(args.Columns) > 0 {
+		// resolve short column names
+		srcNames = make([]string, 0, len(args.Columns))
+		for _, v := range args.Columns {
+			n, ok := codeFamilySourceNames[v]
+			if !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", v), nil))
+			}
+			if n != "-" {
+				srcNames = append(srcNames, n)
+			}
+		}
+	} else {
+		// use all table columns in order and reverse lookup their long names
+		srcNames = table.Fields().Names()
+		args.Columns = codeFamilyAllAliases
+	}
+
+	// build table query
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields(srcNames...).
+		WithLimit(int(args.Limit)).
+		WithOrder(args.Order)
+
+	// build dynamic filter conditions from query (will panic on error)
+	for key, val := range ctx.Request.URL.Query() {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		field := codeFamilySourceNames[prefix]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "limit", "order", "verbose", "filename":
+			// skip these fields
+		case "cursor":
+			// add row id condition: id > cursor (new cursor == last row id)
+			id, err := strconv.ParseUint(val[0], 10, 64)
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid cursor value '%s'", val), err))
+			}
+			cursorMode := pack.FilterModeGt
+			if args.Order == pack.OrderDesc {
+				cursorMode = pack.FilterModeLt
+			}
+			q = q.And("I", cursorMode, id)
+		case "contract", "creator":
+			addrs := make([]model.AccountID, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				addr, err := tezos.ParseAddress(v)
+				if err != nil || !addr.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				if err == nil && acc.RowId > 0 {
+					addrs = append(addrs, acc.RowId)
+				}
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				if len(addrs) > 0 {
+					q = q.And(field, mode, addrs[0])
+				} else if mode == pack.FilterModeEqual {
+					// unknown account never matches
+					q = q.And(field, mode, uint64(0))
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(field, mode, addrs)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		case "kind":
+			kinds := make([]uint8, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				kind := model.ParseCodeFamilyKind(v)
+				if !kind.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid family kind '%s'", v), nil))
+				}
+				kinds = append(kinds, uint8(kind))
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And(field, mode, kinds[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(field, mode, kinds)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		case "hash":
+			// hashes are rendered as hex strings
+			hashes := make([]uint64, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				h, err := strconv.ParseUint(v, 16, 64)
+				if err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid hash '%s'", v), err))
+				}
+				hashes = append(hashes, h)
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And(field, mode, hashes[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(field, mode, hashes)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		default:
+			// translate long column name used in query to short column name used in packs
+			if short, ok := codeFamilySourceNames[prefix]; !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+			} else {
+				key = strings.Replace(key, prefix, short, 1)
+			}
+
+			// the same field name may appear multiple times, in which case conditions
+			// are combined like any other condition with logical AND
+			for _, v := range val {
+				if cond, err := pack.ParseCondition(key, v, table.Fields()); err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+				} else {
+					q = q.AndCondition(cond)
+				}
+			}
+		}
+	}
+
+	var (
+		count  int
+		lastId uint64
+	)
+
+	// prepare return type marshalling
+	val := &CodeFamily{
+		verbose: args.Verbose,
+		columns: args.Columns,
+		ctx:     ctx,
+	}
+
+	// prepare response stream
+	ctx.StreamResponseHeaders(http.StatusOK, mimetypes[args.Format])
+
+	switch args.Format {
+	case "json":
+		enc := json.NewEncoder(ctx.ResponseWriter)
+		enc.SetIndent("", "")
+		enc.SetEscapeHTML(false)
+
+		// open JSON array
+		_, _ = io.WriteString(ctx.ResponseWriter, "[")
+		// close JSON array on panic
+		defer func() {
+			if e := recover(); e != nil {
+				_, _ = io.WriteString(ctx.ResponseWriter, "]")
+				panic(e)
+			}
+		}()
+
+		// run query and stream results
+		var needComma bool
+		err = table.Stream(ctx, q, func(r pack.Row) error {
+			if needComma {
+				_, _ = io.WriteString(ctx.ResponseWriter, ",")
+			} else {
+				needComma = true
+			}
+			if err := r.Decode(val); err != nil {
+				return err
+			}
+			if err := enc.Encode(val); err != nil {
+				return err
+			}
+			count++
+			lastId = val.RowId
+			if args.Limit > 0 && count == int(args.Limit) {
+				return io.EOF
+			}
+			return nil
+		})
+		// close JSON bracket
+		_, _ = io.WriteString(ctx.ResponseWriter, "]")
+
+	case "csv":
+		enc := newCsvEncoder(ctx, args)
+		// use custom header columns and order
+		if len(args.Columns) > 0 {
+			err = enc.EncodeHeader(args.Columns, nil)
+		}
+		if err == nil {
+			// run query and stream results
+			err = table.Stream(ctx, q, func(r pack.Row) error {
+				if err := r.Decode(val); err != nil {
+					return err
+				}
+				if err := enc.EncodeRecord(val); err != nil {
+					return err
+				}
+				count++
+				lastId = val.RowId
+				if args.Limit > 0 && count == int(args.Limit) {
+					return io.EOF
+				}
+				return nil
+			})
+		}
+	}
+
+	// without new records, cursor remains the same as input (may be empty)
+	cursor := args.Cursor
+	if lastId > 0 {
+		cursor = strconv.FormatUint(lastId, 10)
+	}
+
+	// write error (except EOF), cursor and count as http trailer
+	ctx.StreamTrailer(cursor, count, err)
+
+	// streaming return
+	return nil, -1
+}
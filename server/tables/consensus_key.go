@@ -0,0 +1,461 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+var (
+	// long -> short form
+	consensusKeySourceNames map[string]string
+	// all aliases as list
+	consensusKeyAllAliases []string
+)
+
+func init() {
+	fields, err := pack.Fields(&model.ConsensusKey{})
+	if err != nil {
+		log.Fatalf("consensus key field type error: %v\n", err)
+	}
+	consensusKeySourceNames = fields.NameMapReverse()
+	consensusKeyAllAliases = fields.Aliases()
+
+	// add extra translations
+	consensusKeySourceNames["baker"] = "B"
+	consensusKeySourceNames["receiver"] = "R"
+	consensusKeySourceNames["op"] = "o"
+	consensusKeyAllAliases = append(consensusKeyAllAliases, "baker", "receiver", "op")
+}
+
+// configurable marshalling helper
+type ConsensusKey struct {
+	model.ConsensusKey
+	verbose bool            // cond. marshal
+	columns util.StringList // cond. cols & order when brief
+	params  *tezos.Params   // blockchain amount conversion
+	ctx     *server.Context
+}
+
+func (k *ConsensusKey) MarshalJSON() ([]byte, error) {
+	if k.verbose {
+		return k.MarshalJSONVerbose()
+	} else {
+		return k.MarshalJSONBrief()
+	}
+}
+
+func (k *ConsensusKey) MarshalJSONVerbose() ([]byte, error) {
+	ck := struct {
+		RowId           uint64  `json:"row_id"`
+		BakerId         uint64  `json:"baker_id"`
+		Baker           string  `json:"baker"`
+		Type            string  `json:"type"`
+		Key             string  `json:"key"`
+		Address         string  `json:"address"`
+		Height          int64   `json:"height"`
+		Cycle           int64   `json:"cycle"`
+		ActivationCycle int64   `json:"activation_cycle"`
+		Timestamp       int64   `json:"time"`
+		OpId            uint64  `json:"op_id"`
+		Op              string  `json:"op"`
+		ReceiverId      uint64  `json:"receiver_id"`
+		Receiver        string  `json:"receiver"`
+		Amount          float64 `json:"amount"`
+	}{
+		RowId:           k.RowId,
+		BakerId:         k.BakerId.Value(),
+		Baker:           k.ctx.Indexer.LookupAddress(k.ctx, k.BakerId).String(),
+		Type:            k.Type.String(),
+		Key:             k.keyString(),
+		Address:         k.Address.String(),
+		Height:          k.Height,
+		Cycle:           k.Cycle,
+		ActivationCycle: k.ActivationCycle,
+		Timestamp:       util.UnixMilliNonZero(k.Timestamp),
+		OpId:            k.OpId.Value(),
+		Op:              k.ctx.Indexer.LookupOpHash(k.ctx, k.OpId).String(),
+		ReceiverId:      k.ReceiverId.Value(),
+		Receiver:        k.receiverString(),
+		Amount:          k.params.ConvertValue(k.Amount),
+	}
+	return json.Marshal(ck)
+}
+
+func (k *ConsensusKey) MarshalJSONBrief() ([]byte, error) {
+	dec := k.params.Decimals
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range k.columns {
+		switch v {
+		case "row_id":
+			buf = strconv.AppendUint(buf, k.RowId, 10)
+		case "baker_id":
+			buf = strconv.AppendUint(buf, k.BakerId.Value(), 10)
+		case "baker":
+			buf = strconv.AppendQuote(buf, k.ctx.Indexer.LookupAddress(k.ctx, k.BakerId).String())
+		case "type":
+			buf = strconv.AppendQuote(buf, k.Type.String())
+		case "key":
+			buf = strconv.AppendQuote(buf, k.keyString())
+		case "address":
+			buf = strconv.AppendQuote(buf, k.Address.String())
+		case "height":
+			buf = strconv.AppendInt(buf, k.Height, 10)
+		case "cycle":
+			buf = strconv.AppendInt(buf, k.Cycle, 10)
+		case "activation_cycle":
+			buf = strconv.AppendInt(buf, k.ActivationCycle, 10)
+		case "time":
+			buf = strconv.AppendInt(buf, util.UnixMilliNonZero(k.Timestamp), 10)
+		case "op_id":
+			buf = strconv.AppendUint(buf, k.OpId.Value(), 10)
+		case "op":
+			buf = strconv.AppendQuote(buf, k.ctx.Indexer.LookupOpHash(k.ctx, k.OpId).String())
+		case "receiver_id":
+			buf = strconv.AppendUint(buf, k.ReceiverId.Value(), 10)
+		case "receiver":
+			buf = strconv.AppendQuote(buf, k.receiverString())
+		case "amount":
+			buf = strconv.AppendFloat(buf, k.params.ConvertValue(k.Amount), 'f', dec, 64)
+		default:
+			continue
+		}
+		if i < len(k.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (k *ConsensusKey) MarshalCSV() ([]string, error) {
+	dec := k.params.Decimals
+	res := make([]string, len(k.columns))
+	for i, v := range k.columns {
+		switch v {
+		case "row_id":
+			res[i] = strconv.FormatUint(k.RowId, 10)
+		case "baker_id":
+			res[i] = strconv.FormatUint(k.BakerId.Value(), 10)
+		case "baker":
+			res[i] = strconv.Quote(k.ctx.Indexer.LookupAddress(k.ctx, k.BakerId).String())
+		case "type":
+			res[i] = strconv.Quote(k.Type.String())
+		case "key":
+			res[i] = strconv.Quote(k.keyString())
+		case "address":
+			res[i] = strconv.Quote(k.Address.String())
+		case "height":
+			res[i] = strconv.FormatInt(k.Height, 10)
+		case "cycle":
+			res[i] = strconv.FormatInt(k.Cycle, 10)
+		case "activation_cycle":
+			res[i] = strconv.FormatInt(k.ActivationCycle, 10)
+		case "time":
+			res[i] = strconv.Quote(k.Timestamp.Format(time.RFC3339))
+		case "op_id":
+			res[i] = strconv.FormatUint(k.OpId.Value(), 10)
+		case "op":
+			res[i] = strconv.Quote(k.ctx.Indexer.LookupOpHash(k.ctx, k.OpId).String())
+		case "receiver_id":
+			res[i] = strconv.FormatUint(k.ReceiverId.Value(), 10)
+		case "receiver":
+			res[i] = strconv.Quote(k.receiverString())
+		case "amount":
+			res[i] = strconv.FormatFloat(k.params.ConvertValue(k.Amount), 'f', dec, 64)
+		default:
+			continue
+		}
+	}
+	return res, nil
+}
+
+func (k *ConsensusKey) keyString() string {
+	if !k.Key.IsValid() {
+		return ""
+	}
+	return k.Key.String()
+}
+
+func (k *ConsensusKey) receiverString() string {
+	if k.ReceiverId == 0 {
+		return ""
+	}
+	return k.ctx.Indexer.LookupAddress(k.ctx, k.ReceiverId).String()
+}
+
+func StreamConsensusKeyTable(ctx *server.Context, args *TableRequest) (interface{}, int) {
+	params := ctx.Params
+	// access table
+	table, err := ctx.Indexer.Table(args.Table)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", args.Table), err))
+	}
+
+	// translate long column names to short names used in pack tables
+	var srcNames []string
+	if len(args.Columns) > 0 {
+		// resolve short column names
+		srcNames = make([]string, 0, len(args.Columns))
+		for _, v := range args.Columns {
+			n, ok := consensusKeySourceNames[v]
+			if !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", v), nil))
+			}
+			if n != "-" {
+				srcNames = append(srcNames, n)
+			}
+		}
+	} else {
+		// use all table columns in order and reverse lookup their long names
+		srcNames = table.Fields().Names()
+		args.Columns = consensusKeyAllAliases
+	}
+
+	// build table query
+	q := pack.NewQuery(ctx.RequestID).
+		WithTable(table).
+		WithFields(srcNames...).
+		WithLimit(int(args.Limit)).
+		WithOrder(args.Order)
+
+	// build dynamic filter conditions from query (will panic on error)
+	for key, val := range ctx.Request.URL.Query() {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		field := consensusKeySourceNames[prefix]
+		mode := pack.FilterModeEqual
+		if len(keys) > 1 {
+			mode = pack.ParseFilterMode(keys[1])
+			if !mode.IsValid() {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s'", keys[1]), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "limit", "order", "verbose", "filename":
+			// skip these fields
+		case "cursor":
+			// add row id condition: id > cursor (new cursor == last row id)
+			id, err := strconv.ParseUint(val[0], 10, 64)
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid cursor value '%s'", val), err))
+			}
+			cursorMode := pack.FilterModeGt
+			if args.Order == pack.OrderDesc {
+				cursorMode = pack.FilterModeLt
+			}
+			q = q.And("I", cursorMode, id)
+		case "baker", "receiver":
+			addrs := make([]model.AccountID, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				addr, err := tezos.ParseAddress(v)
+				if err != nil || !addr.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				acc, err := ctx.Indexer.LookupAccount(ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				if err == nil && acc.RowId > 0 {
+					addrs = append(addrs, acc.RowId)
+				}
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				if len(addrs) > 0 {
+					q = q.And(field, mode, addrs[0])
+				} else if mode == pack.FilterModeEqual {
+					// unknown account never matches
+					q = q.And(field, mode, uint64(0))
+				}
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(field, mode, addrs)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		case "address":
+			// consensus key address, special address type to []byte conversion
+			addrs := make([][]byte, 0)
+			for _, v := range strings.Split(val[0], ",") {
+				addr, err := tezos.ParseAddress(v)
+				if err != nil || !addr.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				addrs = append(addrs, addr.Bytes22())
+			}
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				q = q.And(field, mode, addrs[0])
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				q = q.And(field, mode, addrs)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		case "type":
+			// parse only the first value
+			switch mode {
+			case pack.FilterModeEqual, pack.FilterModeNotEqual:
+				typ := model.ParseOpType(val[0])
+				if !typ.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid operation type '%s'", val[0]), nil))
+				}
+				q = q.And(field, mode, typ)
+			case pack.FilterModeIn, pack.FilterModeNotIn:
+				typs := make([]uint8, 0)
+				for _, t := range strings.Split(val[0], ",") {
+					typ := model.ParseOpType(t)
+					if !typ.IsValid() {
+						panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid operation type '%s'", t), nil))
+					}
+					typs = append(typs, uint8(typ))
+				}
+				q = q.And(field, mode, typs)
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", mode, prefix), nil))
+			}
+		default:
+			// translate long column name used in query to short column name used in packs
+			if short, ok := consensusKeySourceNames[prefix]; !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+			} else {
+				key = strings.Replace(key, prefix, short, 1)
+			}
+
+			// the same field name may appear multiple times, in which case conditions
+			// are combined like any other condition with logical AND
+			for _, v := range val {
+				// convert amounts from float to int64
+				switch prefix {
+				case "cycle", "activation_cycle":
+					if v == "head" {
+						currentCycle := params.CycleFromHeight(ctx.Tip.BestHeight)
+						v = strconv.FormatInt(currentCycle, 10)
+					}
+				case "amount":
+					fvals := make([]string, 0)
+					for _, vv := range strings.Split(v, ",") {
+						fval, err := strconv.ParseFloat(vv, 64)
+						if err != nil {
+							panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, vv), err))
+						}
+						fvals = append(fvals, strconv.FormatInt(params.ConvertAmount(fval), 10))
+					}
+					v = strings.Join(fvals, ",")
+				}
+				if cond, err := pack.ParseCondition(key, v, table.Fields()); err != nil {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid %s filter value '%s'", key, v), err))
+				} else {
+					q = q.AndCondition(cond)
+				}
+			}
+		}
+	}
+
+	var (
+		count  int
+		lastId uint64
+	)
+
+	// prepare return type marshalling
+	val := &ConsensusKey{
+		verbose: args.Verbose,
+		columns: args.Columns,
+		params:  params,
+		ctx:     ctx,
+	}
+
+	// prepare response stream
+	ctx.StreamResponseHeaders(http.StatusOK, mimetypes[args.Format])
+
+	switch args.Format {
+	case "json":
+		enc := json.NewEncoder(ctx.ResponseWriter)
+		enc.SetIndent("", "")
+		enc.SetEscapeHTML(false)
+
+		// open JSON array
+		_, _ = io.WriteString(ctx.ResponseWriter, "[")
+		// close JSON array on panic
+		defer func() {
+			if e := recover(); e != nil {
+				_, _ = io.WriteString(ctx.ResponseWriter, "]")
+				panic(e)
+			}
+		}()
+
+		// run query and stream results
+		var needComma bool
+		err = table.Stream(ctx, q, func(r pack.Row) error {
+			if needComma {
+				_, _ = io.WriteString(ctx.ResponseWriter, ",")
+			} else {
+				needComma = true
+			}
+			if err := r.Decode(val); err != nil {
+				return err
+			}
+			if err := enc.Encode(val); err != nil {
+				return err
+			}
+			count++
+			lastId = val.RowId
+			if args.Limit > 0 && count == int(args.Limit) {
+				return io.EOF
+			}
+			return nil
+		})
+		// close JSON bracket
+		_, _ = io.WriteString(ctx.ResponseWriter, "]")
+
+	case "csv":
+		enc := newCsvEncoder(ctx, args)
+		// use custom header columns and order
+		if len(args.Columns) > 0 {
+			err = enc.EncodeHeader(args.Columns, nil)
+		}
+		if err == nil {
+			// run query and stream results
+			err = table.Stream(ctx, q, func(r pack.Row) error {
+				if err := r.Decode(val); err != nil {
+					return err
+				}
+				if err := enc.EncodeRecord(val); err != nil {
+					return err
+				}
+				count++
+				lastId = val.RowId
+				if args.Limit > 0 && count == int(args.Limit) {
+					return io.EOF
+				}
+				return nil
+			})
+		}
+	}
+
+	// without new records, cursor remains the same as input (may be empty)
+	cursor := args.Cursor
+	if lastId > 0 {
+		cursor = strconv.FormatUint(lastId, 10)
+	}
+
+	// write error (except EOF), cursor and count as http trailer
+	ctx.StreamTrailer(cursor, count, err)
+
+	// streaming return
+	return nil, -1
+}
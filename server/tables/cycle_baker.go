@@ -0,0 +1,417 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/packdb/util"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl"
+	"blockwatch.cc/tzindex/etl/model"
+	"blockwatch.cc/tzindex/server"
+)
+
+// cycle_baker is a virtual table that is built on request from rights,
+// income and baker operations for a single cycle
+var cycleBakerAllAliases = []string{
+	"cycle",
+	"baker_id",
+	"baker",
+	"is_degraded",
+	"n_baking_rights",
+	"n_blocks_baked",
+	"n_blocks_stolen",
+	"n_blocks_missed",
+	"n_endorsing_rights",
+	"n_blocks_endorsed",
+	"n_blocks_not_endorsed",
+	"n_endorsing_slots",
+	"n_slots_endorsed",
+	"n_seeds_required",
+	"n_seeds_revealed",
+	"n_seeds_unrevealed",
+	"n_seeds_pending",
+	"n_vdf_revelations",
+	"n_double_baking",
+	"n_double_endorsement",
+	"n_accusations",
+	"lost_deposits",
+	"lost_rewards",
+	"lost_fees",
+	"accusation_rewards",
+	"reliability",
+}
+
+// configurable marshalling helper
+type CycleBaker struct {
+	etl.CycleBaker
+	verbose bool            // cond. marshal
+	columns util.StringList // cond. cols & order when brief
+	params  *tezos.Params   // blockchain amount conversion
+	ctx     *server.Context
+}
+
+func (b *CycleBaker) MarshalJSON() ([]byte, error) {
+	if b.verbose {
+		return b.MarshalJSONVerbose()
+	} else {
+		return b.MarshalJSONBrief()
+	}
+}
+
+func (b *CycleBaker) MarshalJSONVerbose() ([]byte, error) {
+	cb := struct {
+		Cycle              int64   `json:"cycle"`
+		BakerId            uint64  `json:"baker_id"`
+		Baker              string  `json:"baker"`
+		IsDegraded         bool    `json:"is_degraded"`
+		NBakingRights      int64   `json:"n_baking_rights"`
+		NBlocksBaked       int64   `json:"n_blocks_baked"`
+		NBlocksStolen      int64   `json:"n_blocks_stolen"`
+		NBlocksMissed      int64   `json:"n_blocks_missed"`
+		NEndorsingRights   int64   `json:"n_endorsing_rights"`
+		NBlocksEndorsed    int64   `json:"n_blocks_endorsed"`
+		NBlocksNotEndorsed int64   `json:"n_blocks_not_endorsed"`
+		NEndorsingSlots    int64   `json:"n_endorsing_slots"`
+		NSlotsEndorsed     int64   `json:"n_slots_endorsed"`
+		NSeedsRequired     int64   `json:"n_seeds_required"`
+		NSeedsRevealed     int64   `json:"n_seeds_revealed"`
+		NSeedsUnrevealed   int64   `json:"n_seeds_unrevealed"`
+		NSeedsPending      int64   `json:"n_seeds_pending"`
+		NVdfRevelations    int64   `json:"n_vdf_revelations"`
+		NDoubleBaking      int64   `json:"n_double_baking"`
+		NDoubleEndorsement int64   `json:"n_double_endorsement"`
+		NAccusations       int64   `json:"n_accusations"`
+		LostDeposits       float64 `json:"lost_deposits"`
+		LostRewards        float64 `json:"lost_rewards"`
+		LostFees           float64 `json:"lost_fees"`
+		AccusationRewards  float64 `json:"accusation_rewards"`
+		Reliability        float64 `json:"reliability"`
+	}{
+		Cycle:              b.Cycle,
+		BakerId:            b.AccountId.Value(),
+		Baker:              b.ctx.Indexer.LookupAddress(b.ctx, b.AccountId).String(),
+		IsDegraded:         b.IsDegraded(),
+		NBakingRights:      b.NBakingRights,
+		NBlocksBaked:       b.NBlocksBaked,
+		NBlocksStolen:      b.NBlocksStolen,
+		NBlocksMissed:      b.NBlocksMissed,
+		NEndorsingRights:   b.NEndorsingRights,
+		NBlocksEndorsed:    b.NBlocksEndorsed,
+		NBlocksNotEndorsed: b.NBlocksNotEndorsed,
+		NEndorsingSlots:    b.NEndorsingSlots,
+		NSlotsEndorsed:     b.NSlotsEndorsed,
+		NSeedsRequired:     b.NSeedsRequired,
+		NSeedsRevealed:     b.NSeedsRevealed,
+		NSeedsUnrevealed:   b.NSeedsUnrevealed,
+		NSeedsPending:      b.NSeedsPending,
+		NVdfRevelations:    b.NVdfRevelations,
+		NDoubleBaking:      b.NDoubleBaking,
+		NDoubleEndorsement: b.NDoubleEndorsement,
+		NAccusations:       b.NAccusations,
+		LostDeposits:       b.params.ConvertValue(b.LostDeposits),
+		LostRewards:        b.params.ConvertValue(b.LostRewards),
+		LostFees:           b.params.ConvertValue(b.LostFees),
+		AccusationRewards:  b.params.ConvertValue(b.AccusationRewards),
+		Reliability:        float64(b.Reliability) / 100,
+	}
+	return json.Marshal(cb)
+}
+
+func (b *CycleBaker) MarshalJSONBrief() ([]byte, error) {
+	dec := b.params.Decimals
+	buf := make([]byte, 0, 2048)
+	buf = append(buf, '[')
+	for i, v := range b.columns {
+		switch v {
+		case "cycle":
+			buf = strconv.AppendInt(buf, b.Cycle, 10)
+		case "baker_id":
+			buf = strconv.AppendUint(buf, b.AccountId.Value(), 10)
+		case "baker":
+			buf = strconv.AppendQuote(buf, b.ctx.Indexer.LookupAddress(b.ctx, b.AccountId).String())
+		case "is_degraded":
+			if b.IsDegraded() {
+				buf = append(buf, '1')
+			} else {
+				buf = append(buf, '0')
+			}
+		case "lost_deposits", "lost_rewards", "lost_fees", "accusation_rewards":
+			buf = strconv.AppendFloat(buf, b.params.ConvertValue(b.amount(v)), 'f', dec, 64)
+		case "reliability":
+			buf = strconv.AppendFloat(buf, float64(b.Reliability)/100, 'f', 2, 64)
+		default:
+			n, ok := b.count(v)
+			if !ok {
+				continue
+			}
+			buf = strconv.AppendInt(buf, n, 10)
+		}
+		if i < len(b.columns)-1 {
+			buf = append(buf, ',')
+		}
+	}
+	buf = append(buf, ']')
+	return buf, nil
+}
+
+func (b *CycleBaker) MarshalCSV() ([]string, error) {
+	dec := b.params.Decimals
+	res := make([]string, len(b.columns))
+	for i, v := range b.columns {
+		switch v {
+		case "cycle":
+			res[i] = strconv.FormatInt(b.Cycle, 10)
+		case "baker_id":
+			res[i] = strconv.FormatUint(b.AccountId.Value(), 10)
+		case "baker":
+			res[i] = strconv.Quote(b.ctx.Indexer.LookupAddress(b.ctx, b.AccountId).String())
+		case "is_degraded":
+			res[i] = strconv.FormatBool(b.IsDegraded())
+		case "lost_deposits", "lost_rewards", "lost_fees", "accusation_rewards":
+			res[i] = strconv.FormatFloat(b.params.ConvertValue(b.amount(v)), 'f', dec, 64)
+		case "reliability":
+			res[i] = strconv.FormatFloat(float64(b.Reliability)/100, 'f', 2, 64)
+		default:
+			n, ok := b.count(v)
+			if !ok {
+				continue
+			}
+			res[i] = strconv.FormatInt(n, 10)
+		}
+	}
+	return res, nil
+}
+
+func (b *CycleBaker) amount(col string) int64 {
+	switch col {
+	case "lost_deposits":
+		return b.LostDeposits
+	case "lost_rewards":
+		return b.LostRewards
+	case "lost_fees":
+		return b.LostFees
+	case "accusation_rewards":
+		return b.AccusationRewards
+	default:
+		return 0
+	}
+}
+
+func (b *CycleBaker) count(col string) (int64, bool) {
+	switch col {
+	case "n_baking_rights":
+		return b.NBakingRights, true
+	case "n_blocks_baked":
+		return b.NBlocksBaked, true
+	case "n_blocks_stolen":
+		return b.NBlocksStolen, true
+	case "n_blocks_missed":
+		return b.NBlocksMissed, true
+	case "n_endorsing_rights":
+		return b.NEndorsingRights, true
+	case "n_blocks_endorsed":
+		return b.NBlocksEndorsed, true
+	case "n_blocks_not_endorsed":
+		return b.NBlocksNotEndorsed, true
+	case "n_endorsing_slots":
+		return b.NEndorsingSlots, true
+	case "n_slots_endorsed":
+		return b.NSlotsEndorsed, true
+	case "n_seeds_required":
+		return b.NSeedsRequired, true
+	case "n_seeds_revealed":
+		return b.NSeedsRevealed, true
+	case "n_seeds_unrevealed":
+		return b.NSeedsUnrevealed, true
+	case "n_seeds_pending":
+		return b.NSeedsPending, true
+	case "n_vdf_revelations":
+		return b.NVdfRevelations, true
+	case "n_double_baking":
+		return b.NDoubleBaking, true
+	case "n_double_endorsement":
+		return b.NDoubleEndorsement, true
+	case "n_accusations":
+		return b.NAccusations, true
+	default:
+		return 0, false
+	}
+}
+
+func StreamCycleBakerTable(ctx *server.Context, args *TableRequest) (interface{}, int) {
+	params := ctx.Params
+
+	// validate column names
+	if len(args.Columns) > 0 {
+		known := make(map[string]struct{}, len(cycleBakerAllAliases))
+		for _, v := range cycleBakerAllAliases {
+			known[v] = struct{}{}
+		}
+		for _, v := range args.Columns {
+			if _, ok := known[v]; !ok {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", v), nil))
+			}
+		}
+	} else {
+		args.Columns = cycleBakerAllAliases
+	}
+
+	// parse filters, the cycle is required
+	var (
+		cycle    int64 = -1
+		bakers   map[model.AccountID]struct{}
+		degraded *bool
+	)
+	for key, val := range ctx.Request.URL.Query() {
+		keys := strings.Split(key, ".")
+		prefix := keys[0]
+		if len(keys) > 1 && keys[1] != pack.FilterModeEqual.String() {
+			switch prefix {
+			case "baker":
+				if keys[1] != pack.FilterModeIn.String() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", keys[1], prefix), nil))
+				}
+			default:
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid filter mode '%s' for column '%s'", keys[1], prefix), nil))
+			}
+		}
+		switch prefix {
+		case "columns", "limit", "order", "verbose", "filename":
+			// skip these fields
+		case "cycle":
+			if val[0] == "head" {
+				cycle = params.CycleFromHeight(ctx.Tip.BestHeight)
+			} else {
+				c, err := strconv.ParseInt(val[0], 10, 64)
+				if err != nil || c < 0 {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid cycle '%s'", val[0]), err))
+				}
+				cycle = c
+			}
+		case "baker":
+			bakers = make(map[model.AccountID]struct{})
+			for _, v := range strings.Split(val[0], ",") {
+				addr, err := tezos.ParseAddress(v)
+				if err != nil || !addr.IsValid() {
+					panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid address '%s'", v), err))
+				}
+				if acc, err := ctx.Indexer.LookupAccount(ctx, addr); err == nil {
+					bakers[acc.RowId] = struct{}{}
+				}
+			}
+		case "is_degraded":
+			b, err := strconv.ParseBool(val[0])
+			if err != nil {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid is_degraded value '%s'", val[0]), err))
+			}
+			degraded = &b
+		default:
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unknown column '%s'", prefix), nil))
+		}
+	}
+	if cycle < 0 {
+		panic(server.EBadRequest(server.EC_PARAM_REQUIRED, "missing cycle filter", nil))
+	}
+
+	list, _, err := ctx.Indexer.ListCycleBakers(ctx, cycle)
+	if err != nil {
+		panic(server.EInternal(server.EC_DATABASE, "cannot list cycle bakers", err))
+	}
+	if args.Order == pack.OrderDesc {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+
+	var count int
+
+	// prepare return type marshalling
+	val := &CycleBaker{
+		verbose: args.Verbose,
+		columns: args.Columns,
+		params:  params,
+		ctx:     ctx,
+	}
+
+	// filter and emit rows
+	each := func(fn func() error) error {
+		for _, v := range list {
+			if bakers != nil {
+				if _, ok := bakers[v.AccountId]; !ok {
+					continue
+				}
+			}
+			if degraded != nil && v.IsDegraded() != *degraded {
+				continue
+			}
+			val.CycleBaker = *v
+			if err := fn(); err != nil {
+				return err
+			}
+			count++
+			if args.Limit > 0 && count == int(args.Limit) {
+				break
+			}
+		}
+		return nil
+	}
+
+	// prepare response stream
+	ctx.StreamResponseHeaders(http.StatusOK, mimetypes[args.Format])
+
+	switch args.Format {
+	case "json":
+		enc := json.NewEncoder(ctx.ResponseWriter)
+		enc.SetIndent("", "")
+		enc.SetEscapeHTML(false)
+
+		// open JSON array
+		_, _ = io.WriteString(ctx.ResponseWriter, "[")
+		// close JSON array on panic
+		defer func() {
+			if e := recover(); e != nil {
+				_, _ = io.WriteString(ctx.ResponseWriter, "]")
+				panic(e)
+			}
+		}()
+
+		var needComma bool
+		err = each(func() error {
+			if needComma {
+				_, _ = io.WriteString(ctx.ResponseWriter, ",")
+			} else {
+				needComma = true
+			}
+			return enc.Encode(val)
+		})
+		// close JSON bracket
+		_, _ = io.WriteString(ctx.ResponseWriter, "]")
+
+	case "csv":
+		enc := newCsvEncoder(ctx, args)
+		// use custom header columns and order
+		if len(args.Columns) > 0 {
+			err = enc.EncodeHeader(args.Columns, nil)
+		}
+		if err == nil {
+			err = each(func() error {
+				return enc.EncodeRecord(val)
+			})
+		}
+	}
+
+	// write error, cursor and count as http trailer
+	ctx.StreamTrailer(args.Cursor, count, err)
+
+	// streaming return
+	return nil, -1
+}
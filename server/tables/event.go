@@ -12,7 +12,6 @@ import (
     "strconv"
     "strings"
 
-    "blockwatch.cc/packdb/encoding/csv"
     "blockwatch.cc/packdb/pack"
     "blockwatch.cc/packdb/util"
     "blockwatch.cc/tzgo/tezos"
@@ -342,7 +341,7 @@ func StreamEventTable(ctx *server.Context, args *TableRequest) (interface{}, int
         // ctx.Log.Tracef("JSON encoded %d rows", count)
 
     case "csv":
-        enc := csv.NewEncoder(ctx.ResponseWriter)
+        enc := newCsvEncoder(ctx, args)
         // use custom header columns and order
         if len(args.Columns) > 0 {
             err = enc.EncodeHeader(args.Columns, nil)
@@ -0,0 +1,1379 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/packdb/encoding/csv"
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzindex/etl/index"
+	"blockwatch.cc/tzindex/server"
+	"github.com/gorilla/mux"
+)
+
+// max size of a SQL query string
+const maxSqlQuery = 64 * 1024
+
+// number of base table rows loaded per batch
+const sqlBatchSize = 1 << 12
+
+// tables available to SQL queries, virtual tables like cycle_baker are
+// built on request and cannot be scanned
+var sqlTables = map[string]bool{
+	"block":          true,
+	"chain":          true,
+	"supply":         true,
+	"op":             true,
+	"flow":           true,
+	"contract":       true,
+	"account":        true,
+	"rights":         true,
+	"snapshot":       true,
+	"election":       true,
+	"proposal":       true,
+	"vote":           true,
+	"ballot":         true,
+	"income":         true,
+	"bigmaps":        true,
+	"bigmap_values":  true,
+	"bigmap_updates": true,
+	"constant":       true,
+	"balance":        true,
+	"event":          true,
+	"consensus_key":  true,
+	"code_family":    true,
+}
+
+var (
+	errSqlRowLimit = errors.New("row limit exceeded")
+	errSqlLimit    = errors.New("limit reached")
+)
+
+type SqlRequest struct {
+	Query  string `schema:"q"    json:"query"`
+	Format string `schema:"-"    json:"-"` // from URL
+}
+
+func (r *SqlRequest) Parse(ctx *server.Context) {
+	r.Format = strings.ToLower(mux.Vars(ctx.Request)["format"])
+	if r.Format == "" {
+		r.Format = "json"
+	}
+	switch r.Format {
+	case "json", "csv":
+	default:
+		panic(server.EBadRequest(server.EC_CONTENTTYPE_UNSUPPORTED, fmt.Sprintf("unsupported format '%s'", r.Format), nil))
+	}
+	if r.Query == "" {
+		panic(server.EBadRequest(server.EC_PARAM_REQUIRED, "missing query", nil))
+	}
+	if len(r.Query) > maxSqlQuery {
+		panic(server.ERequestTooLarge(server.EC_PARAM_INVALID, "query too long", nil))
+	}
+}
+
+// QuerySql executes a read-only SQL query against index tables. Queries are
+// accepted as `q` URL parameter or as JSON body `{"query":"..."}` or plain
+// text body on POST.
+func QuerySql(ctx *server.Context) (interface{}, int) {
+	args := &SqlRequest{}
+	if ctx.Request.Method != http.MethodGet && !strings.HasPrefix(ctx.Request.Header.Get("Content-Type"), "application/json") {
+		buf, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxSqlQuery+1))
+		if err != nil {
+			panic(server.EBadRequest(server.EC_DEMARSHAL_FAILED, "cannot read query", err))
+		}
+		q := ctx.Request.URL.Query()
+		if len(bytes.TrimSpace(buf)) > 0 {
+			q.Set("q", string(buf))
+		}
+		ctx.Request.URL.RawQuery = q.Encode()
+	}
+	ctx.ParseRequestArgs(args)
+
+	query, err := ParseSql(args.Query)
+	if err != nil {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, "invalid query: "+err.Error(), nil))
+	}
+	plan := newSqlPlan(ctx, query)
+
+	// enforce query time limit
+	qctx := context.Context(ctx.Context)
+	if d := ctx.Cfg.Http.MaxSqlDuration; d > 0 {
+		var cancel context.CancelFunc
+		qctx, cancel = context.WithTimeout(ctx.Context, d)
+		defer cancel()
+	}
+
+	// stream output rows, headers are sent with the first row so that
+	// errors of sorted and aggregate queries still produce an error status
+	var (
+		count   int
+		started bool
+		enc     sqlEncoder
+	)
+	err = plan.Execute(qctx, func(vals []interface{}) error {
+		if !started {
+			started = true
+			var err error
+			if enc, err = startSqlResponse(ctx, args.Format, plan.names); err != nil {
+				return err
+			}
+		}
+		if err := enc.encode(SqlRow{plan.names, vals, plan.outFields}, count); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if !started {
+		switch {
+		case err == errSqlRowLimit:
+			panic(server.ERequestTooLarge(server.EC_PARAM_INVALID, fmt.Sprintf("query scans more than %d rows, please narrow filters", ctx.Cfg.Http.MaxSqlRows), nil))
+		case err == context.DeadlineExceeded && ctx.Context.Err() == nil:
+			panic(server.ERequestTooLarge(server.EC_PARAM_INVALID, fmt.Sprintf("query exceeds time limit of %s", ctx.Cfg.Http.MaxSqlDuration), nil))
+		case err != nil:
+			if e, ok := err.(*server.Error); ok {
+				panic(e)
+			}
+			panic(server.EInternal(server.EC_DATABASE, "query failed", err))
+		}
+		enc, err = startSqlResponse(ctx, args.Format, plan.names)
+	}
+	if enc != nil {
+		enc.close()
+	}
+
+	// write error (except EOF) and count as http trailer
+	ctx.StreamTrailer("", count, err)
+
+	// streaming return
+	return nil, -1
+}
+
+// sqlEncoder writes output rows in the requested format.
+type sqlEncoder interface {
+	encode(row SqlRow, n int) error
+	close()
+}
+
+type sqlJsonEncoder struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func (e *sqlJsonEncoder) encode(row SqlRow, n int) error {
+	if n > 0 {
+		_, _ = io.WriteString(e.w, ",")
+	}
+	return e.enc.Encode(row)
+}
+
+func (e *sqlJsonEncoder) close() {
+	// close JSON bracket
+	_, _ = io.WriteString(e.w, "]")
+}
+
+type sqlCsvEncoder struct {
+	enc *csv.Encoder
+}
+
+func (e *sqlCsvEncoder) encode(row SqlRow, _ int) error {
+	return e.enc.EncodeRecord(row)
+}
+
+func (e *sqlCsvEncoder) close() {}
+
+// startSqlResponse sends response headers and opens the output stream.
+func startSqlResponse(ctx *server.Context, format string, names []string) (sqlEncoder, error) {
+	ctx.StreamResponseHeaders(http.StatusOK, mimetypes[format])
+	switch format {
+	case "csv":
+		enc := csv.NewEncoder(ctx.ResponseWriter)
+		return &sqlCsvEncoder{enc}, enc.EncodeHeader(names, nil)
+	default:
+		enc := json.NewEncoder(ctx.ResponseWriter)
+		enc.SetIndent("", "")
+		enc.SetEscapeHTML(false)
+		// open JSON array
+		_, _ = io.WriteString(ctx.ResponseWriter, "[")
+		return &sqlJsonEncoder{ctx.ResponseWriter, enc}, nil
+	}
+}
+
+// sqlSource is a table taking part in a query.
+type sqlSource struct {
+	alias  string
+	table  *pack.Table
+	fields pack.FieldList          // loaded columns in row order
+	conds  []pack.UnboundCondition // conditions pushed into table scans
+	left   bool                    // outer join, conditions cannot be pushed
+	on     [2]SqlColumn            // join columns [earlier table, this table]
+	limit  int                     // scan limit (simple queries only)
+	order  pack.OrderType          // scan order (simple queries only)
+}
+
+func (s *sqlSource) use(f pack.Field) int {
+	for i, v := range s.fields {
+		if v.Name == f.Name {
+			return i
+		}
+	}
+	s.fields = append(s.fields, f)
+	return len(s.fields) - 1
+}
+
+// sqlRow holds loaded values per source table, nil for unmatched outer joins.
+type sqlRow [][]interface{}
+
+func (r sqlRow) value(c SqlColumn) interface{} {
+	if r[c.table] == nil {
+		return nil
+	}
+	return r[c.table][c.pos]
+}
+
+type sqlPlan struct {
+	ctx       *server.Context
+	query     *SqlQuery
+	sources   []*sqlSource
+	where     []SqlExpr    // conditions evaluated after joins
+	items     []SqlItem    // output columns
+	names     []string     // output column names
+	outFields []pack.Field // output column source fields
+	order     []sqlSortKey // sort keys
+	hidden    []SqlColumn  // extra sort columns not in output
+	cursor    int          // position of base table primary key
+	scanned   int
+}
+
+type sqlSortKey struct {
+	pos  int
+	desc bool
+}
+
+func newSqlPlan(ctx *server.Context, q *SqlQuery) *sqlPlan {
+	p := &sqlPlan{ctx: ctx, query: q}
+	p.addSource(q.From, false)
+	for _, j := range q.Joins {
+		src := p.addSource(j.Table, j.Left)
+		n := len(p.sources)
+		left, right := j.On[0], j.On[1]
+		if p.tryBind(&right, n-1, n) != nil {
+			left, right = right, left
+		}
+		p.bind(&left, 0, n-1)
+		p.bind(&right, n-1, n)
+		if left.field.Type != right.field.Type && !(isSqlInt(left.field.Type) && isSqlInt(right.field.Type)) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("cannot join %s on %s with different types", left, right), nil))
+		}
+		src.on = [2]SqlColumn{left, right}
+	}
+
+	// output columns
+	if q.Star {
+		for _, s := range p.sources {
+			for _, f := range s.table.Fields() {
+				item := SqlItem{Col: SqlColumn{Qualifier: s.alias, Name: f.Alias}}
+				if len(p.sources) > 1 {
+					item.Alias = s.alias + "." + f.Alias
+				}
+				p.items = append(p.items, item)
+			}
+		}
+	} else {
+		p.items = append(p.items, q.Items...)
+	}
+	for i := range p.items {
+		item := &p.items[i]
+		if !item.Star {
+			p.bind(&item.Col, 0, len(p.sources))
+		}
+		p.names = append(p.names, item.Name())
+		switch item.Func {
+		case "", "min", "max":
+			p.outFields = append(p.outFields, item.Col.field)
+		default:
+			p.outFields = append(p.outFields, pack.Field{Index: -1})
+		}
+	}
+	seen := make(map[string]struct{})
+	for _, v := range p.names {
+		if _, ok := seen[v]; ok {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("duplicate output column '%s', please use an alias", v), nil))
+		}
+		seen[v] = struct{}{}
+	}
+
+	// group columns and aggregate checks
+	for i := range q.GroupBy {
+		p.bind(&q.GroupBy[i], 0, len(p.sources))
+	}
+	if q.Aggregate {
+		for _, item := range p.items {
+			if item.Func != "" {
+				continue
+			}
+			var found bool
+			for _, g := range q.GroupBy {
+				if g.table == item.Col.table && g.pos == item.Col.pos {
+					found = true
+					break
+				}
+			}
+			if !found {
+				panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("column '%s' must appear in GROUP BY or be used in an aggregate function", item.Col), nil))
+			}
+		}
+	}
+
+	// sort keys
+	for _, o := range q.OrderBy {
+		p.order = append(p.order, sqlSortKey{pos: p.bindOrder(o), desc: o.Desc})
+	}
+
+	// filters, push single-table conditions into table scans
+	if q.Where != nil {
+		for _, e := range splitSqlAnd(q.Where) {
+			e = p.bindExpr(e)
+			t := sqlExprTable(e)
+			if t >= 0 && !p.sources[t].left {
+				if cond, ok := p.condition(e); ok {
+					p.sources[t].conds = append(p.sources[t].conds, cond)
+					continue
+				}
+			}
+			p.where = append(p.where, e)
+		}
+	}
+
+	// read at least the primary key from every table, the base table's
+	// primary key is the cursor for batched scans
+	for _, s := range p.sources {
+		if len(s.fields) == 0 {
+			s.use(s.table.Fields().Pk())
+		}
+	}
+	p.cursor = p.sources[0].use(p.sources[0].table.Fields().Pk())
+
+	// simple queries stop scanning at the requested limit
+	base := p.sources[0]
+	if len(p.sources) == 1 && !q.Aggregate && len(p.where) == 0 {
+		switch {
+		case len(q.OrderBy) == 0:
+			base.limit = int(p.offset() + p.limit())
+		case len(q.OrderBy) == 1 && len(p.hidden) <= 1:
+			col := p.sortColumn(q.OrderBy[0])
+			if col.field.Flags.Contains(pack.FlagPrimary) {
+				base.limit = int(p.offset() + p.limit())
+				base.order = pack.OrderAsc
+				if q.OrderBy[0].Desc {
+					base.order = pack.OrderDesc
+				}
+			}
+		}
+	}
+	return p
+}
+
+func (p *sqlPlan) limit() uint {
+	if !p.query.HasLimit {
+		return p.ctx.Cfg.ClampList(0)
+	}
+	return p.ctx.Cfg.ClampList(p.query.Limit)
+}
+
+func (p *sqlPlan) offset() uint {
+	return p.query.Offset
+}
+
+func (p *sqlPlan) addSource(t SqlTable, left bool) *sqlSource {
+	if !sqlTables[t.Name] {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("no such table '%s'", t.Name), nil))
+	}
+	for _, s := range p.sources {
+		if s.alias == t.Alias {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("duplicate table alias '%s'", t.Alias), nil))
+		}
+	}
+	table, err := p.ctx.Indexer.Table(t.Name)
+	if err != nil {
+		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("cannot access table '%s'", t.Name), err))
+	}
+	src := &sqlSource{
+		alias: t.Alias,
+		table: table,
+		left:  left,
+		order: pack.OrderAsc,
+	}
+	p.sources = append(p.sources, src)
+	return src
+}
+
+// tryBind resolves a column reference against sources in range [from:to].
+func (p *sqlPlan) tryBind(c *SqlColumn, from, to int) error {
+	var found int
+	for i := from; i < to; i++ {
+		s := p.sources[i]
+		if c.Qualifier != "" && c.Qualifier != s.alias {
+			continue
+		}
+		f := s.table.Fields().Find(c.Name)
+		if !f.IsValid() {
+			continue
+		}
+		found++
+		c.table = i
+		c.field = f
+	}
+	switch {
+	case found == 0:
+		return fmt.Errorf("unknown column '%s'", c)
+	case found > 1:
+		return fmt.Errorf("ambiguous column '%s'", c)
+	}
+	c.pos = p.sources[c.table].use(c.field)
+	return nil
+}
+
+func (p *sqlPlan) bind(c *SqlColumn, from, to int) {
+	if err := p.tryBind(c, from, to); err != nil {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, err.Error(), nil))
+	}
+}
+
+func (p *sqlPlan) bindExpr(e SqlExpr) SqlExpr {
+	switch x := e.(type) {
+	case SqlAnd:
+		return SqlAnd{p.bindExpr(x.Left), p.bindExpr(x.Right)}
+	case SqlOr:
+		return SqlOr{p.bindExpr(x.Left), p.bindExpr(x.Right)}
+	case SqlNot:
+		return SqlNot{p.bindExpr(x.Expr)}
+	case SqlCompare:
+		p.bind(&x.Col, 0, len(p.sources))
+		if x.Other != nil {
+			other := *x.Other
+			p.bind(&other, 0, len(p.sources))
+			x.Other = &other
+		} else {
+			x.vals = p.literals(x)
+		}
+		return x
+	}
+	return e
+}
+
+// bindOrder returns the position of a sort column in output rows, adding
+// hidden columns for plain queries when required.
+func (p *sqlPlan) bindOrder(o SqlOrder) int {
+	if o.Pos > 0 {
+		if o.Pos > len(p.items) {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid ORDER BY position %d", o.Pos), nil))
+		}
+		return o.Pos - 1
+	}
+	if o.Col.Qualifier == "" {
+		for i, v := range p.names {
+			if v == o.Col.Name {
+				return i
+			}
+		}
+	}
+	col := o.Col
+	p.bind(&col, 0, len(p.sources))
+	for i, item := range p.items {
+		if item.Func == "" && item.Col.table == col.table && item.Col.pos == col.pos {
+			return i
+		}
+	}
+	if p.query.Aggregate {
+		panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("cannot order by '%s' which is not part of the result", o.Col), nil))
+	}
+	p.hidden = append(p.hidden, col)
+	return len(p.items) + len(p.hidden) - 1
+}
+
+func (p *sqlPlan) sortColumn(o SqlOrder) SqlColumn {
+	if o.Pos > 0 {
+		return p.items[o.Pos-1].Col
+	}
+	pos := p.order[0].pos
+	if pos < len(p.items) {
+		return p.items[pos].Col
+	}
+	return p.hidden[pos-len(p.items)]
+}
+
+func splitSqlAnd(e SqlExpr) []SqlExpr {
+	if x, ok := e.(SqlAnd); ok {
+		return append(splitSqlAnd(x.Left), splitSqlAnd(x.Right)...)
+	}
+	return []SqlExpr{e}
+}
+
+// sqlExprTable returns the single source table an expression refers to
+// or -1 when it spans multiple tables.
+func sqlExprTable(e SqlExpr) int {
+	switch x := e.(type) {
+	case SqlAnd:
+		l, r := sqlExprTable(x.Left), sqlExprTable(x.Right)
+		if l != r {
+			return -1
+		}
+		return l
+	case SqlOr:
+		l, r := sqlExprTable(x.Left), sqlExprTable(x.Right)
+		if l != r {
+			return -1
+		}
+		return l
+	case SqlNot:
+		return sqlExprTable(x.Expr)
+	case SqlCompare:
+		if x.Other != nil && x.Other.table != x.Col.table {
+			return -1
+		}
+		return x.Col.table
+	}
+	return -1
+}
+
+// condition translates an expression into a packdb condition when possible.
+func (p *sqlPlan) condition(e SqlExpr) (pack.UnboundCondition, bool) {
+	switch x := e.(type) {
+	case SqlAnd:
+		l, ok1 := p.condition(x.Left)
+		r, ok2 := p.condition(x.Right)
+		return pack.And(l, r), ok1 && ok2
+	case SqlOr:
+		l, ok1 := p.condition(x.Left)
+		r, ok2 := p.condition(x.Right)
+		return pack.Or(l, r), ok1 && ok2
+	case SqlCompare:
+		if x.Other != nil {
+			break
+		}
+		vals := x.vals
+		c := pack.UnboundCondition{
+			Name: x.Col.field.Name,
+			Mode: x.Mode,
+		}
+		switch x.Mode {
+		case pack.FilterModeRange:
+			c.From, c.To = vals[0], vals[1]
+		case pack.FilterModeIn, pack.FilterModeNotIn:
+			slice, ok := sqlSlice(x.Col.field.Type, vals)
+			if !ok {
+				break
+			}
+			c.Value = slice
+		default:
+			c.Value = vals[0]
+		}
+		if c.Value == nil && c.From == nil {
+			break
+		}
+		return c, true
+	}
+	return pack.UnboundCondition{}, false
+}
+
+// literals converts literal values of a comparison into the column type.
+func (p *sqlPlan) literals(c SqlCompare) []interface{} {
+	vals := make([]interface{}, len(c.Values))
+	for i, lit := range c.Values {
+		v, err := p.literal(c.Col.field, lit)
+		if err != nil {
+			panic(server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("invalid value '%s' for column '%s'", lit.Text, c.Col), err))
+		}
+		vals[i] = v
+	}
+	return vals
+}
+
+func (p *sqlPlan) literal(f pack.Field, lit SqlLiteral) (interface{}, error) {
+	switch f.Type {
+	case pack.FieldTypeUint64:
+		// resolve addresses into account ids
+		if lit.String {
+			if addr, err := tezos.ParseAddress(lit.Text); err == nil {
+				acc, err := p.ctx.Indexer.LookupAccount(p.ctx, addr)
+				if err != nil && err != index.ErrNoAccountEntry {
+					return nil, err
+				}
+				// Note: when not found we insert an always false condition
+				if acc == nil || acc.RowId == 0 {
+					return uint64(math.MaxUint64), nil
+				}
+				return acc.RowId.Value(), nil
+			}
+		}
+	case pack.FieldTypeBytes:
+		if addr, err := tezos.ParseAddress(lit.Text); err == nil {
+			return addr.MarshalBinary()
+		}
+		if buf, err := hex.DecodeString(lit.Text); err == nil {
+			return buf, nil
+		}
+		return []byte(lit.Text), nil
+	}
+	return f.Type.ParseAs(lit.Text)
+}
+
+func sqlSlice(typ pack.FieldType, vals []interface{}) (interface{}, bool) {
+	switch typ {
+	case pack.FieldTypeUint64:
+		s := make([]uint64, len(vals))
+		for i, v := range vals {
+			s[i] = v.(uint64)
+		}
+		return s, true
+	case pack.FieldTypeInt64:
+		s := make([]int64, len(vals))
+		for i, v := range vals {
+			s[i] = v.(int64)
+		}
+		return s, true
+	case pack.FieldTypeString:
+		s := make([]string, len(vals))
+		for i, v := range vals {
+			s[i] = v.(string)
+		}
+		return s, true
+	case pack.FieldTypeBytes:
+		s := make([][]byte, len(vals))
+		for i, v := range vals {
+			s[i] = v.([]byte)
+		}
+		return s, true
+	}
+	return nil, false
+}
+
+func isSqlInt(typ pack.FieldType) bool {
+	return typ == pack.FieldTypeInt64 || typ == pack.FieldTypeUint64
+}
+
+// scan streams up to limit matching rows from a source table.
+func (p *sqlPlan) scan(ctx context.Context, s *sqlSource, extra []pack.UnboundCondition, limit int, fn func([]interface{}) error) error {
+	q := pack.NewQuery(p.ctx.RequestID).
+		WithTable(s.table).
+		WithFields(s.fields.Names()...).
+		WithOrder(s.order)
+	if len(s.conds) > 0 {
+		q = q.AndCondition(s.conds...)
+	}
+	if len(extra) > 0 {
+		q = q.AndCondition(extra...)
+	}
+	var n int
+	err := s.table.Stream(ctx, q, func(r pack.Row) error {
+		p.scanned++
+		if max := p.ctx.Cfg.Http.MaxSqlRows; max > 0 && p.scanned > max {
+			return errSqlRowLimit
+		}
+		vals := make([]interface{}, len(s.fields))
+		for i, f := range s.fields {
+			v, err := r.Field(f.Name)
+			if err != nil {
+				return err
+			}
+			// detach from pack memory
+			switch x := v.(type) {
+			case []byte:
+				v = append([]byte(nil), x...)
+			case string:
+				v = strings.Clone(x)
+			}
+			vals[i] = v
+		}
+		if err := fn(vals); err != nil {
+			return err
+		}
+		n++
+		if limit > 0 && n >= limit {
+			return errSqlLimit
+		}
+		return nil
+	})
+	if err == errSqlLimit || err == io.EOF {
+		err = nil
+	}
+	return err
+}
+
+// Execute runs the query and calls fn for every output row. The base table
+// is scanned in batches of sqlBatchSize rows which are joined, filtered and
+// passed on before the next batch is loaded. Plain queries stream rows as
+// they are produced, only aggregate groups and the top offset+limit rows of
+// sorted queries are kept in memory.
+func (p *sqlPlan) Execute(ctx context.Context, fn func([]interface{}) error) error {
+	var sink sqlSink
+	switch {
+	case p.query.Aggregate:
+		sink = newSqlAggregator(p)
+	case len(p.order) > 0:
+		sink = &sqlSorter{plan: p, max: int(p.offset() + p.limit())}
+	default:
+		sink = &sqlStreamer{plan: p, offset: int(p.offset()), limit: int(p.limit()), fn: fn}
+	}
+
+	var (
+		base    = p.sources[0]
+		pk      = base.table.Fields().Pk()
+		total   int
+		last    uint64
+		hasLast bool
+	)
+	for {
+		// continue after the last primary key seen
+		var extra []pack.UnboundCondition
+		if hasLast {
+			if base.order == pack.OrderDesc {
+				extra = append(extra, pack.Lt(pk.Name, last))
+			} else {
+				extra = append(extra, pack.Gt(pk.Name, last))
+			}
+		}
+		n := sqlBatchSize
+		if base.limit > 0 && base.limit-total < n {
+			n = base.limit - total
+		}
+		rows := make([]sqlRow, 0, n)
+		err := p.scan(ctx, base, extra, n, func(vals []interface{}) error {
+			row := make(sqlRow, len(p.sources))
+			row[0] = vals
+			rows = append(rows, row)
+			last, hasLast = vals[p.cursor].(uint64), true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		total += len(rows)
+		more := len(rows) == n && (base.limit == 0 || total < base.limit)
+
+		// run joins
+		for i := 1; i < len(p.sources) && len(rows) > 0; i++ {
+			rows, err = p.join(ctx, i, rows)
+			if err != nil {
+				return err
+			}
+		}
+
+		// apply remaining filters
+		for _, row := range rows {
+			if !p.match(row) {
+				continue
+			}
+			if err := sink.add(row); err != nil {
+				if err == errSqlLimit {
+					return ctx.Err()
+				}
+				return err
+			}
+		}
+		if !more {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	if err := sink.flush(fn); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func (p *sqlPlan) match(row sqlRow) bool {
+	for _, e := range p.where {
+		if !p.eval(e, row) {
+			return false
+		}
+	}
+	return true
+}
+
+// project returns output values followed by hidden sort columns.
+func (p *sqlPlan) project(row sqlRow) []interface{} {
+	vals := make([]interface{}, 0, len(p.items)+len(p.hidden))
+	for _, item := range p.items {
+		vals = append(vals, row.value(item.Col))
+	}
+	for _, col := range p.hidden {
+		vals = append(vals, row.value(col))
+	}
+	return vals
+}
+
+// less compares output rows by sort keys.
+func (p *sqlPlan) less(a, b []interface{}) bool {
+	for _, k := range p.order {
+		c := compareSqlValues(a[k.pos], b[k.pos])
+		if c == 0 {
+			continue
+		}
+		if k.desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+// emit sorts rows when required, applies offset and limit and passes
+// output columns to fn.
+func (p *sqlPlan) emit(rows [][]interface{}, fn func([]interface{}) error) error {
+	if len(p.order) > 0 {
+		sort.SliceStable(rows, func(i, j int) bool { return p.less(rows[i], rows[j]) })
+	}
+	if offset := int(p.offset()); offset > 0 {
+		if offset > len(rows) {
+			offset = len(rows)
+		}
+		rows = rows[offset:]
+	}
+	if limit := int(p.limit()); limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	for _, v := range rows {
+		if err := fn(v[:len(p.items)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlSink consumes joined and filtered rows.
+type sqlSink interface {
+	add(row sqlRow) error
+	flush(fn func([]interface{}) error) error
+}
+
+// sqlStreamer passes rows of unsorted plain queries on immediately.
+type sqlStreamer struct {
+	plan   *sqlPlan
+	offset int
+	limit  int
+	fn     func([]interface{}) error
+}
+
+func (s *sqlStreamer) add(row sqlRow) error {
+	if s.offset > 0 {
+		s.offset--
+		return nil
+	}
+	if err := s.fn(s.plan.project(row)[:len(s.plan.items)]); err != nil {
+		return err
+	}
+	if s.limit--; s.limit == 0 {
+		return errSqlLimit
+	}
+	return nil
+}
+
+func (s *sqlStreamer) flush(_ func([]interface{}) error) error {
+	return nil
+}
+
+// sqlSorter keeps the first max rows of sorted plain queries.
+type sqlSorter struct {
+	plan *sqlPlan
+	max  int
+	rows [][]interface{}
+}
+
+func (s *sqlSorter) add(row sqlRow) error {
+	s.rows = append(s.rows, s.plan.project(row))
+	if s.max > 0 && len(s.rows) >= 2*s.max {
+		s.truncate()
+	}
+	return nil
+}
+
+// truncate drops rows that cannot be part of the result. Stable sorting
+// keeps earlier rows ahead of equal later ones.
+func (s *sqlSorter) truncate() {
+	sort.SliceStable(s.rows, func(i, j int) bool { return s.plan.less(s.rows[i], s.rows[j]) })
+	for i := s.max; i < len(s.rows); i++ {
+		s.rows[i] = nil
+	}
+	s.rows = s.rows[:s.max]
+}
+
+func (s *sqlSorter) flush(fn func([]interface{}) error) error {
+	return s.plan.emit(s.rows, fn)
+}
+
+// join matches rows against the source table at position i using its join
+// column. Join keys are loaded in a single scan using an IN condition.
+func (p *sqlPlan) join(ctx context.Context, i int, rows []sqlRow) ([]sqlRow, error) {
+	s := p.sources[i]
+	left, right := s.on[0], s.on[1]
+
+	// collect unique join keys in right column type
+	keys := make([]interface{}, 0)
+	seen := make(map[interface{}]struct{})
+	for _, row := range rows {
+		v := convertSqlValue(row.value(left), right.field.Type)
+		if v == nil {
+			continue
+		}
+		k := sqlKey(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		keys = append(keys, v)
+	}
+
+	// load matching rows
+	matches := make(map[interface{}][][]interface{})
+	if len(keys) > 0 {
+		slice, ok := sqlSlice(right.field.Type, keys)
+		if !ok {
+			return nil, server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("unsupported join column '%s'", right), nil)
+		}
+		cond := pack.UnboundCondition{Name: right.field.Name, Mode: pack.FilterModeIn, Value: slice}
+		err := p.scan(ctx, s, []pack.UnboundCondition{cond}, 0, func(vals []interface{}) error {
+			k := sqlKey(vals[right.pos])
+			matches[k] = append(matches[k], vals)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// merge
+	res := make([]sqlRow, 0, len(rows))
+	for _, row := range rows {
+		v := convertSqlValue(row.value(left), right.field.Type)
+		var list [][]interface{}
+		if v != nil {
+			list = matches[sqlKey(v)]
+		}
+		if len(list) == 0 {
+			if s.left {
+				res = append(res, row)
+			}
+			continue
+		}
+		for _, m := range list {
+			r := make(sqlRow, len(row))
+			copy(r, row)
+			r[i] = m
+			res = append(res, r)
+		}
+		if p.ctx.Cfg.Http.MaxSqlRows > 0 && len(res) > p.ctx.Cfg.Http.MaxSqlRows {
+			return nil, errSqlRowLimit
+		}
+	}
+	return res, nil
+}
+
+func (p *sqlPlan) eval(e SqlExpr, row sqlRow) bool {
+	switch x := e.(type) {
+	case SqlAnd:
+		return p.eval(x.Left, row) && p.eval(x.Right, row)
+	case SqlOr:
+		return p.eval(x.Left, row) || p.eval(x.Right, row)
+	case SqlNot:
+		return !p.eval(x.Expr, row)
+	case SqlCompare:
+		v := row.value(x.Col)
+		if v == nil {
+			return false
+		}
+		var vals []interface{}
+		if x.Other != nil {
+			o := row.value(*x.Other)
+			if o == nil {
+				return false
+			}
+			vals = []interface{}{o}
+		} else {
+			vals = x.vals
+		}
+		switch x.Mode {
+		case pack.FilterModeEqual:
+			return compareSqlValues(v, vals[0]) == 0
+		case pack.FilterModeNotEqual:
+			return compareSqlValues(v, vals[0]) != 0
+		case pack.FilterModeGt:
+			return compareSqlValues(v, vals[0]) > 0
+		case pack.FilterModeGte:
+			return compareSqlValues(v, vals[0]) >= 0
+		case pack.FilterModeLt:
+			return compareSqlValues(v, vals[0]) < 0
+		case pack.FilterModeLte:
+			return compareSqlValues(v, vals[0]) <= 0
+		case pack.FilterModeRange:
+			return compareSqlValues(v, vals[0]) >= 0 && compareSqlValues(v, vals[1]) <= 0
+		case pack.FilterModeIn, pack.FilterModeNotIn:
+			var found bool
+			for _, val := range vals {
+				if compareSqlValues(v, val) == 0 {
+					found = true
+					break
+				}
+			}
+			return found == (x.Mode == pack.FilterModeIn)
+		}
+	}
+	return false
+}
+
+// sqlGroup holds aggregate state per group.
+type sqlGroup struct {
+	keys     []interface{}
+	count    []int64
+	sums     []aggregateSum
+	minmax   []interface{}
+	distinct []map[interface{}]struct{}
+}
+
+// sqlAggregator folds rows into aggregate groups.
+type sqlAggregator struct {
+	plan   *sqlPlan
+	groups map[string]*sqlGroup
+	list   []*sqlGroup
+	keybuf bytes.Buffer
+	cells  int
+}
+
+func newSqlAggregator(p *sqlPlan) *sqlAggregator {
+	return &sqlAggregator{
+		plan:   p,
+		groups: make(map[string]*sqlGroup),
+		list:   make([]*sqlGroup, 0),
+	}
+}
+
+func (a *sqlAggregator) newGroup() *sqlGroup {
+	p := a.plan
+	g := &sqlGroup{
+		keys:     make([]interface{}, len(p.query.GroupBy)),
+		count:    make([]int64, len(p.items)),
+		sums:     make([]aggregateSum, len(p.items)),
+		minmax:   make([]interface{}, len(p.items)),
+		distinct: make([]map[interface{}]struct{}, len(p.items)),
+	}
+	for i := range g.sums {
+		g.sums[i].isInt = true
+	}
+	return g
+}
+
+func (a *sqlAggregator) add(row sqlRow) error {
+	p := a.plan
+	maxCells := p.ctx.Cfg.Http.MaxAggregateGroups
+	a.keybuf.Reset()
+	for _, c := range p.query.GroupBy {
+		fmt.Fprint(&a.keybuf, row.value(c))
+		a.keybuf.WriteByte(0)
+	}
+	g, ok := a.groups[a.keybuf.String()]
+	if !ok {
+		a.cells++
+		if maxCells > 0 && a.cells > maxCells {
+			return server.ERequestTooLarge(server.EC_PARAM_INVALID, fmt.Sprintf("query exceeds %d groups, please narrow filters", maxCells), nil)
+		}
+		g = a.newGroup()
+		for i, c := range p.query.GroupBy {
+			g.keys[i] = row.value(c)
+		}
+		a.groups[a.keybuf.String()] = g
+		a.list = append(a.list, g)
+	}
+	for i, item := range p.items {
+		if item.Func == "" {
+			continue
+		}
+		if item.Star {
+			g.count[i]++
+			continue
+		}
+		v := row.value(item.Col)
+		if v == nil {
+			continue
+		}
+		g.count[i]++
+		switch item.Func {
+		case "count":
+			if item.Distinct {
+				if g.distinct[i] == nil {
+					g.distinct[i] = make(map[interface{}]struct{})
+				}
+				k := sqlKey(v)
+				if _, ok := g.distinct[i][k]; !ok {
+					a.cells++
+					if maxCells > 0 && a.cells > maxCells {
+						return server.ERequestTooLarge(server.EC_PARAM_INVALID, fmt.Sprintf("query exceeds %d distinct values, please narrow filters", maxCells), nil)
+					}
+					g.distinct[i][k] = struct{}{}
+				}
+			}
+		case "sum", "avg":
+			if !g.sums[i].Add(v) {
+				return server.EBadRequest(server.EC_PARAM_INVALID, fmt.Sprintf("cannot sum non-numeric column '%s'", item.Col), nil)
+			}
+		case "min":
+			if g.minmax[i] == nil || compareSqlValues(v, g.minmax[i]) < 0 {
+				g.minmax[i] = v
+			}
+		case "max":
+			if g.minmax[i] == nil || compareSqlValues(v, g.minmax[i]) > 0 {
+				g.minmax[i] = v
+			}
+		}
+	}
+	return nil
+}
+
+func (a *sqlAggregator) flush(fn func([]interface{}) error) error {
+	p := a.plan
+
+	// aggregates without groups always return a single row
+	if len(p.query.GroupBy) == 0 && len(a.list) == 0 {
+		a.list = append(a.list, a.newGroup())
+	}
+
+	out := make([][]interface{}, len(a.list))
+	for n, g := range a.list {
+		vals := make([]interface{}, len(p.items))
+		for i, item := range p.items {
+			switch item.Func {
+			case "":
+				for k, c := range p.query.GroupBy {
+					if c.table == item.Col.table && c.pos == item.Col.pos {
+						vals[i] = g.keys[k]
+						break
+					}
+				}
+			case "count":
+				if item.Distinct {
+					vals[i] = int64(len(g.distinct[i]))
+				} else {
+					vals[i] = g.count[i]
+				}
+			case "sum":
+				if g.count[i] > 0 {
+					vals[i] = g.sums[i].Value()
+				}
+			case "avg":
+				if g.count[i] > 0 {
+					sum := g.sums[i]
+					if sum.isInt {
+						sum.f = float64(sum.i)
+					}
+					vals[i] = sum.f / float64(g.count[i])
+				}
+			case "min", "max":
+				vals[i] = g.minmax[i]
+			}
+		}
+		out[n] = vals
+	}
+	return p.emit(out, fn)
+}
+
+// sqlKey returns a comparable map key for a column value.
+func sqlKey(v interface{}) interface{} {
+	switch x := v.(type) {
+	case []byte:
+		return string(x)
+	case time.Time:
+		return x.UnixNano()
+	}
+	return v
+}
+
+// convertSqlValue converts integer join keys between signed and unsigned.
+func convertSqlValue(v interface{}, typ pack.FieldType) interface{} {
+	switch x := v.(type) {
+	case int64:
+		if typ == pack.FieldTypeUint64 {
+			if x < 0 {
+				return nil
+			}
+			return uint64(x)
+		}
+	case uint64:
+		if typ == pack.FieldTypeInt64 {
+			if x > math.MaxInt64 {
+				return nil
+			}
+			return int64(x)
+		}
+	}
+	return v
+}
+
+// compareSqlValues orders values of the same type; numbers of different
+// types compare as floats and nulls sort first.
+func compareSqlValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch x := a.(type) {
+	case uint64:
+		if y, ok := b.(uint64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case int64:
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y)
+		}
+	case []byte:
+		if y, ok := b.([]byte); ok {
+			return bytes.Compare(x, y)
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			switch {
+			case x == y:
+				return 0
+			case !x:
+				return -1
+			}
+			return 1
+		}
+	}
+	fa, oka := sqlFloat(a)
+	fb, okb := sqlFloat(b)
+	if oka && okb {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func sqlFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// SqlRow is a single query result row.
+type SqlRow struct {
+	columns []string
+	values  []interface{}
+	fields  []pack.Field
+}
+
+// format converts raw column values for output.
+func (r SqlRow) format(i int) interface{} {
+	switch x := r.values[i].(type) {
+	case []byte:
+		// decode address columns
+		if r.fields[i].Alias == "address" {
+			var a tezos.Address
+			if err := a.UnmarshalBinary(x); err == nil && a.IsValid() {
+				return a.String()
+			}
+		}
+		return hex.EncodeToString(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil
+		}
+	}
+	return r.values[i]
+}
+
+func (r SqlRow) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	for i, v := range r.columns {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendQuote(buf, v)
+		buf = append(buf, ':')
+		val, err := json.Marshal(r.format(i))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, val...)
+	}
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+func (r SqlRow) MarshalCSV() ([]string, error) {
+	res := make([]string, len(r.values))
+	for i := range r.values {
+		switch val := r.format(i).(type) {
+		case nil:
+			res[i] = ""
+		case string:
+			res[i] = strconv.Quote(val)
+		case float64:
+			res[i] = strconv.FormatFloat(val, 'f', -1, 64)
+		default:
+			res[i] = fmt.Sprint(val)
+		}
+	}
+	return res, nil
+}
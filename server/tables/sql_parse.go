@@ -0,0 +1,619 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"blockwatch.cc/packdb/pack"
+)
+
+// A minimal SQL dialect for read-only table queries:
+//
+//	SELECT <item>[, <item>...] | *
+//	FROM <table> [[AS] <alias>]
+//	[[INNER|LEFT] JOIN <table> [[AS] <alias>] ON <col> = <col>]...
+//	[WHERE <predicate>]
+//	[GROUP BY <col>[, <col>...]]
+//	[ORDER BY <col|alias|position> [ASC|DESC][, ...]]
+//	[LIMIT <n> [OFFSET <n>]]
+//
+// Items are columns or one of COUNT(*), COUNT([DISTINCT] col), SUM(col),
+// MIN(col), MAX(col), AVG(col), each with an optional alias. Predicates
+// combine comparisons (=, !=, <>, <, <=, >, >=), [NOT] IN (...) and
+// BETWEEN .. AND .. with AND, OR, NOT and parentheses.
+
+type sqlTokenKind byte
+
+const (
+	sqlTokenEOF sqlTokenKind = iota
+	sqlTokenIdent
+	sqlTokenNumber
+	sqlTokenString
+	sqlTokenSymbol
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+func (t sqlToken) String() string {
+	switch t.kind {
+	case sqlTokenEOF:
+		return "end of query"
+	case sqlTokenString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+func sqlLex(s string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0, 32)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			// line comment
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case isSqlIdentStart(c):
+			start := i
+			for i < len(s) && isSqlIdent(s[i]) {
+				i++
+			}
+			tokens = append(tokens, sqlToken{sqlTokenIdent, s[start:i], start})
+		case c == '"' || c == '`':
+			// quoted identifier
+			start := i
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated identifier at position %d", start)
+			}
+			tokens = append(tokens, sqlToken{sqlTokenIdent, s[i+1 : i+1+end], start})
+			i += end + 2
+		case isSqlDigit(c) || (c == '-' && i+1 < len(s) && isSqlDigit(s[i+1])):
+			start := i
+			i++
+			for i < len(s) && (isSqlDigit(s[i]) || s[i] == '.' || s[i] == 'e' || s[i] == 'E') {
+				i++
+			}
+			tokens = append(tokens, sqlToken{sqlTokenNumber, s[start:i], start})
+		case c == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+			tokens = append(tokens, sqlToken{sqlTokenString, b.String(), start})
+		default:
+			start := i
+			switch {
+			case strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="),
+				strings.HasPrefix(s[i:], "!="), strings.HasPrefix(s[i:], "<>"):
+				i += 2
+			case strings.IndexByte(",()*.=<>;", c) >= 0:
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+			}
+			tokens = append(tokens, sqlToken{sqlTokenSymbol, s[start:i], start})
+		}
+	}
+	tokens = append(tokens, sqlToken{kind: sqlTokenEOF, pos: len(s)})
+	return tokens, nil
+}
+
+func isSqlIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSqlIdent(c byte) bool {
+	return isSqlIdentStart(c) || isSqlDigit(c)
+}
+
+func isSqlDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// words that cannot be used as implicit table aliases
+var sqlKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "group": true, "by": true,
+	"order": true, "limit": true, "offset": true, "join": true, "inner": true,
+	"left": true, "outer": true, "on": true, "as": true, "and": true,
+	"or": true, "not": true, "in": true, "between": true, "asc": true,
+	"desc": true, "distinct": true,
+}
+
+type SqlQuery struct {
+	Items     []SqlItem
+	Star      bool
+	From      SqlTable
+	Joins     []SqlJoin
+	Where     SqlExpr
+	GroupBy   []SqlColumn
+	OrderBy   []SqlOrder
+	Limit     uint
+	Offset    uint
+	HasLimit  bool
+	Aggregate bool
+}
+
+type SqlTable struct {
+	Name  string
+	Alias string
+}
+
+type SqlJoin struct {
+	Table SqlTable
+	Left  bool
+	On    [2]SqlColumn
+}
+
+// SqlColumn references a table column, optionally qualified by table name
+// or alias. Table and Field are resolved during binding.
+type SqlColumn struct {
+	Qualifier string
+	Name      string
+
+	table int
+	pos   int
+	field pack.Field
+}
+
+func (c SqlColumn) String() string {
+	if c.Qualifier != "" {
+		return c.Qualifier + "." + c.Name
+	}
+	return c.Name
+}
+
+type SqlItem struct {
+	Func     string // empty for plain columns
+	Distinct bool
+	Star     bool // COUNT(*)
+	Col      SqlColumn
+	Alias    string
+}
+
+func (i SqlItem) Name() string {
+	switch {
+	case i.Alias != "":
+		return i.Alias
+	case i.Func == "":
+		return i.Col.Name
+	case i.Star:
+		return i.Func
+	case i.Distinct:
+		return i.Func + "_distinct_" + i.Col.Name
+	default:
+		return i.Func + "_" + i.Col.Name
+	}
+}
+
+type SqlOrder struct {
+	Col  SqlColumn
+	Pos  int // 1-based output column position
+	Desc bool
+}
+
+type SqlExpr interface{}
+
+type SqlAnd struct{ Left, Right SqlExpr }
+type SqlOr struct{ Left, Right SqlExpr }
+type SqlNot struct{ Expr SqlExpr }
+
+// SqlCompare matches a column against literal values or another column.
+type SqlCompare struct {
+	Col    SqlColumn
+	Mode   pack.FilterMode
+	Values []SqlLiteral
+	Other  *SqlColumn
+
+	vals []interface{} // values converted to column type
+}
+
+type SqlLiteral struct {
+	Text   string
+	String bool // quoted
+}
+
+type sqlParser struct {
+	tokens []sqlToken
+	pos    int
+}
+
+func ParseSql(s string) (q *SqlQuery, err error) {
+	tokens, err := sqlLex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{tokens: tokens}
+	defer func() {
+		if e := recover(); e != nil {
+			perr, ok := e.(sqlError)
+			if !ok {
+				panic(e)
+			}
+			q, err = nil, perr
+		}
+	}()
+	q = p.parseQuery()
+	return q, nil
+}
+
+type sqlError string
+
+func (e sqlError) Error() string { return string(e) }
+
+func (p *sqlParser) fail(format string, args ...interface{}) {
+	panic(sqlError(fmt.Sprintf(format, args...)))
+}
+
+func (p *sqlParser) peek() sqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sqlParser) next() sqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != sqlTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sqlParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != sqlTokenIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sqlParser) acceptKeyword(word string) bool {
+	if p.isKeyword(word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectKeyword(word string) {
+	if !p.acceptKeyword(word) {
+		p.fail("expected %s at position %d, found %s", strings.ToUpper(word), p.peek().pos, p.peek())
+	}
+}
+
+func (p *sqlParser) isSymbol(sym string) bool {
+	t := p.peek()
+	return t.kind == sqlTokenSymbol && t.text == sym
+}
+
+func (p *sqlParser) acceptSymbol(sym string) bool {
+	if p.isSymbol(sym) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expectSymbol(sym string) {
+	if !p.acceptSymbol(sym) {
+		p.fail("expected '%s' at position %d, found %s", sym, p.peek().pos, p.peek())
+	}
+}
+
+func (p *sqlParser) ident() string {
+	t := p.next()
+	if t.kind != sqlTokenIdent || sqlKeywords[strings.ToLower(t.text)] {
+		p.fail("expected identifier at position %d, found %s", t.pos, t)
+	}
+	return t.text
+}
+
+func (p *sqlParser) number() uint {
+	t := p.next()
+	n, err := strconv.ParseUint(t.text, 10, 32)
+	if t.kind != sqlTokenNumber || err != nil {
+		p.fail("expected positive integer at position %d, found %s", t.pos, t)
+	}
+	return uint(n)
+}
+
+func (p *sqlParser) parseQuery() *SqlQuery {
+	q := &SqlQuery{}
+	p.expectKeyword("select")
+	if p.acceptSymbol("*") {
+		q.Star = true
+	} else {
+		for {
+			q.Items = append(q.Items, p.parseItem())
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	p.expectKeyword("from")
+	q.From = p.parseTable()
+	for {
+		ok, left := p.parseJoinKind()
+		if !ok {
+			break
+		}
+		j := SqlJoin{Table: p.parseTable(), Left: left}
+		p.expectKeyword("on")
+		j.On[0] = p.parseColumn()
+		p.expectSymbol("=")
+		j.On[1] = p.parseColumn()
+		q.Joins = append(q.Joins, j)
+	}
+	if p.acceptKeyword("where") {
+		q.Where = p.parseOr()
+	}
+	if p.acceptKeyword("group") {
+		p.expectKeyword("by")
+		for {
+			q.GroupBy = append(q.GroupBy, p.parseColumn())
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("order") {
+		p.expectKeyword("by")
+		for {
+			var o SqlOrder
+			if p.peek().kind == sqlTokenNumber {
+				o.Pos = int(p.number())
+			} else {
+				o.Col = p.parseColumn()
+			}
+			if p.acceptKeyword("desc") {
+				o.Desc = true
+			} else {
+				p.acceptKeyword("asc")
+			}
+			q.OrderBy = append(q.OrderBy, o)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("limit") {
+		q.Limit = p.number()
+		q.HasLimit = true
+		if p.acceptKeyword("offset") {
+			q.Offset = p.number()
+		}
+	}
+	p.acceptSymbol(";")
+	if t := p.peek(); t.kind != sqlTokenEOF {
+		p.fail("unexpected %s at position %d", t, t.pos)
+	}
+	for _, v := range q.Items {
+		if v.Func != "" {
+			q.Aggregate = true
+		}
+	}
+	q.Aggregate = q.Aggregate || len(q.GroupBy) > 0
+	return q
+}
+
+func (p *sqlParser) parseJoinKind() (ok, left bool) {
+	switch {
+	case p.acceptKeyword("left"):
+		left = true
+		p.acceptKeyword("outer")
+	case p.acceptKeyword("inner"):
+	case !p.isKeyword("join"):
+		return false, false
+	}
+	p.expectKeyword("join")
+	return true, left
+}
+
+func (p *sqlParser) parseTable() SqlTable {
+	t := SqlTable{Name: strings.ToLower(p.ident())}
+	if p.acceptKeyword("as") {
+		t.Alias = p.ident()
+	} else if tok := p.peek(); tok.kind == sqlTokenIdent && !sqlKeywords[strings.ToLower(tok.text)] {
+		t.Alias = p.ident()
+	}
+	if t.Alias == "" {
+		t.Alias = t.Name
+	}
+	return t
+}
+
+func (p *sqlParser) parseColumn() SqlColumn {
+	var c SqlColumn
+	c.Name = p.ident()
+	if p.acceptSymbol(".") {
+		c.Qualifier = c.Name
+		c.Name = p.ident()
+	}
+	return c
+}
+
+func (p *sqlParser) parseItem() SqlItem {
+	var item SqlItem
+	t := p.peek()
+	fn := strings.ToLower(t.text)
+	if t.kind == sqlTokenIdent && p.tokens[p.pos+1].kind == sqlTokenSymbol && p.tokens[p.pos+1].text == "(" {
+		switch fn {
+		case "count", "sum", "min", "max", "avg":
+		default:
+			p.fail("unsupported function '%s' at position %d", t.text, t.pos)
+		}
+		p.pos += 2
+		item.Func = fn
+		if fn == "count" && p.acceptSymbol("*") {
+			item.Star = true
+		} else {
+			item.Distinct = p.acceptKeyword("distinct")
+			if item.Distinct && fn != "count" {
+				p.fail("DISTINCT is only supported with COUNT")
+			}
+			item.Col = p.parseColumn()
+		}
+		p.expectSymbol(")")
+	} else {
+		item.Col = p.parseColumn()
+	}
+	if p.acceptKeyword("as") {
+		item.Alias = p.ident()
+	} else if tok := p.peek(); tok.kind == sqlTokenIdent && !sqlKeywords[strings.ToLower(tok.text)] {
+		item.Alias = p.ident()
+	}
+	return item
+}
+
+func (p *sqlParser) parseOr() SqlExpr {
+	left := p.parseAnd()
+	for p.acceptKeyword("or") {
+		left = SqlOr{left, p.parseAnd()}
+	}
+	return left
+}
+
+func (p *sqlParser) parseAnd() SqlExpr {
+	left := p.parseNot()
+	for p.acceptKeyword("and") {
+		left = SqlAnd{left, p.parseNot()}
+	}
+	return left
+}
+
+func (p *sqlParser) parseNot() SqlExpr {
+	if p.acceptKeyword("not") {
+		return SqlNot{p.parseNot()}
+	}
+	if p.acceptSymbol("(") {
+		e := p.parseOr()
+		p.expectSymbol(")")
+		return e
+	}
+	return p.parsePredicate()
+}
+
+var sqlOperators = map[string]pack.FilterMode{
+	"=":  pack.FilterModeEqual,
+	"!=": pack.FilterModeNotEqual,
+	"<>": pack.FilterModeNotEqual,
+	">":  pack.FilterModeGt,
+	">=": pack.FilterModeGte,
+	"<":  pack.FilterModeLt,
+	"<=": pack.FilterModeLte,
+}
+
+// operator to use when swapping operands
+var sqlOperatorsFlipped = map[pack.FilterMode]pack.FilterMode{
+	pack.FilterModeEqual:    pack.FilterModeEqual,
+	pack.FilterModeNotEqual: pack.FilterModeNotEqual,
+	pack.FilterModeGt:       pack.FilterModeLt,
+	pack.FilterModeGte:      pack.FilterModeLte,
+	pack.FilterModeLt:       pack.FilterModeGt,
+	pack.FilterModeLte:      pack.FilterModeGte,
+}
+
+func (p *sqlParser) isLiteral() bool {
+	t := p.peek()
+	return t.kind == sqlTokenNumber || t.kind == sqlTokenString ||
+		(t.kind == sqlTokenIdent && (strings.EqualFold(t.text, "true") || strings.EqualFold(t.text, "false")))
+}
+
+func (p *sqlParser) parseLiteral() SqlLiteral {
+	if !p.isLiteral() {
+		p.fail("expected value at position %d, found %s", p.peek().pos, p.peek())
+	}
+	t := p.next()
+	return SqlLiteral{Text: t.text, String: t.kind == sqlTokenString}
+}
+
+func (p *sqlParser) parsePredicate() SqlExpr {
+	// literal <op> column
+	if p.isLiteral() {
+		lit := p.parseLiteral()
+		t := p.next()
+		mode, ok := sqlOperators[t.text]
+		if t.kind != sqlTokenSymbol || !ok {
+			p.fail("expected comparison operator at position %d, found %s", t.pos, t)
+		}
+		return SqlCompare{
+			Col:    p.parseColumn(),
+			Mode:   sqlOperatorsFlipped[mode],
+			Values: []SqlLiteral{lit},
+		}
+	}
+
+	c := SqlCompare{Col: p.parseColumn()}
+	negate := p.acceptKeyword("not")
+	switch {
+	case p.acceptKeyword("in"):
+		c.Mode = pack.FilterModeIn
+		if negate {
+			c.Mode = pack.FilterModeNotIn
+		}
+		p.expectSymbol("(")
+		for {
+			c.Values = append(c.Values, p.parseLiteral())
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+		p.expectSymbol(")")
+		return c
+	case p.acceptKeyword("between"):
+		c.Mode = pack.FilterModeRange
+		c.Values = append(c.Values, p.parseLiteral())
+		p.expectKeyword("and")
+		c.Values = append(c.Values, p.parseLiteral())
+		if negate {
+			return SqlNot{c}
+		}
+		return c
+	case negate:
+		p.fail("expected IN or BETWEEN at position %d, found %s", p.peek().pos, p.peek())
+	}
+
+	t := p.next()
+	mode, ok := sqlOperators[t.text]
+	if t.kind != sqlTokenSymbol || !ok {
+		p.fail("expected comparison operator at position %d, found %s", t.pos, t)
+	}
+	c.Mode = mode
+	if p.isLiteral() {
+		c.Values = []SqlLiteral{p.parseLiteral()}
+	} else {
+		other := p.parseColumn()
+		c.Other = &other
+	}
+	return c
+}
@@ -0,0 +1,317 @@
+// Copyright (c) 2020-2023 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tables
+
+import (
+	"reflect"
+	"testing"
+
+	"blockwatch.cc/packdb/pack"
+	"blockwatch.cc/tzindex/server"
+)
+
+func TestParseSql(t *testing.T) {
+	q, err := ParseSql(`SELECT o.sender_id, COUNT(*) AS n, SUM(o.volume)
+		FROM op o LEFT JOIN account a ON o.sender_id = a.row_id
+		WHERE o.type IN ('transaction', 'delegation') AND o.height BETWEEN 10 AND 20
+		GROUP BY o.sender_id ORDER BY n DESC, 1 LIMIT 5 OFFSET 10;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.From != (SqlTable{Name: "op", Alias: "o"}) {
+		t.Errorf("from = %+v", q.From)
+	}
+	if len(q.Joins) != 1 || !q.Joins[0].Left || q.Joins[0].Table != (SqlTable{Name: "account", Alias: "a"}) {
+		t.Errorf("joins = %+v", q.Joins)
+	}
+	names := []string{"sender_id", "n", "sum_volume"}
+	for i, item := range q.Items {
+		if item.Name() != names[i] {
+			t.Errorf("item %d name = %s, want %s", i, item.Name(), names[i])
+		}
+	}
+	if !q.Aggregate || len(q.GroupBy) != 1 {
+		t.Errorf("aggregate = %t, group by = %v", q.Aggregate, q.GroupBy)
+	}
+	if len(q.OrderBy) != 2 || !q.OrderBy[0].Desc || q.OrderBy[0].Col.Name != "n" || q.OrderBy[1].Pos != 1 {
+		t.Errorf("order by = %+v", q.OrderBy)
+	}
+	if !q.HasLimit || q.Limit != 5 || q.Offset != 10 {
+		t.Errorf("limit = %d offset = %d", q.Limit, q.Offset)
+	}
+	and, ok := q.Where.(SqlAnd)
+	if !ok {
+		t.Fatalf("where = %T, want SqlAnd", q.Where)
+	}
+	in := and.Left.(SqlCompare)
+	if in.Mode != pack.FilterModeIn || len(in.Values) != 2 || in.Values[0] != (SqlLiteral{"transaction", true}) {
+		t.Errorf("in = %+v", in)
+	}
+	rg := and.Right.(SqlCompare)
+	if rg.Mode != pack.FilterModeRange || rg.Values[0].Text != "10" || rg.Values[1].Text != "20" {
+		t.Errorf("between = %+v", rg)
+	}
+}
+
+func TestParseSqlPredicates(t *testing.T) {
+	tests := []struct {
+		where string
+		mode  pack.FilterMode
+		col   string
+		value string
+	}{
+		{"a = 1", pack.FilterModeEqual, "a", "1"},
+		{"a <> 1", pack.FilterModeNotEqual, "a", "1"},
+		{"a != 'x''y'", pack.FilterModeNotEqual, "a", "x'y"},
+		{"a >= -5", pack.FilterModeGte, "a", "-5"},
+		{"5 < a", pack.FilterModeGt, "a", "5"},
+		{"5 >= a", pack.FilterModeLte, "a", "5"},
+		{"a NOT IN (1)", pack.FilterModeNotIn, "a", "1"},
+	}
+	for _, test := range tests {
+		q, err := ParseSql("select * from op where " + test.where)
+		if err != nil {
+			t.Errorf("%s: %s", test.where, err)
+			continue
+		}
+		c, ok := q.Where.(SqlCompare)
+		if !ok {
+			t.Errorf("%s: where = %T", test.where, q.Where)
+			continue
+		}
+		if c.Mode != test.mode || c.Col.Name != test.col || c.Values[0].Text != test.value {
+			t.Errorf("%s: got mode=%s col=%s value=%s", test.where, c.Mode, c.Col.Name, c.Values[0].Text)
+		}
+	}
+
+	// precedence: AND binds tighter than OR, NOT applies to BETWEEN
+	q, err := ParseSql("select * from op where a = 1 or b = 2 and c not between 1 and 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	or, ok := q.Where.(SqlOr)
+	if !ok {
+		t.Fatalf("where = %T, want SqlOr", q.Where)
+	}
+	and, ok := or.Right.(SqlAnd)
+	if !ok {
+		t.Fatalf("or.right = %T, want SqlAnd", or.Right)
+	}
+	if _, ok := and.Right.(SqlNot); !ok {
+		t.Errorf("and.right = %T, want SqlNot", and.Right)
+	}
+}
+
+func TestParseSqlErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"select",
+		"select * from",
+		"select * from op where",
+		"select * from op where a",
+		"select * from op where a not = 1",
+		"select * from op limit x",
+		"select * from op 'x'",
+		"select * from op where a = 'open",
+		"select * from op where a = 1 #",
+		"update op set a = 1",
+	} {
+		if _, err := ParseSql(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestSqlExprTable(t *testing.T) {
+	a := SqlCompare{Col: SqlColumn{table: 0}}
+	b := SqlCompare{Col: SqlColumn{table: 1}}
+	other := SqlColumn{table: 1}
+	tests := []struct {
+		expr SqlExpr
+		want int
+	}{
+		{a, 0},
+		{b, 1},
+		{SqlAnd{a, a}, 0},
+		{SqlOr{a, b}, -1},
+		{SqlNot{b}, 1},
+		{SqlCompare{Col: SqlColumn{table: 0}, Other: &other}, -1},
+	}
+	for i, test := range tests {
+		if got := sqlExprTable(test.expr); got != test.want {
+			t.Errorf("%d: got %d, want %d", i, got, test.want)
+		}
+	}
+	if n := len(splitSqlAnd(SqlAnd{SqlAnd{a, b}, SqlOr{a, b}})); n != 3 {
+		t.Errorf("split: got %d terms, want 3", n)
+	}
+}
+
+// testSqlPlan returns a plan over a single source with two columns.
+func testSqlPlan(q *SqlQuery) *sqlPlan {
+	p := &sqlPlan{
+		ctx:   &server.Context{Cfg: &server.Config{Http: server.HttpConfig{DefaultListCount: 100}}},
+		query: q,
+		items: []SqlItem{
+			{Col: SqlColumn{Name: "a", pos: 0}},
+			{Col: SqlColumn{Name: "b", pos: 1}},
+		},
+	}
+	for _, o := range q.OrderBy {
+		p.order = append(p.order, sqlSortKey{pos: o.Pos - 1, desc: o.Desc})
+	}
+	return p
+}
+
+func testSqlRows(vals ...int64) []sqlRow {
+	rows := make([]sqlRow, len(vals))
+	for i, v := range vals {
+		rows[i] = sqlRow{[]interface{}{v, int64(i)}}
+	}
+	return rows
+}
+
+func runSqlSink(t *testing.T, p *sqlPlan, sink sqlSink, rows []sqlRow) []int64 {
+	t.Helper()
+	var out []int64
+	fn := func(v []interface{}) error {
+		if len(v) != len(p.items) {
+			t.Fatalf("got %d output columns, want %d", len(v), len(p.items))
+		}
+		out = append(out, v[0].(int64))
+		return nil
+	}
+	if s, ok := sink.(*sqlStreamer); ok {
+		s.fn = fn
+	}
+	for _, row := range rows {
+		if err := sink.add(row); err != nil {
+			if err == errSqlLimit {
+				break
+			}
+			t.Fatal(err)
+		}
+	}
+	if err := sink.flush(fn); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestSqlStreamer(t *testing.T) {
+	p := testSqlPlan(&SqlQuery{Limit: 3, Offset: 2, HasLimit: true})
+	sink := &sqlStreamer{plan: p, offset: int(p.offset()), limit: int(p.limit())}
+	got := runSqlSink(t, p, sink, testSqlRows(1, 2, 3, 4, 5, 6, 7))
+	if want := []int64{3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSqlSorter(t *testing.T) {
+	vals := []int64{5, 3, 9, 1, 7, 3, 8, 2, 6, 4, 0}
+	for _, test := range []struct {
+		desc          bool
+		limit, offset uint
+		want          []int64
+	}{
+		{false, 3, 0, []int64{0, 1, 2}},
+		{false, 3, 2, []int64{2, 3, 3}},
+		{true, 4, 1, []int64{8, 7, 6, 5}},
+		{false, 20, 8, []int64{7, 8, 9}},
+	} {
+		q := &SqlQuery{
+			OrderBy:  []SqlOrder{{Pos: 1, Desc: test.desc}},
+			Limit:    test.limit,
+			Offset:   test.offset,
+			HasLimit: true,
+		}
+		p := testSqlPlan(q)
+		sink := &sqlSorter{plan: p, max: int(p.offset() + p.limit())}
+		got := runSqlSink(t, p, sink, testSqlRows(vals...))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("desc=%t limit=%d offset=%d: got %v, want %v", test.desc, test.limit, test.offset, got, test.want)
+		}
+		if len(sink.rows) > 2*sink.max {
+			t.Errorf("sorter kept %d rows, max %d", len(sink.rows), 2*sink.max)
+		}
+	}
+}
+
+func TestSqlSorterStable(t *testing.T) {
+	// equal sort keys keep scan order across truncations
+	p := testSqlPlan(&SqlQuery{OrderBy: []SqlOrder{{Pos: 1}}, Limit: 2, HasLimit: true})
+	sink := &sqlSorter{plan: p, max: 2}
+	rows := testSqlRows(1, 1, 1, 1, 1, 1)
+	for _, row := range rows {
+		if err := sink.add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []int64
+	err := sink.flush(func(v []interface{}) error {
+		got = append(got, v[1].(int64))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSqlAggregator(t *testing.T) {
+	q := &SqlQuery{
+		Aggregate: true,
+		GroupBy:   []SqlColumn{{Name: "a", pos: 0}},
+	}
+	p := testSqlPlan(q)
+	p.items = []SqlItem{
+		{Col: SqlColumn{Name: "a", pos: 0}},
+		{Func: "count", Star: true},
+		{Func: "sum", Col: SqlColumn{Name: "b", pos: 1}},
+		{Func: "max", Col: SqlColumn{Name: "b", pos: 1}},
+	}
+	p.order = []sqlSortKey{{pos: 0}}
+	sink := newSqlAggregator(p)
+	for _, row := range testSqlRows(2, 1, 2, 1, 2) {
+		if err := sink.add(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got [][]interface{}
+	err := sink.flush(func(v []interface{}) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d groups, want 2", len(got))
+	}
+	// group 1 has rows 1 and 3, group 2 has rows 0, 2 and 4
+	if got[0][0] != int64(1) || got[0][1] != int64(2) || got[0][3] != int64(3) {
+		t.Errorf("group 1 = %v", got[0])
+	}
+	if got[1][0] != int64(2) || got[1][1] != int64(3) || got[1][3] != int64(4) {
+		t.Errorf("group 2 = %v", got[1])
+	}
+
+	// aggregates without groups return a single row on empty input
+	q = &SqlQuery{Aggregate: true}
+	p = testSqlPlan(q)
+	p.items = []SqlItem{{Func: "count", Star: true}}
+	got = got[:0]
+	err = newSqlAggregator(p).flush(func(v []interface{}) error {
+		got = append(got, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0][0] != int64(0) {
+		t.Errorf("empty aggregate = %v", got)
+	}
+}
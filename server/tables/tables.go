@@ -4,11 +4,13 @@
 package tables
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
 	"strings"
 	"time"
 
+	"blockwatch.cc/packdb/encoding/csv"
 	"blockwatch.cc/packdb/pack"
 	"blockwatch.cc/packdb/util"
 	"blockwatch.cc/tzindex/server"
@@ -23,6 +25,12 @@ var mimetypes = map[string]string{
 
 func init() {
 	server.Register(TableRequest{})
+	server.Describe("GET", "/tables/{table}", server.ApiDoc{Id: "GetTable", Summary: "Table rows", Args: TableRequest{}, Result: []json.RawMessage{}})
+	server.Describe("GET", "/tables/{table}.{format}", server.ApiDoc{Id: "GetTableFormat", Summary: "Table rows as JSON or CSV", Args: TableRequest{}, Result: []json.RawMessage{}})
+	server.Describe("GET", "/tables/sql", server.ApiDoc{Id: "GetSql", Summary: "Result rows of a SQL query", Args: SqlRequest{}, Result: []json.RawMessage{}})
+	server.Describe("POST", "/tables/sql", server.ApiDoc{Id: "QuerySql", Summary: "Result rows of a SQL query", Body: SqlRequest{}, Result: []json.RawMessage{}})
+	server.Describe("GET", "/tables/sql.{format}", server.ApiDoc{Id: "GetSqlFormat", Summary: "Result rows of a SQL query as JSON or CSV", Args: SqlRequest{}, Result: []json.RawMessage{}})
+	server.Describe("POST", "/tables/sql.{format}", server.ApiDoc{Id: "QuerySqlFormat", Summary: "Result rows of a SQL query as JSON or CSV", Body: SqlRequest{}, Result: []json.RawMessage{}})
 }
 
 var _ server.RESTful = (*TableRequest)(nil)
@@ -37,6 +45,15 @@ type TableRequest struct {
 	Order   pack.OrderType  `schema:"order"` // asc/desc
 	Verbose bool            `schema:"verbose"`
 	// OrderBy string // column name
+
+	// aggregation mode
+	GroupBy  util.StringList `schema:"group_by"` // group columns
+	Sum      util.StringList `schema:"sum"`      // summed columns
+	Distinct util.StringList `schema:"distinct"` // columns to count distinct values
+	Count    bool            `schema:"count"`    // count rows per group
+	Sort     string          `schema:"sort"`     // sort aggregated results by column
+
+	agg *Aggregator // receives table rows in aggregation mode
 }
 
 func (t TableRequest) LastModified() time.Time {
@@ -61,6 +78,8 @@ func (t TableRequest) RegisterDirectRoutes(r *mux.Router) error {
 }
 
 func (t TableRequest) RegisterRoutes(r *mux.Router) error {
+	r.HandleFunc("/sql.{format}", server.C(QuerySql)).Methods("GET", "POST")
+	r.HandleFunc("/sql", server.C(QuerySql)).Methods("GET", "POST")
 	r.HandleFunc("/{table}.{format}", server.C(StreamTable)).Methods("GET").Name("tableurl")
 	r.HandleFunc("/{table}", server.C(StreamTable)).Methods("GET")
 	return nil
@@ -69,6 +88,9 @@ func (t TableRequest) RegisterRoutes(r *mux.Router) error {
 func (t *TableRequest) Parse(ctx *server.Context) {
 	t.Limit = ctx.Cfg.ClampList(t.Limit)
 
+	// resolve `@name` cohort references in filter values
+	ctx.ExpandCohorts()
+
 	// prevent duplicate columns
 	if len(t.Columns) > 0 {
 		seen := make(map[string]struct{})
@@ -93,11 +115,37 @@ func (t *TableRequest) Parse(ctx *server.Context) {
 	default:
 		panic(server.EBadRequest(server.EC_CONTENTTYPE_UNSUPPORTED, fmt.Sprintf("unsupported format '%s'", t.Format), nil))
 	}
+
+	if t.IsAggregate() {
+		t.parseAggregate()
+	}
+}
+
+// rowEncoder is the CSV encoder interface used by table handlers.
+type rowEncoder interface {
+	EncodeHeader(fields []string, v interface{}) error
+	EncodeRecord(v interface{}) error
+}
+
+// newCsvEncoder returns the encoder for CSV table rows. In aggregation mode
+// rows are sent to the request's aggregator.
+func newCsvEncoder(ctx *server.Context, args *TableRequest) rowEncoder {
+	if args.agg != nil {
+		return args.agg
+	}
+	return csv.NewEncoder(ctx.ResponseWriter)
 }
 
 func StreamTable(ctx *server.Context) (interface{}, int) {
 	args := &TableRequest{}
 	ctx.ParseRequestArgs(args)
+	if args.IsAggregate() {
+		return StreamAggregate(ctx, args)
+	}
+	return streamTable(ctx, args)
+}
+
+func streamTable(ctx *server.Context, args *TableRequest) (interface{}, int) {
 	switch args.Table {
 	case "block":
 		return StreamBlockTable(ctx, args)
@@ -139,6 +187,12 @@ func StreamTable(ctx *server.Context) (interface{}, int) {
 		return StreamBalanceTable(ctx, args)
 	case "event":
 		return StreamEventTable(ctx, args)
+	case "cycle_baker":
+		return StreamCycleBakerTable(ctx, args)
+	case "consensus_key":
+		return StreamConsensusKeyTable(ctx, args)
+	case "code_family":
+		return StreamCodeFamilyTable(ctx, args)
 	default:
 		panic(server.ENotFound(server.EC_RESOURCE_NOTFOUND, fmt.Sprintf("no such table '%s'", args.Table), nil))
 	}